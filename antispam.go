@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 )
@@ -36,22 +38,43 @@ import (
 // InMemoryDedupe. This allows recent duplicates to be deduplicated in memory, reducing the need to
 // make calls to a persistent storage.
 func newInMemoryDedupe(size uint) func(AddFn) AddFn {
-	return func(af AddFn) AddFn {
-		c, err := lru.New[string, func() IndexFuture](int(size))
-		if err != nil {
-			panic(fmt.Errorf("lru.New(%d): %v", size, err))
-		}
-		dedupe := &inMemoryDedupe{
-			delegate: af,
-			cache:    c,
-		}
-		return dedupe.add
+	return (&inMemoryDedupes{size: size}).decorator
+}
+
+// inMemoryDedupes creates in-memory dedupes with caches of the given size, and reports the statistics of
+// the one it created most recently.
+type inMemoryDedupes struct {
+	size   uint
+	latest atomic.Pointer[inMemoryDedupe]
+}
+
+func (m *inMemoryDedupes) decorator(af AddFn) AddFn {
+	c, err := lru.New[string, func() IndexFuture](int(m.size))
+	if err != nil {
+		panic(fmt.Errorf("lru.New(%d): %v", m.size, err))
+	}
+	dedupe := &inMemoryDedupe{
+		delegate: af,
+		cache:    c,
+	}
+	m.latest.Store(dedupe)
+	return dedupe.add
+}
+
+// DedupeStats returns the statistics of the most recently created dedupe, whose index size is the number of
+// entries in its cache.
+func (m *inMemoryDedupes) DedupeStats(_ context.Context) (DedupeStatistics, error) {
+	d := m.latest.Load()
+	if d == nil {
+		return DedupeStatistics{}, nil
 	}
+	return d.counters.Statistics(uint64(d.cache.Len())), nil
 }
 
 type inMemoryDedupe struct {
 	delegate func(ctx context.Context, e *Entry) IndexFuture
 	cache    *lru.Cache[string, func() IndexFuture]
+	counters DedupeCounters
 }
 
 // Add adds the entry to the underlying delegate only if e hasn't been recently seen. In either case,
@@ -64,8 +87,9 @@ func (d *inMemoryDedupe) add(ctx context.Context, e *Entry) IndexFuture {
 
 	f := sync.OnceValue(func() IndexFuture {
 		// However many calls with the same entry come in and are deduped, we should only call delegate
-		// once for each unique entry:
-		df := d.delegate(ctx, e)
+		// once for each unique entry. Later callers share the result, so the first caller giving up mustn't
+		// fail it for them:
+		df := d.delegate(context.WithoutCancel(ctx), e)
 
 		return func() (Index, error) {
 			idx, err := df()
@@ -80,7 +104,10 @@ func (d *inMemoryDedupe) add(ctx context.Context, e *Entry) IndexFuture {
 
 	// if we've seen this entry before, discard our f and replace
 	// with the one we created last time, otherwise store f against id.
-	if prev, ok, _ := d.cache.PeekOrAdd(id, f); ok {
+	start := time.Now()
+	prev, ok, _ := d.cache.PeekOrAdd(id, f)
+	d.counters.RecordLookup(time.Since(start), ok)
+	if ok {
 		f = func() IndexFuture {
 			return func() (Index, error) {
 				i, err := prev()()
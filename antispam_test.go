@@ -134,6 +134,33 @@ func TestDedupeDoesNotCacheError(t *testing.T) {
 	}
 }
 
+func TestDedupeFirstCallerCancelled(t *testing.T) {
+	release := make(chan struct{})
+	// The delegate fails entries whose context is done by the time they'd be sequenced, as the queue does.
+	delegate := func(ctx context.Context, e *Entry) IndexFuture {
+		return func() (Index, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return Index{}, err
+			}
+			return Index{Index: 5}, nil
+		}
+	}
+	dedupeAdd := newInMemoryDedupe(256)(delegate)
+
+	ctx1, cancel := context.WithCancel(t.Context())
+	f1 := dedupeAdd(ctx1, NewEntry([]byte("foo")))
+	f2 := dedupeAdd(t.Context(), NewEntry([]byte("foo")))
+	cancel()
+	close(release)
+	if got, err := f2(); err != nil || got != (Index{Index: 5, IsDup: true}) {
+		t.Errorf("f2: got %+v, %v, want index 5", got, err)
+	}
+	if got, err := f1(); err != nil || got != (Index{Index: 5}) {
+		t.Errorf("f1: got %+v, %v, want index 5", got, err)
+	}
+}
+
 func BenchmarkDedupe(b *testing.B) {
 	ctx := context.Background()
 	// Outer loop is for benchmark calibration, inside here is each individual run of the benchmark
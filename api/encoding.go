@@ -0,0 +1,168 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/api/tilespb"
+	"google.golang.org/protobuf/proto"
+)
+
+// JSONVersion is the version of the JSON encoding of HashTile and EntryBundle written by MarshalJSON.
+// It will be changed if the encoding is ever changed incompatibly.
+const JSONVersion = 1
+
+// hashTileJSON is the JSON encoding of a HashTile.
+type hashTileJSON struct {
+	Version int      `json:"version"`
+	Nodes   [][]byte `json:"nodes"`
+}
+
+// entryBundleJSON is the JSON encoding of an EntryBundle.
+type entryBundleJSON struct {
+	Version int      `json:"version"`
+	Entries [][]byte `json:"entries"`
+}
+
+// MarshalJSON implements json.Marshaler, for tools which would rather consume tiles as JSON than parse
+// the format defined by the tlog-tiles spec.
+//
+// The encoding is canonical: an object with a "version" member set to JSONVersion, followed by a "nodes"
+// member holding the base64 encoded hashes, without any whitespace. For example:
+//
+//	{"version":1,"nodes":["3q2+7w...","yv66vg..."]}
+func (t HashTile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hashTileJSON{Version: JSONVersion, Nodes: nonNil(t.Nodes)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler and reads HashTiles written by MarshalJSON.
+func (t *HashTile) UnmarshalJSON(raw []byte) error {
+	var j hashTileJSON
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return err
+	}
+	if j.Version != JSONVersion {
+		return fmt.Errorf("unsupported HashTile encoding version %d", j.Version)
+	}
+	if err := validateNodes(j.Nodes); err != nil {
+		return err
+	}
+	t.Nodes = j.Nodes
+	return nil
+}
+
+// MarshalProto returns the HashTile encoded as a tilespb.HashTile protobuf message.
+//
+// The encoding is deterministic, so equal HashTiles are always encoded as identical bytes.
+func (t HashTile) MarshalProto() ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(&tilespb.HashTile{Nodes: t.Nodes})
+}
+
+// UnmarshalProto reads a HashTile encoded as a tilespb.HashTile protobuf message.
+func (t *HashTile) UnmarshalProto(raw []byte) error {
+	var m tilespb.HashTile
+	if err := proto.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+	if err := validateNodes(m.Nodes); err != nil {
+		return err
+	}
+	t.Nodes = m.Nodes
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, for tools which would rather consume entry bundles as JSON
+// than parse the format defined by the tlog-tiles spec.
+//
+// The encoding is canonical: an object with a "version" member set to JSONVersion, followed by an
+// "entries" member holding the base64 encoded entries, without any whitespace.
+func (t EntryBundle) MarshalJSON() ([]byte, error) {
+	return json.Marshal(entryBundleJSON{Version: JSONVersion, Entries: nonNil(t.Entries)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler and reads EntryBundles written by MarshalJSON.
+func (t *EntryBundle) UnmarshalJSON(raw []byte) error {
+	var j entryBundleJSON
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return err
+	}
+	if j.Version != JSONVersion {
+		return fmt.Errorf("unsupported EntryBundle encoding version %d", j.Version)
+	}
+	if err := validateEntries(j.Entries); err != nil {
+		return err
+	}
+	t.Entries = j.Entries
+	return nil
+}
+
+// MarshalProto returns the EntryBundle encoded as a tilespb.EntryBundle protobuf message.
+//
+// The encoding is deterministic, so equal EntryBundles are always encoded as identical bytes.
+func (t EntryBundle) MarshalProto() ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(&tilespb.EntryBundle{Entries: t.Entries})
+}
+
+// UnmarshalProto reads an EntryBundle encoded as a tilespb.EntryBundle protobuf message.
+func (t *EntryBundle) UnmarshalProto(raw []byte) error {
+	var m tilespb.EntryBundle
+	if err := proto.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+	if err := validateEntries(m.Entries); err != nil {
+		return err
+	}
+	t.Entries = m.Entries
+	return nil
+}
+
+// validateNodes returns an error if nodes can't be the hashes in a tile.
+func validateNodes(nodes [][]byte) error {
+	if len(nodes) > layout.TileWidth {
+		return fmt.Errorf("tile has %d nodes, more than %d", len(nodes), layout.TileWidth)
+	}
+	for i, n := range nodes {
+		if len(n) != sha256.Size {
+			return fmt.Errorf("node %d is %d bytes, want %d", i, len(n), sha256.Size)
+		}
+	}
+	return nil
+}
+
+// validateEntries returns an error if entries can't be the entries in a bundle.
+func validateEntries(entries [][]byte) error {
+	if len(entries) > layout.EntryBundleWidth {
+		return fmt.Errorf("bundle has %d entries, more than %d", len(entries), layout.EntryBundleWidth)
+	}
+	for i, e := range entries {
+		if len(e) > math.MaxUint16 {
+			return fmt.Errorf("entry %d is %d bytes, more than %d", i, len(e), math.MaxUint16)
+		}
+	}
+	return nil
+}
+
+// nonNil returns s, or an empty slice if s is nil, so that it's encoded as an empty JSON array rather than null.
+func nonNil(s [][]byte) [][]byte {
+	if s == nil {
+		return [][]byte{}
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/transparency-dev/tessera/api"
+)
+
+func TestHashTile_EncodingRoundtrip(t *testing.T) {
+	for _, size := range []int{0, 1, 42, 256} {
+		tile := api.HashTile{}
+		for i := range size {
+			tile.Nodes = append(tile.Nodes, bytes.Repeat([]byte{byte(i)}, sha256.Size))
+		}
+		t.Run(fmt.Sprintf("json tile size %d", size), func(t *testing.T) {
+			raw, err := json.Marshal(tile)
+			if err != nil {
+				t.Fatalf("json.Marshal() = %v", err)
+			}
+			var got api.HashTile
+			if err := json.Unmarshal(raw, &got); err != nil {
+				t.Fatalf("json.Unmarshal() = %v", err)
+			}
+			if diff := cmp.Diff(tile, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("Got tile with diff: %s", diff)
+			}
+		})
+		t.Run(fmt.Sprintf("proto tile size %d", size), func(t *testing.T) {
+			raw, err := tile.MarshalProto()
+			if err != nil {
+				t.Fatalf("MarshalProto() = %v", err)
+			}
+			var got api.HashTile
+			if err := got.UnmarshalProto(raw); err != nil {
+				t.Fatalf("UnmarshalProto() = %v", err)
+			}
+			if diff := cmp.Diff(tile, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("Got tile with diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestEntryBundle_EncodingRoundtrip(t *testing.T) {
+	for _, size := range []int{0, 1, 42, 256} {
+		bundle := api.EntryBundle{}
+		for i := range size {
+			bundle.Entries = append(bundle.Entries, fmt.Appendf(nil, "entry %d", i))
+		}
+		t.Run(fmt.Sprintf("json bundle size %d", size), func(t *testing.T) {
+			raw, err := json.Marshal(bundle)
+			if err != nil {
+				t.Fatalf("json.Marshal() = %v", err)
+			}
+			var got api.EntryBundle
+			if err := json.Unmarshal(raw, &got); err != nil {
+				t.Fatalf("json.Unmarshal() = %v", err)
+			}
+			if diff := cmp.Diff(bundle, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("Got bundle with diff: %s", diff)
+			}
+		})
+		t.Run(fmt.Sprintf("proto bundle size %d", size), func(t *testing.T) {
+			raw, err := bundle.MarshalProto()
+			if err != nil {
+				t.Fatalf("MarshalProto() = %v", err)
+			}
+			var got api.EntryBundle
+			if err := got.UnmarshalProto(raw); err != nil {
+				t.Fatalf("UnmarshalProto() = %v", err)
+			}
+			if diff := cmp.Diff(bundle, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("Got bundle with diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONCanonical(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		v    any
+		want string
+	}{
+		{
+			name: "empty tile",
+			v:    api.HashTile{},
+			want: `{"version":1,"nodes":[]}`,
+		}, {
+			name: "tile",
+			v:    api.HashTile{Nodes: [][]byte{make([]byte, sha256.Size)}},
+			want: `{"version":1,"nodes":["AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="]}`,
+		}, {
+			name: "empty bundle",
+			v:    api.EntryBundle{},
+			want: `{"version":1,"entries":[]}`,
+		}, {
+			name: "bundle",
+			v:    api.EntryBundle{Entries: [][]byte{[]byte("one"), []byte("two")}},
+			want: `{"version":1,"entries":["b25l","dHdv"]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := json.Marshal(test.v)
+			if err != nil {
+				t.Fatalf("json.Marshal() = %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("json.Marshal() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		v    any
+		raw  string
+	}{
+		{
+			name: "tile with unknown version",
+			v:    &api.HashTile{},
+			raw:  `{"version":2,"nodes":[]}`,
+		}, {
+			name: "tile without version",
+			v:    &api.HashTile{},
+			raw:  `{"nodes":[]}`,
+		}, {
+			name: "tile with short hash",
+			v:    &api.HashTile{},
+			raw:  `{"version":1,"nodes":["AAAA"]}`,
+		}, {
+			name: "bundle with unknown version",
+			v:    &api.EntryBundle{},
+			raw:  `{"version":2,"entries":[]}`,
+		}, {
+			name: "bundle with bad base64",
+			v:    &api.EntryBundle{},
+			raw:  `{"version":1,"entries":["!"]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(test.raw), test.v); err == nil {
+				t.Errorf("json.Unmarshal(%s) succeeded, want error", test.raw)
+			}
+		})
+	}
+}
+
+func TestUnmarshalProtoInvalid(t *testing.T) {
+	long := api.EntryBundle{Entries: [][]byte{make([]byte, 1<<16)}}
+	raw, err := long.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto() = %v", err)
+	}
+	if err := (&api.EntryBundle{}).UnmarshalProto(raw); err == nil {
+		t.Error("UnmarshalProto() of bundle with oversized entry succeeded, want error")
+	}
+	short := api.HashTile{Nodes: [][]byte{[]byte("short")}}
+	if raw, err = short.MarshalProto(); err != nil {
+		t.Fatalf("MarshalProto() = %v", err)
+	}
+	if err := (&api.HashTile{}).UnmarshalProto(raw); err == nil {
+		t.Error("UnmarshalProto() of tile with short hash succeeded, want error")
+	}
+}
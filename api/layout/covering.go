@@ -0,0 +1,85 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"fmt"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+)
+
+// TileID identifies a hash tile, and the number of hashes it holds in the tree for which it was computed.
+type TileID struct {
+	Level, Index uint64
+	// Partial is the number of hashes in the tile, or 0 if the tile is full.
+	Partial uint8
+}
+
+// Path returns the path of the tile, as specified by the tlog-tiles spec.
+func (t TileID) Path() string {
+	return TilePath(t.Level, t.Index, t.Partial)
+}
+
+// TilesForNodes returns the tiles needed to compute the given nodes of a tree of size treeSize.
+//
+// Each tile is returned once, in the order in which it's first needed. Nodes which are ephemeral in a tree
+// of the given size can't be computed from its tiles, and should be expanded into the nodes from which
+// they're calculated, as is done by the merkle/proof package.
+func TilesForNodes(treeSize uint64, nodes []compact.NodeID) []TileID {
+	r := make([]TileID, 0, len(nodes))
+	seen := make(map[TileID]bool, len(nodes))
+	for _, n := range nodes {
+		level, index, _, _ := NodeCoordsToTileAddress(uint64(n.Level), n.Index)
+		t := TileID{Level: level, Index: index, Partial: PartialTileSize(level, index, treeSize)}
+		if !seen[t] {
+			seen[t] = true
+			r = append(r, t)
+		}
+	}
+	return r
+}
+
+// TilesForInclusionProof returns the tiles needed to build a proof that the entry at index is included in a
+// tree of size treeSize.
+func TilesForInclusionProof(index, treeSize uint64) ([]TileID, error) {
+	nodes, err := proof.Inclusion(index, treeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate inclusion proof nodes for index %d in tree of size %d: %v", index, treeSize, err)
+	}
+	return TilesForNodes(treeSize, nodes.IDs), nil
+}
+
+// TilesForConsistencyProof returns the tiles needed to build a proof that a tree of size smaller is a prefix
+// of a tree of size larger.
+func TilesForConsistencyProof(smaller, larger uint64) ([]TileID, error) {
+	nodes, err := proof.Consistency(smaller, larger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate consistency proof nodes from size %d to %d: %v", smaller, larger, err)
+	}
+	return TilesForNodes(larger, nodes.IDs), nil
+}
+
+// TilesForRange returns the level 0 tiles which hold the leaf hashes of the N entries starting at from, in a
+// tree of size treeSize. The corresponding entry bundles are described by Range.
+//
+// No tiles are returned for entries beyond the end of the tree.
+func TilesForRange(from, N, treeSize uint64) []TileID {
+	var r []TileID
+	for ri := range Range(from, N, treeSize) {
+		r = append(r, TileID{Level: 0, Index: ri.Index, Partial: ri.Partial})
+	}
+	return r
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+)
+
+// recordingFetcher serves tiles of random-looking hashes, recording the tiles which were fetched.
+type recordingFetcher struct {
+	fetched []layout.TileID
+}
+
+func (f *recordingFetcher) fetch(_ context.Context, level, index uint64, p uint8) ([]byte, error) {
+	f.fetched = append(f.fetched, layout.TileID{Level: level, Index: index, Partial: p})
+	n := int(p)
+	if n == 0 {
+		n = layout.TileWidth
+	}
+	var r []byte
+	for i := range n {
+		h := sha256.Sum256(fmt.Appendf(nil, "%d/%d/%d", level, index, i))
+		r = append(r, h[:]...)
+	}
+	return r, nil
+}
+
+func TestTilesForProofs(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		index, smaller, size uint64
+	}{
+		{index: 0, smaller: 1, size: 1},
+		{index: 0, smaller: 1, size: 2},
+		{index: 3, smaller: 7, size: 10},
+		{index: 255, smaller: 255, size: 256},
+		{index: 256, smaller: 256, size: 257},
+		{index: 1000, smaller: 1001, size: 70000},
+		{index: 65535, smaller: 65536, size: 65537},
+		{index: 12345, smaller: 23456, size: 1<<24 + 17},
+	} {
+		t.Run(fmt.Sprintf("index %d smaller %d size %d", test.index, test.smaller, test.size), func(t *testing.T) {
+			f := &recordingFetcher{}
+			pb, err := client.NewProofBuilder(ctx, test.size, f.fetch)
+			if err != nil {
+				t.Fatalf("NewProofBuilder: %v", err)
+			}
+			// NewProofBuilder may fetch tiles, so only record those fetched for each proof.
+			f.fetched = nil
+			if _, err := pb.InclusionProof(ctx, test.index); err != nil {
+				t.Fatalf("InclusionProof: %v", err)
+			}
+			got, err := layout.TilesForInclusionProof(test.index, test.size)
+			if err != nil {
+				t.Fatalf("TilesForInclusionProof: %v", err)
+			}
+			if diff := cmp.Diff(f.fetched, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("TilesForInclusionProof: diff from fetched tiles (-want +got):\n%s", diff)
+			}
+
+			f = &recordingFetcher{}
+			pb, err = client.NewProofBuilder(ctx, test.size, f.fetch)
+			if err != nil {
+				t.Fatalf("NewProofBuilder: %v", err)
+			}
+			f.fetched = nil
+			if _, err := pb.ConsistencyProof(ctx, test.smaller, test.size); err != nil {
+				t.Fatalf("ConsistencyProof: %v", err)
+			}
+			got, err = layout.TilesForConsistencyProof(test.smaller, test.size)
+			if err != nil {
+				t.Fatalf("TilesForConsistencyProof: %v", err)
+			}
+			if diff := cmp.Diff(f.fetched, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("TilesForConsistencyProof: diff from fetched tiles (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTilesForProofsInvalid(t *testing.T) {
+	if _, err := layout.TilesForInclusionProof(10, 10); err == nil {
+		t.Error("TilesForInclusionProof(10, 10) succeeded, want error")
+	}
+	if _, err := layout.TilesForConsistencyProof(11, 10); err == nil {
+		t.Error("TilesForConsistencyProof(11, 10) succeeded, want error")
+	}
+}
+
+func TestTilesForRange(t *testing.T) {
+	for _, test := range []struct {
+		from, n, size uint64
+		want          []layout.TileID
+	}{
+		{from: 0, n: 10, size: 0},
+		{from: 0, n: 0, size: 10},
+		{from: 0, n: 10, size: 5, want: []layout.TileID{{Index: 0, Partial: 5}}},
+		{from: 250, n: 10, size: 600, want: []layout.TileID{{Index: 0}, {Index: 1}}},
+		{from: 250, n: 400, size: 600, want: []layout.TileID{{Index: 0}, {Index: 1}, {Index: 2, Partial: 88}}},
+	} {
+		t.Run(fmt.Sprintf("from %d n %d size %d", test.from, test.n, test.size), func(t *testing.T) {
+			if diff := cmp.Diff(test.want, layout.TilesForRange(test.from, test.n, test.size)); diff != "" {
+				t.Errorf("TilesForRange: diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTileIDPath(t *testing.T) {
+	if got, want := (layout.TileID{Level: 1, Index: 1234, Partial: 5}).Path(), "tile/1/x001/234.p/5"; got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"iter"
 	"math"
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -30,8 +31,87 @@ import (
 const (
 	// CheckpointPath is the location of the file containing the log checkpoint.
 	CheckpointPath = "checkpoint"
+	// CheckpointArchiveIndexPath is the location of the file listing the sizes of the archived checkpoints.
+	CheckpointArchiveIndexPath = "checkpoints/index"
+	// TileHeightPath is the location of the file containing the height of the log's tiles, for logs which
+	// don't use DefaultTiling. Clients should assume DefaultTiling if a log has no such file.
+	TileHeightPath = "tile-height"
 )
 
+// Paths builds the locations of a log's resources relative to a base, such as the URL at which the log
+// is served, or a prefix within a storage bucket.
+//
+// The zero value builds the paths specified by the tlog-tiles spec, e.g. "tile/0/x001/234".
+type Paths struct {
+	// Prefix is prepended to every path. It's typically a URL or directory ending in a slash, e.g.
+	// "https://example.com/logs/a/".
+	Prefix string
+	// Suffix is appended to the paths of tiles and entry bundles, but not to that of the checkpoint.
+	// For example, ".gz" for logs which publish their tiles as precompressed objects, which must be served
+	// with a Content-Encoding: gzip header.
+	Suffix string
+	// Query, if set, is appended to every path as its query string, e.g. to pass a token to the server.
+	Query string
+}
+
+// NewPaths returns the Paths of the resources of a log served at base.
+//
+// A trailing slash is added to the path of base if it has none, so "https://example.com/logs/a" and
+// "https://example.com/logs/a/" are equivalent. Any query string in base is kept, and sent with every request.
+func NewPaths(base *url.URL) Paths {
+	u := *base
+	p := Paths{Query: u.RawQuery}
+	u.RawQuery, u.ForceQuery, u.Fragment, u.RawFragment = "", false, "", ""
+	p.Prefix = u.String()
+	if !strings.HasSuffix(p.Prefix, "/") {
+		p.Prefix += "/"
+	}
+	return p
+}
+
+// Checkpoint returns the location of the log's checkpoint.
+func (p Paths) Checkpoint() string {
+	return p.build(CheckpointPath, "")
+}
+
+// ArchivedCheckpoint returns the location of the log's archived checkpoint with the given tree size.
+func (p Paths) ArchivedCheckpoint(size uint64) string {
+	return p.build(ArchivedCheckpointPath(size), "")
+}
+
+// CheckpointArchiveIndex returns the location of the index of the log's archived checkpoints.
+func (p Paths) CheckpointArchiveIndex() string {
+	return p.build(CheckpointArchiveIndexPath, "")
+}
+
+// TileHeight returns the location of the file containing the height of the log's tiles.
+func (p Paths) TileHeight() string {
+	return p.build(TileHeightPath, "")
+}
+
+// Tile returns the location of the tile at the given level and index, with p hashes if p is non-zero.
+func (p Paths) Tile(tileLevel, tileIndex uint64, partial uint8) string {
+	return p.build(TilePath(tileLevel, tileIndex, partial), p.Suffix)
+}
+
+// EntryBundle returns the location of the entry bundle with the given index, with p entries if p is non-zero.
+func (p Paths) EntryBundle(index uint64, partial uint8) string {
+	return p.build(EntriesPath(index, partial), p.Suffix)
+}
+
+func (p Paths) build(path, suffix string) string {
+	r := p.Prefix + path + suffix
+	if p.Query != "" {
+		r += "?" + p.Query
+	}
+	return r
+}
+
+// ArchivedCheckpointPath returns the local path of the archived checkpoint with the given tree size.
+func ArchivedCheckpointPath(size uint64) string {
+	return fmt.Sprintf("checkpoints/%d", size)
+}
+
 // EntriesPathForLogIndex builds the local path at which the leaf with the given index lives in.
 // Note that this will be an entry bundle containing up to 256 entries and thus multiple
 // indices can map to the same output path.
@@ -16,6 +16,7 @@ package layout
 
 import (
 	"fmt"
+	"net/url"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -398,3 +399,85 @@ func TestRange(t *testing.T) {
 		})
 	}
 }
+
+func TestPaths(t *testing.T) {
+	for _, test := range []struct {
+		name                             string
+		paths                            Paths
+		wantCheckpoint, wantTile, wantEB string
+		wantArchived, wantArchiveIndex   string
+		wantTileHeight                   string
+	}{
+		{
+			name:             "zero",
+			wantCheckpoint:   "checkpoint",
+			wantTile:         "tile/1/x001/234.p/5",
+			wantEB:           "tile/entries/x001/234.p/5",
+			wantArchived:     "checkpoints/1234",
+			wantArchiveIndex: "checkpoints/index",
+			wantTileHeight:   "tile-height",
+		}, {
+			name:             "prefix",
+			paths:            Paths{Prefix: "logs/a/"},
+			wantCheckpoint:   "logs/a/checkpoint",
+			wantTile:         "logs/a/tile/1/x001/234.p/5",
+			wantEB:           "logs/a/tile/entries/x001/234.p/5",
+			wantArchived:     "logs/a/checkpoints/1234",
+			wantArchiveIndex: "logs/a/checkpoints/index",
+			wantTileHeight:   "logs/a/tile-height",
+		}, {
+			name:             "suffix and query",
+			paths:            Paths{Prefix: "https://example.com/", Suffix: ".gz", Query: "token=abc"},
+			wantCheckpoint:   "https://example.com/checkpoint?token=abc",
+			wantTile:         "https://example.com/tile/1/x001/234.p/5.gz?token=abc",
+			wantEB:           "https://example.com/tile/entries/x001/234.p/5.gz?token=abc",
+			wantArchived:     "https://example.com/checkpoints/1234?token=abc",
+			wantArchiveIndex: "https://example.com/checkpoints/index?token=abc",
+			wantTileHeight:   "https://example.com/tile-height?token=abc",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.paths.Checkpoint(); got != test.wantCheckpoint {
+				t.Errorf("Checkpoint() = %q, want %q", got, test.wantCheckpoint)
+			}
+			if got := test.paths.Tile(1, 1234, 5); got != test.wantTile {
+				t.Errorf("Tile() = %q, want %q", got, test.wantTile)
+			}
+			if got := test.paths.EntryBundle(1234, 5); got != test.wantEB {
+				t.Errorf("EntryBundle() = %q, want %q", got, test.wantEB)
+			}
+			if got := test.paths.ArchivedCheckpoint(1234); got != test.wantArchived {
+				t.Errorf("ArchivedCheckpoint() = %q, want %q", got, test.wantArchived)
+			}
+			if got := test.paths.CheckpointArchiveIndex(); got != test.wantArchiveIndex {
+				t.Errorf("CheckpointArchiveIndex() = %q, want %q", got, test.wantArchiveIndex)
+			}
+			if got := test.paths.TileHeight(); got != test.wantTileHeight {
+				t.Errorf("TileHeight() = %q, want %q", got, test.wantTileHeight)
+			}
+		})
+	}
+}
+
+func TestNewPaths(t *testing.T) {
+	for _, test := range []struct {
+		base string
+		want Paths
+	}{
+		{base: "https://example.com", want: Paths{Prefix: "https://example.com/"}},
+		{base: "https://example.com/", want: Paths{Prefix: "https://example.com/"}},
+		{base: "https://example.com/logs/a", want: Paths{Prefix: "https://example.com/logs/a/"}},
+		{base: "https://example.com/logs/a/?token=abc&x=1#frag", want: Paths{Prefix: "https://example.com/logs/a/", Query: "token=abc&x=1"}},
+		{base: "file:///var/log/tiles", want: Paths{Prefix: "file:///var/log/tiles/"}},
+	} {
+		t.Run(test.base, func(t *testing.T) {
+			u, err := url.Parse(test.base)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if diff := cmp.Diff(test.want, NewPaths(u)); diff != "" {
+				t.Errorf("NewPaths(%q): diff (-want +got):\n%s", test.base, diff)
+			}
+		})
+	}
+}
@@ -28,22 +28,11 @@ const (
 // PartialTileSize returns the expected number of leaves in a tile at the given tile level and index
 // within a tree of the specified logSize, or 0 if the tile is expected to be fully populated.
 func PartialTileSize(level, index, logSize uint64) uint8 {
-	sizeAtLevel := logSize >> (level * TileHeight)
-	fullTiles := sizeAtLevel / TileWidth
-	if index < fullTiles {
-		return 0
-	}
-	return uint8(sizeAtLevel % TileWidth)
+	return uint8(DefaultTiling.PartialTileSize(level, index, logSize))
 }
 
 // NodeCoordsToTileAddress returns the (TileLevel, TileIndex) in tile-space, and the
 // (NodeLevel, NodeIndex) address within that tile of the specified tree node co-ordinates.
 func NodeCoordsToTileAddress(treeLevel, treeIndex uint64) (uint64, uint64, uint, uint64) {
-	tileRowWidth := uint64(1 << (TileHeight - treeLevel%TileHeight))
-	tileLevel := treeLevel / TileHeight
-	tileIndex := treeIndex / tileRowWidth
-	nodeLevel := uint(treeLevel % TileHeight)
-	nodeIndex := uint64(treeIndex % tileRowWidth)
-
-	return tileLevel, tileIndex, nodeLevel, nodeIndex
+	return DefaultTiling.NodeCoordsToTileAddress(treeLevel, treeIndex)
 }
@@ -0,0 +1,166 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// MaxTileHeight is the largest tile height supported by Tiling.
+const MaxTileHeight = 16
+
+// DefaultTiling is the tiling used by the tlog-tiles spec, and so by all Tessera storage implementations
+// and clients.
+var DefaultTiling = Tiling{Height: TileHeight}
+
+// Tiling describes the tiles which a log's Merkle tree is divided into. The package level functions use
+// DefaultTiling, while a Tiling with a different height can be used to experiment with the trade-off
+// between the number of tiles and their size, e.g. height 10 tiles for very large trees.
+//
+// Note that partial tile sizes are uint64 here, since tiles taller than TileHeight can have more than
+// 255 nodes in their bottom row, and that the tlog-tiles spec only permits tiles of height TileHeight.
+type Tiling struct {
+	// Height is the number of Merkle tree levels each tile holds.
+	Height uint
+}
+
+// Validate returns an error if the tiling is unusable.
+func (t Tiling) Validate() error {
+	if t.Height < 1 || t.Height > MaxTileHeight {
+		return fmt.Errorf("tile height %d is not in the range [1, %d]", t.Height, MaxTileHeight)
+	}
+	return nil
+}
+
+// MarshalText encodes the tiling as the decimal tile height, which is the format of the file at
+// TileHeightPath.
+func (t Tiling) MarshalText() ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(strconv.FormatUint(uint64(t.Height), 10)), nil
+}
+
+// UnmarshalText parses a tiling encoded by MarshalText. Surrounding whitespace is ignored.
+func (t *Tiling) UnmarshalText(text []byte) error {
+	h, err := strconv.ParseUint(strings.TrimSpace(string(text)), 10, 8)
+	if err != nil {
+		return fmt.Errorf("invalid tile height %q: %v", text, err)
+	}
+	r := Tiling{Height: uint(h)}
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	*t = r
+	return nil
+}
+
+// Width returns the maximum number of hashes in the bottom row of a tile, which is also the maximum number
+// of entries in an entry bundle.
+func (t Tiling) Width() uint64 {
+	return 1 << t.Height
+}
+
+// PartialTileSize returns the expected number of leaves in a tile at the given tile level and index
+// within a tree of the specified logSize, or 0 if the tile is expected to be fully populated.
+func (t Tiling) PartialTileSize(level, index, logSize uint64) uint64 {
+	sizeAtLevel := logSize >> (level * uint64(t.Height))
+	fullTiles := sizeAtLevel / t.Width()
+	if index < fullTiles {
+		return 0
+	}
+	return sizeAtLevel % t.Width()
+}
+
+// NodeCoordsToTileAddress returns the (TileLevel, TileIndex) in tile-space, and the
+// (NodeLevel, NodeIndex) address within that tile of the specified tree node co-ordinates.
+func (t Tiling) NodeCoordsToTileAddress(treeLevel, treeIndex uint64) (uint64, uint64, uint, uint64) {
+	h := uint64(t.Height)
+	tileRowWidth := uint64(1) << (h - treeLevel%h)
+	tileLevel := treeLevel / h
+	tileIndex := treeIndex / tileRowWidth
+	nodeLevel := uint(treeLevel % h)
+	nodeIndex := treeIndex % tileRowWidth
+
+	return tileLevel, tileIndex, nodeLevel, nodeIndex
+}
+
+// TilePath builds the path to the subtree tile with the given level and index in tile space.
+// If p > 0 the path represents a partial tile.
+func (t Tiling) TilePath(tileLevel, tileIndex, p uint64) string {
+	return fmt.Sprintf("tile/%d/%s", tileLevel, nWithSuffix(tileIndex, p))
+}
+
+// EntriesPath returns the local path for the nth entry bundle. p denotes the partial
+// bundle size, or 0 if the bundle is complete.
+func (t Tiling) EntriesPath(n, p uint64) string {
+	return fmt.Sprintf("tile/entries/%s", nWithSuffix(n, p))
+}
+
+// Range returns an iterator over a list of TilingRangeInfo structs which describe the bundles/tiles
+// necessary to cover the specified range of individual entries/hashes `[from, min(from+N, treeSize) )`.
+//
+// If from >= treeSize or N == 0, the returned iterator will yield no elements.
+func (t Tiling) Range(from, N, treeSize uint64) iter.Seq[TilingRangeInfo] {
+	return func(yield func(TilingRangeInfo) bool) {
+		if from >= treeSize || N == 0 {
+			return
+		}
+		if from+N > treeSize {
+			N = treeSize - from
+		}
+		w := t.Width()
+		endInc := from + N - 1
+		sIndex := from / w
+		eIndex := endInc / w
+		for idx := sIndex; idx <= eIndex; idx++ {
+			ri := TilingRangeInfo{Index: idx, Partial: t.PartialTileSize(0, idx, treeSize), N: w}
+			if idx == sIndex {
+				ri.First = from % w
+			}
+			if idx == eIndex {
+				ri.N = endInc%w + 1
+			}
+			ri.N -= ri.First
+			if !yield(ri) {
+				return
+			}
+		}
+	}
+}
+
+// TilingRangeInfo is the equivalent of RangeInfo for a Tiling, and describes a specific range of elements
+// within a particular bundle/tile.
+type TilingRangeInfo struct {
+	// Index is the index of the entry bundle/tile in the tree.
+	Index uint64
+	// Partial is the partial size of the bundle/tile, or zero if a full bundle/tile is expected.
+	Partial uint64
+	// First is the offset into the entries contained by the bundle/tile at which the range starts.
+	First uint64
+	// N is the number of entries, starting at First, which are covered by the range.
+	N uint64
+}
+
+// nWithSuffix returns a tiles-spec "N" path, with a partial suffix if p > 0.
+func nWithSuffix(n, p uint64) string {
+	if p > 0 {
+		return fmt.Sprintf("%s.p/%d", fmtN(n), p)
+	}
+	return fmtN(n)
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTilingDefaultMatchesPackage(t *testing.T) {
+	for _, size := range []uint64{1, 255, 256, 257, 65536, 65537, 1234567} {
+		for _, idx := range []uint64{0, 1, 255, 256, 1000, 4822} {
+			for level := range uint64(3) {
+				if got, want := DefaultTiling.PartialTileSize(level, idx, size), PartialTileSize(level, idx, size); got != uint64(want) {
+					t.Errorf("PartialTileSize(%d, %d, %d) = %d, want %d", level, idx, size, got, want)
+				}
+				p := PartialTileSize(level, idx, size)
+				if got, want := DefaultTiling.TilePath(level, idx, uint64(p)), TilePath(level, idx, p); got != want {
+					t.Errorf("TilePath(%d, %d, %d) = %q, want %q", level, idx, p, got, want)
+				}
+			}
+			p := PartialTileSize(0, idx, size)
+			if got, want := DefaultTiling.EntriesPath(idx, uint64(p)), EntriesPath(idx, p); got != want {
+				t.Errorf("EntriesPath(%d, %d) = %q, want %q", idx, p, got, want)
+			}
+		}
+		for _, from := range []uint64{0, 10, 256, 300} {
+			var got, want []RangeInfo
+			for ri := range DefaultTiling.Range(from, 1000, size) {
+				got = append(got, RangeInfo{Index: ri.Index, Partial: uint8(ri.Partial), First: uint(ri.First), N: uint(ri.N)})
+			}
+			for ri := range Range(from, 1000, size) {
+				want = append(want, ri)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("Range(%d, 1000, %d) = %v, want %v", from, size, got, want)
+			}
+		}
+	}
+}
+
+func TestTilingHeight10(t *testing.T) {
+	tl := Tiling{Height: 10}
+	if err := tl.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got, want := tl.Width(), uint64(1024); got != want {
+		t.Errorf("Width() = %d, want %d", got, want)
+	}
+	// A tree of 1024*1024+1000 entries has 1025 level 0 tiles, the last of which holds 1000 leaves, and a
+	// level 1 tile whose bottom row holds the hashes of the 1024 full tiles below it, and so is full.
+	const size = 1024*1024 + 1000
+	for _, test := range []struct {
+		level, index uint64
+		want         uint64
+	}{
+		{level: 0, index: 0, want: 0},
+		{level: 0, index: 1024, want: 1000},
+		{level: 1, index: 0, want: 0},
+		{level: 1, index: 1, want: 0},
+		{level: 2, index: 0, want: 1},
+	} {
+		if got := tl.PartialTileSize(test.level, test.index, size); got != test.want {
+			t.Errorf("PartialTileSize(%d, %d) = %d, want %d", test.level, test.index, got, test.want)
+		}
+	}
+	if got, want := tl.TilePath(0, 1024, 1000), "tile/0/x001/024.p/1000"; got != want {
+		t.Errorf("TilePath() = %q, want %q", got, want)
+	}
+	tileLevel, tileIndex, nodeLevel, nodeIndex := tl.NodeCoordsToTileAddress(11, 5)
+	if tileLevel != 1 || tileIndex != 0 || nodeLevel != 1 || nodeIndex != 5 {
+		t.Errorf("NodeCoordsToTileAddress(11, 5) = %d, %d, %d, %d, want 1, 0, 1, 5", tileLevel, tileIndex, nodeLevel, nodeIndex)
+	}
+	var got []TilingRangeInfo
+	for ri := range tl.Range(1000, 1100, size) {
+		got = append(got, ri)
+	}
+	want := []TilingRangeInfo{{Index: 0, First: 1000, N: 24}, {Index: 1, First: 0, N: 1024}, {Index: 2, First: 0, N: 52}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Range() = %v, want %v", got, want)
+	}
+}
+
+func TestTilingValidate(t *testing.T) {
+	for _, h := range []uint{0, MaxTileHeight + 1} {
+		if err := (Tiling{Height: h}).Validate(); err == nil {
+			t.Errorf("Validate() for height %d succeeded, want error", h)
+		}
+	}
+}
+
+func TestTilingText(t *testing.T) {
+	for _, test := range []struct {
+		text    string
+		want    Tiling
+		wantErr bool
+	}{
+		{text: "8", want: DefaultTiling},
+		{text: "4\n", want: Tiling{Height: 4}},
+		{text: "0", wantErr: true},
+		{text: "17", wantErr: true},
+		{text: "-1", wantErr: true},
+		{text: "eight", wantErr: true},
+	} {
+		t.Run(test.text, func(t *testing.T) {
+			var got Tiling
+			err := got.UnmarshalText([]byte(test.text))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("UnmarshalText(%q): %v, wantErr %t", test.text, err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if got != test.want {
+				t.Fatalf("UnmarshalText(%q) = %v, want %v", test.text, got, test.want)
+			}
+			b, err := got.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText: %v", err)
+			}
+			var rt Tiling
+			if err := rt.UnmarshalText(b); err != nil || rt != got {
+				t.Errorf("UnmarshalText(MarshalText()) = %v, %v, want %v", rt, err, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/writepb"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultIntegrationPollInterval is used by NewWriterServer if WriterServerOptions.IntegrationPollInterval is unset.
+	DefaultIntegrationPollInterval = 100 * time.Millisecond
+	// DefaultMaxBatchSize is used by NewWriterServer if WriterServerOptions.MaxBatchSize is unset.
+	DefaultMaxBatchSize = 1024
+)
+
+// WriterServerOptions holds optional settings for a WriterServer.
+type WriterServerOptions struct {
+	// IntegrationPollInterval is how often the log's integrated size is checked while a request waits
+	// for its entries to reach writepb.Stage_STAGE_INTEGRATED.
+	IntegrationPollInterval time.Duration
+	// MaxBatchSize is the largest number of entries accepted in a single AddBatch request.
+	MaxBatchSize int
+	// NewEntry is used to create the entries added to the log from the submitted data.
+	// If nil, tessera.NewEntry is used.
+	NewEntry func(data []byte) *tessera.Entry
+}
+
+// WriterServer implements the writepb.Writer gRPC service, which allows high-throughput submitters to add
+// entries to a log over long-lived HTTP/2 connections, rather than making an HTTP request per entry.
+//
+// Each request streams one response for each stage the entries reach, up to and including the stage
+// requested by the client.
+type WriterServer struct {
+	writepb.UnimplementedWriterServer
+
+	appender *tessera.Appender
+	reader   tessera.LogReader
+	awaiter  *tessera.PublicationAwaiter
+	opts     WriterServerOptions
+}
+
+// NewWriterServer returns a WriterServer which adds entries using the provided Appender, and uses the
+// LogReader and PublicationAwaiter to report when they have been integrated and published.
+//
+// The returned server should be registered with a gRPC server using writepb.RegisterWriterServer.
+func NewWriterServer(a *tessera.Appender, r tessera.LogReader, awaiter *tessera.PublicationAwaiter, opts WriterServerOptions) *WriterServer {
+	if opts.IntegrationPollInterval == 0 {
+		opts.IntegrationPollInterval = DefaultIntegrationPollInterval
+	}
+	if opts.MaxBatchSize == 0 {
+		opts.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if opts.NewEntry == nil {
+		opts.NewEntry = tessera.NewEntry
+	}
+	return &WriterServer{appender: a, reader: r, awaiter: awaiter, opts: opts}
+}
+
+// Add implements writepb.WriterServer.
+func (s *WriterServer) Add(req *writepb.AddRequest, stream grpc.ServerStreamingServer[writepb.AddResponse]) error {
+	return s.add(stream, [][]byte{req.GetEntry()}, req.GetUntil())
+}
+
+// AddBatch implements writepb.WriterServer.
+func (s *WriterServer) AddBatch(req *writepb.AddBatchRequest, stream grpc.ServerStreamingServer[writepb.AddResponse]) error {
+	if n := len(req.GetEntries()); n == 0 || n > s.opts.MaxBatchSize {
+		return status.Errorf(codes.InvalidArgument, "batch has %d entries, must have between 1 and %d", n, s.opts.MaxBatchSize)
+	}
+	return s.add(stream, req.GetEntries(), req.GetUntil())
+}
+
+// add adds the entries to the log, and sends a response to the stream as they reach each stage up to until.
+//
+// If an error is returned, some of the entries may nevertheless have been added to the log.
+func (s *WriterServer) add(stream grpc.ServerStreamingServer[writepb.AddResponse], data [][]byte, until writepb.Stage) error {
+	ctx := stream.Context()
+	if until == writepb.Stage_STAGE_UNSPECIFIED {
+		until = writepb.Stage_STAGE_SEQUENCED
+	}
+	if until > writepb.Stage_STAGE_PUBLISHED {
+		return status.Errorf(codes.InvalidArgument, "unknown stage %v", until)
+	}
+
+	entries := make([]*tessera.Entry, 0, len(data))
+	for _, d := range data {
+		entries = append(entries, s.opts.NewEntry(d))
+	}
+	var futures []tessera.IndexFuture
+	if s.appender.AddBatch != nil {
+		futures = s.appender.AddBatch(ctx, entries)
+	} else {
+		for _, e := range entries {
+			futures = append(futures, s.appender.Add(ctx, e))
+		}
+	}
+
+	// size is the smallest tree size which contains all of the entries.
+	var size uint64
+	resp := &writepb.AddResponse{Stage: writepb.Stage_STAGE_SEQUENCED}
+	for i, f := range futures {
+		idx, err := f()
+		if err != nil && !errors.Is(err, tessera.ErrDuplicate) {
+			return toStatus(fmt.Errorf("entry %d: %w", i, err))
+		}
+		resp.Entries = append(resp.Entries, &writepb.EntryStatus{
+			Position:  uint32(i),
+			Index:     idx.Index,
+			Duplicate: idx.IsDup || err != nil,
+		})
+		size = max(size, idx.Index+1)
+	}
+	if err := stream.Send(resp); err != nil || until == writepb.Stage_STAGE_SEQUENCED {
+		return err
+	}
+
+	integrated, err := s.awaitIntegration(ctx, size)
+	if err != nil {
+		return toStatus(err)
+	}
+	if err := stream.Send(&writepb.AddResponse{Stage: writepb.Stage_STAGE_INTEGRATED, TreeSize: integrated}); err != nil || until == writepb.Stage_STAGE_INTEGRATED {
+		return err
+	}
+
+	// All of the futures have already resolved, so wait for the largest index to be published.
+	_, cp, err := s.awaiter.Await(ctx, func() (tessera.Index, error) { return tessera.Index{Index: size - 1}, nil })
+	if err != nil {
+		return toStatus(fmt.Errorf("failed to await publication: %w", err))
+	}
+	_, published, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return toStatus(fmt.Errorf("failed to parse checkpoint: %v", err))
+	}
+	return stream.Send(&writepb.AddResponse{Stage: writepb.Stage_STAGE_PUBLISHED, TreeSize: published, Checkpoint: cp})
+}
+
+// awaitIntegration blocks until the log's integrated tree has at least the given size, and returns its size.
+func (s *WriterServer) awaitIntegration(ctx context.Context, size uint64) (uint64, error) {
+	for {
+		integrated, err := s.reader.IntegratedSize(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read integrated size: %w", err)
+		}
+		if integrated >= size {
+			return integrated, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(s.opts.IntegrationPollInterval):
+		}
+	}
+}
+
+// toStatus converts errors returned by Tessera into gRPC status errors.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, tessera.ErrPushback):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, tessera.ErrStorageUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, tessera.ErrLogNotActive):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, tessera.ErrEntryTooLarge):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	klog.Warningf("Failed to add entries: %v", err)
+	return status.Error(codes.Internal, "failed to add entries")
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/writepb"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/testonly"
+	"golang.org/x/mod/sumdb/note"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestWriterServer(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(1, time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 100*time.Millisecond)
+	writepb.RegisterWriterServer(gs, NewWriterServer(tl.Appender, tl.LogReader, awaiter, WriterServerOptions{MaxBatchSize: 10, IntegrationPollInterval: 10 * time.Millisecond}))
+	go func() {
+		_ = gs.Serve(lis)
+	}()
+	defer gs.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	client := writepb.NewWriterClient(conn)
+
+	recvAll := func(t *testing.T, stream grpc.ServerStreamingClient[writepb.AddResponse]) ([]*writepb.AddResponse, error) {
+		t.Helper()
+		var r []*writepb.AddResponse
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return r, nil
+			}
+			if err != nil {
+				return r, err
+			}
+			r = append(r, resp)
+		}
+	}
+
+	t.Run("add until published", func(t *testing.T) {
+		stream, err := client.Add(ctx, &writepb.AddRequest{Entry: []byte("one"), Until: writepb.Stage_STAGE_PUBLISHED})
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		resps, err := recvAll(t, stream)
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if len(resps) != 3 {
+			t.Fatalf("got %d responses, want 3", len(resps))
+		}
+		for i, want := range []writepb.Stage{writepb.Stage_STAGE_SEQUENCED, writepb.Stage_STAGE_INTEGRATED, writepb.Stage_STAGE_PUBLISHED} {
+			if got := resps[i].GetStage(); got != want {
+				t.Errorf("response %d: got stage %v, want %v", i, got, want)
+			}
+		}
+		idx := resps[0].GetEntries()[0].GetIndex()
+		if s := resps[1].GetTreeSize(); s <= idx {
+			t.Errorf("got integrated size %d, want > %d", s, idx)
+		}
+		cp := resps[2].GetCheckpoint()
+		if _, err := note.Open(cp, note.VerifierList(tl.SigVerifier)); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if _, size, _, err := parse.CheckpointUnsafe(cp); err != nil || size != resps[2].GetTreeSize() || size <= idx {
+			t.Errorf("got checkpoint size %d (%v) and tree size %d, want equal sizes > %d", size, err, resps[2].GetTreeSize(), idx)
+		}
+	})
+
+	t.Run("batch until sequenced", func(t *testing.T) {
+		var entries [][]byte
+		for i := range 5 {
+			entries = append(entries, fmt.Appendf(nil, "batch %d", i))
+		}
+		// Include a duplicate of an earlier entry.
+		entries = append(entries, []byte("one"))
+		stream, err := client.AddBatch(ctx, &writepb.AddBatchRequest{Entries: entries})
+		if err != nil {
+			t.Fatalf("AddBatch: %v", err)
+		}
+		resps, err := recvAll(t, stream)
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if len(resps) != 1 || resps[0].GetStage() != writepb.Stage_STAGE_SEQUENCED {
+			t.Fatalf("got %v, want a single sequenced response", resps)
+		}
+		got := resps[0].GetEntries()
+		if len(got) != len(entries) {
+			t.Fatalf("got %d entry statuses, want %d", len(got), len(entries))
+		}
+		seen := make(map[uint64]bool)
+		for i, e := range got {
+			if e.GetPosition() != uint32(i) {
+				t.Errorf("status %d: got position %d", i, e.GetPosition())
+			}
+			if seen[e.GetIndex()] {
+				t.Errorf("status %d: index %d assigned more than once", i, e.GetIndex())
+			}
+			seen[e.GetIndex()] = true
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, req := range []*writepb.AddBatchRequest{
+			{},
+			{Entries: make([][]byte, 11)},
+			{Entries: [][]byte{[]byte("x")}, Until: writepb.Stage(100)},
+		} {
+			stream, err := client.AddBatch(ctx, req)
+			if err != nil {
+				t.Fatalf("AddBatch: %v", err)
+			}
+			if _, err := recvAll(t, stream); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("got %v, want %v", err, codes.InvalidArgument)
+			}
+		}
+	})
+}
+
+func TestToStatus(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: fmt.Errorf("entry 0: %w", tessera.ErrPushback), want: codes.ResourceExhausted},
+		{err: tessera.ErrStorageUnavailable, want: codes.Unavailable},
+		{err: tessera.ErrLogNotActive, want: codes.FailedPrecondition},
+		{err: tessera.ErrEntryTooLarge, want: codes.InvalidArgument},
+		{err: context.DeadlineExceeded, want: codes.DeadlineExceeded},
+		{err: errors.New("boom"), want: codes.Internal},
+	} {
+		if got := status.Code(toStatus(test.err)); got != test.want {
+			t.Errorf("toStatus(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+// clientSweepInterval is how often clientLimiters forgets clients whose token buckets have refilled.
+const clientSweepInterval = time.Minute
+
+// RemoteAddrClientKey returns the IP address of the client which sent r, which is the default
+// TilesOptions.ClientKey.
+//
+// This will be the address of the proxy for logs served behind one, in which case a ClientKey which
+// reads a header set by the proxy should be used instead.
+func RemoteAddrClientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loadShedder rejects requests which exceed the concurrency and per-client rate limits in TilesOptions.
+type loadShedder struct {
+	// sem holds a token for each request being served, and is nil if concurrency isn't limited.
+	sem chan struct{}
+	// clients is nil if clients aren't rate limited.
+	clients *clientLimiters
+}
+
+// newLoadShedder returns a loadShedder for the limits in opts, or nil if none are configured.
+func newLoadShedder(opts TilesOptions) *loadShedder {
+	if opts.MaxConcurrentReads <= 0 && opts.ClientQPS <= 0 {
+		return nil
+	}
+	s := &loadShedder{}
+	if opts.MaxConcurrentReads > 0 {
+		s.sem = make(chan struct{}, opts.MaxConcurrentReads)
+	}
+	if opts.ClientQPS > 0 {
+		key := opts.ClientKey
+		if key == nil {
+			key = RemoteAddrClientKey
+		}
+		burst := opts.ClientBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(opts.ClientQPS))
+		}
+		s.clients = &clientLimiters{
+			key:     key,
+			limit:   rate.Limit(opts.ClientQPS),
+			burst:   burst,
+			clients: make(map[string]*rate.Limiter),
+		}
+	}
+	return s
+}
+
+// wrap returns a handler which serves requests with next, unless they exceed the limits.
+//
+// Clients which exceed their rate limit are answered with 429 Too Many Requests, and requests which arrive
+// while the maximum number of reads are in flight are answered with 503 Service Unavailable. Both carry a
+// Retry-After header.
+func (s *loadShedder) wrap(h *tilesHandler, next http.HandlerFunc) http.HandlerFunc {
+	if s == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.clients != nil {
+			if ok, wait := s.clients.allow(r); !ok {
+				recordShed(r, "client_rate")
+				h.cors(w)
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		if s.sem != nil {
+			select {
+			case s.sem <- struct{}{}:
+				defer func() { <-s.sem }()
+			default:
+				recordShed(r, "concurrency")
+				h.cors(w)
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "too many concurrent requests", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// recordShed counts a request which was rejected for the given reason.
+func recordShed(r *http.Request, reason string) {
+	serveShedCounter.Add(r.Context(), 1, metric.WithAttributes(attribute.String("tessera.serve.shed_reason", reason)))
+}
+
+// clientLimiters holds a token bucket for each recently seen client.
+type clientLimiters struct {
+	key   func(*http.Request) string
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	clients   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// allow reports whether the client which sent r is within its rate limit, and if not, how long it
+// should wait before retrying.
+func (c *clientLimiters) allow(r *http.Request) (bool, time.Duration) {
+	k := c.key(r)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) > clientSweepInterval {
+		c.sweep(now)
+	}
+	l, ok := c.clients[k]
+	if !ok {
+		l = rate.NewLimiter(c.limit, c.burst)
+		c.clients[k] = l
+	}
+	if l.AllowN(now, 1) {
+		return true, 0
+	}
+	return false, time.Duration(float64(time.Second) * (1 - l.TokensAt(now)) / float64(c.limit))
+}
+
+// sweep forgets the clients whose buckets are full, since a new bucket would behave identically.
+// This bounds the memory used to the number of clients seen recently.
+func (c *clientLimiters) sweep(now time.Time) {
+	for k, l := range c.clients {
+		if l.TokensAt(now) >= float64(c.burst) {
+			delete(c.clients, k)
+		}
+	}
+	c.lastSweep = now
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestClientRateLimit(t *testing.T) {
+	cp := []byte("example.com/log\n10\nAAAA\n\n— example.com/log AAAA\n")
+	for _, test := range []struct {
+		name string
+		opts TilesOptions
+		// reqs are the clients making each request, in order.
+		reqs       []string
+		wantStatus []int
+	}{
+		{
+			name:       "unlimited",
+			reqs:       []string{"a", "a", "a"},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		}, {
+			name:       "burst exceeded",
+			opts:       TilesOptions{ClientQPS: 0.001, ClientBurst: 2},
+			reqs:       []string{"a", "a", "a"},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		}, {
+			name:       "default burst",
+			opts:       TilesOptions{ClientQPS: 0.001},
+			reqs:       []string{"a", "a"},
+			wantStatus: []int{http.StatusOK, http.StatusTooManyRequests},
+		}, {
+			name:       "separate clients",
+			opts:       TilesOptions{ClientQPS: 0.001},
+			reqs:       []string{"a", "b", "a", "b"},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		}, {
+			name: "custom key",
+			opts: TilesOptions{ClientQPS: 0.001, ClientKey: func(r *http.Request) string {
+				return r.Header.Get("X-API-Key")
+			}},
+			reqs:       []string{"a", "b", "a"},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			RegisterTilesHandlers(mux, &fakeLogReader{checkpoint: cp}, test.opts)
+			for i, client := range test.reqs {
+				req := httptest.NewRequest(http.MethodGet, "/checkpoint", nil)
+				if test.opts.ClientKey != nil {
+					req.Header.Set("X-API-Key", client)
+				} else {
+					req.RemoteAddr = client + ":1234"
+				}
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, req)
+				if rec.Code != test.wantStatus[i] {
+					t.Fatalf("request %d: got status %d, want %d", i, rec.Code, test.wantStatus[i])
+				}
+				if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d: no Retry-After header", i)
+				}
+			}
+		})
+	}
+}
+
+// blockingLogReader blocks reads of the checkpoint until release is closed.
+type blockingLogReader struct {
+	fakeLogReader
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingLogReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.fakeLogReader.ReadCheckpoint(ctx)
+}
+
+func TestMaxConcurrentReads(t *testing.T) {
+	r := &blockingLogReader{
+		fakeLogReader: fakeLogReader{checkpoint: []byte("example.com/log\n10\nAAAA\n")},
+		started:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	RegisterTilesHandlers(mux, r, TilesOptions{MaxConcurrentReads: 1})
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/checkpoint", nil))
+		done <- rec.Code
+	}()
+	<-r.started
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tile/0/000", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d while read in flight, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("no Retry-After header")
+	}
+
+	close(r.release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("got status %d for first request, want %d", code, http.StatusOK)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tile/0/000", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d after read finished, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestClientLimitersSweep(t *testing.T) {
+	c := &clientLimiters{
+		key:     RemoteAddrClientKey,
+		limit:   rate.Limit(1),
+		burst:   1,
+		clients: make(map[string]*rate.Limiter),
+	}
+	now := time.Now()
+	c.clients["idle"] = rate.NewLimiter(c.limit, c.burst)
+	busy := rate.NewLimiter(c.limit, c.burst)
+	busy.AllowN(now, 1)
+	c.clients["busy"] = busy
+
+	c.sweep(now)
+	if _, ok := c.clients["idle"]; ok {
+		t.Error("idle client wasn't swept")
+	}
+	if _, ok := c.clients["busy"]; !ok {
+		t.Error("busy client was swept")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const name = "github.com/transparency-dev/tessera/api/serve"
+
+var (
+	meter = otel.Meter(name)
+
+	resourceKey = attribute.Key("tessera.resource")
+	hitKey      = attribute.Key("tessera.hit")
+)
+
+var (
+	serveReadHistogram metric.Int64Histogram
+	serveShedCounter   metric.Int64Counter
+	proofCacheLookups  metric.Int64Counter
+
+	// Custom histogram buckets as we're interested in low-millis upto low-seconds.
+	histogramBuckets = []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1200, 1400, 1600, 1800, 2000, 2500, 3000, 4000, 5000, 6000, 8000, 10000}
+)
+
+func init() {
+	var err error
+
+	serveReadHistogram, err = meter.Int64Histogram(
+		"tessera.serve.read.duration",
+		metric.WithDescription("Duration of LogReader calls made to serve checkpoints, tiles, and entry bundles"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(histogramBuckets...))
+	if err != nil {
+		klog.Exitf("Failed to create serveReadHistogram metric: %v", err)
+	}
+
+	serveShedCounter, err = meter.Int64Counter(
+		"tessera.serve.shed",
+		metric.WithDescription("Number of read requests rejected by the concurrency and per-client rate limits"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		klog.Exitf("Failed to create serveShedCounter metric: %v", err)
+	}
+
+	proofCacheLookups, err = meter.Int64Counter(
+		"tessera.serve.proof_cache.lookups",
+		metric.WithDescription("Number of inclusion proof requests looked up in the InclusionProofCache, by whether the proof was cached"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		klog.Exitf("Failed to create proofCacheLookups metric: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultInclusionProofCacheEntries is used by NewInclusionProofCache if InclusionProofCacheOptions.Entries is unset.
+	DefaultInclusionProofCacheEntries = 256
+	// DefaultInclusionProofCachePollInterval is used by NewInclusionProofCache if InclusionProofCacheOptions.PollInterval is unset.
+	DefaultInclusionProofCachePollInterval = time.Second
+)
+
+// InclusionProofCacheOptions holds optional settings for an InclusionProofCache.
+type InclusionProofCacheOptions struct {
+	// Hasher is the hasher used by the log's Merkle tree. If nil, the RFC 6962 SHA-256 hasher is used.
+	Hasher merkle.LogHasher
+	// Tiling describes the log's tiles. If unset, layout.DefaultTiling is used.
+	Tiling layout.Tiling
+	// Entries is the number of most recently added entries for which proofs are precomputed.
+	// If zero, DefaultInclusionProofCacheEntries is used.
+	Entries uint64
+	// PollInterval is how often to check for a new checkpoint, if the LogReader isn't a tessera.LogWatcher.
+	// If zero, DefaultInclusionProofCachePollInterval is used.
+	PollInterval time.Duration
+}
+
+// InclusionProofCache is a tessera.Follower which precomputes inclusion proofs for the most recently added
+// entries against the latest published checkpoint, and recomputes them each time a new one is published.
+//
+// Newly added entries are the ones clients most often ask about, and they usually ask for a proof against
+// the latest checkpoint, so passing the cache to a ProofServer via ProofServerOptions.InclusionCache allows
+// those requests to be answered without reading any tiles.
+//
+// The cache must be started, either by attaching it to the Appender with tessera.AppendOptions.WithFollower,
+// or by calling Follow directly.
+type InclusionProofCache struct {
+	opts   InclusionProofCacheOptions
+	proofs atomic.Pointer[inclusionProofs]
+}
+
+// inclusionProofs holds the proofs for entries [first, size) in the tree of the given size.
+type inclusionProofs struct {
+	size   uint64
+	first  uint64
+	proofs [][][]byte
+}
+
+// NewInclusionProofCache returns an InclusionProofCache configured by opts.
+func NewInclusionProofCache(opts InclusionProofCacheOptions) *InclusionProofCache {
+	if opts.Hasher == nil {
+		opts.Hasher = rfc6962.DefaultHasher
+	}
+	if opts.Tiling.Height == 0 {
+		opts.Tiling = layout.DefaultTiling
+	}
+	if opts.Entries == 0 {
+		opts.Entries = DefaultInclusionProofCacheEntries
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultInclusionProofCachePollInterval
+	}
+	return &InclusionProofCache{opts: opts}
+}
+
+// Name returns the name of the follower.
+func (c *InclusionProofCache) Name() string {
+	return "inclusion_proof_cache"
+}
+
+// EntriesProcessed returns the size of the tree which the cached proofs are for.
+func (c *InclusionProofCache) EntriesProcessed(_ context.Context) (uint64, error) {
+	if p := c.proofs.Load(); p != nil {
+		return p.size, nil
+	}
+	return 0, nil
+}
+
+// Follow keeps the cache up to date with the log's published checkpoint until ctx is done.
+func (c *InclusionProofCache) Follow(ctx context.Context, lr tessera.LogReader) {
+	var changes <-chan tessera.LogChange
+	if w, ok := lr.(tessera.LogWatcher); ok {
+		changes = w.Watch(ctx)
+	}
+	for {
+		if err := c.update(ctx, lr); err != nil && ctx.Err() == nil {
+			klog.Warningf("Inclusion proof cache: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.opts.PollInterval):
+		case <-changes:
+		}
+	}
+}
+
+// InclusionProof returns the cached proof that the entry at index is included in the tree of the given size,
+// if there is one. The returned proof must not be modified.
+func (c *InclusionProofCache) InclusionProof(index, size uint64) ([][]byte, bool) {
+	p := c.proofs.Load()
+	if p == nil || p.size != size || index < p.first || index >= p.size {
+		return nil, false
+	}
+	return p.proofs[index-p.first], true
+}
+
+// update recomputes the cached proofs if a new checkpoint has been published.
+func (c *InclusionProofCache) update(ctx context.Context, lr tessera.LogReader) error {
+	cp, err := lr.ReadCheckpoint(ctx)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	_, size, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	if p := c.proofs.Load(); size == 0 || (p != nil && p.size >= size) {
+		return nil
+	}
+	// Proofs for every entry change as the tree grows, so they're all recomputed. The proof builder caches
+	// the tiles it reads, so this only reads the handful of tiles along the right hand edge of the tree.
+	pb, err := client.NewProofBuilderWithTiling(ctx, size, lr.ReadTile, c.opts.Hasher, c.opts.Tiling)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder for size %d: %v", size, err)
+	}
+	first := size - min(size, c.opts.Entries)
+	proofs := make([][][]byte, 0, size-first)
+	for i := first; i < size; i++ {
+		p, err := pb.InclusionProof(ctx, i)
+		if err != nil {
+			return fmt.Errorf("failed to build inclusion proof for %d in tree of size %d: %v", i, size, err)
+		}
+		proofs = append(proofs, p)
+	}
+	c.proofs.Store(&inclusionProofs{size: size, first: first, proofs: proofs})
+	return nil
+}
+
+// recordLookup counts a lookup in the cache by a ProofServer.
+func recordLookup(ctx context.Context, hit bool) {
+	proofCacheLookups.Add(ctx, 1, metric.WithAttributes(hitKey.Bool(hit)))
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+func TestInclusionProofCache(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(1, time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	const numEntries, cached = 300, 100
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 100*time.Millisecond)
+	var futures []tessera.IndexFuture
+	for i := range numEntries {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	idx, cpRaw, err := awaiter.AwaitAll(ctx, futures)
+	if err != nil {
+		t.Fatalf("AwaitAll: %v", err)
+	}
+	_, size, root, err := parse.CheckpointUnsafe(cpRaw)
+	if err != nil {
+		t.Fatalf("CheckpointUnsafe: %v", err)
+	}
+
+	c := NewInclusionProofCache(InclusionProofCacheOptions{Entries: cached, PollInterval: 10 * time.Millisecond})
+	go c.Follow(ctx, tl.LogReader)
+	for {
+		n, err := c.EntriesProcessed(ctx)
+		if err != nil {
+			t.Fatalf("EntriesProcessed: %v", err)
+		}
+		if n >= size {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n, _ := c.EntriesProcessed(ctx); n != size {
+		t.Fatalf("cache is for size %d, want %d", n, size)
+	}
+
+	uncached, err := NewProofServer(tl.LogReader, ProofServerOptions{})
+	if err != nil {
+		t.Fatalf("NewProofServer: %v", err)
+	}
+	withCache, err := NewProofServer(tl.LogReader, ProofServerOptions{InclusionCache: c})
+	if err != nil {
+		t.Fatalf("NewProofServer: %v", err)
+	}
+	for i, x := range idx {
+		p, ok := c.InclusionProof(x.Index, size)
+		if want := x.Index >= size-cached; ok != want {
+			t.Fatalf("index %d: InclusionProof returned ok=%t, want %t", x.Index, ok, want)
+		}
+		if !ok {
+			continue
+		}
+		leaf := rfc6962.DefaultHasher.HashLeaf(fmt.Appendf(nil, "entry %d", i))
+		if err := proof.VerifyInclusion(rfc6962.DefaultHasher, x.Index, size, leaf, p, root); err != nil {
+			t.Errorf("index %d: VerifyInclusion: %v", x.Index, err)
+		}
+		want, err := uncached.InclusionProof(ctx, x.Index, size)
+		if err != nil {
+			t.Fatalf("InclusionProof: %v", err)
+		}
+		got, err := withCache.InclusionProof(ctx, x.Index, size)
+		if err != nil {
+			t.Fatalf("InclusionProof: %v", err)
+		}
+		if !cmp.Equal(got, want) {
+			t.Errorf("index %d: got proof %x from cache, want %x", x.Index, got, want)
+		}
+	}
+	if _, ok := c.InclusionProof(size-2, size-1); ok {
+		t.Error("InclusionProof returned a proof for a tree size which isn't cached")
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package serve provides HTTP handlers which personalities can mount into their own muxes to serve
+// read access to a Tessera log, and a gRPC service which allows entries to be added to it.
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// InclusionProofPath is the path at which RegisterHandlers serves inclusion proofs.
+	InclusionProofPath = "/proof/inclusion"
+	// ConsistencyProofPath is the path at which RegisterHandlers serves consistency proofs.
+	ConsistencyProofPath = "/proof/consistency"
+
+	// DefaultProofCacheSize is used by NewProofServer if ProofServerOptions.CacheSize is unset.
+	DefaultProofCacheSize = 8
+)
+
+// errBadRequest is returned when a proof is requested for tree sizes which are invalid, or not published.
+var errBadRequest = errors.New("bad request")
+
+// ProofServerOptions holds optional settings for a ProofServer.
+type ProofServerOptions struct {
+	// Hasher is the hasher used by the log's Merkle tree. If nil, the RFC 6962 SHA-256 hasher is used.
+	Hasher merkle.LogHasher
+	// Tiling describes the log's tiles. If unset, layout.DefaultTiling is used.
+	Tiling layout.Tiling
+	// CacheSize is the number of tree sizes for which proof builders, and the tiles they have fetched,
+	// are retained between requests. If zero, DefaultProofCacheSize is used.
+	CacheSize int
+	// InclusionCache, if set, is consulted for inclusion proofs before building them from tiles.
+	InclusionCache *InclusionProofCache
+}
+
+// InclusionProof is the JSON response served at InclusionProofPath.
+type InclusionProof struct {
+	// Index is the index of the entry whose inclusion is proven.
+	Index uint64 `json:"index"`
+	// TreeSize is the size of the tree the proof is for.
+	TreeSize uint64 `json:"tree_size"`
+	// Proof holds the proof hashes.
+	Proof [][]byte `json:"proof"`
+	// Checkpoint is the latest published checkpoint, which commits to TreeSize. It is only set when
+	// the request did not specify a tree size.
+	Checkpoint []byte `json:"checkpoint,omitempty"`
+}
+
+// ConsistencyProof is the JSON response served at ConsistencyProofPath.
+type ConsistencyProof struct {
+	// From is the size of the smaller tree.
+	From uint64 `json:"from"`
+	// To is the size of the larger tree.
+	To uint64 `json:"to"`
+	// Proof holds the proof hashes.
+	Proof [][]byte `json:"proof"`
+	// Checkpoint is the latest published checkpoint, which commits to To. It is only set when the request
+	// did not specify the size of the larger tree.
+	Checkpoint []byte `json:"checkpoint,omitempty"`
+}
+
+// cachedBuilder guards a ProofBuilder, which is not safe for concurrent use.
+type cachedBuilder struct {
+	mu sync.Mutex
+	pb *client.ProofBuilder
+}
+
+// ProofServer computes inclusion and consistency proofs from a log's tiles, for clients which are unable
+// to do so themselves.
+//
+// Proofs are only served for tree sizes committed to by the log's published checkpoint.
+type ProofServer struct {
+	lr     tessera.LogReader
+	hasher merkle.LogHasher
+	tiling layout.Tiling
+	cache  *InclusionProofCache
+
+	// mu serialises the creation of builders in the cache.
+	mu       sync.Mutex
+	builders *lru.Cache[uint64, *cachedBuilder]
+}
+
+// NewProofServer returns a ProofServer which computes proofs using the provided LogReader.
+func NewProofServer(lr tessera.LogReader, opts ProofServerOptions) (*ProofServer, error) {
+	if opts.Hasher == nil {
+		opts.Hasher = rfc6962.DefaultHasher
+	}
+	if opts.Tiling.Height == 0 {
+		opts.Tiling = layout.DefaultTiling
+	}
+	if opts.CacheSize == 0 {
+		opts.CacheSize = DefaultProofCacheSize
+	}
+	c, err := lru.New[uint64, *cachedBuilder](opts.CacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof builder cache: %v", err)
+	}
+	return &ProofServer{lr: lr, hasher: opts.Hasher, tiling: opts.Tiling, cache: opts.InclusionCache, builders: c}, nil
+}
+
+// RegisterHandlers registers handlers for InclusionProofPath and ConsistencyProofPath with the provided mux.
+//
+// Inclusion proofs are requested with the index and, optionally, tree_size query parameters, and consistency
+// proofs with the from and, optionally, to query parameters. If the size of the tree to prove against is
+// omitted, the size of the latest published checkpoint is used, and that checkpoint is included in the response.
+func (s *ProofServer) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+InclusionProofPath, s.handleInclusionProof)
+	mux.HandleFunc("GET "+ConsistencyProofPath, s.handleConsistencyProof)
+}
+
+// InclusionProof returns a proof that the entry at index is included in the tree of the given size.
+func (s *ProofServer) InclusionProof(ctx context.Context, index, size uint64) ([][]byte, error) {
+	if index >= size {
+		return nil, fmt.Errorf("%w: index %d is outside of tree of size %d", errBadRequest, index, size)
+	}
+	if s.cache != nil {
+		// The cache only holds proofs for published tree sizes, so there's no need to check size here.
+		p, ok := s.cache.InclusionProof(index, size)
+		recordLookup(ctx, ok)
+		if ok {
+			return p, nil
+		}
+	}
+	b, err := s.builder(ctx, size)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pb.InclusionProof(ctx, index)
+}
+
+// ConsistencyProof returns a proof that the tree of size from is a prefix of the tree of size to.
+func (s *ProofServer) ConsistencyProof(ctx context.Context, from, to uint64) ([][]byte, error) {
+	if from > to {
+		return nil, fmt.Errorf("%w: from %d is larger than to %d", errBadRequest, from, to)
+	}
+	b, err := s.builder(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pb.ConsistencyProof(ctx, from, to)
+}
+
+// builder returns a cached ProofBuilder for the given tree size, which must not exceed the published size.
+func (s *ProofServer) builder(ctx context.Context, size uint64) (*cachedBuilder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.builders.Get(size); ok {
+		return b, nil
+	}
+	// Sizes in the cache were checked against the published checkpoint when they were added, and
+	// the published size never shrinks, so only new sizes need to be checked.
+	published, _, err := s.latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if size > published {
+		return nil, fmt.Errorf("%w: tree size %d is larger than published size %d", errBadRequest, size, published)
+	}
+	pb, err := client.NewProofBuilderWithTiling(ctx, size, s.lr.ReadTile, s.hasher, s.tiling)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	b := &cachedBuilder{pb: pb}
+	s.builders.Add(size, b)
+	return b, nil
+}
+
+// latest returns the size of the latest published checkpoint, and the checkpoint itself.
+func (s *ProofServer) latest(ctx context.Context) (uint64, []byte, error) {
+	cp, err := s.lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	_, size, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	return size, cp, nil
+}
+
+func (s *ProofServer) handleInclusionProof(w http.ResponseWriter, r *http.Request) {
+	resp := InclusionProof{}
+	var err error
+	if resp.Index, err = uintParam(r, "index"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if resp.TreeSize, resp.Checkpoint, err = s.sizeParam(r, "tree_size"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if resp.Proof, err = s.InclusionProof(r.Context(), resp.Index, resp.TreeSize); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *ProofServer) handleConsistencyProof(w http.ResponseWriter, r *http.Request) {
+	resp := ConsistencyProof{}
+	var err error
+	if resp.From, err = uintParam(r, "from"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if resp.To, resp.Checkpoint, err = s.sizeParam(r, "to"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if resp.Proof, err = s.ConsistencyProof(r.Context(), resp.From, resp.To); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// sizeParam returns the tree size in the named query parameter. If the parameter is absent, the size of
+// the latest published checkpoint is returned along with the checkpoint.
+func (s *ProofServer) sizeParam(r *http.Request, name string) (uint64, []byte, error) {
+	if r.URL.Query().Has(name) {
+		v, err := uintParam(r, name)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: %v", errBadRequest, err)
+		}
+		return v, nil, nil
+	}
+	return s.latest(r.Context())
+}
+
+// uintParam parses the named query parameter as a decimal integer.
+func uintParam(r *http.Request, name string) (uint64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, fmt.Errorf("missing %s parameter", name)
+	}
+	i, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter %q", name, v)
+	}
+	return i, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errBadRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, os.ErrNotExist):
+		http.Error(w, "no checkpoint has been published", http.StatusNotFound)
+		return
+	}
+	klog.Warningf("Failed to build proof: %v", err)
+	http.Error(w, "failed to build proof", http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
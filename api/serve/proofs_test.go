@@ -0,0 +1,149 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/testonly"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestProofServer(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(1, time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	const numEntries = 300
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 100*time.Millisecond)
+	var futures []tessera.IndexFuture
+	for i := range numEntries {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	idx, cpRaw, err := awaiter.AwaitAll(ctx, futures)
+	if err != nil {
+		t.Fatalf("AwaitAll: %v", err)
+	}
+	n, err := note.Open(cpRaw, note.VerifierList(tl.SigVerifier))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	cp := &log.Checkpoint{}
+	if _, err := cp.Unmarshal([]byte(n.Text)); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if cp.Size < numEntries {
+		t.Fatalf("got checkpoint size %d, want at least %d", cp.Size, numEntries)
+	}
+
+	ps, err := NewProofServer(tl.LogReader, ProofServerOptions{})
+	if err != nil {
+		t.Fatalf("NewProofServer: %v", err)
+	}
+	mux := http.NewServeMux()
+	ps.RegisterHandlers(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	get := func(t *testing.T, path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	t.Run("inclusion", func(t *testing.T) {
+		for _, i := range []uint64{0, 1, 255, 256, numEntries - 1} {
+			var p InclusionProof
+			if s := get(t, fmt.Sprintf("%s?index=%d", InclusionProofPath, idx[i].Index), &p); s != http.StatusOK {
+				t.Fatalf("index %d: got status %d", i, s)
+			}
+			if p.TreeSize != cp.Size || len(p.Checkpoint) == 0 {
+				t.Errorf("index %d: got tree size %d and checkpoint %q, want size %d and a checkpoint", i, p.TreeSize, p.Checkpoint, cp.Size)
+			}
+			leaf := rfc6962.DefaultHasher.HashLeaf(fmt.Appendf(nil, "entry %d", i))
+			if err := proof.VerifyInclusion(rfc6962.DefaultHasher, idx[i].Index, p.TreeSize, leaf, p.Proof, cp.Hash); err != nil {
+				t.Errorf("index %d: VerifyInclusion: %v", i, err)
+			}
+		}
+	})
+
+	t.Run("consistency", func(t *testing.T) {
+		// The root hash for the smaller tree is derived from an inclusion proof for its last leaf.
+		const from = 100
+		var ip InclusionProof
+		if s := get(t, fmt.Sprintf("%s?index=%d&tree_size=%d", InclusionProofPath, from-1, from), &ip); s != http.StatusOK {
+			t.Fatalf("inclusion: got status %d", s)
+		}
+		if len(ip.Checkpoint) != 0 {
+			t.Errorf("got checkpoint %q when tree size was specified", ip.Checkpoint)
+		}
+		var leaf []byte
+		for i, x := range idx {
+			if x.Index == from-1 {
+				leaf = rfc6962.DefaultHasher.HashLeaf(fmt.Appendf(nil, "entry %d", i))
+			}
+		}
+		fromRoot, err := proof.RootFromInclusionProof(rfc6962.DefaultHasher, from-1, from, leaf, ip.Proof)
+		if err != nil {
+			t.Fatalf("RootFromInclusionProof: %v", err)
+		}
+		var p ConsistencyProof
+		if s := get(t, fmt.Sprintf("%s?from=%d", ConsistencyProofPath, from), &p); s != http.StatusOK {
+			t.Fatalf("consistency: got status %d", s)
+		}
+		if err := proof.VerifyConsistency(rfc6962.DefaultHasher, from, p.To, p.Proof, fromRoot, cp.Hash); err != nil {
+			t.Errorf("VerifyConsistency: %v", err)
+		}
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		for _, path := range []string{
+			InclusionProofPath,
+			fmt.Sprintf("%s?index=x", InclusionProofPath),
+			fmt.Sprintf("%s?index=%d", InclusionProofPath, cp.Size),
+			fmt.Sprintf("%s?index=0&tree_size=%d", InclusionProofPath, cp.Size+1),
+			fmt.Sprintf("%s?from=10&to=5", ConsistencyProofPath),
+			fmt.Sprintf("%s?from=1&to=%d", ConsistencyProofPath, cp.Size+1),
+		} {
+			if s := get(t, path, nil); s != http.StatusBadRequest {
+				t.Errorf("%s: got status %d, want %d", path, s, http.StatusBadRequest)
+			}
+		}
+	})
+}
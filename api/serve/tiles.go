@@ -0,0 +1,405 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultCheckpointCacheControl is used by RegisterTilesHandlers if TilesOptions.CheckpointCacheControl is unset.
+	DefaultCheckpointCacheControl = "no-cache"
+	// DefaultImmutableCacheControl is used by RegisterTilesHandlers if TilesOptions.ImmutableCacheControl is unset.
+	DefaultImmutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// TilesOptions holds optional settings for the handlers registered by RegisterTilesHandlers.
+type TilesOptions struct {
+	// CheckpointCacheControl is the Cache-Control header value set on checkpoint responses.
+	// A personality could set a small max-age here, so long as it is no higher than the checkpoint interval.
+	CheckpointCacheControl string
+	// ImmutableCacheControl is the Cache-Control header value set on tile and entry bundle responses.
+	ImmutableCacheControl string
+	// CORSAllowOrigin, if set, is returned in the Access-Control-Allow-Origin header of all responses,
+	// and preflight requests are answered, so that the log can be read from browsers on other origins.
+	CORSAllowOrigin string
+	// DisableCompression disables the gzip and zstd encoding of checkpoints and entry bundles.
+	DisableCompression bool
+	// Prefix is the path under which the log is served, e.g. "/logs/a/", which allows several logs to be
+	// served by the same mux. Clients can read such a log using a client.HTTPFetcher with a root URL
+	// ending in the same path. Defaults to "/".
+	Prefix string
+	// MaxConcurrentReads, if positive, is the maximum number of requests which are served at once.
+	// Requests which arrive while this many are in flight are answered with 503 Service Unavailable,
+	// rather than queueing up behind them.
+	MaxConcurrentReads int
+	// ClientQPS, if positive, is the rate at which each client may make requests, averaged over ClientBurst
+	// requests. Clients which exceed it are answered with 429 Too Many Requests.
+	ClientQPS float64
+	// ClientBurst is the number of requests a client may make at once before ClientQPS is enforced.
+	// Defaults to ClientQPS, rounded up.
+	ClientBurst int
+	// ClientKey identifies the client which sent a request for the purposes of ClientQPS, e.g. by an
+	// API key header. Defaults to RemoteAddrClientKey.
+	ClientKey func(*http.Request) string
+}
+
+// RegisterTilesHandlers registers handlers for the https://c2sp.org/tlog-tiles read API with the provided mux,
+// serving the checkpoint, tiles, and entry bundles from the provided LogReader.
+//
+// Checkpoints and entry bundles are compressed using zstd or gzip, if the client accepts either and hasn't
+// made a Range request. Hash tiles are effectively random data, and so are never compressed.
+//
+// Requests for a partial tile or entry bundle with width W are answered with exactly W hashes or entries,
+// even if the LogReader returns a larger resource, or with 404 Not Found if fewer are available.
+// Requests for entry bundles which have expired are answered with 410 Gone.
+//
+// Responses carry an ETag, and conditional requests for resources which haven't changed, such as a checkpoint
+// polled by a monitor, are answered with 304 Not Modified. LogReaders don't report when resources were
+// modified, so Last-Modified isn't set.
+//
+// If the LogReader implements tessera.CheckpointArchiveReader, the log's archived checkpoints and the index
+// listing them are also served, at the paths given by layout.ArchivedCheckpointPath and
+// layout.CheckpointArchiveIndexPath. If it implements tessera.TileHeightReader, the height of the log's tiles is
+// served at layout.TileHeightPath.
+//
+// The concurrency and per-client rate limits in opts, if set, are shared by all of these handlers, and
+// allow small logs to shed load from aggressive crawlers rather than being overwhelmed by them.
+// Preflight requests aren't limited.
+func RegisterTilesHandlers(mux *http.ServeMux, r tessera.LogReader, opts TilesOptions) {
+	if opts.CheckpointCacheControl == "" {
+		opts.CheckpointCacheControl = DefaultCheckpointCacheControl
+	}
+	if opts.ImmutableCacheControl == "" {
+		opts.ImmutableCacheControl = DefaultImmutableCacheControl
+	}
+	p := layout.Paths{Prefix: opts.Prefix}
+	if !strings.HasPrefix(p.Prefix, "/") {
+		p.Prefix = "/" + p.Prefix
+	}
+	if !strings.HasSuffix(p.Prefix, "/") {
+		p.Prefix += "/"
+	}
+	h := &tilesHandler{r: r, opts: opts}
+	s := newLoadShedder(opts)
+	mux.HandleFunc("GET "+p.Checkpoint(), s.wrap(h, h.handleCheckpoint))
+	mux.HandleFunc("GET "+p.Prefix+"tile/{level}/{index...}", s.wrap(h, h.handleTile))
+	mux.HandleFunc("GET "+p.Prefix+"tile/entries/{index...}", s.wrap(h, h.handleEntryBundle))
+	if ar, ok := r.(tessera.CheckpointArchiveReader); ok {
+		h.archive = ar
+		mux.HandleFunc("GET "+p.CheckpointArchiveIndex(), s.wrap(h, h.handleCheckpointArchiveIndex))
+		mux.HandleFunc("GET "+p.Prefix+"checkpoints/{size}", s.wrap(h, h.handleArchivedCheckpoint))
+	}
+	if tr, ok := r.(tessera.TileHeightReader); ok {
+		h.tileHeight = tr
+		mux.HandleFunc("GET "+p.TileHeight(), s.wrap(h, h.handleTileHeight))
+	}
+	if opts.CORSAllowOrigin != "" {
+		mux.HandleFunc("OPTIONS "+p.Checkpoint(), h.handlePreflight)
+		mux.HandleFunc("OPTIONS "+p.Prefix+"tile/", h.handlePreflight)
+		if h.archive != nil {
+			mux.HandleFunc("OPTIONS "+p.Prefix+"checkpoints/", h.handlePreflight)
+		}
+		if h.tileHeight != nil {
+			mux.HandleFunc("OPTIONS "+p.TileHeight(), h.handlePreflight)
+		}
+	}
+}
+
+type tilesHandler struct {
+	r tessera.LogReader
+	// archive is set if r can read the log's archived checkpoints.
+	archive tessera.CheckpointArchiveReader
+	// tileHeight is set if r can read the height of the log's tiles.
+	tileHeight tessera.TileHeightReader
+	opts       TilesOptions
+}
+
+func (h *tilesHandler) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	cp, err := h.r.ReadCheckpoint(r.Context())
+	recordRead(r, "checkpoint", start, err)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.serve(w, r, cp, "text/plain; charset=utf-8", h.opts.CheckpointCacheControl, !h.opts.DisableCompression)
+}
+
+func (h *tilesHandler) handleArchivedCheckpoint(w http.ResponseWriter, r *http.Request) {
+	size, err := strconv.ParseUint(r.PathValue("size"), 10, 64)
+	if err != nil {
+		h.cors(w)
+		http.Error(w, fmt.Sprintf("Malformed URL: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	cp, err := h.archive.ReadArchivedCheckpoint(r.Context(), size)
+	recordRead(r, "archived_checkpoint", start, err)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	// Only the first checkpoint for each size is archived, so they never change.
+	h.serve(w, r, cp, "text/plain; charset=utf-8", h.opts.ImmutableCacheControl, !h.opts.DisableCompression)
+}
+
+func (h *tilesHandler) handleCheckpointArchiveIndex(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	idx, err := h.archive.ReadCheckpointArchiveIndex(r.Context())
+	recordRead(r, "checkpoint_archive_index", start, err)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.serve(w, r, idx, "text/plain; charset=utf-8", h.opts.CheckpointCacheControl, !h.opts.DisableCompression)
+}
+
+func (h *tilesHandler) handleTileHeight(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	th, err := h.tileHeight.ReadTileHeight(r.Context())
+	recordRead(r, "tile_height", start, err)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	// The tile height is fixed when the log is created.
+	h.serve(w, r, th, "text/plain; charset=utf-8", h.opts.ImmutableCacheControl, false)
+}
+
+func (h *tilesHandler) handleTile(w http.ResponseWriter, r *http.Request) {
+	level, index, p, err := layout.ParseTileLevelIndexPartial(r.PathValue("level"), r.PathValue("index"))
+	if err != nil {
+		h.cors(w)
+		http.Error(w, fmt.Sprintf("Malformed URL: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	tile, err := h.r.ReadTile(r.Context(), level, index, p)
+	recordRead(r, "tile", start, err)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if tile, err = partialTile(tile, p); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.serve(w, r, tile, "application/octet-stream", h.opts.ImmutableCacheControl, false)
+}
+
+func (h *tilesHandler) handleEntryBundle(w http.ResponseWriter, r *http.Request) {
+	index, p, err := layout.ParseTileIndexPartial(r.PathValue("index"))
+	if err != nil {
+		h.cors(w)
+		http.Error(w, fmt.Sprintf("Malformed URL: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	bundle, err := h.r.ReadEntryBundle(r.Context(), index, p)
+	recordRead(r, "entries", start, err)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if bundle, err = partialEntryBundle(bundle, p); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.serve(w, r, bundle, "application/octet-stream", h.opts.ImmutableCacheControl, !h.opts.DisableCompression)
+}
+
+// recordRead records the duration of a read of the named resource which began at start.
+func recordRead(r *http.Request, resource string, start time.Time, err error) {
+	attr := []attribute.KeyValue{resourceKey.String(resource)}
+	if err != nil {
+		attr = append(attr, attribute.String("error.type", readErrorType(err)))
+	}
+	serveReadHistogram.Record(r.Context(), time.Since(start).Milliseconds(), metric.WithAttributes(attr...))
+}
+
+// readErrorType classifies read errors in the same way as writeError, to avoid high cardinality of
+// attribute values.
+func readErrorType(err error) string {
+	switch {
+	case errors.Is(err, tessera.ErrEntryBundleExpired):
+		return "expired"
+	case errors.Is(err, os.ErrNotExist):
+		return "not_found"
+	default:
+		return "_OTHER"
+	}
+}
+
+func (h *tilesHandler) handlePreflight(w http.ResponseWriter, _ *http.Request) {
+	h.cors(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Range")
+	w.Header().Set("Access-Control-Max-Age", "86400")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cors sets the CORS headers on the response, if configured.
+func (h *tilesHandler) cors(w http.ResponseWriter) {
+	if h.opts.CORSAllowOrigin == "" {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", h.opts.CORSAllowOrigin)
+	w.Header().Set("Access-Control-Expose-Headers", "Content-Encoding, Content-Length, Content-Range")
+}
+
+// serve writes the resource to the response, compressing it if requested and permitted.
+// Range and conditional requests are handled by http.ServeContent, using an ETag derived from the resource's
+// contents, so clients polling a checkpoint or partial tile which hasn't changed are answered with
+// 304 Not Modified.
+func (h *tilesHandler) serve(w http.ResponseWriter, r *http.Request, data []byte, contentType, cacheControl string, compress bool) {
+	h.cors(w)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Content-Type", contentType)
+	tag := etag(data)
+	if compress {
+		w.Header().Add("Vary", "Accept-Encoding")
+		// Ranges are applied to the encoded representation, so only identity encoded ranges are supported.
+		if r.Header.Get("Range") == "" {
+			if enc := negotiateEncoding(r.Header.Get("Accept-Encoding")); enc != "" {
+				c, err := encode(enc, data)
+				if err != nil {
+					klog.FromContext(r.Context()).Error(err, "Failed to encode response", "path", r.URL.Path, "encoding", enc)
+				} else {
+					w.Header().Set("Content-Encoding", enc)
+					data = c
+					// Each encoding is a different representation, and so needs its own strong ETag.
+					tag = tag[:len(tag)-1] + "-" + enc + `"`
+				}
+			}
+		}
+	}
+	w.Header().Set("ETag", tag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (h *tilesHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	h.cors(w)
+	switch {
+	case errors.Is(err, tessera.ErrEntryBundleExpired):
+		http.Error(w, "entry bundle has expired", http.StatusGone)
+	case errors.Is(err, os.ErrNotExist):
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		klog.FromContext(r.Context()).Error(err, "Failed to read resource", "path", r.URL.Path)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// partialTile returns the first p hashes of the tile, or os.ErrNotExist if it has fewer.
+// If p is zero, the tile must be full.
+func partialTile(tile []byte, p uint8) ([]byte, error) {
+	w := int(p)
+	if w == 0 {
+		w = layout.TileWidth
+	}
+	if len(tile) < w*sha256.Size {
+		return nil, fmt.Errorf("tile has %d hashes, want %d: %w", len(tile)/sha256.Size, w, os.ErrNotExist)
+	}
+	return tile[:w*sha256.Size], nil
+}
+
+// partialEntryBundle returns the first p entries of the bundle, or os.ErrNotExist if it has fewer.
+// If p is zero, the bundle must be full.
+func partialEntryBundle(bundle []byte, p uint8) ([]byte, error) {
+	w := int(p)
+	if w == 0 {
+		w = layout.EntryBundleWidth
+	}
+	if bundle == nil {
+		return nil, os.ErrNotExist
+	}
+	off := 0
+	for n := range w {
+		if off+2 > len(bundle) {
+			return nil, fmt.Errorf("entry bundle has %d entries, want %d: %w", n, w, os.ErrNotExist)
+		}
+		l := int(binary.BigEndian.Uint16(bundle[off:]))
+		if off+2+l > len(bundle) {
+			return nil, fmt.Errorf("entry %d of bundle is truncated", n)
+		}
+		off += 2 + l
+	}
+	return bundle[:off], nil
+}
+
+// etag returns a strong entity tag for the resource with the given contents.
+func etag(data []byte) string {
+	h := sha256.Sum256(data)
+	return fmt.Sprintf(`"%x"`, h[:16])
+}
+
+// negotiateEncoding returns the preferred content encoding supported by both the server and the client's
+// Accept-Encoding header, or the empty string if there is none.
+func negotiateEncoding(accept string) string {
+	var gz bool
+	for _, e := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(e), ";")
+		if q := strings.TrimSpace(params); q == "q=0" || q == "q=0.0" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "zstd":
+			return "zstd"
+		case "gzip":
+			gz = true
+		}
+	}
+	if gz {
+		return "gzip"
+	}
+	return ""
+}
+
+// zstdEncoder is safe for concurrent use with EncodeAll.
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// encode compresses data using the named encoding.
+func encode(enc string, data []byte) ([]byte, error) {
+	if enc == "zstd" {
+		return zstdEncoder.EncodeAll(data, nil), nil
+	}
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
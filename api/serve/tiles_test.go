@@ -0,0 +1,373 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/transparency-dev/tessera"
+)
+
+// fakeLogReader serves a checkpoint, a single tile of 10 hashes, and entry bundles with 10 entries.
+// Entry bundle 1 has expired. The checkpoint, which has size 10, is also the only archived checkpoint, and logs
+// with a checkpoint have a tile height of 4.
+type fakeLogReader struct {
+	tessera.LogReader
+	checkpoint []byte
+}
+
+func (f *fakeLogReader) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	if f.checkpoint == nil {
+		return nil, os.ErrNotExist
+	}
+	return f.checkpoint, nil
+}
+
+func (f *fakeLogReader) ReadArchivedCheckpoint(_ context.Context, size uint64) ([]byte, error) {
+	if f.checkpoint == nil || size != 10 {
+		return nil, os.ErrNotExist
+	}
+	return f.checkpoint, nil
+}
+
+func (f *fakeLogReader) ReadCheckpointArchiveIndex(_ context.Context) ([]byte, error) {
+	if f.checkpoint == nil {
+		return nil, os.ErrNotExist
+	}
+	return []byte("10\n"), nil
+}
+
+func (f *fakeLogReader) ReadTileHeight(_ context.Context) ([]byte, error) {
+	if f.checkpoint == nil {
+		return nil, os.ErrNotExist
+	}
+	return []byte("4"), nil
+}
+
+func (f *fakeLogReader) ReadTile(_ context.Context, level, index uint64, _ uint8) ([]byte, error) {
+	if level != 0 || index != 0 {
+		return nil, os.ErrNotExist
+	}
+	return bytes.Repeat([]byte{1}, 10*32), nil
+}
+
+func (f *fakeLogReader) ReadEntryBundle(_ context.Context, index uint64, _ uint8) ([]byte, error) {
+	if index == 1 {
+		return nil, fmt.Errorf("bundle %d: %w", index, tessera.ErrEntryBundleExpired)
+	}
+	return testBundle(10), nil
+}
+
+func testBundle(n int) []byte {
+	var b []byte
+	for i := range n {
+		b = append(b, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)).MarshalBundleData(uint64(i))...)
+	}
+	return b
+}
+
+func TestTilesHandlers(t *testing.T) {
+	cp := []byte("example.com/log\n10\nAAAA\n\n— example.com/log AAAA\n")
+	for _, test := range []struct {
+		name       string
+		checkpoint []byte
+		opts       TilesOptions
+		method     string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantBody   []byte
+		wantHeader map[string]string
+	}{
+		{
+			name:       "checkpoint",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			wantStatus: http.StatusOK,
+			wantBody:   cp,
+			wantHeader: map[string]string{"Cache-Control": DefaultCheckpointCacheControl},
+		}, {
+			name:       "no checkpoint",
+			path:       "/checkpoint",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "archived checkpoint",
+			checkpoint: cp,
+			path:       "/checkpoints/10",
+			wantStatus: http.StatusOK,
+			wantBody:   cp,
+			wantHeader: map[string]string{"Cache-Control": DefaultImmutableCacheControl},
+		}, {
+			name:       "missing archived checkpoint",
+			checkpoint: cp,
+			path:       "/checkpoints/5",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "malformed archived checkpoint path",
+			checkpoint: cp,
+			path:       "/checkpoints/x",
+			wantStatus: http.StatusBadRequest,
+		}, {
+			name:       "checkpoint archive index",
+			checkpoint: cp,
+			path:       "/checkpoints/index",
+			wantStatus: http.StatusOK,
+			wantBody:   []byte("10\n"),
+			wantHeader: map[string]string{"Cache-Control": DefaultCheckpointCacheControl},
+		}, {
+			name:       "tile height",
+			checkpoint: cp,
+			path:       "/tile-height",
+			wantStatus: http.StatusOK,
+			wantBody:   []byte("4"),
+			wantHeader: map[string]string{"Cache-Control": DefaultImmutableCacheControl},
+		}, {
+			name:       "default tile height",
+			path:       "/tile-height",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "partial tile",
+			path:       "/tile/0/000.p/5",
+			wantStatus: http.StatusOK,
+			wantBody:   bytes.Repeat([]byte{1}, 5*32),
+			wantHeader: map[string]string{"Cache-Control": DefaultImmutableCacheControl},
+		}, {
+			name:       "partial tile too small",
+			path:       "/tile/0/000.p/11",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "full tile not available",
+			path:       "/tile/0/000",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "missing tile",
+			path:       "/tile/1/000",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "malformed tile path",
+			path:       "/tile/0/x",
+			wantStatus: http.StatusBadRequest,
+		}, {
+			name:       "tiles are not compressed",
+			path:       "/tile/0/000.p/5",
+			header:     http.Header{"Accept-Encoding": {"gzip"}},
+			wantStatus: http.StatusOK,
+			wantBody:   bytes.Repeat([]byte{1}, 5*32),
+			wantHeader: map[string]string{"Content-Encoding": ""},
+		}, {
+			name:       "partial entry bundle",
+			path:       "/tile/entries/000.p/3",
+			wantStatus: http.StatusOK,
+			wantBody:   testBundle(3),
+		}, {
+			name:       "partial entry bundle too small",
+			path:       "/tile/entries/000.p/20",
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "expired entry bundle",
+			path:       "/tile/entries/001",
+			wantStatus: http.StatusGone,
+		}, {
+			name:       "gzip",
+			path:       "/tile/entries/000.p/10",
+			header:     http.Header{"Accept-Encoding": {"gzip, deflate"}},
+			wantStatus: http.StatusOK,
+			wantBody:   testBundle(10),
+			wantHeader: map[string]string{"Content-Encoding": "gzip", "Vary": "Accept-Encoding"},
+		}, {
+			name:       "zstd preferred",
+			path:       "/tile/entries/000.p/10",
+			header:     http.Header{"Accept-Encoding": {"gzip, zstd"}},
+			wantStatus: http.StatusOK,
+			wantBody:   testBundle(10),
+			wantHeader: map[string]string{"Content-Encoding": "zstd"},
+		}, {
+			name:       "compression disabled",
+			opts:       TilesOptions{DisableCompression: true},
+			path:       "/tile/entries/000.p/10",
+			header:     http.Header{"Accept-Encoding": {"gzip"}},
+			wantStatus: http.StatusOK,
+			wantBody:   testBundle(10),
+			wantHeader: map[string]string{"Content-Encoding": ""},
+		}, {
+			name:       "checkpoint etag",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"ETag": etag(cp)},
+		}, {
+			name:       "checkpoint not modified",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			header:     http.Header{"If-None-Match": {etag(cp)}},
+			wantStatus: http.StatusNotModified,
+		}, {
+			name:       "checkpoint modified",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			header:     http.Header{"If-None-Match": {etag([]byte("old checkpoint"))}},
+			wantStatus: http.StatusOK,
+			wantBody:   cp,
+		}, {
+			name:       "encoded checkpoint not modified",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			header:     http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {`"x", ` + etag(cp)[:33] + `-gzip"`}},
+			wantStatus: http.StatusNotModified,
+		}, {
+			name:       "identity etag does not match encoded checkpoint",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			header:     http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {etag(cp)}},
+			wantStatus: http.StatusOK,
+			wantBody:   cp,
+			wantHeader: map[string]string{"ETag": etag(cp)[:33] + `-gzip"`},
+		}, {
+			name:       "partial tile not modified",
+			path:       "/tile/0/000.p/5",
+			header:     http.Header{"If-None-Match": {etag(bytes.Repeat([]byte{1}, 5*32))}},
+			wantStatus: http.StatusNotModified,
+		}, {
+			name:       "range",
+			checkpoint: cp,
+			path:       "/checkpoint",
+			header:     http.Header{"Range": {"bytes=0-14"}, "Accept-Encoding": {"gzip"}},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   cp[:15],
+			wantHeader: map[string]string{"Content-Encoding": ""},
+		}, {
+			name:       "cors",
+			checkpoint: cp,
+			opts:       TilesOptions{CORSAllowOrigin: "*", CheckpointCacheControl: "max-age=5"},
+			path:       "/checkpoint",
+			wantStatus: http.StatusOK,
+			wantBody:   cp,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": "*", "Cache-Control": "max-age=5"},
+		}, {
+			name:       "cors preflight",
+			opts:       TilesOptions{CORSAllowOrigin: "https://example.com"},
+			method:     http.MethodOptions,
+			path:       "/tile/0/000",
+			wantStatus: http.StatusNoContent,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": "https://example.com", "Access-Control-Allow-Headers": "Range"},
+		}, {
+			name:       "checkpoint under prefix",
+			checkpoint: cp,
+			opts:       TilesOptions{Prefix: "/logs/a"},
+			path:       "/logs/a/checkpoint",
+			wantStatus: http.StatusOK,
+			wantBody:   cp,
+		}, {
+			name:       "entry bundle under prefix",
+			opts:       TilesOptions{Prefix: "logs/a/"},
+			path:       "/logs/a/tile/entries/000.p/3",
+			wantStatus: http.StatusOK,
+			wantBody:   testBundle(3),
+		}, {
+			name:       "outside prefix",
+			checkpoint: cp,
+			opts:       TilesOptions{Prefix: "/logs/a/"},
+			path:       "/checkpoint",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			RegisterTilesHandlers(mux, &fakeLogReader{checkpoint: test.checkpoint}, test.opts)
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, test.path, nil)
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != test.wantStatus {
+				t.Fatalf("got status %d (%q), want %d", rec.Code, rec.Body.Bytes(), test.wantStatus)
+			}
+			for k, want := range test.wantHeader {
+				if got := rec.Header().Get(k); got != want {
+					t.Errorf("got header %s %q, want %q", k, got, want)
+				}
+			}
+			if test.wantBody == nil {
+				return
+			}
+			body := decode(t, rec.Header().Get("Content-Encoding"), rec.Body.Bytes())
+			if !bytes.Equal(body, test.wantBody) {
+				t.Errorf("got body %q, want %q", body, test.wantBody)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, enc string, b []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	switch enc {
+	case "":
+		return b
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+		r = gr
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("zstd.NewReader: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		t.Fatalf("unexpected encoding %q", enc)
+	}
+	d, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	return d
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for _, test := range []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: ""},
+		{accept: "identity", want: ""},
+		{accept: "gzip", want: "gzip"},
+		{accept: "gzip;q=1.0, zstd", want: "zstd"},
+		{accept: "zstd;q=0, gzip", want: "gzip"},
+		{accept: "br, deflate", want: ""},
+	} {
+		if got := negotiateEncoding(test.accept); got != test.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// UIPath is the path under which UI.RegisterHandlers serves the UI.
+	UIPath = "/ui/"
+
+	// DefaultUISampleInterval is used by NewUI if UIOptions.SampleInterval is unset.
+	DefaultUISampleInterval = 30 * time.Second
+	// DefaultUIHistorySize is used by NewUI if UIOptions.HistorySize is unset.
+	DefaultUIHistorySize = 240
+
+	// maxUIEntries is the largest number of entries returned by a single request to the entries API.
+	maxUIEntries = layout.EntryBundleWidth
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// UIOptions holds optional settings for a UI.
+type UIOptions struct {
+	// DecodeBundle parses an entry bundle into the entries it contains. If nil, bundles are parsed as
+	// tlog-tiles entry bundles.
+	DecodeBundle func([]byte) ([][]byte, error)
+	// Hasher is the hasher used by the log's Merkle tree. If nil, the RFC 6962 SHA-256 hasher is used.
+	Hasher merkle.LogHasher
+	// SampleInterval is how often the size of the tree is recorded. If zero, DefaultUISampleInterval is used.
+	SampleInterval time.Duration
+	// HistorySize is the number of samples of the size of the tree which are retained. If zero,
+	// DefaultUIHistorySize is used.
+	HistorySize int
+}
+
+// UI serves a small read-only web UI for browsing a log: its current checkpoint and how its size has
+// changed over time, the entries it contains, and a checker for inclusion and consistency proofs.
+//
+// Only the published state of the log is shown, and the UI cannot be used to modify the log.
+type UI struct {
+	lr     tessera.LogReader
+	proofs *ProofServer
+	opts   UIOptions
+	files  http.Handler
+
+	mu      sync.Mutex
+	history []UISizeSample
+}
+
+// UISizeSample records the size of the tree at a point in time.
+type UISizeSample struct {
+	Time time.Time `json:"time"`
+	Size uint64    `json:"size"`
+}
+
+// UICheckpoint is the JSON response served by the UI's checkpoint API.
+type UICheckpoint struct {
+	// Checkpoint is the latest published checkpoint.
+	Checkpoint string `json:"checkpoint"`
+	Origin     string `json:"origin"`
+	Size       uint64 `json:"size"`
+	RootHash   []byte `json:"root_hash"`
+	// History holds samples of the size of the tree, oldest first.
+	History []UISizeSample `json:"history"`
+}
+
+// UIEntry is an entry returned by the UI's entries API.
+type UIEntry struct {
+	Index    uint64 `json:"index"`
+	Data     []byte `json:"data"`
+	LeafHash []byte `json:"leaf_hash"`
+	// Text is set to the entry's data if it's printable UTF-8.
+	Text string `json:"text,omitempty"`
+}
+
+// UIVerification is the JSON response served by the UI's proof checker.
+type UIVerification struct {
+	// Index is set if the inclusion of an entry was checked, and From if the consistency of a smaller
+	// tree with the tree of size TreeSize was checked.
+	Index    *uint64  `json:"index,omitempty"`
+	From     *uint64  `json:"from,omitempty"`
+	TreeSize uint64   `json:"tree_size"`
+	Proof    [][]byte `json:"proof"`
+	// Verified is true if the proof was successfully verified, and Error describes why it wasn't otherwise.
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewUI returns a UI for the log read by lr. The size of the tree is sampled in the background until ctx is done.
+func NewUI(ctx context.Context, lr tessera.LogReader, opts UIOptions) (*UI, error) {
+	if opts.DecodeBundle == nil {
+		opts.DecodeBundle = decodeEntryBundle
+	}
+	if opts.Hasher == nil {
+		opts.Hasher = rfc6962.DefaultHasher
+	}
+	if opts.SampleInterval == 0 {
+		opts.SampleInterval = DefaultUISampleInterval
+	}
+	if opts.HistorySize == 0 {
+		opts.HistorySize = DefaultUIHistorySize
+	}
+	ps, err := NewProofServer(lr, ProofServerOptions{Hasher: opts.Hasher})
+	if err != nil {
+		return nil, err
+	}
+	static, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load UI: %v", err)
+	}
+	u := &UI{lr: lr, proofs: ps, opts: opts, files: http.FileServerFS(static)}
+	go u.sample(ctx)
+	return u, nil
+}
+
+// RegisterHandlers registers the UI's handlers under UIPath with the provided mux.
+func (u *UI) RegisterHandlers(mux *http.ServeMux) {
+	files := http.StripPrefix(UIPath[:len(UIPath)-1], u.files)
+	mux.HandleFunc("GET "+UIPath, func(w http.ResponseWriter, r *http.Request) {
+		// The UI only loads its own scripts and styles, and only talks to its own APIs.
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		files.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("GET "+UIPath+"api/checkpoint", u.handleCheckpoint)
+	mux.HandleFunc("GET "+UIPath+"api/entries", u.handleEntries)
+	mux.HandleFunc("GET "+UIPath+"api/verify", u.handleVerify)
+}
+
+// sample periodically records the size of the tree.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (u *UI) sample(ctx context.Context) {
+	t := time.NewTicker(u.opts.SampleInterval)
+	defer t.Stop()
+	for {
+		if size, _, err := u.proofs.latest(ctx); err == nil {
+			u.record(time.Now(), size)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			klog.Warningf("UI: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// record adds a sample to the history, discarding the oldest if the history is full.
+func (u *UI) record(at time.Time, size uint64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.history = append(u.history, UISizeSample{Time: at, Size: size})
+	if n := len(u.history) - u.opts.HistorySize; n > 0 {
+		u.history = u.history[n:]
+	}
+}
+
+func (u *UI) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	cp, err := u.lr.ReadCheckpoint(r.Context())
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to read checkpoint: %w", err))
+		return
+	}
+	origin, size, hash, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to parse checkpoint: %v", err))
+		return
+	}
+	u.mu.Lock()
+	history := append([]UISizeSample{}, u.history...)
+	u.mu.Unlock()
+	writeJSON(w, UICheckpoint{Checkpoint: string(cp), Origin: origin, Size: size, RootHash: hash, History: history})
+}
+
+// handleEntries serves the entries from the start query parameter, up to the number in the count parameter.
+func (u *UI) handleEntries(w http.ResponseWriter, r *http.Request) {
+	start, err := uintParam(r, "start")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	count := uint64(16)
+	if r.URL.Query().Has("count") {
+		if count, err = uintParam(r, "count"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	size, _, err := u.proofs.latest(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if start >= size {
+		writeError(w, fmt.Errorf("%w: index %d is not in the published tree of size %d", errBadRequest, start, size))
+		return
+	}
+	end := min(start+min(count, maxUIEntries), size)
+	entries, err := u.entries(r.Context(), start, end, size)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+// entries returns the entries in [start, end) of the tree of the given size.
+func (u *UI) entries(ctx context.Context, start, end, size uint64) ([]UIEntry, error) {
+	r := make([]UIEntry, 0, end-start)
+	for i := start; i < end; {
+		idx := i / layout.EntryBundleWidth
+		raw, err := u.lr.ReadEntryBundle(ctx, idx, layout.PartialTileSize(0, idx, size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry bundle %d: %w", idx, err)
+		}
+		bundle, err := u.opts.DecodeBundle(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode entry bundle %d: %v", idx, err)
+		}
+		for ; i < end && i/layout.EntryBundleWidth == idx; i++ {
+			o := i % layout.EntryBundleWidth
+			if o >= uint64(len(bundle)) {
+				return nil, fmt.Errorf("entry bundle %d has %d entries, want at least %d", idx, len(bundle), o+1)
+			}
+			e := UIEntry{Index: i, Data: bundle[o], LeafHash: u.opts.Hasher.HashLeaf(bundle[o])}
+			if printable(e.Data) {
+				e.Text = string(e.Data)
+			}
+			r = append(r, e)
+		}
+	}
+	return r, nil
+}
+
+// handleVerify checks a proof against the checkpoint in the checkpoint query parameter, which defaults to the
+// latest published checkpoint. If the index parameter is set, the inclusion of that entry in the checkpoint's
+// tree is checked. Otherwise, the consistency of the checkpoint's tree with the latest published tree is checked.
+//
+// The signatures on the checkpoint are not verified; only the root hash which it contains is used.
+func (u *UI) handleVerify(w http.ResponseWriter, r *http.Request) {
+	latest, latestCP, err := u.proofs.latest(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	cp := latestCP
+	if c := r.URL.Query().Get("checkpoint"); c != "" {
+		cp = []byte(c)
+	}
+	_, size, root, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid checkpoint: %v", err), http.StatusBadRequest)
+		return
+	}
+	if size > latest {
+		http.Error(w, fmt.Sprintf("checkpoint size %d is larger than published size %d", size, latest), http.StatusBadRequest)
+		return
+	}
+
+	resp := UIVerification{}
+	if r.URL.Query().Has("index") {
+		index, err := uintParam(r, "index")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if index >= size {
+			http.Error(w, fmt.Sprintf("index %d is not in the tree of size %d", index, size), http.StatusBadRequest)
+			return
+		}
+		e, err := u.entries(r.Context(), index, index+1, latest)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		resp.Index, resp.TreeSize = &index, size
+		if resp.Proof, err = u.proofs.InclusionProof(r.Context(), index, size); err != nil {
+			writeError(w, err)
+			return
+		}
+		err = proof.VerifyInclusion(u.opts.Hasher, index, size, e[0].LeafHash, resp.Proof, root)
+		resp.Verified, resp.Error = err == nil, errString(err)
+	} else {
+		_, _, latestRoot, err := parse.CheckpointUnsafe(latestCP)
+		if err != nil {
+			writeError(w, fmt.Errorf("failed to parse checkpoint: %v", err))
+			return
+		}
+		resp.From, resp.TreeSize = &size, latest
+		if resp.Proof, err = u.proofs.ConsistencyProof(r.Context(), size, latest); err != nil {
+			writeError(w, err)
+			return
+		}
+		err = proof.VerifyConsistency(u.opts.Hasher, size, latest, resp.Proof, root, latestRoot)
+		resp.Verified, resp.Error = err == nil, errString(err)
+	}
+	writeJSON(w, resp)
+}
+
+func decodeEntryBundle(raw []byte) ([][]byte, error) {
+	b := api.EntryBundle{}
+	if err := b.UnmarshalText(raw); err != nil {
+		return nil, err
+	}
+	return b.Entries, nil
+}
+
+// printable returns true if b is UTF-8 text which can be displayed as is.
+func printable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
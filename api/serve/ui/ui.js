@@ -0,0 +1,131 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+"use strict";
+
+// refreshInterval is how often the checkpoint is reloaded, in milliseconds.
+const refreshInterval = 10000;
+
+const $ = (id) => document.getElementById(id);
+
+// hex returns the hex encoding of the base64 encoded bytes in b.
+function hex(b) {
+  return Array.from(atob(b), (c) => c.charCodeAt(0).toString(16).padStart(2, "0")).join("");
+}
+
+async function getJSON(path, params) {
+  const resp = await fetch(path + "?" + new URLSearchParams(params));
+  if (!resp.ok) {
+    throw new Error((await resp.text()).trim() || resp.statusText);
+  }
+  return resp.json();
+}
+
+async function refreshCheckpoint() {
+  try {
+    const cp = await getJSON("api/checkpoint", {});
+    $("origin").textContent = cp.origin;
+    $("size").textContent = cp.size;
+    $("checkpoint").textContent = cp.checkpoint;
+    $("refreshed").textContent = new Date().toLocaleTimeString();
+    drawHistory(cp.history || []);
+  } catch (e) {
+    $("checkpoint").textContent = "Failed to load checkpoint: " + e.message;
+  }
+}
+
+// drawHistory plots the tree size samples as a line.
+function drawHistory(samples) {
+  const svg = $("history");
+  svg.replaceChildren();
+  if (samples.length < 2) {
+    return;
+  }
+  const t0 = Date.parse(samples[0].time);
+  const t1 = Date.parse(samples[samples.length - 1].time);
+  const s0 = samples[0].size;
+  const s1 = Math.max(samples[samples.length - 1].size, s0 + 1);
+  const points = samples.map((s) => {
+    const x = ((Date.parse(s.time) - t0) / Math.max(t1 - t0, 1)) * 600;
+    const y = 145 - ((s.size - s0) / (s1 - s0)) * 140;
+    return x.toFixed(1) + "," + y.toFixed(1);
+  });
+  const line = document.createElementNS("http://www.w3.org/2000/svg", "polyline");
+  line.setAttribute("points", points.join(" "));
+  svg.appendChild(line);
+  $("history-from").textContent = new Date(t0).toLocaleTimeString() + ": " + s0;
+  $("history-to").textContent = new Date(t1).toLocaleTimeString() + ": " + samples[samples.length - 1].size;
+}
+
+async function showEntries() {
+  $("entries-error").textContent = "";
+  const body = $("entries");
+  try {
+    const entries = await getJSON("api/entries", { start: $("entries-start").value, count: $("entries-count").value });
+    body.replaceChildren(...entries.map((e) => {
+      const row = document.createElement("tr");
+      for (const v of [e.index, hex(e.leaf_hash), e.text !== undefined ? e.text : hex(e.data)]) {
+        const cell = document.createElement("td");
+        cell.textContent = v;
+        row.appendChild(cell);
+      }
+      return row;
+    }));
+  } catch (e) {
+    body.replaceChildren();
+    $("entries-error").textContent = e.message;
+  }
+}
+
+// pageEntries moves the entry browser by a page in the given direction.
+function pageEntries(dir) {
+  const count = Number($("entries-count").value);
+  $("entries-start").value = Math.max(0, Number($("entries-start").value) + dir * count);
+  showEntries();
+}
+
+async function verify() {
+  const result = $("verify-result");
+  const params = {};
+  if ($("verify-checkpoint").value.trim() !== "") {
+    // Checkpoints must end with a newline, which is easily lost when pasting.
+    params.checkpoint = $("verify-checkpoint").value.replace(/\n*$/, "\n");
+  }
+  if ($("verify-index").value !== "") {
+    params.index = $("verify-index").value;
+  }
+  result.className = "";
+  try {
+    const v = await getJSON("api/verify", params);
+    const what = v.index !== undefined ?
+      `Inclusion of entry ${v.index} in tree of size ${v.tree_size}` :
+      `Consistency of tree of size ${v.from} with tree of size ${v.tree_size}`;
+    result.className = v.verified ? "verified" : "failed";
+    result.textContent = `${what}: ${v.verified ? "verified" : "FAILED: " + v.error}\n\nProof:\n` +
+      (v.proof || []).map(hex).join("\n");
+  } catch (e) {
+    result.className = "failed";
+    result.textContent = e.message;
+  }
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("entries-form").addEventListener("submit", (e) => { e.preventDefault(); showEntries(); });
+  $("entries-prev").addEventListener("click", () => pageEntries(-1));
+  $("entries-next").addEventListener("click", () => pageEntries(1));
+  $("verify-form").addEventListener("submit", (e) => { e.preventDefault(); verify(); });
+  refreshCheckpoint();
+  setInterval(refreshCheckpoint, refreshInterval);
+  showEntries();
+});
@@ -0,0 +1,168 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+func TestUI(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(1, time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 100*time.Millisecond)
+	add := func(n int) []byte {
+		t.Helper()
+		var futures []tessera.IndexFuture
+		for range n {
+			futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", len(futures)))))
+		}
+		_, cp, err := awaiter.AwaitAll(ctx, futures)
+		if err != nil {
+			t.Fatalf("AwaitAll: %v", err)
+		}
+		return cp
+	}
+	oldCP := add(10)
+	add(300)
+
+	ui, err := NewUI(ctx, tl.LogReader, UIOptions{HistorySize: 2})
+	if err != nil {
+		t.Fatalf("NewUI: %v", err)
+	}
+	mux := http.NewServeMux()
+	ui.RegisterHandlers(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	get := func(t *testing.T, path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode == http.StatusOK && v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	t.Run("static", func(t *testing.T) {
+		for _, p := range []string{"/ui/", "/ui/ui.js", "/ui/ui.css"} {
+			if code := get(t, p, nil); code != http.StatusOK {
+				t.Errorf("GET %s = %d, want %d", p, code, http.StatusOK)
+			}
+		}
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		cp := UICheckpoint{}
+		if code := get(t, "/ui/api/checkpoint", &cp); code != http.StatusOK {
+			t.Fatalf("GET checkpoint = %d", code)
+		}
+		if cp.Size < 310 || !strings.HasPrefix(cp.Checkpoint, cp.Origin+"\n") {
+			t.Errorf("GET checkpoint = %+v, want size at least 310", cp)
+		}
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		for _, test := range []struct {
+			start, count uint64
+			wantFirst    uint64
+			wantLen      int
+			wantCode     int
+		}{
+			{start: 0, count: 4, wantLen: 4, wantCode: http.StatusOK},
+			// Spans two entry bundles.
+			{start: 250, count: 10, wantFirst: 250, wantLen: 10, wantCode: http.StatusOK},
+			{start: 300, count: 1000, wantFirst: 300, wantLen: 10, wantCode: http.StatusOK},
+			{start: 310, count: 1, wantCode: http.StatusBadRequest},
+		} {
+			var got []UIEntry
+			code := get(t, fmt.Sprintf("/ui/api/entries?start=%d&count=%d", test.start, test.count), &got)
+			if code != test.wantCode {
+				t.Errorf("GET entries from %d = %d, want %d", test.start, code, test.wantCode)
+				continue
+			}
+			if code != http.StatusOK {
+				continue
+			}
+			if len(got) != test.wantLen || got[0].Index != test.wantFirst {
+				t.Errorf("GET entries from %d: got %d entries from %d, want %d from %d", test.start, len(got), got[0].Index, test.wantLen, test.wantFirst)
+			}
+			for _, e := range got {
+				if e.Text == "" || string(e.Data) != e.Text {
+					t.Errorf("Entry %d: got text %q for data %q", e.Index, e.Text, e.Data)
+				}
+			}
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		for _, test := range []struct {
+			name   string
+			params url.Values
+			want   bool
+		}{
+			{name: "inclusion in latest", params: url.Values{"index": {"260"}}, want: true},
+			{name: "inclusion in old checkpoint", params: url.Values{"index": {"3"}, "checkpoint": {string(oldCP)}}, want: true},
+			{name: "consistency", params: url.Values{"checkpoint": {string(oldCP)}}, want: true},
+			{name: "wrong root", params: url.Values{"index": {"3"}, "checkpoint": {strings.Replace(string(oldCP), "\n10\n", "\n11\n", 1)}}, want: false},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				v := UIVerification{}
+				if code := get(t, "/ui/api/verify?"+test.params.Encode(), &v); code != http.StatusOK {
+					t.Fatalf("GET verify = %d", code)
+				}
+				if v.Verified != test.want {
+					t.Errorf("GET verify = %+v, want verified=%t", v, test.want)
+				}
+			})
+		}
+		if code := get(t, "/ui/api/verify?index=10&checkpoint="+url.QueryEscape(string(oldCP)), nil); code != http.StatusBadRequest {
+			t.Errorf("GET verify for index outside tree = %d, want %d", code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestUIHistory(t *testing.T) {
+	ui := &UI{opts: UIOptions{HistorySize: 2}}
+	now := time.Unix(1000, 0)
+	for i := range uint64(3) {
+		ui.record(now.Add(time.Duration(i)*time.Second), i)
+	}
+	if len(ui.history) != 2 || ui.history[0].Size != 1 || ui.history[1].Size != 2 {
+		t.Errorf("history = %+v, want the two most recent samples", ui.history)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
+	"strconv"
 
 	"github.com/transparency-dev/tessera/api/layout"
 )
@@ -91,3 +92,38 @@ func (t *EntryBundle) UnmarshalText(raw []byte) error {
 	t.Entries = nodes
 	return nil
 }
+
+// CheckpointArchiveIndex lists the tree sizes of the checkpoints archived by a log, which are available at
+// the paths given by layout.ArchivedCheckpointPath.
+type CheckpointArchiveIndex struct {
+	// Sizes holds the tree sizes of the archived checkpoints, in ascending order.
+	Sizes []uint64
+}
+
+// MarshalText implements encoding/TextMarshaler and writes out the index as one decimal tree size per line.
+func (c CheckpointArchiveIndex) MarshalText() ([]byte, error) {
+	b := bytes.Buffer{}
+	for _, s := range c.Sizes {
+		if _, err := fmt.Fprintf(&b, "%d\n", s); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalText implements encoding/TextUnmarshaler and reads an index written by MarshalText.
+func (c *CheckpointArchiveIndex) UnmarshalText(raw []byte) error {
+	sizes := []uint64{}
+	for i, l := range bytes.Fields(raw) {
+		s, err := strconv.ParseUint(string(l), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid size on line %d: %v", i+1, err)
+		}
+		if len(sizes) > 0 && s <= sizes[len(sizes)-1] {
+			return fmt.Errorf("size %d on line %d is not larger than the previous size %d", s, i+1, sizes[len(sizes)-1])
+		}
+		sizes = append(sizes, s)
+	}
+	c.Sizes = sizes
+	return nil
+}
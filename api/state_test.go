@@ -146,6 +146,58 @@ func TestLeafBundle_UnmarshalText(t *testing.T) {
 	}
 }
 
+func TestCheckpointArchiveIndex_UnmarshalText(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		input   string
+		want    []uint64
+		wantErr bool
+	}{
+		{
+			desc:  "empty",
+			input: "",
+			want:  []uint64{},
+		}, {
+			desc:  "sizes",
+			input: "1\n10\n256\n",
+			want:  []uint64{1, 10, 256},
+		}, {
+			desc:    "not a number",
+			input:   "1\nten\n",
+			wantErr: true,
+		}, {
+			desc:    "not ascending",
+			input:   "10\n1\n",
+			wantErr: true,
+		}, {
+			desc:    "duplicate",
+			input:   "10\n10\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			idx := api.CheckpointArchiveIndex{}
+			err := idx.UnmarshalText([]byte(test.input))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("wantErr: %t, got %v", test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if d := cmp.Diff(test.want, idx.Sizes); d != "" {
+				t.Errorf("Sizes mismatch (-want +got):\n%s", d)
+			}
+			raw, err := idx.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText() = %v", err)
+			}
+			if got := string(raw); got != test.input {
+				t.Errorf("MarshalText() = %q, want %q", got, test.input)
+			}
+		})
+	}
+}
+
 func BenchmarkLeafBundle_UnmarshalText(b *testing.B) {
 	bs := bytes.Buffer{}
 	for i := range 222 {
@@ -0,0 +1,22 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tilespb contains protobuf messages equivalent to the hash tiles and entry bundles of the
+// tlog-tiles API, for tools which would rather consume tile data as protobufs than parse the formats
+// defined by the spec.
+//
+// Conversions to and from these messages are provided by api.HashTile and api.EntryBundle.
+package tilespb
+
+//go:generate protoc -I=../.. --go_out=../.. --go_opt=paths=source_relative api/tilespb/tiles.proto
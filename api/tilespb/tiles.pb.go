@@ -0,0 +1,173 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: api/tilespb/tiles.proto
+
+package tilespb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// HashTile is a tile of hashes within the log's Merkle tree.
+type HashTile struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The hashes of the non-ephemeral nodes in the tile, from left to right.
+	Nodes         [][]byte `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashTile) Reset() {
+	*x = HashTile{}
+	mi := &file_api_tilespb_tiles_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashTile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashTile) ProtoMessage() {}
+
+func (x *HashTile) ProtoReflect() protoreflect.Message {
+	mi := &file_api_tilespb_tiles_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashTile.ProtoReflect.Descriptor instead.
+func (*HashTile) Descriptor() ([]byte, []int) {
+	return file_api_tilespb_tiles_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HashTile) GetNodes() [][]byte {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+// EntryBundle is a sequence of entries in the log, corresponding to a leaf HashTile.
+type EntryBundle struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The entries, in the order in which they appear in the log.
+	Entries       [][]byte `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntryBundle) Reset() {
+	*x = EntryBundle{}
+	mi := &file_api_tilespb_tiles_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntryBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntryBundle) ProtoMessage() {}
+
+func (x *EntryBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_api_tilespb_tiles_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntryBundle.ProtoReflect.Descriptor instead.
+func (*EntryBundle) Descriptor() ([]byte, []int) {
+	return file_api_tilespb_tiles_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EntryBundle) GetEntries() [][]byte {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+var File_api_tilespb_tiles_proto protoreflect.FileDescriptor
+
+const file_api_tilespb_tiles_proto_rawDesc = "" +
+	"\n" +
+	"\x17api/tilespb/tiles.proto\x12\x10tessera.tiles.v1\" \n" +
+	"\bHashTile\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\fR\x05nodes\"'\n" +
+	"\vEntryBundle\x12\x18\n" +
+	"\aentries\x18\x01 \x03(\fR\aentriesB1Z/github.com/transparency-dev/tessera/api/tilespbb\x06proto3"
+
+var (
+	file_api_tilespb_tiles_proto_rawDescOnce sync.Once
+	file_api_tilespb_tiles_proto_rawDescData []byte
+)
+
+func file_api_tilespb_tiles_proto_rawDescGZIP() []byte {
+	file_api_tilespb_tiles_proto_rawDescOnce.Do(func() {
+		file_api_tilespb_tiles_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_tilespb_tiles_proto_rawDesc), len(file_api_tilespb_tiles_proto_rawDesc)))
+	})
+	return file_api_tilespb_tiles_proto_rawDescData
+}
+
+var file_api_tilespb_tiles_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_api_tilespb_tiles_proto_goTypes = []any{
+	(*HashTile)(nil),    // 0: tessera.tiles.v1.HashTile
+	(*EntryBundle)(nil), // 1: tessera.tiles.v1.EntryBundle
+}
+var file_api_tilespb_tiles_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_api_tilespb_tiles_proto_init() }
+func file_api_tilespb_tiles_proto_init() {
+	if File_api_tilespb_tiles_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_tilespb_tiles_proto_rawDesc), len(file_api_tilespb_tiles_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_api_tilespb_tiles_proto_goTypes,
+		DependencyIndexes: file_api_tilespb_tiles_proto_depIdxs,
+		MessageInfos:      file_api_tilespb_tiles_proto_msgTypes,
+	}.Build()
+	File_api_tilespb_tiles_proto = out.File
+	file_api_tilespb_tiles_proto_goTypes = nil
+	file_api_tilespb_tiles_proto_depIdxs = nil
+}
@@ -16,6 +16,7 @@ package tessera
 
 import (
 	"context"
+	"crypto"
 	"errors"
 	"fmt"
 	"net/http"
@@ -25,9 +26,8 @@ import (
 	"sync/atomic"
 	"time"
 
-	f_log "github.com/transparency-dev/formats/log"
-	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/future"
 	"github.com/transparency-dev/tessera/internal/otel"
 	"github.com/transparency-dev/tessera/internal/parse"
 	"github.com/transparency-dev/tessera/internal/witness"
@@ -61,7 +61,28 @@ var (
 	appenderWitnessedSize    metric.Int64Gauge
 	appenderWitnessRequests  metric.Int64Counter
 
+	appenderWatchdogDivergences           metric.Int64Counter
+	appenderWebhookFailures               metric.Int64Counter
+	appenderCheckpointDestinationFailures metric.Int64Counter
+	appenderTeeDivergences                metric.Int64Counter
+	appenderWALReplays                    metric.Int64Counter
+
+	appenderIntegrationBacklog metric.Int64ObservableGauge
+	appenderIntegrationRate    metric.Float64ObservableGauge
+	appenderIntegrationETA     metric.Float64ObservableGauge
+
+	dedupeLookups       metric.Int64ObservableCounter
+	dedupeHits          metric.Int64ObservableCounter
+	dedupeLookupLatency metric.Float64ObservableGauge
+	dedupeHitLatency    metric.Float64ObservableGauge
+	dedupeIndexSize     metric.Int64ObservableGauge
+
+	// lastCheckpointCreated is the time, in Unix nanoseconds, at which the most recent checkpoint was
+	// created by a CheckpointPublisher, or zero if none has been.
+	lastCheckpointCreated atomic.Int64
+
 	followerEntriesProcessed metric.Int64Gauge
+	followerErrors           metric.Int64Counter
 	followerLag              metric.Int64Gauge
 
 	// Custom histogram buckets as we're still interested in details in the 1-2s area.
@@ -143,6 +164,14 @@ func init() {
 		klog.Exitf("Failed to create followerEntriesProcessed metric: %v", err)
 	}
 
+	followerErrors, err = meter.Int64Counter(
+		"tessera.follower.errors",
+		metric.WithDescription("Number of failed attempts by a follower to process entries"),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		klog.Exitf("Failed to create followerErrors metric: %v", err)
+	}
+
 	followerLag, err = meter.Int64Gauge(
 		"tessera.follower.lag",
 		metric.WithDescription("Number of unprocessed entries in the current integrated tree"),
@@ -159,6 +188,123 @@ func init() {
 		klog.Exitf("Failed to create appenderWitnessRequests metric: %v", err)
 	}
 
+	if _, err = meter.Int64ObservableGauge(
+		"tessera.appender.checkpoint.age",
+		metric.WithDescription("Time since the latest checkpoint was created"),
+		metric.WithUnit("ms"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			if t := lastCheckpointCreated.Load(); t != 0 {
+				o.Observe(time.Since(time.Unix(0, t)).Milliseconds())
+			}
+			return nil
+		})); err != nil {
+		klog.Exitf("Failed to create appenderCheckpointAge metric: %v", err)
+	}
+
+	appenderIntegrationBacklog, err = meter.Int64ObservableGauge(
+		"tessera.appender.integration.backlog",
+		metric.WithDescription("Number of entries which have been sequenced but not yet integrated, as seen by an IntegrationMonitor"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderIntegrationBacklog metric: %v", err)
+	}
+
+	appenderIntegrationRate, err = meter.Float64ObservableGauge(
+		"tessera.appender.integration.rate",
+		metric.WithDescription("Rate at which entries have recently been integrated, as seen by an IntegrationMonitor"),
+		metric.WithUnit("{entry}/s"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderIntegrationRate metric: %v", err)
+	}
+
+	appenderIntegrationETA, err = meter.Float64ObservableGauge(
+		"tessera.appender.integration.eta",
+		metric.WithDescription("Estimated time to integrate the sequencing backlog at the recent integration rate, as seen by an IntegrationMonitor"),
+		metric.WithUnit("s"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderIntegrationETA metric: %v", err)
+	}
+
+	dedupeLookups, err = meter.Int64ObservableCounter(
+		"tessera.dedupe.lookups",
+		metric.WithDescription("Number of entries looked up by an antispam or dedupe implementation"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create dedupeLookups metric: %v", err)
+	}
+
+	dedupeHits, err = meter.Int64ObservableCounter(
+		"tessera.dedupe.hits",
+		metric.WithDescription("Number of lookups by an antispam or dedupe implementation which found a duplicate"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create dedupeHits metric: %v", err)
+	}
+
+	dedupeLookupLatency, err = meter.Float64ObservableGauge(
+		"tessera.dedupe.lookup.latency",
+		metric.WithDescription("Mean time taken by lookups made by an antispam or dedupe implementation"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		klog.Exitf("Failed to create dedupeLookupLatency metric: %v", err)
+	}
+
+	dedupeHitLatency, err = meter.Float64ObservableGauge(
+		"tessera.dedupe.hit.latency",
+		metric.WithDescription("Mean time taken by lookups made by an antispam or dedupe implementation which found a duplicate"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		klog.Exitf("Failed to create dedupeHitLatency metric: %v", err)
+	}
+
+	dedupeIndexSize, err = meter.Int64ObservableGauge(
+		"tessera.dedupe.index.size",
+		metric.WithDescription("Number of entries held by the index of an antispam or dedupe implementation"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create dedupeIndexSize metric: %v", err)
+	}
+
+	appenderWatchdogDivergences, err = meter.Int64Counter(
+		"tessera.appender.watchdog.divergences",
+		metric.WithDescription("Number of divergences found by the consistency watchdog in the log's published checkpoints"),
+		metric.WithUnit("{divergence}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderWatchdogDivergences metric: %v", err)
+	}
+
+	appenderWebhookFailures, err = meter.Int64Counter(
+		"tessera.appender.webhook.failures",
+		metric.WithDescription("Number of notifications of published checkpoints which could not be delivered to webhooks"),
+		metric.WithUnit("{notification}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderWebhookFailures metric: %v", err)
+	}
+
+	appenderCheckpointDestinationFailures, err = meter.Int64Counter(
+		"tessera.appender.checkpoint.destination.failures",
+		metric.WithDescription("Number of checkpoints which could not be published to a destination configured with WithCheckpointDestinations"),
+		metric.WithUnit("{checkpoint}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderCheckpointDestinationFailures metric: %v", err)
+	}
+
+	appenderTeeDivergences, err = meter.Int64Counter(
+		"tessera.appender.tee.divergences",
+		metric.WithDescription("Number of differences found by a TeeAppender between the primary and shadow logs"),
+		metric.WithUnit("{divergence}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderTeeDivergences metric: %v", err)
+	}
+
+	appenderWALReplays, err = meter.Int64Counter(
+		"tessera.appender.wal.replays",
+		metric.WithDescription("Number of entries replayed from the submission WAL configured with WithSubmissionWAL"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		klog.Exitf("Failed to create appenderWALReplays metric: %v", err)
+	}
+
 }
 
 // AddFn adds a new entry to be sequenced by the storage implementation.
@@ -175,6 +321,10 @@ func init() {
 // Once the future resolves and returns an index, the entry can be considered to have been
 // durably sequenced and will be preserved even in the event that the process terminates.
 //
+// If ctx is done before the entry has been handed to the storage for sequencing, the entry
+// will be dropped and the future will resolve to ctx's error. Once sequencing of the entry has
+// begun it can no longer be abandoned in this way.
+//
 // Once an entry is sequenced, the storage implementation MUST integrate it into the tree soon
 // (how long this is expected to take is left unspecified, but as a guideline it should happen
 // within single digit seconds). Until the entry is integrated and published, clients of the log
@@ -184,6 +334,17 @@ func init() {
 // to return an inclusion proof) may use the PublicationAwaiter to wrap the call to this method.
 type AddFn func(ctx context.Context, entry *Entry) IndexFuture
 
+// AddBatchFn adds a number of new entries to be sequenced by the storage implementation.
+//
+// This behaves as though AddFn were called for each of the entries in turn, returning a slice
+// containing the IndexFuture for each entry in the same order as the entries were provided, with
+// the same guarantees and caveats as described on AddFn applying to each of the returned futures.
+//
+// Entries in a batch are submitted to the storage implementation together, which allows
+// personalities with many entries to hand (e.g. bulk importers) to reduce the per-entry
+// overhead of queueing and sequencing.
+type AddBatchFn func(ctx context.Context, entries []*Entry) []IndexFuture
+
 // IndexFuture is the signature of a function which can return an assigned index or error.
 //
 // Implementations of this func are likely to be "futures", or a promise to return this data at
@@ -203,6 +364,35 @@ type Index struct {
 // such as a Shutdown method for #341.
 type Appender struct {
 	Add AddFn
+	// AddBatch adds a batch of entries to the log.
+	//
+	// Drivers may leave this unset, in which case NewAppender will provide an implementation which
+	// calls the driver's Add function once per entry.
+	AddBatch AddBatchFn
+
+	// lifecycle is set by NewAppender, and manages the lifecycle state of the log.
+	lifecycle *logLifecycle
+	// audit is set by NewAppender, and records administrative operations.
+	audit *auditor
+	// signers is set by NewAppender if WithCheckpointSigner was used, and allows the signing key to be rotated.
+	signers *checkpointSigners
+	// followers is set by NewAppender, and holds the followers which it started.
+	followers []Follower
+	// dedupers is set by NewAppender, and holds the antispam and dedupe implementations which report statistics.
+	dedupers []namedDedupe
+	// checkpointInterval, qps, and unintegrated are set by NewAppender, and allow the checkpoint interval
+	// and pushback limits to be changed while the log is running.
+	checkpointInterval *atomic.Int64
+	qps                *qpsLimiter
+	unintegrated       *unintegratedLimiter
+	// witnesses is set by NewAppender, and allows the witness policy to be changed while the log is running.
+	witnesses *atomic.Pointer[witnessPolicy]
+
+	// GarbageCollect runs a single pass of the storage implementation's garbage collector, removing
+	// partial tiles and entry bundles which are no longer needed, without waiting for the next scheduled run.
+	//
+	// Drivers which don't garbage collect leave this unset.
+	GarbageCollect func(ctx context.Context) error
 }
 
 // NewAppender returns an Appender, which allows a personality to incrementally append new
@@ -239,20 +429,104 @@ func NewAppender(ctx context.Context, d Driver, opts *AppendOptions) (*Appender,
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to init appender lifecycle: %v", err)
 	}
+	if _, err := opts.checkpointArchive(r); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := opts.checkTileHeightReader(r); err != nil {
+		return nil, nil, nil, err
+	}
+	expunger, ok := r.(entryBundleExpunger)
+	if opts.retention != nil && !ok {
+		return nil, nil, nil, fmt.Errorf("WithRetention is not supported by LogReader %T", r)
+	}
+	driverAdd, driverAddBatch := a.Add, a.AddBatch
+	if driverAddBatch == nil {
+		driverAddBatch = func(ctx context.Context, entries []*Entry) []IndexFuture {
+			r := make([]IndexFuture, 0, len(entries))
+			for _, e := range entries {
+				r = append(r, driverAdd(ctx, e))
+			}
+			return r
+		}
+	}
+	// The innermost Add collects entries which are part of a call to AddBatch, once they've made it
+	// through the decorators, so that they can be passed on to the driver together.
+	a.Add = func(ctx context.Context, entry *Entry) IndexFuture {
+		if c, ok := ctx.Value(batchCollectorKey{}).(*batchCollector); ok {
+			return c.add(ctx, entry, driverAdd)
+		}
+		return driverAdd(ctx, entry)
+	}
+	if opts.preordered {
+		a.Add = newPreorderedChecker(r.NextIndex).decorator(a.Add)
+	}
+	lifecycle, err := newLogLifecycle(ctx, d, r, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	go lifecycle.poll(ctx)
+	// Limits are applied inside the other decorators so that duplicate entries are not subject to them.
+	a.Add = lifecycle.decorator(a.Add)
+	// The limits are always installed, even if disabled, so that they can be enabled while the log is running.
+	u := &unintegratedLimiter{max: opts.maxUnintegrated}
+	go u.poll(ctx, r)
+	a.Add = u.decorator(a.Add)
+	q := &qpsLimiter{}
+	q.set(opts.maxAddQPS)
+	a.Add = q.decorator(a.Add)
 	for i := len(opts.addDecorators) - 1; i >= 0; i-- {
 		a.Add = opts.addDecorators[i](a.Add)
 	}
+	if d := opts.leafHashDecorator(); d != nil {
+		a.Add = d(a.Add)
+	}
+	if d := opts.identityDecorator(); d != nil {
+		a.Add = d(a.Add)
+	}
+	if v := opts.validationDecorator(); v != nil {
+		a.Add = v(a.Add)
+	}
 	sd := &integrationStats{}
+	if opts.idempotencyWindow > 0 {
+		k, err := newIdempotencyKeys(ctx, d, opts.idempotencyWindow)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		a.Add = k.decorator(a.Add)
+		go k.expire(ctx)
+	}
 	a.Add = sd.statsDecorator(a.Add)
 	for _, f := range opts.followers {
 		go f.Follow(ctx, r)
 		go followerStats(ctx, f, r.IntegratedSize)
 	}
 	go sd.updateStats(ctx, r)
+	go opts.watchLog(ctx, r)
+	if opts.watchdog != nil {
+		go newWatchdog(*opts.watchdog, r, opts.Hasher(), opts.Tiling()).run(ctx)
+	}
+	if m := opts.integrationMonitor; m != nil {
+		go m.run(ctx, r)
+	}
+	if opts.retention != nil {
+		ret := &retention{policy: *opts.retention, reader: r, expunger: expunger, state: lifecycle.get, now: time.Now, audit: opts.auditLog, bundleWidth: opts.Tiling().Width()}
+		go ret.run(ctx)
+	}
+	if opts.duplicateErrors {
+		a.Add = duplicateErrorDecorator(a.Add)
+	}
 	t := terminator{
 		delegate:       a.Add,
 		readCheckpoint: r.ReadCheckpoint,
 	}
+	tAdd := t.Add
+	var wal *submissionWAL
+	if opts.submissionWALDir != "" {
+		if wal, err = openSubmissionWAL(opts.submissionWALDir); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to open submission WAL: %v", err)
+		}
+		tAdd = wal.decorator(tAdd)
+	}
 	// TODO(mhutchinson): move this into the decorators
 	a.Add = func(ctx context.Context, entry *Entry) IndexFuture {
 		ctx, span := tracer.Start(ctx, "tessera.Appender.Add")
@@ -264,11 +538,100 @@ func NewAppender(ctx context.Context, d Driver, opts *AppendOptions) (*Appender,
 		//		 Currently this is the outermost wrapping of Add so we do the memoization
 		//		 here, if this changes, ensure that we move the memoization call so that
 		//		 this remains true.
-		return memoizeFuture(t.Add(ctx, entry))
+		return memoizeFuture(tAdd(ctx, entry))
+	}
+	add := a.Add
+	a.AddBatch = func(ctx context.Context, entries []*Entry) []IndexFuture {
+		ctx, span := tracer.Start(ctx, "tessera.Appender.AddBatch")
+		defer span.End()
+
+		c := &batchCollector{}
+		bctx := context.WithValue(ctx, batchCollectorKey{}, c)
+		r := make([]IndexFuture, 0, len(entries))
+		for _, e := range entries {
+			r = append(r, add(bctx, e))
+		}
+		if wal != nil {
+			if err := wal.sync(); err != nil {
+				c.submit(ctx, func(_ context.Context, es []*Entry) []IndexFuture {
+					fs := make([]IndexFuture, len(es))
+					for i := range fs {
+						fs[i] = func() (Index, error) { return Index{}, err }
+					}
+					return fs
+				})
+				return r
+			}
+		}
+		c.submit(ctx, driverAddBatch)
+		return r
+	}
+	a.lifecycle = lifecycle
+	a.audit = &auditor{log: opts.auditLog, now: time.Now}
+	a.signers = opts.checkpointSigners
+	a.followers = opts.followers
+	a.dedupers = opts.dedupers
+	go a.reportDedupeStats(ctx)
+	a.checkpointInterval = opts.checkpointInterval
+	a.witnesses = opts.witnesses
+	a.qps, a.unintegrated = q, u
+	if opts.auditInTree {
+		a.audit.add = a.Add
+	}
+	if wal != nil {
+		// Entries from before a restart are added before Add is made available to the caller.
+		wal.replayEntries(ctx, t.Add)
 	}
 	return a, t.Shutdown, r, nil
 }
 
+// batchCollectorKey is the context key used to find the batchCollector for an in-progress call to AddBatch.
+type batchCollectorKey struct{}
+
+// batchCollector gathers the entries from a call to AddBatch which have made it through the Add decorators
+// (i.e. were not deduplicated, etc.) so they can be submitted to the driver as a single batch.
+type batchCollector struct {
+	mu        sync.Mutex
+	submitted bool
+	entries   []*Entry
+	set       []func(IndexFuture, error)
+}
+
+// add records the entry as part of the batch, and returns a future which will resolve once the batch has been
+// submitted and the driver has resolved the index for the entry.
+//
+// If the batch has already been submitted (e.g. because a decorator added the entry asynchronously), the entry
+// is instead passed directly to the provided delegate.
+func (c *batchCollector) add(ctx context.Context, e *Entry, delegate AddFn) IndexFuture {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.submitted {
+		return delegate(ctx, e)
+	}
+	f, set := future.NewFutureErr[IndexFuture]()
+	c.entries = append(c.entries, e)
+	c.set = append(c.set, set)
+	return func() (Index, error) {
+		df, _ := f.Get()
+		return df()
+	}
+}
+
+// submit passes all collected entries to the provided batch function, and makes the returned futures available to
+// the futures previously returned by add.
+func (c *batchCollector) submit(ctx context.Context, batch AddBatchFn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.submitted = true
+	if len(c.entries) == 0 {
+		return
+	}
+	fs := batch(ctx, c.entries)
+	for i, set := range c.set {
+		set(fs[i], nil)
+	}
+}
+
 // memoizeFuture wraps an AddFn delegate with logic to ensure that the delegate is called at most
 // once.
 func memoizeFuture(delegate IndexFuture) IndexFuture {
@@ -502,24 +865,97 @@ func (t *terminator) Shutdown(ctx context.Context) error {
 }
 
 func (o *AppendOptions) WithAntispam(inMemEntries uint, as Antispam) *AppendOptions {
-	o.addDecorators = append(o.addDecorators, newInMemoryDedupe(inMemEntries))
+	m := &inMemoryDedupes{size: inMemEntries}
+	o.addDecorators = append(o.addDecorators, m.decorator)
+	o.dedupers = append(o.dedupers, namedDedupe{name: "in_memory", stats: m})
 	if as != nil {
 		o.addDecorators = append(o.addDecorators, as.Decorator())
-		o.followers = append(o.followers, as.Follower(o.bundleIDHasher))
+		if s, ok := as.(dedupeStatser); ok {
+			o.dedupers = append(o.dedupers, namedDedupe{name: "antispam", stats: s})
+		}
+		// Resolve the hasher lazily so that options may be specified in any order.
+		o.followers = append(o.followers, as.Follower(func(b []byte) ([][]byte, error) { return o.bundleIDHasher()(b) }))
 	}
 	return o
 }
 
+// WithDuplicateErrors configures the Appender to return an error wrapping ErrDuplicate, along with the Index
+// previously assigned to the identical entry, from the IndexFuture of any entry found to be a duplicate.
+//
+// This is useful for personalities which prefer to handle all outcomes other than the addition of a new
+// entry to the log by inspecting the returned error.
+func (o *AppendOptions) WithDuplicateErrors() *AppendOptions {
+	o.duplicateErrors = true
+	return o
+}
+
+// duplicateErrorDecorator returns an AddFn which turns duplicate results from the delegate into errors.
+func duplicateErrorDecorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, entry *Entry) IndexFuture {
+		f := delegate(ctx, entry)
+		return func() (Index, error) {
+			i, err := f()
+			if err == nil && i.IsDup {
+				return i, fmt.Errorf("%w: previously assigned index %d", ErrDuplicate, i.Index)
+			}
+			return i, err
+		}
+	}
+}
+
+// WithIdentityHash configures the function used to calculate the identity hash of entries, which is what
+// antispam uses to determine whether an entry is a duplicate of one already in the log.
+//
+// By default, the identity is the SHA256 hash of the entry data, or, when the CT layout is used, of the
+// (pre)certificate. Personalities which need a different notion of what counts as a duplicate (e.g. a
+// sumdb-style log where only the module@version should be considered) may provide a function here
+// which extracts and hashes the relevant parts of that data.
+//
+// The function must be deterministic, and must not be changed once the log has entries in it, otherwise
+// previously seen entries will no longer be recognised as duplicates.
+func (o *AppendOptions) WithIdentityHash(f func([]byte) []byte) *AppendOptions {
+	o.identityHash = f
+	return o
+}
+
+// identityDecorator returns a decorator which recalculates entry identities using the configured identity hash,
+// or nil if the default identity should be used.
+//
+// This must be applied outside of any decorators which might rely on entry identities (e.g. antispam).
+func (o *AppendOptions) identityDecorator() func(AddFn) AddFn {
+	h := o.identityHash
+	if h == nil {
+		return nil
+	}
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			entry.internal.Identity = h(entry.identityPreimage)
+			return delegate(ctx, entry)
+		}
+	}
+}
+
+// bundleIDHasher returns a function which knows how to create antispam leaf identities for entries in a serialised bundle.
+func (o *AppendOptions) bundleIDHasher() func([]byte) ([][]byte, error) {
+	h := o.identityHash
+	if h == nil {
+		h = identityHash
+	}
+	return o.newBundleIDHasher(h)
+}
+
 func NewAppendOptions() *AppendOptions {
 	return &AppendOptions{
 		batchMaxSize:              DefaultBatchMaxSize,
 		batchMaxAge:               DefaultBatchMaxAge,
 		entriesPath:               layout.EntriesPath,
-		bundleIDHasher:            defaultIDHasher,
-		checkpointInterval:        DefaultCheckpointInterval,
+		newBundleIDHasher:         newIDHasher,
+		checkpointInterval:        newDuration(DefaultCheckpointInterval),
+		witnesses:                 &atomic.Pointer[witnessPolicy]{},
 		addDecorators:             make([]func(AddFn) AddFn, 0),
 		pushbackMaxOutstanding:    DefaultPushbackMaxOutstanding,
 		garbageCollectionInterval: DefaultGarbageCollectionInterval,
+		frozen:                    &atomic.Bool{},
 	}
 }
 
@@ -527,26 +963,98 @@ func NewAppendOptions() *AppendOptions {
 type AppendOptions struct {
 	// newCP knows how to format and sign checkpoints.
 	newCP func(ctx context.Context, size uint64, hash []byte) ([]byte, error)
+	// checkpointFormatter, if set, customises the body of checkpoints created by newCP.
+	checkpointFormatter CheckpointFormatter
+	// checkpointTimestampers, if set, add timestamp extension lines to checkpoints created by newCP.
+	checkpointTimestampers []CheckpointTimestamper
+	// checkpointSigners, if set, are the signers used by newCP.
+	checkpointSigners *checkpointSigners
 
 	batchMaxAge  time.Duration
 	batchMaxSize uint
 
 	pushbackMaxOutstanding uint
 
+	// maxAddQPS and maxUnintegrated, if non-zero, are limits enforced by the Appender on calls to Add.
+	maxAddQPS       float64
+	maxUnintegrated uint64
+
+	// maxEntrySize and entryValidators are checked before entries are passed on for deduplication and sequencing.
+	maxEntrySize    uint
+	entryValidators []func(*Entry) error
+
+	// preordered is true if entries must be added with their index already decided.
+	preordered bool
+
+	// onSequence, onIntegrate, and onPublish are hooks registered by the personality.
+	// The onPublish hooks are passed the size of the previously published checkpoint, as well as the new one.
+	onSequence  []func(context.Context, []*Entry)
+	onIntegrate []func(context.Context, uint64, uint64)
+	onPublish   []func(ctx context.Context, prevSize, size uint64, checkpoint []byte)
+
 	// EntriesPath knows how to format entry bundle paths.
 	entriesPath func(n uint64, p uint8) string
-	// bundleIDHasher knows how to create antispam leaf identities for entries in a serialised bundle.
-	bundleIDHasher func([]byte) ([][]byte, error)
-
-	checkpointInterval time.Duration
-	witnesses          WitnessGroup
-	witnessOpts        WitnessOptions
+	// newBundleIDHasher knows how to create a function which creates antispam leaf identities, using the
+	// provided identity hash function, for entries in a serialised bundle.
+	newBundleIDHasher func(func([]byte) []byte) func([]byte) ([][]byte, error)
+	// identityHash, if set, overrides the default function used to calculate antispam identity hashes.
+	identityHash func([]byte) []byte
+	// hashFunction, if set, overrides SHA-256 as the hash function used for the log's Merkle tree.
+	hashFunction crypto.Hash
+	// tiling, if set, overrides layout.DefaultTiling as the tiling used for the log's Merkle tree.
+	tiling layout.Tiling
+	// ctLayout is true if the Static CT API layout is being used.
+	ctLayout bool
+	// entryBundleEncoding is the content encoding used to store entry bundles, or empty if they're stored as-is.
+	entryBundleEncoding string
+	// entryBundleKeys, if set, wraps the keys used to encrypt entry bundles at rest.
+	entryBundleKeys EntryBundleKeyProvider
+
+	// checkpointInterval is shared with the Appender, so that it can be changed while the log is running.
+	checkpointInterval *atomic.Int64
+	// checkpointGrowthTrigger, if non-zero, is the number of entries by which the tree must grow for a new
+	// checkpoint to be published before the checkpoint interval has passed.
+	checkpointGrowthTrigger uint64
+	// witnesses is shared with the Appender, so that the witness policy can be changed while the log is running.
+	witnesses *atomic.Pointer[witnessPolicy]
+	// checkpointDests, if set, are where checkpoints are published in addition to the log's storage.
+	checkpointDests *checkpointDestinations
+	// archiveCheckpoints is true if published checkpoints should be archived, and checkpointArchiveRetain is
+	// the number of them to keep, or zero to keep them all.
+	archiveCheckpoints      bool
+	checkpointArchiveRetain uint
 
 	addDecorators []func(AddFn) AddFn
 	followers     []Follower
+	dedupers      []namedDedupe
+	// duplicateErrors is true if duplicate entries should be reported with ErrDuplicate.
+	duplicateErrors bool
 
 	// garbageCollectionInterval of zero should be interpreted as requesting garbage collection to be disabled.
 	garbageCollectionInterval time.Duration
+
+	// retention, if set, is the policy used to expunge old entry bundles.
+	retention *RetentionPolicy
+
+	// auditLog, if set, records administrative operations, and auditInTree is true if they should also
+	// be added to the log.
+	auditLog    *AuditLog
+	auditInTree bool
+
+	// watchdog, if set, configures the consistency watchdog.
+	watchdog *WatchdogOptions
+
+	// integrationMonitor, if set, is kept up to date with the sequencing backlog and integration rate.
+	integrationMonitor *IntegrationMonitor
+
+	// submissionWALDir, if set, is the directory in which entries are recorded until they're sequenced.
+	submissionWALDir string
+
+	// idempotencyWindow, if non-zero, is how long the indices of entries with idempotency keys are remembered.
+	idempotencyWindow time.Duration
+
+	// frozen is set by the Appender once the log is frozen, and prevents new checkpoints from being published.
+	frozen *atomic.Bool
 }
 
 // valid returns an error if an invalid combination of options has been set, or nil otherwise.
@@ -554,16 +1062,65 @@ func (o AppendOptions) valid() error {
 	if o.newCP == nil {
 		return errors.New("invalid AppendOptions: WithCheckpointSigner must be set")
 	}
+	if o.hashFunction != 0 {
+		if err := validHashFunction(o.hashFunction); err != nil {
+			return fmt.Errorf("invalid AppendOptions: %v", err)
+		}
+		if o.ctLayout && o.hashFunction != crypto.SHA256 {
+			return errors.New("invalid AppendOptions: WithHashFunction cannot be used with WithCTLayout")
+		}
+	}
+	if err := o.validTiling(); err != nil {
+		return fmt.Errorf("invalid AppendOptions: %v", err)
+	}
+	if o.preordered && len(o.addDecorators) > 0 {
+		return errors.New("invalid AppendOptions: WithPreorderedEntries cannot be used with WithAntispam")
+	}
+	if o.preordered && o.auditInTree {
+		return errors.New("invalid AppendOptions: WithPreorderedEntries cannot be used with WithAuditLogInTree")
+	}
+	if o.idempotencyWindow < 0 {
+		return errors.New("invalid AppendOptions: WithIdempotencyKeys window must not be negative")
+	}
+	if o.ctLayout && o.submissionWALDir != "" {
+		return errors.New("invalid AppendOptions: WithSubmissionWAL cannot be used with WithCTLayout")
+	}
+	if o.archiveCheckpoints && (o.checkpointArchiveRetain == 0 || o.checkpointArchiveRetain > MaxArchivedCheckpoints) {
+		return fmt.Errorf("invalid AppendOptions: WithCheckpointArchive retain must be between 1 and %d", MaxArchivedCheckpoints)
+	}
+	if w := o.watchdog; w != nil && (w.Verifier == nil || w.FetchCheckpoint == nil || w.FetchTile == nil) {
+		return errors.New("invalid AppendOptions: WithConsistencyWatchdog requires Verifier, FetchCheckpoint, and FetchTile to be set")
+	}
 	return nil
 }
 
 // CheckpointPublisher returns a function which should be used to create, sign, and potentially witness a new checkpoint.
 func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client) func(context.Context, uint64, []byte) ([]byte, error) {
-	wg := witness.NewWitnessGateway(o.witnesses, httpClient, lr.ReadTile)
+	// The gateway is recreated whenever the witness policy is changed by Appender.SetWitnesses.
+	var (
+		mu     sync.Mutex
+		policy *witnessPolicy
+		wg     witness.WitnessGateway
+	)
+	gateway := func() (*witness.WitnessGateway, WitnessOptions) {
+		mu.Lock()
+		defer mu.Unlock()
+		p := o.witnessPolicy()
+		if policy == nil || p != policy {
+			policy = p
+			wg = witness.NewWitnessGateway(p.group, httpClient, lr.ReadTile, o.Hasher(), o.Tiling())
+		}
+		return &wg, p.opts
+	}
+	// NewAppender checks that lr can archive checkpoints, if requested, so the error can be ignored here.
+	archive, _ := o.checkpointArchive(lr)
 	return func(ctx context.Context, size uint64, root []byte) ([]byte, error) {
 		ctx, span := tracer.Start(ctx, "tessera.CheckpointPublisher")
 		defer span.End()
 
+		if o.frozen != nil && o.frozen.Load() {
+			return nil, errLogFrozen
+		}
 		cp, err := o.newCP(ctx, size, root)
 		if err != nil {
 			return nil, fmt.Errorf("newCP: %v", err)
@@ -571,9 +1128,10 @@ func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client
 		appenderSignedSize.Record(ctx, otel.Clamp64(size))
 
 		witAttr := []attribute.KeyValue{}
+		wg, witnessOpts := gateway()
 		cp, err = wg.Witness(ctx, cp)
 		if err != nil {
-			if !o.witnessOpts.FailOpen {
+			if !witnessOpts.FailOpen {
 				appenderWitnessRequests.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "failed")))
 				return nil, err
 			}
@@ -584,6 +1142,17 @@ func (o AppendOptions) CheckpointPublisher(lr LogReader, httpClient *http.Client
 		appenderWitnessRequests.Add(ctx, 1, metric.WithAttributes(witAttr...))
 		appenderWitnessedSize.Record(ctx, otel.Clamp64(size))
 
+		// Checkpoints are archived before they're published anywhere, so that the archive is complete.
+		if archive != nil {
+			if err := archive.ArchiveCheckpoint(ctx, size, cp, o.checkpointArchiveRetain); err != nil {
+				return nil, fmt.Errorf("ArchiveCheckpoint: %v", err)
+			}
+		}
+		if err := o.checkpointDests.publish(ctx, cp); err != nil {
+			return nil, err
+		}
+		lastCheckpointCreated.Store(time.Now().UnixNano())
+
 		return cp, nil
 	}
 }
@@ -604,8 +1173,30 @@ func (o AppendOptions) EntriesPath() func(uint64, uint8) string {
 	return o.entriesPath
 }
 
+// CheckpointInterval returns the interval at which checkpoints should be published.
+//
+// The interval may be changed while the log is running by Appender.SetCheckpointInterval, so storage
+// implementations should call this each time they schedule a checkpoint to be published.
 func (o AppendOptions) CheckpointInterval() time.Duration {
-	return o.checkpointInterval
+	return time.Duration(o.checkpointInterval.Load())
+}
+
+// CheckpointGrowthTrigger returns the number of entries set by WithCheckpointGrowthTrigger, or zero if it's unset.
+func (o AppendOptions) CheckpointGrowthTrigger() uint64 {
+	return o.checkpointGrowthTrigger
+}
+
+// CheckpointMinStaleness returns how old the published checkpoint, which commits to publishedSize entries, must
+// be before storage implementations replace it with a checkpoint for the integrated tree of integratedSize entries.
+//
+// This is the checkpoint interval, unless the tree has grown by at least the number of entries set by
+// WithCheckpointGrowthTrigger, in which case it's zero. Storage implementations should still enforce their
+// own minimum interval between checkpoint updates.
+func (o AppendOptions) CheckpointMinStaleness(publishedSize, integratedSize uint64) time.Duration {
+	if g := o.checkpointGrowthTrigger; g > 0 && integratedSize >= publishedSize && integratedSize-publishedSize >= g {
+		return 0
+	}
+	return o.CheckpointInterval()
 }
 
 func (o AppendOptions) GarbageCollectionInterval() time.Duration {
@@ -622,13 +1213,18 @@ func (o AppendOptions) GarbageCollectionInterval() time.Duration {
 //
 // Zero or more dditional signers may also be provided.
 // This enables cases like:
-//   - a rolling key rotation, where checkpoints are signed by both the old and new keys for some period of time,
+//   - a rolling key rotation, where checkpoints are signed by both the old and new keys for some period of time
+//     (see also Appender.RotateCheckpointSigner, which allows the primary signer to be rotated without a restart),
 //   - using different signature schemes for different audiences, etc.
 //
 // When providing additional signers, their names MUST be identical to the primary signer name, and this name will be used
 // as the checkpoint Origin line.
 //
 // Checkpoints signed by these signer(s) will be standard checkpoints as defined by https://c2sp.org/tlog-checkpoint.
+// Their origin line and extension lines may be customised using WithCheckpointFormatter.
+//
+// Signers whose keys are held in a KMS or HSM can be created with the signer package. Any signer which
+// also implements SignContext(ctx, msg) will have it called with the context used to publish the checkpoint.
 func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ...note.Signer) *AppendOptions {
 	origin := s.Name()
 	for _, signer := range additionalSigners {
@@ -636,23 +1232,30 @@ func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ..
 			klog.Exitf("WithCheckpointSigner: additional signer name (%q) does not match primary signer name (%q)", signer.Name(), origin)
 		}
 	}
+	cs := &checkpointSigners{now: time.Now, primary: s, additional: additionalSigners}
+	o.checkpointSigners = cs
 	o.newCP = func(ctx context.Context, size uint64, hash []byte) ([]byte, error) {
 		_, span := tracer.Start(ctx, "tessera.SignCheckpoint")
 		defer span.End()
 
 		// If we're signing a zero-sized tree, the tlog-checkpoint spec says (via RFC6962) that
-		// the root must be SHA256 of the empty string, so we'll enforce that here:
+		// the root must be the hash of the empty string, so we'll enforce that here:
 		if size == 0 {
-			emptyRoot := rfc6962.DefaultHasher.EmptyRoot()
-			hash = emptyRoot[:]
+			hash = o.Hasher().EmptyRoot()
+		}
+		cpRaw, err := checkpointBody(ctx, o.checkpointFormatter, origin, size, hash)
+		if err != nil {
+			return nil, err
+		}
+		if cpRaw, err = timestampCheckpoint(ctx, o.checkpointTimestampers, cpRaw); err != nil {
+			return nil, err
 		}
-		cpRaw := f_log.Checkpoint{
-			Origin: origin,
-			Size:   size,
-			Hash:   hash,
-		}.Marshal()
 
-		n, err := note.Sign(&note.Note{Text: string(cpRaw)}, append([]note.Signer{s}, additionalSigners...)...)
+		signers := cs.signers()
+		for i, s := range signers {
+			signers[i] = withSignContext(ctx, s)
+		}
+		n, err := note.Sign(&note.Note{Text: string(cpRaw)}, signers...)
 		if err != nil {
 			return nil, fmt.Errorf("note.Sign: %w", err)
 		}
@@ -662,6 +1265,31 @@ func (o *AppendOptions) WithCheckpointSigner(s note.Signer, additionalSigners ..
 	return o
 }
 
+// contextSigner is implemented by note.Signers which are able to make use of a context, e.g. because they
+// make remote calls.
+type contextSigner interface {
+	note.Signer
+	SignContext(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+// ctxSigner binds a contextSigner to a context so that it can be used as a note.Signer.
+type ctxSigner struct {
+	contextSigner
+	ctx context.Context
+}
+
+func (s ctxSigner) Sign(msg []byte) ([]byte, error) {
+	return s.SignContext(s.ctx, msg)
+}
+
+// withSignContext returns a note.Signer which passes ctx to s, if s implements contextSigner.
+func withSignContext(ctx context.Context, s note.Signer) note.Signer {
+	if cs, ok := s.(contextSigner); ok {
+		return ctxSigner{contextSigner: cs, ctx: ctx}
+	}
+	return s
+}
+
 // WithBatching configures the batching behaviour of leaves being sequenced.
 // A batch will be allowed to grow in memory until either:
 //   - the number of entries in the batch reach maxSize
@@ -704,8 +1332,24 @@ func (o *AppendOptions) WithPushback(maxOutstanding uint) *AppendOptions {
 // Note that this option probably only makes sense for long-lived applications (e.g. HTTP servers).
 //
 // If this option isn't provided, storage implementations will use the DefaultCheckpointInterval const above.
+// The interval can be changed while the log is running with Appender.SetCheckpointInterval.
 func (o *AppendOptions) WithCheckpointInterval(interval time.Duration) *AppendOptions {
-	o.checkpointInterval = interval
+	o.checkpointInterval.Store(int64(interval))
+	return o
+}
+
+// WithCheckpointGrowthTrigger configures Tessera to publish a new checkpoint as soon as the integrated tree
+// has grown by at least entries since the published checkpoint, rather than waiting for the checkpoint
+// interval to pass.
+//
+// The checkpoint interval still acts as a deadline, so checkpoints are replaced at least that often
+// regardless of growth. This lets low-traffic logs publish promptly after rare submissions without
+// also having to republish unchanged checkpoints at a short interval. Storage implementations still
+// enforce their own minimum interval between checkpoint updates.
+//
+// Passing zero, which is the default, disables the trigger.
+func (o *AppendOptions) WithCheckpointGrowthTrigger(entries uint64) *AppendOptions {
+	o.checkpointGrowthTrigger = entries
 	return o
 }
 
@@ -714,6 +1358,11 @@ func (o *AppendOptions) WithCheckpointInterval(interval time.Duration) *AppendOp
 // using the URLs method. The checkpoint will be accepted for publishing when a sufficient number of
 // witnesses to Satisfy the group have responded.
 //
+// Witnessing happens synchronously as part of publishing each new checkpoint: the cosignatures returned by
+// the witnesses are embedded in the published checkpoint, and the checkpoint is not published at all if the
+// policy cannot be satisfied (unless WitnessOptions.FailOpen is set). For example, a 2-of-3 policy can be
+// created by passing three Witnesses, created with NewWitness, to NewWitnessGroup with a threshold of 2.
+//
 // If this method is not called, then the default empty WitnessGroup will be used, which contacts zero
 // witnesses and requires zero witnesses in order to publish.
 func (o *AppendOptions) WithWitnesses(witnesses WitnessGroup, opts *WitnessOptions) *AppendOptions {
@@ -721,11 +1370,32 @@ func (o *AppendOptions) WithWitnesses(witnesses WitnessGroup, opts *WitnessOptio
 		opts = &WitnessOptions{}
 	}
 
-	o.witnesses = witnesses
-	o.witnessOpts = *opts
+	if o.witnesses == nil {
+		o.witnesses = &atomic.Pointer[witnessPolicy]{}
+	}
+	o.witnesses.Store(&witnessPolicy{group: witnesses, opts: *opts})
 	return o
 }
 
+// witnessPolicy holds the witnesses and options set by WithWitnesses or Appender.SetWitnesses.
+type witnessPolicy struct {
+	group WitnessGroup
+	opts  WitnessOptions
+}
+
+// witnessPolicy returns the current witness policy, which is empty if none has been set.
+func (o AppendOptions) witnessPolicy() *witnessPolicy {
+	if o.witnesses != nil {
+		if p := o.witnesses.Load(); p != nil {
+			return p
+		}
+	}
+	return emptyWitnessPolicy
+}
+
+// emptyWitnessPolicy contacts zero witnesses, and requires zero witnesses in order to publish.
+var emptyWitnessPolicy = &witnessPolicy{}
+
 // WitnessOptions contains extra optional configuration for how Tessera should use/interact with
 // a user-provided WitnessGroup policy.
 type WitnessOptions struct {
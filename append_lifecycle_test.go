@@ -15,8 +15,16 @@
 package tessera
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/sumdb/note"
 )
 
 func TestMemoize(t *testing.T) {
@@ -54,3 +62,261 @@ func TestMemoize(t *testing.T) {
 		t.Fatalf("c(=%d) != d(=%d)", c.Index, d.Index)
 	}
 }
+
+// fakeDriver is a driver which implements the Appender lifecycle, recording the batches it's given.
+type fakeDriver struct {
+	mu      sync.Mutex
+	next    uint64
+	batches [][]string
+}
+
+func (d *fakeDriver) Appender(_ context.Context, _ *AppendOptions) (*Appender, LogReader, error) {
+	assign := func(entries []*Entry) []IndexFuture {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		b := make([]string, 0, len(entries))
+		r := make([]IndexFuture, 0, len(entries))
+		for _, e := range entries {
+			b = append(b, string(e.Data()))
+			idx := d.next
+			d.next++
+			r = append(r, func() (Index, error) { return Index{Index: idx}, nil })
+		}
+		d.batches = append(d.batches, b)
+		return r
+	}
+	return &Appender{
+		Add: func(_ context.Context, e *Entry) IndexFuture {
+			return assign([]*Entry{e})[0]
+		},
+		AddBatch: func(_ context.Context, entries []*Entry) []IndexFuture {
+			return assign(entries)
+		},
+	}, &fakeLogReader{}, nil
+}
+
+func TestAddBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	d := &fakeDriver{}
+	a, _, _, err := NewAppender(ctx, d, NewAppendOptions().WithCheckpointSigner(s).WithAntispam(256, nil))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+
+	if _, err := a.Add(ctx, NewEntry([]byte("zero")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	entries := []*Entry{NewEntry([]byte("one")), NewEntry([]byte("zero")), NewEntry([]byte("two")), NewEntry([]byte("one"))}
+	fs := a.AddBatch(ctx, entries)
+	if got, want := len(fs), len(entries); got != want {
+		t.Fatalf("got %d futures, want %d", got, want)
+	}
+	want := []Index{{Index: 1}, {Index: 0, IsDup: true}, {Index: 2}, {Index: 1, IsDup: true}}
+	for i, f := range fs {
+		got, err := f()
+		if err != nil {
+			t.Fatalf("future %d: %v", i, err)
+		}
+		if got != want[i] {
+			t.Errorf("future %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+	if diff := cmp.Diff([][]string{{"zero"}, {"one", "two"}}, d.batches); diff != "" {
+		t.Errorf("unexpected driver batches (-want +got):\n%s", diff)
+	}
+}
+
+// recordingAntispam is an Antispam which records the bundle ID hasher it's given.
+type recordingAntispam struct {
+	hasher func([]byte) ([][]byte, error)
+}
+
+func (r *recordingAntispam) Decorator() func(AddFn) AddFn {
+	return func(delegate AddFn) AddFn { return delegate }
+}
+
+func (r *recordingAntispam) Follower(h func([]byte) ([][]byte, error)) Follower {
+	r.hasher = h
+	return &nopFollower{}
+}
+
+type nopFollower struct{}
+
+func (nopFollower) Name() string                                     { return "nop" }
+func (nopFollower) Follow(context.Context, LogReader)                {}
+func (nopFollower) EntriesProcessed(context.Context) (uint64, error) { return 0, nil }
+
+func TestWithIdentityHash(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	// Only the module@version part of each entry contributes to its identity.
+	modVer := func(data []byte) []byte {
+		mv, _, _ := bytes.Cut(data, []byte(" "))
+		h := sha256.Sum256(mv)
+		return h[:]
+	}
+	as := &recordingAntispam{}
+	// Note that WithIdentityHash is deliberately specified after WithAntispam.
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithAntispam(256, as).WithIdentityHash(modVer)
+	d := &fakeDriver{}
+	a, _, _, err := NewAppender(ctx, d, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+
+	for i, test := range []struct {
+		data string
+		want Index
+	}{
+		{data: "example.com/a@v1.0.0 h1", want: Index{Index: 0}},
+		{data: "example.com/a@v1.0.0 h2", want: Index{Index: 0, IsDup: true}},
+		{data: "example.com/a@v1.0.1 h1", want: Index{Index: 1}},
+	} {
+		got, err := a.Add(ctx, NewEntry([]byte(test.data)))()
+		if err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("Add(%q): got %+v, want %+v", test.data, got, test.want)
+		}
+	}
+
+	// The antispam follower must calculate identities in the same way.
+	bundle := NewEntry([]byte("example.com/a@v1.0.0 h1")).MarshalBundleData(0)
+	bundle = append(bundle, NewEntry([]byte("example.com/b@v2.0.0")).MarshalBundleData(1)...)
+	ids, err := as.hasher(bundle)
+	if err != nil {
+		t.Fatalf("hasher: %v", err)
+	}
+	want := [][]byte{modVer([]byte("example.com/a@v1.0.0")), modVer([]byte("example.com/b@v2.0.0"))}
+	if diff := cmp.Diff(want, ids); diff != "" {
+		t.Errorf("unexpected bundle identities (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithDuplicateErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	a, _, _, err := NewAppender(ctx, &fakeDriver{}, NewAppendOptions().WithCheckpointSigner(s).WithAntispam(256, nil).WithDuplicateErrors())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	if _, err := a.Add(ctx, NewEntry([]byte("zero")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	i, err := a.Add(ctx, NewEntry([]byte("zero")))()
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Add duplicate: got err %v, want ErrDuplicate", err)
+	}
+	if want := (Index{Index: 0, IsDup: true}); i != want {
+		t.Errorf("Add duplicate: got %+v, want %+v", i, want)
+	}
+}
+
+// ctxCheckingSigner is a contextSigner which records whether SignContext was called with a done context.
+type ctxCheckingSigner struct {
+	note.Signer
+	sawDone bool
+}
+
+func (s *ctxCheckingSigner) SignContext(ctx context.Context, msg []byte) ([]byte, error) {
+	s.sawDone = ctx.Err() != nil
+	return s.Sign(msg)
+}
+
+func TestWithCheckpointSignerContext(t *testing.T) {
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	ns, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	s := &ctxCheckingSigner{Signer: ns}
+	opts := NewAppendOptions().WithCheckpointSigner(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := opts.newCP(ctx, 0, nil); err != nil {
+		t.Fatalf("newCP: %v", err)
+	}
+	if !s.sawDone {
+		t.Error("SignContext was not called with the checkpoint context")
+	}
+}
+
+func TestCheckpointMinStaleness(t *testing.T) {
+	for _, test := range []struct {
+		name                    string
+		growthTrigger           uint64
+		publishedSize, treeSize uint64
+		want                    time.Duration
+	}{
+		{
+			name:          "no trigger",
+			publishedSize: 10,
+			treeSize:      1000,
+			want:          time.Minute,
+		}, {
+			name:          "not grown enough",
+			growthTrigger: 100,
+			publishedSize: 10,
+			treeSize:      109,
+			want:          time.Minute,
+		}, {
+			name:          "grown enough",
+			growthTrigger: 100,
+			publishedSize: 10,
+			treeSize:      110,
+			want:          0,
+		}, {
+			name:          "no growth",
+			growthTrigger: 1,
+			publishedSize: 10,
+			treeSize:      10,
+			want:          time.Minute,
+		}, {
+			name:          "published ahead of tree",
+			growthTrigger: 1,
+			publishedSize: 10,
+			treeSize:      5,
+			want:          time.Minute,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := NewAppendOptions().WithCheckpointInterval(time.Minute).WithCheckpointGrowthTrigger(test.growthTrigger)
+			if got := opts.CheckpointMinStaleness(test.publishedSize, test.treeSize); got != test.want {
+				t.Errorf("CheckpointMinStaleness(%d, %d) = %v, want %v", test.publishedSize, test.treeSize, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultAuditLogSize is the default number of events of each AuditOp retained by an AuditLog.
+	DefaultAuditLogSize = 1000
+
+	// AuditEntryPrefix is the prefix of entries written to the log by WithAuditLogInTree.
+	// The remainder of the entry is the JSON serialisation of an AuditEvent.
+	AuditEntryPrefix = "tessera-audit/v1\n"
+)
+
+// AuditOp identifies a type of administrative operation recorded in an AuditLog.
+type AuditOp string
+
+const (
+	// AuditOpQuiesce records a call to Appender.Quiesce.
+	AuditOpQuiesce AuditOp = "quiesce"
+	// AuditOpFreeze records a call to Appender.Freeze.
+	AuditOpFreeze AuditOp = "freeze"
+	// AuditOpResume records a call to Appender.Resume.
+	AuditOpResume AuditOp = "resume"
+	// AuditOpKeyRotation records a change to the key used to sign checkpoints.
+	AuditOpKeyRotation AuditOp = "key-rotation"
+	// AuditOpGarbageCollection records a run of the storage implementation's garbage collector.
+	AuditOpGarbageCollection AuditOp = "garbage-collection"
+	// AuditOpRetention records entry bundles being expunged by the retention policy.
+	AuditOpRetention AuditOp = "retention"
+	// AuditOpMigration records a call to MigrationTarget.Migrate.
+	AuditOpMigration AuditOp = "migration"
+	// AuditOpConfiguration records a change to a setting made while the log is running, e.g. by
+	// Appender.SetCheckpointInterval.
+	AuditOpConfiguration AuditOp = "configuration"
+)
+
+// AuditEvent describes a single administrative operation.
+type AuditEvent struct {
+	// Time is when the operation completed, or, for events written to the tree, when it was requested.
+	Time time.Time `json:"time"`
+	// Op is the type of operation.
+	Op AuditOp `json:"op"`
+	// Detail is optional human readable information about the operation.
+	Detail string `json:"detail,omitempty"`
+	// Error is set if the operation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// ParseAuditEntry returns the AuditEvent held in an entry written to the log by WithAuditLogInTree.
+// If the entry does not hold an AuditEvent, false is returned.
+func ParseAuditEntry(data []byte) (AuditEvent, bool) {
+	var ev AuditEvent
+	b, ok := bytes.CutPrefix(data, []byte(AuditEntryPrefix))
+	if !ok {
+		return ev, false
+	}
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return ev, false
+	}
+	return ev, true
+}
+
+// AuditQuery selects events from an AuditLog.
+type AuditQuery struct {
+	// Ops, if non-empty, restricts the results to events of these types.
+	Ops []AuditOp
+	// Since and Until, if non-zero, restrict the results to events which happened in [Since, Until).
+	Since, Until time.Time
+	// Limit, if non-zero, restricts the results to the most recent Limit matching events.
+	Limit int
+}
+
+// matches returns true if ev should be returned for the query.
+func (q AuditQuery) matches(ev AuditEvent) bool {
+	if len(q.Ops) > 0 && !slices.Contains(q.Ops, ev.Op) {
+		return false
+	}
+	if !q.Since.IsZero() && ev.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !ev.Time.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditLog is an in-memory record of the administrative operations performed on a log, so that operators
+// can demonstrate how the log has been run.
+//
+// A fixed number of the most recent events of each AuditOp are retained, so that frequent operations such
+// as garbage collection do not displace rarer ones. For a durable record, see WithAuditLogInTree.
+//
+// AuditLog implements http.Handler, serving the results of Query as a JSON array. The query may be
+// controlled with the op (which may be repeated), since and until (RFC 3339 timestamps), and limit
+// URL parameters.
+type AuditLog struct {
+	size int
+	now  func() time.Time
+
+	mu     sync.Mutex
+	events map[AuditOp][]AuditEvent
+}
+
+// NewAuditLog returns an AuditLog which retains up to size events of each AuditOp.
+// If size is zero, DefaultAuditLogSize is used.
+func NewAuditLog(size int) *AuditLog {
+	if size <= 0 {
+		size = DefaultAuditLogSize
+	}
+	return &AuditLog{
+		size:   size,
+		now:    time.Now,
+		events: make(map[AuditOp][]AuditEvent),
+	}
+}
+
+// Record adds an event to the audit log. If err is non-nil, the operation is recorded as having failed.
+//
+// Record may be called on a nil AuditLog, in which case it does nothing.
+func (l *AuditLog) Record(op AuditOp, detail string, err error) {
+	if l == nil {
+		return
+	}
+	ev := AuditEvent{Time: l.now(), Op: op, Detail: detail}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	evs := append(l.events[op], ev)
+	if len(evs) > l.size {
+		evs = slices.Delete(evs, 0, len(evs)-l.size)
+	}
+	l.events[op] = evs
+}
+
+// Query returns the events matching q, oldest first.
+func (l *AuditLog) Query(q AuditQuery) []AuditEvent {
+	l.mu.Lock()
+	r := []AuditEvent{}
+	for _, evs := range l.events {
+		for _, ev := range evs {
+			if q.matches(ev) {
+				r = append(r, ev)
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	slices.SortStableFunc(r, func(a, b AuditEvent) int { return a.Time.Compare(b.Time) })
+	if q.Limit > 0 && len(r) > q.Limit {
+		r = r[len(r)-q.Limit:]
+	}
+	return r
+}
+
+// ServeHTTP implements http.Handler.
+func (l *AuditLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	q := AuditQuery{}
+	for _, op := range v["op"] {
+		q.Ops = append(q.Ops, AuditOp(op))
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		if s := v.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s: %v", p.name, err), http.StatusBadRequest)
+				return
+			}
+			*p.t = t
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", s), http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(l.Query(q)); err != nil {
+		klog.Warningf("AuditLog: failed to write response: %v", err)
+	}
+}
+
+// WithAuditLog configures the Appender to record administrative operations to the provided AuditLog.
+//
+// Storage implementations should use RecordAudit to record operations they perform, such as garbage collection.
+func (o *AppendOptions) WithAuditLog(l *AuditLog) *AppendOptions {
+	o.auditLog = l
+	return o
+}
+
+// WithAuditLogInTree configures the Appender to also add an entry to the log itself for each call to
+// Quiesce, Freeze, and Resume, so that the record of these operations is publicly verifiable.
+//
+// Each entry is prefixed with AuditEntryPrefix, and can be read with ParseAuditEntry. Since the log doesn't
+// accept new entries once it's quiescing, entries for Quiesce and Freeze are added before the operation is
+// performed, and the operation fails if the entry cannot be added. The entry for Resume is added once the
+// log is active again.
+//
+// Frequent operations, such as garbage collection, are never added to the log.
+//
+// This option cannot be used with WithPreorderedEntries.
+func (o *AppendOptions) WithAuditLogInTree() *AppendOptions {
+	o.auditInTree = true
+	return o
+}
+
+// RecordAudit records an administrative operation to the AuditLog configured with WithAuditLog, if any.
+func (o AppendOptions) RecordAudit(op AuditOp, detail string, err error) {
+	o.auditLog.Record(op, detail, err)
+}
+
+// WithAuditLog configures the migration target to record migrations to the provided AuditLog.
+func (o *MigrationOptions) WithAuditLog(l *AuditLog) *MigrationOptions {
+	o.auditLog = l
+	return o
+}
+
+// auditor records administrative operations performed via an Appender.
+type auditor struct {
+	log *AuditLog
+	// add, if set, is used to add audit entries to the tree.
+	add AddFn
+	now func() time.Time
+}
+
+// record adds an event to the audit log. It's a no-op if a is nil.
+func (a *auditor) record(op AuditOp, detail string, err error) {
+	if a == nil {
+		return
+	}
+	a.log.Record(op, detail, err)
+}
+
+// addToTree adds an entry recording the operation to the log, and waits for it to be sequenced.
+// It's a no-op if a is nil or entries are not being added to the tree.
+func (a *auditor) addToTree(ctx context.Context, op AuditOp, detail string) error {
+	if a == nil || a.add == nil {
+		return nil
+	}
+	b, err := json.Marshal(AuditEvent{Time: a.now(), Op: op, Detail: detail})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %v", err)
+	}
+	idx, err := a.add(ctx, NewEntry(append([]byte(AuditEntryPrefix), b...)))()
+	if err != nil {
+		return fmt.Errorf("failed to add %s audit entry to log: %w", op, err)
+	}
+	klog.Infof("Added %s audit entry to log at index %d", op, idx.Index)
+	return nil
+}
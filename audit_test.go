@@ -0,0 +1,206 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// newTestAuditLog returns an AuditLog with events recorded one second apart, starting at the zero Unix time.
+func newTestAuditLog(size int, ops ...AuditOp) *AuditLog {
+	l := NewAuditLog(size)
+	var n int64
+	l.now = func() time.Time {
+		n++
+		return time.Unix(n, 0)
+	}
+	for _, op := range ops {
+		l.Record(op, "", nil)
+	}
+	return l
+}
+
+func opsOf(evs []AuditEvent) []AuditOp {
+	r := []AuditOp{}
+	for _, ev := range evs {
+		r = append(r, ev.Op)
+	}
+	return r
+}
+
+func TestAuditLogQuery(t *testing.T) {
+	l := newTestAuditLog(2, AuditOpGarbageCollection, AuditOpQuiesce, AuditOpGarbageCollection, AuditOpResume, AuditOpGarbageCollection)
+
+	for _, test := range []struct {
+		name  string
+		query AuditQuery
+		want  []AuditOp
+	}{
+		{
+			name: "all, oldest gc evicted",
+			want: []AuditOp{AuditOpQuiesce, AuditOpGarbageCollection, AuditOpResume, AuditOpGarbageCollection},
+		}, {
+			name:  "by op",
+			query: AuditQuery{Ops: []AuditOp{AuditOpQuiesce, AuditOpResume}},
+			want:  []AuditOp{AuditOpQuiesce, AuditOpResume},
+		}, {
+			name:  "time range",
+			query: AuditQuery{Since: time.Unix(3, 0), Until: time.Unix(5, 0)},
+			want:  []AuditOp{AuditOpGarbageCollection, AuditOpResume},
+		}, {
+			name:  "limit",
+			query: AuditQuery{Limit: 1},
+			want:  []AuditOp{AuditOpGarbageCollection},
+		}, {
+			name:  "no match",
+			query: AuditQuery{Ops: []AuditOp{AuditOpFreeze}},
+			want:  []AuditOp{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := opsOf(l.Query(test.query)); !slices.Equal(got, test.want) {
+				t.Errorf("Query: got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAuditLogServeHTTP(t *testing.T) {
+	l := newTestAuditLog(0, AuditOpQuiesce, AuditOpResume, AuditOpQuiesce)
+	l.Record(AuditOpFreeze, "", errors.New("boom"))
+
+	for _, test := range []struct {
+		name     string
+		query    string
+		wantCode int
+		want     []AuditOp
+	}{
+		{name: "all", wantCode: http.StatusOK, want: []AuditOp{AuditOpQuiesce, AuditOpResume, AuditOpQuiesce, AuditOpFreeze}},
+		{name: "ops", query: "?op=resume&op=freeze", wantCode: http.StatusOK, want: []AuditOp{AuditOpResume, AuditOpFreeze}},
+		{name: "since and limit", query: "?since=1970-01-01T00:00:02Z&limit=2", wantCode: http.StatusOK, want: []AuditOp{AuditOpQuiesce, AuditOpFreeze}},
+		{name: "bad since", query: "?since=yesterday", wantCode: http.StatusBadRequest},
+		{name: "bad limit", query: "?limit=-1", wantCode: http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			l.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit"+test.query, nil))
+			if w.Code != test.wantCode {
+				t.Fatalf("got status %d, want %d", w.Code, test.wantCode)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var evs []AuditEvent
+			if err := json.Unmarshal(w.Body.Bytes(), &evs); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got := opsOf(evs); !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			if last := evs[len(evs)-1]; last.Op == AuditOpFreeze && last.Error != "boom" {
+				t.Errorf("got error %q for failed freeze, want %q", last.Error, "boom")
+			}
+		})
+	}
+}
+
+func TestAuditLogInTree(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	l := NewAuditLog(0)
+	d := &fakeDriver{}
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithBatching(1, time.Millisecond).WithAuditLog(l).WithAuditLogInTree()
+	a, _, _, err := NewAppender(ctx, d, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+
+	if err := a.Quiesce(ctx); err != nil {
+		t.Fatalf("Quiesce: %v", err)
+	}
+	// The log isn't active, so this shouldn't be added to the tree.
+	if err := a.Quiesce(ctx); err != nil {
+		t.Fatalf("Quiesce: %v", err)
+	}
+	if err := a.Resume(ctx); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if err := a.Freeze(ctx); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	if err := a.Resume(ctx); err == nil {
+		t.Fatal("Resume of frozen log succeeded")
+	}
+
+	if got, want := opsOf(l.Query(AuditQuery{})), []AuditOp{AuditOpQuiesce, AuditOpQuiesce, AuditOpResume, AuditOpFreeze, AuditOpResume}; !slices.Equal(got, want) {
+		t.Errorf("AuditLog: got %v, want %v", got, want)
+	}
+	if evs := l.Query(AuditQuery{Ops: []AuditOp{AuditOpResume}}); evs[0].Error != "" || evs[1].Error == "" {
+		t.Errorf("AuditLog: got resume events %+v, want only the second to have failed", evs)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	inTree := []AuditOp{}
+	for _, b := range d.batches {
+		for _, e := range b {
+			ev, ok := ParseAuditEntry([]byte(e))
+			if !ok {
+				t.Errorf("ParseAuditEntry(%q) failed", e)
+				continue
+			}
+			inTree = append(inTree, ev.Op)
+		}
+	}
+	if want := []AuditOp{AuditOpQuiesce, AuditOpResume, AuditOpFreeze}; !slices.Equal(inTree, want) {
+		t.Errorf("Tree: got %v, want %v", inTree, want)
+	}
+}
+
+func TestParseAuditEntry(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		data   string
+		wantOK bool
+	}{
+		{name: "valid", data: AuditEntryPrefix + `{"time":"2025-01-01T00:00:00Z","op":"freeze"}`, wantOK: true},
+		{name: "no prefix", data: `{"time":"2025-01-01T00:00:00Z","op":"freeze"}`},
+		{name: "bad json", data: AuditEntryPrefix + "{"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := ParseAuditEntry([]byte(test.data)); ok != test.wantOK {
+				t.Errorf("ParseAuditEntry: got %t, want %t", ok, test.wantOK)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package tessera
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"sync"
 	"time"
@@ -65,7 +66,8 @@ type PublicationAwaiter struct {
 // commits to this new index. When this happens, Await returns the index at
 // which the leaf has been added, and a checkpoint that commits to this index.
 //
-// This operation can be aborted early by cancelling the context. In this event,
+// This operation can be aborted early by cancelling the context, or by the context's
+// deadline passing, which allows callers to use per-call timeouts. In this event,
 // or in the event that there is an error getting a valid checkpoint, an error
 // will be returned from this method.
 func (a *PublicationAwaiter) Await(ctx context.Context, future IndexFuture) (Index, []byte, error) {
@@ -77,16 +79,60 @@ func (a *PublicationAwaiter) Await(ctx context.Context, future IndexFuture) (Ind
 		return i, nil, err
 	}
 
+	cp, err := a.awaitSize(ctx, i.Index+1)
+	return i, cp, err
+}
+
+// AwaitAll is like Await, but for a batch of IndexFutures. It blocks until all of the futures are resolved and
+// a checkpoint which commits to every one of their indices has been published, and returns the indices (in the
+// same order as the futures) along with that checkpoint.
+//
+// Only a single wait is performed for the whole batch, so this is considerably more efficient than calling
+// Await for each future from separate goroutines.
+//
+// If any of the futures resolve to an error, AwaitAll returns the first such error without waiting for publication.
+// Callers which need to handle failures of individual entries separately should use Await instead.
+func (a *PublicationAwaiter) AwaitAll(ctx context.Context, futures []IndexFuture) ([]Index, []byte, error) {
+	_, span := tracer.Start(ctx, "tessera.AwaitAll")
+	defer span.End()
+
+	r := make([]Index, 0, len(futures))
+	var size uint64
+	for n, f := range futures {
+		i, err := f()
+		if err != nil {
+			return nil, nil, fmt.Errorf("future %d: %w", n, err)
+		}
+		r = append(r, i)
+		size = max(size, i.Index+1)
+	}
+	if len(r) == 0 {
+		return r, nil, nil
+	}
+	cp, err := a.awaitSize(ctx, size)
+	return r, cp, err
+}
+
+// awaitSize blocks until a checkpoint for a tree of at least the given size has been seen, or an error occurs.
+func (a *PublicationAwaiter) awaitSize(ctx context.Context, size uint64) ([]byte, error) {
+	// Wake up promptly if the context is done, rather than waiting for the next poll.
+	stop := context.AfterFunc(ctx, func() {
+		a.c.L.Lock()
+		defer a.c.L.Unlock()
+		a.c.Broadcast()
+	})
+	defer stop()
+
 	a.c.L.Lock()
 	defer a.c.L.Unlock()
-	for (a.size <= i.Index && a.err == nil) && ctx.Err() == nil {
+	for (a.size < size && a.err == nil) && ctx.Err() == nil {
 		a.c.Wait()
 	}
 	// Ensure we propogate context done error, if any.
 	if err := ctx.Err(); err != nil {
-		a.err = err
+		return a.checkpoint, err
 	}
-	return i, a.checkpoint, a.err
+	return a.checkpoint, a.err
 }
 
 // pollLoop MUST be called in a goroutine when constructing an PublicationAwaiter
@@ -227,6 +227,78 @@ func TestAwait_multiClient(t *testing.T) {
 	wg.Wait()
 }
 
+func TestAwaitAll(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		desc    string
+		indices []uint64
+		fErr    error
+		cpSize  uint64
+		wantErr bool
+	}{
+		{desc: "empty", cpSize: 0},
+		{desc: "all covered", indices: []uint64{4, 1, 7}, cpSize: 8},
+		{desc: "last not covered", indices: []uint64{4, 1, 8}, cpSize: 8, wantErr: true},
+		{desc: "future error", indices: []uint64{1, 2}, fErr: errors.New("no future"), cpSize: 8, wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			cpBody := fmt.Appendf(nil, "origin\n%d\nqINS1GRFhWHwdkUeqLEoP4yEMkTBBzxBkGwGQlVlVcs=\n", test.cpSize)
+			readCheckpoint := func(ctx context.Context) ([]byte, error) {
+				return cpBody, nil
+			}
+			awaiter := NewPublicationAwaiter(ctx, readCheckpoint, 10*time.Millisecond)
+
+			futures := make([]IndexFuture, 0, len(test.indices))
+			for n, i := range test.indices {
+				futures = append(futures, func() (Index, error) {
+					if n == len(test.indices)-1 && test.fErr != nil {
+						return Index{}, test.fErr
+					}
+					return Index{Index: i}, nil
+				})
+			}
+			got, cp, err := awaiter.AwaitAll(ctx, futures)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("gotErr != wantErr (%t != %t): %v", gotErr, test.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			for n, i := range got {
+				if i.Index != test.indices[n] {
+					t.Errorf("got index %d at position %d, want %d", i.Index, n, test.indices[n])
+				}
+			}
+			if len(test.indices) > 0 && !bytes.Equal(cp, cpBody) {
+				t.Errorf("expected checkpoint %q but got %q", cpBody, cp)
+			}
+		})
+	}
+}
+
+func TestAwait_deadline(t *testing.T) {
+	t.Parallel()
+	// The awaiter polls very infrequently, but Await should still return promptly when its context expires.
+	awaiter := NewPublicationAwaiter(t.Context(), func(context.Context) ([]byte, error) {
+		return []byte("origin\n1\nqINS1GRFhWHwdkUeqLEoP4yEMkTBBzxBkGwGQlVlVcs=\n"), nil
+	}, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := awaiter.Await(ctx, func() (Index, error) { return Index{Index: 5}, nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await: got err %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Await took %v to notice deadline", d)
+	}
+}
+
 func BenchmarkAwait(b *testing.B) {
 	cpFormat := "origin/\n%d\nhash\n\nsig"
 	cpSize := atomic.Uint64{}
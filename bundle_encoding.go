@@ -0,0 +1,74 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import "github.com/transparency-dev/tessera/internal/compress"
+
+// WithEntryBundleCompression instructs the underlying storage to store entry bundles compressed with zstd,
+// which can considerably reduce the storage and egress used by logs with highly compressible entries, such
+// as JSON documents or PEM encoded certificate chains.
+//
+// Where storage supports it, the encoding is recorded in each bundle's metadata so that it's served with a
+// Content-Encoding: zstd header. POSIX storage records the encoding in the log's state directory instead.
+// Storage decompresses the bundles returned by its LogReader according to the recorded encoding, so enabling or
+// disabling this option for an existing log is safe. Clients which fetch entry bundles by other means must
+// accept zstd.
+//
+// Tiles and checkpoints are not affected by this option.
+func (o *AppendOptions) WithEntryBundleCompression() *AppendOptions {
+	o.entryBundleEncoding = compress.Zstd
+	return o
+}
+
+// WithEntryBundleGzipCompression instructs the underlying storage to store entry bundles compressed with
+// gzip, rather than zstd as with WithEntryBundleCompression.
+//
+// Gzip compresses less well than zstd, but every HTTP client and browser can decode it, and GCS will decompress
+// gzip objects on the fly for clients which don't accept the encoding. It's a better choice for public logs
+// served directly from object storage, whose clients aren't known. S3 serves objects exactly as they're stored,
+// so clients of logs on AWS must still accept gzip. As with zstd, storage only decompresses bundles which were
+// recorded as gzip encoded when they were written.
+//
+// Hash tiles are the output of a hash function and so don't compress, and are always stored as-is.
+func (o *AppendOptions) WithEntryBundleGzipCompression() *AppendOptions {
+	o.entryBundleEncoding = compress.Gzip
+	return o
+}
+
+// EntryBundleEncoding returns the content encoding with which entry bundles should be stored, or the empty
+// string if they should be stored as-is.
+func (o AppendOptions) EntryBundleEncoding() string {
+	return o.entryBundleEncoding
+}
+
+// WithEntryBundleCompression instructs the underlying storage to store the migrated entry bundles
+// compressed with zstd. See AppendOptions.WithEntryBundleCompression.
+func (o *MigrationOptions) WithEntryBundleCompression() *MigrationOptions {
+	o.entryBundleEncoding = compress.Zstd
+	return o
+}
+
+// WithEntryBundleGzipCompression instructs the underlying storage to store the migrated entry bundles
+// compressed with gzip. See AppendOptions.WithEntryBundleGzipCompression.
+func (o *MigrationOptions) WithEntryBundleGzipCompression() *MigrationOptions {
+	o.entryBundleEncoding = compress.Gzip
+	return o
+}
+
+// EntryBundleEncoding returns the content encoding with which entry bundles should be stored, or the empty
+// string if they should be stored as-is.
+func (o MigrationOptions) EntryBundleEncoding() string {
+	return o.entryBundleEncoding
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// EntryBundleKeyProvider wraps and unwraps the data keys with which entry bundles are encrypted at rest, when
+// enabled with WithEntryBundleEncryption.
+//
+// Implementations will typically encrypt data keys using a key held by a KMS, so that the key encryption key
+// never leaves it. Since wrapped data keys are stored with each bundle, UnwrapKey must continue to be able to
+// unwrap keys wrapped with earlier versions of the key encryption key if it's rotated.
+type EntryBundleKeyProvider interface {
+	// WrapKey encrypts the data key key, returning an opaque wrapped key of at most 65535 bytes.
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key previously wrapped with WrapKey.
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// WithEntryBundleEncryption instructs the underlying storage to encrypt entry bundles at rest with AES-256-GCM,
+// for logs whose entries contain data which must be encrypted at rest. Each bundle is encrypted with a data key
+// which is wrapped using kp, and stored with the bundle.
+//
+// Tiles and checkpoints are not encrypted, so the log's Merkle tree remains publicly verifiable, but clients
+// which read entry bundles directly from storage will be unable to read the entries. Storage decrypts the
+// bundles returned by its LogReader, so followers such as antispam are unaffected, as are personalities which
+// serve entry bundles through the LogReader. Storage records which bundles it encrypted, so bundles written
+// before this option was enabled for an existing log remain readable, but the option can't be disabled again.
+//
+// Bundles are compressed before they're encrypted if WithEntryBundleCompression is also set.
+func (o *AppendOptions) WithEntryBundleEncryption(kp EntryBundleKeyProvider) *AppendOptions {
+	o.entryBundleKeys = kp
+	return o
+}
+
+// EntryBundleKeyProvider returns the provider of the keys with which entry bundles should be encrypted, or nil
+// if they should be stored unencrypted.
+func (o AppendOptions) EntryBundleKeyProvider() EntryBundleKeyProvider {
+	return o.entryBundleKeys
+}
+
+// WithEntryBundleEncryption instructs the underlying storage to encrypt the migrated entry bundles at rest.
+// See AppendOptions.WithEntryBundleEncryption.
+func (o *MigrationOptions) WithEntryBundleEncryption(kp EntryBundleKeyProvider) *MigrationOptions {
+	o.entryBundleKeys = kp
+	return o
+}
+
+// EntryBundleKeyProvider returns the provider of the keys with which entry bundles should be encrypted, or nil
+// if they should be stored unencrypted.
+func (o MigrationOptions) EntryBundleKeyProvider() EntryBundleKeyProvider {
+	return o.entryBundleKeys
+}
+
+// NewAESKeyProvider returns an EntryBundleKeyProvider which wraps data keys with AES-GCM, using the 16, 24, or
+// 32 byte key encryption key kek.
+//
+// This is intended for operators who don't use a KMS; kek must be kept secret, and must not be lost, since
+// bundles can't be decrypted without it.
+func NewAESKeyProvider(kek []byte) (EntryBundleKeyProvider, error) {
+	b, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key: %v", err)
+	}
+	aead, err := cipher.NewGCM(b)
+	if err != nil {
+		return nil, err
+	}
+	return &aesKeyProvider{aead: aead}, nil
+}
+
+type aesKeyProvider struct {
+	aead cipher.AEAD
+}
+
+func (p *aesKeyProvider) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize(), p.aead.NonceSize()+len(key)+p.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return p.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *aesKeyProvider) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.aead.NonceSize() {
+		return nil, errors.New("wrapped key is truncated")
+	}
+	return p.aead.Open(nil, wrapped[:p.aead.NonceSize()], wrapped[p.aead.NonceSize():], nil)
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestAESKeyProvider(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name    string
+		kekLen  int
+		wantErr bool
+	}{
+		{name: "AES-128", kekLen: 16},
+		{name: "AES-256", kekLen: 32},
+		{name: "bad key", kekLen: 7, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			kp, err := NewAESKeyProvider(bytes.Repeat([]byte{1}, test.kekLen))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("NewAESKeyProvider: %v, want error %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			key := bytes.Repeat([]byte{2}, 32)
+			wrapped, err := kp.WrapKey(ctx, key)
+			if err != nil {
+				t.Fatalf("WrapKey: %v", err)
+			}
+			if bytes.Contains(wrapped, key) {
+				t.Errorf("WrapKey returned the key in the clear")
+			}
+			got, err := kp.UnwrapKey(ctx, wrapped)
+			if err != nil {
+				t.Fatalf("UnwrapKey: %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("UnwrapKey: got %x, want %x", got, key)
+			}
+			wrapped[len(wrapped)-1] ^= 1
+			if _, err := kp.UnwrapKey(ctx, wrapped); err == nil {
+				t.Errorf("UnwrapKey succeeded with tampered key")
+			}
+		})
+	}
+}
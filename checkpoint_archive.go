@@ -0,0 +1,78 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithCheckpointArchive configures the log to archive every checkpoint it publishes, so that auditors can verify
+// the complete history of the log's published tree heads rather than only its latest checkpoint.
+//
+// Each checkpoint is stored at checkpoints/{size}, alongside the log's checkpoint, and the sizes of the archived
+// checkpoints are listed in ascending order at checkpoints/index; see layout.ArchivedCheckpointPath and
+// api.CheckpointArchiveIndex. Checkpoints are archived before they're published, so every checkpoint the log
+// publishes is in the archive. Only the first checkpoint published for each tree size is archived.
+//
+// Only the retain most recent checkpoints are kept, and older ones are removed from the archive as new ones are
+// added. Since the index is rewritten each time a checkpoint is archived, retain must be between 1 and
+// MaxArchivedCheckpoints; archives which need to hold a log's entire history should be copied elsewhere.
+//
+// The driver must support archiving checkpoints, or NewAppender will return an error.
+func (o *AppendOptions) WithCheckpointArchive(retain uint) *AppendOptions {
+	o.archiveCheckpoints = true
+	o.checkpointArchiveRetain = retain
+	return o
+}
+
+// MaxArchivedCheckpoints is the largest number of checkpoints which WithCheckpointArchive can be configured to
+// retain. This bounds the checkpoint archive index to around 2MB.
+const MaxArchivedCheckpoints = 100_000
+
+// CheckpointArchiveReader is implemented by LogReaders which can read the checkpoints archived by a log
+// configured WithCheckpointArchive.
+type CheckpointArchiveReader interface {
+	// ReadArchivedCheckpoint returns the archived checkpoint with the given tree size.
+	// If there is no such checkpoint then os.ErrNotExist should be returned.
+	ReadArchivedCheckpoint(ctx context.Context, size uint64) ([]byte, error)
+
+	// ReadCheckpointArchiveIndex returns the serialised api.CheckpointArchiveIndex listing the sizes of the
+	// archived checkpoints. If no checkpoints have been archived then os.ErrNotExist should be returned.
+	ReadCheckpointArchiveIndex(ctx context.Context) ([]byte, error)
+}
+
+// checkpointArchiver is implemented by LogReaders which are able to archive the log's checkpoints.
+type checkpointArchiver interface {
+	// ArchiveCheckpoint adds the checkpoint with the given tree size to the archive, unless one with that size
+	// has already been archived. If retain is non-zero, the oldest checkpoints are removed so that no more than
+	// retain remain.
+	//
+	// This is used by the CheckpointPublisher; personalities should use WithCheckpointArchive instead.
+	ArchiveCheckpoint(ctx context.Context, size uint64, checkpoint []byte, retain uint) error
+}
+
+// checkpointArchive returns the archiver which should be used for checkpoints published via lr, or nil if
+// checkpoints shouldn't be archived.
+func (o AppendOptions) checkpointArchive(lr LogReader) (checkpointArchiver, error) {
+	if !o.archiveCheckpoints {
+		return nil, nil
+	}
+	a, ok := lr.(checkpointArchiver)
+	if !ok {
+		return nil, fmt.Errorf("WithCheckpointArchive is not supported by LogReader %T", lr)
+	}
+	return a, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// archivingLogReader is a fakeLogReader which records archived checkpoints.
+type archivingLogReader struct {
+	fakeLogReader
+	err      error
+	archived map[uint64][]byte
+	retain   uint
+}
+
+func (r *archivingLogReader) ArchiveCheckpoint(_ context.Context, size uint64, checkpoint []byte, retain uint) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.archived == nil {
+		r.archived = make(map[uint64][]byte)
+	}
+	r.archived[size] = checkpoint
+	r.retain = retain
+	return nil
+}
+
+func TestWithCheckpointArchive_Unsupported(t *testing.T) {
+	s, _ := mustCreateKeys(t, "test")
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointArchive(5)
+	if _, _, _, err := NewAppender(context.Background(), &fakeDriver{}, opts); err == nil {
+		t.Fatal("NewAppender: got nil error for driver which doesn't support checkpoint archiving")
+	}
+}
+
+func TestWithCheckpointArchive_Retain(t *testing.T) {
+	s, _ := mustCreateKeys(t, "test")
+	for _, test := range []struct {
+		retain  uint
+		wantErr bool
+	}{
+		{retain: 0, wantErr: true},
+		{retain: 1},
+		{retain: MaxArchivedCheckpoints},
+		{retain: MaxArchivedCheckpoints + 1, wantErr: true},
+	} {
+		err := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointArchive(test.retain).valid()
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("WithCheckpointArchive(%d): valid() = %v, want err %t", test.retain, err, test.wantErr)
+		}
+	}
+}
+
+func TestCheckpointPublisher_Archive(t *testing.T) {
+	ctx := context.Background()
+	s, _ := mustCreateKeys(t, "test")
+	for _, test := range []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name: "ok",
+		}, {
+			name:    "archive fails",
+			err:     errors.New("computer says no"),
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointArchive(5)
+			r := &archivingLogReader{err: test.err}
+			cp, err := opts.CheckpointPublisher(r, http.DefaultClient)(ctx, 3, make([]byte, 32))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("CheckpointPublisher: got err %v, want err %t", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if got := r.archived[3]; string(got) != string(cp) {
+				t.Errorf("archived checkpoint %q, want %q", got, cp)
+			}
+			if r.retain != 5 {
+				t.Errorf("archived with retain %d, want 5", r.retain)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultCheckpointDestinationMaxAttempts is used if CheckpointDestinationOptions.MaxAttempts is not set.
+	DefaultCheckpointDestinationMaxAttempts = 3
+	// DefaultCheckpointDestinationTimeout is used if CheckpointDestinationOptions.Timeout is not set.
+	DefaultCheckpointDestinationTimeout = 10 * time.Second
+
+	checkpointDestinationMinRetryDelay = 100 * time.Millisecond
+)
+
+// CheckpointDestination is somewhere, other than the log's own storage, to which each signed checkpoint is
+// published, e.g. a bucket in another region, a local file, or a witness gateway.
+type CheckpointDestination interface {
+	// Name identifies the destination in logs and metrics.
+	Name() string
+	// PublishCheckpoint stores the signed checkpoint at the destination, replacing the previous one.
+	// It may be called more than once with the same checkpoint, so must be idempotent.
+	PublishCheckpoint(ctx context.Context, checkpoint []byte) error
+}
+
+// CheckpointDestinationOptions configures the publication of checkpoints enabled with
+// WithCheckpointDestinations.
+type CheckpointDestinationOptions struct {
+	// Required, if true, prevents the log's own checkpoint from being updated until the new checkpoint has been
+	// published to every destination, so that no destination falls behind the log. Publication will be
+	// attempted again with the next checkpoint.
+	//
+	// Otherwise, failures are logged and counted by the tessera.appender.checkpoint.destination.failures metric,
+	// and the log's checkpoint is updated regardless.
+	Required bool
+	// MaxAttempts is the number of times publication to each destination is attempted before it's considered
+	// to have failed. Defaults to DefaultCheckpointDestinationMaxAttempts.
+	MaxAttempts int
+	// Timeout is the timeout of each attempt to publish a checkpoint to a destination.
+	// Defaults to DefaultCheckpointDestinationTimeout.
+	Timeout time.Duration
+}
+
+// WithCheckpointDestinations configures the Appender to publish each newly signed checkpoint to dests, as well
+// as to the log's own storage, so that the availability of the checkpoint doesn't depend on a single store.
+//
+// Checkpoints are published to all of the destinations in parallel, once they've been witnessed, and before
+// they're written to the log's storage. Failed attempts are retried with exponential backoff, independently for
+// each destination.
+func (o *AppendOptions) WithCheckpointDestinations(dests []CheckpointDestination, opts *CheckpointDestinationOptions) *AppendOptions {
+	do := CheckpointDestinationOptions{}
+	if opts != nil {
+		do = *opts
+	}
+	if do.MaxAttempts <= 0 {
+		do.MaxAttempts = DefaultCheckpointDestinationMaxAttempts
+	}
+	if do.Timeout <= 0 {
+		do.Timeout = DefaultCheckpointDestinationTimeout
+	}
+	o.checkpointDests = &checkpointDestinations{dests: dests, opts: do, minDelay: checkpointDestinationMinRetryDelay}
+	return o
+}
+
+// checkpointDestinations publishes checkpoints to the destinations configured with WithCheckpointDestinations.
+type checkpointDestinations struct {
+	dests    []CheckpointDestination
+	opts     CheckpointDestinationOptions
+	minDelay time.Duration
+}
+
+// publish publishes cp to every destination, returning an error if it could not be published to all of them
+// and CheckpointDestinationOptions.Required is set.
+func (d *checkpointDestinations) publish(ctx context.Context, cp []byte) error {
+	if d == nil || len(d.dests) == 0 {
+		return nil
+	}
+	ctx, span := tracer.Start(ctx, "tessera.PublishCheckpointDestinations")
+	defer span.End()
+
+	errs := make([]error, len(d.dests))
+	wg := sync.WaitGroup{}
+	for i, dest := range d.dests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.publishTo(ctx, dest, cp); err != nil {
+				appenderCheckpointDestinationFailures.Add(ctx, 1, metric.WithAttributes(checkpointDestinationKey.String(dest.Name())))
+				errs[i] = fmt.Errorf("%s: %v", dest.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	err := errors.Join(errs...)
+	if err == nil {
+		return nil
+	}
+	if d.opts.Required {
+		return fmt.Errorf("failed to publish checkpoint to required destinations: %v", err)
+	}
+	klog.Errorf("Failed to publish checkpoint to destinations: %v", err)
+	return nil
+}
+
+// publishTo publishes cp to dest, retrying as necessary.
+func (d *checkpointDestinations) publishTo(ctx context.Context, dest CheckpointDestination, cp []byte) error {
+	delay := d.minDelay
+	for attempt := 1; ; attempt++ {
+		actx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+		err := dest.PublishCheckpoint(actx, cp)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= d.opts.MaxAttempts {
+			return fmt.Errorf("attempt %d: %v", attempt, err)
+		}
+		klog.V(1).Infof("Attempt %d to publish checkpoint to %s failed, retrying in %v: %v", attempt, dest.Name(), delay, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// NewCheckpointDestination returns a CheckpointDestination which publishes checkpoints by calling f.
+//
+// This can be used to publish checkpoints to other storage, e.g. a bucket in another region, using the
+// client library of its provider.
+func NewCheckpointDestination(name string, f func(ctx context.Context, checkpoint []byte) error) CheckpointDestination {
+	return &funcCheckpointDestination{name: name, f: f}
+}
+
+type funcCheckpointDestination struct {
+	name string
+	f    func(context.Context, []byte) error
+}
+
+func (d *funcCheckpointDestination) Name() string {
+	return d.name
+}
+
+func (d *funcCheckpointDestination) PublishCheckpoint(ctx context.Context, cp []byte) error {
+	return d.f(ctx, cp)
+}
+
+// NewFileCheckpointDestination returns a CheckpointDestination which atomically overwrites the file at path
+// with each checkpoint.
+func NewFileCheckpointDestination(path string) CheckpointDestination {
+	return NewCheckpointDestination("file:"+path, func(_ context.Context, cp []byte) error {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, cp, 0o644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, path)
+	})
+}
+
+// NewHTTPCheckpointDestination returns a CheckpointDestination which POSTs each checkpoint to url, e.g. that of
+// a witness gateway or a mirror. Any 2xx status is treated as success.
+//
+// If client is nil, http.DefaultClient is used.
+func NewHTTPCheckpointDestination(url string, client *http.Client) CheckpointDestination {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return NewCheckpointDestination(url, func(ctx context.Context, cp []byte) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(cp))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("status %s", resp.Status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// flakyDestination is a CheckpointDestination which fails the first failures attempts to publish to it.
+type flakyDestination struct {
+	name     string
+	failures int
+
+	mu       sync.Mutex
+	attempts int
+	got      []byte
+}
+
+func (d *flakyDestination) Name() string {
+	return d.name
+}
+
+func (d *flakyDestination) PublishCheckpoint(_ context.Context, cp []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.attempts++
+	if d.attempts <= d.failures {
+		return errors.New("unavailable")
+	}
+	d.got = cp
+	return nil
+}
+
+func TestCheckpointDestinations(t *testing.T) {
+	ctx := context.Background()
+	cp := []byte("example.com/log\n3\nroot\n")
+	for _, test := range []struct {
+		name     string
+		failures []int
+		required bool
+		wantErr  bool
+	}{
+		{name: "all succeed", failures: []int{0, 0}},
+		{name: "retried", failures: []int{2, 1}},
+		{name: "optional destination fails", failures: []int{0, 5}},
+		{name: "required destination fails", failures: []int{0, 5}, required: true, wantErr: true},
+		{name: "required destination retried", failures: []int{2, 0}, required: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dests := []CheckpointDestination{}
+			for i, f := range test.failures {
+				dests = append(dests, &flakyDestination{name: string(rune('a' + i)), failures: f})
+			}
+			o := NewAppendOptions().WithCheckpointDestinations(dests, &CheckpointDestinationOptions{Required: test.required})
+			o.checkpointDests.minDelay = 0
+			err := o.checkpointDests.publish(ctx, cp)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("publish: %v, want error %t", err, test.wantErr)
+			}
+			for i, d := range dests {
+				fd := d.(*flakyDestination)
+				wantAttempts := min(test.failures[i]+1, DefaultCheckpointDestinationMaxAttempts)
+				if fd.attempts != wantAttempts {
+					t.Errorf("destination %s: got %d attempts, want %d", fd.name, fd.attempts, wantAttempts)
+				}
+				if succeeded := fd.got != nil; succeeded != (test.failures[i] < DefaultCheckpointDestinationMaxAttempts) {
+					t.Errorf("destination %s: got checkpoint %q", fd.name, fd.got)
+				}
+			}
+		})
+	}
+}
+
+func TestFileCheckpointDestination(t *testing.T) {
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "mirror", "checkpoint")
+	d := NewFileCheckpointDestination(p)
+	for _, cp := range []string{"first", "second"} {
+		if err := d.PublishCheckpoint(ctx, []byte(cp)); err != nil {
+			t.Fatalf("PublishCheckpoint: %v", err)
+		}
+		got, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if string(got) != cp {
+			t.Errorf("got checkpoint %q, want %q", got, cp)
+		}
+	}
+}
+
+func TestHTTPCheckpointDestination(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "conflict", status: http.StatusConflict, wantErr: true},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("got method %s, want POST", r.Method)
+				}
+				got, _ = io.ReadAll(r.Body)
+				w.WriteHeader(test.status)
+			}))
+			defer srv.Close()
+
+			err := NewHTTPCheckpointDestination(srv.URL, nil).PublishCheckpoint(ctx, []byte("checkpoint"))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("PublishCheckpoint: %v, want error %t", err, test.wantErr)
+			}
+			if string(got) != "checkpoint" {
+				t.Errorf("got body %q, want %q", got, "checkpoint")
+			}
+		})
+	}
+}
+
+func TestCheckpointPublisherDestinations(t *testing.T) {
+	ctx := context.Background()
+	s, _ := mustCreateKeys(t, "example.com/log")
+	for _, test := range []struct {
+		name     string
+		required bool
+		wantErr  bool
+	}{
+		{name: "optional"},
+		{name: "required", required: true, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ok, broken := &flakyDestination{name: "ok"}, &flakyDestination{name: "broken", failures: 5}
+			opts := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointDestinations([]CheckpointDestination{ok, broken}, &CheckpointDestinationOptions{Required: test.required})
+			opts.checkpointDests.minDelay = 0
+			cp, err := opts.CheckpointPublisher(&fakeLogReader{}, http.DefaultClient)(ctx, 0, make([]byte, 32))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("CheckpointPublisher: %v, want error %t", err, test.wantErr)
+			}
+			if err == nil && string(ok.got) != string(cp) {
+				t.Errorf("destination got checkpoint %q, want %q", ok.got, cp)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	f_log "github.com/transparency-dev/formats/log"
+)
+
+// CheckpointFormatter allows personalities to customise the body of the checkpoints published by the log.
+//
+// Tessera always constructs the size and root hash lines itself, so a CheckpointFormatter cannot cause a
+// checkpoint to commit to anything other than the tree which has been integrated.
+type CheckpointFormatter interface {
+	// Origin returns the origin line to use in checkpoints, given the name of the primary checkpoint signer.
+	//
+	// Note that many verifiers, including tlog-witness, expect the origin line to match the key name.
+	Origin(signerName string) string
+	// Extensions returns any extension lines which should follow the root hash in the checkpoint for a tree
+	// of the given size and root hash, e.g. a timestamp.
+	//
+	// Extension lines must be non-empty, and must not contain newlines.
+	Extensions(ctx context.Context, size uint64, hash []byte) ([]string, error)
+}
+
+// WithCheckpointFormatter configures the Appender to use the provided CheckpointFormatter when constructing
+// the body of new checkpoints.
+//
+// If this option isn't provided, checkpoints will have an origin line which matches the checkpoint signer's
+// name, and no extension lines.
+func (o *AppendOptions) WithCheckpointFormatter(f CheckpointFormatter) *AppendOptions {
+	o.checkpointFormatter = f
+	return o
+}
+
+// checkpointBody returns the body of a checkpoint committing to a tree of the given size and root hash.
+func checkpointBody(ctx context.Context, f CheckpointFormatter, signerName string, size uint64, hash []byte) ([]byte, error) {
+	if f == nil {
+		return f_log.Checkpoint{Origin: signerName, Size: size, Hash: hash}.Marshal(), nil
+	}
+	origin := f.Origin(signerName)
+	if origin == "" || strings.Contains(origin, "\n") {
+		return nil, fmt.Errorf("invalid checkpoint origin %q", origin)
+	}
+	ext, err := f.Extensions(ctx, size, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint extensions: %v", err)
+	}
+	b := f_log.Checkpoint{Origin: origin, Size: size, Hash: hash}.Marshal()
+	for _, l := range ext {
+		if l == "" || strings.Contains(l, "\n") {
+			return nil, fmt.Errorf("invalid checkpoint extension line %q", l)
+		}
+		b = append(b, l...)
+		b = append(b, '\n')
+	}
+	return b, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+type fakeFormatter struct {
+	origin string
+	ext    []string
+	err    error
+}
+
+func (f fakeFormatter) Origin(name string) string {
+	if f.origin == "" {
+		return name
+	}
+	return f.origin
+}
+
+func (f fakeFormatter) Extensions(_ context.Context, _ uint64, _ []byte) ([]string, error) {
+	return f.ext, f.err
+}
+
+func TestWithCheckpointFormatter(t *testing.T) {
+	ctx := context.Background()
+	sk, vk, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	root := sha256.Sum256([]byte("root"))
+	hashLine := fmt.Sprintf("42\n%s\n", base64.StdEncoding.EncodeToString(root[:]))
+	for _, test := range []struct {
+		name     string
+		f        CheckpointFormatter
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name:     "default",
+			wantBody: "example.com/log\n" + hashLine,
+		}, {
+			name:     "extensions",
+			f:        fakeFormatter{ext: []string{"X-Tessera-Witnessed", "Timestamp: 1234"}},
+			wantBody: "example.com/log\n" + hashLine + "X-Tessera-Witnessed\nTimestamp: 1234\n",
+		}, {
+			name:     "custom origin",
+			f:        fakeFormatter{origin: "other.example.com"},
+			wantBody: "other.example.com\n" + hashLine,
+		}, {
+			name:    "empty extension",
+			f:       fakeFormatter{ext: []string{""}},
+			wantErr: true,
+		}, {
+			name:    "multi-line extension",
+			f:       fakeFormatter{ext: []string{"one\ntwo"}},
+			wantErr: true,
+		}, {
+			name:    "multi-line origin",
+			f:       fakeFormatter{origin: "one\ntwo"},
+			wantErr: true,
+		}, {
+			name:    "extension error",
+			f:       fakeFormatter{err: errors.New("bang")},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := NewAppendOptions().WithCheckpointSigner(s)
+			if test.f != nil {
+				opts.WithCheckpointFormatter(test.f)
+			}
+			cp, err := opts.newCP(ctx, 42, root[:])
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("newCP: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			n, err := note.Open(cp, note.VerifierList(v))
+			if err != nil {
+				t.Fatalf("note.Open: %v", err)
+			}
+			if n.Text != test.wantBody {
+				t.Errorf("got checkpoint body %q, want %q", n.Text, test.wantBody)
+			}
+		})
+	}
+}
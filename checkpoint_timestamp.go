@@ -0,0 +1,165 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RFC3161ExtensionPrefix is the prefix of the checkpoint extension line added by the CheckpointTimestamper
+// returned by NewRFC3161Timestamper. The remainder of the line is the base64 encoded DER TimeStampToken.
+const RFC3161ExtensionPrefix = "rfc3161 "
+
+// CheckpointTimestamper obtains a trusted timestamp for checkpoints, so that relying parties have a trusted
+// notion of when a tree head existed.
+type CheckpointTimestamper interface {
+	// TimestampCheckpoint returns an extension line holding a timestamp for the provided checkpoint body.
+	// The body includes the origin, size, and root hash lines, and any extension lines which precede this one.
+	//
+	// The returned line must be non-empty, and must not contain newlines.
+	TimestampCheckpoint(ctx context.Context, body []byte) (string, error)
+}
+
+// WithCheckpointTimestamper configures the Appender to add an extension line containing a timestamp obtained
+// from the provided CheckpointTimestamper to each new checkpoint, after any extension lines provided by the
+// CheckpointFormatter. If timestamping fails, the checkpoint will not be published.
+//
+// A signed timestamp may alternatively be provided by including a cosignature/v1 signer, e.g. one created by
+// github.com/transparency-dev/formats/note.NewSignerForCosignatureV1 or signer.NewCosignatureV1, as one of
+// the additional signers passed to WithCheckpointSigner.
+func (o *AppendOptions) WithCheckpointTimestamper(t CheckpointTimestamper) *AppendOptions {
+	o.checkpointTimestampers = append(o.checkpointTimestampers, t)
+	return o
+}
+
+// timestampCheckpoint appends an extension line from each of the timestampers to the checkpoint body.
+func timestampCheckpoint(ctx context.Context, ts []CheckpointTimestamper, body []byte) ([]byte, error) {
+	for _, t := range ts {
+		l, err := t.TimestampCheckpoint(ctx, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to timestamp checkpoint: %v", err)
+		}
+		if l == "" || strings.Contains(l, "\n") {
+			return nil, fmt.Errorf("invalid checkpoint timestamp line %q", l)
+		}
+		body = append(body, l...)
+		body = append(body, '\n')
+	}
+	return body, nil
+}
+
+var oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+
+// The following types are the parts of the RFC 3161 timestamp protocol structures which are needed by the client.
+
+type rfc3161AlgorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type rfc3161MessageImprint struct {
+	HashAlgorithm rfc3161AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type rfc3161Request struct {
+	Version        int
+	MessageImprint rfc3161MessageImprint
+	CertReq        bool `asn1:"optional,default:false"`
+}
+
+type rfc3161StatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type rfc3161Response struct {
+	Status         rfc3161StatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// rfc3161Timestamper is a CheckpointTimestamper which uses an RFC 3161 Time Stamp Authority.
+type rfc3161Timestamper struct {
+	url    string
+	client *http.Client
+}
+
+// NewRFC3161Timestamper returns a CheckpointTimestamper which requests a timestamp token from the RFC 3161
+// Time Stamp Authority at the given URL, using the provided HTTP client.
+//
+// The token's message imprint is the SHA-256 hash of the checkpoint body which precedes the extension line,
+// and the TSA is asked to include its certificate in the token so that relying parties can verify it.
+// The token itself is not verified by the Appender.
+func NewRFC3161Timestamper(url string, client *http.Client) CheckpointTimestamper {
+	return &rfc3161Timestamper{url: url, client: client}
+}
+
+func (t *rfc3161Timestamper) TimestampCheckpoint(ctx context.Context, body []byte) (string, error) {
+	h := sha256.Sum256(body)
+	req, err := asn1.Marshal(rfc3161Request{
+		Version: 1,
+		MessageImprint: rfc3161MessageImprint{
+			HashAlgorithm: rfc3161AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: h[:],
+		},
+		CertReq: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal timestamp request: %v", err)
+	}
+	hr, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(req))
+	if err != nil {
+		return "", err
+	}
+	hr.Header.Set("Content-Type", "application/timestamp-query")
+	resp, err := t.client.Do(hr)
+	if err != nil {
+		return "", fmt.Errorf("timestamp request failed: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	rb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read timestamp response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("timestamp request got status %d: %s", resp.StatusCode, bytes.TrimSpace(rb))
+	}
+	var tr rfc3161Response
+	if rest, err := asn1.Unmarshal(rb, &tr); err != nil {
+		return "", fmt.Errorf("failed to unmarshal timestamp response: %v", err)
+	} else if len(rest) > 0 {
+		return "", errors.New("trailing data after timestamp response")
+	}
+	// Status 0 is granted, and 1 is grantedWithMods.
+	if s := tr.Status.Status; s != 0 && s != 1 {
+		return "", fmt.Errorf("timestamp request was rejected with status %d: %s", s, strings.Join(tr.Status.StatusString, "; "))
+	}
+	if len(tr.TimeStampToken.FullBytes) == 0 {
+		return "", errors.New("timestamp response has no token")
+	}
+	return RFC3161ExtensionPrefix + base64.StdEncoding.EncodeToString(tr.TimeStampToken.FullBytes), nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// fakeTSA returns a Time Stamp Authority which responds with the given status, and a token which is just the
+// DER encoded message imprint from the request.
+func fakeTSA(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/timestamp-query" {
+			http.Error(w, "bad content type "+ct, http.StatusBadRequest)
+			return
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req rfc3161Request
+		if _, err := asn1.Unmarshal(b, &req); err != nil || !req.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := rfc3161Response{Status: rfc3161StatusInfo{Status: status}}
+		if status == 0 {
+			tok, err := asn1.Marshal(req.MessageImprint)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			resp.TimeStampToken = asn1.RawValue{FullBytes: tok}
+		}
+		rb, err := asn1.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, _ = w.Write(rb)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRFC3161Timestamper(t *testing.T) {
+	body := []byte("example.com/log\n1\nAAAA\n")
+	for _, test := range []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "granted", status: 0},
+		{name: "rejected", status: 2, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := fakeTSA(t, test.status)
+			l, err := NewRFC3161Timestamper(srv.URL, srv.Client()).TimestampCheckpoint(context.Background(), body)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("TimestampCheckpoint: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			tok, ok := strings.CutPrefix(l, RFC3161ExtensionPrefix)
+			if !ok {
+				t.Fatalf("got line %q, want prefix %q", l, RFC3161ExtensionPrefix)
+			}
+			der, err := base64.StdEncoding.DecodeString(tok)
+			if err != nil {
+				t.Fatalf("DecodeString: %v", err)
+			}
+			var imprint rfc3161MessageImprint
+			if _, err := asn1.Unmarshal(der, &imprint); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if want := sha256.Sum256(body); !bytes.Equal(imprint.HashedMessage, want[:]) {
+				t.Errorf("got imprint %x, want %x", imprint.HashedMessage, want)
+			}
+		})
+	}
+}
+
+type fixedTimestamper string
+
+func (f fixedTimestamper) TimestampCheckpoint(_ context.Context, _ []byte) (string, error) {
+	return string(f), nil
+}
+
+func TestWithCheckpointTimestamper(t *testing.T) {
+	sk, _, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	for _, test := range []struct {
+		name    string
+		line    string
+		wantErr bool
+	}{
+		{name: "ok", line: "timestamp 1234"},
+		{name: "empty", line: "", wantErr: true},
+		{name: "newline", line: "a\nb", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointTimestamper(fixedTimestamper(test.line))
+			cp, err := opts.newCP(context.Background(), 0, nil)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("newCP: got err %v, want err %t", err, test.wantErr)
+			}
+			if err == nil && !bytes.Contains(cp, []byte("\n"+test.line+"\n\n")) {
+				t.Errorf("checkpoint %q does not end with extension line %q", cp, test.line)
+			}
+		})
+	}
+}
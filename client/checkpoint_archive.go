@@ -0,0 +1,136 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/internal/otel"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// ArchivedCheckpointFetcherFunc is the signature of a function which can fetch the archived checkpoint
+// with the given tree size from a log configured with tessera.WithCheckpointArchive.
+//
+// Note that the implementation of this MUST return (either directly or wrapped) an os.ErrNotExist
+// when the log has no archived checkpoint with the given size.
+type ArchivedCheckpointFetcherFunc func(ctx context.Context, size uint64) ([]byte, error)
+
+// CheckpointArchiveIndexFetcherFunc is the signature of a function which can fetch the index of a log's
+// archived checkpoints.
+//
+// Note that the implementation of this MUST return (either directly or wrapped) an os.ErrNotExist
+// when the log has no archived checkpoints.
+type CheckpointArchiveIndexFetcherFunc func(ctx context.Context) ([]byte, error)
+
+// FetchArchivedCheckpoint retrieves and opens the archived checkpoint with the given tree size.
+// Returns both the parsed structure and the raw serialised checkpoint.
+func FetchArchivedCheckpoint(ctx context.Context, f ArchivedCheckpointFetcherFunc, size uint64, v note.Verifier, origin string) (*log.Checkpoint, []byte, *note.Note, error) {
+	ctx, span := tracer.Start(ctx, "tessera.client.FetchArchivedCheckpoint")
+	defer span.End()
+	span.SetAttributes(logSizeKey.Int64(otel.Clamp64(size)))
+
+	cpRaw, err := f(ctx, size)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cp, _, n, err := log.ParseCheckpoint(cpRaw, origin, v)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse archived Checkpoint %d: %v", size, err)
+	}
+	if cp.Size != size {
+		return nil, nil, nil, fmt.Errorf("archived Checkpoint %d has size %d", size, cp.Size)
+	}
+	return cp, cpRaw, n, nil
+}
+
+// FetchCheckpointArchiveSizes returns the tree sizes of a log's archived checkpoints, in ascending order.
+func FetchCheckpointArchiveSizes(ctx context.Context, f CheckpointArchiveIndexFetcherFunc) ([]uint64, error) {
+	ctx, span := tracer.Start(ctx, "tessera.client.FetchCheckpointArchiveSizes")
+	defer span.End()
+
+	raw, err := f(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx := api.CheckpointArchiveIndex{}
+	if err := idx.UnmarshalText(raw); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint archive index: %v", err)
+	}
+	return idx.Sizes, nil
+}
+
+// VerifyCheckpointArchive verifies the complete history of a log's published checkpoints, as recorded in its
+// checkpoint archive: every archived checkpoint must be signed by v, and each must be consistent with the next.
+//
+// Returns the largest archived checkpoint, and its raw serialisation, so that the caller can go on to check
+// that it's consistent with the log's current checkpoint. If the log has presented inconsistent checkpoints,
+// the returned error is an ErrInconsistency holding the evidence.
+func VerifyCheckpointArchive(ctx context.Context, idxF CheckpointArchiveIndexFetcherFunc, cpF ArchivedCheckpointFetcherFunc, tileF TileFetcherFunc, v note.Verifier, origin string) (*log.Checkpoint, []byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.client.VerifyCheckpointArchive")
+	defer span.End()
+
+	sizes, err := FetchCheckpointArchiveSizes(ctx, idxF)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sizes) == 0 {
+		return nil, nil, fmt.Errorf("checkpoint archive is empty")
+	}
+	latest, latestRaw, _, err := FetchArchivedCheckpoint(ctx, cpF, sizes[len(sizes)-1], v, origin)
+	if err != nil {
+		return nil, nil, err
+	}
+	pb, err := NewProofBuilder(ctx, latest.Size, tileF)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create proof builder: %v", err)
+	}
+
+	prev, prevRaw := latest, latestRaw
+	if len(sizes) > 1 {
+		if prev, prevRaw, _, err = FetchArchivedCheckpoint(ctx, cpF, sizes[0], v, origin); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, s := range sizes[1:] {
+		cp, cpRaw := latest, latestRaw
+		if s != latest.Size {
+			if cp, cpRaw, _, err = FetchArchivedCheckpoint(ctx, cpF, s, v, origin); err != nil {
+				return nil, nil, err
+			}
+		}
+		if prev.Size > 0 {
+			p, err := pb.ConsistencyProof(ctx, prev.Size, cp.Size)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to build consistency proof from %d to %d: %v", prev.Size, cp.Size, err)
+			}
+			if err := proof.VerifyConsistency(hasher, prev.Size, cp.Size, p, prev.Hash, cp.Hash); err != nil {
+				return nil, nil, ErrInconsistency{
+					SmallerRaw: prevRaw,
+					LargerRaw:  cpRaw,
+					Proof:      p,
+					Report:     pb.ConsistencyReport(ctx, prev.Size, cp.Size, err),
+					Wrapped:    err,
+				}
+			}
+		}
+		prev, prevRaw = cp, cpRaw
+	}
+	return latest, latestRaw, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/transparency-dev/tessera/api"
+)
+
+func TestVerifyCheckpointArchive(t *testing.T) {
+	ctx := context.Background()
+	last := uint64(len(testCheckpoints) - 1)
+	for _, test := range []struct {
+		desc     string
+		sizes    []uint64
+		archive  map[uint64][]byte
+		wantSize uint64
+		wantErr  bool
+	}{
+		{
+			desc:     "complete history",
+			sizes:    []uint64{0, 1, 2, 5, 10, last},
+			wantSize: last,
+		}, {
+			desc:     "single checkpoint",
+			sizes:    []uint64{5},
+			wantSize: 5,
+		}, {
+			desc:    "empty",
+			sizes:   []uint64{},
+			wantErr: true,
+		}, {
+			desc:    "missing checkpoint",
+			sizes:   []uint64{1, 2, 5},
+			archive: map[uint64][]byte{2: nil},
+			wantErr: true,
+		}, {
+			desc:    "checkpoint archived at wrong size",
+			sizes:   []uint64{1, 2, 5},
+			archive: map[uint64][]byte{2: testRawCheckpoints[3]},
+			wantErr: true,
+		}, {
+			desc:    "bad signature",
+			sizes:   []uint64{1, 2, 5},
+			archive: map[uint64][]byte{2: bytes.Replace(testRawCheckpoints[2], []byte("\n2\n"), []byte("\n3\n"), 1)},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			idxF := func(context.Context) ([]byte, error) {
+				return api.CheckpointArchiveIndex{Sizes: test.sizes}.MarshalText()
+			}
+			cpF := func(_ context.Context, size uint64) ([]byte, error) {
+				if cp, ok := test.archive[size]; ok {
+					if cp == nil {
+						return nil, fmt.Errorf("checkpoint %d: %w", size, os.ErrNotExist)
+					}
+					return cp, nil
+				}
+				return testRawCheckpoints[size], nil
+			}
+			cp, raw, err := VerifyCheckpointArchive(ctx, idxF, cpF, testLogTileFetcher, testLogVerifier, testOrigin)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("VerifyCheckpointArchive: %v, wantErr %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cp.Size != test.wantSize || !bytes.Equal(raw, testRawCheckpoints[test.wantSize]) {
+				t.Errorf("VerifyCheckpointArchive returned checkpoint of size %d, want %d", cp.Size, test.wantSize)
+			}
+		})
+	}
+}
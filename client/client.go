@@ -24,16 +24,19 @@ import (
 	"sync"
 
 	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/compact"
 	"github.com/transparency-dev/merkle/proof"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/internal/otel"
+	"github.com/transparency-dev/tessera/witness/policy"
 	"golang.org/x/mod/sumdb/note"
 )
 
 var (
+	// hasher is the default LogHasher, as used by logs which implement https://c2sp.org/tlog-tiles.
 	hasher = rfc6962.DefaultHasher
 )
 
@@ -46,6 +49,8 @@ type ErrInconsistency struct {
 	SmallerRaw []byte
 	LargerRaw  []byte
 	Proof      [][]byte
+	// Report describes the tiles and tree nodes the proof was built from, if known.
+	Report *VerificationReport
 
 	Wrapped error
 }
@@ -58,6 +63,28 @@ func (e ErrInconsistency) Error() string {
 	return fmt.Sprintf("log consistency check failed: %s", e.Wrapped)
 }
 
+// ErrInclusion is returned by ProofBuilder.VerifyInclusion when an inclusion proof fails to verify.
+// It includes the proof and a report of the tiles it was built from, so that the failure can be
+// investigated by the caller.
+type ErrInclusion struct {
+	Index    uint64
+	TreeSize uint64
+	LeafHash []byte
+	Proof    [][]byte
+	// Report describes the tiles and tree nodes the proof was built from.
+	Report *VerificationReport
+
+	Wrapped error
+}
+
+func (e ErrInclusion) Unwrap() error {
+	return e.Wrapped
+}
+
+func (e ErrInclusion) Error() string {
+	return fmt.Sprintf("inclusion check for leaf %d in tree size %d failed: %s", e.Index, e.TreeSize, e.Wrapped)
+}
+
 // CheckpointFetcherFunc is the signature of a function which can retrieve the latest
 // checkpoint from a log's data storage.
 //
@@ -86,6 +113,14 @@ type TileFetcherFunc func(ctx context.Context, level, index uint64, p uint8) ([]
 //     fallback bundle exists.
 type EntryBundleFetcherFunc func(ctx context.Context, bundleIndex uint64, p uint8) ([]byte, error)
 
+// EntryBundlesFetcherFunc is the signature of a function which can fetch the raw data
+// for count consecutive full entry bundles, starting with fromBundle, e.g. tessera.LogReader.ReadEntryBundles.
+//
+// Note that the implementation of this MUST return all of the requested bundles, in order, or
+// an error. If any of the bundles does not exist, the error MUST be (either directly or wrapped)
+// an os.ErrIsNotExist.
+type EntryBundlesFetcherFunc func(ctx context.Context, fromBundle, count uint64) ([][]byte, error)
+
 // ConsensusCheckpointFunc is a function which returns the largest checkpoint known which is
 // signed by logSigV and satisfies some consensus algorithm.
 //
@@ -99,6 +134,24 @@ func UnilateralConsensus(f CheckpointFetcherFunc) ConsensusCheckpointFunc {
 	}
 }
 
+// WitnessConsensus returns a ConsensusCheckpointFunc which only accepts checkpoints from the source log if they're
+// cosigned by enough witnesses to satisfy the provided witness policy.
+//
+// This is the client-side counterpart of configuring a log to be witnessed with tessera.NewWitnessGroupFromPolicy,
+// so the same policy can be used by both.
+func WitnessConsensus(f CheckpointFetcherFunc, p *policy.Policy) ConsensusCheckpointFunc {
+	return func(ctx context.Context, logSigV note.Verifier, origin string) (*log.Checkpoint, []byte, *note.Note, error) {
+		cp, cpRaw, n, err := FetchCheckpoint(ctx, f, logSigV, origin)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !p.Satisfied(cpRaw) {
+			return nil, nil, nil, fmt.Errorf("checkpoint of size %d is not cosigned by enough witnesses to satisfy policy", cp.Size)
+		}
+		return cp, cpRaw, n, nil
+	}
+}
+
 // FetchCheckpoint retrieves and opens a checkpoint from the log.
 // Returns both the parsed structure and the raw serialised checkpoint.
 func FetchCheckpoint(ctx context.Context, f CheckpointFetcherFunc, v note.Verifier, origin string) (*log.Checkpoint, []byte, *note.Note, error) {
@@ -119,11 +172,26 @@ func FetchCheckpoint(ctx context.Context, f CheckpointFetcherFunc, v note.Verifi
 // FetchRangeNodes returns the set of nodes representing the compact range covering
 // a log of size s.
 func FetchRangeNodes(ctx context.Context, s uint64, f TileFetcherFunc) ([][]byte, error) {
+	return FetchRangeNodesWithHasher(ctx, s, f, hasher)
+}
+
+// FetchRangeNodesWithHasher is like FetchRangeNodes, but for logs whose Merkle tree is built using h rather than
+// the default RFC6962 SHA-256 hasher.
+func FetchRangeNodesWithHasher(ctx context.Context, s uint64, f TileFetcherFunc, h merkle.LogHasher) ([][]byte, error) {
+	return FetchRangeNodesWithTiling(ctx, s, f, h, layout.DefaultTiling)
+}
+
+// FetchRangeNodesWithTiling is like FetchRangeNodesWithHasher, but for logs whose tiles are described by tl
+// rather than layout.DefaultTiling. See FetchTiling.
+func FetchRangeNodesWithTiling(ctx context.Context, s uint64, f TileFetcherFunc, h merkle.LogHasher, tl layout.Tiling) ([][]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.client.FetchRangeNodes")
 	defer span.End()
 	span.SetAttributes(logSizeKey.Int64(otel.Clamp64(s)))
 
-	nc := newNodeCache(f, s)
+	if err := validTiling(tl); err != nil {
+		return nil, err
+	}
+	nc := newNodeCache(f, s, h, tl)
 	nIDs := make([]compact.NodeID, 0, compact.RangeSize(0, s))
 	nIDs = compact.RangeNodes(0, s, nIDs)
 	hashes := make([][]byte, 0, len(nIDs))
@@ -144,7 +212,7 @@ func FetchLeafHashes(ctx context.Context, f TileFetcherFunc, first, N, logSize u
 
 	span.SetAttributes(firstKey.Int64(otel.Clamp64(first)), NKey.Int64(otel.Clamp64(N)), logSizeKey.Int64(otel.Clamp64(logSize)))
 
-	nc := newNodeCache(f, logSize)
+	nc := newNodeCache(f, logSize, hasher, layout.DefaultTiling)
 	hashes := make([][]byte, 0, N)
 	for i, end := first, first+N; i < end; i++ {
 		nID := compact.NodeID{Level: 0, Index: i}
@@ -181,15 +249,32 @@ func GetEntryBundle(ctx context.Context, f EntryBundleFetcherFunc, i, logSize ui
 type ProofBuilder struct {
 	treeSize  uint64
 	nodeCache nodeCache
+	hasher    merkle.LogHasher
 }
 
 // NewProofBuilder creates a new ProofBuilder object for a given tree size.
 // The returned ProofBuilder can be re-used for proofs related to a given tree size, but
 // it is not thread-safe and should not be accessed concurrently.
 func NewProofBuilder(ctx context.Context, treeSize uint64, f TileFetcherFunc) (*ProofBuilder, error) {
+	return NewProofBuilderWithHasher(ctx, treeSize, f, hasher)
+}
+
+// NewProofBuilderWithHasher is like NewProofBuilder, but for logs whose Merkle tree is built using
+// the provided LogHasher rather than the default RFC6962 SHA-256 one.
+func NewProofBuilderWithHasher(ctx context.Context, treeSize uint64, f TileFetcherFunc, h merkle.LogHasher) (*ProofBuilder, error) {
+	return NewProofBuilderWithTiling(ctx, treeSize, f, h, layout.DefaultTiling)
+}
+
+// NewProofBuilderWithTiling is like NewProofBuilderWithHasher, but for logs whose tiles are described by tl
+// rather than layout.DefaultTiling. See FetchTiling.
+func NewProofBuilderWithTiling(ctx context.Context, treeSize uint64, f TileFetcherFunc, h merkle.LogHasher, tl layout.Tiling) (*ProofBuilder, error) {
+	if err := validTiling(tl); err != nil {
+		return nil, err
+	}
 	pb := &ProofBuilder{
 		treeSize:  treeSize,
-		nodeCache: newNodeCache(f, treeSize),
+		nodeCache: newNodeCache(f, treeSize, h, tl),
+		hasher:    h,
 	}
 	return pb, nil
 }
@@ -211,6 +296,27 @@ func (pb *ProofBuilder) InclusionProof(ctx context.Context, index uint64) ([][]b
 	return pb.fetchNodes(ctx, nodes)
 }
 
+// VerifyInclusion constructs an inclusion proof for the leaf at index, and verifies that it shows the leaf
+// with the given hash to be included in the tree with the given root hash.
+// If the proof is constructed but fails to verify, the returned error is an ErrInclusion.
+func (pb *ProofBuilder) VerifyInclusion(ctx context.Context, index uint64, leafHash, root []byte) ([][]byte, error) {
+	p, err := pb.InclusionProof(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	if err := proof.VerifyInclusion(pb.hasher, index, pb.treeSize, leafHash, p, root); err != nil {
+		return nil, ErrInclusion{
+			Index:    index,
+			TreeSize: pb.treeSize,
+			LeafHash: leafHash,
+			Proof:    p,
+			Report:   pb.InclusionReport(ctx, index, err),
+			Wrapped:  err,
+		}
+	}
+	return p, nil
+}
+
 // ConsistencyProof constructs a consistency proof between the provided tree sizes.
 // This function uses the passed-in function to retrieve tiles containing any log tree
 // nodes necessary to build the proof.
@@ -242,7 +348,7 @@ func (pb *ProofBuilder) fetchNodes(ctx context.Context, nodes proof.Nodes) ([][]
 		hashes = append(hashes, h)
 	}
 	var err error
-	if hashes, err = nodes.Rehash(hashes, hasher.HashChildren); err != nil {
+	if hashes, err = nodes.Rehash(hashes, pb.hasher.HashChildren); err != nil {
 		return nil, fmt.Errorf("failed to rehash proof: %v", err)
 	}
 	return hashes, nil
@@ -256,6 +362,7 @@ type LogStateTracker struct {
 	consensusCheckpoint ConsensusCheckpointFunc
 	cpSigVerifier       note.Verifier
 	tileFetcher         TileFetcherFunc
+	hasher              merkle.LogHasher
 
 	// The fields under here will all be updated at the same time.
 	// Access to any of these fields is guarded by mu.
@@ -274,11 +381,18 @@ type LogStateTracker struct {
 // If a serialised LogState representation is provided then this is used as the
 // initial tracked state, otherwise a log state is fetched from the target log.
 func NewLogStateTracker(ctx context.Context, tF TileFetcherFunc, checkpointRaw []byte, nV note.Verifier, origin string, cc ConsensusCheckpointFunc) (*LogStateTracker, error) {
+	return NewLogStateTrackerWithHasher(ctx, tF, checkpointRaw, nV, origin, cc, hasher)
+}
+
+// NewLogStateTrackerWithHasher is like NewLogStateTracker, but for logs whose Merkle tree is built using
+// the provided LogHasher rather than the default RFC6962 SHA-256 one.
+func NewLogStateTrackerWithHasher(ctx context.Context, tF TileFetcherFunc, checkpointRaw []byte, nV note.Verifier, origin string, cc ConsensusCheckpointFunc, h merkle.LogHasher) (*LogStateTracker, error) {
 	ret := &LogStateTracker{
 		origin:              origin,
 		consensusCheckpoint: cc,
 		cpSigVerifier:       nV,
 		tileFetcher:         tF,
+		hasher:              h,
 	}
 	if len(checkpointRaw) > 0 {
 		ret.latestConsistentRaw = checkpointRaw
@@ -287,7 +401,7 @@ func NewLogStateTracker(ctx context.Context, tF TileFetcherFunc, checkpointRaw [
 			return ret, err
 		}
 		ret.latestConsistent = *cp
-		ret.proofBuilder, err = NewProofBuilder(ctx, ret.latestConsistent.Size, ret.tileFetcher)
+		ret.proofBuilder, err = NewProofBuilderWithHasher(ctx, ret.latestConsistent.Size, ret.tileFetcher, ret.hasher)
 		if err != nil {
 			return ret, fmt.Errorf("NewProofBuilder: %v", err)
 		}
@@ -312,7 +426,7 @@ func (lst *LogStateTracker) Update(ctx context.Context) ([]byte, [][]byte, []byt
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	builder, err := NewProofBuilder(ctx, c.Size, lst.tileFetcher)
+	builder, err := NewProofBuilderWithHasher(ctx, c.Size, lst.tileFetcher, lst.hasher)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to create proof builder: %v", err)
 	}
@@ -327,11 +441,12 @@ func (lst *LogStateTracker) Update(ctx context.Context) ([]byte, [][]byte, []byt
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		if err := proof.VerifyConsistency(hasher, lst.latestConsistent.Size, c.Size, p, lst.latestConsistent.Hash, c.Hash); err != nil {
+		if err := proof.VerifyConsistency(lst.hasher, lst.latestConsistent.Size, c.Size, p, lst.latestConsistent.Hash, c.Hash); err != nil {
 			return nil, nil, nil, ErrInconsistency{
 				SmallerRaw: lst.latestConsistentRaw,
 				LargerRaw:  cRaw,
 				Proof:      p,
+				Report:     builder.ConsistencyReport(ctx, lst.latestConsistent.Size, c.Size, err),
 				Wrapped:    err,
 			}
 		}
@@ -364,16 +479,24 @@ type nodeCache struct {
 	logSize   uint64
 	ephemeral map[compact.NodeID][]byte
 	tiles     map[tileKey]api.HashTile
-	getTile   TileFetcherFunc
+	// raw holds the tiles as they were returned by getTile, for reporting failed verifications.
+	raw     map[tileKey]ReportTile
+	getTile TileFetcherFunc
+	hasher  merkle.LogHasher
+	tiling  layout.Tiling
 }
 
-// newNodeCache creates a new nodeCache instance for a given log size.
-func newNodeCache(f TileFetcherFunc, logSize uint64) nodeCache {
+// newNodeCache creates a new nodeCache instance for a given log size, using h to calculate
+// nodes which are not stored directly in the tiles described by tl.
+func newNodeCache(f TileFetcherFunc, logSize uint64, h merkle.LogHasher, tl layout.Tiling) nodeCache {
 	return nodeCache{
 		logSize:   logSize,
 		ephemeral: make(map[compact.NodeID][]byte),
 		tiles:     make(map[tileKey]api.HashTile),
+		raw:       make(map[tileKey]ReportTile),
 		getTile:   f,
+		hasher:    h,
+		tiling:    tl,
 	}
 }
 
@@ -397,12 +520,13 @@ func (n *nodeCache) GetNode(ctx context.Context, id compact.NodeID) ([]byte, err
 		return e, nil
 	}
 	// Otherwise look in fetched tiles:
-	tileLevel, tileIndex, nodeLevel, nodeIndex := layout.NodeCoordsToTileAddress(uint64(id.Level), uint64(id.Index))
+	tileLevel, tileIndex, nodeLevel, nodeIndex := n.tiling.NodeCoordsToTileAddress(uint64(id.Level), uint64(id.Index))
 	tKey := tileKey{tileLevel, tileIndex}
 	t, ok := n.tiles[tKey]
 	if !ok {
 		span.AddEvent("cache miss")
-		p := layout.PartialTileSize(tileLevel, tileIndex, n.logSize)
+		// validTiling ensures that partial tile sizes fit in a uint8.
+		p := uint8(n.tiling.PartialTileSize(tileLevel, tileIndex, n.logSize))
 		tileRaw, err := n.getTile(ctx, tileLevel, tileIndex, p)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch tile: %v", err)
@@ -413,6 +537,7 @@ func (n *nodeCache) GetNode(ctx context.Context, id compact.NodeID) ([]byte, err
 		}
 		t = tile
 		n.tiles[tKey] = tile
+		n.raw[tKey] = ReportTile{Level: tileLevel, Index: tileIndex, PartialWidth: p, Raw: tileRaw}
 	}
 	// We've got the tile, now we need to look up (or calculate) the node inside of it
 	numLeaves := 1 << nodeLevel
@@ -421,7 +546,7 @@ func (n *nodeCache) GetNode(ctx context.Context, id compact.NodeID) ([]byte, err
 	if lastLeaf > len(t.Nodes) {
 		return nil, fmt.Errorf("require leaf nodes [%d, %d) but only got %d leaves", firstLeaf, lastLeaf, len(t.Nodes))
 	}
-	rf := compact.RangeFactory{Hash: hasher.HashChildren}
+	rf := compact.RangeFactory{Hash: n.hasher.HashChildren}
 	r := rf.NewEmptyRange(0)
 	for _, l := range t.Nodes[firstLeaf:lastLeaf] {
 		if err := r.Append(l, nil); err != nil {
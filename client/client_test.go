@@ -17,7 +17,9 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/sha256"
+	_ "crypto/sha3"
 	"errors"
 	"fmt"
 	"os"
@@ -27,15 +29,17 @@ import (
 	"github.com/transparency-dev/formats/log"
 	"github.com/transparency-dev/merkle/compact"
 	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/witness/policy"
 	"golang.org/x/mod/sumdb/note"
 )
 
 var (
 	testOrigin      = "example.com/log/testdata"
 	testLogVerifier = mustMakeVerifier("example.com/log/testdata+33d7b496+AeHTu4Q3hEIMHNqc6fASMsq3rKNx280NI+oO5xCFkkSx")
-	// Built using testdata/build_log.sh
+	// Built using testdata/build_log.go
 	testRawCheckpoints, testCheckpoints = mustLoadTestCheckpoints()
 )
 
@@ -210,7 +214,7 @@ func TestNodeCacheHandlesInvalidRequest(t *testing.T) {
 
 	// Large tree, but we're emulating skew since f, above, will return a tile which only knows about 1
 	// leaf.
-	nc := newNodeCache(f, 10)
+	nc := newNodeCache(f, 10, hasher, layout.DefaultTiling)
 
 	if got, err := nc.GetNode(ctx, compact.NewNodeID(0, 0)); err != nil {
 		t.Errorf("got %v, want no error", err)
@@ -350,3 +354,123 @@ func TestNodeFetcherAddressing(t *testing.T) {
 		})
 	}
 }
+
+func TestProofBuilderWithHasher(t *testing.T) {
+	const treeSize = 20
+	h := rfc6962.New(crypto.SHA3_256)
+	rf := &compact.RangeFactory{Hash: h.HashChildren}
+	cr := rf.NewEmptyRange(0)
+	tile := api.HashTile{}
+	for i := range treeSize {
+		lh := h.HashLeaf(fmt.Appendf(nil, "leaf %d", i))
+		tile.Nodes = append(tile.Nodes, lh)
+		if err := cr.Append(lh, nil); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	root, err := cr.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash: %v", err)
+	}
+	f := func(_ context.Context, l, i uint64, sz uint8) ([]byte, error) {
+		if l != 0 || i != 0 || sz != treeSize {
+			return nil, os.ErrNotExist
+		}
+		return tile.MarshalText()
+	}
+
+	pb, err := NewProofBuilderWithHasher(t.Context(), treeSize, f, h)
+	if err != nil {
+		t.Fatalf("NewProofBuilderWithHasher: %v", err)
+	}
+	for i := range uint64(treeSize) {
+		p, err := pb.InclusionProof(t.Context(), i)
+		if err != nil {
+			t.Fatalf("InclusionProof(%d): %v", i, err)
+		}
+		if err := proof.VerifyInclusion(h, i, treeSize, tile.Nodes[i], p, root); err != nil {
+			t.Errorf("VerifyInclusion(%d): %v", i, err)
+		}
+	}
+
+	// Proofs built with the default hasher must not verify against this tree.
+	pb, err = NewProofBuilder(t.Context(), treeSize, f)
+	if err != nil {
+		t.Fatalf("NewProofBuilder: %v", err)
+	}
+	p, err := pb.InclusionProof(t.Context(), 0)
+	if err != nil {
+		t.Fatalf("InclusionProof: %v", err)
+	}
+	if err := proof.VerifyInclusion(h, 0, treeSize, tile.Nodes[0], p, root); err == nil {
+		t.Error("VerifyInclusion of proof built with default hasher succeeded, want error")
+	}
+}
+
+func TestWitnessConsensus(t *testing.T) {
+	var signers []note.Signer
+	var policyText string
+	for _, name := range []string{"w1", "w2", "w3"} {
+		skey, vkey, err := note.GenerateKey(nil, name)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		s, err := note.NewSigner(skey)
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+		signers = append(signers, s)
+		policyText += fmt.Sprintf("witness %s %s\n", name, vkey)
+	}
+	p, err := policy.Parse([]byte(policyText + "group g w1 w2 w3\nquorum 2 of g\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	cosign := func(signers ...note.Signer) []byte {
+		n, err := note.Open(testRawCheckpoints[5], note.VerifierList(testLogVerifier))
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		cp, err := note.Sign(n, signers...)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return cp
+	}
+
+	for _, test := range []struct {
+		name    string
+		cp      []byte
+		wantErr bool
+	}{
+		{
+			name:    "no cosignatures",
+			cp:      testRawCheckpoints[5],
+			wantErr: true,
+		}, {
+			name:    "below quorum",
+			cp:      cosign(signers[0]),
+			wantErr: true,
+		}, {
+			name: "quorum",
+			cp:   cosign(signers[0], signers[2]),
+		}, {
+			name: "all",
+			cp:   cosign(signers...),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := func(_ context.Context) ([]byte, error) {
+				return test.cp, nil
+			}
+			cp, _, _, err := WitnessConsensus(f, p)(t.Context(), testLogVerifier, testOrigin)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("WitnessConsensus: %v, wantErr %t", err, test.wantErr)
+			}
+			if err == nil && cp.Size != testCheckpoints[5].Size {
+				t.Errorf("got size %d, want %d", cp.Size, testCheckpoints[5].Size)
+			}
+		})
+	}
+}
@@ -22,9 +22,10 @@ import (
 	"net/url"
 	"os"
 	"path"
-	"strings"
 
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bundleformat"
+	"github.com/transparency-dev/tessera/internal/compress"
 	"github.com/transparency-dev/tessera/internal/fetcher"
 	"k8s.io/klog/v2"
 )
@@ -32,25 +33,23 @@ import (
 // NewHTTPFetcher creates a new HTTPFetcher for the log rooted at the given URL, using
 // the provided HTTP client.
 //
-// rootURL should end in a trailing slash.
+// rootURL may have a path, e.g. if the log is served under a subpath of a site, and a query string, which
+// is sent with every request.
 // c may be nil, in which case http.DefaultClient will be used.
 func NewHTTPFetcher(rootURL *url.URL, c *http.Client) (*HTTPFetcher, error) {
-	if !strings.HasSuffix(rootURL.String(), "/") {
-		rootURL.Path += "/"
-	}
 	if c == nil {
 		c = http.DefaultClient
 	}
 	return &HTTPFetcher{
-		c:       c,
-		rootURL: rootURL,
+		c:     c,
+		paths: layout.NewPaths(rootURL),
 	}, nil
 }
 
 // HTTPFetcher knows how to fetch log artifacts from a log being served via HTTP.
 type HTTPFetcher struct {
 	c          *http.Client
-	rootURL    *url.URL
+	paths      layout.Paths
 	authHeader string
 }
 
@@ -60,30 +59,34 @@ func (h *HTTPFetcher) SetAuthorizationHeader(v string) {
 	h.authHeader = v
 }
 
-func (h HTTPFetcher) fetch(ctx context.Context, p string) ([]byte, error) {
-	u, err := h.rootURL.Parse(p)
-	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %v", err)
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+// SetSuffix sets a suffix to be appended to the URLs of tiles and entry bundles, e.g. ".gz" for logs
+// which publish them as precompressed objects. See layout.Paths.
+func (h *HTTPFetcher) SetSuffix(s string) {
+	h.paths.Suffix = s
+}
+
+func (h HTTPFetcher) fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, fmt.Errorf("NewRequestWithContext(%q): %v", u.String(), err)
+		return nil, fmt.Errorf("NewRequestWithContext(%q): %v", u, err)
 	}
 	if h.authHeader != "" {
 		req.Header.Add("Authorization", h.authHeader)
 	}
+	// Setting this header stops the transport from transparently decoding gzip, so responses are decoded below.
+	req.Header.Set("Accept-Encoding", compress.AcceptEncoding)
 	r, err := h.c.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("get(%q): %v", u.String(), err)
+		return nil, fmt.Errorf("get(%q): %v", u, err)
 	}
 	switch r.StatusCode {
 	case http.StatusOK:
 		// All good, continue below
 	case http.StatusNotFound:
 		// Need to return ErrNotExist here, by contract.
-		return nil, fmt.Errorf("get(%q): %w", u.String(), os.ErrNotExist)
+		return nil, fmt.Errorf("get(%q): %w", u, os.ErrNotExist)
 	default:
-		return nil, fmt.Errorf("get(%q): %v", u.String(), r.StatusCode)
+		return nil, fmt.Errorf("get(%q): %v", u, r.StatusCode)
 	}
 
 	defer func() {
@@ -91,22 +94,42 @@ func (h HTTPFetcher) fetch(ctx context.Context, p string) ([]byte, error) {
 			klog.Errorf("resp.Body.Close(): %v", err)
 		}
 	}()
-	return io.ReadAll(r.Body)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("get(%q): failed to read body: %v", u, err)
+	}
+	body, err = compress.Decode(r.Header.Get("Content-Encoding"), body)
+	if err != nil {
+		return nil, fmt.Errorf("get(%q): %v", u, err)
+	}
+	return body, nil
 }
 
 func (h HTTPFetcher) ReadCheckpoint(ctx context.Context) ([]byte, error) {
-	return h.fetch(ctx, layout.CheckpointPath)
+	return h.fetch(ctx, h.paths.Checkpoint())
+}
+
+func (h HTTPFetcher) ReadArchivedCheckpoint(ctx context.Context, size uint64) ([]byte, error) {
+	return h.fetch(ctx, h.paths.ArchivedCheckpoint(size))
+}
+
+func (h HTTPFetcher) ReadCheckpointArchiveIndex(ctx context.Context) ([]byte, error) {
+	return h.fetch(ctx, h.paths.CheckpointArchiveIndex())
+}
+
+func (h HTTPFetcher) ReadTileHeight(ctx context.Context) ([]byte, error) {
+	return h.fetch(ctx, h.paths.TileHeight())
 }
 
 func (h HTTPFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
 	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
-		return h.fetch(ctx, layout.TilePath(l, i, p))
+		return h.fetch(ctx, h.paths.Tile(l, i, p))
 	})
 }
 
 func (h HTTPFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
 	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
-		return h.fetch(ctx, layout.EntriesPath(i, p))
+		return h.fetch(ctx, h.paths.EntryBundle(i, p))
 	})
 }
 
@@ -119,6 +142,18 @@ func (f FileFetcher) ReadCheckpoint(_ context.Context) ([]byte, error) {
 	return os.ReadFile(path.Join(f.Root, layout.CheckpointPath))
 }
 
+func (f FileFetcher) ReadArchivedCheckpoint(_ context.Context, size uint64) ([]byte, error) {
+	return os.ReadFile(path.Join(f.Root, layout.ArchivedCheckpointPath(size)))
+}
+
+func (f FileFetcher) ReadCheckpointArchiveIndex(_ context.Context) ([]byte, error) {
+	return os.ReadFile(path.Join(f.Root, layout.CheckpointArchiveIndexPath))
+}
+
+func (f FileFetcher) ReadTileHeight(_ context.Context) ([]byte, error) {
+	return os.ReadFile(path.Join(f.Root, layout.TileHeightPath))
+}
+
 func (f FileFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
 	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
 		return os.ReadFile(path.Join(f.Root, layout.TilePath(l, i, p)))
@@ -126,7 +161,20 @@ func (f FileFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte
 }
 
 func (f FileFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	// POSIX logs record how their bundles are stored in their state directory.
+	formats, err := bundleformat.Read(f.Root)
+	if err != nil {
+		return nil, err
+	}
 	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
-		return os.ReadFile(path.Join(f.Root, layout.EntriesPath(i, p)))
+		b, err := os.ReadFile(path.Join(f.Root, layout.EntriesPath(i, p)))
+		if err != nil {
+			return nil, err
+		}
+		bf := formats.For(i, p)
+		if bf.Encrypted {
+			return nil, fmt.Errorf("entry bundle %d.%d is encrypted", i, p)
+		}
+		return compress.DecodeEntryBundle(bf.Encoding, b)
 	})
 }
@@ -0,0 +1,163 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/klauspost/compress/zstd"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bundleformat"
+)
+
+func TestFetchersDecodeEntryBundles(t *testing.T) {
+	bundle := bytes.Repeat([]byte("\x00\x10{\"name\": \"entry\"}"), layout.EntryBundleWidth)
+	e, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	zstdBundle := e.EncodeAll(bundle, nil)
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write(bundle); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     []byte
+	}{
+		{name: "identity", body: bundle, want: bundle},
+		{name: "zstd", encoding: "zstd", body: zstdBundle, want: bundle},
+		{name: "gzip", encoding: "gzip", body: gz.Bytes(), want: bundle},
+		// Bundles are only decoded according to their recorded encoding, whatever they look like.
+		{name: "zstd frame without encoding", body: zstdBundle, want: zstdBundle},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/"+layout.EntriesPath(0, 0) {
+					http.NotFound(w, r)
+					return
+				}
+				if test.encoding != "" {
+					w.Header().Set("Content-Encoding", test.encoding)
+				}
+				_, _ = w.Write(test.body)
+			}))
+			defer srv.Close()
+			u, err := url.Parse(srv.URL)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			hf, err := NewHTTPFetcher(u, nil)
+			if err != nil {
+				t.Fatalf("NewHTTPFetcher: %v", err)
+			}
+			if got, err := hf.ReadEntryBundle(ctx, 0, 0); err != nil {
+				t.Fatalf("HTTPFetcher.ReadEntryBundle: %v", err)
+			} else if !bytes.Equal(got, test.want) {
+				t.Errorf("HTTPFetcher.ReadEntryBundle: got %d bytes, want %d", len(got), len(test.want))
+			}
+
+			// POSIX logs record the encoding of their bundles in their state directory.
+			root := t.TempDir()
+			for p, d := range map[string][]byte{
+				layout.EntriesPath(0, 0): test.body,
+				bundleformat.Path:        fmt.Appendf(nil, `[{"from": 0, "encoding": %q}]`, test.encoding),
+			} {
+				p = filepath.Join(root, p)
+				if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+					t.Fatalf("MkdirAll: %v", err)
+				}
+				if err := os.WriteFile(p, d, 0o644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			}
+			if got, err := (FileFetcher{Root: root}).ReadEntryBundle(ctx, 0, 0); err != nil {
+				t.Fatalf("FileFetcher.ReadEntryBundle: %v", err)
+			} else if !bytes.Equal(got, test.want) {
+				t.Errorf("FileFetcher.ReadEntryBundle: got %d bytes, want %d", len(got), len(test.want))
+			}
+		})
+	}
+}
+
+func TestHTTPFetcherPaths(t *testing.T) {
+	ctx := context.Background()
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.RequestURI())
+		_, _ = w.Write(make([]byte, 32))
+	}))
+	defer srv.Close()
+	for _, test := range []struct {
+		root   string
+		suffix string
+		want   []string
+	}{
+		{
+			root: srv.URL,
+			want: []string{"/checkpoint", "/tile/0/000.p/1", "/tile/entries/000.p/1"},
+		}, {
+			root: srv.URL + "/logs/a?token=abc",
+			want: []string{"/logs/a/checkpoint?token=abc", "/logs/a/tile/0/000.p/1?token=abc", "/logs/a/tile/entries/000.p/1?token=abc"},
+		}, {
+			root:   srv.URL + "/logs/a/",
+			suffix: ".gz",
+			want:   []string{"/logs/a/checkpoint", "/logs/a/tile/0/000.p/1.gz", "/logs/a/tile/entries/000.p/1.gz"},
+		},
+	} {
+		t.Run(test.root, func(t *testing.T) {
+			got = nil
+			u, err := url.Parse(test.root)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			f, err := NewHTTPFetcher(u, nil)
+			if err != nil {
+				t.Fatalf("NewHTTPFetcher: %v", err)
+			}
+			f.SetSuffix(test.suffix)
+			if _, err := f.ReadCheckpoint(ctx); err != nil {
+				t.Fatalf("ReadCheckpoint: %v", err)
+			}
+			if _, err := f.ReadTile(ctx, 0, 0, 1); err != nil {
+				t.Fatalf("ReadTile: %v", err)
+			}
+			if _, err := f.ReadEntryBundle(ctx, 0, 1); err != nil {
+				t.Fatalf("ReadEntryBundle: %v", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Requested paths diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
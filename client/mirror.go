@@ -0,0 +1,526 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// Fetchers groups together the functions needed to read all of the static resources of a log.
+type Fetchers struct {
+	Checkpoint  CheckpointFetcherFunc
+	Tile        TileFetcherFunc
+	EntryBundle EntryBundleFetcherFunc
+}
+
+// LogWriter describes a type which can store the static resources of a mirrored log, along with
+// the private state needed to resume an interrupted mirroring operation.
+type LogWriter interface {
+	// WriteCheckpoint stores the provided checkpoint.
+	WriteCheckpoint(ctx context.Context, data []byte) error
+	// WriteTile stores the provided tile data at the given coordinates.
+	WriteTile(ctx context.Context, l, i uint64, p uint8, data []byte) error
+	// WriteEntryBundle stores the provided entry bundle data at the given coordinates.
+	WriteEntryBundle(ctx context.Context, i uint64, p uint8, data []byte) error
+
+	// ReadMirrorState returns the opaque state most recently stored by WriteMirrorState.
+	// If no state has been stored, os.ErrNotExist MUST be returned.
+	ReadMirrorState(ctx context.Context) ([]byte, error)
+	// WriteMirrorState durably stores the provided opaque state.
+	WriteMirrorState(ctx context.Context, data []byte) error
+}
+
+// MirrorOptions holds settings for the Mirror function.
+type MirrorOptions struct {
+	// NumWorkers is the number of entry bundles and tiles which will be fetched in parallel.
+	// If zero, a single worker will be used.
+	NumWorkers uint
+	// Verifier is used to verify the signature on the source log's checkpoint.
+	Verifier note.Verifier
+	// Origin is the expected origin line of the source log's checkpoint.
+	Origin string
+	// BundleLeafHasher knows how to turn a serialised entry bundle into the Merkle leaf hashes of the
+	// entries it contains. If nil, the bundle is assumed to be a https://c2sp.org/tlog-tiles bundle
+	// and RFC6962 leaf hashing is used.
+	BundleLeafHasher func([]byte) ([][]byte, error)
+	// Progress, if set, is called each time an entry bundle has been copied and verified, with the
+	// number of entries copied so far and the size of the source checkpoint being mirrored.
+	Progress func(copied, total uint64)
+}
+
+// mirrorState is the progress of a mirroring operation, persisted via the LogWriter after each
+// entry bundle has been copied.
+type mirrorState struct {
+	// Size is the number of leading entries of the source log which have been copied & verified.
+	Size uint64 `json:"size"`
+	// Range holds the hashes of the compact range covering [0, Size).
+	Range [][]byte `json:"range"`
+	// Tiles holds, for each tile level above zero, the verified node hashes of the right-most
+	// tile at that level which is implied by Size.
+	Tiles [][][]byte `json:"tiles"`
+}
+
+// Mirror copies the log readable via src into dst.
+//
+// All copied resources are verified: the source checkpoint must be signed by opts.Verifier, every
+// entry bundle must hash to the corresponding level-0 tile, every tile above level zero must
+// commit to the tiles below it, and the root hash recomputed from the copied entries must match that
+// of the source checkpoint. The checkpoint is only written to dst once all of the resources it
+// commits to have been copied and verified.
+//
+// Progress is stored in dst as the copy proceeds, so an interrupted call to Mirror can be resumed
+// by calling it again with the same dst. Calling Mirror against a dst which holds a complete copy of
+// a smaller version of the log will incrementally copy only the new resources, and in doing so
+// verifies that the larger source checkpoint is consistent with the one previously mirrored.
+func Mirror(ctx context.Context, src Fetchers, dst LogWriter, opts MirrorOptions) error {
+	ctx, span := tracer.Start(ctx, "tessera.client.Mirror")
+	defer span.End()
+
+	cp, cpRaw, _, err := FetchCheckpoint(ctx, src.Checkpoint, opts.Verifier, opts.Origin)
+	if err != nil {
+		return fmt.Errorf("failed to fetch source checkpoint: %v", err)
+	}
+
+	m := newMirrorer(src, dst, opts, cp.Size)
+	if err := m.loadState(ctx); err != nil {
+		return err
+	}
+	if m.state.Size > cp.Size {
+		return fmt.Errorf("source checkpoint size %d is smaller than already mirrored size %d", cp.Size, m.state.Size)
+	}
+	klog.V(1).Infof("Mirror: source size %d, resuming from %d", cp.Size, m.state.Size)
+
+	if err := m.copy(ctx, cp.Hash); err != nil {
+		return err
+	}
+	if err := m.storeState(ctx); err != nil {
+		return err
+	}
+	if err := dst.WriteCheckpoint(ctx, cpRaw); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return nil
+}
+
+// mirrorer holds the working state of a single call to Mirror.
+type mirrorer struct {
+	src Fetchers
+	// dst is nil when the source is only being verified, rather than copied.
+	dst        LogWriter
+	opts       MirrorOptions
+	treeSize   uint64
+	leafHasher func([]byte) ([][]byte, error)
+	rf         *compact.RangeFactory
+
+	state mirrorState
+	cr    *compact.Range
+	// resumed holds the hashes of the compact range covering the entries already mirrored from the
+	// partially copied bundle which copying resumes from, if any.
+	resumed [][]byte
+}
+
+// newMirrorer creates a mirrorer which will copy a source log of the given size into dst.
+func newMirrorer(src Fetchers, dst LogWriter, opts MirrorOptions, size uint64) *mirrorer {
+	m := &mirrorer{
+		src:        src,
+		dst:        dst,
+		opts:       opts,
+		treeSize:   size,
+		leafHasher: opts.BundleLeafHasher,
+		rf:         &compact.RangeFactory{Hash: hasher.HashChildren},
+	}
+	if m.leafHasher == nil {
+		m.leafHasher = rfc6962LeafHasher
+	}
+	m.cr = m.rf.NewEmptyRange(0)
+	return m
+}
+
+// copy copies and verifies all of the resources which have not yet been mirrored, and checks that the
+// resulting tree has the provided root hash.
+func (m *mirrorer) copy(ctx context.Context, wantRoot []byte) error {
+	if err := m.copyBundles(ctx); err != nil {
+		return err
+	}
+	if err := m.flushTiles(ctx); err != nil {
+		return err
+	}
+
+	root, err := m.cr.GetRootHash(nil)
+	if err != nil {
+		return fmt.Errorf("failed to calculate root hash: %v", err)
+	}
+	if m.treeSize == 0 {
+		r := hasher.EmptyRoot()
+		root = r[:]
+	}
+	if !bytes.Equal(root, wantRoot) {
+		return fmt.Errorf("computed root hash %x != source checkpoint root hash %x", root, wantRoot)
+	}
+	return nil
+}
+
+// loadState reads any previously stored mirror state from dst.
+func (m *mirrorer) loadState(ctx context.Context) error {
+	raw, err := m.dst.ReadMirrorState(ctx)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read mirror state: %v", err)
+	}
+	if err := json.Unmarshal(raw, &m.state); err != nil {
+		return fmt.Errorf("failed to parse mirror state: %v", err)
+	}
+	m.cr, err = m.rf.NewRange(0, m.state.Size, m.state.Range)
+	if err != nil {
+		return fmt.Errorf("invalid compact range in mirror state: %v", err)
+	}
+	// The bundle start is aligned to a perfect subtree of the tree, so the range covering the entries
+	// mirrored from the partial bundle is the tail of the range covering the whole mirrored prefix.
+	if n := bits.OnesCount64(m.state.Size % layout.EntryBundleWidth); n > 0 {
+		h := m.cr.Hashes()
+		m.resumed = h[len(h)-n:]
+	}
+	return nil
+}
+
+// storeState persists the current mirror state to dst.
+func (m *mirrorer) storeState(ctx context.Context) error {
+	if m.dst == nil {
+		return nil
+	}
+	m.state.Range = m.cr.Hashes()
+	raw, err := json.Marshal(m.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mirror state: %v", err)
+	}
+	if err := m.dst.WriteMirrorState(ctx, raw); err != nil {
+		return fmt.Errorf("failed to write mirror state: %v", err)
+	}
+	return nil
+}
+
+// copiedBundle is the result of copying a single entry bundle and its level-0 tile.
+type copiedBundle struct {
+	ri     layout.RangeInfo
+	hashes [][]byte
+	err    error
+}
+
+// copyBundles copies all entry bundles and level-0 tiles which have not yet been mirrored.
+//
+// Fetches happen in parallel, but the results are consumed in order so that the compact range and
+// upper tiles are built correctly, and the persisted state always describes a prefix of the log.
+func (m *mirrorer) copyBundles(ctx context.Context) error {
+	cctx, cancel := context.WithCancel(ctx)
+	numWorkers := max(m.opts.NumWorkers, 1)
+	results := make(chan chan copiedBundle, numWorkers)
+	defer func() {
+		// Ensure that no in-flight copies are still writing to dst once we return.
+		cancel()
+		for c := range results {
+			<-c
+		}
+	}()
+	go func() {
+		defer close(results)
+		for ri := range layout.Range(m.state.Size, m.treeSize-m.state.Size, m.treeSize) {
+			c := make(chan copiedBundle, 1)
+			select {
+			case <-cctx.Done():
+				return
+			case results <- c:
+			}
+			go func(ri layout.RangeInfo) {
+				h, err := m.copyBundle(cctx, ri)
+				c <- copiedBundle{ri: ri, hashes: h, err: err}
+			}(ri)
+		}
+	}()
+
+	for c := range results {
+		r := <-c
+		if r.err != nil {
+			return r.err
+		}
+		for _, h := range r.hashes[r.ri.First : r.ri.First+r.ri.N] {
+			if err := m.cr.Append(h, nil); err != nil {
+				return fmt.Errorf("failed to append to compact range: %v", err)
+			}
+		}
+		if r.ri.Partial == 0 {
+			// This bundle's tile is full, so it's now a node in the parent tile.
+			if err := m.addNode(ctx, 1, r.ri.Index, tileRoot(m.rf, r.hashes)); err != nil {
+				return err
+			}
+		}
+		m.state.Size = r.ri.Index*layout.EntryBundleWidth + uint64(r.ri.First+r.ri.N)
+		if err := m.storeState(ctx); err != nil {
+			return err
+		}
+		if m.opts.Progress != nil {
+			m.opts.Progress(m.state.Size, m.treeSize)
+		}
+	}
+	return nil
+}
+
+// copyBundle fetches the entry bundle and level-0 tile described by ri, checks that they agree,
+// and writes them to dst.
+//
+// Returns the verified leaf hashes of the bundle.
+func (m *mirrorer) copyBundle(ctx context.Context, ri layout.RangeInfo) ([][]byte, error) {
+	want := tileWidth(ri.Partial)
+	b, err := m.src.EntryBundle(ctx, ri.Index, ri.Partial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch entry bundle %d (p=%d): %v", ri.Index, ri.Partial, err)
+	}
+	bh, err := m.leafHasher(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash entry bundle %d (p=%d): %v", ri.Index, ri.Partial, err)
+	}
+	if len(bh) < want {
+		return nil, fmt.Errorf("entry bundle %d (p=%d) has %d entries, want %d", ri.Index, ri.Partial, len(bh), want)
+	}
+	t, err := m.fetchTile(ctx, 0, ri.Index, ri.Partial)
+	if err != nil {
+		return nil, err
+	}
+	for i := range want {
+		if !bytes.Equal(bh[i], t.Nodes[i]) {
+			return nil, fmt.Errorf("entry %d of bundle %d hashes to %x, but tile has %x", i, ri.Index, bh[i], t.Nodes[i])
+		}
+	}
+	if ri.First > 0 {
+		// These entries were verified when they were first mirrored, so the source must still agree with them.
+		if err := m.verifyResumed(ri, bh[:ri.First]); err != nil {
+			return nil, err
+		}
+	}
+	// The source may have grown since the checkpoint was fetched, in which case we'll have been given the full
+	// resource rather than the partial one we asked for. Tiles can be truncated, but entry bundles are opaque so
+	// are stored as they are; readers of partial bundles must already tolerate them holding more entries.
+	if err := m.writeTile(ctx, 0, ri.Index, ri.Partial, t.Nodes[:want]); err != nil {
+		return nil, err
+	}
+	if m.dst != nil {
+		if err := m.dst.WriteEntryBundle(ctx, ri.Index, ri.Partial, b); err != nil {
+			return nil, fmt.Errorf("failed to write entry bundle %d: %v", ri.Index, err)
+		}
+	}
+	return bh[:want], nil
+}
+
+// verifyResumed checks that the leaf hashes of the entries at the start of the bundle described by ri match
+// those which were mirrored before copying was resumed.
+func (m *mirrorer) verifyResumed(ri layout.RangeInfo, hashes [][]byte) error {
+	r := m.rf.NewEmptyRange(ri.Index * layout.EntryBundleWidth)
+	for _, h := range hashes {
+		if err := r.Append(h, nil); err != nil {
+			return fmt.Errorf("failed to append to compact range: %v", err)
+		}
+	}
+	got := r.Hashes()
+	if len(got) != len(m.resumed) {
+		return fmt.Errorf("logic error: have %d hashes for the mirrored entries of bundle %d, want %d", len(m.resumed), ri.Index, len(got))
+	}
+	for i := range got {
+		if !bytes.Equal(got[i], m.resumed[i]) {
+			return fmt.Errorf("entries [0, %d) of bundle %d don't match those already mirrored", ri.First, ri.Index)
+		}
+	}
+	return nil
+}
+
+// addNode records the hash of a full tile at level-1 with index childIndex as a node in the tile at the
+// provided level. If that causes the tile at level to become full, it is verified against the source,
+// written, and its own hash is recorded in the tile above, and so on up the tree.
+func (m *mirrorer) addNode(ctx context.Context, level, childIndex uint64, h []byte) error {
+	for {
+		for uint64(len(m.state.Tiles)) < level {
+			m.state.Tiles = append(m.state.Tiles, nil)
+		}
+		nodes := append(m.state.Tiles[level-1], h)
+		m.state.Tiles[level-1] = nodes
+		if len(nodes) < layout.TileWidth {
+			return nil
+		}
+		index := childIndex >> layout.TileHeight
+		if err := m.verifyAndWriteTile(ctx, level, index, 0, nodes); err != nil {
+			return err
+		}
+		h = tileRoot(m.rf, nodes)
+		m.state.Tiles[level-1] = nil
+		level, childIndex = level+1, index
+	}
+}
+
+// flushTiles verifies and writes the partial tiles at the right-hand edge of the tree above level zero.
+func (m *mirrorer) flushTiles(ctx context.Context) error {
+	for i, nodes := range m.state.Tiles {
+		level := uint64(i + 1)
+		if len(nodes) == 0 {
+			continue
+		}
+		index := m.treeSize >> ((level + 1) * layout.TileHeight)
+		p := layout.PartialTileSize(level, index, m.treeSize)
+		if int(p) != len(nodes) {
+			return fmt.Errorf("logic error: have %d nodes for tile level %d index %d, but partial size is %d", len(nodes), level, index, p)
+		}
+		if err := m.verifyAndWriteTile(ctx, level, index, p, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyAndWriteTile fetches the specified tile from the source and checks that it contains the expected
+// node hashes before writing them to dst.
+func (m *mirrorer) verifyAndWriteTile(ctx context.Context, level, index uint64, p uint8, want [][]byte) error {
+	t, err := m.fetchTile(ctx, level, index, p)
+	if err != nil {
+		return err
+	}
+	for i, h := range want {
+		if !bytes.Equal(h, t.Nodes[i]) {
+			return fmt.Errorf("node %d of tile level %d index %d is %x, but calculated %x", i, level, index, t.Nodes[i], h)
+		}
+	}
+	return m.writeTile(ctx, level, index, p, want)
+}
+
+// fetchTile fetches and parses the specified tile, checking that it's at least as large as requested.
+func (m *mirrorer) fetchTile(ctx context.Context, level, index uint64, p uint8) (*api.HashTile, error) {
+	raw, err := m.src.Tile(ctx, level, index, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tile level %d index %d (p=%d): %v", level, index, p, err)
+	}
+	t := &api.HashTile{}
+	if err := t.UnmarshalText(raw); err != nil {
+		return nil, fmt.Errorf("failed to parse tile level %d index %d (p=%d): %v", level, index, p, err)
+	}
+	if want := tileWidth(p); len(t.Nodes) < want {
+		return nil, fmt.Errorf("tile level %d index %d (p=%d) has %d nodes, want %d", level, index, p, len(t.Nodes), want)
+	}
+	return t, nil
+}
+
+func (m *mirrorer) writeTile(ctx context.Context, level, index uint64, p uint8, nodes [][]byte) error {
+	if m.dst == nil {
+		return nil
+	}
+	raw, err := api.HashTile{Nodes: nodes}.MarshalText()
+	if err != nil {
+		return fmt.Errorf("failed to marshal tile level %d index %d: %v", level, index, err)
+	}
+	if err := m.dst.WriteTile(ctx, level, index, p, raw); err != nil {
+		return fmt.Errorf("failed to write tile level %d index %d (p=%d): %v", level, index, p, err)
+	}
+	return nil
+}
+
+// tileWidth returns the number of nodes in a tile with the given partial size.
+func tileWidth(p uint8) int {
+	if p == 0 {
+		return layout.TileWidth
+	}
+	return int(p)
+}
+
+// tileRoot returns the root hash of the perfect subtree formed by the provided full row of tile nodes.
+func tileRoot(rf *compact.RangeFactory, nodes [][]byte) []byte {
+	r := rf.NewEmptyRange(0)
+	for _, n := range nodes {
+		if err := r.Append(n, nil); err != nil {
+			// This can only fail if the range was constructed incorrectly, which it wasn't.
+			panic(fmt.Errorf("Append: %v", err))
+		}
+	}
+	h, err := r.GetRootHash(nil)
+	if err != nil {
+		panic(fmt.Errorf("GetRootHash: %v", err))
+	}
+	return h
+}
+
+// rfc6962LeafHasher parses a https://c2sp.org/tlog-tiles entry bundle and returns the RFC6962 leaf hashes of
+// the entries it contains.
+func rfc6962LeafHasher(bundle []byte) ([][]byte, error) {
+	eb := &api.EntryBundle{}
+	if err := eb.UnmarshalText(bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal: %v", err)
+	}
+	r := make([][]byte, 0, len(eb.Entries))
+	for _, e := range eb.Entries {
+		r = append(r, hasher.HashLeaf(e))
+	}
+	return r, nil
+}
+
+// mirrorStatePath is the location, relative to the log root, of the file FileWriter uses to store mirror state.
+const mirrorStatePath = ".state/mirror"
+
+// FileWriter is a LogWriter which stores a mirrored log in a filesystem rooted at Root, using
+// the same layout which FileFetcher reads.
+type FileWriter struct {
+	Root string
+}
+
+func (f FileWriter) WriteCheckpoint(_ context.Context, d []byte) error {
+	return f.store(layout.CheckpointPath, d)
+}
+
+func (f FileWriter) WriteTile(_ context.Context, l, i uint64, p uint8, d []byte) error {
+	return f.store(layout.TilePath(l, i, p), d)
+}
+
+func (f FileWriter) WriteEntryBundle(_ context.Context, i uint64, p uint8, d []byte) error {
+	return f.store(layout.EntriesPath(i, p), d)
+}
+
+func (f FileWriter) ReadMirrorState(_ context.Context) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.Root, mirrorStatePath))
+}
+
+func (f FileWriter) WriteMirrorState(_ context.Context, d []byte) error {
+	return f.store(mirrorStatePath, d)
+}
+
+// store atomically writes d to the file at path p relative to the root.
+func (f FileWriter) store(p string, d []byte) error {
+	fp := filepath.Join(f.Root, p)
+	if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+		return err
+	}
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, d, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// memLog is a simple in-memory tlog-tiles log used for testing.
+type memLog struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// newMemLog builds an in-memory log containing size entries, signed by s.
+func newMemLog(t *testing.T, s note.Signer, size uint64) *memLog {
+	t.Helper()
+	m := &memLog{files: make(map[string][]byte)}
+	rf := &compact.RangeFactory{Hash: hasher.HashChildren}
+	cr := rf.NewEmptyRange(0)
+	// rows holds the nodes of each tile level, i.e. tree levels 0, 8, 16, ...
+	rows := [][][]byte{{}}
+	bundle := &bytes.Buffer{}
+	for i := range size {
+		e := fmt.Appendf(nil, "entry %d", i)
+		bundle.Write(binary.BigEndian.AppendUint16(nil, uint16(len(e))))
+		bundle.Write(e)
+		lh := hasher.HashLeaf(e)
+		if err := cr.Append(lh, nil); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		rows[0] = append(rows[0], lh)
+		if (i+1)%layout.EntryBundleWidth == 0 || i+1 == size {
+			m.files[layout.EntriesPathForLogIndex(i, size)] = bytes.Clone(bundle.Bytes())
+			bundle.Reset()
+		}
+	}
+	for l := 0; len(rows[l]) >= layout.TileWidth; l++ {
+		rows = append(rows, nil)
+		for i := 0; i+layout.TileWidth <= len(rows[l]); i += layout.TileWidth {
+			rows[l+1] = append(rows[l+1], tileRoot(rf, rows[l][i:i+layout.TileWidth]))
+		}
+	}
+	for l, row := range rows {
+		for i := 0; i < len(row); i += layout.TileWidth {
+			n := row[i:min(i+layout.TileWidth, len(row))]
+			raw, _ := api.HashTile{Nodes: n}.MarshalText()
+			idx := uint64(i / layout.TileWidth)
+			m.files[layout.TilePath(uint64(l), idx, layout.PartialTileSize(uint64(l), idx, size))] = raw
+		}
+	}
+	root, err := cr.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash: %v", err)
+	}
+	if size == 0 {
+		r := hasher.EmptyRoot()
+		root = r[:]
+	}
+	cp, err := note.Sign(&note.Note{Text: string(f_log.Checkpoint{Origin: s.Name(), Size: size, Hash: root}.Marshal())}, s)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	m.files[layout.CheckpointPath] = cp
+	return m
+}
+
+func (m *memLog) get(p string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.files[p]; ok {
+		return d, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (m *memLog) fetchers() Fetchers {
+	return Fetchers{
+		Checkpoint: func(_ context.Context) ([]byte, error) { return m.get(layout.CheckpointPath) },
+		Tile: func(_ context.Context, l, i uint64, p uint8) ([]byte, error) {
+			return m.get(layout.TilePath(l, i, p))
+		},
+		EntryBundle: func(_ context.Context, i uint64, p uint8) ([]byte, error) {
+			return m.get(layout.EntriesPath(i, p))
+		},
+	}
+}
+
+func (m *memLog) WriteCheckpoint(_ context.Context, d []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[layout.CheckpointPath] = d
+	return nil
+}
+
+func (m *memLog) WriteTile(_ context.Context, l, i uint64, p uint8, d []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[layout.TilePath(l, i, p)] = d
+	return nil
+}
+
+func (m *memLog) WriteEntryBundle(_ context.Context, i uint64, p uint8, d []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[layout.EntriesPath(i, p)] = d
+	return nil
+}
+
+func (m *memLog) ReadMirrorState(_ context.Context) ([]byte, error) {
+	return m.get(mirrorStatePath)
+}
+
+func (m *memLog) WriteMirrorState(_ context.Context, d []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[mirrorStatePath] = d
+	return nil
+}
+
+func mustGenerateKey(t *testing.T) (note.Signer, note.Verifier) {
+	t.Helper()
+	sk, vk, err := note.GenerateKey(nil, "example.com/mirror/test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return s, v
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	s, v := mustGenerateKey(t)
+	opts := MirrorOptions{NumWorkers: 4, Verifier: v, Origin: s.Name()}
+
+	for _, test := range []struct {
+		name  string
+		sizes []uint64
+	}{
+		{name: "empty", sizes: []uint64{0}},
+		{name: "single partial bundle", sizes: []uint64{10}},
+		{name: "multiple bundles", sizes: []uint64{3*layout.EntryBundleWidth + 7}},
+		{name: "full upper tile", sizes: []uint64{layout.TileWidth*layout.TileWidth + 3}},
+		{name: "incremental", sizes: []uint64{0, 1, 300, 512, 513, layout.TileWidth*layout.TileWidth + 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dst := &memLog{files: make(map[string][]byte)}
+			var src *memLog
+			for _, size := range test.sizes {
+				src = newMemLog(t, s, size)
+				if err := Mirror(ctx, src.fetchers(), dst, opts); err != nil {
+					t.Fatalf("Mirror(%d): %v", size, err)
+				}
+			}
+			// The destination should hold everything implied by the final checkpoint; some obsolete partial
+			// resources from earlier mirrored sizes may also be present.
+			for p, want := range src.files {
+				if got := dst.files[p]; !bytes.Equal(got, want) {
+					t.Errorf("%s: got %x, want %x", p, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMirrorResumes(t *testing.T) {
+	ctx := context.Background()
+	s, v := mustGenerateKey(t)
+	src := newMemLog(t, s, 5*layout.EntryBundleWidth)
+	dst := &memLog{files: make(map[string][]byte)}
+
+	// Fail fetching bundle 3 the first time around.
+	f := src.fetchers()
+	var mu sync.Mutex
+	fail := true
+	fetched := map[uint64]int{}
+	getBundle := f.EntryBundle
+	f.EntryBundle = func(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched[i]++
+		if i == 3 && fail {
+			return nil, fmt.Errorf("boom")
+		}
+		return getBundle(ctx, i, p)
+	}
+	opts := MirrorOptions{NumWorkers: 1, Verifier: v, Origin: s.Name()}
+	if err := Mirror(ctx, f, dst, opts); err == nil {
+		t.Fatal("Mirror: want error, got nil")
+	}
+	if _, ok := dst.files[layout.CheckpointPath]; ok {
+		t.Fatal("checkpoint written despite failed mirror")
+	}
+	fail = false
+	if err := Mirror(ctx, f, dst, opts); err != nil {
+		t.Fatalf("Mirror: %v", err)
+	}
+	for i := range uint64(3) {
+		if got := fetched[i]; got != 1 {
+			t.Errorf("bundle %d fetched %d times, want 1", i, got)
+		}
+	}
+	if got, want := dst.files[layout.CheckpointPath], src.files[layout.CheckpointPath]; !bytes.Equal(got, want) {
+		t.Errorf("got checkpoint %q, want %q", got, want)
+	}
+}
+
+func TestMirrorSourceGrows(t *testing.T) {
+	ctx := context.Background()
+	s, v := mustGenerateKey(t)
+	src := newMemLog(t, s, 300)
+	grown := newMemLog(t, s, 2*layout.EntryBundleWidth)
+	dst := &memLog{files: make(map[string][]byte)}
+
+	// The source has grown since its checkpoint was fetched, so serves the full bundle and tile instead of the
+	// partial ones.
+	f := src.fetchers()
+	f.EntryBundle = func(_ context.Context, i uint64, _ uint8) ([]byte, error) {
+		return grown.get(layout.EntriesPath(i, 0))
+	}
+	f.Tile = func(_ context.Context, l, i uint64, p uint8) ([]byte, error) {
+		if l == 0 {
+			return grown.get(layout.TilePath(l, i, 0))
+		}
+		return src.get(layout.TilePath(l, i, p))
+	}
+	opts := MirrorOptions{Verifier: v, Origin: s.Name()}
+	if err := Mirror(ctx, f, dst, opts); err != nil {
+		t.Fatalf("Mirror: %v", err)
+	}
+	// The bundle must be where the mirrored checkpoint implies it is.
+	p := layout.EntriesPath(1, 300-layout.EntryBundleWidth)
+	if got, want := dst.files[p], grown.files[layout.EntriesPath(1, 0)]; !bytes.Equal(got, want) {
+		t.Errorf("%s: got %d bytes, want the %d byte full bundle", p, len(got), len(want))
+	}
+}
+
+func TestMirrorVerifiesResumedBundle(t *testing.T) {
+	ctx := context.Background()
+	s, v := mustGenerateKey(t)
+	opts := MirrorOptions{Verifier: v, Origin: s.Name()}
+	dst := &memLog{files: make(map[string][]byte)}
+	if err := Mirror(ctx, newMemLog(t, s, 300).fetchers(), dst, opts); err != nil {
+		t.Fatalf("Mirror(300): %v", err)
+	}
+
+	// Rewrite an entry which has already been mirrored from the partial bundle, consistently with its tile.
+	src := newMemLog(t, s, 600)
+	b := bytes.Replace(src.files[layout.EntriesPath(1, 0)], []byte("entry 260"), []byte("ENTRY 260"), 1)
+	hashes, err := rfc6962LeafHasher(b)
+	if err != nil {
+		t.Fatalf("rfc6962LeafHasher: %v", err)
+	}
+	tile, err := api.HashTile{Nodes: hashes}.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+	src.files[layout.EntriesPath(1, 0)] = b
+	src.files[layout.TilePath(0, 1, 0)] = tile
+
+	err = Mirror(ctx, src.fetchers(), dst, opts)
+	if wantErr := "don't match those already mirrored"; err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("Mirror(600): got %v, want error containing %q", err, wantErr)
+	}
+	if _, ok := dst.files[layout.EntriesPath(1, 0)]; ok {
+		t.Error("bundle written despite failed verification")
+	}
+}
+
+func TestMirrorDetectsCorruption(t *testing.T) {
+	ctx := context.Background()
+	s, v := mustGenerateKey(t)
+	opts := MirrorOptions{Verifier: v, Origin: s.Name()}
+
+	for _, test := range []struct {
+		name    string
+		corrupt func(m *memLog)
+		wantErr string
+	}{
+		{
+			name: "bundle",
+			corrupt: func(m *memLog) {
+				p := layout.EntriesPath(1, 0)
+				m.files[p] = bytes.Replace(m.files[p], []byte("entry"), []byte("ENTRY"), 1)
+			},
+			wantErr: "hashes to",
+		},
+		{
+			name: "upper tile",
+			corrupt: func(m *memLog) {
+				p := layout.TilePath(1, 0, 3)
+				b := bytes.Clone(m.files[p])
+				b[0] ^= 1
+				m.files[p] = b
+			},
+			wantErr: "node 0 of tile level 1",
+		},
+		{
+			name: "root",
+			corrupt: func(m *memLog) {
+				cp, err := note.Sign(&note.Note{Text: string(f_log.Checkpoint{Origin: s.Name(), Size: 3 * layout.EntryBundleWidth, Hash: make([]byte, 32)}.Marshal())}, s)
+				if err != nil {
+					t.Fatalf("Sign: %v", err)
+				}
+				m.files[layout.CheckpointPath] = cp
+			},
+			wantErr: "!= source checkpoint root hash",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			src := newMemLog(t, s, 3*layout.EntryBundleWidth)
+			test.corrupt(src)
+			dst := &memLog{files: make(map[string][]byte)}
+			err := Mirror(ctx, src.fetchers(), dst, opts)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Mirror: got %v, want error containing %q", err, test.wantErr)
+			}
+			if _, ok := dst.files[layout.CheckpointPath]; ok {
+				t.Error("checkpoint written despite failed mirror")
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// MultiSourceOptions holds settings for a MultiSourceFetcher.
+type MultiSourceOptions struct {
+	// Verifier is used to verify the signature on the log's checkpoint.
+	Verifier note.Verifier
+	// Origin is the expected origin line of the log's checkpoint.
+	Origin string
+	// BundleLeafHasher knows how to turn a serialised entry bundle into the Merkle leaf hashes of the
+	// entries it contains. If nil, the bundle is assumed to be a https://c2sp.org/tlog-tiles bundle
+	// and RFC6962 leaf hashing is used.
+	BundleLeafHasher func([]byte) ([][]byte, error)
+}
+
+// MultiSourceFetcher reads a log's resources from an ordered list of sources, e.g. the log itself followed by
+// its mirrors, falling back to the next source whenever one fails to provide a resource or provides one which
+// doesn't verify. This allows monitors to keep working while the log itself is unavailable.
+//
+// All tiles and entry bundles are verified against the latest checkpoint returned by ReadCheckpoint, so a
+// source can't serve data which differs from that committed to by the log. ReadCheckpoint must therefore be
+// called before any other resources are read.
+type MultiSourceFetcher struct {
+	sources    []*source
+	opts       MultiSourceOptions
+	leafHasher func([]byte) ([][]byte, error)
+	rf         *compact.RangeFactory
+
+	// mu guards the fields below.
+	mu    sync.Mutex
+	cp    *log.Checkpoint
+	cpRaw []byte
+}
+
+// source is one of the sources of a MultiSourceFetcher.
+type source struct {
+	f Fetchers
+
+	// mu guards the fields below, since ProofBuilders are not safe for concurrent use.
+	mu sync.Mutex
+	// pb builds the proofs used to verify resources from this source against a checkpoint of the given size.
+	pb   *ProofBuilder
+	size uint64
+}
+
+// NewMultiSourceFetcher creates a fetcher which reads from sources in the order given.
+func NewMultiSourceFetcher(sources []Fetchers, opts MultiSourceOptions) (*MultiSourceFetcher, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("at least one source must be provided")
+	}
+	if opts.Verifier == nil {
+		return nil, errors.New("a checkpoint verifier must be provided")
+	}
+	f := &MultiSourceFetcher{
+		opts:       opts,
+		leafHasher: opts.BundleLeafHasher,
+		rf:         &compact.RangeFactory{Hash: hasher.HashChildren},
+	}
+	if f.leafHasher == nil {
+		f.leafHasher = rfc6962LeafHasher
+	}
+	for _, s := range sources {
+		f.sources = append(f.sources, &source{f: s})
+	}
+	return f, nil
+}
+
+// Fetchers returns the fetcher's methods in a form suitable for passing to e.g. Mirror.
+func (f *MultiSourceFetcher) Fetchers() Fetchers {
+	return Fetchers{
+		Checkpoint:  f.ReadCheckpoint,
+		Tile:        f.ReadTile,
+		EntryBundle: f.ReadEntryBundle,
+	}
+}
+
+// ReadCheckpoint fetches the checkpoint from the first source able to provide one with a valid signature.
+//
+// If it's larger than the checkpoint previously returned, it's checked to be consistent with it and becomes
+// the checkpoint which all other resources are verified against; if not, e.g. because a lagging mirror was
+// used, the previous checkpoint is returned. If the log has presented inconsistent checkpoints, the returned
+// error is an ErrInconsistency holding the evidence.
+func (f *MultiSourceFetcher) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	var errs []error
+	for i, s := range f.sources {
+		raw, err := s.f.Checkpoint(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %d: %w", i, err))
+			continue
+		}
+		cp, _, _, err := log.ParseCheckpoint(raw, f.opts.Origin, f.opts.Verifier)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %d: invalid checkpoint: %v", i, err))
+			continue
+		}
+		if len(errs) > 0 {
+			klog.V(1).Infof("MultiSourceFetcher: read checkpoint from source %d after: %v", i, errors.Join(errs...))
+		}
+		return f.update(ctx, s, cp, raw)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// update makes cp the checkpoint which resources are verified against, if it's larger than the current one
+// and consistent with it. The tiles needed to prove consistency are read from s.
+func (f *MultiSourceFetcher) update(ctx context.Context, s *source, cp *log.Checkpoint, raw []byte) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cp != nil && cp.Size <= f.cp.Size {
+		if cp.Size == f.cp.Size && !bytes.Equal(cp.Hash, f.cp.Hash) {
+			return nil, ErrInconsistency{
+				SmallerRaw: f.cpRaw,
+				LargerRaw:  raw,
+				Wrapped:    fmt.Errorf("checkpoints of size %d have different root hashes", cp.Size),
+			}
+		}
+		return f.cpRaw, nil
+	}
+	if f.cp != nil && f.cp.Size > 0 {
+		pb, err := NewProofBuilder(ctx, cp.Size, s.f.Tile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proof builder: %v", err)
+		}
+		p, err := pb.ConsistencyProof(ctx, f.cp.Size, cp.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build consistency proof from %d to %d: %v", f.cp.Size, cp.Size, err)
+		}
+		if err := proof.VerifyConsistency(hasher, f.cp.Size, cp.Size, p, f.cp.Hash, cp.Hash); err != nil {
+			return nil, ErrInconsistency{
+				SmallerRaw: f.cpRaw,
+				LargerRaw:  raw,
+				Proof:      p,
+				Report:     pb.ConsistencyReport(ctx, f.cp.Size, cp.Size, err),
+				Wrapped:    err,
+			}
+		}
+	}
+	f.cp, f.cpRaw = cp, raw
+	return raw, nil
+}
+
+// checkpoint returns the checkpoint which resources are currently verified against.
+func (f *MultiSourceFetcher) checkpoint() (*log.Checkpoint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cp == nil {
+		return nil, errors.New("no checkpoint to verify against, ReadCheckpoint must be called first")
+	}
+	return f.cp, nil
+}
+
+// ReadTile returns the specified tile from the first source able to provide a version of it which verifies
+// against the checkpoint most recently returned by ReadCheckpoint.
+//
+// The returned tile holds exactly the number of hashes requested, even if a source returned a larger version
+// of it, since only those hashes can be verified.
+func (f *MultiSourceFetcher) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+	cp, err := f.checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	want := tileWidth(p)
+	var errs []error
+	for si, s := range f.sources {
+		raw, err := s.f.Tile(ctx, l, i, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %d: %w", si, err))
+			continue
+		}
+		t := api.HashTile{}
+		if err := t.UnmarshalText(raw); err != nil {
+			errs = append(errs, fmt.Errorf("source %d: failed to parse tile: %v", si, err))
+			continue
+		}
+		if len(t.Nodes) < want {
+			errs = append(errs, fmt.Errorf("source %d: tile has %d nodes, want %d", si, len(t.Nodes), want))
+			continue
+		}
+		if err := f.verify(ctx, s, cp, l, i, t.Nodes[:want]); err != nil {
+			errs = append(errs, fmt.Errorf("source %d: %v", si, err))
+			continue
+		}
+		if len(errs) > 0 {
+			klog.V(1).Infof("MultiSourceFetcher: read tile level %d index %d from source %d after: %v", l, i, si, errors.Join(errs...))
+		}
+		if len(t.Nodes) > want {
+			return api.HashTile{Nodes: t.Nodes[:want]}.MarshalText()
+		}
+		return raw, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ReadEntryBundle returns the specified entry bundle from the first source able to provide a version of it
+// which verifies against the checkpoint most recently returned by ReadCheckpoint.
+//
+// Entry bundles are opaque, so if a source returns a larger version of the bundle than was requested, it's
+// returned as-is, but only the entries which were requested have been verified.
+func (f *MultiSourceFetcher) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	cp, err := f.checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	want := tileWidth(p)
+	var errs []error
+	for si, s := range f.sources {
+		raw, err := s.f.EntryBundle(ctx, i, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %d: %w", si, err))
+			continue
+		}
+		bh, err := f.leafHasher(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %d: failed to hash entry bundle: %v", si, err))
+			continue
+		}
+		if len(bh) < want {
+			errs = append(errs, fmt.Errorf("source %d: entry bundle has %d entries, want %d", si, len(bh), want))
+			continue
+		}
+		if err := f.verify(ctx, s, cp, 0, i, bh[:want]); err != nil {
+			errs = append(errs, fmt.Errorf("source %d: %v", si, err))
+			continue
+		}
+		if len(errs) > 0 {
+			klog.V(1).Infof("MultiSourceFetcher: read entry bundle %d from source %d after: %v", i, si, errors.Join(errs...))
+		}
+		return raw, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// verify checks that nodes are the hashes held by the tile at the given level and index in the tree
+// committed to by cp.
+//
+// It does this by calculating the root of the tree whose right-most nodes are those of the tile, using
+// the nodes to its left read from s, and then checking that this tree is consistent with cp. Neither the
+// nodes to the left nor the consistency proof need to be trusted, as they can only combine with the tile
+// to produce the checkpoint's root hash if all of them are genuine.
+func (f *MultiSourceFetcher) verify(ctx context.Context, s *source, cp *log.Checkpoint, level, index uint64, nodes [][]byte) error {
+	nodeLevel := uint(level * layout.TileHeight)
+	begin := index * layout.TileWidth << nodeLevel
+	end := begin + uint64(len(nodes))<<nodeLevel
+	if end > cp.Size {
+		return fmt.Errorf("tile level %d index %d with %d nodes is beyond checkpoint size %d", level, index, len(nodes), cp.Size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pb == nil || s.size != cp.Size {
+		pb, err := NewProofBuilder(ctx, cp.Size, s.f.Tile)
+		if err != nil {
+			return fmt.Errorf("failed to create proof builder: %v", err)
+		}
+		s.pb, s.size = pb, cp.Size
+	}
+
+	// The nodes covering [0, begin) are all at higher levels than the tile, so are found in the tiles above it.
+	ids := compact.RangeNodes(0, begin, nil)
+	left := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		h, err := s.pb.nodeCache.GetNode(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get node (%v): %v", id, err)
+		}
+		left = append(left, h)
+	}
+	r, err := f.rf.NewRange(0, begin, left)
+	if err != nil {
+		return fmt.Errorf("failed to create range: %v", err)
+	}
+	for j, n := range nodes {
+		b := begin + uint64(j)<<nodeLevel
+		nr, err := f.rf.NewRange(b, b+1<<nodeLevel, [][]byte{n})
+		if err != nil {
+			return fmt.Errorf("failed to create range: %v", err)
+		}
+		if err := r.AppendRange(nr, nil); err != nil {
+			return fmt.Errorf("failed to append node %d: %v", j, err)
+		}
+	}
+	root, err := r.GetRootHash(nil)
+	if err != nil {
+		return fmt.Errorf("failed to calculate root hash: %v", err)
+	}
+	var p [][]byte
+	if end < cp.Size {
+		if p, err = s.pb.ConsistencyProof(ctx, end, cp.Size); err != nil {
+			return fmt.Errorf("failed to build consistency proof from %d to %d: %v", end, cp.Size, err)
+		}
+	}
+	if err := proof.VerifyConsistency(hasher, end, cp.Size, p, root, cp.Hash); err != nil {
+		return fmt.Errorf("tile level %d index %d does not verify against checkpoint size %d: %v", level, index, cp.Size, err)
+	}
+	return nil
+}
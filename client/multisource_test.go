@@ -0,0 +1,158 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestMultiSourceFetcher(t *testing.T) {
+	s, v := mustGenerateKey(t)
+	// A log with three levels of tiles, and partial tiles at each of them.
+	const size = layout.TileWidth*layout.TileWidth + 3*layout.TileWidth + 10
+	good := newMemLog(t, s, size)
+	down := Fetchers{
+		Checkpoint:  func(context.Context) ([]byte, error) { return nil, os.ErrNotExist },
+		Tile:        func(context.Context, uint64, uint64, uint8) ([]byte, error) { return nil, os.ErrNotExist },
+		EntryBundle: func(context.Context, uint64, uint8) ([]byte, error) { return nil, os.ErrNotExist },
+	}
+	corrupt := newMemLog(t, s, size)
+	for _, p := range []string{layout.TilePath(0, 5, 0), layout.TilePath(1, 1, 3), layout.TilePath(2, 0, 1), layout.EntriesPath(259, 10)} {
+		b := bytes.Clone(corrupt.files[p])
+		b[len(b)-1] ^= 1
+		corrupt.files[p] = b
+	}
+
+	for _, test := range []struct {
+		name    string
+		sources []Fetchers
+		wantErr bool
+	}{
+		{name: "single good source", sources: []Fetchers{good.fetchers()}},
+		{name: "primary down", sources: []Fetchers{down, good.fetchers()}},
+		{name: "corrupt mirror", sources: []Fetchers{down, corrupt.fetchers(), good.fetchers()}},
+		{name: "only corrupt mirror", sources: []Fetchers{down, corrupt.fetchers()}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := NewMultiSourceFetcher(test.sources, MultiSourceOptions{Verifier: v, Origin: s.Name()})
+			if err != nil {
+				t.Fatalf("NewMultiSourceFetcher: %v", err)
+			}
+			if _, err := f.ReadTile(t.Context(), 0, 0, 0); err == nil {
+				t.Error("ReadTile before ReadCheckpoint succeeded, want error")
+			}
+			cp, err := f.ReadCheckpoint(t.Context())
+			if err != nil {
+				t.Fatalf("ReadCheckpoint: %v", err)
+			}
+			if want := good.files[layout.CheckpointPath]; !bytes.Equal(cp, want) {
+				t.Errorf("ReadCheckpoint: got %q, want %q", cp, want)
+			}
+
+			var gotErr error
+			for _, r := range []struct {
+				l, i uint64
+				p    uint8
+			}{{0, 0, 0}, {0, 5, 0}, {0, 259, 10}, {1, 0, 0}, {1, 1, 3}, {2, 0, 1}} {
+				got, err := f.ReadTile(t.Context(), r.l, r.i, r.p)
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				if want := good.files[layout.TilePath(r.l, r.i, r.p)]; !bytes.Equal(got, want) {
+					t.Errorf("ReadTile(%d, %d, %d): got %x, want %x", r.l, r.i, r.p, got, want)
+				}
+			}
+			for _, r := range []struct {
+				i uint64
+				p uint8
+			}{{0, 0}, {258, 0}, {259, 10}} {
+				got, err := f.ReadEntryBundle(t.Context(), r.i, r.p)
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				if want := good.files[layout.EntriesPath(r.i, r.p)]; !bytes.Equal(got, want) {
+					t.Errorf("ReadEntryBundle(%d, %d): got %q, want %q", r.i, r.p, got, want)
+				}
+			}
+			if (gotErr != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %t", gotErr, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestMultiSourceFetcherCheckpoints(t *testing.T) {
+	s, v := mustGenerateKey(t)
+	small := newMemLog(t, s, 2*layout.TileWidth+1)
+	large := newMemLog(t, s, 5*layout.TileWidth+7)
+	forked := newMemLog(t, s, 5*layout.TileWidth+7)
+	cp, err := note.Sign(&note.Note{Text: string(f_log.Checkpoint{Origin: s.Name(), Size: 5*layout.TileWidth + 7, Hash: make([]byte, 32)}.Marshal())}, s)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	forked.files[layout.CheckpointPath] = cp
+
+	for _, test := range []struct {
+		name    string
+		next    *memLog
+		want    []byte
+		wantErr error
+	}{
+		{name: "log grows", next: large, want: large.files[layout.CheckpointPath]},
+		{name: "lagging source", next: newMemLog(t, s, 3), want: small.files[layout.CheckpointPath]},
+		{name: "log forks", next: forked, wantErr: ErrInconsistency{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			src := &Fetchers{}
+			*src = small.fetchers()
+			f, err := NewMultiSourceFetcher([]Fetchers{{
+				Checkpoint:  func(ctx context.Context) ([]byte, error) { return src.Checkpoint(ctx) },
+				Tile:        func(ctx context.Context, l, i uint64, p uint8) ([]byte, error) { return src.Tile(ctx, l, i, p) },
+				EntryBundle: func(ctx context.Context, i uint64, p uint8) ([]byte, error) { return src.EntryBundle(ctx, i, p) },
+			}}, MultiSourceOptions{Verifier: v, Origin: s.Name()})
+			if err != nil {
+				t.Fatalf("NewMultiSourceFetcher: %v", err)
+			}
+			if _, err := f.ReadCheckpoint(t.Context()); err != nil {
+				t.Fatalf("ReadCheckpoint: %v", err)
+			}
+			*src = test.next.fetchers()
+			got, err := f.ReadCheckpoint(t.Context())
+			if test.wantErr != nil {
+				var inconsistent ErrInconsistency
+				if !errors.As(err, &inconsistent) {
+					t.Fatalf("ReadCheckpoint: got %v, want ErrInconsistency", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadCheckpoint: %v", err)
+			}
+			if !bytes.Equal(got, test.want) {
+				t.Errorf("ReadCheckpoint: got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
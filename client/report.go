@@ -0,0 +1,128 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
+)
+
+// VerificationReport describes the data from which a proof which failed verification was built, so that
+// the failure can be attributed to e.g. a corrupt tile, a tile from a different version of the tree, or a
+// log which has presented inconsistent checkpoints.
+type VerificationReport struct {
+	// Tiles are the tiles which were fetched to build the proof, ordered by level and index.
+	Tiles []ReportTile
+	// Nodes are the tree nodes which the proof was built from, as read from Tiles. Nodes which are not
+	// stored in tiles are computed from those which are, so the proof itself may hold fewer hashes.
+	Nodes []ReportNode
+	// ExpectedRoot is the root hash committed to by the checkpoint the proof was verified against, and
+	// ComputedRoot is the one calculated from the proof. Both are nil if verification failed for another
+	// reason, e.g. because the proof was the wrong length.
+	ExpectedRoot []byte
+	ComputedRoot []byte
+}
+
+// ReportTile is a tile which was fetched while building a proof.
+type ReportTile struct {
+	Level        uint64
+	Index        uint64
+	PartialWidth uint8
+	// Raw is the tile as it was returned by the TileFetcherFunc.
+	Raw []byte
+}
+
+// Hash returns the SHA-256 hash of the tile's raw bytes, which identifies the version of the tile which was
+// fetched without needing to compare its contents.
+func (t ReportTile) Hash() []byte {
+	h := sha256.Sum256(t.Raw)
+	return h[:]
+}
+
+// ReportNode is a tree node which a proof was built from.
+type ReportNode struct {
+	ID   compact.NodeID
+	Hash []byte
+}
+
+// String returns a multi-line, human readable description of the report, suitable for logging.
+func (r *VerificationReport) String() string {
+	b := &strings.Builder{}
+	if r.ExpectedRoot != nil || r.ComputedRoot != nil {
+		fmt.Fprintf(b, "expected root: %x\ncomputed root: %x\n", r.ExpectedRoot, r.ComputedRoot)
+	}
+	for _, t := range r.Tiles {
+		fmt.Fprintf(b, "tile level %d index %d width %d: %d bytes, sha256 %x\n", t.Level, t.Index, tileWidth(t.PartialWidth), len(t.Raw), t.Hash())
+	}
+	for _, n := range r.Nodes {
+		fmt.Fprintf(b, "node level %d index %d: %x\n", n.ID.Level, n.ID.Index, n.Hash)
+	}
+	return b.String()
+}
+
+// InclusionReport returns a report describing the tiles and nodes which an inclusion proof for the leaf at
+// index was built from, given the error which verifying the proof returned.
+//
+// It must be called on the ProofBuilder which built the proof, after InclusionProof.
+func (pb *ProofBuilder) InclusionReport(ctx context.Context, index uint64, verifyErr error) *VerificationReport {
+	nodes, err := proof.Inclusion(index, pb.treeSize)
+	if err != nil {
+		return pb.report(ctx, nil, verifyErr)
+	}
+	return pb.report(ctx, nodes.IDs, verifyErr)
+}
+
+// ConsistencyReport returns a report describing the tiles and nodes which a consistency proof between the
+// given tree sizes was built from, given the error which verifying the proof returned.
+//
+// It must be called on the ProofBuilder which built the proof, after ConsistencyProof.
+func (pb *ProofBuilder) ConsistencyReport(ctx context.Context, smaller, larger uint64, verifyErr error) *VerificationReport {
+	nodes, err := proof.Consistency(smaller, larger)
+	if err != nil {
+		return pb.report(ctx, nil, verifyErr)
+	}
+	return pb.report(ctx, nodes.IDs, verifyErr)
+}
+
+func (pb *ProofBuilder) report(ctx context.Context, ids []compact.NodeID, verifyErr error) *VerificationReport {
+	r := &VerificationReport{}
+	for _, id := range ids {
+		// The tiles holding these nodes were fetched when the proof was built, so they're read from the cache.
+		h, err := pb.nodeCache.GetNode(ctx, id)
+		if err != nil {
+			continue
+		}
+		r.Nodes = append(r.Nodes, ReportNode{ID: id, Hash: h})
+	}
+	for _, t := range pb.nodeCache.raw {
+		r.Tiles = append(r.Tiles, t)
+	}
+	slices.SortFunc(r.Tiles, func(a, b ReportTile) int {
+		return cmp.Or(cmp.Compare(a.Level, b.Level), cmp.Compare(a.Index, b.Index))
+	})
+	var mismatch proof.RootMismatchError
+	if errors.As(verifyErr, &mismatch) {
+		r.ExpectedRoot, r.ComputedRoot = mismatch.ExpectedRoot, mismatch.CalculatedRoot
+	}
+	return r
+}
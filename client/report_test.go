@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+)
+
+func TestVerifyInclusionReport(t *testing.T) {
+	const treeSize = 10
+	h := rfc6962.DefaultHasher
+	rf := &compact.RangeFactory{Hash: h.HashChildren}
+	cr := rf.NewEmptyRange(0)
+	tile := api.HashTile{}
+	for i := range treeSize {
+		lh := h.HashLeaf(fmt.Appendf(nil, "leaf %d", i))
+		tile.Nodes = append(tile.Nodes, lh)
+		if err := cr.Append(lh, nil); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	root, err := cr.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash: %v", err)
+	}
+	good, err := tile.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+	// The corrupt tile has the wrong hash for leaf 1, which is a sibling of leaf 0.
+	tile.Nodes[1] = h.HashLeaf([]byte("corrupt"))
+	corrupt, err := tile.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		tile    []byte
+		wantErr bool
+	}{
+		{name: "good tile", tile: good},
+		{name: "corrupt tile", tile: corrupt, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := func(_ context.Context, l, i uint64, p uint8) ([]byte, error) {
+				return test.tile, nil
+			}
+			pb, err := NewProofBuilder(t.Context(), treeSize, f)
+			if err != nil {
+				t.Fatalf("NewProofBuilder: %v", err)
+			}
+			leafHash := h.HashLeaf([]byte("leaf 0"))
+			_, err = pb.VerifyInclusion(t.Context(), 0, leafHash, root)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("VerifyInclusion: %v, want error %t", err, test.wantErr)
+			}
+			if !test.wantErr {
+				return
+			}
+			var e ErrInclusion
+			if !errors.As(err, &e) {
+				t.Fatalf("VerifyInclusion returned %T, want ErrInclusion", err)
+			}
+			r := e.Report
+			if !bytes.Equal(r.ExpectedRoot, root) || r.ComputedRoot == nil || bytes.Equal(r.ComputedRoot, root) {
+				t.Errorf("got expected root %x and computed root %x, want expected root %x and a different computed root", r.ExpectedRoot, r.ComputedRoot, root)
+			}
+			if len(r.Tiles) != 1 || !bytes.Equal(r.Tiles[0].Raw, corrupt) || r.Tiles[0].PartialWidth != treeSize {
+				t.Errorf("got tiles %+v, want only the corrupt tile", r.Tiles)
+			}
+			found := false
+			for _, n := range r.Nodes {
+				if n.ID == compact.NewNodeID(0, 1) {
+					found = bytes.Equal(n.Hash, tile.Nodes[1])
+				}
+			}
+			if !found {
+				t.Errorf("report nodes %v don't include the corrupt node", r.Nodes)
+			}
+		})
+	}
+}
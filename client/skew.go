@@ -0,0 +1,121 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/parse"
+)
+
+const (
+	// DefaultSkewRetries is used by the skew tolerant fetchers if SkewOptions.Retries is unset.
+	DefaultSkewRetries = 3
+	// DefaultSkewRetryInterval is used by the skew tolerant fetchers if SkewOptions.RetryInterval is unset.
+	DefaultSkewRetryInterval = time.Second
+)
+
+// SkewOptions configures the fetchers returned by SkewTolerantTileFetcher and SkewTolerantEntryBundleFetcher.
+type SkewOptions struct {
+	// Retries is the maximum number of times the checkpoint is refetched, and the resource requested again,
+	// for each call to the fetcher. If zero, DefaultSkewRetries is used.
+	Retries int
+	// RetryInterval is how long to wait before requesting the resource again when the log's checkpoint
+	// doesn't yet cover it. If zero, DefaultSkewRetryInterval is used.
+	RetryInterval time.Duration
+}
+
+// SkewTolerantTileFetcher returns a TileFetcherFunc which handles skew between the checkpoint the caller is
+// working from and the state of the log that f fetches from.
+//
+// When f can't find a tile, the log's checkpoint is refetched using cf. If the log has since grown beyond
+// the tile, the version of the tile implied by the new checkpoint is requested instead, since the partial
+// tile the caller asked for may have been garbage collected, and the current one begins with the same
+// hashes. If the log hasn't yet caught up with the caller, e.g. because it's served from a replica or
+// cache which lags behind, the tile is requested again after SkewOptions.RetryInterval. An error wrapping
+// os.ErrNotExist is only returned once SkewOptions.Retries attempts have been made.
+//
+// The returned tile may therefore contain more hashes than were requested, which the TileFetcherFunc
+// contract allows.
+func SkewTolerantTileFetcher(f TileFetcherFunc, cf CheckpointFetcherFunc, opts SkewOptions) TileFetcherFunc {
+	opts = opts.withDefaults()
+	return func(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+		return fetchWithSkew(ctx, cf, opts, level, index, p, func(ctx context.Context, p uint8) ([]byte, error) {
+			return f(ctx, level, index, p)
+		})
+	}
+}
+
+// SkewTolerantEntryBundleFetcher returns an EntryBundleFetcherFunc which handles skew between the checkpoint
+// the caller is working from and the state of the log that f fetches from, in the same way as
+// SkewTolerantTileFetcher.
+func SkewTolerantEntryBundleFetcher(f EntryBundleFetcherFunc, cf CheckpointFetcherFunc, opts SkewOptions) EntryBundleFetcherFunc {
+	opts = opts.withDefaults()
+	return func(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+		return fetchWithSkew(ctx, cf, opts, 0, index, p, func(ctx context.Context, p uint8) ([]byte, error) {
+			return f(ctx, index, p)
+		})
+	}
+}
+
+func (o SkewOptions) withDefaults() SkewOptions {
+	if o.Retries == 0 {
+		o.Retries = DefaultSkewRetries
+	}
+	if o.RetryInterval == 0 {
+		o.RetryInterval = DefaultSkewRetryInterval
+	}
+	return o
+}
+
+// fetchWithSkew calls f to fetch the resource at the given level and index with partial size p, retrying
+// as described by SkewTolerantTileFetcher if it doesn't exist.
+func fetchWithSkew(ctx context.Context, cf CheckpointFetcherFunc, opts SkewOptions, level, index uint64, p uint8, f func(context.Context, uint8) ([]byte, error)) ([]byte, error) {
+	r, err := f(ctx, p)
+	// need is the size of the tree which first contains the requested resource.
+	need := (index*layout.TileWidth + uint64(tileWidth(p))) << (layout.TileHeight * level)
+	for attempt := 0; errors.Is(err, os.ErrNotExist) && attempt < opts.Retries; attempt++ {
+		cp, cErr := cf(ctx)
+		if cErr != nil {
+			return nil, fmt.Errorf("%w (and failed to refetch checkpoint: %v)", err, cErr)
+		}
+		_, size, _, cErr := parse.CheckpointUnsafe(cp)
+		if cErr != nil {
+			return nil, fmt.Errorf("%w (and failed to parse refetched checkpoint: %v)", err, cErr)
+		}
+		fetchP := p
+		if size >= need {
+			// The log has the resource, but perhaps only in a larger version than we asked for.
+			fetchP = layout.PartialTileSize(level, index, size)
+		} else {
+			// The log is behind the caller, so give it a chance to catch up.
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(opts.RetryInterval):
+			}
+		}
+		r, err = f(ctx, fetchP)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
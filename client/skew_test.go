@@ -0,0 +1,139 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/fetcher"
+)
+
+// skewedLog serves tiles from a map keyed by path, and reports the next of its checkpoint sizes each time
+// its checkpoint is fetched, modelling a log which grows, or a lagging replica which catches up.
+type skewedLog struct {
+	mu    sync.Mutex
+	tiles map[string][]byte
+	sizes []uint64
+}
+
+func (l *skewedLog) checkpoint(_ context.Context) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	size := l.sizes[0]
+	if len(l.sizes) > 1 {
+		l.sizes = l.sizes[1:]
+	}
+	// Tiles appear once the log has grown to the size which implies them.
+	for i := uint64(1); i <= size; i++ {
+		if p := layout.PartialTileSize(0, 0, i); p == 0 || i == size {
+			l.tiles[layout.TilePath(0, 0, p)] = fmt.Appendf(nil, "size %d", i)
+		}
+	}
+	return fmt.Appendf(nil, "origin\n%d\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", size), nil
+}
+
+func (l *skewedLog) tile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	return fetcher.PartialOrFullResource(ctx, p, func(_ context.Context, p uint8) ([]byte, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		t, ok := l.tiles[layout.TilePath(level, index, p)]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return t, nil
+	})
+}
+
+func TestSkewTolerantTileFetcher(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		tiles   map[string][]byte
+		sizes   []uint64
+		p       uint8
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "no skew",
+			tiles: map[string][]byte{layout.TilePath(0, 0, 10): []byte("size 10")},
+			sizes: []uint64{10},
+			p:     10,
+			want:  "size 10",
+		},
+		{
+			name:  "partial tile garbage collected",
+			sizes: []uint64{20},
+			p:     10,
+			want:  "size 20",
+		},
+		{
+			name:  "log catches up",
+			sizes: []uint64{5, 8, 12},
+			p:     10,
+			want:  "size 12",
+		},
+		{
+			name:    "log never catches up",
+			sizes:   []uint64{5},
+			p:       10,
+			wantErr: os.ErrNotExist,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			l := &skewedLog{tiles: map[string][]byte{}, sizes: test.sizes}
+			for k, v := range test.tiles {
+				l.tiles[k] = v
+			}
+			f := SkewTolerantTileFetcher(l.tile, l.checkpoint, SkewOptions{RetryInterval: time.Millisecond})
+			got, err := f(t.Context(), 0, 0, test.p)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("got error %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSkewTolerantFetcherOtherErrors(t *testing.T) {
+	calls := 0
+	f := SkewTolerantEntryBundleFetcher(func(context.Context, uint64, uint8) ([]byte, error) {
+		calls++
+		return nil, errors.New("boom")
+	}, func(context.Context) ([]byte, error) {
+		t.Error("checkpoint refetched after an error other than not found")
+		return nil, os.ErrNotExist
+	}, SkewOptions{})
+	if _, err := f(t.Context(), 0, 0); err == nil {
+		t.Error("fetch succeeded, want error")
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+}
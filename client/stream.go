@@ -135,6 +135,69 @@ func EntryBundles(ctx context.Context, numWorkers uint, getSize TreeSizeFunc, ge
 	}
 }
 
+// BulkEntryBundles produces an iterator which returns a stream of Bundle structs which cover the requested range of
+// entries in their natural order in the log, in the same way as EntryBundles.
+//
+// Rather than fetching each bundle separately, full bundles are fetched batchSize at a time using getBundles, which
+// is intended for sources which can read many bundles with a single request (e.g. tessera.LogReader.ReadEntryBundles).
+// Only a partial bundle at the end of the range is fetched with getBundle.
+//
+// If the adaptor encounters an error while reading entry bundles, the encountered error will be returned via the iterator.
+func BulkEntryBundles(ctx context.Context, batchSize uint, getSize TreeSizeFunc, getBundles EntryBundlesFetcherFunc, getBundle EntryBundleFetcherFunc, fromEntry uint64, N uint64) iter.Seq2[Bundle, error] {
+	return func(yield func(Bundle, error) bool) {
+		ctx, span := tracer.Start(ctx, "tessera.storage.BulkStreamAdaptor")
+		defer span.End()
+
+		treeSize, err := getSize(ctx)
+		if err != nil {
+			yield(Bundle{}, err)
+			return
+		}
+		batchSize = max(batchSize, 1)
+
+		// batch holds the consecutive full bundles which are yet to be fetched.
+		batch := make([]layout.RangeInfo, 0, batchSize)
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+			bs, err := getBundles(ctx, batch[0].Index, uint64(len(batch)))
+			if err == nil && len(bs) != len(batch) {
+				err = fmt.Errorf("got %d entry bundles from %d, want %d", len(bs), batch[0].Index, len(batch))
+			}
+			if err != nil {
+				yield(Bundle{}, err)
+				return false
+			}
+			for i, ri := range batch {
+				if !yield(Bundle{RangeInfo: ri, Data: bs[i]}, nil) {
+					return false
+				}
+			}
+			batch = batch[:0]
+			return true
+		}
+
+		for ri := range layout.Range(fromEntry, N, treeSize) {
+			if ri.Partial == 0 {
+				batch = append(batch, ri)
+				if uint(len(batch)) >= batchSize && !flush() {
+					return
+				}
+				continue
+			}
+			if !flush() {
+				return
+			}
+			b, err := getBundle(ctx, ri.Index, ri.Partial)
+			if !yield(Bundle{RangeInfo: ri, Data: b}, err) || err != nil {
+				return
+			}
+		}
+		flush()
+	}
+}
+
 // Entry represents a single leaf in a log.
 type Entry[T any] struct {
 	// Index is the index of the entry in the log.
@@ -82,6 +82,61 @@ func TestEntryBundles(t *testing.T) {
 	}
 }
 
+func TestBulkEntryBundles(t *testing.T) {
+	ctx := t.Context()
+
+	logSize := uint64(2345)
+
+	tl, done := testonly.NewTestLog(t, tessera.NewAppendOptions().WithBatching(uint(logSize), time.Second).WithCheckpointInterval(time.Second))
+	defer func() {
+		if err := done(ctx); err != nil {
+			t.Fatalf("done: %v", err)
+		}
+	}()
+
+	if _, err := populateEntries(t, tl, logSize, "bulk"); err != nil {
+		t.Fatalf("populateEntries(): %v", err)
+	}
+	size := func(ctx context.Context) (uint64, error) {
+		return logSize, nil
+	}
+
+	for _, test := range []struct {
+		name      string
+		batchSize uint
+		from, N   uint64
+	}{
+		{name: "all", batchSize: 3, from: 0, N: logSize},
+		{name: "unaligned", batchSize: 2, from: 300, N: 1000},
+		{name: "one batch", batchSize: 100, from: 10, N: logSize - 10},
+		{name: "partial only", batchSize: 3, from: logSize - 5, N: 5},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			want := test.from
+			for gotBundle, gotErr := range client.BulkEntryBundles(ctx, test.batchSize, size, tl.LogReader.ReadEntryBundles, tl.LogReader.ReadEntryBundle, test.from, test.N) {
+				if gotErr != nil {
+					t.Fatalf("gotErr after %d: %v", want, gotErr)
+				}
+				ri := gotBundle.RangeInfo
+				if e := ri.Index*layout.EntryBundleWidth + uint64(ri.First); e != want {
+					t.Fatalf("got idx %d, want %d", e, want)
+				}
+				wantData, err := tl.LogReader.ReadEntryBundle(ctx, ri.Index, ri.Partial)
+				if err != nil {
+					t.Fatalf("ReadEntryBundle(%d): %v", ri.Index, err)
+				}
+				if string(gotBundle.Data) != string(wantData) {
+					t.Fatalf("bundle %d differs from ReadEntryBundle", ri.Index)
+				}
+				want += uint64(ri.N)
+			}
+			if want != test.from+test.N {
+				t.Fatalf("streamed up to %d, want %d", want, test.from+test.N)
+			}
+		})
+	}
+}
+
 func TestEntries(t *testing.T) {
 	ctx := t.Context()
 
@@ -0,0 +1,63 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// TileHeightFetcherFunc is the signature of a function which can fetch the file describing the height of a
+// log's tiles, found at layout.TileHeightPath.
+//
+// Note that the implementation of this MUST return (either directly or wrapped) an os.ErrNotExist
+// when the log has no such file.
+type TileHeightFetcherFunc func(ctx context.Context) ([]byte, error)
+
+// FetchTiling returns the tiling used by a log, for use with NewProofBuilderWithTiling and
+// FetchRangeNodesWithTiling.
+//
+// Logs which use layout.DefaultTiling needn't publish their tile height, so layout.DefaultTiling is returned
+// if the log has no tile height file.
+func FetchTiling(ctx context.Context, f TileHeightFetcherFunc) (layout.Tiling, error) {
+	raw, err := f(ctx)
+	if errors.Is(err, os.ErrNotExist) {
+		return layout.DefaultTiling, nil
+	}
+	if err != nil {
+		return layout.Tiling{}, err
+	}
+	var tl layout.Tiling
+	if err := tl.UnmarshalText(raw); err != nil {
+		return layout.Tiling{}, fmt.Errorf("failed to parse tile height: %v", err)
+	}
+	return tl, nil
+}
+
+// validTiling returns an error if tiles described by tl can't be fetched with a TileFetcherFunc, whose
+// partial tile sizes are limited to the 255 nodes which can be held by a tile of height layout.TileHeight.
+func validTiling(tl layout.Tiling) error {
+	if err := tl.Validate(); err != nil {
+		return err
+	}
+	if tl.Height > layout.TileHeight {
+		return fmt.Errorf("tile height %d is not supported, the maximum is %d", tl.Height, layout.TileHeight)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+func TestFetchTiling(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		raw     []byte
+		err     error
+		want    layout.Tiling
+		wantErr bool
+	}{
+		{name: "no tile height", err: fmt.Errorf("get: %w", os.ErrNotExist), want: layout.DefaultTiling},
+		{name: "height 4", raw: []byte("4\n"), want: layout.Tiling{Height: 4}},
+		{name: "malformed", raw: []byte("four"), wantErr: true},
+		{name: "fetch error", err: errors.New("boom"), wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := FetchTiling(t.Context(), func(context.Context) ([]byte, error) { return test.raw, test.err })
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("FetchTiling: %v, wantErr %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("FetchTiling() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewProofBuilderWithTilingInvalid(t *testing.T) {
+	f := func(context.Context, uint64, uint64, uint8) ([]byte, error) { return nil, os.ErrNotExist }
+	for _, tl := range []layout.Tiling{{Height: 0}, {Height: layout.TileHeight + 2}} {
+		if _, err := NewProofBuilderWithTiling(t.Context(), 10, f, rfc6962.DefaultHasher, tl); err == nil {
+			t.Errorf("NewProofBuilderWithTiling(height %d) succeeded, want error", tl.Height)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/transparency-dev/formats/log"
+)
+
+// verifyLogWorkers is the number of entry bundles and tiles which VerifyLog fetches in parallel.
+const verifyLogWorkers = 8
+
+// VerifyLog checks that the entries of the log readable via f are those committed to by cp, which the
+// caller must already have verified the signature of.
+//
+// Every entry bundle is fetched and hashed, and the tree is rebuilt from the hashes in the order they
+// appear in the log, checking along the way that every tile matches the hashes it should contain. Only
+// the compact range of the tree being rebuilt, and the right-most row of nodes at each tile level, are
+// held in memory, so the memory needed grows only logarithmically with the size of the log. This allows
+// auditors to check even very large logs in their entirety.
+//
+// Entry bundles are assumed to be https://c2sp.org/tlog-tiles bundles, with RFC6962 leaf hashing.
+func VerifyLog(ctx context.Context, f Fetchers, cp log.Checkpoint) error {
+	ctx, span := tracer.Start(ctx, "tessera.client.VerifyLog")
+	defer span.End()
+
+	m := newMirrorer(f, nil, MirrorOptions{NumWorkers: verifyLogWorkers}, cp.Size)
+	return m.copy(ctx, cp.Hash)
+}
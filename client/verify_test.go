@@ -0,0 +1,83 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+func TestVerifyLog(t *testing.T) {
+	s, v := mustGenerateKey(t)
+	const size = layout.TileWidth*layout.TileWidth + 3*layout.TileWidth + 10
+
+	for _, test := range []struct {
+		name    string
+		size    uint64
+		corrupt func(m *memLog)
+		wantErr bool
+	}{
+		{name: "empty", size: 0},
+		{name: "single entry", size: 1},
+		{name: "one full bundle", size: layout.EntryBundleWidth},
+		{name: "three tile levels", size: size},
+		{
+			name: "corrupt bundle",
+			size: size,
+			corrupt: func(m *memLog) {
+				p := layout.EntriesPath(100, 0)
+				m.files[p] = bytes.Replace(m.files[p], []byte("entry"), []byte("ENTRY"), 1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "corrupt upper tile",
+			size: size,
+			corrupt: func(m *memLog) {
+				p := layout.TilePath(2, 0, 1)
+				b := bytes.Clone(m.files[p])
+				b[0] ^= 1
+				m.files[p] = b
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing bundle",
+			size: size,
+			corrupt: func(m *memLog) {
+				delete(m.files, layout.EntriesPath(259, 10))
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := newMemLog(t, s, test.size)
+			if test.corrupt != nil {
+				test.corrupt(m)
+			}
+			cp, _, _, err := f_log.ParseCheckpoint(m.files[layout.CheckpointPath], s.Name(), v)
+			if err != nil {
+				t.Fatalf("ParseCheckpoint: %v", err)
+			}
+			err = VerifyLog(t.Context(), m.fetchers(), *cp)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("VerifyLog: %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
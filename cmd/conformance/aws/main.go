@@ -29,11 +29,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/go-sql-driver/mysql"
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/serve"
+	"github.com/transparency-dev/tessera/cmd/conformance/internal/server"
 	"github.com/transparency-dev/tessera/storage/aws"
 	aws_as "github.com/transparency-dev/tessera/storage/aws/antispam"
 	"golang.org/x/mod/sumdb/note"
-	"golang.org/x/net/http2"
-	"golang.org/x/net/http2/h2c"
 	"k8s.io/klog/v2"
 )
 
@@ -46,7 +46,9 @@ var (
 	dbPassword        = flag.String("db_password", "", "AuroraDB user")
 	dbMaxConns        = flag.Int("db_max_conns", 0, "Maximum connections to the database, defaults to 0, i.e unlimited")
 	dbMaxIdle         = flag.Int("db_max_idle_conns", 2, "Maximum idle database connections in the connection pool, defaults to 2")
+	dbFailoverTimeout = flag.Duration("db_failover_retry_timeout", aws.DefaultFailoverRetryTimeout, "How long to retry sequencing batches which fail while the database fails over to a new writer, or 0 to not retry")
 	s3Endpoint        = flag.String("s3_endpoint", "", "Endpoint for custom non-AWS S3 service")
+	verifyWrites      = flag.Float64("verify_writes_fraction", 0, "Fraction of tiles and entry bundles to read back from S3 after writing, to check they were stored intact")
 	s3AccessKeyID     = flag.String("s3_access_key", "", "Access key ID for custom non-AWS S3 service")
 	s3SecretAccessKey = flag.String("s3_secret", "", "Secret access key for custom non-AWS S3 service")
 
@@ -60,6 +62,30 @@ var (
 	antispamDb     = flag.String("antispam_db_name", "", "AuroraDB name for the antispam DB")
 )
 
+// tlsOpts configures the listener to serve HTTPS, if set.
+var tlsOpts = server.RegisterTLSFlags()
+
+// healthOpts configures the checks made by the /healthz and /readyz handlers.
+var healthOpts = server.RegisterHealthFlags()
+
+// adminListen is the address of the admin API, if set.
+var adminListen = server.RegisterAdminFlags()
+
+// logFormat selects the format of log output.
+var logFormat = server.RegisterLoggingFlags()
+
+// enableUI serves a read-only web UI for browsing the log, if set.
+var enableUI = flag.Bool("ui", false, "Serve a read-only web UI for browsing the log at /ui/")
+
+// compressEntryBundles stores entry bundles compressed with zstd, if set.
+var compressEntryBundles = flag.Bool("compress_entry_bundles", false, "Store entry bundles compressed with zstd")
+
+// gzipEntryBundles stores entry bundles compressed with gzip, if set.
+var gzipEntryBundles = flag.Bool("gzip_entry_bundles", false, "Store entry bundles compressed with gzip, rather than zstd")
+
+// integrationHints serves hints for autoscaling the integration workers, if set.
+var integrationHints = flag.Bool("integration_hints", false, "Serve the sequencing backlog and integration rate as JSON at /integration, and export them as metrics")
+
 func init() {
 	flag.Func("additional_signer", "Additional note signer for checkpoints, may be specified multiple times", func(s string) error {
 		additionalSigners = append(additionalSigners, s)
@@ -75,6 +101,9 @@ func main() {
 	shutdownOTel := initOTel(ctx, *traceFraction)
 	defer shutdownOTel(ctx)
 	s, a := signerFromFlags()
+	if err := server.InitLogging(*logFormat, s.Name()); err != nil {
+		klog.Exit(err)
+	}
 
 	// Create our Tessera storage backend:
 	awsCfg := storageConfigFromFlags()
@@ -91,12 +120,24 @@ func main() {
 			klog.Exitf("Failed to create new AWS antispam storage: %v", err)
 		}
 	}
-	appender, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+	appendOpts := tessera.NewAppendOptions().
 		WithCheckpointSigner(s, a...).
 		WithCheckpointInterval(*publishInterval).
 		WithBatching(512, 300*time.Millisecond).
 		WithPushback(10*4096).
-		WithAntispam(256<<10, antispam))
+		WithAntispam(256<<10, antispam)
+	if *compressEntryBundles {
+		appendOpts.WithEntryBundleCompression()
+	}
+	if *gzipEntryBundles {
+		appendOpts.WithEntryBundleGzipCompression()
+	}
+	if *integrationHints {
+		m := tessera.NewIntegrationMonitor(tessera.IntegrationMonitorOptions{})
+		appendOpts.WithIntegrationMonitor(m)
+		http.Handle("GET /integration", m.Handler())
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, appendOpts)
 	if err != nil {
 		klog.Exit(err)
 	}
@@ -126,20 +167,23 @@ func main() {
 		_, _ = fmt.Fprintf(w, "%d", idx.Index)
 	})
 
-	h2s := &http2.Server{}
-	h1s := &http.Server{
-		Addr:    *listen,
-		Handler: h2c.NewHandler(http.DefaultServeMux, h2s),
-	}
-	if err := http2.ConfigureServer(h1s, h2s); err != nil {
-		klog.Exitf("http2.ConfigureServer: %v", err)
-	}
-
-	if err := h1s.ListenAndServe(); err != nil {
-		if err := shutdown(ctx); err != nil {
+	if *enableUI {
+		ui, err := serve.NewUI(ctx, reader, serve.UIOptions{})
+		if err != nil {
 			klog.Exit(err)
 		}
-		klog.Exitf("ListenAndServe: %v", err)
+		ui.RegisterHandlers(http.DefaultServeMux)
+	}
+	server.NewHealth(appender, reader, *healthOpts).RegisterHandlers(http.DefaultServeMux)
+	if err := server.ServeAdmin(*adminListen, appender, nil); err != nil {
+		klog.Exit(err)
+	}
+	srv, err := server.New(*listen, http.DefaultServeMux, tlsOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ListenAndServe(srv, shutdown); err != nil {
+		klog.Exit(err)
 	}
 }
 
@@ -193,13 +237,20 @@ func storageConfigFromFlags() aws.Config {
 		}
 	}
 
+	// A zero timeout asks for the default, so a negative one is needed to disable retries.
+	failoverTimeout := *dbFailoverTimeout
+	if failoverTimeout == 0 {
+		failoverTimeout = -1
+	}
 	return aws.Config{
-		Bucket:       *bucket,
-		SDKConfig:    awsConfig,
-		S3Options:    s3Opts,
-		DSN:          c.FormatDSN(),
-		MaxOpenConns: *dbMaxConns,
-		MaxIdleConns: *dbMaxIdle,
+		Bucket:               *bucket,
+		SDKConfig:            awsConfig,
+		S3Options:            s3Opts,
+		DSN:                  c.FormatDSN(),
+		MaxOpenConns:         *dbMaxConns,
+		MaxIdleConns:         *dbMaxIdle,
+		FailoverRetryTimeout: failoverTimeout,
+		VerifyWritesFraction: *verifyWrites,
 	}
 }
 
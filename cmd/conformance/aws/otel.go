@@ -99,7 +99,7 @@ func initOTel(ctx context.Context, traceFraction float64) func(context.Context)
 		trace.WithBatcher(traceExporter),
 		trace.WithIDGenerator(idg),
 		trace.WithResource(resources),
-		trace.WithSampler(trace.TraceIDRatioBased(traceFraction)),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(traceFraction))),
 	)
 	shutdownFuncs = append(shutdownFuncs, tp.Shutdown)
 	otel.SetTracerProvider(tp)
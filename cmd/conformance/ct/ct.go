@@ -0,0 +1,289 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/ctonly"
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+	"k8s.io/klog/v2"
+)
+
+// maxChainRequestSize is the largest add-chain or add-pre-chain request body which will be read.
+const maxChainRequestSize = 1 << 20
+
+var (
+	// oidPoison is the critical extension which marks a certificate as a precertificate, see RFC 6962 section 3.1.
+	oidPoison = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+	// oidPrecertSigning is the extended key usage of a precertificate signing certificate.
+	oidPrecertSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 4}
+
+	// errBadChain is returned when a submitted chain is not acceptable to the log.
+	errBadChain = errors.New("bad chain")
+)
+
+// addChainRequest is the body of add-chain and add-pre-chain requests.
+type addChainRequest struct {
+	Chain [][]byte `json:"chain"`
+}
+
+// addChainResponse is the SCT returned in response to add-chain and add-pre-chain requests.
+type addChainResponse struct {
+	SCTVersion uint8  `json:"sct_version"`
+	ID         []byte `json:"id"`
+	Timestamp  uint64 `json:"timestamp"`
+	Extensions []byte `json:"extensions"`
+	Signature  []byte `json:"signature"`
+}
+
+// ctLog accepts c2sp.org/static-ct-api submissions, and adds them to a Tessera log.
+type ctLog struct {
+	add     func(context.Context, *ctonly.Entry) tessera.IndexFuture
+	awaiter *tessera.PublicationAwaiter
+	signer  *ctSigner
+	roots   *x509.CertPool
+	// rootsDER holds the accepted roots, in the order they are returned by get-roots.
+	rootsDER [][]byte
+	// storeIssuer durably stores the DER encoded issuer certificate, so that it can be served at
+	// /issuer/<fingerprint>.
+	storeIssuer func(ctx context.Context, fingerprint [sha256.Size]byte, der []byte) error
+}
+
+// RegisterHandlers registers the submission endpoints of the log with the provided mux.
+func (l *ctLog) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /ct/v1/add-chain", func(w http.ResponseWriter, r *http.Request) { l.handleAdd(w, r, false) })
+	mux.HandleFunc("POST /ct/v1/add-pre-chain", func(w http.ResponseWriter, r *http.Request) { l.handleAdd(w, r, true) })
+	mux.HandleFunc("GET /ct/v1/get-roots", l.handleGetRoots)
+}
+
+func (l *ctLog) handleAdd(w http.ResponseWriter, r *http.Request, precert bool) {
+	req := addChainRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxChainRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+	sct, err := l.addChain(r.Context(), req.Chain, precert)
+	switch {
+	case errors.Is(err, errBadChain):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, tessera.ErrPushback):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "pushback", http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		klog.FromContext(r.Context()).Error(err, "Failed to add chain", "path", r.URL.Path)
+		http.Error(w, "failed to add chain", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, sct)
+}
+
+func (l *ctLog) handleGetRoots(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, struct {
+		Certificates [][]byte `json:"certificates"`
+	}{Certificates: l.rootsDER})
+}
+
+// addChain validates the chain, adds it to the log, and returns an SCT for it once it has been published.
+func (l *ctLog) addChain(ctx context.Context, chainDER [][]byte, precert bool) (*addChainResponse, error) {
+	e, issuers, err := l.entryFromChain(chainDER, precert)
+	if err != nil {
+		return nil, err
+	}
+	// Issuers must be stored before the entry is sequenced, so that every entry in the log refers to
+	// issuers which can be fetched.
+	for i, c := range issuers {
+		if err := l.storeIssuer(ctx, e.FingerprintsChain[i], c.Raw); err != nil {
+			return nil, fmt.Errorf("failed to store issuer: %v", err)
+		}
+	}
+	idx, _, err := l.awaiter.Await(ctx, l.add(ctx, e))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add entry: %w", err)
+	}
+
+	ext, err := ctonly.SCTExtensions(idx.Index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SCT extensions: %v", err)
+	}
+	// The data covered by an SCT signature is the same as the entry's MerkleTreeLeaf, since the
+	// signature_type of certificate_timestamp and the leaf_type of timestamped_entry are both zero.
+	sig, err := l.signer.digitallySigned(e.MerkleTreeLeaf(idx.Index))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign SCT: %v", err)
+	}
+	return &addChainResponse{
+		SCTVersion: 0,
+		ID:         l.signer.logID[:],
+		Timestamp:  e.Timestamp,
+		Extensions: ext,
+		Signature:  sig,
+	}, nil
+}
+
+// entryFromChain validates the submitted chain, and returns the entry to add to the log along with the
+// certificates in the verified chain, excluding the leaf.
+func (l *ctLog) entryFromChain(chainDER [][]byte, precert bool) (*ctonly.Entry, []*x509.Certificate, error) {
+	if len(chainDER) == 0 {
+		return nil, nil, fmt.Errorf("%w: empty chain", errBadChain)
+	}
+	certs := make([]*x509.Certificate, 0, len(chainDER))
+	for i, der := range chainDER {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: failed to parse certificate %d: %v", errBadChain, i, err)
+		}
+		certs = append(certs, c)
+	}
+	leaf := certs[0]
+
+	isPrecert := false
+	for _, ext := range leaf.Extensions {
+		if ext.Id.Equal(oidPoison) {
+			if !ext.Critical {
+				return nil, nil, fmt.Errorf("%w: precertificate poison extension is not critical", errBadChain)
+			}
+			isPrecert = true
+		}
+	}
+	if isPrecert != precert {
+		return nil, nil, fmt.Errorf("%w: got precertificate %t, want %t", errBadChain, isPrecert, precert)
+	}
+	// The poison extension would otherwise cause verification to fail, as it's not understood by crypto/x509.
+	leaf.UnhandledCriticalExtensions = slices.DeleteFunc(leaf.UnhandledCriticalExtensions, oidPoison.Equal)
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         l.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errBadChain, err)
+	}
+	issuers := chains[0][1:]
+
+	e := &ctonly.Entry{
+		Timestamp:   uint64(time.Now().UnixMilli()),
+		IsPrecert:   precert,
+		Certificate: leaf.Raw,
+	}
+	for _, c := range issuers {
+		e.FingerprintsChain = append(e.FingerprintsChain, sha256.Sum256(c.Raw))
+	}
+	if precert {
+		if len(issuers) == 0 {
+			return nil, nil, fmt.Errorf("%w: precertificate must not be a root", errBadChain)
+		}
+		if slices.ContainsFunc(issuers[0].UnknownExtKeyUsage, oidPrecertSigning.Equal) {
+			return nil, nil, fmt.Errorf("%w: precertificates issued by precertificate signing certificates are not supported", errBadChain)
+		}
+		tbs, err := removePoison(leaf.RawTBSCertificate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", errBadChain, err)
+		}
+		ikh := sha256.Sum256(issuers[0].RawSubjectPublicKeyInfo)
+		e.Certificate = tbs
+		e.Precertificate = leaf.Raw
+		e.IssuerKeyHash = ikh[:]
+	}
+	return e, issuers, nil
+}
+
+// removePoison returns the DER encoded TBSCertificate with the precertificate poison extension removed.
+func removePoison(tbs []byte) ([]byte, error) {
+	input := cryptobyte.String(tbs)
+	var fields cryptobyte.String
+	if !input.ReadASN1(&fields, cbasn1.SEQUENCE) || !input.Empty() {
+		return nil, errors.New("failed to parse TBSCertificate")
+	}
+	extsTag := cbasn1.Tag(3).Constructed().ContextSpecific()
+
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for !fields.Empty() {
+			var field cryptobyte.String
+			var tag cbasn1.Tag
+			if !fields.ReadAnyASN1Element(&field, &tag) {
+				b.SetError(errors.New("failed to parse TBSCertificate field"))
+				return
+			}
+			if tag != extsTag {
+				b.AddBytes(field)
+				continue
+			}
+			var exts cryptobyte.String
+			if !field.ReadASN1(&field, extsTag) || !field.ReadASN1(&exts, cbasn1.SEQUENCE) {
+				b.SetError(errors.New("failed to parse extensions"))
+				return
+			}
+			var kept [][]byte
+			for !exts.Empty() {
+				var ext cryptobyte.String
+				var oid asn1.ObjectIdentifier
+				if !exts.ReadASN1Element(&ext, cbasn1.SEQUENCE) {
+					b.SetError(errors.New("failed to parse extension"))
+					return
+				}
+				body := ext
+				if !body.ReadASN1(&body, cbasn1.SEQUENCE) || !body.ReadASN1ObjectIdentifier(&oid) {
+					b.SetError(errors.New("failed to parse extension ID"))
+					return
+				}
+				if !oid.Equal(oidPoison) {
+					kept = append(kept, ext)
+				}
+			}
+			// Extensions must be omitted, rather than empty, if there are none.
+			if len(kept) == 0 {
+				continue
+			}
+			b.AddASN1(extsTag, func(b *cryptobyte.Builder) {
+				b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					for _, ext := range kept {
+						b.AddBytes(ext)
+					}
+				})
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
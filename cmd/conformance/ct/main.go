@@ -0,0 +1,248 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ct runs a web server which implements the submission endpoints of https://c2sp.org/static-ct-api,
+// storing the resulting Certificate Transparency log on a posix filesystem.
+//
+// It shows how to use Tessera's CT support, and allows static-ct-api clients and monitors to be tested
+// against a Tessera log. It is not intended to be run as a production CT log.
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/cmd/conformance/internal/server"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageDir         = flag.String("storage_dir", "", "Root directory to store log data.")
+	listen             = flag.String("listen", ":2025", "Address:port to listen on")
+	origin             = flag.String("origin", "", "Origin of the log, i.e. its submission prefix without the scheme, e.g. ct.example.com/2025h1")
+	privKeyFile        = flag.String("private_key", "", "Location of a PEM encoded ECDSA P-256 private key, used to sign SCTs and checkpoints.")
+	rootsFile          = flag.String("roots_pem_file", "", "Location of a file containing the PEM encoded root certificates which are accepted by the log.")
+	checkpointInterval = flag.Duration("checkpoint_interval", time.Second, "Interval between checkpoints. Submissions are answered once their entry has been published.")
+)
+
+// tlsOpts configures the listener to serve HTTPS, if set.
+var tlsOpts = server.RegisterTLSFlags()
+
+// healthOpts configures the checks made by the /healthz and /readyz handlers.
+var healthOpts = server.RegisterHealthFlags()
+
+// adminListen is the address of the admin API, if set.
+var adminListen = server.RegisterAdminFlags()
+
+// logFormat selects the format of log output.
+var logFormat = server.RegisterLoggingFlags()
+
+// metricsOpts configures the export of OpenTelemetry metrics.
+var metricsOpts = server.RegisterMetricsFlags()
+
+// tracingOpts configures the export of OpenTelemetry traces.
+var tracingOpts = server.RegisterTracingFlags()
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	shutdownMetrics, err := server.InitMetrics(ctx, metricsOpts, http.DefaultServeMux)
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer shutdownMetrics(ctx)
+	shutdownTracing, err := server.InitTracing(ctx, tracingOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer shutdownTracing(ctx)
+
+	if *storageDir == "" || *origin == "" {
+		klog.Exit("--storage_dir and --origin must be set")
+	}
+	s := signerFromFlags()
+	if err := server.InitLogging(*logFormat, *origin); err != nil {
+		klog.Exit(err)
+	}
+	roots, rootsDER := rootsFromFlags()
+
+	driver, err := posix.New(ctx, posix.Config{Path: *storageDir})
+	if err != nil {
+		klog.Exitf("Failed to construct storage: %v", err)
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCTLayout().
+		WithCheckpointInterval(*checkpointInterval).
+		WithBatching(256, time.Second))
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	l := &ctLog{
+		add:         tessera.NewCertificateTransparencyAppender(appender),
+		awaiter:     tessera.NewPublicationAwaiter(ctx, reader.ReadCheckpoint, 100*time.Millisecond),
+		signer:      s,
+		roots:       roots,
+		rootsDER:    rootsDER,
+		storeIssuer: storeIssuer,
+	}
+	l.RegisterHandlers(http.DefaultServeMux)
+
+	// Serve the monitoring API directly from the filesystem.
+	fs := http.FileServer(http.Dir(*storageDir))
+	http.Handle("GET /checkpoint", addHeaders(fs, "Cache-Control", "no-cache"))
+	http.Handle("GET /tile/", addHeaders(fs, "Cache-Control", "max-age=31536000, immutable"))
+	http.Handle("GET /issuer/", addHeaders(fs, "Cache-Control", "max-age=31536000, immutable", "Content-Type", "application/pkix-cert"))
+
+	vkey, err := f_note.RFC6962VerifierString(*origin, s.key.Public())
+	if err != nil {
+		klog.Exitf("Failed to create verifier string: %v", err)
+	}
+	klog.Infof("Log %q has verifier key %s", *origin, vkey)
+
+	server.NewHealth(appender, reader, *healthOpts).RegisterHandlers(http.DefaultServeMux)
+	if err := server.ServeAdmin(*adminListen, appender, nil); err != nil {
+		klog.Exit(err)
+	}
+	srv, err := server.New(*listen, http.DefaultServeMux, tlsOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ListenAndServe(srv, shutdown); err != nil {
+		klog.Exit(err)
+	}
+}
+
+// addHeaders returns a handler which sets the provided header key/value pairs before calling h.
+func addHeaders(h http.Handler, kv ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(kv); i += 2 {
+			w.Header().Set(kv[i], kv[i+1])
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+// storeIssuer writes the issuer certificate to storage_dir/issuer/<hex fingerprint>, if it's not already present.
+func storeIssuer(_ context.Context, fingerprint [sha256.Size]byte, der []byte) error {
+	dir := filepath.Join(*storageDir, "issuer")
+	p := filepath.Join(dir, hex.EncodeToString(fingerprint[:]))
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a partially written issuer is never served.
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(der); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func signerFromFlags() *ctSigner {
+	if *privKeyFile == "" {
+		klog.Exit("--private_key must be set")
+	}
+	b, err := os.ReadFile(*privKeyFile)
+	if err != nil {
+		klog.Exitf("Failed to read private key: %v", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		klog.Exitf("No PEM data found in %q", *privKeyFile)
+	}
+	var key *ecdsa.PrivateKey
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		var k any
+		k, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err == nil {
+			var ok bool
+			if key, ok = k.(*ecdsa.PrivateKey); !ok {
+				err = fmt.Errorf("got %T, want ECDSA key", k)
+			}
+		}
+	default:
+		err = fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		klog.Exitf("Failed to parse private key: %v", err)
+	}
+	s, err := newCTSigner(*origin, key)
+	if err != nil {
+		klog.Exitf("Failed to create signer: %v", err)
+	}
+	return s
+}
+
+func rootsFromFlags() (*x509.CertPool, [][]byte) {
+	if *rootsFile == "" {
+		klog.Exit("--roots_pem_file must be set")
+	}
+	b, err := os.ReadFile(*rootsFile)
+	if err != nil {
+		klog.Exitf("Failed to read roots: %v", err)
+	}
+	pool := x509.NewCertPool()
+	var ders [][]byte
+	for {
+		var block *pem.Block
+		if block, b = pem.Decode(b); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			klog.Exitf("Failed to parse root certificate: %v", err)
+		}
+		pool.AddCert(c)
+		ders = append(ders, c.Raw)
+	}
+	if len(ders) == 0 {
+		klog.Exitf("No root certificates found in %q", *rootsFile)
+	}
+	return pool, ders
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/parse"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// algRFC6962STH is the note signature type for c2sp.org/static-ct-api checkpoint signatures.
+const algRFC6962STH = 0x05
+
+// ctSigner signs SCTs, and checkpoints with RFC6962NoteSignatures as described by c2sp.org/static-ct-api,
+// using an ECDSA P-256 key.
+type ctSigner struct {
+	name  string
+	hash  uint32
+	key   *ecdsa.PrivateKey
+	logID [sha256.Size]byte
+	now   func() time.Time
+}
+
+// newCTSigner returns a ctSigner which uses the provided key, and signs checkpoints with the given origin.
+func newCTSigner(origin string, key *ecdsa.PrivateKey) (*ctSigner, error) {
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("key must be an ECDSA P-256 key")
+	}
+	spki, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	s := &ctSigner{name: origin, key: key, logID: sha256.Sum256(spki), now: time.Now}
+	h := sha256.New()
+	h.Write([]byte(origin))
+	h.Write([]byte{'\n', algRFC6962STH})
+	h.Write(s.logID[:])
+	s.hash = binary.BigEndian.Uint32(h.Sum(nil))
+	return s, nil
+}
+
+// Name implements note.Signer.
+func (s *ctSigner) Name() string { return s.name }
+
+// KeyHash implements note.Signer.
+func (s *ctSigner) KeyHash() uint32 { return s.hash }
+
+// Sign implements note.Signer, returning a timestamped signature over the TreeHeadSignature structure
+// from RFC 6962 for the checkpoint in msg.
+func (s *ctSigner) Sign(msg []byte) ([]byte, error) {
+	origin, size, hash, err := parse.CheckpointUnsafe(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	if origin != s.name {
+		return nil, fmt.Errorf("checkpoint origin %q does not match signer name %q", origin, s.name)
+	}
+	if len(hash) != sha256.Size {
+		return nil, fmt.Errorf("root hash has length %d, want %d", len(hash), sha256.Size)
+	}
+	ts := uint64(s.now().UnixMilli())
+
+	b := cryptobyte.NewBuilder(nil)
+	b.AddUint8(0 /* version = v1 */)
+	b.AddUint8(1 /* signature_type = tree_hash */)
+	b.AddUint64(ts)
+	b.AddUint64(size)
+	b.AddBytes(hash)
+	sig, err := s.digitallySigned(b.BytesOrPanic())
+	if err != nil {
+		return nil, err
+	}
+	return append(binary.BigEndian.AppendUint64(nil, ts), sig...), nil
+}
+
+// digitallySigned returns the TLS encoded DigitallySigned struct, as used by RFC 6962, for msg.
+func (s *ctSigner) digitallySigned(msg []byte) ([]byte, error) {
+	dgst := sha256.Sum256(msg)
+	sig, err := s.key.Sign(rand.Reader, dgst[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+	b := cryptobyte.NewBuilder(nil)
+	b.AddUint8(4 /* hash = sha256 */)
+	b.AddUint8(3 /* signature = ecdsa */)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(sig)
+	})
+	return b.Bytes()
+}
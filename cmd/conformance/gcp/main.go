@@ -22,14 +22,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/serve"
+	"github.com/transparency-dev/tessera/cmd/conformance/internal/server"
 	"github.com/transparency-dev/tessera/storage/gcp"
 	gcp_as "github.com/transparency-dev/tessera/storage/gcp/antispam"
 	"golang.org/x/mod/sumdb/note"
-	"golang.org/x/net/http2"
-	"golang.org/x/net/http2/h2c"
 	"k8s.io/klog/v2"
 )
 
@@ -39,10 +40,36 @@ var (
 	spanner            = flag.String("spanner", "", "Spanner resource URI ('projects/.../...')")
 	signer             = flag.String("signer", "", "Note signer to use to sign checkpoints")
 	persistentAntispam = flag.Bool("antispam", false, "EXPERIMENTAL: Set to true to enable GCP-based persistent antispam storage")
+	antispamEmulator   = flag.Bool("antispam_emulator_compatible", os.Getenv("SPANNER_EMULATOR_HOST") != "", "Avoid Spanner features in antispam storage which aren't supported by the Spanner emulator. Defaults to true if SPANNER_EMULATOR_HOST is set")
 	traceFraction      = flag.Float64("trace_fraction", 0.01, "Fraction of open-telemetry span traces to sample")
+	verifyWrites       = flag.Float64("verify_writes_fraction", 0, "Fraction of tiles and entry bundles to read back from GCS after writing, to check they were stored intact")
 	additionalSigners  = []string{}
 )
 
+// tlsOpts configures the listener to serve HTTPS, if set.
+var tlsOpts = server.RegisterTLSFlags()
+
+// healthOpts configures the checks made by the /healthz and /readyz handlers.
+var healthOpts = server.RegisterHealthFlags()
+
+// adminListen is the address of the admin API, if set.
+var adminListen = server.RegisterAdminFlags()
+
+// logFormat selects the format of log output.
+var logFormat = server.RegisterLoggingFlags()
+
+// enableUI serves a read-only web UI for browsing the log, if set.
+var enableUI = flag.Bool("ui", false, "Serve a read-only web UI for browsing the log at /ui/")
+
+// compressEntryBundles stores entry bundles compressed with zstd, if set.
+var compressEntryBundles = flag.Bool("compress_entry_bundles", false, "Store entry bundles compressed with zstd")
+
+// gzipEntryBundles stores entry bundles compressed with gzip, if set.
+var gzipEntryBundles = flag.Bool("gzip_entry_bundles", false, "Store entry bundles compressed with gzip, rather than zstd")
+
+// integrationHints serves hints for autoscaling the integration workers, if set.
+var integrationHints = flag.Bool("integration_hints", false, "Serve the sequencing backlog and integration rate as JSON at /integration, and export them as metrics")
+
 func init() {
 	flag.Func("additional_signer", "Additional note signer for checkpoints, may be specified multiple times", func(s string) error {
 		additionalSigners = append(additionalSigners, s)
@@ -59,6 +86,9 @@ func main() {
 	defer shutdownOTel(ctx)
 
 	s, a := signerFromFlags()
+	if err := server.InitLogging(*logFormat, s.Name()); err != nil {
+		klog.Exit(err)
+	}
 
 	// Create our Tessera storage backend:
 	gcpCfg := storageConfigFromFlags()
@@ -70,19 +100,31 @@ func main() {
 	var antispam tessera.Antispam
 	// Persistent antispam is currently experimental, so there's no terraform or documentation yet!
 	if *persistentAntispam {
-		asOpts := gcp_as.AntispamOpts{} // Use defaults
+		asOpts := gcp_as.AntispamOpts{EmulatorCompatible: *antispamEmulator} // Otherwise use defaults
 		antispam, err = gcp_as.NewAntispam(ctx, fmt.Sprintf("%s-antispam", *spanner), asOpts)
 		if err != nil {
 			klog.Exitf("Failed to create new GCP antispam storage: %v", err)
 		}
 	}
 
-	appender, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+	appendOpts := tessera.NewAppendOptions().
 		WithCheckpointSigner(s, a...).
 		WithCheckpointInterval(10*time.Second).
 		WithBatching(512, 300*time.Millisecond).
 		WithPushback(10*4096).
-		WithAntispam(256<<10, antispam))
+		WithAntispam(256<<10, antispam)
+	if *compressEntryBundles {
+		appendOpts.WithEntryBundleCompression()
+	}
+	if *gzipEntryBundles {
+		appendOpts.WithEntryBundleGzipCompression()
+	}
+	if *integrationHints {
+		m := tessera.NewIntegrationMonitor(tessera.IntegrationMonitorOptions{})
+		appendOpts.WithIntegrationMonitor(m)
+		http.Handle("GET /integration", m.Handler())
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, appendOpts)
 	if err != nil {
 		klog.Exit(err)
 	}
@@ -114,20 +156,23 @@ func main() {
 		_, _ = fmt.Fprintf(w, "%d", idx.Index)
 	})
 
-	h2s := &http2.Server{}
-	h1s := &http.Server{
-		Addr:    *listen,
-		Handler: h2c.NewHandler(http.DefaultServeMux, h2s),
-	}
-	if err := http2.ConfigureServer(h1s, h2s); err != nil {
-		klog.Exitf("http2.ConfigureServer: %v", err)
-	}
-
-	if err := h1s.ListenAndServe(); err != nil {
-		if err := shutdown(ctx); err != nil {
+	if *enableUI {
+		ui, err := serve.NewUI(ctx, reader, serve.UIOptions{})
+		if err != nil {
 			klog.Exit(err)
 		}
-		klog.Exitf("ListenAndServe: %v", err)
+		ui.RegisterHandlers(http.DefaultServeMux)
+	}
+	server.NewHealth(appender, reader, *healthOpts).RegisterHandlers(http.DefaultServeMux)
+	if err := server.ServeAdmin(*adminListen, appender, nil); err != nil {
+		klog.Exit(err)
+	}
+	srv, err := server.New(*listen, http.DefaultServeMux, tlsOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ListenAndServe(srv, shutdown); err != nil {
+		klog.Exit(err)
 	}
 }
 
@@ -141,8 +186,9 @@ func storageConfigFromFlags() gcp.Config {
 		klog.Exit("--spanner must be set")
 	}
 	return gcp.Config{
-		Bucket:  *bucket,
-		Spanner: *spanner,
+		Bucket:               *bucket,
+		Spanner:              *spanner,
+		VerifyWritesFraction: *verifyWrites,
 	}
 }
 
@@ -0,0 +1,241 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"k8s.io/klog/v2"
+)
+
+// maxAdminRequestSize is the largest request body accepted by the admin API.
+const maxAdminRequestSize = 1 << 12
+
+// RegisterAdminFlags registers a flag which sets the address of the admin API with the default flag set.
+func RegisterAdminFlags() *string {
+	return flag.String("admin_listen", "", "Loopback address:port on which to serve the admin API, e.g. localhost:2099. If unset, the admin API is disabled.")
+}
+
+// ServeAdmin serves the admin API for a on addr, which must be a loopback address, in the background.
+// It does nothing if addr is empty. If reload is non-nil, it's called to reload the log's configuration
+// file.
+func ServeAdmin(addr string, a *tessera.Appender, reload func() error) error {
+	if addr == "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid admin address %q: %v", addr, err)
+	}
+	if !isLoopback(host) {
+		return fmt.Errorf("admin address %q is not a loopback address", addr)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for admin API: %v", err)
+	}
+	klog.Infof("Serving admin API on %s", l.Addr())
+	go func() {
+		if err := http.Serve(l, NewAdminHandler(a, reload)); err != nil {
+			klog.Errorf("Admin API: %v", err)
+		}
+	}()
+	return nil
+}
+
+// AdminConfig is the JSON encoding of the settings which can be changed with the admin API.
+//
+// In requests, fields which are absent are left unchanged.
+type AdminConfig struct {
+	// CheckpointInterval is a duration, e.g. "5s".
+	CheckpointInterval *string  `json:"checkpoint_interval,omitempty"`
+	MaxAddQPS          *float64 `json:"max_add_qps,omitempty"`
+	MaxUnintegrated    *uint64  `json:"max_unintegrated,omitempty"`
+	// LogVerbosity is the klog verbosity level, as set by the -v flag.
+	LogVerbosity *int `json:"log_verbosity,omitempty"`
+}
+
+// NewAdminHandler returns a handler for the admin API of a, which allows it to be managed while it's running:
+//
+//	GET  /config   returns the current AdminConfig.
+//	POST /config   applies the AdminConfig in the request body, and returns the resulting config.
+//	POST /gc       runs the storage implementation's garbage collector.
+//	POST /quiesce  quiesces the log, see tessera.Appender.Quiesce.
+//	POST /freeze   freezes the log, see tessera.Appender.Freeze.
+//	POST /resume   resumes a quiesced log, see tessera.Appender.Resume.
+//	POST /reload   reloads the log's configuration file, if it has one, see config.Reloader.
+//
+// The API is unauthenticated, so it should only be served on a loopback address. Requests from other
+// addresses, and requests made by web pages, are refused.
+func NewAdminHandler(a *tessera.Appender, reload func() error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminConfig(w, a)
+	})
+	mux.HandleFunc("POST /config", func(w http.ResponseWriter, r *http.Request) {
+		c := AdminConfig{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminRequestSize)).Decode(&c); err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse config: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := applyAdminConfig(a, c); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeAdminConfig(w, a)
+	})
+	mux.HandleFunc("POST /gc", func(w http.ResponseWriter, r *http.Request) {
+		if a.GarbageCollect == nil {
+			http.Error(w, "storage does not support garbage collection", http.StatusNotImplemented)
+			return
+		}
+		adminOp(w, r, "gc", a.GarbageCollect)
+	})
+	mux.HandleFunc("POST /quiesce", func(w http.ResponseWriter, r *http.Request) {
+		adminOp(w, r, "quiesce", a.Quiesce)
+	})
+	mux.HandleFunc("POST /freeze", func(w http.ResponseWriter, r *http.Request) {
+		adminOp(w, r, "freeze", a.Freeze)
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		adminOp(w, r, "resume", a.Resume)
+	})
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		if reload == nil {
+			http.Error(w, "log was not configured with a config file", http.StatusNotImplemented)
+			return
+		}
+		adminOp(w, r, "reload", func(context.Context) error { return reload() })
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil || !isLoopback(host) {
+			http.Error(w, "admin API is only available from loopback addresses", http.StatusForbidden)
+			return
+		}
+		// Browsers set Origin on cross-origin requests, so refusing them prevents web pages from using the API.
+		if r.Header.Get("Origin") != "" {
+			http.Error(w, "admin API is not available to web pages", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// adminOp runs an operation which may take some time, e.g. waiting for entries to be published.
+func adminOp(w http.ResponseWriter, r *http.Request, name string, f func(context.Context) error) {
+	klog.Infof("Admin API: %s requested", name)
+	if err := f(r.Context()); err != nil {
+		klog.Warningf("Admin API: %s: %v", name, err)
+		http.Error(w, fmt.Sprintf("%s failed: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+	klog.Infof("Admin API: %s complete", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyAdminConfig changes the settings present in c. Settings are validated before any are changed.
+func applyAdminConfig(a *tessera.Appender, c AdminConfig) error {
+	var interval time.Duration
+	if c.CheckpointInterval != nil {
+		d, err := time.ParseDuration(*c.CheckpointInterval)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid checkpoint_interval %q", *c.CheckpointInterval)
+		}
+		interval = d
+	}
+	if c.MaxAddQPS != nil && *c.MaxAddQPS < 0 {
+		return fmt.Errorf("invalid max_add_qps %v", *c.MaxAddQPS)
+	}
+	if c.LogVerbosity != nil && *c.LogVerbosity < 0 {
+		return fmt.Errorf("invalid log_verbosity %d", *c.LogVerbosity)
+	}
+
+	var errs []error
+	if c.CheckpointInterval != nil {
+		errs = append(errs, a.SetCheckpointInterval(interval))
+	}
+	if c.MaxAddQPS != nil {
+		errs = append(errs, a.SetMaxAddQPS(*c.MaxAddQPS))
+	}
+	if c.MaxUnintegrated != nil {
+		errs = append(errs, a.SetMaxUnintegrated(*c.MaxUnintegrated))
+	}
+	if c.LogVerbosity != nil {
+		errs = append(errs, setLogVerbosity(*c.LogVerbosity))
+	}
+	return errors.Join(errs...)
+}
+
+func writeAdminConfig(w http.ResponseWriter, a *tessera.Appender) {
+	rc, err := a.RuntimeConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	interval := rc.CheckpointInterval.String()
+	c := AdminConfig{
+		CheckpointInterval: &interval,
+		MaxAddQPS:          &rc.MaxAddQPS,
+		MaxUnintegrated:    &rc.MaxUnintegrated,
+	}
+	if v, err := logVerbosity(); err == nil {
+		c.LogVerbosity = &v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		klog.Warningf("Admin API: failed to write response: %v", err)
+	}
+}
+
+// logVerbosity returns the klog verbosity, which is only available if klog.InitFlags registered the -v flag.
+func logVerbosity() (int, error) {
+	f := flag.Lookup("v")
+	if f == nil {
+		return 0, errors.New("klog flags are not registered")
+	}
+	return strconv.Atoi(f.Value.String())
+}
+
+func setLogVerbosity(v int) error {
+	f := flag.Lookup("v")
+	if f == nil {
+		return errors.New("klog flags are not registered")
+	}
+	old := f.Value.String()
+	if err := f.Value.Set(strconv.Itoa(v)); err != nil {
+		return fmt.Errorf("failed to set log verbosity: %v", err)
+	}
+	klog.Infof("Changed log verbosity %s -> %d", old, v)
+	return nil
+}
+
+// isLoopback returns true if host is localhost, or a loopback IP address.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func newTestAppender(t *testing.T) *tessera.Appender {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	sk, _, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	d, err := posix.New(ctx, posix.Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, _, _, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointInterval(time.Second).
+		WithBatching(1, time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	return a
+}
+
+func TestAdminHandler(t *testing.T) {
+	a := newTestAppender(t)
+	reloads := 0
+	h := NewAdminHandler(a, func() error {
+		reloads++
+		if reloads > 1 {
+			return errors.New("unsafe change")
+		}
+		return nil
+	})
+	do := func(method, path, body string, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:1234"
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, test := range []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{name: "valid", body: `{"checkpoint_interval": "5s", "max_add_qps": 10, "max_unintegrated": 1000}`, wantCode: http.StatusOK},
+		{name: "invalid interval", body: `{"checkpoint_interval": "soon"}`, wantCode: http.StatusBadRequest},
+		{name: "negative qps", body: `{"max_add_qps": -1}`, wantCode: http.StatusBadRequest},
+		{name: "malformed", body: `{`, wantCode: http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if w := do(http.MethodPost, "/config", test.body); w.Code != test.wantCode {
+				t.Errorf("POST /config = %d %q, want %d", w.Code, w.Body, test.wantCode)
+			}
+		})
+	}
+
+	w := do(http.MethodGet, "/config", "")
+	c := AdminConfig{}
+	if err := json.Unmarshal(w.Body.Bytes(), &c); err != nil {
+		t.Fatalf("Failed to parse GET /config response %q: %v", w.Body, err)
+	}
+	if *c.CheckpointInterval != "5s" || *c.MaxAddQPS != 10 || *c.MaxUnintegrated != 1000 {
+		t.Errorf("GET /config = %s, want the valid changes to have been applied", w.Body)
+	}
+
+	for _, op := range []string{"/gc", "/quiesce", "/resume", "/freeze"} {
+		if w := do(http.MethodPost, op, ""); w.Code != http.StatusNoContent {
+			t.Errorf("POST %s = %d %q, want %d", op, w.Code, w.Body, http.StatusNoContent)
+		}
+	}
+	for _, wantCode := range []int{http.StatusNoContent, http.StatusInternalServerError} {
+		if w := do(http.MethodPost, "/reload", ""); w.Code != wantCode {
+			t.Errorf("POST /reload = %d %q, want %d", w.Code, w.Body, wantCode)
+		}
+	}
+	noReload := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reload", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	NewAdminHandler(a, nil).ServeHTTP(noReload, req)
+	if noReload.Code != http.StatusNotImplemented {
+		t.Errorf("POST /reload without a config file = %d, want %d", noReload.Code, http.StatusNotImplemented)
+	}
+	if got := a.State(); got != tessera.LogStateFrozen {
+		t.Errorf("State() = %v, want %v", got, tessera.LogStateFrozen)
+	}
+
+	if w := do(http.MethodGet, "/config", "", "Origin", "https://example.com"); w.Code != http.StatusForbidden {
+		t.Errorf("GET /config from web page = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("GET /config from remote address = %d, want %d", rw.Code, http.StatusForbidden)
+	}
+}
+
+func TestServeAdminRejectsNonLoopback(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0", "bad"} {
+		if err := ServeAdmin(addr, &tessera.Appender{}, nil); err == nil {
+			t.Errorf("ServeAdmin(%q) succeeded, want error", addr)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// CheckpointDestinationOptions configures where checkpoints are published, in addition to the log's storage.
+type CheckpointDestinationOptions struct {
+	// Files is a comma separated list of paths to which checkpoints are written.
+	Files string
+	// URLs is a comma separated list of URLs to which checkpoints are POSTed.
+	URLs string
+	// Required prevents the log's checkpoint from being updated until it's been published to every destination.
+	Required bool
+}
+
+// RegisterCheckpointDestinationFlags registers flags which populate the returned CheckpointDestinationOptions
+// with the default flag set.
+func RegisterCheckpointDestinationFlags() *CheckpointDestinationOptions {
+	o := &CheckpointDestinationOptions{}
+	flag.StringVar(&o.Files, "checkpoint_mirror_files", "", "Comma separated list of files to write each checkpoint to, in addition to the log's storage")
+	flag.StringVar(&o.URLs, "checkpoint_mirror_urls", "", "Comma separated list of URLs to POST each checkpoint to, in addition to the log's storage")
+	flag.BoolVar(&o.Required, "checkpoint_mirrors_required", false, "Don't update the log's checkpoint until it has been published to all of the mirrors")
+	return o
+}
+
+// ConfigureCheckpointDestinations enables publication of checkpoints to the destinations in opts, if there
+// are any.
+func ConfigureCheckpointDestinations(opts *CheckpointDestinationOptions, appendOpts *tessera.AppendOptions) {
+	dests := []tessera.CheckpointDestination{}
+	for _, f := range splitList(opts.Files) {
+		dests = append(dests, tessera.NewFileCheckpointDestination(f))
+	}
+	for _, u := range splitList(opts.URLs) {
+		dests = append(dests, tessera.NewHTTPCheckpointDestination(u, nil))
+	}
+	if len(dests) == 0 {
+		return
+	}
+	appendOpts.WithCheckpointDestinations(dests, &tessera.CheckpointDestinationOptions{Required: opts.Required})
+}
+
+// splitList returns the non-empty elements of the comma separated list l.
+func splitList(l string) []string {
+	r := []string{}
+	for _, s := range strings.Split(l, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			r = append(r, s)
+		}
+	}
+	return r
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/events"
+)
+
+// EventsOptions configures the publication of an event for each newly integrated entry bundle.
+type EventsOptions struct {
+	// URL, if set, identifies the topic, queue, or subject to publish events to. See events.NewPublisher.
+	URL string
+	// PositionFile is where the number of entries which events have been published for is stored.
+	PositionFile string
+	// IncludeEntries includes the contents of entries in events.
+	IncludeEntries bool
+}
+
+// RegisterEventsFlags registers flags which populate the returned EventsOptions with the default flag set.
+func RegisterEventsFlags() *EventsOptions {
+	o := &EventsOptions{}
+	flag.StringVar(&o.URL, "events_url", "", "Topic, queue, or subject to publish an event to for each newly integrated entry bundle, e.g. gcppubsub://projects/<project>/topics/<topic>, awssns:///<topic ARN>, awssqs://<queue host>/<account>/<queue>, or nats://<host>/<subject>")
+	flag.StringVar(&o.PositionFile, "events_position_file", "", "File in which to store how many entries events have been published for. Required if --events_url is set.")
+	flag.BoolVar(&o.IncludeEntries, "events_include_entries", false, "Include the contents of entries in events")
+	return o
+}
+
+// ConfigureEvents attaches a follower which publishes events to appendOpts, if opts.URL is set.
+func ConfigureEvents(ctx context.Context, opts *EventsOptions, origin string, appendOpts *tessera.AppendOptions) error {
+	if opts.URL == "" {
+		return nil
+	}
+	if opts.PositionFile == "" {
+		return errors.New("--events_position_file must be set to publish events")
+	}
+	p, err := events.NewPublisher(ctx, opts.URL)
+	if err != nil {
+		return fmt.Errorf("failed to create events publisher: %v", err)
+	}
+	appendOpts.WithFollower(events.NewFollower("events", tessera.NewFilePositionStore(opts.PositionFile), p, events.Options{
+		Origin:         origin,
+		IncludeEntries: opts.IncludeEntries,
+	}))
+	return nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/parse"
+)
+
+const (
+	// DefaultMaxStall is used by NewHealth if HealthOptions.MaxIntegrationStall or MaxPublicationStall is unset.
+	DefaultMaxStall = time.Minute
+	// healthTimeout bounds the time spent reading the log's state for a single probe.
+	healthTimeout = 5 * time.Second
+)
+
+// HealthOptions configures the checks made by a Health.
+type HealthOptions struct {
+	// MaxIntegrationStall is how long sequenced entries may wait without the integrated tree growing
+	// before the log is considered unhealthy.
+	MaxIntegrationStall time.Duration
+	// MaxPublicationStall is how long integrated entries may wait without the published checkpoint
+	// growing before the log is considered unhealthy.
+	MaxPublicationStall time.Duration
+	// MaxFollowerLag, if non-zero, is the largest number of integrated entries which any follower,
+	// e.g. that used by antispam, may have left to process for the log to be considered ready.
+	MaxFollowerLag uint64
+}
+
+// RegisterHealthFlags registers flags which populate the returned HealthOptions with the default flag set.
+func RegisterHealthFlags() *HealthOptions {
+	o := &HealthOptions{}
+	flag.DurationVar(&o.MaxIntegrationStall, "health_max_integration_stall", DefaultMaxStall, "How long sequenced entries may wait to be integrated before /healthz reports the log as unhealthy.")
+	flag.DurationVar(&o.MaxPublicationStall, "health_max_publication_stall", DefaultMaxStall, "How long integrated entries may wait to be published before /healthz reports the log as unhealthy.")
+	flag.Uint64Var(&o.MaxFollowerLag, "health_max_follower_lag", 0, "Maximum number of entries which followers, including antispam, may have left to process before /readyz reports the log as not ready. Zero disables the check.")
+	return o
+}
+
+// Health serves health and readiness probes for a personality, based on the state of its log.
+//
+// The log is healthy unless sequencing or publication has stalled, which a restart may fix. It's ready if
+// it's healthy, its storage can be read, and its followers are keeping up.
+type Health struct {
+	appender *tessera.Appender
+	reader   tessera.LogReader
+	opts     HealthOptions
+	now      func() time.Time
+
+	// mu guards integrated and published, which record when the integrated tree and published
+	// checkpoint were last seen to grow.
+	mu         sync.Mutex
+	integrated progress
+	published  progress
+}
+
+// progress records the time at which a size was first seen.
+type progress struct {
+	size uint64
+	at   time.Time
+}
+
+// update records size as seen at now if it's larger than the last size seen, or if behind is false, and
+// returns how long the size has been unchanged.
+func (p *progress) update(size uint64, behind bool, now time.Time) time.Duration {
+	if p.at.IsZero() || size > p.size || !behind {
+		p.size, p.at = size, now
+	}
+	return now.Sub(p.at)
+}
+
+// HealthStatus is the JSON encoded body of responses to health and readiness probes.
+type HealthStatus struct {
+	// Healthy is true if sequencing and publication are making progress.
+	Healthy bool `json:"healthy"`
+	// Ready is true if the log is healthy, its storage is reachable, and its followers are keeping up.
+	Ready bool `json:"ready"`
+	// LogState is the lifecycle state of the log.
+	LogState string `json:"log_state"`
+	// NextIndex is the index which will be assigned to the next entry to be sequenced.
+	NextIndex uint64 `json:"next_index"`
+	// IntegratedSize is the size of the integrated tree.
+	IntegratedSize uint64 `json:"integrated_size"`
+	// CheckpointSize is the size of the tree committed to by the published checkpoint.
+	CheckpointSize uint64 `json:"checkpoint_size"`
+	// IntegrationStallSeconds is how long sequenced entries have been waiting without the integrated tree growing.
+	IntegrationStallSeconds float64 `json:"integration_stall_seconds"`
+	// PublicationStallSeconds is how long integrated entries have been waiting without a larger checkpoint being published.
+	PublicationStallSeconds float64 `json:"publication_stall_seconds"`
+	// FollowerLag holds the number of integrated entries which each follower has yet to process.
+	FollowerLag map[string]uint64 `json:"follower_lag,omitempty"`
+	// Errors describes each of the checks which failed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// NewHealth returns a Health which checks the log managed by a and read by r.
+func NewHealth(a *tessera.Appender, r tessera.LogReader, opts HealthOptions) *Health {
+	if opts.MaxIntegrationStall == 0 {
+		opts.MaxIntegrationStall = DefaultMaxStall
+	}
+	if opts.MaxPublicationStall == 0 {
+		opts.MaxPublicationStall = DefaultMaxStall
+	}
+	return &Health{appender: a, reader: r, opts: opts, now: time.Now}
+}
+
+// RegisterHandlers registers the /healthz and /readyz probes with mux. Each responds with a JSON encoded
+// HealthStatus, with a status code of 200 if the check passed, and 503 otherwise.
+func (h *Health) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		s := h.Status(r.Context())
+		writeStatus(w, s, s.Healthy)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		s := h.Status(r.Context())
+		writeStatus(w, s, s.Ready)
+	})
+}
+
+// Status checks the state of the log.
+func (h *Health) Status(ctx context.Context) HealthStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
+	s := HealthStatus{LogState: h.appender.State().String()}
+	storageOK := true
+	fail := func(storage bool, format string, args ...any) {
+		s.Errors = append(s.Errors, fmt.Sprintf(format, args...))
+		storageOK = storageOK && !storage
+	}
+	var err error
+	if s.NextIndex, err = h.reader.NextIndex(ctx); err != nil {
+		fail(true, "failed to read next index: %v", err)
+	}
+	if s.IntegratedSize, err = h.reader.IntegratedSize(ctx); err != nil {
+		fail(true, "failed to read integrated size: %v", err)
+	}
+	if cp, err := h.reader.ReadCheckpoint(ctx); err != nil {
+		// There won't be a checkpoint until the first one is published, which publication stall
+		// detection will catch if it doesn't happen.
+		if !errors.Is(err, os.ErrNotExist) {
+			fail(true, "failed to read checkpoint: %v", err)
+		}
+	} else if _, s.CheckpointSize, _, err = parse.CheckpointUnsafe(cp); err != nil {
+		fail(false, "failed to parse checkpoint: %v", err)
+	}
+
+	stalled := false
+	if storageOK {
+		now := h.now()
+		h.mu.Lock()
+		intStall := h.integrated.update(s.IntegratedSize, s.IntegratedSize < s.NextIndex, now)
+		pubStall := h.published.update(s.CheckpointSize, s.CheckpointSize < s.IntegratedSize, now)
+		h.mu.Unlock()
+		s.IntegrationStallSeconds = intStall.Seconds()
+		s.PublicationStallSeconds = pubStall.Seconds()
+		if intStall > h.opts.MaxIntegrationStall {
+			stalled = true
+			fail(false, "integrated tree hasn't grown for %v", intStall)
+		}
+		if pubStall > h.opts.MaxPublicationStall {
+			stalled = true
+			fail(false, "published checkpoint hasn't grown for %v", pubStall)
+		}
+	}
+
+	laggy := false
+	if p, err := h.appender.FollowerProgress(ctx); err != nil {
+		laggy = true
+		fail(true, "failed to read follower progress: %v", err)
+	} else if len(p) > 0 {
+		s.FollowerLag = make(map[string]uint64, len(p))
+		for name, n := range p {
+			lag := uint64(0)
+			if s.IntegratedSize > n {
+				lag = s.IntegratedSize - n
+			}
+			s.FollowerLag[name] = lag
+			if h.opts.MaxFollowerLag > 0 && lag > h.opts.MaxFollowerLag {
+				laggy = true
+				fail(false, "follower %q is %d entries behind", name, lag)
+			}
+		}
+	}
+
+	s.Healthy = !stalled
+	s.Ready = s.Healthy && storageOK && !laggy
+	return s
+}
+
+func writeStatus(w http.ResponseWriter, s HealthStatus, ok bool) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(b)
+}
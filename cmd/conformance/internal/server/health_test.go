@@ -0,0 +1,138 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+type fakeReader struct {
+	tessera.LogReader
+	nextIndex, integrated, published uint64
+	err                              error
+}
+
+func (r *fakeReader) NextIndex(context.Context) (uint64, error) {
+	return r.nextIndex, r.err
+}
+
+func (r *fakeReader) IntegratedSize(context.Context) (uint64, error) {
+	return r.integrated, r.err
+}
+
+func (r *fakeReader) ReadCheckpoint(context.Context) ([]byte, error) {
+	if r.published == 0 {
+		return nil, os.ErrNotExist
+	}
+	return fmt.Appendf(nil, "example.com/log\n%d\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", r.published), nil
+}
+
+func TestHealthStatus(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// before is the state of the log when first probed, and after its state a minute later.
+		before, after fakeReader
+		wantHealthy   bool
+		wantReady     bool
+	}{
+		{
+			name:        "idle",
+			before:      fakeReader{nextIndex: 10, integrated: 10, published: 10},
+			after:       fakeReader{nextIndex: 10, integrated: 10, published: 10},
+			wantHealthy: true,
+			wantReady:   true,
+		}, {
+			name:        "progressing",
+			before:      fakeReader{nextIndex: 20, integrated: 10, published: 5},
+			after:       fakeReader{nextIndex: 30, integrated: 20, published: 10},
+			wantHealthy: true,
+			wantReady:   true,
+		}, {
+			name:   "integration stalled",
+			before: fakeReader{nextIndex: 20, integrated: 10, published: 10},
+			after:  fakeReader{nextIndex: 20, integrated: 10, published: 10},
+		}, {
+			name:   "publication stalled",
+			before: fakeReader{nextIndex: 20, integrated: 20},
+			after:  fakeReader{nextIndex: 20, integrated: 20},
+		}, {
+			name:        "storage unreachable",
+			before:      fakeReader{nextIndex: 10, integrated: 10, published: 10},
+			after:       fakeReader{err: errors.New("unreachable")},
+			wantHealthy: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			r := test.before
+			h := NewHealth(&tessera.Appender{}, &r, HealthOptions{MaxIntegrationStall: 30 * time.Second, MaxPublicationStall: 30 * time.Second})
+			h.now = func() time.Time { return now }
+			if s := h.Status(t.Context()); !s.Healthy || !s.Ready {
+				t.Fatalf("Initial Status() = %+v, want healthy and ready", s)
+			}
+			now = now.Add(time.Minute)
+			r = test.after
+			s := h.Status(t.Context())
+			if s.Healthy != test.wantHealthy || s.Ready != test.wantReady {
+				t.Errorf("Status() = %+v, want healthy=%t, ready=%t", s, test.wantHealthy, test.wantReady)
+			}
+			if !s.Ready && len(s.Errors) == 0 {
+				t.Errorf("Status() = %+v, want errors", s)
+			}
+		})
+	}
+}
+
+func TestHealthHandlers(t *testing.T) {
+	r := &fakeReader{err: errors.New("unreachable")}
+	mux := http.NewServeMux()
+	NewHealth(&tessera.Appender{}, r, HealthOptions{}).RegisterHandlers(mux)
+
+	for _, test := range []struct {
+		path     string
+		wantCode int
+	}{
+		{path: "/healthz", wantCode: http.StatusOK},
+		{path: "/readyz", wantCode: http.StatusServiceUnavailable},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+			if w.Code != test.wantCode {
+				t.Errorf("GET %s = %d, want %d", test.path, w.Code, test.wantCode)
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+			var s HealthStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+				t.Fatalf("Failed to parse response body: %v", err)
+			}
+			if s.LogState != tessera.LogStateActive.String() {
+				t.Errorf("LogState = %q, want %q", s.LogState, tessera.LogStateActive.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+)
+
+// maxRequestIDLength is the longest X-Request-Id header value which is accepted from clients.
+const maxRequestIDLength = 128
+
+// RegisterLoggingFlags registers a flag which selects the format of log output with the default flag set.
+func RegisterLoggingFlags() *string {
+	return flag.String("log_format", "text", "Format of log output: text for klog's usual format, or json for one JSON object per line, for log aggregation systems.")
+}
+
+// InitLogging configures klog to write logs in the given format, which is either "text" or "json".
+//
+// In the json format, each record carries the origin of the log, as well as any structured values attached
+// to the message, such as the request_id of the request being served. Logs logged before this is called are
+// written in the text format, so it should be called as soon as the origin is known.
+func InitLogging(format, origin string) error {
+	switch format {
+	case "text":
+		return nil
+	case "json":
+		h := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+			AddSource: true,
+			// Records have already been filtered by klog's -v and -vmodule flags.
+			Level: slog.Level(math.MinInt),
+		})
+		klog.SetSlogLogger(slog.New(h).With("origin", origin))
+		return nil
+	}
+	return fmt.Errorf("unknown log format %q, want text or json", format)
+}
+
+// withRequestID returns a handler which adds a request ID to the context logger of each request to h, so
+// that logs made while serving the request can be correlated with it. The ID is taken from the
+// X-Request-Id header if the client, or a load balancer, provided one, and is returned in the response.
+func withRequestID(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if !validRequestID(id) {
+			b := make([]byte, 8)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-Id", id)
+		kv := []any{"request_id", id}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			kv = append(kv, "trace_id", sc.TraceID().String())
+		}
+		ctx := klog.NewContext(r.Context(), klog.LoggerWithValues(klog.FromContext(r.Context()), kv...))
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID returns true if id is a non-empty string of at most maxRequestIDLength printable ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
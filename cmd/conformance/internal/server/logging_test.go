@@ -0,0 +1,59 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestID(t *testing.T) {
+	h := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, test := range []struct {
+		name   string
+		header string
+		// want is the expected ID, or empty if a new one should be generated.
+		want string
+	}{
+		{name: "generated"},
+		{name: "from client", header: "abc-123", want: "abc-123"},
+		{name: "invalid characters", header: "abc 123"},
+		{name: "too long", header: strings.Repeat("a", maxRequestIDLength+1)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/checkpoint", nil)
+			if test.header != "" {
+				req.Header.Set("X-Request-Id", test.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			got := w.Header().Get("X-Request-Id")
+			if test.want != "" && got != test.want {
+				t.Errorf("X-Request-Id = %q, want %q", got, test.want)
+			}
+			if test.want == "" && (len(got) != 16 || got == test.header) {
+				t.Errorf("X-Request-Id = %q, want a new ID", got)
+			}
+		})
+	}
+}
+
+func TestInitLoggingUnknownFormat(t *testing.T) {
+	if err := InitLogging("xml", "example.com/log"); err == nil {
+		t.Error("InitLogging(xml): want error")
+	}
+}
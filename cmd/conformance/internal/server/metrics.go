@@ -0,0 +1,209 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"k8s.io/klog/v2"
+)
+
+// MetricsOptions configures the export of the OpenTelemetry metrics recorded by Tessera and the personality.
+// If neither exporter is enabled, metrics are not recorded.
+type MetricsOptions struct {
+	// OTLPEndpoint, if set, is the host:port of an OTLP/gRPC collector to which metrics are pushed. The
+	// connection is not encrypted, so this is intended for a collector running alongside the personality.
+	OTLPEndpoint string
+	// Prometheus enables serving the metrics in the Prometheus text format at /metrics.
+	Prometheus bool
+}
+
+// RegisterMetricsFlags registers flags which populate the returned MetricsOptions with the default flag set.
+func RegisterMetricsFlags() *MetricsOptions {
+	o := &MetricsOptions{}
+	flag.StringVar(&o.OTLPEndpoint, "otlp_metrics_endpoint", "", "Address:port of an OTLP/gRPC collector to push metrics to in cleartext, e.g. localhost:4317. If unset, metrics are not pushed.")
+	flag.BoolVar(&o.Prometheus, "prometheus_metrics", false, "Set to true to serve metrics in the Prometheus text format at /metrics.")
+	return o
+}
+
+// InitMetrics installs a global OpenTelemetry MeterProvider which exports metrics as configured by opts,
+// registering the /metrics handler with mux if the Prometheus format is enabled.
+//
+// Returns a shutdown function which flushes any pending metrics, and should be called just before exiting
+// the process.
+func InitMetrics(ctx context.Context, opts *MetricsOptions, mux *http.ServeMux) (func(context.Context), error) {
+	var readers []sdkmetric.Option
+	if opts.OTLPEndpoint != "" {
+		e, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithInsecure(), otlpmetricgrpc.WithEndpoint(opts.OTLPEndpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP metric exporter: %v", err)
+		}
+		readers = append(readers, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(e)))
+	}
+	if opts.Prometheus {
+		r := sdkmetric.NewManualReader()
+		readers = append(readers, sdkmetric.WithReader(r))
+		mux.Handle("GET /metrics", prometheusHandler{r: r})
+	}
+	if len(readers) == 0 {
+		return func(context.Context) {}, nil
+	}
+	mp := sdkmetric.NewMeterProvider(readers...)
+	otel.SetMeterProvider(mp)
+	return func(ctx context.Context) {
+		if err := mp.Shutdown(ctx); err != nil {
+			klog.Errorf("MeterProvider shutdown: %v", err)
+		}
+	}, nil
+}
+
+// prometheusHandler serves the metrics collected from r in the Prometheus text exposition format.
+type prometheusHandler struct {
+	r *sdkmetric.ManualReader
+}
+
+func (h prometheusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rm := metricdata.ResourceMetrics{}
+	if err := h.r.Collect(r.Context(), &rm); err != nil {
+		klog.Errorf("/metrics: failed to collect metrics: %v", err)
+		http.Error(w, "failed to collect metrics", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writePrometheus(w, &rm); err != nil {
+		klog.Errorf("/metrics: %v", err)
+	}
+}
+
+// writePrometheus writes the metrics in rm to w in the Prometheus text exposition format.
+//
+// OpenTelemetry metric and attribute names are converted by replacing characters which are not permitted in
+// Prometheus names with underscores, and monotonic sums are given the conventional _total suffix.
+// Exponential histograms, which Tessera doesn't use, are skipped.
+func writePrometheus(w io.Writer, rm *metricdata.ResourceMetrics) error {
+	b := bufio.NewWriter(w)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			name := prometheusName(m.Name)
+			switch d := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				writeSum(b, name, m.Description, d.IsMonotonic, d.DataPoints)
+			case metricdata.Sum[float64]:
+				writeSum(b, name, m.Description, d.IsMonotonic, d.DataPoints)
+			case metricdata.Gauge[int64]:
+				writePoints(b, name, m.Description, "gauge", d.DataPoints)
+			case metricdata.Gauge[float64]:
+				writePoints(b, name, m.Description, "gauge", d.DataPoints)
+			case metricdata.Histogram[int64]:
+				writeHistogram(b, name, m.Description, d.DataPoints)
+			case metricdata.Histogram[float64]:
+				writeHistogram(b, name, m.Description, d.DataPoints)
+			}
+		}
+	}
+	return b.Flush()
+}
+
+func writeSum[N int64 | float64](w *bufio.Writer, name, help string, monotonic bool, dps []metricdata.DataPoint[N]) {
+	if !monotonic {
+		writePoints(w, name, help, "gauge", dps)
+		return
+	}
+	writePoints(w, name+"_total", help, "counter", dps)
+}
+
+func writePoints[N int64 | float64](w *bufio.Writer, name, help, typ string, dps []metricdata.DataPoint[N]) {
+	writeHeader(w, name, help, typ)
+	for _, dp := range dps {
+		fmt.Fprintf(w, "%s%s %s\n", name, prometheusLabels(dp.Attributes), formatValue(float64(dp.Value)))
+	}
+}
+
+func writeHistogram[N int64 | float64](w *bufio.Writer, name, help string, dps []metricdata.HistogramDataPoint[N]) {
+	writeHeader(w, name, help, "histogram")
+	for _, dp := range dps {
+		// Prometheus buckets are cumulative, and the final bucket counts all observations.
+		var n uint64
+		for i, c := range dp.BucketCounts {
+			n += c
+			le := math.Inf(1)
+			if i < len(dp.Bounds) {
+				le = dp.Bounds[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, prometheusLabels(dp.Attributes, "le", formatValue(le)), n)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, prometheusLabels(dp.Attributes), formatValue(float64(dp.Sum)))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, prometheusLabels(dp.Attributes), dp.Count)
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	if help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// labelEscaper escapes the characters which are not permitted unescaped in Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabels returns the Prometheus label set for attrs, followed by the extra name/value pairs in kv.
+func prometheusLabels(attrs attribute.Set, kv ...string) string {
+	var l []string
+	for _, a := range attrs.ToSlice() {
+		l = append(l, prometheusName(string(a.Key))+`="`+labelEscaper.Replace(a.Value.Emit())+`"`)
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		l = append(l, kv[i]+`="`+labelEscaper.Replace(kv[i+1])+`"`)
+	}
+	if len(l) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(l, ",") + "}"
+}
+
+// prometheusName replaces the characters in an OpenTelemetry name which are not permitted in Prometheus
+// metric and label names with underscores.
+func prometheusName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
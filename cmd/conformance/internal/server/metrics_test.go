@@ -0,0 +1,80 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	ctx := t.Context()
+	r := sdkmetric.NewManualReader()
+	m := sdkmetric.NewMeterProvider(sdkmetric.WithReader(r)).Meter("test")
+
+	c, err := m.Int64Counter("tessera.test.calls", metric.WithDescription("Number of calls"))
+	if err != nil {
+		t.Fatalf("Int64Counter: %v", err)
+	}
+	c.Add(ctx, 3, metric.WithAttributes(attribute.String("tessera.pushback", `a "b"`)))
+	g, err := m.Int64Gauge("tessera.test.size")
+	if err != nil {
+		t.Fatalf("Int64Gauge: %v", err)
+	}
+	g.Record(ctx, 42)
+	h, err := m.Int64Histogram("tessera.test.duration", metric.WithExplicitBucketBoundaries(10, 100))
+	if err != nil {
+		t.Fatalf("Int64Histogram: %v", err)
+	}
+	for _, v := range []int64{5, 50, 500} {
+		h.Record(ctx, v)
+	}
+
+	srv := httptest.NewServer(prometheusHandler{r: r})
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	for _, want := range []string{
+		"# HELP tessera_test_calls_total Number of calls\n",
+		"# TYPE tessera_test_calls_total counter\n",
+		`tessera_test_calls_total{tessera_pushback="a \"b\""} 3` + "\n",
+		"# TYPE tessera_test_size gauge\ntessera_test_size 42\n",
+		"# TYPE tessera_test_duration histogram\n",
+		`tessera_test_duration_bucket{le="10"} 1` + "\n",
+		`tessera_test_duration_bucket{le="100"} 2` + "\n",
+		`tessera_test_duration_bucket{le="+Inf"} 3` + "\n",
+		"tessera_test_duration_sum 555\ntessera_test_duration_count 3\n",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Response missing %q, got:\n%s", want, b)
+		}
+	}
+}
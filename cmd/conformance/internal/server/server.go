@@ -0,0 +1,171 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server contains the HTTP serving code shared by the conformance personalities.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"k8s.io/klog/v2"
+)
+
+// ShutdownTimeout is how long ListenAndServe waits for in-flight requests to complete, and for their
+// entries to be published, once a shutdown has been requested. It's a little shorter than the default
+// Kubernetes termination grace period, so that the process exits before being killed.
+const ShutdownTimeout = 25 * time.Second
+
+// TLSOptions configures serving over TLS. If neither a certificate nor autocert domains are set, the
+// server uses cleartext HTTP.
+type TLSOptions struct {
+	// CertFile and KeyFile are the paths of the PEM encoded certificate chain and private key to serve.
+	CertFile string
+	KeyFile  string
+	// AutocertDomains, if set, is a comma separated list of domains for which certificates are obtained
+	// from Let's Encrypt using the TLS-ALPN-01 challenge, so the server must be reachable on port 443.
+	AutocertDomains string
+	// AutocertCacheDir is the directory in which certificates obtained by autocert are stored.
+	AutocertCacheDir string
+}
+
+// RegisterTLSFlags registers flags which populate the returned TLSOptions with the default flag set.
+func RegisterTLSFlags() *TLSOptions {
+	o := &TLSOptions{}
+	flag.StringVar(&o.CertFile, "tls_cert", "", "Location of a PEM encoded TLS certificate chain. If set, --tls_key must also be set and the personality serves HTTPS.")
+	flag.StringVar(&o.KeyFile, "tls_key", "", "Location of the PEM encoded private key for --tls_cert.")
+	flag.StringVar(&o.AutocertDomains, "autocert_domains", "", "Comma separated list of domains for which to obtain TLS certificates from Let's Encrypt. Cannot be used with --tls_cert.")
+	flag.StringVar(&o.AutocertCacheDir, "autocert_cache_dir", "", "Directory in which to store certificates obtained for --autocert_domains.")
+	return o
+}
+
+// tlsConfig returns the TLS configuration described by the options, or nil if TLS is not enabled.
+func (o *TLSOptions) tlsConfig() (*tls.Config, error) {
+	if o == nil {
+		return nil, nil
+	}
+	switch {
+	case o.AutocertDomains != "" && (o.CertFile != "" || o.KeyFile != ""):
+		return nil, errors.New("--autocert_domains cannot be used with --tls_cert or --tls_key")
+	case o.AutocertDomains != "":
+		if o.AutocertCacheDir == "" {
+			return nil, errors.New("--autocert_cache_dir must be set when using --autocert_domains")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(strings.Split(o.AutocertDomains, ",")...),
+			Cache:      autocert.DirCache(o.AutocertCacheDir),
+		}
+		return m.TLSConfig(), nil
+	case o.CertFile != "" || o.KeyFile != "":
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("--tls_cert and --tls_key must be set together")
+		}
+		c, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{c}}, nil
+	}
+	return nil, nil
+}
+
+// New returns an http.Server which serves h on addr. If TLS is enabled by opts, which may be nil, the
+// server uses HTTPS, with HTTP/2 negotiated with clients which support it. Otherwise it uses either
+// HTTP/1.1 or cleartext HTTP/2.
+//
+// Each request is traced with OpenTelemetry, continuing any trace propagated by the client, so that
+// the spans of the work done to serve it, such as sequencing added entries, are part of the same trace.
+// Each request is also given an ID, which is added to the context logger and returned in the X-Request-Id
+// response header.
+func New(addr string, h http.Handler, opts *TLSOptions) (*http.Server, error) {
+	tc, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	h2s := &http2.Server{}
+	h1s := &http.Server{
+		Addr:    addr,
+		Handler: h2c.NewHandler(otelhttp.NewHandler(withRequestID(h), "tessera.server"), h2s),
+		// ConfigureServer adds h2 to the protocols negotiated by this config.
+		TLSConfig: tc,
+	}
+	if err := http2.ConfigureServer(h1s, h2s); err != nil {
+		return nil, fmt.Errorf("http2.ConfigureServer: %v", err)
+	}
+	return h1s, nil
+}
+
+// ListenAndServe runs srv until it fails, or until the process receives SIGINT or SIGTERM.
+//
+// On receiving a signal, the server stops accepting connections and waits for in-flight requests to
+// complete, so that entries which are being added are sequenced. The appender's shutdown function, as
+// returned by tessera.NewAppender, is then called to wait for those entries to be integrated and
+// published in a checkpoint. A second signal terminates the process immediately.
+func ListenAndServe(srv *http.Server, shutdown func(context.Context) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errC := make(chan error, 1)
+	go func() {
+		// http2.ConfigureServer always sets a TLS config, so TLS is only enabled if it provides certificates.
+		if tc := srv.TLSConfig; tc != nil && (len(tc.Certificates) > 0 || tc.GetCertificate != nil) {
+			errC <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errC <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errC:
+		// Entries which were added before the failure should still be published.
+		sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		if sErr := shutdown(sctx); sErr != nil {
+			return errors.Join(fmt.Errorf("ListenAndServe: %v", err), fmt.Errorf("shutdown: %v", sErr))
+		}
+		return fmt.Errorf("ListenAndServe: %v", err)
+	case <-ctx.Done():
+	}
+	// Restore the default signal behaviour, so that a second signal kills the process.
+	stop()
+
+	klog.Infof("Shutting down: draining requests, and waiting for entries to be published")
+	sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	var srvErr error
+	if err := srv.Shutdown(sctx); err != nil {
+		// Carry on, since entries which have already been sequenced should still be published.
+		srvErr = fmt.Errorf("failed to drain requests: %v", err)
+	}
+	if err := shutdown(sctx); err != nil {
+		return errors.Join(srvErr, fmt.Errorf("failed to publish entries: %v", err))
+	}
+	if srvErr != nil {
+		return srvErr
+	}
+	klog.Infof("Shutdown complete")
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// StatsOptions configures the log statistics served at /stats.
+type StatsOptions struct {
+	// Enabled serves statistics about the log at /stats, if set.
+	Enabled bool
+	// File, if set, is where the history of the log's size is stored, so that it survives restarts.
+	File string
+}
+
+// RegisterStatsFlags registers flags which populate the returned StatsOptions with the default flag set.
+func RegisterStatsFlags() *StatsOptions {
+	o := &StatsOptions{}
+	flag.BoolVar(&o.Enabled, "stats", false, "Serve statistics about the log's growth and integration latency as JSON at /stats")
+	flag.StringVar(&o.File, "stats_file", "", "File in which to store the history of the log's size served at /stats. If unset, the history is lost on restart.")
+	return o
+}
+
+// ConfigureStats configures appendOpts to collect log statistics, if opts.Enabled is set, and returns them.
+// Otherwise, nil is returned.
+func ConfigureStats(ctx context.Context, opts *StatsOptions, appendOpts *tessera.AppendOptions) (*tessera.LogStats, error) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+	so := tessera.StatsOptions{}
+	if opts.File != "" {
+		so.Store = tessera.StatsFile{Path: opts.File}
+	}
+	s, err := tessera.NewLogStats(ctx, so)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log stats: %v", err)
+	}
+	appendOpts.WithStats(s)
+	return s, nil
+}
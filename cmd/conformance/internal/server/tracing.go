@@ -0,0 +1,71 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"k8s.io/klog/v2"
+)
+
+// TracingOptions configures the export of the OpenTelemetry traces recorded by Tessera and the personality.
+type TracingOptions struct {
+	// OTLPEndpoint, if set, is the host:port of an OTLP/gRPC collector to which traces are pushed. The
+	// connection is not encrypted, so this is intended for a collector running alongside the personality.
+	OTLPEndpoint string
+	// TraceFraction is the fraction of traces which are sampled, unless the client which made the request
+	// has already decided whether to sample it.
+	TraceFraction float64
+}
+
+// RegisterTracingFlags registers flags which populate the returned TracingOptions with the default flag set.
+func RegisterTracingFlags() *TracingOptions {
+	o := &TracingOptions{}
+	flag.StringVar(&o.OTLPEndpoint, "otlp_traces_endpoint", "", "Address:port of an OTLP/gRPC collector to push traces to in cleartext, e.g. localhost:4317. If unset, traces are not recorded.")
+	flag.Float64Var(&o.TraceFraction, "trace_fraction", 0.01, "Fraction of open-telemetry span traces to sample")
+	return o
+}
+
+// InitTracing installs a global OpenTelemetry TracerProvider which exports traces as configured by opts,
+// and a propagator for W3C Trace Context headers. It does nothing if no exporter is configured.
+//
+// Returns a shutdown function which flushes any pending spans, and should be called just before exiting
+// the process.
+func InitTracing(ctx context.Context, opts *TracingOptions) (func(context.Context), error) {
+	if opts.OTLPEndpoint == "" {
+		return func(context.Context) {}, nil
+	}
+	e, err := otlptracegrpc.New(ctx, otlptracegrpc.WithInsecure(), otlptracegrpc.WithEndpoint(opts.OTLPEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %v", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.TraceFraction))),
+		sdktrace.WithBatcher(e),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return func(ctx context.Context) {
+		if err := tp.Shutdown(ctx); err != nil {
+			klog.Errorf("TracerProvider shutdown: %v", err)
+		}
+	}, nil
+}
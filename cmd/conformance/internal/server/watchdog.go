@@ -0,0 +1,82 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// WatchdogOptions configures the consistency watchdog, which checks the log's published checkpoints.
+type WatchdogOptions struct {
+	// ReadURL, if set, enables the watchdog, which reads the log through this URL, i.e. the one clients use.
+	ReadURL string
+	// PublicKey is the log's public key, in note verifier format. If unset, the contents of the
+	// LOG_PUBLIC_KEY environment variable are used.
+	PublicKey string
+	// Interval is how often the log's published checkpoint is checked.
+	Interval time.Duration
+}
+
+// RegisterWatchdogFlags registers flags which populate the returned WatchdogOptions with the default flag set.
+func RegisterWatchdogFlags() *WatchdogOptions {
+	o := &WatchdogOptions{}
+	flag.StringVar(&o.ReadURL, "watchdog_read_url", "", "URL through which the log is read by its clients. If set, the log's published checkpoints are regularly fetched from here, and checked for consistency with each other and with storage.")
+	flag.StringVar(&o.PublicKey, "watchdog_public_key", "", "Public key of the log, used by the watchdog to verify checkpoints. If unset, uses the contents of the LOG_PUBLIC_KEY environment variable.")
+	flag.DurationVar(&o.Interval, "watchdog_interval", tessera.DefaultWatchdogInterval, "How often the watchdog checks the log's published checkpoint")
+	return o
+}
+
+// ConfigureWatchdog enables the consistency watchdog in appendOpts, if opts.ReadURL is set.
+func ConfigureWatchdog(opts *WatchdogOptions, appendOpts *tessera.AppendOptions) error {
+	if opts.ReadURL == "" {
+		return nil
+	}
+	vkey := opts.PublicKey
+	if vkey == "" {
+		vkey = os.Getenv("LOG_PUBLIC_KEY")
+	}
+	if vkey == "" {
+		return errors.New("--watchdog_public_key or LOG_PUBLIC_KEY must be set to use the watchdog")
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		return fmt.Errorf("failed to create watchdog verifier: %v", err)
+	}
+	u, err := url.Parse(opts.ReadURL)
+	if err != nil {
+		return fmt.Errorf("invalid --watchdog_read_url: %v", err)
+	}
+	f, err := client.NewHTTPFetcher(u, http.DefaultClient)
+	if err != nil {
+		return fmt.Errorf("failed to create watchdog fetcher: %v", err)
+	}
+	appendOpts.WithConsistencyWatchdog(tessera.WatchdogOptions{
+		Verifier:        v,
+		FetchCheckpoint: f.ReadCheckpoint,
+		FetchTile:       f.ReadTile,
+		Interval:        opts.Interval,
+	})
+	return nil
+}
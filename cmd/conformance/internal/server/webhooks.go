@@ -0,0 +1,64 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// WebhookOptions configures the webhooks notified when checkpoints are published.
+type WebhookOptions struct {
+	// URLs is a comma separated list of webhooks. If empty, no webhooks are notified.
+	URLs string
+	// SecretFile, if set, is the path to a file containing the key used to sign notifications.
+	SecretFile string
+	// IncludeRange adds the range of indices newly covered by each checkpoint to its notification.
+	IncludeRange bool
+}
+
+// RegisterWebhookFlags registers flags which populate the returned WebhookOptions with the default flag set.
+func RegisterWebhookFlags() *WebhookOptions {
+	o := &WebhookOptions{}
+	flag.StringVar(&o.URLs, "webhook_urls", "", "Comma separated list of URLs to POST a notification to each time a checkpoint is published")
+	flag.StringVar(&o.SecretFile, "webhook_secret_file", "", "Path to a file containing the key used to sign webhook notifications with HMAC-SHA256")
+	flag.BoolVar(&o.IncludeRange, "webhook_include_range", false, "Include the range of indices newly covered by each checkpoint in webhook notifications")
+	return o
+}
+
+// ConfigureWebhooks enables webhooks in appendOpts, if opts.URLs is set.
+func ConfigureWebhooks(opts *WebhookOptions, appendOpts *tessera.AppendOptions) error {
+	if opts.URLs == "" {
+		return nil
+	}
+	wo := tessera.WebhookOptions{
+		URLs:         strings.Split(opts.URLs, ","),
+		IncludeRange: opts.IncludeRange,
+	}
+	if opts.SecretFile != "" {
+		s, err := os.ReadFile(opts.SecretFile)
+		if err != nil {
+			return fmt.Errorf("failed to read webhook secret: %v", err)
+		}
+		wo.Secret = bytes.TrimSpace(s)
+	}
+	appendOpts.WithWebhooks(wo)
+	return nil
+}
@@ -18,29 +18,32 @@ package main
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"io/fs"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/transparency-dev/tessera"
-	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/api/serve"
+	"github.com/transparency-dev/tessera/cmd/conformance/internal/server"
 	"github.com/transparency-dev/tessera/storage/mysql"
 	"golang.org/x/mod/sumdb/note"
-	"golang.org/x/net/http2"
-	"golang.org/x/net/http2/h2c"
 	"k8s.io/klog/v2"
 )
 
 var (
 	mysqlURI                  = flag.String("mysql_uri", "user:password@tcp(db:3306)/tessera", "Connection string for a MySQL database")
+	mysqlReadReplicaURI       = flag.String("mysql_read_replica_uri", "", "If set, connection string for a read replica of the MySQL database, from which the tlog-tiles API is served")
 	dbConnMaxLifetime         = flag.Duration("db_conn_max_lifetime", 3*time.Minute, "")
 	dbMaxOpenConns            = flag.Int("db_max_open_conns", 64, "")
 	dbMaxIdleConns            = flag.Int("db_max_idle_conns", 64, "")
+	dbCircuitBreakerFailures  = flag.Uint("db_circuit_breaker_failures", 0, "If non-zero, reject new entries with pushback after this many consecutive failures to write to the database")
+	leaderElection            = flag.Bool("leader_election", false, "If set, elect a leader among the instances sharing the database, so that only one accepts entries at a time")
+	leaderLeaseDuration       = flag.Duration("leader_lease_duration", mysql.DefaultLeaseDuration, "How long the leader lease lasts without being renewed")
+	dbCircuitBreakerOpen      = flag.Duration("db_circuit_breaker_open_duration", mysql.DefaultCircuitBreakerOpenDuration, "How long to reject new entries for once the circuit breaker has opened, before retrying the database")
+	idempotencyWindow         = flag.Duration("idempotency_window", 0, "If non-zero, how long to remember the index assigned to entries added with an Idempotency-Key header")
 	initSchemaPath            = flag.String("init_schema_path", "", "Location of the schema file if database initialization is needed")
 	listen                    = flag.String("listen", ":2024", "Address:port to listen on")
 	privateKeyPath            = flag.String("private_key_path", "", "Location of private key file")
@@ -48,6 +51,42 @@ var (
 	additionalPrivateKeyPaths = []string{}
 )
 
+// tlsOpts configures the listener to serve HTTPS, if set.
+var tlsOpts = server.RegisterTLSFlags()
+
+// healthOpts configures the checks made by the /healthz and /readyz handlers.
+var healthOpts = server.RegisterHealthFlags()
+
+// adminListen is the address of the admin API, if set.
+var adminListen = server.RegisterAdminFlags()
+
+// logFormat selects the format of log output.
+var logFormat = server.RegisterLoggingFlags()
+
+// metricsOpts configures the export of OpenTelemetry metrics.
+var metricsOpts = server.RegisterMetricsFlags()
+
+// tracingOpts configures the export of OpenTelemetry traces.
+var tracingOpts = server.RegisterTracingFlags()
+
+// watchdogOpts configures the consistency watchdog.
+var watchdogOpts = server.RegisterWatchdogFlags()
+
+// statsOpts configures the log statistics served at /stats.
+var statsOpts = server.RegisterStatsFlags()
+
+// webhookOpts configures the webhooks notified of published checkpoints.
+var webhookOpts = server.RegisterWebhookFlags()
+
+// checkpointDestOpts configures where checkpoints are published, in addition to the log's storage.
+var checkpointDestOpts = server.RegisterCheckpointDestinationFlags()
+
+// eventsOpts configures the events published for newly integrated entry bundles.
+var eventsOpts = server.RegisterEventsFlags()
+
+// enableUI serves a read-only web UI for browsing the log, if set.
+var enableUI = flag.Bool("ui", false, "Serve a read-only web UI for browsing the log at /ui/")
+
 func init() {
 	flag.Func("additional_private_key_path", "Location of additional private key file, may be specified multiple times", func(s string) error {
 		additionalPrivateKeyPaths = append(additionalPrivateKeyPaths, s)
@@ -60,38 +99,96 @@ func main() {
 	flag.Parse()
 	ctx := context.Background()
 
-	db := createDatabaseOrDie(ctx)
+	shutdownMetrics, err := server.InitMetrics(ctx, metricsOpts, http.DefaultServeMux)
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer shutdownMetrics(ctx)
+	shutdownTracing, err := server.InitTracing(ctx, tracingOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer shutdownTracing(ctx)
+
 	noteSigner, additionalSigners := createSignersOrDie()
+	if err := server.InitLogging(*logFormat, noteSigner.Name()); err != nil {
+		klog.Exit(err)
+	}
+	db := createDatabaseOrDie(ctx)
 
 	// Initialise the Tessera MySQL storage
-	driver, err := mysql.New(ctx, db)
+	cfg := mysql.Config{
+		MaxOpenConns:    *dbMaxOpenConns,
+		MaxIdleConns:    *dbMaxIdleConns,
+		ConnMaxLifetime: *dbConnMaxLifetime,
+	}
+	if *dbCircuitBreakerFailures > 0 {
+		cfg.CircuitBreaker = &mysql.CircuitBreakerOptions{
+			FailureThreshold: *dbCircuitBreakerFailures,
+			OpenDuration:     *dbCircuitBreakerOpen,
+		}
+	}
+	if *leaderElection {
+		cfg.LeaderElection = &mysql.LeaderElectionOptions{LeaseDuration: *leaderLeaseDuration}
+	}
+	if *mysqlReadReplicaURI != "" {
+		replica, err := sql.Open("mysql", *mysqlReadReplicaURI)
+		if err != nil {
+			klog.Exitf("Failed to connect to read replica: %v", err)
+		}
+		cfg.ReadReplica = replica
+	}
+	driver, err := mysql.NewWithConfig(ctx, db, cfg)
 	if err != nil {
 		klog.Exitf("Failed to create new MySQL storage: %v", err)
 	}
 
-	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+	appendOpts := tessera.NewAppendOptions().
 		WithCheckpointSigner(noteSigner, additionalSigners...).
 		WithCheckpointInterval(*publishInterval).
-		WithAntispam(256, nil))
+		WithAntispam(256, nil)
+	if *idempotencyWindow > 0 {
+		appendOpts.WithIdempotencyKeys(*idempotencyWindow)
+	}
+	if err := server.ConfigureWatchdog(watchdogOpts, appendOpts); err != nil {
+		klog.Exit(err)
+	}
+	server.ConfigureCheckpointDestinations(checkpointDestOpts, appendOpts)
+	if err := server.ConfigureWebhooks(webhookOpts, appendOpts); err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ConfigureEvents(ctx, eventsOpts, noteSigner.Name(), appendOpts); err != nil {
+		klog.Exit(err)
+	}
+	stats, err := server.ConfigureStats(ctx, statsOpts, appendOpts)
 	if err != nil {
 		klog.Exit(err)
 	}
-	// Set up the handlers for the tlog-tiles GET methods, and a custom handler for HTTP POSTs to /add
-	configureTilesReadAPI(http.DefaultServeMux, reader)
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, appendOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	// Set up the handlers for the tlog-tiles GET methods, and a custom handler for HTTP POSTs to /add.
+	// Reads are served from the read replica, if there is one.
+	serve.RegisterTilesHandlers(http.DefaultServeMux, driver.ReplicaLogReader(), serve.TilesOptions{})
 	http.HandleFunc("POST /add", func(w http.ResponseWriter, r *http.Request) {
 		b, err := io.ReadAll(r.Body)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		idx, err := appender.Add(r.Context(), tessera.NewEntry(b))()
+		e := tessera.NewEntry(b)
+		if k := r.Header.Get("Idempotency-Key"); k != "" {
+			e.WithIdempotencyKey(k)
+		}
+		idx, err := appender.Add(r.Context(), e)()
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			_, _ = w.Write([]byte(err.Error()))
 			return
 		}
 		if _, err := fmt.Fprintf(w, "%d", idx.Index); err != nil {
-			klog.Errorf("/add: %v", err)
+			klog.FromContext(r.Context()).Error(err, "Failed to write response", "path", r.URL.Path)
 			return
 		}
 	})
@@ -100,21 +197,26 @@ func main() {
 	klog.Infof("Environment variables useful for accessing this log:\n"+
 		"export WRITE_URL=http://localhost%s/ \n"+
 		"export READ_URL=http://localhost%s/ \n", *listen, *listen)
-	// Run the HTTP server with the single handler and block until this is terminated
-	h2s := &http2.Server{}
-	h1s := &http.Server{
-		Addr:    *listen,
-		Handler: h2c.NewHandler(http.DefaultServeMux, h2s),
-	}
-	if err := http2.ConfigureServer(h1s, h2s); err != nil {
-		klog.Exitf("http2.ConfigureServer: %v", err)
-	}
-
-	if err := h1s.ListenAndServe(); err != nil {
-		if err := shutdown(ctx); err != nil {
+	if *enableUI {
+		ui, err := serve.NewUI(ctx, driver.ReplicaLogReader(), serve.UIOptions{})
+		if err != nil {
 			klog.Exit(err)
 		}
-		klog.Exitf("ListenAndServe: %v", err)
+		ui.RegisterHandlers(http.DefaultServeMux)
+	}
+	if stats != nil {
+		http.Handle("GET /stats", stats.Handler(reader))
+	}
+	server.NewHealth(appender, reader, *healthOpts).RegisterHandlers(http.DefaultServeMux)
+	if err := server.ServeAdmin(*adminListen, appender, nil); err != nil {
+		klog.Exit(err)
+	}
+	srv, err := server.New(*listen, http.DefaultServeMux, tlsOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ListenAndServe(srv, shutdown); err != nil {
+		klog.Exit(err)
 	}
 }
 
@@ -123,10 +225,6 @@ func createDatabaseOrDie(ctx context.Context) *sql.DB {
 	if err != nil {
 		klog.Exitf("Failed to connect to DB: %v", err)
 	}
-	db.SetConnMaxLifetime(*dbConnMaxLifetime)
-	db.SetMaxOpenConns(*dbMaxOpenConns)
-	db.SetMaxIdleConns(*dbMaxIdleConns)
-
 	initDatabaseSchema(ctx)
 	return db
 }
@@ -152,91 +250,6 @@ func createSignerOrDie(s string) note.Signer {
 	return noteSigner
 }
 
-// configureTilesReadAPI adds the API methods from https://c2sp.org/tlog-tiles to the mux,
-// routing the requests to the mysql storage.
-// This method could be moved into the storage API as it's likely this will be
-// the same for any implementation of a personality based on MySQL.
-func configureTilesReadAPI(mux *http.ServeMux, reader tessera.LogReader) {
-	mux.HandleFunc("GET /checkpoint", func(w http.ResponseWriter, r *http.Request) {
-		checkpoint, err := reader.ReadCheckpoint(r.Context())
-		if err != nil {
-			if errors.Is(err, fs.ErrNotExist) {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			klog.Errorf("/checkpoint: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		// Don't cache checkpoints as the endpoint refreshes regularly.
-		// A personality that wanted to _could_ set a small cache time here which was no higher
-		// than the checkpoint publish interval.
-		w.Header().Set("Cache-Control", "no-cache")
-		if _, err := w.Write(checkpoint); err != nil {
-			klog.Errorf("/checkpoint: %v", err)
-			return
-		}
-	})
-
-	mux.HandleFunc("GET /tile/{level}/{index...}", func(w http.ResponseWriter, r *http.Request) {
-		level, index, p, err := layout.ParseTileLevelIndexPartial(r.PathValue("level"), r.PathValue("index"))
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			if _, werr := fmt.Fprintf(w, "Malformed URL: %s", err.Error()); werr != nil {
-				klog.Errorf("/tile/{level}/{index...}: %v", werr)
-			}
-			return
-		}
-		tile, err := reader.ReadTile(r.Context(), level, index, p)
-		if err != nil {
-			if errors.Is(err, fs.ErrNotExist) {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			klog.Errorf("/tile/{level}/{index...}: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Cache-Control", "max-age=31536000, immutable")
-
-		if _, err := w.Write(tile); err != nil {
-			klog.Errorf("/tile/{level}/{index...}: %v", err)
-			return
-		}
-	})
-
-	mux.HandleFunc("GET /tile/entries/{index...}", func(w http.ResponseWriter, r *http.Request) {
-		index, p, err := layout.ParseTileIndexPartial(r.PathValue("index"))
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			if _, werr := fmt.Fprintf(w, "Malformed URL: %s", err.Error()); werr != nil {
-				klog.Errorf("/tile/entries/{index...}: %v", werr)
-			}
-			return
-		}
-
-		entryBundle, err := reader.ReadEntryBundle(r.Context(), index, p)
-		if err != nil {
-			klog.Errorf("/tile/entries/{index...}: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if entryBundle == nil {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-
-		if _, err := w.Write(entryBundle); err != nil {
-			klog.Errorf("/tile/entries/{index...}: %v", err)
-			return
-		}
-	})
-}
-
 func initDatabaseSchema(ctx context.Context) {
 	if *initSchemaPath != "" {
 		klog.Infof("Initializing database schema")
@@ -29,10 +29,10 @@ import (
 	"time"
 
 	"golang.org/x/mod/sumdb/note"
-	"golang.org/x/net/http2"
-	"golang.org/x/net/http2/h2c"
 
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/serve"
+	"github.com/transparency-dev/tessera/cmd/conformance/internal/server"
 	"github.com/transparency-dev/tessera/storage/posix"
 	badger_as "github.com/transparency-dev/tessera/storage/posix/antispam"
 	"k8s.io/klog/v2"
@@ -43,9 +43,50 @@ var (
 	listen                    = flag.String("listen", ":2025", "Address:port to listen on")
 	privKeyFile               = flag.String("private_key", "", "Location of private key file. If unset, uses the contents of the LOG_PRIVATE_KEY environment variable.")
 	persistentAntispam        = flag.Bool("antispam", false, "EXPERIMENTAL: Set to true to enable Badger-based persistent antispam storage")
+	idempotencyWindow         = flag.Duration("idempotency_window", 0, "If non-zero, how long to remember the index assigned to entries added with an Idempotency-Key header")
+	submissionWALDir          = flag.String("submission_wal_dir", "", "If set, directory in which accepted entries are recorded until they're sequenced, so that they survive restarts")
 	additionalPrivateKeyFiles = []string{}
 )
 
+// tlsOpts configures the listener to serve HTTPS, if set.
+var tlsOpts = server.RegisterTLSFlags()
+
+// healthOpts configures the checks made by the /healthz and /readyz handlers.
+var healthOpts = server.RegisterHealthFlags()
+
+// adminListen is the address of the admin API, if set.
+var adminListen = server.RegisterAdminFlags()
+
+// logFormat selects the format of log output.
+var logFormat = server.RegisterLoggingFlags()
+
+// metricsOpts configures the export of OpenTelemetry metrics.
+var metricsOpts = server.RegisterMetricsFlags()
+
+// tracingOpts configures the export of OpenTelemetry traces.
+var tracingOpts = server.RegisterTracingFlags()
+
+// watchdogOpts configures the consistency watchdog.
+var watchdogOpts = server.RegisterWatchdogFlags()
+
+// statsOpts configures the log statistics served at /stats.
+var statsOpts = server.RegisterStatsFlags()
+
+// webhookOpts configures the webhooks notified of published checkpoints.
+var webhookOpts = server.RegisterWebhookFlags()
+
+// checkpointDestOpts configures where checkpoints are published, in addition to the log's storage.
+var checkpointDestOpts = server.RegisterCheckpointDestinationFlags()
+
+// eventsOpts configures the events published for newly integrated entry bundles.
+var eventsOpts = server.RegisterEventsFlags()
+
+// enableUI serves a read-only web UI for browsing the log, if set.
+var enableUI = flag.Bool("ui", false, "Serve a read-only web UI for browsing the log at /ui/")
+
+// compressEntryBundles stores entry bundles compressed with zstd, if set.
+var compressEntryBundles = flag.Bool("compress_entry_bundles", false, "Store entry bundles compressed with zstd")
+
 func init() {
 	flag.Func("additional_private_key", "Location of addition private key, may be specified multiple times", func(s string) error {
 		additionalPrivateKeyFiles = append(additionalPrivateKeyFiles, s)
@@ -65,8 +106,22 @@ func main() {
 	flag.Parse()
 	ctx := context.Background()
 
+	shutdownMetrics, err := server.InitMetrics(ctx, metricsOpts, http.DefaultServeMux)
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer shutdownMetrics(ctx)
+	shutdownTracing, err := server.InitTracing(ctx, tracingOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	defer shutdownTracing(ctx)
+
 	// Gather the info needed for reading/writing checkpoints
 	s, a := getSignersOrDie()
+	if err := server.InitLogging(*logFormat, s.Name()); err != nil {
+		klog.Exit(err)
+	}
 
 	// Create the Tessera POSIX storage, using the directory from the --storage_dir flag
 	driver, err := posix.New(ctx, posix.Config{Path: *storageDir})
@@ -83,10 +138,34 @@ func main() {
 		}
 	}
 
-	appender, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+	appendOpts := tessera.NewAppendOptions().
 		WithCheckpointSigner(s, a...).
 		WithBatching(256, time.Second).
-		WithAntispam(256, antispam))
+		WithAntispam(256, antispam)
+	if *compressEntryBundles {
+		appendOpts.WithEntryBundleCompression()
+	}
+	if *submissionWALDir != "" {
+		appendOpts.WithSubmissionWAL(*submissionWALDir)
+	}
+	if *idempotencyWindow > 0 {
+		appendOpts.WithIdempotencyKeys(*idempotencyWindow)
+	}
+	if err := server.ConfigureWatchdog(watchdogOpts, appendOpts); err != nil {
+		klog.Exit(err)
+	}
+	server.ConfigureCheckpointDestinations(checkpointDestOpts, appendOpts)
+	if err := server.ConfigureWebhooks(webhookOpts, appendOpts); err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ConfigureEvents(ctx, eventsOpts, s.Name(), appendOpts); err != nil {
+		klog.Exit(err)
+	}
+	stats, err := server.ConfigureStats(ctx, statsOpts, appendOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, appendOpts)
 	if err != nil {
 		klog.Exit(err)
 	}
@@ -98,43 +177,58 @@ func main() {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		idx, err := appender.Add(r.Context(), tessera.NewEntry(b))()
+		e := tessera.NewEntry(b)
+		if k := r.Header.Get("Idempotency-Key"); k != "" {
+			e.WithIdempotencyKey(k)
+		}
+		idx, err := appender.Add(r.Context(), e)()
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			_, _ = w.Write([]byte(err.Error()))
 			return
 		}
 		if _, err := fmt.Fprintf(w, "%d", idx.Index); err != nil {
-			klog.Errorf("/add: %v", err)
+			klog.FromContext(r.Context()).Error(err, "Failed to write response", "path", r.URL.Path)
 			return
 		}
 	})
-	// Proxy all GET requests to the filesystem as a lightweight file server.
-	// This makes it easier to test this implementation from another machine.
-	fs := http.FileServer(http.Dir(*storageDir))
-	http.Handle("GET /checkpoint", addCacheHeaders("no-cache", fs))
-	http.Handle("GET /tile/", addCacheHeaders("max-age=31536000, immutable", fs))
-	http.Handle("GET /entries/", fs)
+	if *compressEntryBundles {
+		// The files don't record that entry bundles are compressed, so serve the log through the LogReader,
+		// which decompresses them.
+		serve.RegisterTilesHandlers(http.DefaultServeMux, reader, serve.TilesOptions{})
+	} else {
+		// Proxy all GET requests to the filesystem as a lightweight file server.
+		// This makes it easier to test this implementation from another machine.
+		fs := http.FileServer(http.Dir(*storageDir))
+		http.Handle("GET /checkpoint", addCacheHeaders("no-cache", fs))
+		http.Handle("GET /tile/", addCacheHeaders("max-age=31536000, immutable", fs))
+		http.Handle("GET /entries/", fs)
+	}
 
 	// TODO(mhutchinson): Change the listen flag to just a port, or fix up this address formatting
 	klog.Infof("Environment variables useful for accessing this log:\n"+
 		"export WRITE_URL=http://localhost%s/ \n"+
 		"export READ_URL=http://localhost%s/ \n", *listen, *listen)
-	// Run the HTTP server with the single handler and block until this is terminated
-	h2s := &http2.Server{}
-	h1s := &http.Server{
-		Addr:    *listen,
-		Handler: h2c.NewHandler(http.DefaultServeMux, h2s),
-	}
-	if err := http2.ConfigureServer(h1s, h2s); err != nil {
-		klog.Exitf("http2.ConfigureServer: %v", err)
-	}
-
-	if err := h1s.ListenAndServe(); err != nil {
-		if err := shutdown(ctx); err != nil {
+	if *enableUI {
+		ui, err := serve.NewUI(ctx, reader, serve.UIOptions{})
+		if err != nil {
 			klog.Exit(err)
 		}
-		klog.Exitf("ListenAndServe: %v", err)
+		ui.RegisterHandlers(http.DefaultServeMux)
+	}
+	if stats != nil {
+		http.Handle("GET /stats", stats.Handler(reader))
+	}
+	server.NewHealth(appender, reader, *healthOpts).RegisterHandlers(http.DefaultServeMux)
+	if err := server.ServeAdmin(*adminListen, appender, nil); err != nil {
+		klog.Exit(err)
+	}
+	srv, err := server.New(*listen, http.DefaultServeMux, tlsOpts)
+	if err != nil {
+		klog.Exit(err)
+	}
+	if err := server.ListenAndServe(srv, shutdown); err != nil {
+		klog.Exit(err)
 	}
 }
 
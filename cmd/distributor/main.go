@@ -0,0 +1,99 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// distributor is a server which collects witnessed checkpoints for a set of logs and serves them with as
+// many cosignatures as possible.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/distributor"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	listen        = flag.String("listen", ":2026", "Address to listen on")
+	logsFile      = flag.String("logs", "", "Path to a file containing the verifier keys of the logs to distribute checkpoints for, one per line. The origin of each log is the name of its key")
+	witnessesFile = flag.String("witnesses", "", "Path to a file containing the verifier keys of the witnesses whose cosignatures are accepted, one per line")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	var logs []distributor.LogConfig
+	for _, v := range readVerifiers(*logsFile, "--logs", f_note.NewVerifier) {
+		logs = append(logs, distributor.LogConfig{Origin: v.Name(), Verifier: v})
+	}
+	// Witnesses following the tlog-witness protocol produce timestamped cosignature/v1 signatures.
+	witnesses := readVerifiers(*witnessesFile, "--witnesses", f_note.NewVerifierForCosignatureV1)
+	d, err := distributor.New(logs, witnesses)
+	if err != nil {
+		klog.Exitf("Failed to create distributor: %v", err)
+	}
+	for _, l := range logs {
+		klog.Infof("Distributing checkpoints for %q as log %s", l.Origin, distributor.LogID(l.Origin))
+	}
+	for _, w := range witnesses {
+		klog.Infof("Accepting cosignatures from %q as witness %s", w.Name(), distributor.WitnessID(w.Name()))
+	}
+
+	klog.Infof("Distributor listening on %s", *listen)
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           d.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if err := srv.ListenAndServe(); err != nil {
+		klog.Exitf("ListenAndServe: %v", err)
+	}
+}
+
+// readVerifiers parses the verifier keys in the named file, one per line, using newVerifier.
+// Blank lines and lines starting with # are ignored.
+func readVerifiers(path, flagName string, newVerifier func(string) (note.Verifier, error)) []note.Verifier {
+	if path == "" {
+		klog.Exitf("Must provide the %s flag", flagName)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		klog.Exitf("Failed to read %q: %v", path, err)
+	}
+	var vs []note.Verifier
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		v, err := newVerifier(l)
+		if err != nil {
+			klog.Exitf("Invalid verifier key %q in %q: %v", l, path, err)
+		}
+		vs = append(vs, v)
+	}
+	if len(vs) == 0 {
+		klog.Exitf("No verifier keys found in %q", path)
+	}
+	return vs
+}
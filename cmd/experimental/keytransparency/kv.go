@@ -0,0 +1,49 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// badgerKV implements tessera.KVStore using a Badger database.
+type badgerKV struct {
+	db *badger.DB
+}
+
+func (k *badgerKV) Get(_ context.Context, key []byte) ([]byte, bool, error) {
+	var v []byte
+	err := k.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		v, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, false, nil
+	}
+	return v, err == nil, err
+}
+
+func (k *badgerKV) Set(_ context.Context, key, value []byte) error {
+	return k.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// keytransparency runs a key transparency style personality: a log of key/value updates stored on a
+// posix filesystem, together with a verifiable map of the latest value of each key which is derived
+// from the log.
+//
+// It is intended to show how the vmap package can be used, and is not suitable for production use.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"github.com/transparency-dev/tessera/vmap"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// maxUpdateSize is the largest update request body which will be accepted.
+const maxUpdateSize = 1 << 16
+
+var (
+	storageDir     = flag.String("storage_dir", "", "Root directory to store the log and map data.")
+	listen         = flag.String("listen", ":2025", "Address:port to listen on")
+	logPrivKeyFile = flag.String("log_private_key", "", "Location of the private key file used to sign log checkpoints.")
+	mapPrivKeyFile = flag.String("map_private_key", "", "Location of the private key file used to sign map roots.")
+)
+
+// update is the JSON encoding of the log entries of this personality. A null or absent value removes the key.
+type update struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	if *storageDir == "" {
+		klog.Exit("--storage_dir must be set")
+	}
+	logSigner := signerOrDie(*logPrivKeyFile)
+	mapSigner := signerOrDie(*mapPrivKeyFile)
+
+	db, err := badger.Open(badger.DefaultOptions(filepath.Join(*storageDir, ".state", "map")))
+	if err != nil {
+		klog.Exitf("Failed to open map storage: %v", err)
+	}
+	m, err := vmap.New(ctx, &badgerKV{db: db}, mapSigner, mapUpdate, nil)
+	if err != nil {
+		klog.Exitf("Failed to create map: %v", err)
+	}
+
+	driver, err := posix.New(ctx, posix.Config{Path: *storageDir})
+	if err != nil {
+		klog.Exitf("Failed to construct storage: %v", err)
+	}
+	appender, shutdown, _, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(logSigner).
+		WithBatching(256, time.Second).
+		WithFollower(m.Follower("vmap", &tessera.FollowerOptions{FollowPublished: true})))
+	if err != nil {
+		klog.Exit(err)
+	}
+
+	// Updates are validated and re-encoded before being added, so every entry in the log can be mapped.
+	http.HandleFunc("POST /add", func(w http.ResponseWriter, r *http.Request) {
+		u := update{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateSize)).Decode(&u); err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse update: %v", err), http.StatusBadRequest)
+			return
+		}
+		if u.Key == "" {
+			http.Error(w, "key must be set", http.StatusBadRequest)
+			return
+		}
+		e, err := json.Marshal(u)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		idx, err := appender.Add(r.Context(), tessera.NewEntry(e))()
+		if err != nil {
+			klog.Warningf("/add: %v", err)
+			http.Error(w, "failed to add update", http.StatusInternalServerError)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "%d", idx.Index); err != nil {
+			klog.Errorf("/add: %v", err)
+		}
+	})
+	http.Handle("GET /map/", http.StripPrefix("/map", m.Handler()))
+	fs := http.FileServer(http.Dir(*storageDir))
+	http.Handle("GET /checkpoint", addCacheHeaders("no-cache", fs))
+	http.Handle("GET /tile/", addCacheHeaders("max-age=31536000, immutable", fs))
+
+	if err := http.ListenAndServe(*listen, nil); err != nil {
+		if err := shutdown(ctx); err != nil {
+			klog.Exit(err)
+		}
+		klog.Exitf("ListenAndServe: %v", err)
+	}
+}
+
+// mapUpdate is the vmap.MapFunc for this personality's log entries.
+func mapUpdate(index uint64, entry []byte) ([]vmap.Update, error) {
+	u := update{}
+	if err := json.Unmarshal(entry, &u); err != nil {
+		// Entries are validated before they're added, so this should never happen. Skip the entry rather
+		// than preventing the map from making progress.
+		klog.Warningf("Ignoring invalid entry %d: %v", index, err)
+		return nil, nil
+	}
+	return []vmap.Update{{Key: []byte(u.Key), Value: u.Value}}, nil
+}
+
+func addCacheHeaders(value string, fs http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Cache-Control", value)
+		fs.ServeHTTP(w, r)
+	}
+}
+
+func signerOrDie(path string) note.Signer {
+	if path == "" {
+		klog.Exit("--log_private_key and --map_private_key must be set")
+	}
+	k, err := os.ReadFile(path)
+	if err != nil {
+		klog.Exitf("Failed to read private key: %v", err)
+	}
+	s, err := note.NewSigner(string(k))
+	if err != nil {
+		klog.Exitf("Failed to instantiate signer from %q: %v", path, err)
+	}
+	return s
+}
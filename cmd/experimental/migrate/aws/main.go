@@ -46,8 +46,11 @@ var (
 	s3AccessKeyID     = flag.String("s3_access_key", "", "Access key ID for custom non-AWS S3 service")
 	s3SecretAccessKey = flag.String("s3_secret", "", "Secret access key for custom non-AWS S3 service")
 
-	sourceURL  = flag.String("source_url", "", "Base URL for the source log.")
-	numWorkers = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	sourceURL    = flag.String("source_url", "", "Base URL for the source log.")
+	numWorkers   = flag.Uint("num_workers", 30, "Number of migration worker goroutines.")
+	maxQPS       = flag.Float64("max_qps", 0, "Maximum number of requests per second to make to the source log. Zero means no limit.")
+	maxBandwidth = flag.Uint64("max_bandwidth", 0, "Maximum number of bytes of entry bundles per second to fetch from the source log. Zero means no limit.")
+	progressFile = flag.String("progress_file", "", "If set, the progress of the migration is stored in this file, so that an interrupted migration resumes copying from where it left off. Must only be used for migrations into the same log.")
 )
 
 func main() {
@@ -84,6 +87,10 @@ func main() {
 	}
 	opts := tessera.NewMigrationOptions()
 
+	opts.WithMaxSourceQPS(*maxQPS).WithMaxSourceBandwidth(*maxBandwidth)
+	if *progressFile != "" {
+		opts.WithProgressStore(tessera.MigrationProgressFile{Path: *progressFile})
+	}
 	m, err := tessera.NewMigrationTarget(ctx, driver, opts)
 	if err != nil {
 		klog.Exitf("Failed to create MigrationTarget: %v", err)
@@ -0,0 +1,139 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"k8s.io/klog/v2"
+)
+
+// trillianSource reads the entries of a Trillian log tree directly from the MySQL database used by the
+// Trillian log server, and serves them as tlog-tiles entry bundles.
+//
+// Trillian's database is read directly rather than through its gRPC API so that the tree can be migrated
+// without running, or retaining, a Trillian log server.
+type trillianSource struct {
+	db     *sql.DB
+	treeID int64
+}
+
+// newTrillianSource returns a source for the Trillian tree with the given ID, having checked that it's a log
+// whose Merkle tree Tessera can reproduce.
+func newTrillianSource(ctx context.Context, db *sql.DB, treeID int64) (*trillianSource, error) {
+	var treeState, treeType, hashStrategy string
+	var deleted sql.NullBool
+	if err := db.QueryRowContext(ctx,
+		"SELECT TreeState, TreeType, HashStrategy, Deleted FROM Trees WHERE TreeId = ?", treeID).Scan(&treeState, &treeType, &hashStrategy, &deleted); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no such tree")
+		}
+		return nil, fmt.Errorf("failed to read tree: %v", err)
+	}
+	if treeType != "LOG" && treeType != "PREORDERED_LOG" {
+		return nil, fmt.Errorf("tree is of type %s, not a log", treeType)
+	}
+	if hashStrategy != "RFC6962_SHA256" {
+		return nil, fmt.Errorf("tree uses the %s hash strategy, only RFC6962_SHA256 is supported", hashStrategy)
+	}
+	if deleted.Valid && deleted.Bool {
+		return nil, errors.New("tree has been deleted")
+	}
+	if treeState == "ACTIVE" {
+		klog.Warningf("Tree %d is ACTIVE: only entries covered by the tree head being migrated will be copied, consider freezing the tree first", treeID)
+	}
+	return &trillianSource{db: db, treeID: treeID}, nil
+}
+
+// treeHead returns the size and root hash of the tree head to migrate. This is the latest tree head of the
+// given size, if size is non-zero, or the latest tree head otherwise.
+func (s *trillianSource) treeHead(ctx context.Context, size uint64) (uint64, []byte, error) {
+	q, args := "SELECT TreeSize, RootHash FROM TreeHead WHERE TreeId = ? ORDER BY TreeHeadTimestamp DESC LIMIT 1", []any{s.treeID}
+	if size > 0 {
+		q, args = "SELECT TreeSize, RootHash FROM TreeHead WHERE TreeId = ? AND TreeSize = ? ORDER BY TreeHeadTimestamp DESC LIMIT 1", []any{s.treeID, size}
+	}
+	var treeSize uint64
+	var root []byte
+	if err := s.db.QueryRowContext(ctx, q, args...).Scan(&treeSize, &root); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, errors.New("no matching tree head found")
+		}
+		return 0, nil, fmt.Errorf("failed to read tree head: %v", err)
+	}
+	return treeSize, root, nil
+}
+
+// ReadEntryBundle returns the tlog-tiles entry bundle with the given index and partial size, built from the
+// sequenced leaves of the Trillian tree.
+//
+// It is a client.EntryBundleFetcherFunc. The value of each leaf is used as the entry, and an error is returned
+// if the Merkle leaf hash that Trillian stored for it is not the RFC6962 hash of that value, since the Tessera
+// tree would then differ from Trillian's.
+func (s *trillianSource) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	from := i * layout.EntryBundleWidth
+	n := uint64(p)
+	if n == 0 {
+		n = layout.EntryBundleWidth
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT s.SequenceNumber, s.MerkleLeafHash, l.LeafValue
+		FROM SequencedLeafData s JOIN LeafData l ON s.TreeId = l.TreeId AND s.LeafIdentityHash = l.LeafIdentityHash
+		WHERE s.TreeId = ? AND s.SequenceNumber >= ? AND s.SequenceNumber < ?
+		ORDER BY s.SequenceNumber`, s.treeID, from, from+n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leaves [%d, %d): %v", from, from+n, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			klog.Warningf("Failed to close rows: %v", err)
+		}
+	}()
+
+	bundle := make([]byte, 0, n*2)
+	next := from
+	for rows.Next() {
+		var seq uint64
+		var leafHash, value []byte
+		if err := rows.Scan(&seq, &leafHash, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan leaf: %v", err)
+		}
+		if seq != next {
+			return nil, fmt.Errorf("leaf %d is missing from the tree", next)
+		}
+		if len(value) > math.MaxUint16 {
+			return nil, fmt.Errorf("leaf %d has a value of %d bytes, larger than a tlog-tiles entry can be", seq, len(value))
+		}
+		if got := rfc6962.DefaultHasher.HashLeaf(value); !bytes.Equal(got, leafHash) {
+			return nil, fmt.Errorf("leaf %d has Merkle leaf hash %x, but its value hashes to %x", seq, leafHash, got)
+		}
+		bundle = append(bundle, tessera.NewEntry(value).MarshalBundleData(seq)...)
+		next++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read leaves [%d, %d): %v", from, from+n, err)
+	}
+	if next != from+n {
+		return nil, fmt.Errorf("leaf %d is missing from the tree", next)
+	}
+	return bundle, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// export is a command-line tool which writes a verified copy of a tlog-tiles log to a tar archive.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/cmd/internal/archive"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageURL  = flag.String("storage_url", "", "Base tlog-tiles URL of the log to export. Use a file:// URL to export a log stored in a local directory, e.g. by the POSIX storage implementation, without serving it.")
+	bearerToken = flag.String("bearer_token", "", "The bearer token for authorizing HTTP requests to the storage URL, if needed")
+	numWorkers  = flag.Uint("num_workers", 30, "Number of resources to fetch from the log in parallel")
+	origin      = flag.String("origin", "", "Origin of the log to export, if unset, will use the name of the provided public key")
+	pubKey      = flag.String("public_key", "", "Path to a file containing the log's public key")
+	output      = flag.String("output", "", "Path of the tar archive to write")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	src := fetchersFromFlags()
+	v := verifierFromFlags()
+	if *origin == "" {
+		*origin = v.Name()
+	}
+	if *output == "" {
+		klog.Exit("Must provide the --output flag")
+	}
+	// Fetch the checkpoint once, so that the one reported below is the one which was exported.
+	cpRaw, err := src.Checkpoint(ctx)
+	if err != nil {
+		klog.Exitf("Failed to fetch checkpoint: %v", err)
+	}
+	src.Checkpoint = func(context.Context) ([]byte, error) { return cpRaw, nil }
+
+	// Write to a temporary file, so that an archive is only found at the output path if it's complete.
+	tmp := *output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		klog.Exitf("Failed to create archive: %v", err)
+	}
+	w := archive.NewWriter(f)
+	err = client.Mirror(ctx, src, w, client.MirrorOptions{
+		NumWorkers: *numWorkers,
+		Verifier:   v,
+		Origin:     *origin,
+		Progress: func(copied, total uint64) {
+			klog.V(1).Infof("Exported %d of %d entries", copied, total)
+		},
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		if rErr := os.Remove(tmp); rErr != nil {
+			klog.Warningf("Failed to remove %q: %v", tmp, rErr)
+		}
+		klog.Exitf("Failed to export log: %v", err)
+	}
+	if err := os.Rename(tmp, *output); err != nil {
+		klog.Exitf("Failed to rename archive: %v", err)
+	}
+	klog.Infof("Exported log to %q, with checkpoint:\n%s", *output, cpRaw)
+}
+
+func fetchersFromFlags() client.Fetchers {
+	logURL, err := url.Parse(*storageURL)
+	if err != nil {
+		klog.Exitf("Invalid --storage_url %q: %v", *storageURL, err)
+	}
+	switch logURL.Scheme {
+	case "file":
+		f := client.FileFetcher{Root: logURL.Path}
+		return client.Fetchers{Checkpoint: f.ReadCheckpoint, Tile: f.ReadTile, EntryBundle: f.ReadEntryBundle}
+	case "http", "https":
+		f, err := client.NewHTTPFetcher(logURL, nil)
+		if err != nil {
+			klog.Exitf("Failed to create HTTP fetcher: %v", err)
+		}
+		if *bearerToken != "" {
+			f.SetAuthorizationHeader(fmt.Sprintf("Bearer %s", *bearerToken))
+		}
+		return client.Fetchers{Checkpoint: f.ReadCheckpoint, Tile: f.ReadTile, EntryBundle: f.ReadEntryBundle}
+	default:
+		klog.Exitf("Unsupported --storage_url scheme %q, want one of file, http, https", logURL.Scheme)
+	}
+	return client.Fetchers{}
+}
+
+func verifierFromFlags() note.Verifier {
+	if *pubKey == "" {
+		klog.Exit("Must provide the --public_key flag")
+	}
+	b, err := os.ReadFile(*pubKey)
+	if err != nil {
+		klog.Exitf("Failed to read verifier from %q: %v", *pubKey, err)
+	}
+	v, err := f_note.NewVerifier(string(b))
+	if err != nil {
+		klog.Exitf("Invalid verifier in %q: %v", *pubKey, err)
+	}
+	return v
+}
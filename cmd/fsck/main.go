@@ -32,11 +32,12 @@ import (
 )
 
 var (
-	storageURL  = flag.String("storage_url", "", "Base tlog-tiles URL")
+	storageURL  = flag.String("storage_url", "", "Base tlog-tiles URL. Use a file:// URL to check a log stored in a local directory, e.g. by the POSIX storage implementation, without serving it.")
 	bearerToken = flag.String("bearer_token", "", "The bearer token for authorizing HTTP requests to the storage URL, if needed")
 	N           = flag.Uint("N", 1, "The number of workers to use when fetching/comparing resources")
 	origin      = flag.String("origin", "", "Origin of the log to check, if unset, will use the name of the provided public key")
 	pubKey      = flag.String("public_key", "", "Path to a file containing the log's public key")
+	repair      = flag.Bool("repair", false, "Replace missing or corrupt tiles with those re-derived from the log's entry bundles. Only supported for file:// storage URLs, and the log must not be written to while it's being repaired.")
 )
 
 func main() {
@@ -47,20 +48,61 @@ func main() {
 	if err != nil {
 		klog.Exitf("Invalid --storage_url %q: %v", *storageURL, err)
 	}
-	src, err := client.NewHTTPFetcher(logURL, nil)
-	if err != nil {
-		klog.Exitf("Failed to create HTTP fetcher: %v", err)
+	var src fsck.Fetcher
+	var dst fsck.TileWriter
+	switch logURL.Scheme {
+	case "file":
+		src = client.FileFetcher{Root: logURL.Path}
+		if *repair {
+			dst = fsck.FileTileWriter{Root: logURL.Path}
+		}
+	case "http", "https":
+		f, err := client.NewHTTPFetcher(logURL, nil)
+		if err != nil {
+			klog.Exitf("Failed to create HTTP fetcher: %v", err)
+		}
+		if *bearerToken != "" {
+			f.SetAuthorizationHeader(fmt.Sprintf("Bearer %s", *bearerToken))
+		}
+		src = f
+	default:
+		klog.Exitf("Unsupported --storage_url scheme %q, want one of file, http, https", logURL.Scheme)
 	}
-	if *bearerToken != "" {
-		src.SetAuthorizationHeader(fmt.Sprintf("Bearer %s", *bearerToken))
+	if *repair && dst == nil {
+		klog.Exitf("--repair is only supported for file:// storage URLs")
 	}
 	v := verifierFromFlags()
 	if *origin == "" {
 		*origin = v.Name()
 	}
-	if err := fsck.Check(ctx, *origin, v, src, *N, defaultMerkleLeafHasher); err != nil {
+	var r *fsck.Report
+	if dst != nil {
+		r, err = fsck.Repair(ctx, *origin, v, src, dst, *N, defaultMerkleLeafHasher)
+	} else {
+		r, err = fsck.Verify(ctx, *origin, v, src, *N, defaultMerkleLeafHasher)
+	}
+	if err != nil {
 		klog.Exitf("fsck failed: %v", err)
 	}
+	unrepaired := 0
+	for _, p := range r.Problems {
+		state := "corrupt"
+		if p.Missing {
+			state = "missing"
+		}
+		if p.Repaired {
+			state = "repaired"
+		} else {
+			unrepaired++
+		}
+		fmt.Printf("%s\t%s\t%v\n", state, p.Path, p.Err)
+	}
+	for _, u := range r.Unrecoverable {
+		fmt.Printf("unrecoverable\tentries %v\tentry bundle is missing or corrupt\n", u)
+	}
+	if unrepaired > 0 {
+		klog.Exitf("fsck found %d problems with log of size %d", unrepaired, r.Size)
+	}
 }
 
 // defaultMerkleLeafHasher parses a C2SP tlog-tile bundle and returns the Merkle leaf hashes of each entry it contains.
@@ -0,0 +1,262 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive reads and writes tlog-tiles logs stored in a single tar archive.
+//
+// An archive holds the log's checkpoint, tiles, and entry bundles at their tlog-tiles paths, followed by a
+// manifest of their SHA-256 hashes in the format used by sha256sum, so the integrity of an archive can be
+// checked with or without this package.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// ManifestPath is the path in the archive of the manifest, which is always its last file.
+const ManifestPath = "SHA256SUMS"
+
+// Writer writes a log to a tar archive.
+//
+// It is a client.LogWriter, so a log can be archived, and verified as it is, using client.Mirror. Resources
+// may be written concurrently, but each may only be written once.
+type Writer struct {
+	mu      sync.Mutex
+	tw      *tar.Writer
+	modTime time.Time
+	sums    map[string][sha256.Size]byte
+}
+
+// NewWriter returns a Writer which writes an archive to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		tw:      tar.NewWriter(w),
+		modTime: time.Now().UTC().Truncate(time.Second),
+		sums:    make(map[string][sha256.Size]byte),
+	}
+}
+
+func (w *Writer) WriteCheckpoint(_ context.Context, d []byte) error {
+	return w.add(layout.CheckpointPath, d)
+}
+
+func (w *Writer) WriteTile(_ context.Context, l, i uint64, p uint8, d []byte) error {
+	return w.add(layout.TilePath(l, i, p), d)
+}
+
+func (w *Writer) WriteEntryBundle(_ context.Context, i uint64, p uint8, d []byte) error {
+	return w.add(layout.EntriesPath(i, p), d)
+}
+
+// ReadMirrorState always returns os.ErrNotExist, since an archive is written in one go.
+func (w *Writer) ReadMirrorState(_ context.Context) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+// WriteMirrorState discards the state, since an archive is written in one go.
+func (w *Writer) WriteMirrorState(_ context.Context, _ []byte) error {
+	return nil
+}
+
+// Close writes the manifest and the end of the archive. It doesn't close the underlying io.Writer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.sums[layout.CheckpointPath]; !ok {
+		return errors.New("no checkpoint has been written")
+	}
+	b := &bytes.Buffer{}
+	for _, p := range slices.Sorted(maps.Keys(w.sums)) {
+		s := w.sums[p]
+		fmt.Fprintf(b, "%s  %s\n", hex.EncodeToString(s[:]), p)
+	}
+	if err := w.writeFile(ManifestPath, b.Bytes()); err != nil {
+		return err
+	}
+	return w.tw.Close()
+}
+
+func (w *Writer) add(p string, d []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.sums[p]; ok {
+		return fmt.Errorf("%s has already been written", p)
+	}
+	if err := w.writeFile(p, d); err != nil {
+		return err
+	}
+	w.sums[p] = sha256.Sum256(d)
+	return nil
+}
+
+func (w *Writer) writeFile(p string, d []byte) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     p,
+		Mode:     0o644,
+		Size:     int64(len(d)),
+		ModTime:  w.modTime,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return fmt.Errorf("failed to write header for %s: %v", p, err)
+	}
+	if _, err := w.tw.Write(d); err != nil {
+		return fmt.Errorf("failed to write %s: %v", p, err)
+	}
+	return nil
+}
+
+// Reader reads a log from a tar archive.
+//
+// Its ReadCheckpoint, ReadTile, and ReadEntryBundle methods are client fetcher functions, and are safe for
+// concurrent use.
+type Reader struct {
+	r io.ReaderAt
+	// files maps the path of each resource in the archive to the section of r which holds it.
+	files map[string]*io.SectionReader
+}
+
+// NewReader returns a Reader for the uncompressed tar archive in r, which is size bytes long.
+//
+// The whole archive is read, and an error is returned if any of the resources it holds don't match the
+// hashes in its manifest, or are missing from the manifest.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	c := &countingReader{r: io.NewSectionReader(r, 0, size)}
+	tr := tar.NewReader(c)
+	files := make(map[string]*io.SectionReader)
+	sums := make(map[string]string)
+	var manifest []byte
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %v", err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%s is not a regular file", h.Name)
+		}
+		if manifest != nil {
+			return nil, fmt.Errorf("%s follows the manifest", h.Name)
+		}
+		if _, ok := files[h.Name]; ok {
+			return nil, fmt.Errorf("%s appears more than once", h.Name)
+		}
+		off := c.n
+		s := sha256.New()
+		var buf bytes.Buffer
+		dst := io.Writer(s)
+		if h.Name == ManifestPath {
+			dst = &buf
+		}
+		if _, err := io.Copy(dst, tr); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", h.Name, err)
+		}
+		if h.Name == ManifestPath {
+			manifest = buf.Bytes()
+			continue
+		}
+		files[h.Name] = io.NewSectionReader(r, off, h.Size)
+		sums[h.Name] = hex.EncodeToString(s.Sum(nil))
+	}
+	if manifest == nil {
+		return nil, errors.New("archive has no manifest")
+	}
+
+	want, err := parseManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	for p, got := range sums {
+		w, ok := want[p]
+		if !ok {
+			return nil, fmt.Errorf("%s is not in the manifest", p)
+		}
+		if w != got {
+			return nil, fmt.Errorf("%s has SHA-256 hash %s, but the manifest says %s", p, got, w)
+		}
+		delete(want, p)
+	}
+	for p := range want {
+		return nil, fmt.Errorf("%s is in the manifest, but not the archive", p)
+	}
+	return &Reader{r: r, files: files}, nil
+}
+
+// parseManifest returns the hex-encoded hashes of the files in the manifest, keyed by path.
+func parseManifest(m []byte) (map[string]string, error) {
+	r := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(m))
+	for s.Scan() {
+		sum, p, ok := strings.Cut(s.Text(), "  ")
+		if !ok || len(sum) != hex.EncodedLen(sha256.Size) {
+			return nil, fmt.Errorf("invalid manifest line %q", s.Text())
+		}
+		r[p] = sum
+	}
+	return r, s.Err()
+}
+
+func (r *Reader) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return r.read(layout.CheckpointPath)
+}
+
+func (r *Reader) ReadTile(_ context.Context, l, i uint64, p uint8) ([]byte, error) {
+	return r.read(layout.TilePath(l, i, p))
+}
+
+func (r *Reader) ReadEntryBundle(_ context.Context, i uint64, p uint8) ([]byte, error) {
+	return r.read(layout.EntriesPath(i, p))
+}
+
+func (r *Reader) read(p string) ([]byte, error) {
+	s, ok := r.files[p]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+	}
+	d := make([]byte, s.Size())
+	if _, err := s.ReadAt(d, 0); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", p, err)
+	}
+	return d, nil
+}
+
+// countingReader counts the bytes read through it, so that the offsets of files in the archive are known.
+// archive/tar reads no further ahead than the end of the header of the file it returns.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
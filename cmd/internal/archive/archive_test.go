@@ -0,0 +1,128 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+func TestRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	tl := newTestLog(t, 300)
+	opts := client.MirrorOptions{Verifier: tl.SigVerifier, Origin: tl.SigVerifier.Name()}
+	src := client.FileFetcher{Root: tl.Root}
+
+	b := &bytes.Buffer{}
+	w := NewWriter(b)
+	if err := client.Mirror(ctx, client.Fetchers{Checkpoint: src.ReadCheckpoint, Tile: src.ReadTile, EntryBundle: src.ReadEntryBundle}, w, opts); err != nil {
+		t.Fatalf("Mirror to archive: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	archive := b.Bytes()
+
+	for _, test := range []struct {
+		name    string
+		archive func() []byte
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			archive: func() []byte { return archive },
+		}, {
+			name: "corrupt entry",
+			archive: func() []byte {
+				a := bytes.Clone(archive)
+				i := bytes.Index(a, []byte("entry 123"))
+				a[i] = 'E'
+				return a
+			},
+			wantErr: true,
+		}, {
+			name: "file not in manifest",
+			archive: func() []byte {
+				b := &bytes.Buffer{}
+				tw := tar.NewWriter(b)
+				if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "tile/entries/999", Size: 1}); err != nil {
+					t.Fatalf("WriteHeader: %v", err)
+				}
+				if _, err := tw.Write([]byte("x")); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+				if err := tw.Flush(); err != nil {
+					t.Fatalf("Flush: %v", err)
+				}
+				return append(b.Bytes(), archive...)
+			},
+			wantErr: true,
+		}, {
+			name: "no manifest",
+			archive: func() []byte {
+				b := &bytes.Buffer{}
+				w := NewWriter(b)
+				if err := w.WriteCheckpoint(ctx, []byte("checkpoint")); err != nil {
+					t.Fatalf("WriteCheckpoint: %v", err)
+				}
+				if err := w.tw.Close(); err != nil {
+					t.Fatalf("Close: %v", err)
+				}
+				return b.Bytes()
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a := test.archive()
+			r, err := NewReader(bytes.NewReader(a), int64(len(a)))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("NewReader: %v, want error %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// Check every resource in the archive by mirroring it to a new directory.
+			dst := client.FileWriter{Root: t.TempDir()}
+			if err := client.Mirror(ctx, client.Fetchers{Checkpoint: r.ReadCheckpoint, Tile: r.ReadTile, EntryBundle: r.ReadEntryBundle}, dst, opts); err != nil {
+				t.Fatalf("Mirror from archive: %v", err)
+			}
+		})
+	}
+}
+
+func newTestLog(t *testing.T, n int) *testonly.TestLog {
+	t.Helper()
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(uint(n), time.Millisecond))
+	var futures []tessera.IndexFuture
+	for i := range n {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	if _, _, err := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond).AwaitAll(ctx, futures); err != nil {
+		t.Fatalf("AwaitAll: %v", err)
+	}
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	return tl
+}
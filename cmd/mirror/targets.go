@@ -0,0 +1,129 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/cmd/internal/drivers"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// target is a replica of the source log.
+type target interface {
+	// checkpoint returns the source log checkpoint which the replica was last updated to, or an error
+	// wrapping os.ErrNotExist if it's not known.
+	checkpoint(ctx context.Context) ([]byte, error)
+	// sync updates the replica to hold the first size entries of the source log, which have the given
+	// root hash, and are committed to by the verified checkpoint cpRaw.
+	sync(ctx context.Context, size uint64, root []byte, cpRaw []byte) error
+}
+
+// newTarget returns the replica described by the storage URL, which will be updated from src.
+//
+// file:// URLs are replicas which hold exact copies of the source log's checkpoint, tiles, and entry
+// bundles; any other URL is passed to drivers.New and the source log's entries are migrated into it.
+func newTarget(ctx context.Context, storage string, src *client.HTTPFetcher, v note.Verifier, origin string, numWorkers uint) (target, error) {
+	u, err := url.Parse(storage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL: %v", err)
+	}
+	if u.Scheme == "file" {
+		if u.Path == "" {
+			return nil, errors.New("file storage URL has no path")
+		}
+		return &fileTarget{
+			root: u.Path,
+			src:  src,
+			opts: client.MirrorOptions{
+				NumWorkers: numWorkers,
+				Verifier:   v,
+				Origin:     origin,
+				Progress: func(copied, total uint64) {
+					klog.V(1).Infof("Copied %d of %d entries", copied, total)
+				},
+			},
+		}, nil
+	}
+	if !slices.Contains(drivers.Schemes(), u.Scheme) {
+		return nil, fmt.Errorf("unsupported storage URL scheme %q, want one of %s", u.Scheme, schemes())
+	}
+	d, err := drivers.New(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	mt, err := tessera.NewMigrationTarget(ctx, d, tessera.NewMigrationOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration target: %v", err)
+	}
+	return &migrationTarget{mt: mt, src: src, numWorkers: numWorkers}, nil
+}
+
+// fileTarget is a replica which holds an exact copy of the source log's resources in a directory, which can
+// be served as a tlog-tiles log by any static file server.
+type fileTarget struct {
+	root string
+	src  *client.HTTPFetcher
+	opts client.MirrorOptions
+}
+
+func (t *fileTarget) checkpoint(ctx context.Context) ([]byte, error) {
+	return client.FileFetcher{Root: t.root}.ReadCheckpoint(ctx)
+}
+
+// sync uses client.Mirror to copy and verify the resources added to the source log since the last sync, and
+// then writes cpRaw as the replica's checkpoint.
+func (t *fileTarget) sync(ctx context.Context, _ uint64, _ []byte, cpRaw []byte) error {
+	src := client.Fetchers{
+		// Mirror the checkpoint which has already been checked for consistency, rather than fetching a new one.
+		Checkpoint:  func(context.Context) ([]byte, error) { return cpRaw, nil },
+		Tile:        t.src.ReadTile,
+		EntryBundle: t.src.ReadEntryBundle,
+	}
+	return client.Mirror(ctx, src, client.FileWriter{Root: t.root}, t.opts)
+}
+
+// migrationTarget is a replica which is a Tessera log, into which the source log's entries are migrated.
+//
+// The replica's tiles are built by its driver, and it doesn't hold the source log's checkpoint.
+type migrationTarget struct {
+	mt         *tessera.MigrationTarget
+	src        *client.HTTPFetcher
+	numWorkers uint
+}
+
+func (t *migrationTarget) checkpoint(_ context.Context) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+// sync migrates the entries added to the source log since the last sync, and fails if the resulting tree's
+// root hash isn't the one given.
+func (t *migrationTarget) sync(ctx context.Context, size uint64, root []byte, _ []byte) error {
+	return t.mt.Migrate(ctx, t.numWorkers, size, root, t.src.ReadEntryBundle)
+}
+
+// schemes returns the supported storage URL schemes, for use in help text.
+func schemes() string {
+	return strings.Join(append([]string{"file"}, drivers.Schemes()...), ", ")
+}
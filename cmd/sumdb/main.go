@@ -0,0 +1,105 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sumdb runs a Go checksum database, backed by a Tessera log stored on a posix filesystem.
+//
+// Module versions which are looked up are fetched from the configured Go module proxy, hashed, and
+// added to the log.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"github.com/transparency-dev/tessera/sumdb"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	storageDir         = flag.String("storage_dir", "", "Root directory to store the log and its module index.")
+	listen             = flag.String("listen", ":2025", "Address:port to listen on")
+	privKeyFile        = flag.String("private_key", "", "Location of private key file. If unset, uses the contents of the LOG_PRIVATE_KEY environment variable.")
+	proxyURL           = flag.String("proxy_url", "", "URL of the Go module proxy from which module versions are fetched, e.g. https://proxy.golang.org")
+	checkpointInterval = flag.Duration("checkpoint_interval", time.Second, "Interval between checkpoints. Lookups of new module versions are answered once their record has been published.")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	ctx := context.Background()
+
+	if *storageDir == "" || *proxyURL == "" {
+		klog.Exit("--storage_dir and --proxy_url must be set")
+	}
+	s := getSignerOrDie()
+
+	driver, err := posix.New(ctx, posix.Config{Path: filepath.Join(*storageDir, "log")})
+	if err != nil {
+		klog.Exitf("Failed to construct storage: %v", err)
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithCheckpointFormatter(sumdb.CheckpointFormatter).
+		WithCheckpointInterval(*checkpointInterval).
+		WithBatching(256, 100*time.Millisecond))
+	if err != nil {
+		klog.Exit(err)
+	}
+	index, err := sumdb.NewFileIndex(filepath.Join(*storageDir, "index"))
+	if err != nil {
+		klog.Exitf("Failed to construct index: %v", err)
+	}
+	fetch, err := sumdb.ProxyFetcher(*proxyURL, nil)
+	if err != nil {
+		klog.Exit(err)
+	}
+	srv := sumdb.NewServer(appender, reader, tessera.NewPublicationAwaiter(ctx, reader.ReadCheckpoint, 100*time.Millisecond), index, fetch)
+
+	klog.Infof("Serving checksum database %q on %s", s.Name(), *listen)
+	if err := http.ListenAndServe(*listen, srv.Handler()); err != nil {
+		if err := shutdown(ctx); err != nil {
+			klog.Exit(err)
+		}
+		klog.Exitf("ListenAndServe: %v", err)
+	}
+}
+
+// Read log private key from file or environment variable
+func getSignerOrDie() note.Signer {
+	var privKey string
+	if len(*privKeyFile) > 0 {
+		k, err := os.ReadFile(*privKeyFile)
+		if err != nil {
+			klog.Exitf("Failed to read private key: %v", err)
+		}
+		privKey = string(k)
+	} else {
+		privKey = os.Getenv("LOG_PRIVATE_KEY")
+		if len(privKey) == 0 {
+			klog.Exit("Supply private key file path using --private_key or set LOG_PRIVATE_KEY environment variable")
+		}
+	}
+	s, err := note.NewSigner(privKey)
+	if err != nil {
+		klog.Exitf("Failed to instantiate signer: %q", err)
+	}
+	return s
+}
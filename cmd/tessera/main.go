@@ -0,0 +1,423 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// tessera is a command-line tool for verifying tlog-tiles based logs from shell scripts and CI,
+// without needing to write any Go.
+//
+// Usage:
+//
+//	tessera [global flags] <command> [command flags]
+//
+// Each command exits with a non-zero status if the log fails verification.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	f_log "github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/fsck"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// command is a subcommand of the tool.
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"verify-checkpoint":  {"Fetch the log's checkpoint, or read one from a file, and verify its signature.", verifyCheckpoint},
+	"verify-inclusion":   {"Verify that an entry, identified by its index or leaf hash, is included in the log.", verifyInclusion},
+	"verify-consistency": {"Verify that an earlier checkpoint is consistent with a later one.", verifyConsistency},
+	"mirror":             {"Copy the log into a local directory, verifying every resource as it's copied.", mirror},
+	"fsck":               {"Check the integrity of every resource in the log.", runFsck},
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(context.Background(), flag.Args()[1:]); err != nil {
+		klog.Exitf("%s: %v", flag.Arg(0), err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [global flags] <command> [command flags]\n\nCommands:\n", os.Args[0])
+	for _, n := range []string{"verify-checkpoint", "verify-inclusion", "verify-consistency", "mirror", "fsck"} {
+		fmt.Fprintf(os.Stderr, "  %-20s%s\n", n, commands[n].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> --help' for the flags accepted by a command.\n\nGlobal flags:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// logFlags holds the flags common to all commands, which identify the log and how to verify it.
+type logFlags struct {
+	storageURL  *string
+	bearerToken *string
+	origin      *string
+	pubKey      *string
+}
+
+func newLogFlags(fs *flag.FlagSet) logFlags {
+	return logFlags{
+		storageURL:  fs.String("storage_url", "", "Base tlog-tiles URL. Use a file:// URL to read a log stored in a local directory, e.g. by the POSIX storage implementation, without serving it."),
+		bearerToken: fs.String("bearer_token", "", "The bearer token for authorizing HTTP requests to the storage URL, if needed"),
+		origin:      fs.String("origin", "", "Origin of the log, if unset, will use the name of the provided public key"),
+		pubKey:      fs.String("public_key", "", "Path to a file containing the log's public key"),
+	}
+}
+
+// fetcher returns a fetcher for the log at --storage_url.
+func (l logFlags) fetcher() (fsck.Fetcher, error) {
+	if *l.storageURL == "" {
+		return nil, fmt.Errorf("must provide the --storage_url flag")
+	}
+	logURL, err := url.Parse(*l.storageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --storage_url %q: %v", *l.storageURL, err)
+	}
+	switch logURL.Scheme {
+	case "file":
+		return client.FileFetcher{Root: logURL.Path}, nil
+	case "http", "https":
+		f, err := client.NewHTTPFetcher(logURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP fetcher: %v", err)
+		}
+		if *l.bearerToken != "" {
+			f.SetAuthorizationHeader(fmt.Sprintf("Bearer %s", *l.bearerToken))
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unsupported --storage_url scheme %q, want one of file, http, https", logURL.Scheme)
+	}
+}
+
+// verifier returns the log's verifier and origin.
+func (l logFlags) verifier() (note.Verifier, string, error) {
+	if *l.pubKey == "" {
+		return nil, "", fmt.Errorf("must provide the --public_key flag")
+	}
+	b, err := os.ReadFile(*l.pubKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read verifier from %q: %v", *l.pubKey, err)
+	}
+	v, err := f_note.NewVerifier(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid verifier in %q: %v", *l.pubKey, err)
+	}
+	origin := *l.origin
+	if origin == "" {
+		origin = v.Name()
+	}
+	return v, origin, nil
+}
+
+// checkpoint returns the verified checkpoint read from the file at path or, if path is empty,
+// fetched from the log.
+func (l logFlags) checkpoint(ctx context.Context, path string) (*f_log.Checkpoint, []byte, error) {
+	v, origin, err := l.verifier()
+	if err != nil {
+		return nil, nil, err
+	}
+	var raw []byte
+	if path != "" {
+		if raw, err = os.ReadFile(path); err != nil {
+			return nil, nil, fmt.Errorf("failed to read checkpoint: %v", err)
+		}
+	} else {
+		f, err := l.fetcher()
+		if err != nil {
+			return nil, nil, err
+		}
+		if raw, err = f.ReadCheckpoint(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch checkpoint: %v", err)
+		}
+	}
+	cp, _, _, err := f_log.ParseCheckpoint(raw, origin, v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify checkpoint: %v", err)
+	}
+	return cp, raw, nil
+}
+
+func verifyCheckpoint(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-checkpoint", flag.ExitOnError)
+	l := newLogFlags(fs)
+	cpFile := fs.String("checkpoint", "", "Path to a file containing the checkpoint to verify. If unset, the log's current checkpoint is fetched from --storage_url.")
+	out := fs.String("out", "", "If set, the verified checkpoint is written to this file, e.g. for use with verify-consistency later.")
+	_ = fs.Parse(args)
+
+	cp, raw, err := l.checkpoint(ctx, *cpFile)
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, raw, 0o644); err != nil {
+			return fmt.Errorf("failed to write checkpoint: %v", err)
+		}
+	}
+	fmt.Printf("%s\n%d\n%s\n", cp.Origin, cp.Size, base64.StdEncoding.EncodeToString(cp.Hash))
+	return nil
+}
+
+func verifyInclusion(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-inclusion", flag.ExitOnError)
+	l := newLogFlags(fs)
+	cpFile := fs.String("checkpoint", "", "Path to a file containing the checkpoint to verify inclusion in. If unset, the log's current checkpoint is fetched from --storage_url.")
+	index := fs.Int64("index", -1, "Index of the entry. If unset, the log's leaf hashes are searched for the entry given by --leaf_hash or --entry_file.")
+	leafHash := fs.String("leaf_hash", "", "Hex-encoded RFC 6962 leaf hash of the entry.")
+	entryFile := fs.String("entry_file", "", "Path to a file containing the entry, whose RFC 6962 leaf hash is used.")
+	_ = fs.Parse(args)
+
+	f, err := l.fetcher()
+	if err != nil {
+		return err
+	}
+	cp, _, err := l.checkpoint(ctx, *cpFile)
+	if err != nil {
+		return err
+	}
+
+	var h []byte
+	switch {
+	case *leafHash != "" && *entryFile != "":
+		return fmt.Errorf("only one of --leaf_hash and --entry_file may be set")
+	case *leafHash != "":
+		if h, err = hex.DecodeString(*leafHash); err != nil || len(h) != rfc6962.DefaultHasher.Size() {
+			return fmt.Errorf("invalid --leaf_hash %q: must be %d hex-encoded bytes", *leafHash, rfc6962.DefaultHasher.Size())
+		}
+	case *entryFile != "":
+		e, err := os.ReadFile(*entryFile)
+		if err != nil {
+			return fmt.Errorf("failed to read entry: %v", err)
+		}
+		h = rfc6962.DefaultHasher.HashLeaf(e)
+	case *index < 0:
+		return fmt.Errorf("must provide at least one of --index, --leaf_hash, or --entry_file")
+	}
+
+	var idx uint64
+	switch {
+	case *index >= 0:
+		idx = uint64(*index)
+		if idx >= cp.Size {
+			return fmt.Errorf("index %d is not covered by checkpoint of size %d", idx, cp.Size)
+		}
+		if h == nil {
+			// Only an index was given, so take the leaf hash from the log, and verify that.
+			hs, err := client.FetchLeafHashes(ctx, f.ReadTile, idx, 1, cp.Size)
+			if err != nil {
+				return fmt.Errorf("failed to fetch leaf hash: %v", err)
+			}
+			h = hs[0]
+		}
+	default:
+		if idx, err = findLeaf(ctx, f, cp.Size, h); err != nil {
+			return err
+		}
+	}
+
+	pb, err := client.NewProofBuilder(ctx, cp.Size, f.ReadTile)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	if _, err := pb.VerifyInclusion(ctx, idx, h, cp.Hash); err != nil {
+		var e client.ErrInclusion
+		if errors.As(err, &e) {
+			return fmt.Errorf("leaf %x is not included at index %d in tree of size %d: %v\n%v", h, idx, cp.Size, e.Wrapped, e.Report)
+		}
+		return fmt.Errorf("failed to build inclusion proof: %v", err)
+	}
+	fmt.Printf("Leaf %x is included at index %d in tree of size %d\n", h, idx, cp.Size)
+	return nil
+}
+
+// findLeaf searches the level 0 tiles of a log of the given size for the leaf hash h, and returns its index.
+func findLeaf(ctx context.Context, f fsck.Fetcher, size uint64, h []byte) (uint64, error) {
+	for first := uint64(0); first < size; first += layout.TileWidth {
+		end := min(first+layout.TileWidth, size)
+		hs, err := client.FetchLeafHashes(ctx, f.ReadTile, first, end-first, size)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch leaf hashes [%d, %d): %v", first, end, err)
+		}
+		for i, lh := range hs {
+			if bytes.Equal(lh, h) {
+				return first + uint64(i), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("leaf %x not found in tree of size %d", h, size)
+}
+
+func verifyConsistency(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-consistency", flag.ExitOnError)
+	l := newLogFlags(fs)
+	oldFile := fs.String("old_checkpoint", "", "Path to a file containing the earlier checkpoint.")
+	newFile := fs.String("new_checkpoint", "", "Path to a file containing the later checkpoint. If unset, the log's current checkpoint is fetched from --storage_url.")
+	out := fs.String("out", "", "If set, the later checkpoint is written to this file once it's been verified, e.g. to use as --old_checkpoint next time.")
+	_ = fs.Parse(args)
+
+	if *oldFile == "" {
+		return fmt.Errorf("must provide the --old_checkpoint flag")
+	}
+	oldCP, _, err := l.checkpoint(ctx, *oldFile)
+	if err != nil {
+		return fmt.Errorf("old checkpoint: %v", err)
+	}
+	newCP, newRaw, err := l.checkpoint(ctx, *newFile)
+	if err != nil {
+		return fmt.Errorf("new checkpoint: %v", err)
+	}
+
+	switch {
+	case oldCP.Size > newCP.Size:
+		return fmt.Errorf("old checkpoint size %d is larger than new checkpoint size %d", oldCP.Size, newCP.Size)
+	case oldCP.Size == newCP.Size:
+		if !bytes.Equal(oldCP.Hash, newCP.Hash) {
+			return fmt.Errorf("checkpoints of size %d have different root hashes %x and %x", oldCP.Size, oldCP.Hash, newCP.Hash)
+		}
+	case oldCP.Size > 0:
+		f, err := l.fetcher()
+		if err != nil {
+			return err
+		}
+		pb, err := client.NewProofBuilder(ctx, newCP.Size, f.ReadTile)
+		if err != nil {
+			return fmt.Errorf("failed to create proof builder: %v", err)
+		}
+		p, err := pb.ConsistencyProof(ctx, oldCP.Size, newCP.Size)
+		if err != nil {
+			return fmt.Errorf("failed to build consistency proof: %v", err)
+		}
+		if err := proof.VerifyConsistency(rfc6962.DefaultHasher, oldCP.Size, newCP.Size, p, oldCP.Hash, newCP.Hash); err != nil {
+			return fmt.Errorf("tree of size %d is not consistent with tree of size %d: %v", oldCP.Size, newCP.Size, err)
+		}
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, newRaw, 0o644); err != nil {
+			return fmt.Errorf("failed to write checkpoint: %v", err)
+		}
+	}
+	fmt.Printf("Tree of size %d is consistent with tree of size %d\n", oldCP.Size, newCP.Size)
+	return nil
+}
+
+func mirror(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("mirror", flag.ExitOnError)
+	l := newLogFlags(fs)
+	dest := fs.String("dest_dir", "", "Directory to copy the log into. Re-running the command against the same directory copies only the new entries.")
+	N := fs.Uint("N", 1, "The number of workers to use when fetching resources")
+	_ = fs.Parse(args)
+
+	if *dest == "" {
+		return fmt.Errorf("must provide the --dest_dir flag")
+	}
+	f, err := l.fetcher()
+	if err != nil {
+		return err
+	}
+	v, origin, err := l.verifier()
+	if err != nil {
+		return err
+	}
+	src := client.Fetchers{Checkpoint: f.ReadCheckpoint, Tile: f.ReadTile, EntryBundle: f.ReadEntryBundle}
+	var size uint64
+	opts := client.MirrorOptions{
+		NumWorkers: *N,
+		Verifier:   v,
+		Origin:     origin,
+		Progress:   func(_, total uint64) { size = total },
+	}
+	if err := client.Mirror(ctx, src, client.FileWriter{Root: *dest}, opts); err != nil {
+		return err
+	}
+	fmt.Printf("Mirrored log of size %d to %s\n", size, *dest)
+	return nil
+}
+
+func runFsck(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	l := newLogFlags(fs)
+	N := fs.Uint("N", 1, "The number of workers to use when fetching/comparing resources")
+	_ = fs.Parse(args)
+
+	f, err := l.fetcher()
+	if err != nil {
+		return err
+	}
+	v, origin, err := l.verifier()
+	if err != nil {
+		return err
+	}
+	r, err := fsck.Verify(ctx, origin, v, f, *N, defaultMerkleLeafHasher)
+	if err != nil {
+		return err
+	}
+	for _, p := range r.Problems {
+		state := "corrupt"
+		if p.Missing {
+			state = "missing"
+		}
+		fmt.Printf("%s\t%s\t%v\n", state, p.Path, p.Err)
+	}
+	for _, u := range r.Unrecoverable {
+		fmt.Printf("unrecoverable\tentries %v\tentry bundle is missing or corrupt\n", u)
+	}
+	if len(r.Problems) > 0 {
+		return fmt.Errorf("found %d problems with log of size %d", len(r.Problems), r.Size)
+	}
+	fmt.Printf("Log of size %d is OK\n", r.Size)
+	return nil
+}
+
+// defaultMerkleLeafHasher parses a C2SP tlog-tile bundle and returns the Merkle leaf hashes of each entry it contains.
+func defaultMerkleLeafHasher(bundle []byte) ([][]byte, error) {
+	eb := &api.EntryBundle{}
+	if err := eb.UnmarshalText(bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal: %v", err)
+	}
+	r := make([][]byte, 0, len(eb.Entries))
+	for _, e := range eb.Entries {
+		h := rfc6962.DefaultHasher.HashLeaf(e)
+		r = append(r, h[:])
+	}
+	return r, nil
+}
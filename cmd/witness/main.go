@@ -0,0 +1,126 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// witness is a server which cosigns checkpoints for a set of logs using the tlog-witness protocol.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/tessera/witness"
+	"k8s.io/klog/v2"
+)
+
+var (
+	listen     = flag.String("listen", ":2025", "Address to listen on")
+	privateKey = flag.String("private_key", "", "Path to a file containing the witness's note signer key")
+	logsFile   = flag.String("logs", "", "Path to a file containing the verifier keys of the logs to witness, one per line. The origin of each log is the name of its key")
+	stateDir   = flag.String("state_dir", "", "Directory in which to store the latest cosigned checkpoint for each log")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if *stateDir == "" {
+		klog.Exit("Must provide the --state_dir flag")
+	}
+	store, err := witness.NewFileStore(*stateDir)
+	if err != nil {
+		klog.Exitf("Failed to create state store: %v", err)
+	}
+	signer, pub := signerFromFlags()
+	w, err := witness.New(signer, store, logsFromFlags()...)
+	if err != nil {
+		klog.Exitf("Failed to create witness: %v", err)
+	}
+
+	// The endpoint is also served under the hex SHA-256 hash of the witness's public key, which is where
+	// tessera.NewWitness expects to find it.
+	prefix := fmt.Sprintf("/%x", sha256.Sum256(pub))
+	mux := http.NewServeMux()
+	mux.Handle("/", w.Handler())
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, w.Handler()))
+
+	klog.Infof("Witness %q listening on %s", signer.Name(), *listen)
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if err := srv.ListenAndServe(); err != nil {
+		klog.Exitf("ListenAndServe: %v", err)
+	}
+}
+
+// signerFromFlags returns the witness's cosignature/v1 signer, and its Ed25519 public key.
+func signerFromFlags() (*f_note.Signer, ed25519.PublicKey) {
+	if *privateKey == "" {
+		klog.Exit("Must provide the --private_key flag")
+	}
+	b, err := os.ReadFile(*privateKey)
+	if err != nil {
+		klog.Exitf("Failed to read private key from %q: %v", *privateKey, err)
+	}
+	skey := strings.TrimSpace(string(b))
+	s, err := f_note.NewSignerForCosignatureV1(skey)
+	if err != nil {
+		klog.Exitf("Invalid private key in %q: %v", *privateKey, err)
+	}
+	// The signer key is PRIVATE+KEY+<name>+<hash>+<base64(alg || seed)>.
+	parts := strings.Split(skey, "+")
+	k, err := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+	if err != nil || len(k) != 1+ed25519.SeedSize {
+		klog.Exitf("Invalid private key in %q", *privateKey)
+	}
+	return s, ed25519.NewKeyFromSeed(k[1:]).Public().(ed25519.PublicKey)
+}
+
+func logsFromFlags() []witness.LogConfig {
+	if *logsFile == "" {
+		klog.Exit("Must provide the --logs flag")
+	}
+	b, err := os.ReadFile(*logsFile)
+	if err != nil {
+		klog.Exitf("Failed to read logs from %q: %v", *logsFile, err)
+	}
+	var logs []witness.LogConfig
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		v, err := f_note.NewVerifier(l)
+		if err != nil {
+			klog.Exitf("Invalid log verifier key %q in %q: %v", l, *logsFile, err)
+		}
+		logs = append(logs, witness.LogConfig{Origin: v.Name(), Verifier: v})
+	}
+	if len(logs) == 0 {
+		klog.Exitf("No logs found in %q", *logsFile)
+	}
+	return logs
+}
@@ -0,0 +1,352 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config defines a file format for configuring a Tessera log, and functions for loading it.
+//
+// Configuration files are YAML (of which JSON is a subset), for example:
+//
+//	origin: example.com/log
+//	storage: posix:///var/lib/log
+//	private_key_file: /etc/log/private.key
+//	checkpoint_interval: 2s
+//	batching:
+//	  max_size: 256
+//	  max_age: 1s
+//	limits:
+//	  max_add_qps: 1000
+//	witnesses:
+//	  threshold: 1
+//	  witnesses:
+//	  - vkey: witness.example.com+1c2b4d1e+AaVrE0Ygw9B0mleAUbjaOMpY6Tf8V2qDRLmZdaMFA1yr
+//	    url: https://witness.example.com/
+//
+// Fields which aren't set leave the corresponding Tessera defaults in place.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/witness/policy"
+	"golang.org/x/mod/sumdb/note"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of a single log.
+type Config struct {
+	// Origin is the origin line of the log's checkpoints, which must match the name of its private key.
+	Origin string `yaml:"origin"`
+	// Storage is the URL of the log's storage, e.g. posix:///var/lib/log or gcp://bucket?spanner=...
+	//
+	// The scheme selects the storage driver; interpretation of the rest of the URL is up to the driver.
+	Storage string `yaml:"storage"`
+
+	// PrivateKeyFile is the location of the note-formatted private key used to sign checkpoints.
+	PrivateKeyFile string `yaml:"private_key_file"`
+	// PrivateKeyEnv is the name of an environment variable containing the private key, used if
+	// PrivateKeyFile isn't set.
+	PrivateKeyEnv string `yaml:"private_key_env"`
+	// AdditionalPrivateKeyFiles are the locations of private keys which also sign checkpoints, e.g. during
+	// key rotation.
+	AdditionalPrivateKeyFiles []string `yaml:"additional_private_key_files"`
+
+	// CheckpointInterval is how frequently checkpoints are published.
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"`
+	// CheckpointGrowthTrigger, if set, is the number of entries by which the tree must grow for a new checkpoint
+	// to be published before the checkpoint interval has passed.
+	CheckpointGrowthTrigger uint64 `yaml:"checkpoint_growth_trigger"`
+	// GarbageCollectionInterval is how frequently partial tiles and bundles are garbage collected.
+	GarbageCollectionInterval time.Duration `yaml:"garbage_collection_interval"`
+	Batching                  Batching      `yaml:"batching"`
+	Antispam                  Antispam      `yaml:"antispam"`
+	Limits                    Limits        `yaml:"limits"`
+	Witnesses                 Witnesses     `yaml:"witnesses"`
+
+	// LogVerbosity, if set, overrides the klog verbosity set by the -v flag.
+	LogVerbosity *int `yaml:"log_verbosity"`
+}
+
+// Batching configures how entries are grouped to be sequenced.
+type Batching struct {
+	// MaxSize is the maximum number of entries in each batch.
+	MaxSize uint `yaml:"max_size"`
+	// MaxAge is the maximum time an entry waits to be sequenced.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// Antispam configures the suppression of duplicate entries.
+type Antispam struct {
+	// InMemoryEntries is the number of recently added entries remembered in memory, or zero to disable antispam.
+	InMemoryEntries uint `yaml:"in_memory_entries"`
+}
+
+// Limits configures the limits applied to entries being added to the log.
+type Limits struct {
+	// MaxAddQPS is the maximum rate at which entries are accepted.
+	MaxAddQPS float64 `yaml:"max_add_qps"`
+	// MaxUnintegrated is the maximum number of entries which may be sequenced but not yet integrated.
+	MaxUnintegrated uint64 `yaml:"max_unintegrated"`
+	// MaxOutstanding is the maximum number of entries which may be waiting to be sequenced.
+	MaxOutstanding uint `yaml:"max_outstanding"`
+	// MaxEntrySize is the maximum size in bytes of an entry.
+	MaxEntrySize uint `yaml:"max_entry_size"`
+}
+
+// Witnesses configures the witnesses which must cosign checkpoints before they're published.
+type Witnesses struct {
+	// Threshold is the number of witnesses which must cosign each checkpoint.
+	Threshold int `yaml:"threshold"`
+	// Witnesses are the witnesses which may cosign checkpoints.
+	Witnesses []Witness `yaml:"witnesses"`
+	// Policy is a witness policy, in the format described by the witness/policy package, which may be
+	// used instead of Threshold and Witnesses to express more complex quorums.
+	// Each witness named by the policy must have a URL.
+	Policy string `yaml:"policy"`
+	// FailOpen allows checkpoints to be published if the threshold can't be met.
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// Witness identifies a single witness.
+type Witness struct {
+	// VKey is the witness's note-formatted verifier key.
+	VKey string `yaml:"vkey"`
+	// URL is the witness's base URL.
+	URL string `yaml:"url"`
+}
+
+// Load reads and validates the configuration in the file at path.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	c, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Parse parses and validates the configuration in b.
+//
+// Unknown fields are rejected, so that typos don't silently leave defaults in place.
+func Parse(b []byte) (*Config, error) {
+	c := &Config{}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error if the configuration is incomplete or inconsistent.
+func (c *Config) Validate() error {
+	errs := []error{}
+	if c.Origin == "" {
+		errs = append(errs, errors.New("origin must be set"))
+	}
+	if c.Storage == "" {
+		errs = append(errs, errors.New("storage must be set"))
+	} else if u, err := url.Parse(c.Storage); err != nil || u.Scheme == "" {
+		errs = append(errs, fmt.Errorf("storage %q must be a URL with a scheme selecting the driver", c.Storage))
+	}
+	if c.PrivateKeyFile == "" && c.PrivateKeyEnv == "" {
+		errs = append(errs, errors.New("one of private_key_file or private_key_env must be set"))
+	}
+	for _, d := range []struct {
+		name string
+		v    time.Duration
+	}{
+		{"checkpoint_interval", c.CheckpointInterval},
+		{"garbage_collection_interval", c.GarbageCollectionInterval},
+		{"batching.max_age", c.Batching.MaxAge},
+	} {
+		if d.v < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", d.name))
+		}
+	}
+	if c.LogVerbosity != nil && *c.LogVerbosity < 0 {
+		errs = append(errs, errors.New("log_verbosity must not be negative"))
+	}
+	if c.Limits.MaxAddQPS < 0 {
+		errs = append(errs, errors.New("limits.max_add_qps must not be negative"))
+	}
+	if w := c.Witnesses; w.Policy != "" {
+		if len(w.Witnesses) > 0 || w.Threshold != 0 {
+			errs = append(errs, errors.New("witnesses.policy must not be set together with witnesses.threshold or witnesses.witnesses"))
+		} else if _, err := c.witnessGroup(); err != nil {
+			errs = append(errs, err)
+		}
+	} else if len(w.Witnesses) > 0 || w.Threshold != 0 {
+		if w.Threshold < 1 || w.Threshold > len(w.Witnesses) {
+			errs = append(errs, fmt.Errorf("witnesses.threshold must be between 1 and the number of witnesses (%d)", len(w.Witnesses)))
+		}
+		for i, wit := range w.Witnesses {
+			if _, err := note.NewVerifier(wit.VKey); err != nil {
+				errs = append(errs, fmt.Errorf("witnesses[%d].vkey is invalid: %v", i, err))
+			}
+			if u, err := url.Parse(wit.URL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("witnesses[%d].url %q is invalid", i, wit.URL))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %v", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Signers returns the signers for the log's private keys, checking that the primary one matches Origin.
+func (c *Config) Signers() (note.Signer, []note.Signer, error) {
+	var k string
+	if c.PrivateKeyFile != "" {
+		b, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read private key: %v", err)
+		}
+		k = string(b)
+	} else if k = os.Getenv(c.PrivateKeyEnv); k == "" {
+		return nil, nil, fmt.Errorf("environment variable %s does not contain a private key", c.PrivateKeyEnv)
+	}
+	s, err := note.NewSigner(strings.TrimSpace(k))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create signer: %v", err)
+	}
+	if s.Name() != c.Origin {
+		return nil, nil, fmt.Errorf("private key is for %q, but origin is %q", s.Name(), c.Origin)
+	}
+	a := []note.Signer{}
+	for _, p := range c.AdditionalPrivateKeyFiles {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read additional private key %q: %v", p, err)
+		}
+		as, err := note.NewSigner(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create signer from %q: %v", p, err)
+		}
+		a = append(a, as)
+	}
+	return s, a, nil
+}
+
+// AppendOptions returns Tessera AppendOptions configured as described by c.
+//
+// Callers may set further options on the returned value before passing it to tessera.NewAppender.
+func (c *Config) AppendOptions() (*tessera.AppendOptions, error) {
+	s, a, err := c.Signers()
+	if err != nil {
+		return nil, err
+	}
+	o := tessera.NewAppendOptions().WithCheckpointSigner(s, a...)
+	if c.CheckpointInterval > 0 {
+		o.WithCheckpointInterval(c.CheckpointInterval)
+	}
+	if c.CheckpointGrowthTrigger > 0 {
+		o.WithCheckpointGrowthTrigger(c.CheckpointGrowthTrigger)
+	}
+	if c.GarbageCollectionInterval > 0 {
+		o.WithGarbageCollectionInterval(c.GarbageCollectionInterval)
+	}
+	if b := c.Batching; b.MaxSize > 0 || b.MaxAge > 0 {
+		maxSize, maxAge := b.MaxSize, b.MaxAge
+		if maxSize == 0 {
+			maxSize = tessera.DefaultBatchMaxSize
+		}
+		if maxAge == 0 {
+			maxAge = tessera.DefaultBatchMaxAge
+		}
+		o.WithBatching(maxSize, maxAge)
+	}
+	if c.Antispam.InMemoryEntries > 0 {
+		o.WithAntispam(c.Antispam.InMemoryEntries, nil)
+	}
+	if l := c.Limits; l.MaxAddQPS > 0 {
+		o.WithMaxAddQPS(l.MaxAddQPS)
+	}
+	if l := c.Limits; l.MaxUnintegrated > 0 {
+		o.WithMaxUnintegrated(l.MaxUnintegrated)
+	}
+	if l := c.Limits; l.MaxOutstanding > 0 {
+		o.WithPushback(l.MaxOutstanding)
+	}
+	if l := c.Limits; l.MaxEntrySize > 0 {
+		o.WithMaxEntrySize(l.MaxEntrySize)
+	}
+	if w := c.Witnesses; len(w.Witnesses) > 0 || w.Policy != "" {
+		g, err := c.witnessGroup()
+		if err != nil {
+			return nil, err
+		}
+		o.WithWitnesses(g, &tessera.WitnessOptions{FailOpen: w.FailOpen})
+	}
+	return o, nil
+}
+
+// witnessGroup returns the WitnessGroup described by c.Witnesses.
+func (c *Config) witnessGroup() (tessera.WitnessGroup, error) {
+	if c.Witnesses.Policy != "" {
+		p, err := policy.Parse([]byte(c.Witnesses.Policy))
+		if err != nil {
+			return tessera.WitnessGroup{}, fmt.Errorf("witnesses.policy is invalid: %v", err)
+		}
+		g, err := tessera.NewWitnessGroupFromPolicy(p)
+		if err != nil {
+			return tessera.WitnessGroup{}, fmt.Errorf("witnesses.policy is invalid: %v", err)
+		}
+		return g, nil
+	}
+	g := tessera.WitnessGroup{N: c.Witnesses.Threshold}
+	for i, wit := range c.Witnesses.Witnesses {
+		u, err := url.Parse(wit.URL)
+		if err != nil {
+			return g, fmt.Errorf("witnesses[%d].url is invalid: %v", i, err)
+		}
+		nw, err := tessera.NewWitness(wit.VKey, u)
+		if err != nil {
+			return g, fmt.Errorf("witnesses[%d]: %v", i, err)
+		}
+		g.Components = append(g.Components, nw)
+	}
+	return g, nil
+}
+
+// ApplyLogVerbosity sets the klog verbosity to LogVerbosity, if it's set.
+//
+// The -v flag must have been registered with klog.InitFlags.
+func (c *Config) ApplyLogVerbosity() error {
+	if c.LogVerbosity == nil {
+		return nil
+	}
+	f := flag.Lookup("v")
+	if f == nil {
+		return errors.New("klog flags are not registered")
+	}
+	if err := f.Value.Set(strconv.Itoa(*c.LogVerbosity)); err != nil {
+		return fmt.Errorf("failed to set log verbosity: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestParse(t *testing.T) {
+	_, wvkey, err := note.GenerateKey(nil, "witness")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	for _, test := range []struct {
+		name    string
+		config  string
+		want    func(*Config) bool
+		wantErr string
+	}{
+		{
+			name: "minimal",
+			config: `
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+`,
+			want: func(c *Config) bool { return c.Origin == "example.com/log" && c.CheckpointInterval == 0 },
+		}, {
+			name: "full",
+			config: fmt.Sprintf(`
+origin: example.com/log
+storage: mysql://user:password@db:3306/tessera
+private_key_env: LOG_PRIVATE_KEY
+additional_private_key_files: [/tmp/old]
+checkpoint_interval: 2s
+checkpoint_growth_trigger: 50
+batching:
+  max_size: 100
+  max_age: 500ms
+antispam:
+  in_memory_entries: 256
+limits:
+  max_add_qps: 10.5
+  max_entry_size: 1024
+witnesses:
+  threshold: 1
+  witnesses:
+  - vkey: %s
+    url: https://witness.example.com/
+`, wvkey),
+			want: func(c *Config) bool {
+				return c.CheckpointInterval == 2*time.Second && c.CheckpointGrowthTrigger == 50 && c.Batching.MaxAge == 500*time.Millisecond && c.Limits.MaxAddQPS == 10.5 && len(c.Witnesses.Witnesses) == 1
+			},
+		}, {
+			name:    "JSON",
+			config:  `{"origin": "example.com/log", "storage": "posix:///tmp/log", "private_key_env": "K"}`,
+			want:    func(c *Config) bool { return c.PrivateKeyEnv == "K" },
+			wantErr: "",
+		}, {
+			name: "unknown field",
+			config: `
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+checkpoint_intreval: 2s
+`,
+			wantErr: "checkpoint_intreval",
+		}, {
+			name:    "missing fields",
+			config:  `checkpoint_interval: 2s`,
+			wantErr: "origin must be set",
+		}, {
+			name: "storage without scheme",
+			config: `
+origin: example.com/log
+storage: /tmp/log
+private_key_file: /tmp/key
+`,
+			wantErr: "scheme",
+		}, {
+			name: "negative duration",
+			config: `
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+batching:
+  max_age: -1s
+`,
+			wantErr: "batching.max_age",
+		}, {
+			name: "threshold too high",
+			config: fmt.Sprintf(`
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+witnesses:
+  threshold: 2
+  witnesses:
+  - vkey: %s
+    url: https://witness.example.com/
+`, wvkey),
+			wantErr: "witnesses.threshold",
+		}, {
+			name: "bad witness",
+			config: `
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+witnesses:
+  threshold: 1
+  witnesses:
+  - vkey: nonsense
+    url: witness.example.com
+`,
+			wantErr: "witnesses[0].vkey",
+		}, {
+			name: "policy",
+			config: fmt.Sprintf(`
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+witnesses:
+  policy: |
+    witness w %s https://witness.example.com/
+    quorum w
+`, wvkey),
+			want: func(c *Config) bool { return strings.HasPrefix(c.Witnesses.Policy, "witness w ") },
+		}, {
+			name: "policy and threshold",
+			config: fmt.Sprintf(`
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+witnesses:
+  threshold: 1
+  policy: |
+    witness w %s https://witness.example.com/
+    quorum w
+`, wvkey),
+			wantErr: "witnesses.policy must not be set",
+		}, {
+			name: "bad policy",
+			config: fmt.Sprintf(`
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: /tmp/key
+witnesses:
+  policy: |
+    witness w %s
+    quorum w
+`, wvkey),
+			wantErr: "witnesses.policy is invalid",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := Parse([]byte(test.config))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Parse: got err %v, want error containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !test.want(c) {
+				t.Errorf("Parse: unexpected config %+v", c)
+			}
+		})
+	}
+}
+
+func TestAppendOptions(t *testing.T) {
+	dir := t.TempDir()
+	sk, _, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(sk+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	for _, test := range []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "ok",
+			config: `
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: ` + keyFile + `
+checkpoint_interval: 5s
+batching:
+  max_size: 10
+`,
+		}, {
+			name: "origin mismatch",
+			config: `
+origin: example.com/other
+storage: posix:///tmp/log
+private_key_file: ` + keyFile + `
+`,
+			wantErr: "origin",
+		}, {
+			name: "missing key file",
+			config: `
+origin: example.com/log
+storage: posix:///tmp/log
+private_key_file: ` + filepath.Join(dir, "missing") + `
+`,
+			wantErr: "private key",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.name+".yaml")
+			if err := os.WriteFile(path, []byte(test.config), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			c, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			o, err := c.AppendOptions()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("AppendOptions: got err %v, want error containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AppendOptions: %v", err)
+			}
+			if got, want := o.CheckpointInterval(), 5*time.Second; got != want {
+				t.Errorf("CheckpointInterval: got %v, want %v", got, want)
+			}
+			if got, want := o.BatchMaxSize(), uint(10); got != want {
+				t.Errorf("BatchMaxSize: got %d, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/transparency-dev/tessera"
+	"k8s.io/klog/v2"
+)
+
+// Reloader applies changes made to a configuration file to the running Appender which it configured.
+//
+// Only the checkpoint interval, the QPS and unintegrated entry limits, the witnesses, and the log verbosity
+// can be changed without restarting. A reload which changes anything else is rejected, leaving the running
+// configuration unchanged.
+type Reloader struct {
+	path     string
+	appender *tessera.Appender
+
+	// mu serialises reloads, and guards current, the configuration which is currently applied.
+	mu      sync.Mutex
+	current *Config
+}
+
+// NewReloader returns a Reloader for the configuration file at path, which was loaded as current and used
+// to create a.
+func NewReloader(path string, current *Config, a *tessera.Appender) *Reloader {
+	return &Reloader{path: path, appender: a, current: current}
+}
+
+// Reload re-reads the configuration file and applies any changes to the Appender.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	if err := unsafeChanges(r.current, c); err != nil {
+		return err
+	}
+
+	// Build everything which can fail before changing anything.
+	var witnesses tessera.WitnessGroup
+	witnessesChanged := !reflect.DeepEqual(r.current.Witnesses, c.Witnesses)
+	if witnessesChanged {
+		if witnesses, err = c.witnessGroup(); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	if c.CheckpointInterval != r.current.CheckpointInterval {
+		interval := c.CheckpointInterval
+		if interval == 0 {
+			interval = tessera.DefaultCheckpointInterval
+		}
+		errs = append(errs, r.appender.SetCheckpointInterval(interval))
+	}
+	if c.Limits.MaxAddQPS != r.current.Limits.MaxAddQPS {
+		errs = append(errs, r.appender.SetMaxAddQPS(c.Limits.MaxAddQPS))
+	}
+	if c.Limits.MaxUnintegrated != r.current.Limits.MaxUnintegrated {
+		errs = append(errs, r.appender.SetMaxUnintegrated(c.Limits.MaxUnintegrated))
+	}
+	if witnessesChanged {
+		errs = append(errs, r.appender.SetWitnesses(witnesses, &tessera.WitnessOptions{FailOpen: c.Witnesses.FailOpen}))
+	}
+	if !reflect.DeepEqual(c.LogVerbosity, r.current.LogVerbosity) {
+		errs = append(errs, c.ApplyLogVerbosity())
+	}
+	r.current = c
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to apply config: %v", err)
+	}
+	klog.Infof("Reloaded config from %s", r.path)
+	return nil
+}
+
+// ReloadOnSignal calls Reload each time the process receives SIGHUP, until ctx is done.
+func (r *Reloader) ReloadOnSignal(ctx context.Context) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+		}
+		if err := r.Reload(); err != nil {
+			klog.Errorf("Failed to reload config: %v", err)
+		}
+	}
+}
+
+// unsafeChanges returns an error describing the changes from old to new which can't be applied to a
+// running Appender, or nil if there are none.
+func unsafeChanges(old, new *Config) error {
+	var errs []error
+	for _, f := range []struct {
+		name     string
+		old, new any
+	}{
+		{"origin", old.Origin, new.Origin},
+		{"storage", old.Storage, new.Storage},
+		{"private_key_file", old.PrivateKeyFile, new.PrivateKeyFile},
+		{"private_key_env", old.PrivateKeyEnv, new.PrivateKeyEnv},
+		{"additional_private_key_files", old.AdditionalPrivateKeyFiles, new.AdditionalPrivateKeyFiles},
+		{"checkpoint_growth_trigger", old.CheckpointGrowthTrigger, new.CheckpointGrowthTrigger},
+		{"garbage_collection_interval", old.GarbageCollectionInterval, new.GarbageCollectionInterval},
+		{"batching", old.Batching, new.Batching},
+		{"antispam", old.Antispam, new.Antispam},
+		{"limits.max_outstanding", old.Limits.MaxOutstanding, new.Limits.MaxOutstanding},
+		{"limits.max_entry_size", old.Limits.MaxEntrySize, new.Limits.MaxEntrySize},
+	} {
+		if !reflect.DeepEqual(f.old, f.new) {
+			errs = append(errs, fmt.Errorf("%s can't be changed without restarting", f.name))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("config not reloaded: %v", errors.Join(errs...))
+	}
+	return nil
+}
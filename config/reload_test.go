@@ -0,0 +1,128 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestReloader(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	sk, _, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(sk), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	write := func(origin, extra string) {
+		t.Helper()
+		base := "origin: " + origin + "\nstorage: posix://" + filepath.Join(dir, "log") + "\nprivate_key_file: " + keyFile + "\n"
+		if err := os.WriteFile(path, []byte(base+extra), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	write("example.com/log", "checkpoint_interval: 2s\n")
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	opts, err := c.AppendOptions()
+	if err != nil {
+		t.Fatalf("AppendOptions: %v", err)
+	}
+	d, err := posix.New(ctx, posix.Config{Path: filepath.Join(dir, "log")})
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	a, _, _, err := tessera.NewAppender(ctx, d, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	r := NewReloader(path, c, a)
+
+	for _, test := range []struct {
+		name    string
+		origin  string
+		config  string
+		want    tessera.RuntimeConfig
+		wantErr string
+	}{
+		{
+			name:   "unchanged",
+			config: "checkpoint_interval: 2s\n",
+			want:   tessera.RuntimeConfig{CheckpointInterval: 2 * time.Second},
+		}, {
+			name:   "limits and interval",
+			config: "checkpoint_interval: 5s\nlimits:\n  max_add_qps: 10\n  max_unintegrated: 100\n",
+			want:   tessera.RuntimeConfig{CheckpointInterval: 5 * time.Second, MaxAddQPS: 10, MaxUnintegrated: 100},
+		}, {
+			name:    "origin",
+			origin:  "example.com/other",
+			config:  "checkpoint_interval: 1s\n",
+			want:    tessera.RuntimeConfig{CheckpointInterval: 5 * time.Second, MaxAddQPS: 10, MaxUnintegrated: 100},
+			wantErr: "origin can't be changed",
+		}, {
+			name:    "batching",
+			config:  "checkpoint_interval: 1s\nbatching:\n  max_size: 10\n",
+			want:    tessera.RuntimeConfig{CheckpointInterval: 5 * time.Second, MaxAddQPS: 10, MaxUnintegrated: 100},
+			wantErr: "batching can't be changed",
+		}, {
+			name:    "invalid",
+			config:  "checkpoint_interval: -1s\n",
+			want:    tessera.RuntimeConfig{CheckpointInterval: 5 * time.Second, MaxAddQPS: 10, MaxUnintegrated: 100},
+			wantErr: "checkpoint_interval must not be negative",
+		}, {
+			name:   "back to defaults",
+			config: "",
+			want:   tessera.RuntimeConfig{CheckpointInterval: tessera.DefaultCheckpointInterval},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			origin := test.origin
+			if origin == "" {
+				origin = "example.com/log"
+			}
+			write(origin, test.config)
+			err := r.Reload()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("Reload: got err %v, want error containing %q", err, test.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Reload: %v", err)
+			}
+			got, err := a.RuntimeConfig()
+			if err != nil {
+				t.Fatalf("RuntimeConfig: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("RuntimeConfig: got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -47,7 +47,11 @@ func NewCertificateTransparencyAppender(a *Appender) func(context.Context, *cton
 func convertCTEntry(e *ctonly.Entry) *Entry {
 	r := &Entry{}
 	r.internal.Identity = e.Identity()
-	r.marshalForBundle = func(idx uint64) []byte {
+	r.identityPreimage = e.Certificate
+	if e.IsPrecert {
+		r.identityPreimage = e.Precertificate
+	}
+	r.marshalForBundle = func(r *Entry, idx uint64) []byte {
 		r.internal.LeafHash = e.MerkleLeafHash(idx)
 		r.internal.Data = e.LeafData(idx)
 		return r.internal.Data
@@ -59,14 +63,15 @@ func convertCTEntry(e *ctonly.Entry) *Entry {
 // WithCTLayout instructs the underlying storage to use a Static CT API compatible scheme for layout.
 func (o *AppendOptions) WithCTLayout() *AppendOptions {
 	o.entriesPath = ctEntriesPath
-	o.bundleIDHasher = ctBundleIDHasher
+	o.newBundleIDHasher = newCTBundleIDHasher
+	o.ctLayout = true
 	return o
 }
 
 // WithCTLayout instructs the underlying storage to use a Static CT API compatible scheme for layout.
 func (o *MigrationOptions) WithCTLayout() *MigrationOptions {
 	o.entriesPath = ctEntriesPath
-	o.bundleIDHasher = ctBundleIDHasher
+	o.newBundleIDHasher = newCTBundleIDHasher
 	o.bundleLeafHasher = ctMerkleLeafHasher
 	return o
 }
@@ -77,67 +82,75 @@ func ctEntriesPath(n uint64, p uint8) string {
 
 // ctBundleIDHasher knows how to calculate antispam identity hashes for entries in a Static-CT formatted entry bundle.
 func ctBundleIDHasher(bundle []byte) ([][]byte, error) {
-	r := make([][]byte, 0, layout.EntryBundleWidth)
-	b := cryptobyte.String(bundle)
-	for i := 0; i < layout.EntryBundleWidth && !b.Empty(); i++ {
-		// Timestamp
-		if !b.Skip(8) {
-			return nil, fmt.Errorf("failed to read timestamp of entry index %d of bundle", i)
-		}
-
-		var entryType uint16
-		if !b.ReadUint16(&entryType) {
-			return nil, fmt.Errorf("failed to read entry type of entry index %d of bundle", i)
-		}
+	return newCTBundleIDHasher(identityHash)(bundle)
+}
 
-		switch entryType {
-		case 0: // X509 entry
-			cert := cryptobyte.String{}
-			if !b.ReadUint24LengthPrefixed(&cert) {
-				return nil, fmt.Errorf("failed to read certificate at entry index %d of bundle", i)
+// newCTBundleIDHasher returns a function which parses a CT entry bundle and returns the identity hashes, as
+// calculated by h over the certificate or precertificate, of each entry it contains.
+func newCTBundleIDHasher(h func([]byte) []byte) func(bundle []byte) ([][]byte, error) {
+	return func(bundle []byte) ([][]byte, error) {
+		r := make([][]byte, 0, layout.EntryBundleWidth)
+		b := cryptobyte.String(bundle)
+		for i := 0; i < layout.EntryBundleWidth && !b.Empty(); i++ {
+			// Timestamp
+			if !b.Skip(8) {
+				return nil, fmt.Errorf("failed to read timestamp of entry index %d of bundle", i)
 			}
 
-			// For x509 entries we hash (just) the x509 certificate for identity.
-			r = append(r, identityHash(cert))
-
-			// Must continue below to consume all the remaining bytes in the entry.
-
-		case 1: // Precert entry
-			// IssuerKeyHash
-			if !b.Skip(sha256.Size) {
-				return nil, fmt.Errorf("failed to read issuer key hash at entry index %d of bundle", i)
+			var entryType uint16
+			if !b.ReadUint16(&entryType) {
+				return nil, fmt.Errorf("failed to read entry type of entry index %d of bundle", i)
 			}
-			tbs := cryptobyte.String{}
-			if !b.ReadUint24LengthPrefixed(&tbs) {
-				return nil, fmt.Errorf("failed to read precert tbs at entry index %d of bundle", i)
+
+			switch entryType {
+			case 0: // X509 entry
+				cert := cryptobyte.String{}
+				if !b.ReadUint24LengthPrefixed(&cert) {
+					return nil, fmt.Errorf("failed to read certificate at entry index %d of bundle", i)
+				}
+
+				// For x509 entries we hash (just) the x509 certificate for identity.
+				r = append(r, h(cert))
+
+				// Must continue below to consume all the remaining bytes in the entry.
+
+			case 1: // Precert entry
+				// IssuerKeyHash
+				if !b.Skip(sha256.Size) {
+					return nil, fmt.Errorf("failed to read issuer key hash at entry index %d of bundle", i)
+				}
+				tbs := cryptobyte.String{}
+				if !b.ReadUint24LengthPrefixed(&tbs) {
+					return nil, fmt.Errorf("failed to read precert tbs at entry index %d of bundle", i)
+				}
+
+			default:
+				return nil, fmt.Errorf("unknown entry type at entry index %d of bundle", i)
 			}
 
-		default:
-			return nil, fmt.Errorf("unknown entry type at entry index %d of bundle", i)
-		}
+			ignore := cryptobyte.String{}
+			if !b.ReadUint16LengthPrefixed(&ignore) {
+				return nil, fmt.Errorf("failed to read SCT extensions at entry index %d of bundle", i)
+			}
 
-		ignore := cryptobyte.String{}
-		if !b.ReadUint16LengthPrefixed(&ignore) {
-			return nil, fmt.Errorf("failed to read SCT extensions at entry index %d of bundle", i)
-		}
+			if entryType == 1 {
+				precert := cryptobyte.String{}
+				if !b.ReadUint24LengthPrefixed(&precert) {
+					return nil, fmt.Errorf("failed to read precert at entry index %d of bundle", i)
+				}
+				// For Precert entries we hash (just) the full precertificate for identity.
+				r = append(r, h(precert))
 
-		if entryType == 1 {
-			precert := cryptobyte.String{}
-			if !b.ReadUint24LengthPrefixed(&precert) {
-				return nil, fmt.Errorf("failed to read precert at entry index %d of bundle", i)
 			}
-			// For Precert entries we hash (just) the full precertificate for identity.
-			r = append(r, identityHash(precert))
-
+			if !b.ReadUint16LengthPrefixed(&ignore) {
+				return nil, fmt.Errorf("failed to read chain fingerprints at entry index %d of bundle", i)
+			}
 		}
-		if !b.ReadUint16LengthPrefixed(&ignore) {
-			return nil, fmt.Errorf("failed to read chain fingerprints at entry index %d of bundle", i)
+		if !b.Empty() {
+			return nil, fmt.Errorf("unexpected %d bytes of trailing data in entry bundle", len(b))
 		}
+		return r, nil
 	}
-	if !b.Empty() {
-		return nil, fmt.Errorf("unexpected %d bytes of trailing data in entry bundle", len(b))
-	}
-	return r, nil
 }
 
 // copyBytes copies N bytes between from and to.
@@ -183,3 +183,9 @@ func (c extensions) Marshal() ([]byte, error) {
 func addUint40(b *cryptobyte.Builder, v uint64) {
 	b.AddBytes([]byte{byte(v >> 32), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
 }
+
+// SCTExtensions returns the CtExtensions to include in an SCT issued for the entry at leafIndex,
+// according to c2sp.org/static-ct-api.
+func SCTExtensions(leafIndex uint64) ([]byte, error) {
+	return extensions{LeafIndex: leafIndex}.Marshal()
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/otel"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+// DedupeStatistics describes how often an antispam or dedupe implementation has found duplicate entries, and
+// what looking for them has cost, so that operators can judge whether it's worthwhile for their workload.
+//
+// The counts cover lookups made since the implementation was created, i.e. since the process started.
+type DedupeStatistics struct {
+	// Lookups is the number of entries whose identity has been looked up, and Hits the number of those
+	// which were found to be duplicates.
+	Lookups uint64 `json:"lookups"`
+	Hits    uint64 `json:"hits"`
+	// LookupTime is the total time spent on lookups, and HitTime the time spent on those which were hits.
+	LookupTime time.Duration `json:"lookup_time_ns"`
+	HitTime    time.Duration `json:"hit_time_ns"`
+	// IndexSize is the number of entries held by the index, or zero if the implementation can't tell.
+	// For follower-based antispam, this is the number of log entries which the follower has indexed.
+	IndexSize uint64 `json:"index_size"`
+}
+
+// HitRate returns the fraction of lookups which found a duplicate.
+func (s DedupeStatistics) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+// MeanLookupLatency returns the mean time taken by a lookup.
+func (s DedupeStatistics) MeanLookupLatency() time.Duration {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return s.LookupTime / time.Duration(s.Lookups)
+}
+
+// MeanHitLatency returns the mean time taken by a lookup which found a duplicate.
+func (s DedupeStatistics) MeanHitLatency() time.Duration {
+	if s.Hits == 0 {
+		return 0
+	}
+	return s.HitTime / time.Duration(s.Hits)
+}
+
+// DedupeCounters accumulates the lookup counts reported in DedupeStatistics, and is intended to be used by
+// antispam and dedupe implementations. It's safe for concurrent use.
+type DedupeCounters struct {
+	lookups, hits       atomic.Uint64
+	lookupTime, hitTime atomic.Int64
+}
+
+// RecordLookup records a lookup which took d, and whether it found a duplicate.
+func (c *DedupeCounters) RecordLookup(d time.Duration, hit bool) {
+	c.lookups.Add(1)
+	c.lookupTime.Add(int64(d))
+	if hit {
+		c.hits.Add(1)
+		c.hitTime.Add(int64(d))
+	}
+}
+
+// Statistics returns the lookups recorded so far, along with the provided index size.
+func (c *DedupeCounters) Statistics(indexSize uint64) DedupeStatistics {
+	return DedupeStatistics{
+		Lookups:    c.lookups.Load(),
+		Hits:       c.hits.Load(),
+		LookupTime: time.Duration(c.lookupTime.Load()),
+		HitTime:    time.Duration(c.hitTime.Load()),
+		IndexSize:  indexSize,
+	}
+}
+
+// dedupeStatser is implemented by antispam and dedupe implementations which report their statistics.
+type dedupeStatser interface {
+	DedupeStats(ctx context.Context) (DedupeStatistics, error)
+}
+
+// namedDedupe is an antispam or dedupe implementation used by an Appender, and the name its statistics are
+// reported under.
+type namedDedupe struct {
+	name  string
+	stats dedupeStatser
+}
+
+// DedupeStats returns the statistics of each of the antispam and dedupe implementations configured with
+// WithAntispam and WithPersistentDedupe, keyed by name: "in_memory" for the in-memory cache, "antispam" for
+// the Antispam implementation, if it reports statistics, and "persistent" for the PersistentDedupe.
+func (a *Appender) DedupeStats(ctx context.Context) (map[string]DedupeStatistics, error) {
+	r := make(map[string]DedupeStatistics, len(a.dedupers))
+	for _, d := range a.dedupers {
+		s, err := d.stats.DedupeStats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s dedupe: %v", d.name, err)
+		}
+		r[d.name] = s
+	}
+	return r, nil
+}
+
+// reportDedupeStats exports the statistics of the Appender's antispam and dedupe implementations as metrics
+// until ctx is done.
+func (a *Appender) reportDedupeStats(ctx context.Context) {
+	if len(a.dedupers) == 0 {
+		return
+	}
+	reg, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for _, d := range a.dedupers {
+			s, err := d.stats.DedupeStats(ctx)
+			if err != nil {
+				klog.V(1).Infof("Failed to read %s dedupe statistics: %v", d.name, err)
+				continue
+			}
+			attrs := metric.WithAttributes(dedupeNameKey.String(d.name))
+			o.ObserveInt64(dedupeLookups, otel.Clamp64(s.Lookups), attrs)
+			o.ObserveInt64(dedupeHits, otel.Clamp64(s.Hits), attrs)
+			o.ObserveFloat64(dedupeLookupLatency, float64(s.MeanLookupLatency())/float64(time.Millisecond), attrs)
+			o.ObserveFloat64(dedupeHitLatency, float64(s.MeanHitLatency())/float64(time.Millisecond), attrs)
+			o.ObserveInt64(dedupeIndexSize, otel.Clamp64(s.IndexSize), attrs)
+		}
+		return nil
+	}, dedupeLookups, dedupeHits, dedupeLookupLatency, dedupeHitLatency, dedupeIndexSize)
+	if err != nil {
+		klog.Errorf("Failed to register dedupe metrics callback: %v", err)
+		return
+	}
+	<-ctx.Done()
+	_ = reg.Unregister()
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDedupeStatistics(t *testing.T) {
+	for _, test := range []struct {
+		name                              string
+		s                                 DedupeStatistics
+		wantHitRate                       float64
+		wantLookupLatency, wantHitLatency time.Duration
+	}{
+		{name: "empty"},
+		{
+			name:              "no hits",
+			s:                 DedupeStatistics{Lookups: 4, LookupTime: 8 * time.Millisecond},
+			wantLookupLatency: 2 * time.Millisecond,
+		},
+		{
+			name:              "hits",
+			s:                 DedupeStatistics{Lookups: 4, Hits: 1, LookupTime: 8 * time.Millisecond, HitTime: 3 * time.Millisecond},
+			wantHitRate:       0.25,
+			wantLookupLatency: 2 * time.Millisecond,
+			wantHitLatency:    3 * time.Millisecond,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.s.HitRate(); got != test.wantHitRate {
+				t.Errorf("HitRate() = %v, want %v", got, test.wantHitRate)
+			}
+			if got := test.s.MeanLookupLatency(); got != test.wantLookupLatency {
+				t.Errorf("MeanLookupLatency() = %v, want %v", got, test.wantLookupLatency)
+			}
+			if got := test.s.MeanHitLatency(); got != test.wantHitLatency {
+				t.Errorf("MeanHitLatency() = %v, want %v", got, test.wantHitLatency)
+			}
+		})
+	}
+}
+
+func TestAppenderDedupeStats(t *testing.T) {
+	ctx := context.Background()
+	next := uint64(0)
+	delegate := func(_ context.Context, _ *Entry) IndexFuture {
+		i := next
+		next++
+		return func() (Index, error) { return Index{Index: i}, nil }
+	}
+	m := &inMemoryDedupes{size: 10}
+	p := &persistentDedupes{kv: &mapKV{m: make(map[string][]byte)}}
+	add := m.decorator(p.decorator(delegate))
+	for _, e := range []string{"one", "two", "one", "three", "one"} {
+		if _, err := add(ctx, NewEntry([]byte(e)))(); err != nil {
+			t.Fatalf("Add(%q): %v", e, err)
+		}
+	}
+
+	a := &Appender{dedupers: []namedDedupe{{name: "in_memory", stats: m}, {name: "persistent", stats: p}}}
+	got, err := a.DedupeStats(ctx)
+	if err != nil {
+		t.Fatalf("DedupeStats: %v", err)
+	}
+	for name, want := range map[string]DedupeStatistics{
+		// Duplicates are all caught by the in-memory cache, so the persistent dedupe only sees unique entries.
+		"in_memory":  {Lookups: 5, Hits: 2, IndexSize: 3},
+		"persistent": {Lookups: 3},
+	} {
+		s := got[name]
+		if s.Lookups != want.Lookups || s.Hits != want.Hits || s.IndexSize != want.IndexSize {
+			t.Errorf("%s: got %+v, want %d lookups, %d hits, index size %d", name, s, want.Lookups, want.Hits, want.IndexSize)
+		}
+	}
+}
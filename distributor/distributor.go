@@ -0,0 +1,254 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package distributor provides a service which collects checkpoints cosigned by witnesses, and makes
+// them available to relying parties with as many cosignatures as possible.
+//
+// Witnesses, or the feeders which drive them, submit the latest checkpoint each witness has cosigned
+// for a log. The distributor keeps the latest cosigned checkpoint from each witness, and serves the
+// largest checkpoint which has been cosigned by at least N witnesses, along with every cosignature
+// it holds for that checkpoint.
+package distributor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/transparency-dev/formats/log"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrUnknownLog is returned when a request refers to a log which the distributor doesn't know about.
+	ErrUnknownLog = errors.New("unknown log")
+	// ErrUnknownWitness is returned when a request refers to a witness which the distributor doesn't know about.
+	ErrUnknownWitness = errors.New("unknown witness")
+	// ErrInvalidCheckpoint is returned when a submitted checkpoint cannot be parsed, or is not signed by
+	// both the log and the witness.
+	ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+	// ErrStale is returned when a submitted checkpoint is smaller than, or inconsistent with, the latest
+	// checkpoint already held from the same witness.
+	ErrStale = errors.New("checkpoint is older than, or conflicts with, the latest held from this witness")
+	// ErrNotFound is returned when there is no checkpoint with the requested number of cosignatures.
+	ErrNotFound = errors.New("no checkpoint with enough cosignatures")
+)
+
+// LogID returns the identifier used for a log with the given origin in the distributor API.
+func LogID(origin string) string {
+	h := sha256.Sum256([]byte("o:" + origin))
+	return hex.EncodeToString(h[:])
+}
+
+// WitnessID returns the identifier used for a witness with the given key name in the distributor API.
+func WitnessID(name string) string {
+	h := sha256.Sum256([]byte("w:" + name))
+	return hex.EncodeToString(h[:])
+}
+
+// LogConfig describes a log which the distributor will collect checkpoints for.
+type LogConfig struct {
+	// Origin is the origin line of the log's checkpoints.
+	Origin string
+	// Verifier verifies the log's signature on its checkpoints.
+	Verifier note.Verifier
+}
+
+// cosigned is a checkpoint signed by the log and by a single witness.
+type cosigned struct {
+	cp      *log.Checkpoint
+	text    string
+	logSig  note.Signature
+	witSig  note.Signature
+	witness string
+}
+
+// distLog holds the state of a log known to the distributor.
+type distLog struct {
+	LogConfig
+	// latest holds the latest cosigned checkpoint from each witness, keyed by witness ID.
+	latest map[string]*cosigned
+}
+
+// Distributor collects cosigned checkpoints for a set of logs from a set of witnesses.
+type Distributor struct {
+	witnesses map[string]note.Verifier
+
+	mu   sync.RWMutex
+	logs map[string]*distLog
+}
+
+// New returns a Distributor which collects checkpoints for the provided logs, cosigned by the witnesses
+// whose signatures are verified by the provided verifiers.
+//
+// Witnesses which follow the tlog-witness protocol produce cosignature/v1 signatures, which are verified
+// by verifiers created by github.com/transparency-dev/formats/note.NewVerifierForCosignatureV1.
+func New(logs []LogConfig, witnesses []note.Verifier) (*Distributor, error) {
+	d := &Distributor{
+		witnesses: make(map[string]note.Verifier, len(witnesses)),
+		logs:      make(map[string]*distLog, len(logs)),
+	}
+	for _, w := range witnesses {
+		id := WitnessID(w.Name())
+		if _, ok := d.witnesses[id]; ok {
+			return nil, fmt.Errorf("duplicate witness %q", w.Name())
+		}
+		d.witnesses[id] = w
+	}
+	for _, l := range logs {
+		if l.Origin == "" || l.Verifier == nil {
+			return nil, fmt.Errorf("log %q must have an origin and a verifier", l.Origin)
+		}
+		id := LogID(l.Origin)
+		if _, ok := d.logs[id]; ok {
+			return nil, fmt.Errorf("duplicate log %q", l.Origin)
+		}
+		d.logs[id] = &distLog{LogConfig: l, latest: make(map[string]*cosigned)}
+	}
+	return d, nil
+}
+
+// Logs returns the IDs of the logs known to the distributor, in sorted order.
+func (d *Distributor) Logs() []string {
+	ids := make([]string, 0, len(d.logs))
+	for id := range d.logs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// NumWitnesses returns the number of witnesses known to the distributor.
+func (d *Distributor) NumWitnesses() int {
+	return len(d.witnesses)
+}
+
+// Distribute records a checkpoint signed by the log with ID logID and cosigned by the witness with ID witID,
+// if it is newer than the latest checkpoint already held from that witness.
+//
+// A checkpoint of the same size as the one held from the witness but with a different root hash is
+// evidence that the log has forked; it is rejected and logged.
+func (d *Distributor) Distribute(logID, witID string, cpRaw []byte) error {
+	l, ok := d.logs[logID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownLog, logID)
+	}
+	wv, ok := d.witnesses[witID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownWitness, witID)
+	}
+	n, err := note.Open(cpRaw, note.VerifierList(l.Verifier, wv))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+	}
+	c := &cosigned{text: n.Text, witness: witID}
+	var haveLog, haveWit bool
+	for _, s := range n.Sigs {
+		switch {
+		case s.Name == l.Verifier.Name() && s.Hash == l.Verifier.KeyHash():
+			c.logSig, haveLog = s, true
+		case s.Name == wv.Name() && s.Hash == wv.KeyHash():
+			c.witSig, haveWit = s, true
+		}
+	}
+	if !haveLog || !haveWit {
+		return fmt.Errorf("%w: must be signed by both the log and the witness", ErrInvalidCheckpoint)
+	}
+	c.cp = &log.Checkpoint{}
+	if _, err := c.cp.Unmarshal([]byte(n.Text)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+	}
+	if c.cp.Origin != l.Origin {
+		return fmt.Errorf("%w: checkpoint origin %q does not match %q", ErrInvalidCheckpoint, c.cp.Origin, l.Origin)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if prev, ok := l.latest[witID]; ok {
+		if c.cp.Size < prev.cp.Size {
+			return fmt.Errorf("%w: size %d is smaller than %d", ErrStale, c.cp.Size, prev.cp.Size)
+		}
+		if c.cp.Size == prev.cp.Size && !bytes.Equal(c.cp.Hash, prev.cp.Hash) {
+			klog.Warningf("Witness %q cosigned conflicting checkpoints for %q:\n%s\n%s", wv.Name(), l.Origin, prev.text, c.text)
+			return fmt.Errorf("%w: root hash differs from the checkpoint of the same size", ErrStale)
+		}
+	}
+	l.latest[witID] = c
+	return nil
+}
+
+// Checkpoint returns the largest checkpoint for the log with ID logID which has been cosigned by at least
+// n witnesses, along with all of the cosignatures the distributor holds for it.
+func (d *Distributor) Checkpoint(logID string, n int) ([]byte, error) {
+	l, ok := d.logs[logID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLog, logID)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	// Group the latest cosigned checkpoints from each witness by their body.
+	byText := make(map[string][]*cosigned)
+	for _, c := range l.latest {
+		byText[c.text] = append(byText[c.text], c)
+	}
+	var best []*cosigned
+	for _, cs := range byText {
+		if len(cs) < n {
+			continue
+		}
+		if best == nil || cs[0].cp.Size > best[0].cp.Size || (cs[0].cp.Size == best[0].cp.Size && len(cs) > len(best)) {
+			best = cs
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: %d cosignatures requested", ErrNotFound, n)
+	}
+	return merge(best)
+}
+
+// WitnessCheckpoint returns the latest checkpoint for the log with ID logID cosigned by the witness with ID witID.
+func (d *Distributor) WitnessCheckpoint(logID, witID string) ([]byte, error) {
+	l, ok := d.logs[logID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLog, logID)
+	}
+	if _, ok := d.witnesses[witID]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownWitness, witID)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	c, ok := l.latest[witID]
+	if !ok {
+		return nil, fmt.Errorf("%w: no checkpoint from witness %q", ErrNotFound, witID)
+	}
+	return merge([]*cosigned{c})
+}
+
+// merge returns a note holding the checkpoint shared by all of cs, signed by the log and all of the witnesses.
+func merge(cs []*cosigned) ([]byte, error) {
+	// Order the cosignatures deterministically, by witness ID.
+	cs = slices.SortedFunc(slices.Values(cs), func(a, b *cosigned) int { return strings.Compare(a.witness, b.witness) })
+	n := &note.Note{Text: cs[0].text, Sigs: []note.Signature{cs[0].logSig}}
+	for _, c := range cs {
+		n.Sigs = append(n.Sigs, c.witSig)
+	}
+	return note.Sign(n)
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
+	"golang.org/x/mod/sumdb/note"
+)
+
+const testOrigin = "example.com/log"
+
+func mustSigner(t *testing.T, name string) (note.Signer, note.Verifier) {
+	t.Helper()
+	sk, vk, err := note.GenerateKey(nil, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return s, v
+}
+
+func mustCosigner(t *testing.T, name string) *f_note.Signer {
+	t.Helper()
+	sk, _, err := note.GenerateKey(nil, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := f_note.NewSignerForCosignatureV1(sk)
+	if err != nil {
+		t.Fatalf("NewSignerForCosignatureV1: %v", err)
+	}
+	return s
+}
+
+func TestDistributor(t *testing.T) {
+	logSigner, logVerifier := mustSigner(t, testOrigin)
+	otherLogSigner, _ := mustSigner(t, testOrigin)
+	wits := []*f_note.Signer{mustCosigner(t, "example.com/w1"), mustCosigner(t, "example.com/w2"), mustCosigner(t, "example.com/w3")}
+	unknownWit := mustCosigner(t, "example.com/unknown")
+	d, err := New([]LogConfig{{Origin: testOrigin, Verifier: logVerifier}}, []note.Verifier{wits[0].Verifier(), wits[1].Verifier(), wits[2].Verifier()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv := httptest.NewServer(d.Handler())
+	defer srv.Close()
+	logID := LogID(testOrigin)
+
+	cosign := func(ls note.Signer, w note.Signer, size uint64, hash string) []byte {
+		t.Helper()
+		cp, err := note.Sign(&note.Note{Text: string(log.Checkpoint{Origin: testOrigin, Size: size, Hash: []byte(hash)}.Marshal())}, ls, w)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return cp
+	}
+	get := func(path string) (int, []byte) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		return resp.StatusCode, b
+	}
+
+	// The steps are run in order against the same distributor.
+	for _, test := range []struct {
+		name       string
+		witID      string
+		cp         []byte
+		wantStatus int
+		// wantSizes holds the expected size of checkpoint.N for each N in [1, 3], or 0 if there should be none.
+		wantSizes [3]uint64
+	}{
+		{
+			name:       "unknown witness",
+			witID:      WitnessID(unknownWit.Name()),
+			cp:         cosign(logSigner, unknownWit, 5, "aaaa"),
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "not signed by log",
+			witID:      WitnessID(wits[0].Name()),
+			cp:         cosign(otherLogSigner, wits[0], 5, "aaaa"),
+			wantStatus: http.StatusBadRequest,
+		}, {
+			name:       "wrong witness",
+			witID:      WitnessID(wits[1].Name()),
+			cp:         cosign(logSigner, wits[0], 5, "aaaa"),
+			wantStatus: http.StatusBadRequest,
+		}, {
+			name:       "first",
+			witID:      WitnessID(wits[0].Name()),
+			cp:         cosign(logSigner, wits[0], 5, "aaaa"),
+			wantStatus: http.StatusOK,
+			wantSizes:  [3]uint64{5, 0, 0},
+		}, {
+			name:       "second witness same checkpoint",
+			witID:      WitnessID(wits[1].Name()),
+			cp:         cosign(logSigner, wits[1], 5, "aaaa"),
+			wantStatus: http.StatusOK,
+			wantSizes:  [3]uint64{5, 5, 0},
+		}, {
+			name:       "first witness advances",
+			witID:      WitnessID(wits[0].Name()),
+			cp:         cosign(logSigner, wits[0], 8, "bbbb"),
+			wantStatus: http.StatusOK,
+			wantSizes:  [3]uint64{8, 0, 0},
+		}, {
+			name:       "stale",
+			witID:      WitnessID(wits[0].Name()),
+			cp:         cosign(logSigner, wits[0], 5, "aaaa"),
+			wantStatus: http.StatusConflict,
+			wantSizes:  [3]uint64{8, 0, 0},
+		}, {
+			name:       "fork",
+			witID:      WitnessID(wits[0].Name()),
+			cp:         cosign(logSigner, wits[0], 8, "cccc"),
+			wantStatus: http.StatusConflict,
+			wantSizes:  [3]uint64{8, 0, 0},
+		}, {
+			name:       "all witnesses agree",
+			witID:      WitnessID(wits[2].Name()),
+			cp:         cosign(logSigner, wits[2], 8, "bbbb"),
+			wantStatus: http.StatusOK,
+			wantSizes:  [3]uint64{8, 8, 0},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, srv.URL+fmt.Sprintf(HTTPCheckpointByWitness, logID, test.witID), bytes.NewReader(test.cp))
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("PUT got status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+
+			for i, wantSize := range test.wantSizes {
+				n := i + 1
+				status, body := get(fmt.Sprintf(HTTPGetCheckpointN, logID, n))
+				if wantSize == 0 {
+					if status != http.StatusNotFound {
+						t.Errorf("checkpoint.%d: got status %d, want %d", n, status, http.StatusNotFound)
+					}
+					continue
+				}
+				if status != http.StatusOK {
+					t.Fatalf("checkpoint.%d: got status %d (%q), want %d", n, status, body, http.StatusOK)
+				}
+				vs := []note.Verifier{logVerifier}
+				for _, w := range wits {
+					vs = append(vs, w.Verifier())
+				}
+				cn, err := note.Open(body, note.VerifierList(vs...))
+				if err != nil {
+					t.Fatalf("checkpoint.%d: Open: %v", n, err)
+				}
+				cp := &log.Checkpoint{}
+				if _, err := cp.Unmarshal([]byte(cn.Text)); err != nil {
+					t.Fatalf("checkpoint.%d: Unmarshal: %v", n, err)
+				}
+				if cp.Size != wantSize {
+					t.Errorf("checkpoint.%d: got size %d, want %d", n, cp.Size, wantSize)
+				}
+				if len(cn.Sigs) < n+1 {
+					t.Errorf("checkpoint.%d: got %d signatures, want at least %d", n, len(cn.Sigs), n+1)
+				}
+			}
+		})
+	}
+
+	if status, _ := get(fmt.Sprintf(HTTPGetCheckpointN, logID, 4)); status != http.StatusBadRequest {
+		t.Errorf("checkpoint.4: got status %d, want %d", status, http.StatusBadRequest)
+	}
+	if status, _ := get(fmt.Sprintf(HTTPCheckpointByWitness, logID, WitnessID(wits[1].Name()))); status != http.StatusOK {
+		t.Errorf("byWitness: got status %d, want %d", status, http.StatusOK)
+	}
+	status, body := get(HTTPGetLogs)
+	var logs []string
+	if err := json.Unmarshal(body, &logs); status != http.StatusOK || err != nil || len(logs) != 1 || logs[0] != logID {
+		t.Errorf("logs: got (%d, %q, %v), want [%q]", status, body, err, logID)
+	}
+}
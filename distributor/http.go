@@ -0,0 +1,122 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distributor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HTTPGetLogs is the path which serves a JSON list of the IDs of the logs known to the distributor.
+	HTTPGetLogs = "/distributor/v0/logs"
+	// HTTPGetCheckpointN is the format of the path which serves the largest checkpoint for a log with at
+	// least N cosignatures. It is formatted with the log ID and N.
+	HTTPGetCheckpointN = "/distributor/v0/logs/%s/checkpoint.%d"
+	// HTTPCheckpointByWitness is the format of the path to which the latest checkpoint cosigned by a witness
+	// for a log is PUT, and from which it is served. It is formatted with the log ID and witness ID.
+	HTTPCheckpointByWitness = "/distributor/v0/logs/%s/byWitness/%s/checkpoint"
+
+	// maxCheckpointSize is the largest checkpoint which will be accepted.
+	maxCheckpointSize = 1 << 16
+)
+
+// Handler returns an http.Handler which serves the distributor API.
+func (d *Distributor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+HTTPGetLogs, d.handleGetLogs)
+	mux.HandleFunc("GET "+HTTPGetLogs+"/{log}/{file}", d.handleGetCheckpointN)
+	mux.HandleFunc("GET "+fmt.Sprintf(HTTPCheckpointByWitness, "{log}", "{witness}"), d.handleGetByWitness)
+	mux.HandleFunc("PUT "+fmt.Sprintf(HTTPCheckpointByWitness, "{log}", "{witness}"), d.handlePutByWitness)
+	return mux
+}
+
+func (d *Distributor) handleGetLogs(w http.ResponseWriter, _ *http.Request) {
+	b, err := json.Marshal(d.Logs())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func (d *Distributor) handleGetCheckpointN(w http.ResponseWriter, r *http.Request) {
+	nStr, ok := strings.CutPrefix(r.PathValue("file"), "checkpoint.")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	n, err := strconv.Atoi(nStr)
+	if err != nil || n < 0 {
+		http.Error(w, fmt.Sprintf("invalid number of cosignatures %q", nStr), http.StatusBadRequest)
+		return
+	}
+	if n > d.NumWitnesses() {
+		http.Error(w, fmt.Sprintf("requested %d cosignatures, but only %d witnesses are known", n, d.NumWitnesses()), http.StatusBadRequest)
+		return
+	}
+	cp, err := d.Checkpoint(r.PathValue("log"), n)
+	writeCheckpoint(w, cp, err)
+}
+
+func (d *Distributor) handleGetByWitness(w http.ResponseWriter, r *http.Request) {
+	cp, err := d.WitnessCheckpoint(r.PathValue("log"), r.PathValue("witness"))
+	writeCheckpoint(w, cp, err)
+}
+
+func (d *Distributor) handlePutByWitness(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCheckpointSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := d.Distribute(r.PathValue("log"), r.PathValue("witness"), body); err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+	}
+}
+
+// writeCheckpoint writes the checkpoint as the response, or an error status if err is non-nil.
+func writeCheckpoint(w http.ResponseWriter, cp []byte, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), statusForError(err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(cp)
+}
+
+// statusForError returns the HTTP status code corresponding to an error returned by the Distributor.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownLog), errors.Is(err, ErrUnknownWitness), errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidCheckpoint):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrStale):
+		return http.StatusConflict
+	default:
+		klog.Warningf("Distributor: %v", err)
+		return http.StatusInternalServerError
+	}
+}
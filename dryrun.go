@@ -0,0 +1,101 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// NewDryRunAppender returns an Appender which behaves like one returned by NewAppender, but which never writes
+// anything to the log.
+//
+// Entries passed to Add are validated, checked for duplicates (both in memory, and against any Antispam
+// configured in opts, which is consulted but not updated), and then assigned the index they would have been
+// given had they been added to the log read by r, assuming that nothing else is added to it in the meantime.
+// The returned indices are predictions only, and entries will never be integrated into the log.
+//
+// This is intended for load-testing personalities, and for staging changes, such as migrations, against
+// production traffic. The CheckpointSigner in opts is not used, but must still be set.
+func NewDryRunAppender(ctx context.Context, r LogReader, opts *AppendOptions) (*Appender, error) {
+	if opts == nil {
+		return nil, errors.New("opts cannot be nil")
+	}
+	if err := opts.valid(); err != nil {
+		return nil, err
+	}
+	next, err := r.NextIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read next index: %v", err)
+	}
+	s := &dryRunSequencer{next: next}
+
+	add := s.add
+	if opts.preordered {
+		add = newPreorderedChecker(s.nextIndex).decorator(add)
+	}
+	if opts.maxAddQPS > 0 {
+		add = newQPSLimiter(opts.maxAddQPS)(add)
+	}
+	for i := len(opts.addDecorators) - 1; i >= 0; i-- {
+		add = opts.addDecorators[i](add)
+	}
+	if d := opts.leafHashDecorator(); d != nil {
+		add = d(add)
+	}
+	if d := opts.identityDecorator(); d != nil {
+		add = d(add)
+	}
+	if v := opts.validationDecorator(); v != nil {
+		add = v(add)
+	}
+	a := &Appender{
+		Add: func(ctx context.Context, entry *Entry) IndexFuture {
+			return memoizeFuture(add(ctx, entry))
+		},
+	}
+	a.AddBatch = func(ctx context.Context, entries []*Entry) []IndexFuture {
+		r := make([]IndexFuture, 0, len(entries))
+		for _, e := range entries {
+			r = append(r, a.Add(ctx, e))
+		}
+		return r
+	}
+	return a, nil
+}
+
+// dryRunSequencer assigns indices to entries without storing them.
+type dryRunSequencer struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+func (s *dryRunSequencer) add(_ context.Context, e *Entry) IndexFuture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.next
+	s.next++
+	// Serialise the entry as storage would, so that any problems doing so are found.
+	_ = e.MarshalBundleData(i)
+	return func() (Index, error) { return Index{Index: i}, nil }
+}
+
+func (s *dryRunSequencer) nextIndex(_ context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next, nil
+}
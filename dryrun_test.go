@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestDryRunAppender(t *testing.T) {
+	ctx := context.Background()
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	r := &fakeLogReader{size: 10}
+	a, err := NewDryRunAppender(ctx, r, NewAppendOptions().
+		WithCheckpointSigner(s).
+		WithAntispam(256, nil).
+		WithMaxEntrySize(10))
+	if err != nil {
+		t.Fatalf("NewDryRunAppender: %v", err)
+	}
+
+	for _, test := range []struct {
+		data    string
+		want    Index
+		wantErr error
+	}{
+		{data: "one", want: Index{Index: 10}},
+		{data: "two", want: Index{Index: 11}},
+		{data: "one", want: Index{Index: 10, IsDup: true}},
+		{data: "much too large", wantErr: ErrEntryTooLarge},
+		{data: "three", want: Index{Index: 12}},
+	} {
+		got, err := a.Add(ctx, NewEntry([]byte(test.data)))()
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("Add(%q): got err %v, want %v", test.data, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("Add(%q): got %+v, want %+v", test.data, got, test.want)
+		}
+	}
+	fs := a.AddBatch(ctx, []*Entry{NewEntry([]byte("four")), NewEntry([]byte("two"))})
+	for i, want := range []Index{{Index: 13}, {Index: 11, IsDup: true}} {
+		if got, err := fs[i](); err != nil || got != want {
+			t.Errorf("AddBatch future %d: got %+v, %v, want %+v", i, got, err, want)
+		}
+	}
+}
@@ -34,8 +34,20 @@ type Entry struct {
 		Index    *uint64
 	}
 
-	// marshalForBundle knows how to convert this entry's Data into a marshalled bundle entry.
-	marshalForBundle func(index uint64) []byte
+	// identityPreimage is the data over which the entry's identity hash is calculated.
+	// It is used to recalculate the identity if a custom hash function has been configured
+	// with WithIdentityHash.
+	identityPreimage []byte
+
+	// preorderedIndex, if set, is the index which this entry must be assigned in the log.
+	preorderedIndex *uint64
+
+	// idempotencyKey, if set, identifies the submission which this entry came from.
+	idempotencyKey string
+
+	// marshalForBundle knows how to convert the entry e's Data into a marshalled bundle entry. It's passed the
+	// entry rather than closing over it, so that it acts on the right entry once the entry has been cloned.
+	marshalForBundle func(e *Entry, index uint64) []byte
 }
 
 // Data returns the raw entry bytes which will form the entry in the log.
@@ -51,6 +63,26 @@ func (e Entry) LeafHash() []byte { return e.internal.LeafHash }
 // Index returns the index assigned to the entry in the log, or nil if no index has been assigned.
 func (e Entry) Index() *uint64 { return e.internal.Index }
 
+// PreorderedIndex returns the index which the entry must be assigned in the log, or nil if the entry
+// may be assigned any index.
+//
+// Storage implementations must refuse to sequence an entry at any other index than this.
+func (e Entry) PreorderedIndex() *uint64 { return e.preorderedIndex }
+
+// IdempotencyKey returns the idempotency key set with WithIdempotencyKey, or the empty string if there isn't one.
+func (e Entry) IdempotencyKey() string { return e.idempotencyKey }
+
+// WithIdempotencyKey sets a key, chosen by the submitter, which identifies the submission this entry came from.
+//
+// If the Appender was configured with WithIdempotencyKeys, adding another entry with the same key within the
+// configured window will return the index assigned to this entry, marked as a duplicate, rather than adding it
+// to the log. This allows submitters to safely retry submissions whose outcome they don't know, e.g. following a
+// network timeout, even if the entry they retry with isn't identical.
+func (e *Entry) WithIdempotencyKey(key string) *Entry {
+	e.idempotencyKey = key
+	return e
+}
+
 // MarshalBundleData returns this entry's data in a format ready to be appended to an EntryBundle.
 //
 // Note that MarshalBundleData _may_ be called multiple times, potentially with different values for index
@@ -58,19 +90,27 @@ func (e Entry) Index() *uint64 { return e.internal.Index }
 // be considered final until the storage Add method has returned successfully with the durably assigned index.
 func (e *Entry) MarshalBundleData(index uint64) []byte {
 	e.internal.Index = &index
-	return e.marshalForBundle(index)
+	return e.marshalForBundle(e, index)
+}
+
+// clone returns a copy of the entry which can be added to another log, i.e. without the index assigned to it.
+func (e *Entry) clone() *Entry {
+	c := *e
+	c.internal.Index = nil
+	return &c
 }
 
 // NewEntry creates a new Entry object with leaf data.
 func NewEntry(data []byte) *Entry {
 	e := &Entry{}
 	e.internal.Data = data
-	h := identityHash(e.internal.Data)
+	e.identityPreimage = data
+	h := identityHash(e.identityPreimage)
 	e.internal.Identity = h[:]
 	e.internal.LeafHash = rfc6962.DefaultHasher.HashLeaf(e.internal.Data)
 	// By default we will marshal ourselves into a bundle using the mechanism described
 	// by https://c2sp.org/tlog-tiles:
-	e.marshalForBundle = func(_ uint64) []byte {
+	e.marshalForBundle = func(e *Entry, _ uint64) []byte {
 		r := make([]byte, 0, 2+len(e.internal.Data))
 		r = binary.BigEndian.AppendUint16(r, uint16(len(e.internal.Data)))
 		r = append(r, e.internal.Data...)
@@ -78,3 +118,12 @@ func NewEntry(data []byte) *Entry {
 	}
 	return e
 }
+
+// NewPreorderedEntry creates a new Entry object with leaf data, which must be assigned the provided index in the log.
+//
+// This is intended for use with Appenders configured with WithPreorderedEntries.
+func NewPreorderedEntry(data []byte, index uint64) *Entry {
+	e := NewEntry(data)
+	e.preorderedIndex = &index
+	return e
+}
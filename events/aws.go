@@ -0,0 +1,147 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// AWSPublisher is a Publisher which publishes messages to an Amazon SNS topic or SQS queue, using their query
+// APIs. Message attributes are set as SNS or SQS message attributes.
+//
+// FIFO topics and queues are not supported, since these require a message group ID. Publishing fails if the
+// service doesn't respond within 30 seconds, or by the deadline of the context passed to Publish if it has one.
+type AWSPublisher struct {
+	cfg      aws.Config
+	client   *http.Client
+	timeout  time.Duration
+	service  string
+	region   string
+	endpoint string
+	// params holds the parameters which identify the action, and the topic or queue.
+	params url.Values
+	// attrPrefix is the prefix of the parameters which hold message attributes.
+	attrPrefix string
+	// bodyParam is the parameter which holds the message.
+	bodyParam string
+}
+
+// NewSNSPublisher returns an AWSPublisher which publishes messages to the SNS topic with the given ARN,
+// authenticating with the default AWS credentials.
+func NewSNSPublisher(ctx context.Context, topicARN string) (*AWSPublisher, error) {
+	// ARNs have the form arn:<partition>:sns:<region>:<account>:<topic>.
+	parts := strings.Split(topicARN, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sns" || parts[3] == "" {
+		return nil, fmt.Errorf("invalid SNS topic ARN %q", topicARN)
+	}
+	region := parts[3]
+	domain := "amazonaws.com"
+	if parts[1] == "aws-cn" {
+		domain = "amazonaws.com.cn"
+	}
+	return newAWSPublisher(ctx, "sns", region, fmt.Sprintf("https://sns.%s.%s/", region, domain), url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {topicARN},
+	}, "MessageAttributes.entry", "Message")
+}
+
+// NewSQSPublisher returns an AWSPublisher which sends messages to the SQS queue with the given URL, e.g.
+// https://sqs.us-east-2.amazonaws.com/123456789012/queue, authenticating with the default AWS credentials.
+func NewSQSPublisher(ctx context.Context, queueURL string) (*AWSPublisher, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Scheme != "https" || !strings.HasPrefix(u.Host, "sqs.") {
+		return nil, fmt.Errorf("invalid SQS queue URL %q", queueURL)
+	}
+	region, _, _ := strings.Cut(strings.TrimPrefix(u.Host, "sqs."), ".")
+	return newAWSPublisher(ctx, "sqs", region, queueURL, url.Values{
+		"Action":  {"SendMessage"},
+		"Version": {"2012-11-05"},
+	}, "MessageAttribute", "MessageBody")
+}
+
+func newAWSPublisher(ctx context.Context, service, region, endpoint string, params url.Values, attrPrefix, bodyParam string) (*AWSPublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	return &AWSPublisher{
+		cfg:        cfg,
+		client:     http.DefaultClient,
+		timeout:    defaultPublishTimeout,
+		service:    service,
+		region:     region,
+		endpoint:   endpoint,
+		params:     params,
+		attrPrefix: attrPrefix,
+		bodyParam:  bodyParam,
+	}, nil
+}
+
+func (p *AWSPublisher) Publish(ctx context.Context, msg Message) error {
+	ctx, cancel := withDefaultTimeout(ctx, p.timeout)
+	defer cancel()
+	form := maps.Clone(p.params)
+	form.Set(p.bodyParam, string(msg.Data))
+	for i, k := range slices.Sorted(maps.Keys(msg.Attributes)) {
+		prefix := p.attrPrefix + "." + strconv.Itoa(i+1) + "."
+		form.Set(prefix+"Name", k)
+		form.Set(prefix+"Value.DataType", "String")
+		form.Set(prefix+"Value.StringValue", msg.Attributes[k])
+	}
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	creds, err := p.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %v", err)
+	}
+	h := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(h[:]), p.service, p.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %v", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %v", p.service, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to publish to %s: %s: %s", p.service, resp.Status, bytes.TrimSpace(b))
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events publishes an event to a message broker, such as Google Cloud Pub/Sub, Amazon SNS or SQS, or
+// NATS, for each entry bundle as it's integrated into a Tessera log. This allows stream processing pipelines
+// to consume the contents of a log without each running a custom follower.
+//
+// Events are published at least once: if the process is restarted, or the broker fails to acknowledge an
+// event, the events for some entries may be published again. Consumers should use the index of the first
+// entry in each event to deduplicate them.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// Event describes entries which have been added to an entry bundle of the log.
+//
+// An event is published for each bundle that a batch of newly integrated entries falls into, so a partial
+// bundle at the right edge of the tree may be the subject of several events as it fills up.
+type Event struct {
+	// Origin is the origin of the log, if it was set in Options.
+	Origin string `json:"origin,omitempty"`
+	// Bundle is the index of the entry bundle, and BundleSize the number of entries it now holds.
+	Bundle     uint64 `json:"bundle"`
+	BundleSize uint64 `json:"bundle_size"`
+	// First is the index in the log of the first entry which the event describes, and Count the number of
+	// entries it describes.
+	First uint64 `json:"first"`
+	Count uint64 `json:"count"`
+	// Entries holds the entries which the event describes, if Options.IncludeEntries is set.
+	Entries [][]byte `json:"entries,omitempty"`
+}
+
+// Message is an event encoded for a message broker.
+type Message struct {
+	// Data is the JSON encoding of the Event.
+	Data []byte
+	// Attributes summarise the event, so that brokers which support it can filter and route messages without
+	// parsing Data. These are "bundle", "first", and "count", along with "origin" if it's set.
+	Attributes map[string]string
+}
+
+// defaultPublishTimeout bounds how long the publishers in this package wait for a broker, if the context passed
+// to Publish has no deadline.
+const defaultPublishTimeout = 30 * time.Second
+
+// Publisher sends messages to a message broker.
+type Publisher interface {
+	// Publish returns once the broker has accepted msg, or an error if it hasn't.
+	Publish(ctx context.Context, msg Message) error
+}
+
+// withDefaultTimeout returns a context which is done after timeout if ctx has no deadline of its own.
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// PublisherFunc is a Publisher implemented by a function, which can be used to adapt the client of a message
+// broker which isn't directly supported by this package.
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// NewPublisher returns a Publisher for the topic, queue, or subject identified by rawURL, which has one of the
+// forms:
+//
+//	gcppubsub://projects/<project>/topics/<topic>
+//	awssns:///<topic ARN>
+//	awssqs://sqs.<region>.amazonaws.com/<account>/<queue>
+//	nats://[<user>:<password>@]<host>[:<port>]/<subject>
+func NewPublisher(ctx context.Context, rawURL string) (Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	switch u.Scheme {
+	case "gcppubsub":
+		return NewPubSubPublisher(ctx, u.Host+u.Path)
+	case "awssns":
+		return NewSNSPublisher(ctx, strings.TrimPrefix(u.Path, "/"))
+	case "awssqs":
+		return NewSQSPublisher(ctx, "https://"+u.Host+u.Path)
+	case "nats":
+		subject := strings.TrimPrefix(u.Path, "/")
+		u.Path = ""
+		return NewNATSPublisher(u, subject)
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// Options holds optional settings for followers created by NewFollower.
+type Options struct {
+	// Origin, if set, is included in each event.
+	Origin string
+	// IncludeEntries includes the contents of the entries in each event. Brokers limit the size of messages,
+	// so this should only be set for logs with small entries: for example, a full bundle of 1KB entries
+	// exceeds the 256KB limit of SNS and SQS.
+	IncludeEntries bool
+	// FollowPublished only publishes events for entries once they're committed to by a published checkpoint.
+	// Pipelines which pass on information about entries to external parties should set this.
+	FollowPublished bool
+}
+
+// NewFollower returns a tessera.Follower which publishes an Event using p for each entry bundle that newly
+// integrated entries are added to, and records how far it has got in pos.
+//
+// The returned follower should be attached to an Appender using tessera.AppendOptions.WithFollower.
+func NewFollower(name string, pos tessera.FollowerPositionStore, p Publisher, opts Options) tessera.Follower {
+	e := &emitter{p: p, opts: opts}
+	return tessera.NewFollower(name, pos, e.process, &tessera.FollowerOptions{
+		BatchSize:       layout.EntryBundleWidth,
+		FollowPublished: opts.FollowPublished,
+	})
+}
+
+// emitter publishes events for batches of entries passed to it by a follower.
+type emitter struct {
+	p    Publisher
+	opts Options
+}
+
+// process publishes an event for each bundle which the entries, the first of which is at index first, fall into.
+func (e *emitter) process(ctx context.Context, first uint64, entries [][]byte) error {
+	for len(entries) > 0 {
+		bundle := first / layout.EntryBundleWidth
+		offset := first % layout.EntryBundleWidth
+		n := min(uint64(len(entries)), layout.EntryBundleWidth-offset)
+		ev := Event{
+			Origin:     e.opts.Origin,
+			Bundle:     bundle,
+			BundleSize: offset + n,
+			First:      first,
+			Count:      n,
+		}
+		if e.opts.IncludeEntries {
+			ev.Entries = entries[:n]
+		}
+		msg, err := encode(ev)
+		if err != nil {
+			return err
+		}
+		if err := e.p.Publish(ctx, msg); err != nil {
+			return fmt.Errorf("failed to publish event for entries [%d, %d): %v", first, first+n, err)
+		}
+		first += n
+		entries = entries[n:]
+	}
+	return nil
+}
+
+// encode returns the Message for ev.
+func encode(ev Event) (Message, error) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to marshal event: %v", err)
+	}
+	attrs := map[string]string{
+		"bundle": strconv.FormatUint(ev.Bundle, 10),
+		"first":  strconv.FormatUint(ev.First, 10),
+		"count":  strconv.FormatUint(ev.Count, 10),
+	}
+	if ev.Origin != "" {
+		attrs["origin"] = ev.Origin
+	}
+	return Message{Data: b, Attributes: attrs}, nil
+}
@@ -0,0 +1,393 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/go-cmp/cmp"
+)
+
+func entries(first, n uint64) [][]byte {
+	r := make([][]byte, 0, n)
+	for i := range n {
+		r = append(r, fmt.Appendf(nil, "entry %d", first+i))
+	}
+	return r
+}
+
+func TestProcess(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		opts  Options
+		first uint64
+		n     uint64
+		want  []Event
+	}{
+		{
+			name:  "partial bundle",
+			first: 0,
+			n:     10,
+			want:  []Event{{Bundle: 0, BundleSize: 10, First: 0, Count: 10}},
+		}, {
+			name:  "filling partial bundle",
+			first: 10,
+			n:     246,
+			want:  []Event{{Bundle: 0, BundleSize: 256, First: 10, Count: 246}},
+		}, {
+			name:  "spanning bundles",
+			opts:  Options{Origin: "example.com/log"},
+			first: 250,
+			n:     256,
+			want: []Event{
+				{Origin: "example.com/log", Bundle: 0, BundleSize: 256, First: 250, Count: 6},
+				{Origin: "example.com/log", Bundle: 1, BundleSize: 250, First: 256, Count: 250},
+			},
+		}, {
+			name:  "with entries",
+			opts:  Options{IncludeEntries: true},
+			first: 511,
+			n:     2,
+			want: []Event{
+				{Bundle: 1, BundleSize: 256, First: 511, Count: 1, Entries: entries(511, 1)},
+				{Bundle: 2, BundleSize: 1, First: 512, Count: 1, Entries: entries(512, 1)},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []Event
+			e := &emitter{opts: test.opts, p: PublisherFunc(func(_ context.Context, msg Message) error {
+				var ev Event
+				if err := json.Unmarshal(msg.Data, &ev); err != nil {
+					t.Fatalf("Unmarshal: %v", err)
+				}
+				wantAttrs := map[string]string{
+					"bundle": strconv.FormatUint(ev.Bundle, 10),
+					"first":  strconv.FormatUint(ev.First, 10),
+					"count":  strconv.FormatUint(ev.Count, 10),
+				}
+				if ev.Origin != "" {
+					wantAttrs["origin"] = ev.Origin
+				}
+				if d := cmp.Diff(wantAttrs, msg.Attributes); d != "" {
+					t.Errorf("Attributes: diff (-want +got):\n%s", d)
+				}
+				got = append(got, ev)
+				return nil
+			})}
+			if err := e.process(context.Background(), test.first, entries(test.first, test.n)); err != nil {
+				t.Fatalf("process: %v", err)
+			}
+			if d := cmp.Diff(test.want, got); d != "" {
+				t.Errorf("events: diff (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+// fakeNATSServer accepts connections from publishers, and sends the payload and headers of each message published
+// to it on msgs.
+func fakeNATSServer(t *testing.T, msgs chan<- string) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveNATS(c, msgs)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveNATS(c net.Conn, msgs chan<- string) {
+	defer func() { _ = c.Close() }()
+	r := bufio.NewReader(c)
+	if _, err := io.WriteString(c, "INFO {\"headers\":true}\r\n"); err != nil {
+		return
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f := strings.Fields(line)
+		switch {
+		case len(f) == 0:
+			return
+		case f[0] == "PING":
+			_, _ = io.WriteString(c, "PONG\r\n")
+		case f[0] == "HPUB" && len(f) == 4:
+			n, _ := strconv.Atoi(f[3])
+			b := make([]byte, n+2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return
+			}
+			if f[1] == "bad" {
+				_, _ = io.WriteString(c, "-ERR 'Permissions Violation'\r\n")
+				continue
+			}
+			msgs <- string(b[:n])
+		}
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	ctx := context.Background()
+	msgs := make(chan string, 1)
+	addr := fakeNATSServer(t, msgs)
+
+	p, err := NewPublisher(ctx, "nats://"+addr+"/log.events")
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	for i := range 2 {
+		if err := p.Publish(ctx, Message{Data: []byte("hello"), Attributes: map[string]string{"first": "1", "bundle": "0"}}); err != nil {
+			t.Fatalf("Publish %d: %v", i, err)
+		}
+		if got, want := <-msgs, "NATS/1.0\r\nbundle: 0\r\nfirst: 1\r\n\r\nhello"; got != want {
+			t.Errorf("Publish %d: server got %q, want %q", i, got, want)
+		}
+	}
+
+	bad, err := NewPublisher(ctx, "nats://"+addr+"/bad")
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	if err := bad.Publish(ctx, Message{Data: []byte("hello")}); err == nil {
+		t.Error("Publish to forbidden subject succeeded, want error")
+	}
+}
+
+func TestPubSubPublisher(t *testing.T) {
+	ctx := context.Background()
+	var gotPath string
+	var gotBody struct {
+		Messages []struct {
+			Data       string            `json:"data"`
+			Attributes map[string]string `json:"attributes"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("Decode: %v", err)
+		}
+		_, _ = io.WriteString(w, `{"messageIds":["1"]}`)
+	}))
+	defer srv.Close()
+
+	p := &PubSubPublisher{client: srv.Client(), endpoint: srv.URL + "/v1/", topic: "projects/p/topics/t", timeout: defaultPublishTimeout}
+	if err := p.Publish(ctx, Message{Data: []byte("hello"), Attributes: map[string]string{"first": "1"}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if want := "/v1/projects/p/topics/t:publish"; gotPath != want {
+		t.Errorf("got path %q, want %q", gotPath, want)
+	}
+	if len(gotBody.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(gotBody.Messages))
+	}
+	if got, want := gotBody.Messages[0].Data, base64.StdEncoding.EncodeToString([]byte("hello")); got != want {
+		t.Errorf("got data %q, want %q", got, want)
+	}
+	if d := cmp.Diff(map[string]string{"first": "1"}, gotBody.Messages[0].Attributes); d != "" {
+		t.Errorf("attributes: diff (-want +got):\n%s", d)
+	}
+}
+
+func TestAWSPublisher(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name    string
+		service string
+		params  url.Values
+		prefix  string
+		body    string
+		want    url.Values
+	}{
+		{
+			name:    "sns",
+			service: "sns",
+			params:  url.Values{"Action": {"Publish"}, "TopicArn": {"arn:aws:sns:us-east-2:123:t"}},
+			prefix:  "MessageAttributes.entry",
+			body:    "Message",
+			want: url.Values{
+				"Action":                         {"Publish"},
+				"TopicArn":                       {"arn:aws:sns:us-east-2:123:t"},
+				"Message":                        {"hello"},
+				"MessageAttributes.entry.1.Name": {"count"},
+				"MessageAttributes.entry.1.Value.DataType":    {"String"},
+				"MessageAttributes.entry.1.Value.StringValue": {"2"},
+				"MessageAttributes.entry.2.Name":              {"first"},
+				"MessageAttributes.entry.2.Value.DataType":    {"String"},
+				"MessageAttributes.entry.2.Value.StringValue": {"1"},
+			},
+		}, {
+			name:    "sqs",
+			service: "sqs",
+			params:  url.Values{"Action": {"SendMessage"}},
+			prefix:  "MessageAttribute",
+			body:    "MessageBody",
+			want: url.Values{
+				"Action":                               {"SendMessage"},
+				"MessageBody":                          {"hello"},
+				"MessageAttribute.1.Name":              {"count"},
+				"MessageAttribute.1.Value.DataType":    {"String"},
+				"MessageAttribute.1.Value.StringValue": {"2"},
+				"MessageAttribute.2.Name":              {"first"},
+				"MessageAttribute.2.Value.DataType":    {"String"},
+				"MessageAttribute.2.Value.StringValue": {"1"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got url.Values
+			var auth string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth = r.Header.Get("Authorization")
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("ParseForm: %v", err)
+				}
+				got = r.PostForm
+			}))
+			defer srv.Close()
+
+			p := &AWSPublisher{
+				cfg:        aws.Config{Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", "")},
+				client:     srv.Client(),
+				timeout:    defaultPublishTimeout,
+				service:    test.service,
+				region:     "us-east-2",
+				endpoint:   srv.URL,
+				params:     test.params,
+				attrPrefix: test.prefix,
+				bodyParam:  test.body,
+			}
+			if err := p.Publish(ctx, Message{Data: []byte("hello"), Attributes: map[string]string{"first": "1", "count": "2"}}); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if d := cmp.Diff(test.want, got); d != "" {
+				t.Errorf("form: diff (-want +got):\n%s", d)
+			}
+			if want := "AWS4-HMAC-SHA256 Credential=AKID/"; !strings.HasPrefix(auth, want) || !strings.Contains(auth, "/us-east-2/"+test.service+"/") {
+				t.Errorf("got Authorization %q, want a signature for %s in us-east-2", auth, test.service)
+			}
+		})
+	}
+}
+
+func TestPublishTimeout(t *testing.T) {
+	// The brokers accept requests but never respond, and the caller's context has no deadline.
+	ctx := context.Background()
+	const timeout = 100 * time.Millisecond
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = c.Close() })
+		}
+	}()
+	stop := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stop
+	}))
+	defer srv.Close()
+	defer close(stop)
+
+	u, err := url.Parse("nats://" + l.Addr().String())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	nats, err := NewNATSPublisher(u, "log.events")
+	if err != nil {
+		t.Fatalf("NewNATSPublisher: %v", err)
+	}
+	nats.timeout = timeout
+	for _, test := range []struct {
+		name string
+		p    Publisher
+	}{
+		{name: "nats", p: nats},
+		{name: "pubsub", p: &PubSubPublisher{client: srv.Client(), endpoint: srv.URL + "/v1/", topic: "projects/p/topics/t", timeout: timeout}},
+		{name: "aws", p: &AWSPublisher{
+			cfg:      aws.Config{Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", "")},
+			client:   srv.Client(),
+			timeout:  timeout,
+			service:  "sqs",
+			region:   "us-east-2",
+			endpoint: srv.URL,
+			params:   url.Values{"Action": {"SendMessage"}},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			errc := make(chan error, 1)
+			go func() { errc <- test.p.Publish(ctx, Message{Data: []byte("hello")}) }()
+			select {
+			case err := <-errc:
+				if err == nil {
+					t.Error("Publish succeeded, want error")
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("Publish didn't time out")
+			}
+		})
+	}
+}
+
+func TestNewPublisherErrors(t *testing.T) {
+	ctx := context.Background()
+	for _, u := range []string{
+		"kafka://broker/topic",
+		"gcppubsub://projects/p",
+		"awssns:///arn:aws:sqs:us-east-2:123:q",
+		"awssqs://example.com/123/q",
+		"nats://localhost",
+		"nats:///subject",
+	} {
+		t.Run(u, func(t *testing.T) {
+			if _, err := NewPublisher(ctx, u); err == nil {
+				t.Errorf("NewPublisher(%q) succeeded, want error", u)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// pubSubEndpoint is the Google Cloud Pub/Sub API used by PubSubPublisher.
+const pubSubEndpoint = "https://pubsub.googleapis.com/v1/"
+
+var pubSubTopic = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// PubSubPublisher is a Publisher which publishes messages to a Google Cloud Pub/Sub topic, using the Pub/Sub
+// REST API. Message attributes are set as Pub/Sub message attributes. Publishing fails if Pub/Sub doesn't
+// respond within 30 seconds, or by the deadline of the context passed to Publish if it has one.
+type PubSubPublisher struct {
+	client   *http.Client
+	endpoint string
+	topic    string
+	timeout  time.Duration
+}
+
+// NewPubSubPublisher returns a PubSubPublisher which publishes messages to topic, which has the form
+// projects/<project>/topics/<topic>, authenticating with Application Default Credentials.
+func NewPubSubPublisher(ctx context.Context, topic string) (*PubSubPublisher, error) {
+	if !pubSubTopic.MatchString(topic) {
+		return nil, fmt.Errorf("invalid Pub/Sub topic %q, want projects/<project>/topics/<topic>", topic)
+	}
+	c, _, err := htransport.NewClient(ctx, option.WithScopes("https://www.googleapis.com/auth/pubsub"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %v", err)
+	}
+	return &PubSubPublisher{client: c, endpoint: pubSubEndpoint, topic: topic, timeout: defaultPublishTimeout}, nil
+}
+
+func (p *PubSubPublisher) Publish(ctx context.Context, msg Message) error {
+	ctx, cancel := withDefaultTimeout(ctx, p.timeout)
+	defer cancel()
+	type pubSubMessage struct {
+		// Data is base64 encoded by encoding/json, as the API requires.
+		Data       []byte            `json:"data"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+	}
+	body, err := json.Marshal(struct {
+		Messages []pubSubMessage `json:"messages"`
+	}{Messages: []pubSubMessage{{Data: msg.Data, Attributes: msg.Attributes}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+p.topic+":publish", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %q: %v", p.topic, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to publish to %q: %s: %s", p.topic, resp.Status, bytes.TrimSpace(b))
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsDefaultPort is used if the address of a NATS server doesn't specify one.
+const natsDefaultPort = "4222"
+
+// NATSPublisher is a Publisher which publishes messages to a subject on a NATS server, using the core NATS
+// protocol. Message attributes are sent as NATS headers.
+//
+// The server acknowledges each message once it has received it, so for messages to survive the loss of
+// subscribers, a JetStream stream should capture the subject. Publishing fails if the server doesn't respond
+// within 30 seconds, or by the deadline of the context passed to Publish if it has one.
+type NATSPublisher struct {
+	addr    string
+	subject string
+	connect []byte
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewNATSPublisher returns a NATSPublisher which publishes messages to subject on the server at u, which has
+// the form nats://[user:password@]host[:port]. The connection to the server is made when the first message is
+// published, and remade if it fails.
+func NewNATSPublisher(u *url.URL, subject string) (*NATSPublisher, error) {
+	if u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS server URL %q", u.Redacted())
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("invalid NATS subject %q", subject)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+	opts := map[string]any{"verbose": false, "pedantic": false, "headers": true, "name": "tessera"}
+	if u.User != nil {
+		opts["user"] = u.User.Username()
+		opts["pass"], _ = u.User.Password()
+	}
+	c, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{addr: addr, subject: subject, connect: c, timeout: defaultPublishTimeout}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, msg Message) error {
+	// The lock is held while waiting for the server, so this must always be bounded.
+	ctx, cancel := withDefaultTimeout(ctx, p.timeout)
+	defer cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.publish(ctx, msg); err != nil {
+		// Start afresh next time, since the state of the connection is unknown.
+		p.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to the server, if there is one.
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closeLocked()
+}
+
+func (p *NATSPublisher) closeLocked() error {
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.r = nil, nil
+	return err
+}
+
+// publish sends msg to the server, followed by a PING, and waits for the PONG which shows that the server has
+// processed the message.
+func (p *NATSPublisher) publish(ctx context.Context, msg Message) error {
+	if p.conn == nil {
+		if err := p.dial(ctx); err != nil {
+			return err
+		}
+	}
+	conn := p.conn
+	d, _ := ctx.Deadline()
+	if err := conn.SetDeadline(d); err != nil {
+		return err
+	}
+	// Also give up if ctx is cancelled before its deadline.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	var hdr bytes.Buffer
+	hdr.WriteString("NATS/1.0\r\n")
+	for _, k := range slices.Sorted(maps.Keys(msg.Attributes)) {
+		fmt.Fprintf(&hdr, "%s: %s\r\n", k, msg.Attributes[k])
+	}
+	hdr.WriteString("\r\n")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "HPUB %s %d %d\r\n", p.subject, hdr.Len(), hdr.Len()+len(msg.Data))
+	b.Write(hdr.Bytes())
+	b.Write(msg.Data)
+	b.WriteString("\r\nPING\r\n")
+	if _, err := p.conn.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to send message: %v", err)
+	}
+	return p.awaitPong()
+}
+
+// dial connects to the server and completes the protocol handshake.
+func (p *NATSPublisher) dial(ctx context.Context) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS server: %v", err)
+	}
+	p.conn, p.r = conn, bufio.NewReader(conn)
+	d, _ := ctx.Deadline()
+	if err := conn.SetDeadline(d); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+	line, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting from NATS server: %q", line)
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", p.connect); err != nil {
+		return fmt.Errorf("failed to send CONNECT: %v", err)
+	}
+	return p.awaitPong()
+}
+
+// awaitPong reads from the server until it receives a PONG, answering any PINGs from the server.
+func (p *NATSPublisher) awaitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server returned error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case line == "+OK", strings.HasPrefix(line, "INFO "):
+			// Acknowledgements and updated server information can be ignored.
+		default:
+			return fmt.Errorf("unexpected message from NATS server: %q", line)
+		}
+	}
+}
+
+func (p *NATSPublisher) readLine() (string, error) {
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read from NATS server: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultFollowerBatchSize is used by NewFollower if FollowerOptions.BatchSize is unset.
+	DefaultFollowerBatchSize = 256
+	// DefaultFollowerNumFetchers is used by NewFollower if FollowerOptions.NumFetchers is unset.
+	DefaultFollowerNumFetchers = 10
+	// DefaultFollowerPollInterval is used by NewFollower if FollowerOptions.PollInterval is unset.
+	DefaultFollowerPollInterval = time.Second
+	// DefaultFollowerMaxRetryInterval is used by NewFollower if FollowerOptions.MaxRetryInterval is unset.
+	DefaultFollowerMaxRetryInterval = 30 * time.Second
+)
+
+// FollowerPositionStore persists the progress of a follower created by NewFollower.
+//
+// Implementations should store the position in the same place as the follower's own output where
+// possible (e.g. in the same database), so that the two can be updated together.
+type FollowerPositionStore interface {
+	// Position returns the number of log entries already processed, i.e. the index of the next entry
+	// which should be processed. Zero must be returned if the follower has not yet processed anything.
+	Position(ctx context.Context) (uint64, error)
+	// SetPosition durably stores the number of log entries processed.
+	SetPosition(ctx context.Context, pos uint64) error
+}
+
+// FollowerProcessFunc is the signature of a function which processes a batch of contiguous log entries.
+//
+// The first entry in the batch has index first in the log. If an error is returned, the follower's position is
+// not updated, and the batch will be retried later. Since the position is only updated after this function
+// returns successfully, implementations must be prepared to see the same entries more than once if the
+// process is restarted. Followers which can't tolerate this should use NewTxFollower instead.
+type FollowerProcessFunc func(ctx context.Context, first uint64, entries [][]byte) error
+
+// FollowerTxPositionStore persists the progress of a follower created by NewTxFollower, in the same
+// transaction as the follower's output.
+//
+// T is the type of the transaction, e.g. *sql.Tx, and implementations are typically provided by storage drivers.
+type FollowerTxPositionStore[T any] interface {
+	// Position returns the number of log entries already processed, i.e. the index of the next entry
+	// which should be processed. Zero must be returned if the follower has not yet processed anything.
+	Position(ctx context.Context) (uint64, error)
+	// Update starts a transaction, and checks that the stored position is still from before calling f with it.
+	// If f returns successfully, the position is set to to in the same transaction, which is then committed.
+	// Otherwise, the transaction is rolled back and an error returned.
+	Update(ctx context.Context, from, to uint64, f func(ctx context.Context, tx T) error) error
+}
+
+// FollowerTxProcessFunc is the signature of a function which processes a batch of contiguous log entries
+// as part of the transaction tx.
+//
+// The first entry in the batch has index first in the log. The follower's position is updated in the same
+// transaction, so any changes made through tx are committed if and only if the position is advanced past
+// the batch. Implementations must not commit or roll back tx themselves.
+type FollowerTxProcessFunc[T any] func(ctx context.Context, tx T, first uint64, entries [][]byte) error
+
+// FollowerOptions holds optional settings for followers created by NewFollower.
+type FollowerOptions struct {
+	// BatchSize is the maximum number of entries passed to each call to the process function.
+	BatchSize uint
+	// NumFetchers is the number of entry bundles which will be fetched at a time, using a single call to
+	// LogReader.ReadEntryBundles.
+	NumFetchers uint
+	// PollInterval is how long to wait before checking for new entries once the follower has caught up.
+	PollInterval time.Duration
+	// MaxRetryInterval caps the exponential backoff used when a failure occurs.
+	MaxRetryInterval time.Duration
+	// Unbundle knows how to split a serialised entry bundle into its entries.
+	// If unset, bundles are parsed as https://c2sp.org/tlog-tiles entry bundles.
+	Unbundle func(bundle []byte) ([][]byte, error)
+	// FollowPublished, if true, causes the follower to only process entries committed to by the published checkpoint,
+	// rather than entries which have been integrated but not yet published. Followers which act on behalf of, or
+	// publish information to, external parties should set this.
+	FollowPublished bool
+}
+
+// NewFollower returns a Follower which streams entries from the log in order, passing them to process in batches,
+// and recording its progress in pos.
+//
+// Errors reading from the log, processing entries, or updating the position are logged and retried with
+// exponential backoff, picking up from the last successfully stored position.
+//
+// The returned follower can be attached to an Appender using AppendOptions.WithFollower, in which case its lag
+// will be reported via metrics.
+func NewFollower(name string, pos FollowerPositionStore, process FollowerProcessFunc, opts *FollowerOptions) Follower {
+	apply := func(ctx context.Context, first uint64, entries [][]byte) error {
+		next := first + uint64(len(entries))
+		if err := process(ctx, first, entries); err != nil {
+			return fmt.Errorf("failed to process entries [%d, %d): %v", first, next, err)
+		}
+		if err := pos.SetPosition(ctx, next); err != nil {
+			return fmt.Errorf("failed to store position %d: %v", next, err)
+		}
+		return nil
+	}
+	return newStreamingFollower(name, pos, apply, opts)
+}
+
+// NewTxFollower returns a Follower which streams entries from the log in order, passing them to process in
+// batches along with a transaction from pos, in which its progress is also recorded.
+//
+// Unlike followers returned by NewFollower, each batch is processed exactly once provided that all of the
+// follower's output is written via the transaction: a crash between processing a batch and storing the new
+// position can't happen, since both are committed together. Update also refuses to commit if the stored
+// position has moved, so a second instance of the follower can't apply the same batch twice either.
+//
+// Errors are handled as for NewFollower, and the same options apply.
+func NewTxFollower[T any](name string, pos FollowerTxPositionStore[T], process FollowerTxProcessFunc[T], opts *FollowerOptions) Follower {
+	apply := func(ctx context.Context, first uint64, entries [][]byte) error {
+		next := first + uint64(len(entries))
+		if err := pos.Update(ctx, first, next, func(ctx context.Context, tx T) error {
+			return process(ctx, tx, first, entries)
+		}); err != nil {
+			return fmt.Errorf("failed to process entries [%d, %d): %v", first, next, err)
+		}
+		return nil
+	}
+	return newStreamingFollower(name, pos, apply, opts)
+}
+
+// positionReader is the part of a position store which the follower needs outside of applying a batch.
+type positionReader interface {
+	Position(ctx context.Context) (uint64, error)
+}
+
+func newStreamingFollower(name string, pos positionReader, apply func(ctx context.Context, first uint64, entries [][]byte) error, opts *FollowerOptions) *streamingFollower {
+	o := FollowerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize == 0 {
+		o.BatchSize = DefaultFollowerBatchSize
+	}
+	if o.NumFetchers == 0 {
+		o.NumFetchers = DefaultFollowerNumFetchers
+	}
+	if o.PollInterval == 0 {
+		o.PollInterval = DefaultFollowerPollInterval
+	}
+	if o.MaxRetryInterval == 0 {
+		o.MaxRetryInterval = DefaultFollowerMaxRetryInterval
+	}
+	if o.Unbundle == nil {
+		o.Unbundle = unbundleEntries
+	}
+	return &streamingFollower{
+		name:  name,
+		pos:   pos,
+		apply: apply,
+		opts:  o,
+	}
+}
+
+// streamingFollower is the Follower implementation returned by NewFollower and NewTxFollower.
+type streamingFollower struct {
+	name string
+	pos  positionReader
+	// apply processes a batch of entries starting at first, and advances the stored position past them.
+	apply func(ctx context.Context, first uint64, entries [][]byte) error
+	opts  FollowerOptions
+}
+
+func (f *streamingFollower) Name() string {
+	return f.name
+}
+
+func (f *streamingFollower) EntriesProcessed(ctx context.Context) (uint64, error) {
+	return f.pos.Position(ctx)
+}
+
+// Follow processes entries from the log until ctx is done.
+func (f *streamingFollower) Follow(ctx context.Context, lr LogReader) {
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxInterval = f.opts.MaxRetryInterval
+	attrs := metric.WithAttributes(followerNameKey.String(f.name))
+	// If the log can tell us when it changes, catch up as soon as it does rather than waiting for the next poll.
+	var changes <-chan LogChange
+	if w, ok := lr.(LogWatcher); ok {
+		changes = w.Watch(ctx)
+	}
+	for {
+		wait, wake := f.opts.PollInterval, changes
+		if err := f.catchUp(ctx, lr); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			followerErrors.Add(ctx, 1, attrs)
+			wait, wake = bo.NextBackOff(), nil
+			klog.Warningf("Follower %q: %v (retrying in %v)", f.name, err, wait)
+		} else {
+			bo.Reset()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		case <-wake:
+		}
+	}
+}
+
+// catchUp processes all entries between the stored position and the current size of the log.
+func (f *streamingFollower) catchUp(ctx context.Context, lr LogReader) error {
+	ctx, span := tracer.Start(ctx, "tessera.follower.CatchUp")
+	defer span.End()
+
+	from, err := f.pos.Position(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read position: %v", err)
+	}
+	size, err := f.logSize(ctx, lr)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The log probably just hasn't completed its first integration yet.
+			return nil
+		}
+		return fmt.Errorf("failed to read log size: %v", err)
+	}
+	switch {
+	case from > size:
+		return fmt.Errorf("follower position %d is beyond log size %d", from, size)
+	case from == size:
+		return nil
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sizeFn := func(_ context.Context) (uint64, error) { return size, nil }
+	entries := client.Entries(client.BulkEntryBundles(cctx, f.opts.NumFetchers, sizeFn, lr.ReadEntryBundles, lr.ReadEntryBundle, from, size-from), f.opts.Unbundle)
+
+	batch := make([][]byte, 0, f.opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := f.apply(ctx, from, batch); err != nil {
+			return err
+		}
+		from += uint64(len(batch))
+		batch = make([][]byte, 0, f.opts.BatchSize)
+		return nil
+	}
+	for e, err := range entries {
+		if err != nil {
+			return fmt.Errorf("failed to read entries: %v", err)
+		}
+		if want := from + uint64(len(batch)); e.Index != want {
+			return fmt.Errorf("out of sync: got entry %d, want %d", e.Index, want)
+		}
+		batch = append(batch, e.Entry)
+		if uint(len(batch)) >= f.opts.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// logSize returns the size of the tree which the follower should process up to.
+func (f *streamingFollower) logSize(ctx context.Context, lr LogReader) (uint64, error) {
+	if !f.opts.FollowPublished {
+		return lr.IntegratedSize(ctx)
+	}
+	cp, err := lr.ReadCheckpoint(ctx)
+	if err != nil {
+		return 0, err
+	}
+	_, size, _, err := parse.CheckpointUnsafe(cp)
+	return size, err
+}
+
+// unbundleEntries parses a https://c2sp.org/tlog-tiles entry bundle and returns the entries it contains.
+func unbundleEntries(bundle []byte) ([][]byte, error) {
+	eb := &api.EntryBundle{}
+	if err := eb.UnmarshalText(bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal: %v", err)
+	}
+	return eb.Entries, nil
+}
+
+// NewFilePositionStore returns a FollowerPositionStore which keeps the position in a file at the given path.
+func NewFilePositionStore(path string) FollowerPositionStore {
+	return &filePositionStore{path: path}
+}
+
+// filePositionStore is a FollowerPositionStore which atomically overwrites a small file on each update.
+type filePositionStore struct {
+	path string
+}
+
+func (s *filePositionStore) Position(_ context.Context) (uint64, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+}
+
+// SetPosition writes pos to a temporary file, which is synced before being renamed over the position file.
+// The containing directory is then synced too, so that the rename itself survives a crash.
+func (s *filePositionStore) SetPosition(_ context.Context, pos uint64) error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(strconv.FormatUint(pos, 10))); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %q: %v", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync %q: %v", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %v", dir, err)
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return fmt.Errorf("failed to sync %q: %v", dir, err)
+	}
+	return d.Close()
+}
+
+// NewInMemoryPositionStore returns a FollowerPositionStore which does not persist the position across restarts.
+//
+// This is intended for followers whose output is itself held only in memory,
+// or for testing.
+func NewInMemoryPositionStore() FollowerPositionStore {
+	return &memPositionStore{}
+}
+
+type memPositionStore struct {
+	pos atomic.Uint64
+}
+
+func (s *memPositionStore) Position(_ context.Context) (uint64, error) {
+	return s.pos.Load(), nil
+}
+
+func (s *memPositionStore) SetPosition(_ context.Context, pos uint64) error {
+	s.pos.Store(pos)
+	return nil
+}
+
+// WithFollower attaches a Follower to the Appender, which will be started when the Appender is created,
+// and have its progress reported via metrics.
+//
+// Followers created with NewFollower are suitable for use here.
+func (o *AppendOptions) WithFollower(f Follower) *AppendOptions {
+	o.followers = append(o.followers, f)
+	return o
+}
+
+// FollowerProgress returns the number of log entries processed by each of the followers started by the
+// Appender, including any used by antispam, keyed by follower name.
+func (a *Appender) FollowerProgress(ctx context.Context) (map[string]uint64, error) {
+	p := make(map[string]uint64, len(a.followers))
+	for _, f := range a.followers {
+		n, err := f.EntriesProcessed(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("follower %q: %v", f.Name(), err)
+		}
+		p[f.Name()] = n
+	}
+	return p, nil
+}
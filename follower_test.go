@@ -0,0 +1,306 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// fakeLogReader serves entry bundles for a log containing size entries of the form "entry N".
+type fakeLogReader struct {
+	size uint64
+}
+
+func (f *fakeLogReader) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return fmt.Appendf(nil, "origin\n%d\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", f.size), nil
+}
+
+func (f *fakeLogReader) ReadTile(_ context.Context, _, _ uint64, _ uint8) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+func (f *fakeLogReader) ReadEntryBundle(_ context.Context, i uint64, p uint8) ([]byte, error) {
+	n := uint64(p)
+	if n == 0 {
+		n = layout.EntryBundleWidth
+	}
+	var b []byte
+	for j := range n {
+		e := NewEntry(fmt.Appendf(nil, "entry %d", i*layout.EntryBundleWidth+j))
+		b = append(b, e.MarshalBundleData(0)...)
+	}
+	return b, nil
+}
+
+func (f *fakeLogReader) ReadEntryBundles(ctx context.Context, from, count uint64) ([][]byte, error) {
+	r := make([][]byte, 0, count)
+	for i := from; i < from+count; i++ {
+		b, err := f.ReadEntryBundle(ctx, i, 0)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, b)
+	}
+	return r, nil
+}
+
+func (f *fakeLogReader) NextIndex(_ context.Context) (uint64, error) {
+	return f.size, nil
+}
+
+func (f *fakeLogReader) IntegratedSize(_ context.Context) (uint64, error) {
+	return f.size, nil
+}
+
+func (f *fakeLogReader) ReadInternalCheckpoint(_ context.Context) (InternalCheckpoint, error) {
+	return InternalCheckpoint{Size: f.size}, nil
+}
+
+func (f *fakeLogReader) RootAt(_ context.Context, _ uint64) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+func TestFollower(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		size      uint64
+		batchSize uint
+		failAt    uint64
+	}{
+		{name: "empty", size: 0, batchSize: 10},
+		{name: "single bundle", size: 17, batchSize: 10},
+		{name: "multiple bundles", size: 3*layout.EntryBundleWidth + 5, batchSize: 100},
+		{name: "retries failure", size: 600, batchSize: 64, failAt: 128},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var (
+				mu     sync.Mutex
+				seen   []string
+				failed bool
+			)
+			process := func(_ context.Context, first uint64, entries [][]byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if uint(len(entries)) > test.batchSize {
+					t.Errorf("got batch of %d entries, want <= %d", len(entries), test.batchSize)
+				}
+				if test.failAt > 0 && first == test.failAt && !failed {
+					failed = true
+					return errors.New("boom")
+				}
+				if first != uint64(len(seen)) {
+					return fmt.Errorf("got first %d, want %d", first, len(seen))
+				}
+				for _, e := range entries {
+					seen = append(seen, string(e))
+				}
+				return nil
+			}
+			pos := NewFilePositionStore(filepath.Join(t.TempDir(), "pos"))
+			f := NewFollower("test", pos, process, &FollowerOptions{
+				BatchSize:        test.batchSize,
+				PollInterval:     10 * time.Millisecond,
+				MaxRetryInterval: 10 * time.Millisecond,
+			})
+			go f.Follow(ctx, &fakeLogReader{size: test.size})
+
+			for {
+				n, err := f.EntriesProcessed(ctx)
+				if err != nil {
+					t.Fatalf("EntriesProcessed: %v", err)
+				}
+				if n == test.size {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for i, e := range seen {
+				if want := fmt.Sprintf("entry %d", i); e != want {
+					t.Fatalf("entry %d is %q, want %q", i, e, want)
+				}
+			}
+			if test.failAt > 0 && !failed {
+				t.Error("process never failed")
+			}
+		})
+	}
+}
+
+func TestFilePositionStore(t *testing.T) {
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "sub", "pos")
+	s := NewFilePositionStore(p)
+	if got, err := s.Position(ctx); err != nil || got != 0 {
+		t.Fatalf("Position() = %d, %v, want 0, nil", got, err)
+	}
+	if err := s.SetPosition(ctx, 1234); err != nil {
+		t.Fatalf("SetPosition: %v", err)
+	}
+	if got, err := NewFilePositionStore(p).Position(ctx); err != nil || got != 1234 {
+		t.Fatalf("Position() = %d, %v, want 1234, nil", got, err)
+	}
+	// Overwriting with a shorter value mustn't leave any of the old one behind.
+	if err := s.SetPosition(ctx, 56); err != nil {
+		t.Fatalf("SetPosition: %v", err)
+	}
+	if got, err := NewFilePositionStore(p).Position(ctx); err != nil || got != 56 {
+		t.Fatalf("Position() = %d, %v, want 56, nil", got, err)
+	}
+	if _, err := os.Stat(p + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestFollowerProgress(t *testing.T) {
+	ctx := context.Background()
+	a, b := NewInMemoryPositionStore(), NewInMemoryPositionStore()
+	if err := b.SetPosition(ctx, 42); err != nil {
+		t.Fatalf("SetPosition: %v", err)
+	}
+	process := func(context.Context, uint64, [][]byte) error { return nil }
+	app := &Appender{followers: []Follower{NewFollower("a", a, process, nil), NewFollower("b", b, process, nil)}}
+	got, err := app.FollowerProgress(ctx)
+	if err != nil {
+		t.Fatalf("FollowerProgress: %v", err)
+	}
+	if want := map[string]uint64{"a": 0, "b": 42}; !maps.Equal(got, want) {
+		t.Errorf("FollowerProgress = %v, want %v", got, want)
+	}
+}
+
+// memTxPositionStore is a FollowerTxPositionStore whose output is a list of entries, which transactions append to.
+type memTxPositionStore struct {
+	mu  sync.Mutex
+	pos uint64
+	out []string
+}
+
+// memTx buffers the entries written by a transaction until it's committed.
+type memTx struct {
+	entries []string
+}
+
+func (s *memTxPositionStore) Position(_ context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pos, nil
+}
+
+func (s *memTxPositionStore) Update(ctx context.Context, from, to uint64, f func(context.Context, *memTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pos != from {
+		return fmt.Errorf("position is %d, not %d", s.pos, from)
+	}
+	tx := &memTx{}
+	if err := f(ctx, tx); err != nil {
+		return err
+	}
+	s.out = append(s.out, tx.entries...)
+	s.pos = to
+	return nil
+}
+
+func TestTxFollower(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		size      uint64
+		batchSize uint
+		failAt    uint64
+	}{
+		{name: "empty", size: 0, batchSize: 10},
+		{name: "multiple bundles", size: 3*layout.EntryBundleWidth + 5, batchSize: 100},
+		{name: "failure part way through batch", size: 600, batchSize: 64, failAt: 150},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			failed := false
+			process := func(_ context.Context, tx *memTx, first uint64, entries [][]byte) error {
+				for i, e := range entries {
+					if test.failAt > 0 && first+uint64(i) == test.failAt && !failed {
+						failed = true
+						return errors.New("boom")
+					}
+					tx.entries = append(tx.entries, string(e))
+				}
+				return nil
+			}
+			pos := &memTxPositionStore{}
+			f := NewTxFollower("test", pos, process, &FollowerOptions{
+				BatchSize:        test.batchSize,
+				PollInterval:     10 * time.Millisecond,
+				MaxRetryInterval: 10 * time.Millisecond,
+			})
+			go f.Follow(ctx, &fakeLogReader{size: test.size})
+
+			for {
+				n, err := f.EntriesProcessed(ctx)
+				if err != nil {
+					t.Fatalf("EntriesProcessed: %v", err)
+				}
+				if n == test.size {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			pos.mu.Lock()
+			defer pos.mu.Unlock()
+			if got := uint64(len(pos.out)); got != test.size {
+				t.Fatalf("got %d entries written, want %d", got, test.size)
+			}
+			for i, e := range pos.out {
+				if want := fmt.Sprintf("entry %d", i); e != want {
+					t.Fatalf("entry %d is %q, want %q", i, e, want)
+				}
+			}
+			if test.failAt > 0 && !failed {
+				t.Error("process never failed")
+			}
+		})
+	}
+}
+
+func TestTxFollowerPositionMoved(t *testing.T) {
+	ctx := context.Background()
+	pos := &memTxPositionStore{}
+	f := NewTxFollower("test", pos, func(context.Context, *memTx, uint64, [][]byte) error { return nil }, nil).(*streamingFollower)
+	// Another instance of the follower has already applied the first batch.
+	if err := pos.Update(ctx, 0, 10, func(context.Context, *memTx) error { return nil }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := f.apply(ctx, 0, make([][]byte, 10)); err == nil {
+		t.Error("apply succeeded with stale position, want error")
+	}
+	if got, _ := pos.Position(ctx); got != 10 {
+		t.Errorf("Position() = %d, want 10", got)
+	}
+}
@@ -19,7 +19,13 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/transparency-dev/merkle/compact"
@@ -39,19 +45,116 @@ type Fetcher interface {
 	ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error)
 }
 
+// TileWriter describes a struct which knows how to store tlog-tiles tile resources in a log, for use by Repair.
+type TileWriter interface {
+	// WriteTile atomically creates or replaces the tile resource at the given coordinates.
+	WriteTile(ctx context.Context, l, i uint64, p uint8, data []byte) error
+}
+
+// Problem describes a resource in the log which is missing, or which doesn't have the expected contents.
+type Problem struct {
+	// Path is the path of the resource, relative to the root of the log.
+	Path string
+	// Missing is true if the resource doesn't exist, rather than being corrupt.
+	Missing bool
+	// Repaired is true if the resource has been replaced with the correct contents by Repair.
+	Repaired bool
+	Err      error
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s: %v", p.Path, p.Err)
+}
+
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+// Report describes the outcome of checking a log.
+type Report struct {
+	// Size and Root are the tree size and root hash committed to by the log's checkpoint.
+	Size uint64
+	Root []byte
+	// Problems lists the missing and corrupt resources which were found, ordered by path.
+	Problems []Problem
+	// Unrecoverable lists the ranges of entries, in order, whose entry bundles are missing or corrupt. These
+	// entries can't be recovered from the log's other resources, even if their leaf hashes can.
+	Unrecoverable []EntryRange
+}
+
+// EntryRange is the range of entries with indices in [Start, End).
+type EntryRange struct {
+	Start, End uint64
+}
+
+func (r EntryRange) String() string {
+	return fmt.Sprintf("[%d, %d)", r.Start, r.End)
+}
+
+// Err returns an error describing all of the problems found which haven't been repaired, or nil if there
+// were none.
+func (r *Report) Err() error {
+	var errs []error
+	for _, p := range r.Problems {
+		if !p.Repaired {
+			errs = append(errs, p)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("found %d problems with log of size %d: %w", len(errs), r.Size, errors.Join(errs...))
+}
+
 // Check performs an integrity check against a log via the provided fetcher, using the provided
 // bundleHasher to parse and convert entries from the log's entry bundles into leaf hashes.
 //
+// This is equivalent to calling Verify, and returning the error from the Report.
+func Check(ctx context.Context, origin string, verifier note.Verifier, f Fetcher, N uint, bundleHasher func([]byte) ([][]byte, error)) error {
+	r, err := Verify(ctx, origin, verifier, f, N, bundleHasher)
+	if err != nil {
+		return err
+	}
+	return r.Err()
+}
+
+// Verify performs an integrity check against a log via the provided fetcher, using the provided
+// bundleHasher to parse and convert entries from the log's entry bundles into leaf hashes.
+//
 // The leaf hashes are used to:
 // 1. re-construct the root hash of the log, and compare it against the value in the log's checkpoint
 // 2. re-construct the internal tiles of the log, and compare them against the log's tile resources.
 //
+// Rather than stopping at the first problem, all of the log's resources are checked, and any which are
+// missing or corrupt are listed in the returned Report. If an entry bundle is missing or corrupt, the leaf
+// hashes from the corresponding level 0 tile are used in its place, so that the rest of the log can still be
+// checked. An error is returned only if the log couldn't be checked at all, e.g. because its checkpoint
+// couldn't be fetched or verified.
+//
 // The checking will use the provided N parameter to control the number of concurrent workers undertaking
 // this process.
-func Check(ctx context.Context, origin string, verifier note.Verifier, f Fetcher, N uint, bundleHasher func([]byte) ([][]byte, error)) error {
+func Verify(ctx context.Context, origin string, verifier note.Verifier, f Fetcher, N uint, bundleHasher func([]byte) ([][]byte, error)) (*Report, error) {
+	return check(ctx, origin, verifier, f, nil, N, bundleHasher)
+}
+
+// Repair is like Verify, but also uses w to replace any tiles which are missing or corrupt with the tiles
+// re-derived from the log's entry bundles. Any problems which are fixed are marked as Repaired in the
+// returned Report.
+//
+// Tiles are only replaced once the root hash re-derived from all of the log's leaf hashes has been verified
+// against the log's checkpoint. Entry bundles can't be re-derived from tiles, so missing or corrupt entry
+// bundles are never repaired, and the entries they contain are listed in Report.Unrecoverable instead.
+//
+// The log must not be written to while it's being repaired.
+func Repair(ctx context.Context, origin string, verifier note.Verifier, f Fetcher, w TileWriter, N uint, bundleHasher func([]byte) ([][]byte, error)) (*Report, error) {
+	return check(ctx, origin, verifier, f, w, N, bundleHasher)
+}
+
+// check implements Verify, and Repair if w is not nil.
+func check(ctx context.Context, origin string, verifier note.Verifier, f Fetcher, w TileWriter, N uint, bundleHasher func([]byte) ([][]byte, error)) (*Report, error) {
 	cp, cpRaw, _, err := client.FetchCheckpoint(ctx, f.ReadCheckpoint, verifier, origin)
 	if err != nil {
-		klog.Exitf("Failed to fetch and verify checkpoint: %v", err)
+		return nil, fmt.Errorf("failed to fetch and verify checkpoint: %v", err)
 	}
 	klog.Infof("Fsck: checking log of size %d", cp.Size)
 
@@ -64,6 +167,7 @@ func Check(ctx context.Context, origin string, verifier note.Verifier, f Fetcher
 		sourceSize:        cp.Size,
 		pendingTiles:      make(map[compact.NodeID]*api.HashTile),
 		expectedResources: make(chan resource, N),
+		repair:            w != nil,
 	}
 
 	// Set up a stream of entry bundles from the log to be checked.
@@ -76,14 +180,28 @@ func Check(ctx context.Context, origin string, verifier note.Verifier, f Fetcher
 	}
 
 	getSize := func(_ context.Context) (uint64, error) { return cp.Size, nil }
+	// Entry bundles which can't be read are reported as problems, rather than ending the stream, and are
+	// passed to AppendBundle without any data.
+	readBundle := func(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+		b, err := f.ReadEntryBundle(ctx, i, p)
+		if err != nil {
+			fTree.addProblem(layout.EntriesPath(i, p), err)
+			return nil, nil
+		}
+		return b, nil
+	}
 	// Consume the stream of bundles to re-derive the other log resources.
 	// TODO(al): consider chunking the log and doing each in parallel.
-	for b, err := range client.EntryBundles(ctx, N, getSize, f.ReadEntryBundle, 0, cp.Size) {
+	var walkErr error
+	for b, err := range client.EntryBundles(ctx, N, getSize, readBundle, 0, cp.Size) {
 		if err != nil {
-			return fmt.Errorf("error while streaming bundles: %v", err)
+			walkErr = fmt.Errorf("error while streaming bundles: %v", err)
+			break
 		}
-		if err := fTree.AppendBundle(b.RangeInfo, b.Data); err != nil {
-			return fmt.Errorf("failure calling AppendBundle(%v): %v", b.RangeInfo, err)
+		if err := fTree.AppendBundle(ctx, b.RangeInfo, b.Data); err != nil {
+			// The leaf hashes for these entries aren't available, so the rest of the tree can't be re-derived.
+			fTree.addProblem(layout.CheckpointPath, fmt.Errorf("unable to verify root hash, since leaf hashes from index %d are unavailable: %v", fTree.tree.End(), err))
+			break
 		}
 		if fTree.tree.End() >= cp.Size {
 			break
@@ -97,21 +215,54 @@ func Check(ctx context.Context, origin string, verifier note.Verifier, f Fetcher
 
 	// Wait for all the work to be done.
 	if err := eg.Wait(); err != nil {
-		klog.Exitf("Failed: %v", err)
+		return nil, err
+	}
+	if walkErr != nil {
+		return nil, walkErr
 	}
 
 	// Finally, check that the claimed root hash matches what we calculated.
-	gotRoot, err := fTree.tree.GetRootHash(nil)
-	switch {
-	case err != nil:
-		klog.Exitf("Failed to calculate root: %v", err)
-	case !bytes.Equal(gotRoot, cp.Hash):
-		klog.Exitf("Calculated root %x, but checkpoint claims %x", gotRoot, cp.Hash)
-	default:
-		klog.Infof("Successfully fsck'd log with size %d and root %s (%x)", cp.Size, base64.StdEncoding.EncodeToString(gotRoot), gotRoot)
+	rootVerified := false
+	if fTree.tree.End() == cp.Size {
+		gotRoot, err := fTree.tree.GetRootHash(nil)
+		if cp.Size == 0 {
+			// The compact range doesn't know the root hash of the empty tree.
+			gotRoot = rfc6962.DefaultHasher.EmptyRoot()
+		}
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("failed to calculate root: %v", err)
+		case !bytes.Equal(gotRoot, cp.Hash):
+			fTree.addProblem(layout.CheckpointPath, fmt.Errorf("calculated root %x, but checkpoint claims %x", gotRoot, cp.Hash))
+		default:
+			rootVerified = true
+		}
 	}
 
-	return nil
+	r := &Report{Size: cp.Size, Root: cp.Hash, Problems: fTree.problems, Unrecoverable: fTree.unrecoverable}
+	slices.SortFunc(r.Problems, func(a, b Problem) int { return strings.Compare(a.Path, b.Path) })
+	if w != nil && len(fTree.repairs) > 0 {
+		if !rootVerified {
+			klog.Warningf("Fsck: not repairing %d tiles, since the log's root hash couldn't be verified", len(fTree.repairs))
+		} else {
+			for _, t := range fTree.repairs {
+				p := layout.TilePath(t.level, t.index, t.partial)
+				if err := w.WriteTile(ctx, t.level, t.index, t.partial, t.content); err != nil {
+					return nil, fmt.Errorf("failed to repair %s: %v", p, err)
+				}
+				klog.Infof("Fsck: repaired %s", p)
+				for i := range r.Problems {
+					if r.Problems[i].Path == p {
+						r.Problems[i].Repaired = true
+					}
+				}
+			}
+		}
+	}
+	if r.Err() == nil {
+		klog.Infof("Successfully fsck'd log with size %d and root %s (%x)", cp.Size, base64.StdEncoding.EncodeToString(cp.Hash), cp.Hash)
+	}
+	return r, nil
 }
 
 // resource represents a single static tile resource on the log, and the derived content we expect it to contain.
@@ -140,17 +291,54 @@ type fsckTree struct {
 	// expectedResources is a channel of derived tlog resources which need to be verified against the source log's static resources.
 	// Entries in this channel are consumed by the resoruceCheckWorker functions.
 	expectedResources chan resource
+
+	// repair is true if tiles which are missing or corrupt should be collected in repairs.
+	repair bool
+	// unrecoverable holds the ranges of entries found so far whose entry bundles are missing or corrupt.
+	unrecoverable []EntryRange
+
+	// problems holds the missing and corrupt resources found so far, and repairs the correct contents of
+	// those which are tiles. Both are guarded by mu.
+	mu       sync.Mutex
+	problems []Problem
+	repairs  []resource
+}
+
+// addProblem records that the resource at path is missing or corrupt.
+func (f *fsckTree) addProblem(path string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.problems = append(f.problems, Problem{Path: path, Missing: errors.Is(err, os.ErrNotExist), Err: err})
 }
 
 // AppendBundle appends leaf hashes from the provided entry bundle.
-func (f *fsckTree) AppendBundle(ri layout.RangeInfo, data []byte) error {
+//
+// If data is nil, or is not a valid entry bundle, the leaf hashes are read from the corresponding level 0 tile
+// instead. An error is returned only if neither source of leaf hashes is usable.
+func (f *fsckTree) AppendBundle(ctx context.Context, ri layout.RangeInfo, data []byte) error {
 	if impliedSeq := ri.Index*layout.EntryBundleWidth + uint64(ri.First); impliedSeq != f.tree.End() {
 		return fmt.Errorf("bundle with implied sequence number %d but expected %d", impliedSeq, f.tree.End())
 	}
 
-	hs, err := f.bundleHasher(data)
-	if err != nil {
-		return err
+	var hs [][]byte
+	if data != nil {
+		var err error
+		hs, err = f.bundleHasher(data)
+		if err == nil && uint(len(hs)) < ri.First+ri.N {
+			err = fmt.Errorf("bundle has %d entries, expected at least %d", len(hs), ri.First+ri.N)
+		}
+		if err != nil {
+			f.addProblem(layout.EntriesPath(ri.Index, ri.Partial), err)
+			hs = nil
+		}
+	}
+	if hs == nil {
+		start := ri.Index*layout.EntryBundleWidth + uint64(ri.First)
+		f.addUnrecoverable(start, start+uint64(ri.N))
+		var err error
+		if hs, err = f.tileLeafHashes(ctx, ri); err != nil {
+			return err
+		}
 	}
 	for i := ri.First; i < ri.First+ri.N; i++ {
 		if err := f.tree.Append(hs[i], f.visit); err != nil {
@@ -160,6 +348,37 @@ func (f *fsckTree) AppendBundle(ri layout.RangeInfo, data []byte) error {
 	return nil
 }
 
+// addUnrecoverable records that the entries in [start, end) can't be recovered. Ranges must be added in order.
+func (f *fsckTree) addUnrecoverable(start, end uint64) {
+	if l := len(f.unrecoverable); l > 0 && f.unrecoverable[l-1].End == start {
+		f.unrecoverable[l-1].End = end
+		return
+	}
+	f.unrecoverable = append(f.unrecoverable, EntryRange{Start: start, End: end})
+}
+
+// tileLeafHashes returns the leaf hashes stored in the level 0 tile which corresponds to the entry bundle
+// described by ri. Any problem reading the tile is recorded, as well as being returned.
+func (f *fsckTree) tileLeafHashes(ctx context.Context, ri layout.RangeInfo) ([][]byte, error) {
+	p := layout.TilePath(0, ri.Index, ri.Partial)
+	data, err := f.fetcher.ReadTile(ctx, 0, ri.Index, ri.Partial)
+	if err != nil {
+		f.addProblem(p, err)
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	t := &api.HashTile{}
+	if err := t.UnmarshalText(data); err != nil {
+		f.addProblem(p, err)
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	if uint(len(t.Nodes)) < ri.First+ri.N {
+		err := fmt.Errorf("tile has %d hashes, expected at least %d", len(t.Nodes), ri.First+ri.N)
+		f.addProblem(p, err)
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return t.Nodes, nil
+}
+
 // visit is used to populate the derived tiles as we consume entries from the log we're checking.
 func (f *fsckTree) visit(id compact.NodeID, h []byte) {
 	// We're only storing the lowest level of hash in the tiles, so early-out in other cases.
@@ -210,6 +429,16 @@ func (f *fsckTree) flushPartialTiles() {
 	}
 }
 
+// addRepair records the correct contents of a tile which is missing or corrupt, if repairs are enabled.
+func (f *fsckTree) addRepair(r resource) {
+	if !f.repair {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repairs = append(f.repairs, r)
+}
+
 var resourceWorkerID atomic.Uint32
 
 // resourceCheckWorker returns a func which will consume resource check jobs from the
@@ -219,20 +448,66 @@ func (f *fsckTree) resourceCheckWorker(ctx context.Context) func() error {
 
 	return func() error {
 		for r := range f.expectedResources {
+			p := layout.TilePath(r.level, r.index, r.partial)
 			data, err := f.fetcher.ReadTile(ctx, r.level, r.index, r.partial)
 			if err != nil {
-				return err
+				f.addProblem(p, err)
+				f.addRepair(r)
+				continue
 			}
 			if l, e := uint(len(data)), uint(r.partial)*sha256.Size; r.partial != 0 && l > e {
 				// We were likely given a full tile rather than a partial tile, so trim it to the expected size.
 				data = data[:e]
 			}
-			p := layout.TilePath(r.level, r.index, r.partial)
 			if !bytes.Equal(data, r.content) {
-				return fmt.Errorf("%s: log has:\n%x\nexpected:\n%x", p, data, r.content)
+				klog.V(1).Infof("%s: log has:\n%x\nexpected:\n%x", p, data, r.content)
+				f.addProblem(p, errors.New("tile hashes don't match those derived from the log's entries"))
+				f.addRepair(r)
+				continue
 			}
 			klog.V(2).Infof("%s: %s ok", id, p)
 		}
 		return nil
 	}
 }
+
+// FileTileWriter knows how to store tile resources in a log stored on a filesystem rooted at Root, as used by
+// the POSIX storage implementation.
+type FileTileWriter struct {
+	Root string
+}
+
+// WriteTile atomically creates or replaces the tile resource at the given coordinates.
+func (w FileTileWriter) WriteTile(_ context.Context, l, i uint64, p uint8, data []byte) error {
+	name := filepath.Join(w.Root, layout.TilePath(l, i, p))
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %v", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".fsck-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer func() {
+		// Only does anything if the temporary file wasn't renamed below.
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %q: %v", f.Name(), err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync %q: %v", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %v", f.Name(), err)
+	}
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to set permissions of %q: %v", f.Name(), err)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return fmt.Errorf("failed to rename %q to %q: %v", f.Name(), name, err)
+	}
+	return nil
+}
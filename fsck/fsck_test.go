@@ -19,9 +19,220 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/testonly"
 )
 
+func TestVerify(t *testing.T) {
+	tl := newTestLog(t, 300)
+	for _, test := range []struct {
+		name string
+		// damage is applied to the files of the log before it's checked.
+		damage map[string][]byte
+		// wantProblems are the paths of the problems expected to be reported, and wantMissing those which
+		// should be reported as missing.
+		wantProblems []string
+		wantMissing  []string
+	}{
+		{
+			name: "intact",
+		}, {
+			name:         "missing entry bundle",
+			damage:       map[string][]byte{"tile/entries/000": nil},
+			wantProblems: []string{"tile/entries/000"},
+			wantMissing:  []string{"tile/entries/000"},
+		}, {
+			name:         "corrupt entry bundle",
+			damage:       map[string][]byte{"tile/entries/001.p/44": []byte("junk")},
+			wantProblems: []string{"tile/entries/001.p/44"},
+		}, {
+			name:         "corrupt tile",
+			damage:       map[string][]byte{"tile/0/000": makeTile(256)},
+			wantProblems: []string{"tile/0/000"},
+		}, {
+			name:         "missing tile",
+			damage:       map[string][]byte{"tile/1/000.p/1": nil},
+			wantProblems: []string{"tile/1/000.p/1"},
+			wantMissing:  []string{"tile/1/000.p/1"},
+		}, {
+			name:         "missing entry bundle and tile",
+			damage:       map[string][]byte{"tile/entries/000": nil, "tile/0/000": nil},
+			wantProblems: []string{"checkpoint", "tile/0/000", "tile/entries/000"},
+			wantMissing:  []string{"tile/0/000", "tile/entries/000"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := t.Context()
+			// Each test damages its own copy of the log.
+			root := t.TempDir()
+			if err := os.CopyFS(root, os.DirFS(tl.Root)); err != nil {
+				t.Fatalf("CopyFS: %v", err)
+			}
+			for p, d := range test.damage {
+				p = filepath.Join(root, p)
+				var err error
+				if d == nil {
+					err = os.Remove(p)
+				} else {
+					err = os.WriteFile(p, d, 0o644)
+				}
+				if err != nil {
+					t.Fatalf("Failed to damage %s: %v", p, err)
+				}
+			}
+
+			r, err := Verify(ctx, tl.SigVerifier.Name(), tl.SigVerifier, client.FileFetcher{Root: root}, 2, bundleHasher)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			var gotProblems, gotMissing []string
+			for _, p := range r.Problems {
+				gotProblems = append(gotProblems, p.Path)
+				if p.Missing {
+					gotMissing = append(gotMissing, p.Path)
+				}
+			}
+			if !slices.Equal(gotProblems, test.wantProblems) || !slices.Equal(gotMissing, test.wantMissing) {
+				t.Errorf("Verify: got problems %q (missing %q), want %q (missing %q)", gotProblems, gotMissing, test.wantProblems, test.wantMissing)
+			}
+			if gotErr := r.Err() != nil; gotErr != (len(test.wantProblems) > 0) {
+				t.Errorf("Err: %v", r.Err())
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tl := newTestLog(t, 300)
+	// forged is a valid entry bundle which contains different entries to the log's.
+	var forged []byte
+	for range 44 {
+		forged = append(forged, 0, 1, 'x')
+	}
+	for _, test := range []struct {
+		name   string
+		damage map[string][]byte
+		// wantRepaired are the paths which should be repaired, and wantAfter the paths of the problems
+		// which should remain afterwards.
+		wantRepaired      []string
+		wantAfter         []string
+		wantUnrecoverable []EntryRange
+	}{
+		{
+			name:         "damaged tiles",
+			damage:       map[string][]byte{"tile/0/000": makeTile(256), "tile/1/000.p/1": nil},
+			wantRepaired: []string{"tile/0/000", "tile/1/000.p/1"},
+		}, {
+			name:              "missing entry bundle",
+			damage:            map[string][]byte{"tile/entries/000": nil, "tile/0/001.p/44": nil},
+			wantRepaired:      []string{"tile/0/001.p/44"},
+			wantAfter:         []string{"tile/entries/000"},
+			wantUnrecoverable: []EntryRange{{Start: 0, End: 256}},
+		}, {
+			name:              "missing entry bundle and tile",
+			damage:            map[string][]byte{"tile/entries/001.p/44": nil, "tile/0/001.p/44": nil},
+			wantAfter:         []string{"checkpoint", "tile/0/001.p/44", "tile/entries/001.p/44"},
+			wantUnrecoverable: []EntryRange{{Start: 256, End: 300}},
+		}, {
+			name: "forged entry bundle",
+			// The tiles derived from this bundle don't match the checkpoint, so mustn't be written.
+			damage:    map[string][]byte{"tile/entries/001.p/44": forged},
+			wantAfter: []string{"checkpoint", "tile/0/001.p/44"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := t.Context()
+			root := t.TempDir()
+			if err := os.CopyFS(root, os.DirFS(tl.Root)); err != nil {
+				t.Fatalf("CopyFS: %v", err)
+			}
+			for p, d := range test.damage {
+				p = filepath.Join(root, p)
+				var err error
+				if d == nil {
+					err = os.Remove(p)
+				} else {
+					err = os.WriteFile(p, d, 0o644)
+				}
+				if err != nil {
+					t.Fatalf("Failed to damage %s: %v", p, err)
+				}
+			}
+
+			r, err := Repair(ctx, tl.SigVerifier.Name(), tl.SigVerifier, client.FileFetcher{Root: root}, FileTileWriter{Root: root}, 2, bundleHasher)
+			if err != nil {
+				t.Fatalf("Repair: %v", err)
+			}
+			var gotRepaired, gotUnrepaired []string
+			for _, p := range r.Problems {
+				if p.Repaired {
+					gotRepaired = append(gotRepaired, p.Path)
+				} else {
+					gotUnrepaired = append(gotUnrepaired, p.Path)
+				}
+			}
+			if !slices.Equal(gotRepaired, test.wantRepaired) || !slices.Equal(gotUnrepaired, test.wantAfter) {
+				t.Errorf("Repair: repaired %q, left %q, want repaired %q, left %q", gotRepaired, gotUnrepaired, test.wantRepaired, test.wantAfter)
+			}
+			if !slices.Equal(r.Unrecoverable, test.wantUnrecoverable) {
+				t.Errorf("Repair: unrecoverable %v, want %v", r.Unrecoverable, test.wantUnrecoverable)
+			}
+
+			// Checking the log again should only find the problems which couldn't be repaired.
+			r, err = Verify(ctx, tl.SigVerifier.Name(), tl.SigVerifier, client.FileFetcher{Root: root}, 2, bundleHasher)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			var gotAfter []string
+			for _, p := range r.Problems {
+				gotAfter = append(gotAfter, p.Path)
+			}
+			if !slices.Equal(gotAfter, test.wantAfter) {
+				t.Errorf("Verify after Repair: got problems %q, want %q", gotAfter, test.wantAfter)
+			}
+		})
+	}
+}
+
+// newTestLog returns a POSIX log containing n entries, with a checkpoint which commits to all of them.
+func newTestLog(t *testing.T, n int) *testonly.TestLog {
+	t.Helper()
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(uint(n), time.Millisecond))
+	var futures []tessera.IndexFuture
+	for i := range n {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	if _, _, err := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 50*time.Millisecond).AwaitAll(ctx, futures); err != nil {
+		t.Fatalf("AwaitAll: %v", err)
+	}
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	return tl
+}
+
+func bundleHasher(bundle []byte) ([][]byte, error) {
+	eb := &api.EntryBundle{}
+	if err := eb.UnmarshalText(bundle); err != nil {
+		return nil, err
+	}
+	r := make([][]byte, 0, len(eb.Entries))
+	for _, e := range eb.Entries {
+		r = append(r, rfc6962.DefaultHasher.HashLeaf(e))
+	}
+	return r, nil
+}
+
 func TestTrimFullToPartial(t *testing.T) {
 	for _, test := range []struct {
 		name       string
@@ -73,9 +284,11 @@ func TestTrimFullToPartial(t *testing.T) {
 			f.expectedResources <- test.r
 			close(f.expectedResources)
 
-			err := f.resourceCheckWorker(t.Context())()
-			if gotErr := err != nil; gotErr != test.wantErr {
-				t.Fatalf("resourceCheckWorker: %v want err %t", err, test.wantErr)
+			if err := f.resourceCheckWorker(t.Context())(); err != nil {
+				t.Fatalf("resourceCheckWorker: %v", err)
+			}
+			if gotErr := len(f.problems) > 0; gotErr != test.wantErr {
+				t.Fatalf("resourceCheckWorker: problems %v want problems %t", f.problems, test.wantErr)
 			}
 		})
 	}
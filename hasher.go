@@ -0,0 +1,86 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+
+	// Register the non-default hash functions which may be used for the log's Merkle tree.
+	_ "crypto/sha3"
+	_ "crypto/sha512"
+
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// WithHashFunction configures the hash function used to calculate the log's Merkle leaf and node hashes.
+//
+// The tree is constructed as described by RFC6962, but with h in place of SHA-256. Since tiles store 32 byte
+// hashes, h must produce 32 byte digests, e.g. crypto.SHA512_256 or crypto.SHA3_256.
+//
+// The hash function is recorded by the storage implementation when the log is created, and it is an error to
+// subsequently open the log with a different one. Clients of the log will need to be configured with the same
+// hash function in order to verify proofs, see client.NewProofBuilderWithHasher.
+//
+// If this option isn't provided, SHA-256 is used, as required by https://c2sp.org/tlog-tiles.
+// This option cannot be used with WithCTLayout.
+func (o *AppendOptions) WithHashFunction(h crypto.Hash) *AppendOptions {
+	o.hashFunction = h
+	return o
+}
+
+// HashFunction returns the hash function used for the log's Merkle tree.
+func (o AppendOptions) HashFunction() crypto.Hash {
+	if o.hashFunction == 0 {
+		return crypto.SHA256
+	}
+	return o.hashFunction
+}
+
+// Hasher returns the LogHasher which storage implementations must use to build the log's Merkle tree.
+func (o AppendOptions) Hasher() merkle.LogHasher {
+	if h := o.HashFunction(); h != crypto.SHA256 {
+		return rfc6962.New(h)
+	}
+	return rfc6962.DefaultHasher
+}
+
+// validHashFunction returns an error if h can't be used for the log's Merkle tree.
+func validHashFunction(h crypto.Hash) error {
+	if !h.Available() {
+		return fmt.Errorf("hash function %v is not available", h)
+	}
+	if s := h.Size(); s != 32 {
+		return fmt.Errorf("hash function %v produces %d byte digests, but 32 byte digests are required", h, s)
+	}
+	return nil
+}
+
+// leafHashDecorator returns a decorator which recalculates entry leaf hashes using the configured hash function,
+// or nil if the default should be used.
+func (o *AppendOptions) leafHashDecorator() func(AddFn) AddFn {
+	if o.HashFunction() == crypto.SHA256 {
+		return nil
+	}
+	h := o.Hasher()
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			entry.internal.LeafHash = h.HashLeaf(entry.internal.Data)
+			return delegate(ctx, entry)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestHashFunctionValid(t *testing.T) {
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	for _, test := range []struct {
+		name    string
+		opts    func() *AppendOptions
+		wantErr bool
+	}{
+		{name: "default", opts: NewAppendOptions},
+		{name: "SHA-512/256", opts: func() *AppendOptions { return NewAppendOptions().WithHashFunction(crypto.SHA512_256) }},
+		{name: "SHA3-256", opts: func() *AppendOptions { return NewAppendOptions().WithHashFunction(crypto.SHA3_256) }},
+		{name: "wrong size", opts: func() *AppendOptions { return NewAppendOptions().WithHashFunction(crypto.SHA512) }, wantErr: true},
+		{name: "unavailable", opts: func() *AppendOptions { return NewAppendOptions().WithHashFunction(crypto.BLAKE2s_256) }, wantErr: true},
+		{name: "CT layout", opts: func() *AppendOptions { return NewAppendOptions().WithCTLayout().WithHashFunction(crypto.SHA3_256) }, wantErr: true},
+		{name: "CT layout, SHA-256", opts: func() *AppendOptions { return NewAppendOptions().WithCTLayout().WithHashFunction(crypto.SHA256) }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			o := test.opts().WithCheckpointSigner(s)
+			err := o.valid()
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("valid() = %v, wantErr %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLeafHashDecorator(t *testing.T) {
+	data := []byte("hello")
+	for _, test := range []struct {
+		name string
+		h    crypto.Hash
+		want func([]byte) []byte
+	}{
+		{name: "default", want: func(b []byte) []byte { h := sha256.Sum256(b); return h[:] }},
+		{name: "SHA-512/256", h: crypto.SHA512_256, want: func(b []byte) []byte { h := sha512.Sum512_256(b); return h[:] }},
+		{name: "SHA3-256", h: crypto.SHA3_256, want: func(b []byte) []byte { h := sha3.Sum256(b); return h[:] }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			o := NewAppendOptions()
+			if test.h != 0 {
+				o.WithHashFunction(test.h)
+			}
+			var got []byte
+			add := func(_ context.Context, e *Entry) IndexFuture {
+				got = e.LeafHash()
+				return func() (Index, error) { return Index{}, nil }
+			}
+			if d := o.leafHashDecorator(); d != nil {
+				add = d(add)
+			}
+			if _, err := add(t.Context(), NewEntry(data))(); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			// RFC6962 leaf hashes are H(0x00 || data).
+			if want := test.want(append([]byte{0}, data...)); !bytes.Equal(got, want) {
+				t.Errorf("got leaf hash %x, want %x", got, want)
+			}
+			if got, want := o.Hasher().Size(), 32; got != want {
+				t.Errorf("Hasher().Size() = %d, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+// hookPollInterval is how often the log is checked for newly integrated entries and published checkpoints
+// when OnIntegrate or OnPublish hooks are registered.
+const hookPollInterval = 100 * time.Millisecond
+
+// WithOnSequence registers a function which will be called each time a batch of entries added by this
+// Appender has been durably sequenced. Each of the entries will have its Index set.
+//
+// This is called from the storage implementation's sequencing path, so should return quickly.
+// If the process terminates, a batch may be sequenced without the function being called.
+func (o *AppendOptions) WithOnSequence(f func(ctx context.Context, entries []*Entry)) *AppendOptions {
+	o.onSequence = append(o.onSequence, f)
+	return o
+}
+
+// WithOnIntegrate registers a function which will be called with the previous and new sizes of the integrated
+// tree each time it grows, i.e. entries with indices in [from, to) have now been integrated.
+//
+// Integration is not the same as publication, and entries _must not_ be treated as being publicly committed
+// to by the log until a checkpoint has been published; use WithOnPublish for that.
+//
+// Changes in the tree size are detected by polling, so multiple integrations may be reported by a single call.
+// The first call reports growth relative to the size of the tree when the Appender was created.
+func (o *AppendOptions) WithOnIntegrate(f func(ctx context.Context, from, to uint64)) *AppendOptions {
+	o.onIntegrate = append(o.onIntegrate, f)
+	return o
+}
+
+// WithOnPublish registers a function which will be called with each newly published checkpoint which commits
+// to a larger tree than the last one seen.
+//
+// Published checkpoints are detected by polling, so an intermediate checkpoint may not be reported if it is
+// quickly replaced.
+func (o *AppendOptions) WithOnPublish(f func(ctx context.Context, size uint64, checkpoint []byte)) *AppendOptions {
+	o.onPublish = append(o.onPublish, func(ctx context.Context, _, size uint64, checkpoint []byte) {
+		f(ctx, size, checkpoint)
+	})
+	return o
+}
+
+// SequenceHook returns a function which storage implementations must call once a batch of entries has been
+// durably sequenced, or nil if no WithOnSequence hooks have been registered.
+func (o AppendOptions) SequenceHook() func(context.Context, []*Entry) {
+	if len(o.onSequence) == 0 {
+		return nil
+	}
+	hooks := o.onSequence
+	return func(ctx context.Context, entries []*Entry) {
+		for _, h := range hooks {
+			h(ctx, entries)
+		}
+	}
+}
+
+// watchLog polls the log for integration and publication events, calling any registered hooks.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (o AppendOptions) watchLog(ctx context.Context, r LogReader) {
+	if len(o.onIntegrate) == 0 && len(o.onPublish) == 0 {
+		return
+	}
+	var integrated, published uint64
+	var haveIntegrated, havePublished bool
+	t := time.NewTicker(hookPollInterval)
+	defer t.Stop()
+	for {
+		if len(o.onIntegrate) > 0 {
+			if s, err := r.IntegratedSize(ctx); err != nil {
+				klog.Warningf("watchLog: IntegratedSize: %v", err)
+			} else {
+				if haveIntegrated && s > integrated {
+					for _, h := range o.onIntegrate {
+						h(ctx, integrated, s)
+					}
+				}
+				integrated, haveIntegrated = s, true
+			}
+		}
+		if len(o.onPublish) > 0 {
+			cp, err := r.ReadCheckpoint(ctx)
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				// Nothing has been published yet, so the first checkpoint should be reported.
+				havePublished = true
+			case err != nil:
+				klog.Warningf("watchLog: ReadCheckpoint: %v", err)
+			default:
+				if _, s, _, err := parse.CheckpointUnsafe(cp); err != nil {
+					klog.Warningf("watchLog: failed to parse checkpoint: %v", err)
+				} else if !havePublished || s > published {
+					// Don't report the checkpoint which was already published when we started.
+					if havePublished {
+						for _, h := range o.onPublish {
+							h(ctx, published, s, cp)
+						}
+					}
+					published, havePublished = s, true
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
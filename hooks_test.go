@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// growingLogReader is a LogReader whose integrated and published sizes may be changed concurrently.
+type growingLogReader struct {
+	fakeLogReader
+	integrated, published atomic.Uint64
+	// polled is closed the first time the integrated size is read.
+	polled     chan struct{}
+	polledOnce sync.Once
+}
+
+func (r *growingLogReader) IntegratedSize(_ context.Context) (uint64, error) {
+	r.polledOnce.Do(func() { close(r.polled) })
+	return r.integrated.Load(), nil
+}
+
+func (r *growingLogReader) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	s := r.published.Load()
+	if s == 0 {
+		return nil, os.ErrNotExist
+	}
+	return fmt.Appendf(nil, "origin\n%d\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", s), nil
+}
+
+func TestSequenceHook(t *testing.T) {
+	if h := NewAppendOptions().SequenceHook(); h != nil {
+		t.Error("SequenceHook() returned non-nil func with no hooks registered")
+	}
+	var a, b int
+	h := NewAppendOptions().
+		WithOnSequence(func(_ context.Context, e []*Entry) { a += len(e) }).
+		WithOnSequence(func(_ context.Context, e []*Entry) { b += len(e) }).
+		SequenceHook()
+	h(context.Background(), []*Entry{NewEntry(nil), NewEntry(nil)})
+	if a != 2 || b != 2 {
+		t.Errorf("hooks saw %d and %d entries, want 2 and 2", a, b)
+	}
+}
+
+func TestWatchLog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := &growingLogReader{polled: make(chan struct{})}
+	r.integrated.Store(10)
+	integrated := make(chan [2]uint64, 10)
+	published := make(chan uint64, 10)
+	opts := NewAppendOptions().
+		WithOnIntegrate(func(_ context.Context, from, to uint64) { integrated <- [2]uint64{from, to} }).
+		WithOnPublish(func(_ context.Context, size uint64, _ []byte) { published <- size })
+	go opts.watchLog(ctx, r)
+	// Make sure the initial size has been seen before growing the log.
+	<-r.polled
+
+	r.integrated.Store(15)
+	if got, want := waitFor(t, integrated), [2]uint64{10, 15}; got != want {
+		t.Errorf("OnIntegrate got %v, want %v", got, want)
+	}
+	r.published.Store(15)
+	if got, want := waitFor(t, published), uint64(15); got != want {
+		t.Errorf("OnPublish got size %d, want %d", got, want)
+	}
+	r.integrated.Store(20)
+	if got, want := waitFor(t, integrated), [2]uint64{15, 20}; got != want {
+		t.Errorf("OnIntegrate got %v, want %v", got, want)
+	}
+}
+
+func waitFor[T any](t *testing.T, c <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for hook")
+	}
+	var v T
+	return v
+}
@@ -0,0 +1,307 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/future"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// idempotencyExpiryInterval is the maximum time between removals of expired idempotency keys from storage.
+	idempotencyExpiryInterval = 10 * time.Minute
+	// idempotencyClaimTimeout is how long a key is held by an entry which is being sequenced, after which
+	// another submission with the same key may claim it, e.g. if the instance sequencing the entry died.
+	idempotencyClaimTimeout = time.Minute
+	// idempotencyClaimPollInterval is how often a key held by an entry being sequenced elsewhere is checked.
+	idempotencyClaimPollInterval = 100 * time.Millisecond
+)
+
+// WithIdempotencyKeys enables the use of idempotency keys, set with Entry.WithIdempotencyKey.
+//
+// The index assigned to each entry with an idempotency key is stored for at least window, during which
+// further entries with the same key will resolve to that index, and be marked as duplicates, instead of
+// being added to the log.
+//
+// Drivers which support it store the keys along with the log, so that they're shared by all instances and
+// survive restarts. For other drivers, keys are held only in memory.
+func (o *AppendOptions) WithIdempotencyKeys(window time.Duration) *AppendOptions {
+	o.idempotencyWindow = window
+	return o
+}
+
+// idempotencyKeyStorage is implemented by drivers which are able to durably store the indices assigned to
+// entries with idempotency keys.
+//
+// A key is claimed before its entry is sequenced, so that only one of several instances sharing the storage
+// adds an entry with that key.
+type idempotencyKeyStorage interface {
+	// ReadIdempotencyKey returns the index stored for key, and whether there was one which hasn't expired.
+	// A key which has been claimed, but not yet assigned an index, isn't returned.
+	ReadIdempotencyKey(ctx context.Context, key string) (uint64, bool, error)
+	// ClaimIdempotencyKey atomically records that an entry with the given key is being sequenced, until
+	// expiry, and returns true, unless the key is already claimed or stored and hasn't expired.
+	ClaimIdempotencyKey(ctx context.Context, key string, expiry time.Time) (bool, error)
+	// ReleaseIdempotencyKey removes the claim on key, if no index has been stored for it.
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	// WriteIdempotencyKey stores the index assigned to the entry with the given key, until expiry.
+	WriteIdempotencyKey(ctx context.Context, key string, index uint64, expiry time.Time) error
+	// ExpireIdempotencyKeys removes any keys whose expiry is before the given time.
+	ExpireIdempotencyKeys(ctx context.Context, before time.Time) error
+}
+
+// memoryIdempotencyKeys is an idempotencyKeyStorage used for drivers which don't implement it.
+type memoryIdempotencyKeys struct {
+	mu   sync.Mutex
+	keys map[string]memoryIdempotencyKey
+}
+
+type memoryIdempotencyKey struct {
+	index uint64
+	// claimed is true if the key has been claimed, but no index has been stored for it yet.
+	claimed bool
+	expiry  time.Time
+}
+
+func (m *memoryIdempotencyKeys) ReadIdempotencyKey(_ context.Context, key string) (uint64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[key]
+	if !ok || k.claimed || k.expiry.Before(time.Now()) {
+		return 0, false, nil
+	}
+	return k.index, true, nil
+}
+
+func (m *memoryIdempotencyKeys) ClaimIdempotencyKey(_ context.Context, key string, expiry time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if k, ok := m.keys[key]; ok && !k.expiry.Before(time.Now()) {
+		return false, nil
+	}
+	if m.keys == nil {
+		m.keys = make(map[string]memoryIdempotencyKey)
+	}
+	m.keys[key] = memoryIdempotencyKey{claimed: true, expiry: expiry}
+	return true, nil
+}
+
+func (m *memoryIdempotencyKeys) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if k, ok := m.keys[key]; ok && k.claimed {
+		delete(m.keys, key)
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyKeys) WriteIdempotencyKey(_ context.Context, key string, index uint64, expiry time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keys == nil {
+		m.keys = make(map[string]memoryIdempotencyKey)
+	}
+	m.keys[key] = memoryIdempotencyKey{index: index, expiry: expiry}
+	return nil
+}
+
+func (m *memoryIdempotencyKeys) ExpireIdempotencyKeys(_ context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, v := range m.keys {
+		if v.expiry.Before(before) {
+			delete(m.keys, k)
+		}
+	}
+	return nil
+}
+
+// idempotencyKeys resolves entries with idempotency keys which have been seen before to the index assigned
+// to the first of them.
+type idempotencyKeys struct {
+	store  idempotencyKeyStorage
+	window time.Duration
+	now    func() time.Time
+
+	// mu guards inflight, which holds the futures for entries with keys which haven't yet been stored.
+	mu       sync.Mutex
+	inflight map[string]IndexFuture
+}
+
+// newIdempotencyKeys creates an idempotencyKeys, using the driver's storage if it supports it.
+//
+// Expired keys are removed from the storage before returning, so that an error is returned if the driver
+// isn't able to store keys, e.g. because its schema is missing the table for them.
+func newIdempotencyKeys(ctx context.Context, d Driver, window time.Duration) (*idempotencyKeys, error) {
+	k := &idempotencyKeys{
+		window:   window,
+		now:      time.Now,
+		inflight: make(map[string]IndexFuture),
+	}
+	if s, ok := d.(idempotencyKeyStorage); ok {
+		k.store = s
+	} else {
+		klog.Infof("Driver %T does not support storing idempotency keys, they will be held in memory", d)
+		k.store = &memoryIdempotencyKeys{}
+	}
+	if err := k.store.ExpireIdempotencyKeys(ctx, k.now()); err != nil {
+		return nil, fmt.Errorf("failed to initialise idempotency key storage: %v", err)
+	}
+	return k, nil
+}
+
+func (k *idempotencyKeys) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, e *Entry) IndexFuture {
+		key := e.IdempotencyKey()
+		if key == "" {
+			return delegate(ctx, e)
+		}
+		k.mu.Lock()
+		if f, ok := k.inflight[key]; ok {
+			k.mu.Unlock()
+			return asDuplicate(f)
+		}
+		r, set := future.NewFutureErr[Index]()
+		f := IndexFuture(r.Get)
+		k.inflight[key] = f
+		k.mu.Unlock()
+		resolve := func(idx Index, err error) IndexFuture {
+			k.mu.Lock()
+			delete(k.inflight, key)
+			k.mu.Unlock()
+			set(idx, err)
+			return f
+		}
+
+		// Other submissions with the same key share this one's result, so it mustn't fail because this caller
+		// gives up.
+		ctx = context.WithoutCancel(ctx)
+		idx, stored, claimed, err := k.tryClaim(ctx, key)
+		switch {
+		case err != nil:
+			return resolve(Index{}, err)
+		case stored:
+			return resolve(Index{Index: idx, IsDup: true}, nil)
+		case claimed:
+			added := k.add(ctx, key, e, delegate)
+			// Store the key once the entry's index is known, whether or not the caller waits for it.
+			go func() {
+				resolve(added())
+			}()
+			return f
+		}
+		// The key is held by an entry being sequenced by another instance. Wait for it in the background, so
+		// that the caller isn't held up before it gets a future to wait on.
+		go func() {
+			idx, stored, err := k.awaitClaim(ctx, key)
+			switch {
+			case err != nil:
+				resolve(Index{}, err)
+			case stored:
+				resolve(Index{Index: idx, IsDup: true}, nil)
+			default:
+				resolve(k.add(ctx, key, e, delegate)())
+			}
+		}()
+		return f
+	}
+}
+
+// add passes e, whose key has been claimed, to delegate. The returned future resolves to the entry's index
+// once its key has been stored along with it, or the claim has been released if the entry failed.
+func (k *idempotencyKeys) add(ctx context.Context, key string, e *Entry, delegate AddFn) IndexFuture {
+	// The key's expiry starts from when the entry was submitted, so that it can't outlive its window
+	// while the entry waits to be sequenced.
+	expiry := k.now().Add(k.window)
+	inner := delegate(ctx, e)
+	return func() (Index, error) {
+		idx, err := inner()
+		if err == nil {
+			if err := k.store.WriteIdempotencyKey(ctx, key, idx.Index, expiry); err != nil {
+				klog.Warningf("Failed to store idempotency key for entry at index %d: %v", idx.Index, err)
+			}
+		} else if err := k.store.ReleaseIdempotencyKey(ctx, key); err != nil {
+			klog.Warningf("Failed to release idempotency key for failed entry: %v", err)
+		}
+		return idx, err
+	}
+}
+
+// tryClaim claims key for an entry which is about to be sequenced, and returns true for claimed. If an index
+// is already stored for the key, that's returned along with true for stored instead. If both are false, the key
+// is held by an entry being sequenced by another instance.
+func (k *idempotencyKeys) tryClaim(ctx context.Context, key string) (idx uint64, stored, claimed bool, err error) {
+	idx, ok, err := k.store.ReadIdempotencyKey(ctx, key)
+	if err != nil || ok {
+		return idx, ok, false, err
+	}
+	claimed, err = k.store.ClaimIdempotencyKey(ctx, key, k.now().Add(min(k.window, idempotencyClaimTimeout)))
+	return 0, false, claimed, err
+}
+
+// awaitClaim waits until key, which is held by an entry being sequenced by another instance, can be claimed,
+// or has an index stored for it, which is returned along with true.
+//
+// Claims expire after idempotencyClaimTimeout, so an error is returned if the key is still held after that.
+func (k *idempotencyKeys) awaitClaim(ctx context.Context, key string) (uint64, bool, error) {
+	t := time.NewTicker(idempotencyClaimPollInterval)
+	defer t.Stop()
+	timeout := time.NewTimer(idempotencyClaimTimeout + idempotencyClaimPollInterval)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-timeout.C:
+			return 0, false, fmt.Errorf("idempotency key is still held by another submission after %v", idempotencyClaimTimeout)
+		case <-t.C:
+		}
+		idx, stored, claimed, err := k.tryClaim(ctx, key)
+		if err != nil || stored || claimed {
+			return idx, stored, err
+		}
+	}
+}
+
+// asDuplicate returns a future which resolves to the same index as f, but marked as a duplicate.
+func asDuplicate(f IndexFuture) IndexFuture {
+	return func() (Index, error) {
+		idx, err := f()
+		if err != nil {
+			return Index{}, fmt.Errorf("submission with the same idempotency key failed: %w", err)
+		}
+		idx.IsDup = true
+		return idx, nil
+	}
+}
+
+// expire periodically removes expired keys from storage, until ctx is done.
+func (k *idempotencyKeys) expire(ctx context.Context) {
+	t := time.NewTicker(min(k.window, idempotencyExpiryInterval))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := k.store.ExpireIdempotencyKeys(ctx, k.now()); err != nil {
+			klog.Warningf("Failed to expire idempotency keys: %v", err)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	ctx := t.Context()
+	errAdd := errors.New("bang")
+	for _, test := range []struct {
+		name    string
+		entries []*Entry
+		// fail is the set of data for which the delegate should fail.
+		fail     map[string]bool
+		want     []Index
+		wantErrs []bool
+		wantAdds int
+	}{
+		{
+			name:     "no keys",
+			entries:  []*Entry{NewEntry([]byte("a")), NewEntry([]byte("a"))},
+			want:     []Index{{Index: 0}, {Index: 1}},
+			wantErrs: []bool{false, false},
+			wantAdds: 2,
+		}, {
+			name:     "different keys",
+			entries:  []*Entry{NewEntry([]byte("a")).WithIdempotencyKey("1"), NewEntry([]byte("a")).WithIdempotencyKey("2")},
+			want:     []Index{{Index: 0}, {Index: 1}},
+			wantErrs: []bool{false, false},
+			wantAdds: 2,
+		}, {
+			name:     "same key, different data",
+			entries:  []*Entry{NewEntry([]byte("a")).WithIdempotencyKey("1"), NewEntry([]byte("b")).WithIdempotencyKey("1")},
+			want:     []Index{{Index: 0}, {Index: 0, IsDup: true}},
+			wantErrs: []bool{false, false},
+			wantAdds: 1,
+		}, {
+			name:     "failed add isn't remembered",
+			entries:  []*Entry{NewEntry([]byte("fail")).WithIdempotencyKey("1"), NewEntry([]byte("b")).WithIdempotencyKey("1")},
+			fail:     map[string]bool{"fail": true},
+			want:     []Index{{}, {Index: 1}},
+			wantErrs: []bool{true, false},
+			wantAdds: 2,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			adds := 0
+			delegate := func(_ context.Context, e *Entry) IndexFuture {
+				mu.Lock()
+				defer mu.Unlock()
+				idx := uint64(adds)
+				adds++
+				if test.fail[string(e.Data())] {
+					return func() (Index, error) { return Index{}, errAdd }
+				}
+				return func() (Index, error) { return Index{Index: idx}, nil }
+			}
+			k, err := newIdempotencyKeys(ctx, &fakeDriver{}, time.Hour)
+			if err != nil {
+				t.Fatalf("newIdempotencyKeys: %v", err)
+			}
+			add := k.decorator(delegate)
+			for i, e := range test.entries {
+				got, err := add(ctx, e)()
+				if gotErr := err != nil; gotErr != test.wantErrs[i] {
+					t.Fatalf("entry %d: got err %v, want err %t", i, err, test.wantErrs[i])
+				}
+				if got != test.want[i] {
+					t.Errorf("entry %d: got %+v, want %+v", i, got, test.want[i])
+				}
+			}
+			if adds != test.wantAdds {
+				t.Errorf("got %d calls to delegate, want %d", adds, test.wantAdds)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeysInflight(t *testing.T) {
+	ctx := t.Context()
+	release := make(chan struct{})
+	adds := 0
+	delegate := func(_ context.Context, e *Entry) IndexFuture {
+		adds++
+		return func() (Index, error) {
+			<-release
+			return Index{Index: 5}, nil
+		}
+	}
+	k, err := newIdempotencyKeys(ctx, &fakeDriver{}, time.Hour)
+	if err != nil {
+		t.Fatalf("newIdempotencyKeys: %v", err)
+	}
+	add := k.decorator(delegate)
+	f1 := add(ctx, NewEntry([]byte("a")).WithIdempotencyKey("1"))
+	f2 := add(ctx, NewEntry([]byte("b")).WithIdempotencyKey("1"))
+	close(release)
+	if got, err := f1(); err != nil || got != (Index{Index: 5}) {
+		t.Errorf("f1: got %+v, %v", got, err)
+	}
+	if got, err := f2(); err != nil || got != (Index{Index: 5, IsDup: true}) {
+		t.Errorf("f2: got %+v, %v", got, err)
+	}
+	if adds != 1 {
+		t.Errorf("got %d calls to delegate, want 1", adds)
+	}
+}
+
+func TestIdempotencyKeysFirstCallerCancelled(t *testing.T) {
+	release := make(chan struct{})
+	// The delegate fails entries whose context is done by the time they'd be sequenced, as the queue does.
+	delegate := func(ctx context.Context, e *Entry) IndexFuture {
+		return func() (Index, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return Index{}, err
+			}
+			return Index{Index: 5}, nil
+		}
+	}
+	k, err := newIdempotencyKeys(t.Context(), &fakeDriver{}, time.Hour)
+	if err != nil {
+		t.Fatalf("newIdempotencyKeys: %v", err)
+	}
+	add := k.decorator(delegate)
+	ctx1, cancel := context.WithCancel(t.Context())
+	f1 := add(ctx1, NewEntry([]byte("a")).WithIdempotencyKey("1"))
+	f2 := add(t.Context(), NewEntry([]byte("a")).WithIdempotencyKey("1"))
+	cancel()
+	close(release)
+	if got, err := f2(); err != nil || got != (Index{Index: 5, IsDup: true}) {
+		t.Errorf("f2: got %+v, %v, want index 5", got, err)
+	}
+	if got, err := f1(); err != nil || got != (Index{Index: 5}) {
+		t.Errorf("f1: got %+v, %v, want index 5", got, err)
+	}
+}
+
+func TestIdempotencyKeysClaimedElsewhere(t *testing.T) {
+	ctx := t.Context()
+	store := &memoryIdempotencyKeys{}
+	// Another instance sharing the storage is sequencing an entry with the key.
+	if ok, err := store.ClaimIdempotencyKey(ctx, "1", time.Now().Add(time.Minute)); err != nil || !ok {
+		t.Fatalf("ClaimIdempotencyKey: got (%t, %v), want (true, nil)", ok, err)
+	}
+	adds := 0
+	delegate := func(_ context.Context, e *Entry) IndexFuture {
+		adds++
+		return func() (Index, error) { return Index{Index: 1}, nil }
+	}
+	k, err := newIdempotencyKeys(ctx, &idempotencyDriver{memoryIdempotencyKeys: store}, time.Hour)
+	if err != nil {
+		t.Fatalf("newIdempotencyKeys: %v", err)
+	}
+	add := k.decorator(delegate)
+	// Add must return without waiting for the claim held elsewhere.
+	f := add(ctx, NewEntry([]byte("b")).WithIdempotencyKey("1"))
+	time.Sleep(2 * idempotencyClaimPollInterval)
+	if err := store.WriteIdempotencyKey(ctx, "1", 5, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("WriteIdempotencyKey: %v", err)
+	}
+	if got, err := f(); err != nil || got != (Index{Index: 5, IsDup: true}) {
+		t.Errorf("got %+v, %v, want index 5 marked as a duplicate", got, err)
+	}
+	if adds != 0 {
+		t.Errorf("got %d calls to delegate, want 0", adds)
+	}
+}
+
+func TestIdempotencyKeysClaimReleasedElsewhere(t *testing.T) {
+	ctx := t.Context()
+	store := &memoryIdempotencyKeys{}
+	if ok, err := store.ClaimIdempotencyKey(ctx, "1", time.Now().Add(time.Minute)); err != nil || !ok {
+		t.Fatalf("ClaimIdempotencyKey: got (%t, %v), want (true, nil)", ok, err)
+	}
+	adds := 0
+	delegate := func(_ context.Context, e *Entry) IndexFuture {
+		adds++
+		return func() (Index, error) { return Index{Index: 1}, nil }
+	}
+	k, err := newIdempotencyKeys(ctx, &idempotencyDriver{memoryIdempotencyKeys: store}, time.Hour)
+	if err != nil {
+		t.Fatalf("newIdempotencyKeys: %v", err)
+	}
+	// The caller giving up doesn't stop the entry being added once the other instance's claim is released.
+	cctx, cancel := context.WithCancel(ctx)
+	f := k.decorator(delegate)(cctx, NewEntry([]byte("b")).WithIdempotencyKey("1"))
+	cancel()
+	time.Sleep(2 * idempotencyClaimPollInterval)
+	if err := store.ReleaseIdempotencyKey(ctx, "1"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey: %v", err)
+	}
+	if got, err := f(); err != nil || got != (Index{Index: 1}) {
+		t.Errorf("got %+v, %v, want index 1", got, err)
+	}
+	if adds != 1 {
+		t.Errorf("got %d calls to delegate, want 1", adds)
+	}
+	if idx, ok, err := store.ReadIdempotencyKey(ctx, "1"); err != nil || !ok || idx != 1 {
+		t.Errorf("ReadIdempotencyKey: got (%d, %t, %v), want (1, true, nil)", idx, ok, err)
+	}
+}
+
+func TestNewIdempotencyKeysStorageError(t *testing.T) {
+	d := &idempotencyDriver{memoryIdempotencyKeys: &memoryIdempotencyKeys{}, expireErr: errors.New("no such table")}
+	if _, err := newIdempotencyKeys(t.Context(), d, time.Hour); err == nil {
+		t.Error("newIdempotencyKeys: got nil error, want error")
+	}
+}
+
+// idempotencyDriver is a driver which stores idempotency keys.
+type idempotencyDriver struct {
+	fakeDriver
+	*memoryIdempotencyKeys
+	expireErr error
+}
+
+func (d *idempotencyDriver) ExpireIdempotencyKeys(ctx context.Context, before time.Time) error {
+	if d.expireErr != nil {
+		return d.expireErr
+	}
+	return d.memoryIdempotencyKeys.ExpireIdempotencyKeys(ctx, before)
+}
+
+func TestMemoryIdempotencyKeysClaim(t *testing.T) {
+	ctx := t.Context()
+	m := &memoryIdempotencyKeys{}
+	now := time.Now()
+	for _, test := range []struct {
+		name      string
+		prep      func()
+		wantClaim bool
+	}{
+		{
+			name:      "unknown",
+			wantClaim: true,
+		}, {
+			name: "claimed",
+			prep: func() {
+				_, _ = m.ClaimIdempotencyKey(ctx, "claimed", now.Add(time.Minute))
+			},
+		}, {
+			name: "expired claim",
+			prep: func() {
+				_, _ = m.ClaimIdempotencyKey(ctx, "expired claim", now.Add(-time.Minute))
+			},
+			wantClaim: true,
+		}, {
+			name: "released",
+			prep: func() {
+				_, _ = m.ClaimIdempotencyKey(ctx, "released", now.Add(time.Minute))
+				_ = m.ReleaseIdempotencyKey(ctx, "released")
+			},
+			wantClaim: true,
+		}, {
+			name: "stored",
+			prep: func() {
+				_ = m.WriteIdempotencyKey(ctx, "stored", 1, now.Add(time.Hour))
+				_ = m.ReleaseIdempotencyKey(ctx, "stored")
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.prep != nil {
+				test.prep()
+			}
+			got, err := m.ClaimIdempotencyKey(ctx, test.name, now.Add(time.Minute))
+			if err != nil {
+				t.Fatalf("ClaimIdempotencyKey: %v", err)
+			}
+			if got != test.wantClaim {
+				t.Errorf("ClaimIdempotencyKey: got %t, want %t", got, test.wantClaim)
+			}
+			if _, ok, _ := m.ReadIdempotencyKey(ctx, test.name); ok && test.wantClaim {
+				t.Error("claimed key was found")
+			}
+		})
+	}
+}
+
+func TestMemoryIdempotencyKeysExpiry(t *testing.T) {
+	ctx := t.Context()
+	m := &memoryIdempotencyKeys{}
+	now := time.Now()
+	if err := m.WriteIdempotencyKey(ctx, "old", 1, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("WriteIdempotencyKey: %v", err)
+	}
+	if err := m.WriteIdempotencyKey(ctx, "new", 2, now.Add(time.Hour)); err != nil {
+		t.Fatalf("WriteIdempotencyKey: %v", err)
+	}
+	if _, ok, _ := m.ReadIdempotencyKey(ctx, "old"); ok {
+		t.Error("expired key was found")
+	}
+	if err := m.ExpireIdempotencyKeys(ctx, now); err != nil {
+		t.Fatalf("ExpireIdempotencyKeys: %v", err)
+	}
+	if got := len(m.keys); got != 1 {
+		t.Errorf("got %d keys after expiry, want 1", got)
+	}
+	if idx, ok, _ := m.ReadIdempotencyKey(ctx, "new"); !ok || idx != 2 {
+		t.Errorf("ReadIdempotencyKey(new): got (%d, %t), want (2, true)", idx, ok)
+	}
+}
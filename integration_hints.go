@@ -0,0 +1,194 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/otel"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultIntegrationHintsWindow is used by IntegrationMonitor if IntegrationMonitorOptions.Window is not set.
+	DefaultIntegrationHintsWindow = time.Minute
+
+	// integrationHintsPollInterval is how often an IntegrationMonitor samples the log.
+	integrationHintsPollInterval = time.Second
+)
+
+// IntegrationHints describes whether integration is keeping up with sequencing, for autoscalers which size the
+// integration workers of a log, e.g. the Kubernetes HorizontalPodAutoscaler via a custom metrics adapter.
+type IntegrationHints struct {
+	// Time is when the hints were calculated.
+	Time time.Time `json:"time"`
+	// Backlog is the number of entries which have been sequenced but not yet integrated.
+	Backlog uint64 `json:"backlog"`
+	// SequencedPerSecond and IntegratedPerSecond are the rates at which entries were sequenced and integrated
+	// over the IntegrationMonitorOptions.Window, or the time since the monitor started if that's shorter.
+	SequencedPerSecond  float64 `json:"sequenced_per_second"`
+	IntegratedPerSecond float64 `json:"integrated_per_second"`
+	// EstimatedSecondsToIntegrate is how long the Backlog will take to integrate if integration continues at
+	// IntegratedPerSecond. It's zero if there's no backlog.
+	EstimatedSecondsToIntegrate float64 `json:"estimated_seconds_to_integrate"`
+	// Stalled is true if there's a backlog but nothing was integrated over the window, in which case no
+	// estimate can be made.
+	Stalled bool `json:"stalled"`
+}
+
+// IntegrationMonitorOptions configures an IntegrationMonitor.
+type IntegrationMonitorOptions struct {
+	// Window is the period over which rates are calculated. Defaults to DefaultIntegrationHintsWindow.
+	Window time.Duration
+}
+
+// IntegrationMonitor tracks the sequencing backlog and integration rate of a log. Use
+// AppendOptions.WithIntegrationMonitor to have an Appender populate it, and Hints or Handler to read it.
+//
+// The hints are also exported as the tessera.appender.integration.backlog, .rate, and .eta metrics.
+//
+// Drivers which integrate entries as they're sequenced, such as MySQL, never have a backlog.
+type IntegrationMonitor struct {
+	opts IntegrationMonitorOptions
+	now  func() time.Time
+
+	mu sync.Mutex
+	// samples are taken every integrationHintsPollInterval, oldest first, and cover at least the window once
+	// the monitor has been running for that long.
+	samples []integrationSample
+}
+
+// integrationSample records the log's next index and integrated size at a point in time.
+type integrationSample struct {
+	at               time.Time
+	next, integrated uint64
+}
+
+// NewIntegrationMonitor returns an IntegrationMonitor configured by opts.
+func NewIntegrationMonitor(opts IntegrationMonitorOptions) *IntegrationMonitor {
+	if opts.Window <= 0 {
+		opts.Window = DefaultIntegrationHintsWindow
+	}
+	return &IntegrationMonitor{opts: opts, now: time.Now}
+}
+
+// WithIntegrationMonitor configures the Appender to keep m up to date with the sequencing backlog and
+// integration rate of the log.
+func (o *AppendOptions) WithIntegrationMonitor(m *IntegrationMonitor) *AppendOptions {
+	o.integrationMonitor = m
+	return o
+}
+
+// Hints returns the current IntegrationHints. They're all zero until the log has been sampled twice.
+func (m *IntegrationMonitor) Hints() IntegrationHints {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := IntegrationHints{Time: m.now()}
+	if len(m.samples) == 0 {
+		return h
+	}
+	first, last := m.samples[0], m.samples[len(m.samples)-1]
+	if last.next > last.integrated {
+		h.Backlog = last.next - last.integrated
+	}
+	if d := last.at.Sub(first.at).Seconds(); d > 0 {
+		if last.next > first.next {
+			h.SequencedPerSecond = float64(last.next-first.next) / d
+		}
+		if last.integrated > first.integrated {
+			h.IntegratedPerSecond = float64(last.integrated-first.integrated) / d
+		}
+	}
+	switch {
+	case h.Backlog == 0:
+	case h.IntegratedPerSecond > 0:
+		h.EstimatedSecondsToIntegrate = float64(h.Backlog) / h.IntegratedPerSecond
+	default:
+		h.Stalled = len(m.samples) > 1
+	}
+	return h
+}
+
+// Handler returns an http.Handler which serves the current IntegrationHints as JSON.
+func (m *IntegrationMonitor) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(m.Hints()); err != nil {
+			klog.Warningf("IntegrationMonitor: failed to write response: %v", err)
+		}
+	})
+}
+
+// run samples the log every integrationHintsPollInterval, and reports the hints as metrics, until ctx is done.
+func (m *IntegrationMonitor) run(ctx context.Context, r LogReader) {
+	reg, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		h := m.Hints()
+		o.ObserveInt64(appenderIntegrationBacklog, otel.Clamp64(h.Backlog))
+		o.ObserveFloat64(appenderIntegrationRate, h.IntegratedPerSecond)
+		if !h.Stalled {
+			o.ObserveFloat64(appenderIntegrationETA, h.EstimatedSecondsToIntegrate)
+		}
+		return nil
+	}, appenderIntegrationBacklog, appenderIntegrationRate, appenderIntegrationETA)
+	if err != nil {
+		klog.Errorf("IntegrationMonitor: failed to register metrics callback: %v", err)
+	} else {
+		defer func() { _ = reg.Unregister() }()
+	}
+
+	t := time.NewTicker(integrationHintsPollInterval)
+	defer t.Stop()
+	for {
+		if err := m.sample(ctx, r); err != nil {
+			klog.Warningf("IntegrationMonitor: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// sample records the log's current next index and integrated size, and forgets samples which are no longer
+// needed to cover the window.
+func (m *IntegrationMonitor) sample(ctx context.Context, r LogReader) error {
+	// Read the integrated size first so that the backlog can never be negative.
+	s, err := r.IntegratedSize(ctx)
+	if err != nil {
+		return err
+	}
+	n, err := r.NextIndex(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.samples = append(m.samples, integrationSample{at: now, next: n, integrated: s})
+	// Keep the newest sample which is at least as old as the window, so that rates always cover it.
+	i := 0
+	for i+1 < len(m.samples) && !m.samples[i+1].at.After(now.Add(-m.opts.Window)) {
+		i++
+	}
+	m.samples = m.samples[i:]
+	return nil
+}
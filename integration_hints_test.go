@@ -0,0 +1,99 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIntegrationMonitorHints(t *testing.T) {
+	// sample is the log's state at each second.
+	type sample struct{ next, integrated uint64 }
+	for _, test := range []struct {
+		name    string
+		window  time.Duration
+		samples []sample
+		want    IntegrationHints
+	}{
+		{
+			name: "no samples",
+		}, {
+			name:    "one sample",
+			samples: []sample{{next: 20, integrated: 10}},
+			want:    IntegrationHints{Backlog: 10},
+		}, {
+			name:    "keeping up",
+			samples: []sample{{next: 10, integrated: 10}, {next: 20, integrated: 20}, {next: 30, integrated: 30}},
+			want:    IntegrationHints{SequencedPerSecond: 10, IntegratedPerSecond: 10},
+		}, {
+			name:    "falling behind",
+			samples: []sample{{next: 10, integrated: 10}, {next: 30, integrated: 15}, {next: 50, integrated: 20}},
+			want:    IntegrationHints{Backlog: 30, SequencedPerSecond: 20, IntegratedPerSecond: 5, EstimatedSecondsToIntegrate: 6},
+		}, {
+			name:    "stalled",
+			samples: []sample{{next: 10, integrated: 10}, {next: 20, integrated: 10}},
+			want:    IntegrationHints{Backlog: 10, SequencedPerSecond: 10, Stalled: true},
+		}, {
+			name:    "window",
+			window:  2 * time.Second,
+			samples: []sample{{next: 0, integrated: 0}, {next: 100, integrated: 100}, {next: 110, integrated: 110}, {next: 120, integrated: 120}},
+			want:    IntegrationHints{SequencedPerSecond: 10, IntegratedPerSecond: 10},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			m := NewIntegrationMonitor(IntegrationMonitorOptions{Window: test.window})
+			m.now = func() time.Time { return now }
+			r := &sizesLogReader{}
+			for i, s := range test.samples {
+				if i > 0 {
+					now = now.Add(time.Second)
+				}
+				r.next, r.integrated = s.next, s.integrated
+				if err := m.sample(t.Context(), r); err != nil {
+					t.Fatalf("sample: %v", err)
+				}
+			}
+			test.want.Time = now
+			if got := m.Hints(); got != test.want {
+				t.Errorf("Hints() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIntegrationMonitorHandler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	m := NewIntegrationMonitor(IntegrationMonitorOptions{})
+	m.now = func() time.Time { return now }
+	if err := m.sample(t.Context(), &sizesLogReader{next: 8, integrated: 5}); err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", ct)
+	}
+	var got IntegrationHints
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.Backlog != 3 || !got.Time.Equal(now) {
+		t.Errorf("got %+v, want backlog 3 at %v", got, now)
+	}
+}
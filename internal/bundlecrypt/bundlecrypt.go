@@ -0,0 +1,221 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bundlecrypt implements the optional envelope encryption of entry bundles at rest.
+//
+// Each encrypted bundle is sealed with AES-256-GCM using a data key, which is itself encrypted ("wrapped") by a
+// KeyProvider and stored alongside the ciphertext:
+//
+//	magic || uint16 length of wrapped key || wrapped key || nonce || ciphertext
+//
+// The plaintext is the content encoding in which the bundle was stored before it was encrypted, prefixed with its
+// uint8 length, followed by the stored bundle. The path at which the bundle is stored is used as additional
+// authenticated data, so that encrypted bundles can't be moved to a different location in the log undetected.
+//
+// Whether a stored bundle is encrypted must be recorded out of band, e.g. in the object's content type, since
+// the contents of an unencrypted bundle are chosen by the log's submitters and could start with anything.
+package bundlecrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	dataKeySize = 32
+	// dataKeyLifetime is how long a data key is used to encrypt bundles before a new one is generated, bounding
+	// both the number of messages encrypted with each key and the number of calls made to the KeyProvider.
+	dataKeyLifetime = time.Hour
+	// maxCachedKeys bounds the number of unwrapped data keys cached for decrypting bundles.
+	maxCachedKeys = 1024
+)
+
+// ContentType is the content type with which encrypted bundles are stored by drivers which record one, so that
+// they can be told apart from unencrypted bundles.
+const ContentType = "application/vnd.tessera.encrypted-entry-bundle"
+
+// magic is the prefix with which every encrypted bundle starts.
+var magic = []byte{0x00, 0x00, 't', 'e', 'n', 'c', 0x01}
+
+// KeyProvider wraps and unwraps the data keys used to encrypt bundles.
+//
+// It's satisfied by tessera.EntryBundleKeyProvider.
+type KeyProvider interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Cipher encrypts and decrypts entry bundles. A nil *Cipher leaves bundles unencrypted, and can't decrypt them.
+type Cipher struct {
+	kp  KeyProvider
+	now func() time.Time
+
+	mu sync.Mutex
+	// aead and wrapped are the current data key, created at keyCreated, and its wrapped form.
+	aead       cipher.AEAD
+	wrapped    []byte
+	keyCreated time.Time
+	// unwrapped caches the data keys used to decrypt bundles, keyed by their wrapped form.
+	unwrapped map[string]cipher.AEAD
+}
+
+// New returns a Cipher which uses data keys wrapped by kp, or nil if kp is nil.
+func New(kp KeyProvider) *Cipher {
+	if kp == nil {
+		return nil
+	}
+	return &Cipher{kp: kp, now: time.Now, unwrapped: make(map[string]cipher.AEAD)}
+}
+
+// Seal returns the bundle b, stored at path in the content encoding enc, encrypted. If c is nil, b is returned
+// unchanged.
+func (c *Cipher) Seal(ctx context.Context, path string, enc string, b []byte) ([]byte, error) {
+	if c == nil {
+		return b, nil
+	}
+	if len(enc) > 0xff {
+		return nil, errors.New("content encoding is too long")
+	}
+	aead, wrapped, err := c.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pt := make([]byte, 0, 1+len(enc)+len(b))
+	pt = append(pt, byte(len(enc)))
+	pt = append(pt, enc...)
+	pt = append(pt, b...)
+	r := make([]byte, 0, len(magic)+2+len(wrapped)+aead.NonceSize()+len(pt)+aead.Overhead())
+	r = append(r, magic...)
+	r = binary.BigEndian.AppendUint16(r, uint16(len(wrapped)))
+	r = append(r, wrapped...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	r = append(r, nonce...)
+	return aead.Seal(r, nonce, pt, []byte(path)), nil
+}
+
+// Open returns the encrypted bundle b, stored at path, decrypted, along with the content encoding in which it was
+// stored before being encrypted.
+func (c *Cipher) Open(ctx context.Context, path string, b []byte) ([]byte, string, error) {
+	if c == nil {
+		return nil, "", fmt.Errorf("entry bundle %q is encrypted, but no key provider is configured", path)
+	}
+	if !bytes.HasPrefix(b, magic) {
+		return nil, "", fmt.Errorf("entry bundle %q is not a valid encrypted bundle", path)
+	}
+	rest := b[len(magic):]
+	if len(rest) < 2 {
+		return nil, "", fmt.Errorf("encrypted entry bundle %q is truncated", path)
+	}
+	n := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < n {
+		return nil, "", fmt.Errorf("encrypted entry bundle %q is truncated", path)
+	}
+	wrapped, rest := rest[:n], rest[n:]
+	aead, err := c.unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, "", fmt.Errorf("encrypted entry bundle %q is truncated", path)
+	}
+	nonce, ct := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	d, err := aead.Open(nil, nonce, ct, []byte(path))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt entry bundle %q: %v", path, err)
+	}
+	if len(d) < 1 || len(d) < 1+int(d[0]) {
+		return nil, "", fmt.Errorf("encrypted entry bundle %q is malformed", path)
+	}
+	return d[1+int(d[0]):], string(d[1 : 1+int(d[0])]), nil
+}
+
+// dataKey returns the data key with which bundles should currently be encrypted, and its wrapped form.
+func (c *Cipher) dataKey(ctx context.Context) (cipher.AEAD, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aead != nil && c.now().Sub(c.keyCreated) < dataKeyLifetime {
+		return c.aead, c.wrapped, nil
+	}
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %v", err)
+	}
+	wrapped, err := c.kp.WrapKey(ctx, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap data key: %v", err)
+	}
+	if len(wrapped) > 0xffff {
+		return nil, nil, errors.New("wrapped data key is too long")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.aead, c.wrapped, c.keyCreated = aead, wrapped, c.now()
+	c.cache(wrapped, aead)
+	return aead, wrapped, nil
+}
+
+// unwrap returns the data key whose wrapped form is wrapped.
+func (c *Cipher) unwrap(ctx context.Context, wrapped []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.unwrapped[string(wrapped)]
+	c.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	key, err := c.kp.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	aead, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.cache(wrapped, aead)
+	c.mu.Unlock()
+	return aead, nil
+}
+
+// cache stores an unwrapped data key. c.mu must be held.
+func (c *Cipher) cache(wrapped []byte, aead cipher.AEAD) {
+	if len(c.unwrapped) >= maxCachedKeys {
+		clear(c.unwrapped)
+	}
+	c.unwrapped[string(wrapped)] = aead
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("data key is %d bytes, want %d", len(key), dataKeySize)
+	}
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
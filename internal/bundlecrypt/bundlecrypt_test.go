@@ -0,0 +1,147 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundlecrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+// xorKeyProvider "wraps" keys by XORing them with a fixed byte, and counts the calls made to it.
+type xorKeyProvider struct {
+	wraps, unwraps int
+	fail           bool
+}
+
+func (p *xorKeyProvider) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	p.wraps++
+	return xor(key), nil
+}
+
+func (p *xorKeyProvider) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	p.unwraps++
+	if p.fail {
+		return nil, errors.New("KMS unavailable")
+	}
+	return xor(wrapped), nil
+}
+
+func xor(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[i] = b[i] ^ 0x5a
+	}
+	return r
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	bundle := make([]byte, 1000)
+	_, _ = rand.Read(bundle)
+	kp := &xorKeyProvider{}
+	c := New(kp)
+
+	sealed, err := c.Seal(ctx, "tile/entries/000", "zstd", bundle)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !bytes.HasPrefix(sealed, magic) || bytes.Contains(sealed, bundle[:32]) {
+		t.Fatalf("Seal returned unencrypted bundle")
+	}
+	// A different Cipher using the same key provider, e.g. in another process, must be able to decrypt it.
+	for _, c := range []*Cipher{c, New(kp)} {
+		got, enc, err := c.Open(ctx, "tile/entries/000", sealed)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if !bytes.Equal(got, bundle) {
+			t.Errorf("Open returned different bundle")
+		}
+		if enc != "zstd" {
+			t.Errorf("Open returned encoding %q, want zstd", enc)
+		}
+	}
+	if kp.wraps != 1 || kp.unwraps != 1 {
+		t.Errorf("got %d wraps and %d unwraps, want 1 of each", kp.wraps, kp.unwraps)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	c := New(&xorKeyProvider{})
+	sealed, err := c.Seal(ctx, "tile/entries/000", "", []byte("bundle"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	for _, test := range []struct {
+		name    string
+		c       *Cipher
+		path    string
+		b       []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "plaintext", c: c, path: "tile/entries/000", b: []byte("bundle"), wantErr: true},
+		{name: "plaintext without cipher", path: "tile/entries/000", b: []byte("bundle"), wantErr: true},
+		{name: "encrypted", c: c, path: "tile/entries/000", b: sealed, want: []byte("bundle")},
+		{name: "encrypted without cipher", path: "tile/entries/000", b: sealed, wantErr: true},
+		{name: "moved", c: c, path: "tile/entries/001", b: sealed, wantErr: true},
+		{name: "tampered", c: c, path: "tile/entries/000", b: tampered, wantErr: true},
+		{name: "truncated", c: c, path: "tile/entries/000", b: sealed[:len(magic)+5], wantErr: true},
+		{name: "unwrap fails", c: New(&xorKeyProvider{fail: true}), path: "tile/entries/000", b: sealed, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, _, err := test.c.Open(ctx, test.path, test.b)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Open: %v, want error %t", err, test.wantErr)
+			}
+			if !bytes.Equal(got, test.want) {
+				t.Errorf("Open: got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDataKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	kp := &xorKeyProvider{}
+	c := New(kp)
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+	for _, advance := range []time.Duration{0, time.Minute, dataKeyLifetime} {
+		now = now.Add(advance)
+		if _, err := c.Seal(ctx, "tile/entries/000", "", []byte("bundle")); err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+	}
+	if kp.wraps != 2 {
+		t.Errorf("got %d data keys, want 2", kp.wraps)
+	}
+}
+
+func TestSealNil(t *testing.T) {
+	var c *Cipher
+	got, err := c.Seal(context.Background(), "tile/entries/000", "zstd", []byte("bundle"))
+	if err != nil || string(got) != "bundle" {
+		t.Errorf("Seal: %q, %v, want bundle unchanged", got, err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bundleformat records how the entry bundles of a POSIX log are stored.
+//
+// POSIX storage has no object metadata in which to record how each bundle is compressed or whether it's
+// encrypted, and a bundle's contents are chosen by the log's submitters, so can't be relied on to tell. Instead, the log records the
+// format in which bundles are written each time it changes, along with the size of the log at the time: every
+// bundle which was written once the log had grown beyond that size is stored in that format.
+package bundleformat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// Path is the path, relative to the root of a POSIX log, of the file which records the log's bundle formats.
+const Path = ".state/bundleFormat"
+
+// Format describes how entry bundles are stored.
+type Format struct {
+	// Encoding is the content encoding in which bundles are stored, or in which they're stored before being
+	// encrypted, or empty if they're stored as-is.
+	Encoding string `json:"encoding,omitempty"`
+	// Encrypted is true if bundles are encrypted with bundlecrypt, which records their encoding itself.
+	Encrypted bool `json:"encrypted,omitempty"`
+}
+
+// Change records that entry bundles were written in Format once the log had grown beyond From entries.
+type Change struct {
+	From uint64 `json:"from"`
+	Format
+}
+
+// History is the list of changes to the format of a log's entry bundles, oldest first. Bundles written before
+// the first change are stored as-is.
+type History []Change
+
+// For returns the format in which the given entry bundle is stored, where p is its partial size.
+func (h History) For(index uint64, p uint8) Format {
+	end := index*layout.EntryBundleWidth + uint64(p)
+	if p == 0 {
+		end += layout.EntryBundleWidth
+	}
+	// A bundle is written when the log grows to include its last entry.
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].From < end {
+			return h[i].Format
+		}
+	}
+	return Format{}
+}
+
+// Latest returns the format in which entry bundles are currently written.
+func (h History) Latest() Format {
+	if len(h) == 0 {
+		return Format{}
+	}
+	return h[len(h)-1].Format
+}
+
+// Encrypted returns true if any of the log's entry bundles may be encrypted.
+func (h History) Encrypted() bool {
+	for _, c := range h {
+		if c.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+// Set returns h updated to record that entry bundles are written in format f once the log has grown beyond
+// size entries, and whether that changed it.
+func (h History) Set(size uint64, f Format) (History, bool) {
+	if h.Latest() == f {
+		return h, false
+	}
+	// Changes which took effect at this size haven't been used to write any bundles, so are superseded.
+	for len(h) > 0 && h[len(h)-1].From >= size {
+		h = h[:len(h)-1]
+	}
+	if h.Latest() != f {
+		h = append(h, Change{From: size, Format: f})
+	}
+	return h, true
+}
+
+// Read returns the history of the formats of entry bundles of the POSIX log rooted at root, which is empty if
+// its format has never changed.
+func Read(root string) (History, error) {
+	raw, err := os.ReadFile(filepath.Join(root, Path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read entry bundle formats: %v", err)
+	}
+	return Parse(raw)
+}
+
+// Parse parses a serialised History.
+func Parse(raw []byte) (History, error) {
+	var h History
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse entry bundle formats: %v", err)
+	}
+	return h, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compress implements the optional compressed encodings of entry bundles, and the decoding of
+// compressed HTTP responses.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Zstd is the name of the zstd content encoding, as used in Content-Encoding headers and object metadata.
+	Zstd = "zstd"
+	// Gzip is the name of the gzip content encoding, as used in Content-Encoding headers and object metadata.
+	Gzip = "gzip"
+	// AcceptEncoding is the value of the Accept-Encoding header sent by clients which can decode responses
+	// using Decode.
+	AcceptEncoding = "zstd, gzip"
+
+	// maxDecodedSize bounds the size of decoded resources, as a defence against decompression bombs.
+	// It comfortably exceeds the size of the largest possible entry bundle.
+	maxDecodedSize = 64 << 20
+)
+
+var (
+	// zstdEncoder and zstdDecoder are safe for concurrent use with EncodeAll and DecodeAll respectively.
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecodedSize))
+)
+
+// EncodeEntryBundle returns the serialised entry bundle b in the given content encoding, which must be empty
+// (meaning the bundle is stored as-is), Zstd, or Gzip.
+func EncodeEntryBundle(enc string, b []byte) ([]byte, error) {
+	switch enc {
+	case "":
+		return b, nil
+	case Zstd:
+		return zstdEncoder.EncodeAll(b, nil), nil
+	case Gzip:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported entry bundle encoding %q", enc)
+	}
+}
+
+// DecodeEntryBundle returns the serialised entry bundle stored as b in the given content encoding.
+//
+// The encoding must be the one recorded when the bundle was stored, and never inferred from b: a bundle's
+// contents are chosen by the log's submitters, who could make an uncompressed bundle look like a compressed one.
+func DecodeEntryBundle(enc string, b []byte) ([]byte, error) {
+	if enc == "" {
+		return b, nil
+	}
+	return Decode(enc, b)
+}
+
+// Decode returns the body of an HTTP response which was sent with the given Content-Encoding header.
+func Decode(contentEncoding string, body []byte) ([]byte, error) {
+	switch contentEncoding {
+	case "", "identity":
+		return body, nil
+	case Zstd:
+		d, err := zstdDecoder.DecodeAll(body, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode zstd: %v", err)
+		}
+		return d, nil
+	case Gzip:
+		return gunzip(body)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", contentEncoding)
+	}
+}
+
+// gunzip decompresses b, which must be gzip encoded and decode to no more than maxDecodedSize bytes.
+func gunzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gzip: %v", err)
+	}
+	d, err := io.ReadAll(io.LimitReader(r, maxDecodedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gzip: %v", err)
+	}
+	if len(d) > maxDecodedSize {
+		return nil, fmt.Errorf("decoded gzip exceeds %d bytes", maxDecodedSize)
+	}
+	return d, nil
+}
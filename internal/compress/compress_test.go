@@ -0,0 +1,122 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestEntryBundleRoundTrip(t *testing.T) {
+	bundle := bytes.Repeat([]byte("\x00\x10{\"name\": \"entry\"}"), 256)
+	// notZstd starts with the zstd magic number, but isn't a zstd frame.
+	notZstd := append([]byte{0x28, 0xb5, 0x2f, 0xfd}, bundle...)
+	// notGzip starts with the gzip magic number, but isn't a gzip member.
+	notGzip := append([]byte{0x1f, 0x8b, 0x08}, bundle...)
+	// zstdFrame and gzipMember are validly encoded, but must nevertheless be stored as-is if they're
+	// uncompressed bundles.
+	zstdFrame, err := EncodeEntryBundle(Zstd, bundle)
+	if err != nil {
+		t.Fatalf("EncodeEntryBundle: %v", err)
+	}
+	gzipMember, err := EncodeEntryBundle(Gzip, bundle)
+	if err != nil {
+		t.Fatalf("EncodeEntryBundle: %v", err)
+	}
+	for _, test := range []struct {
+		name   string
+		enc    string
+		bundle []byte
+	}{
+		{name: "identity", bundle: bundle},
+		{name: "zstd", enc: Zstd, bundle: bundle},
+		{name: "identity with magic", bundle: notZstd},
+		{name: "zstd with magic", enc: Zstd, bundle: notZstd},
+		{name: "identity zstd frame", bundle: zstdFrame},
+		{name: "empty", enc: Zstd, bundle: []byte{}},
+		{name: "gzip", enc: Gzip, bundle: bundle},
+		{name: "identity with gzip magic", bundle: notGzip},
+		{name: "gzip with magic", enc: Gzip, bundle: notGzip},
+		{name: "identity gzip member", bundle: gzipMember},
+		{name: "empty gzip", enc: Gzip, bundle: []byte{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stored, err := EncodeEntryBundle(test.enc, test.bundle)
+			if err != nil {
+				t.Fatalf("EncodeEntryBundle: %v", err)
+			}
+			if test.enc != "" && len(test.bundle) > 0 && len(stored) >= len(test.bundle) {
+				t.Errorf("EncodeEntryBundle: got %d bytes, want fewer than %d", len(stored), len(test.bundle))
+			}
+			got, err := DecodeEntryBundle(test.enc, stored)
+			if err != nil {
+				t.Fatalf("DecodeEntryBundle: %v", err)
+			}
+			if !bytes.Equal(got, test.bundle) {
+				t.Errorf("DecodeEntryBundle: got %x, want %x", got, test.bundle)
+			}
+		})
+	}
+}
+
+func TestEncodeEntryBundleUnsupported(t *testing.T) {
+	if _, err := EncodeEntryBundle("br", []byte("bundle")); err == nil {
+		t.Error("EncodeEntryBundle(br): got nil error, want error")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	body := []byte("hello, hello, hello")
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write(body); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	e, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		enc     string
+		body    []byte
+		wantErr bool
+	}{
+		{name: "none", body: body},
+		{name: "identity", enc: "identity", body: body},
+		{name: "zstd", enc: Zstd, body: e.EncodeAll(body, nil)},
+		{name: "gzip", enc: "gzip", body: gz.Bytes()},
+		{name: "corrupt zstd", enc: Zstd, body: body, wantErr: true},
+		{name: "corrupt gzip", enc: "gzip", body: body, wantErr: true},
+		{name: "unsupported", enc: "br", body: body, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Decode(test.enc, test.body)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Decode: %v, want error %t", err, test.wantErr)
+			}
+			if err == nil && !bytes.Equal(got, body) {
+				t.Errorf("Decode: got %q, want %q", got, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/hammer/loadtest"
+)
+
+// adversarialWriter returns a LeafWriter which writes leaves using w, but which
+// instead sends an oversized leaf via w with probability oversizedChance, or a
+// malformed request via malformed with probability malformedChance.
+//
+// The outcomes of these adversarial requests are recorded in the Oversized and Malformed
+// stats of ha, and they return ErrSkipped so that the leaf is written on the next turn.
+func adversarialWriter(w, malformed loadtest.LeafWriter, oversizedChance float64, oversizedSize int, malformedChance float64, ha *loadtest.HammerAnalyser) loadtest.LeafWriter {
+	if oversizedChance <= 0 && malformedChance <= 0 {
+		return w
+	}
+	return func(ctx context.Context, data []byte) (uint64, error) {
+		var s *loadtest.OpStats
+		var err error
+		start := time.Now()
+		switch r := rand.Float64(); {
+		case r < oversizedChance:
+			s = &ha.Oversized
+			_, err = w(ctx, oversizedLeaf(oversizedSize))
+		case r < oversizedChance+malformedChance:
+			s = &ha.Malformed
+			_, err = malformed(ctx, data)
+		default:
+			return w(ctx, data)
+		}
+		if ctx.Err() == nil {
+			s.Record(time.Since(start), err)
+		}
+		return 0, loadtest.ErrSkipped
+	}
+}
+
+// oversizedLeaf returns a unique leaf of the given size.
+func oversizedLeaf(size int) []byte {
+	l := fmt.Appendf(nil, "oversized %x ", rand.Uint64())
+	return append(l, bytes.Repeat([]byte{'x'}, max(size-len(l), 0))...)
+}
+
+// malformedWriter returns a LeafWriter which sends a malformed request to the
+// add endpoint at u, which a log should reject. It returns an error if the
+// request was rejected, wrapping ErrRetry if the log pushed back.
+//
+// Requests are either sent with the wrong method, or are POSTs whose body is
+// cut off partway through.
+func malformedWriter(u *url.URL, hc *http.Client, bearerToken string) loadtest.LeafWriter {
+	return func(ctx context.Context, data []byte) (uint64, error) {
+		var req *http.Request
+		var err error
+		if rand.IntN(2) == 0 {
+			req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		} else {
+			req, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), io.MultiReader(bytes.NewReader(data), errReader{}))
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to create request: %v", err)
+		}
+		if bearerToken != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", bearerToken))
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("request failed: %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return 0, nil
+		case resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests:
+			return 0, fmt.Errorf("log not available. Status code: %d %w", resp.StatusCode, loadtest.ErrRetry)
+		default:
+			return 0, fmt.Errorf("rejected with status code %d", resp.StatusCode)
+		}
+	}
+}
+
+// errReader is an io.Reader which always fails, used to cut off request bodies.
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("request body cut off")
+}
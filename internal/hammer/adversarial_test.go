@@ -0,0 +1,99 @@
+// Copyright 2024 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/transparency-dev/tessera/internal/hammer/loadtest"
+)
+
+func TestAdversarialWriter(t *testing.T) {
+	var sizes []int
+	w := func(_ context.Context, data []byte) (uint64, error) {
+		sizes = append(sizes, len(data))
+		return 1, nil
+	}
+	malformed := func(context.Context, []byte) (uint64, error) {
+		return 0, errors.New("rejected")
+	}
+
+	for _, test := range []struct {
+		name                             string
+		oversizedChance, malformedChance float64
+		wantErr                          error
+		wantSizes                        []int
+		wantOversized, wantMalformed     loadtest.OpSummary
+	}{
+		{name: "normal", wantSizes: []int{4}},
+		{name: "oversized", oversizedChance: 1, wantErr: loadtest.ErrSkipped, wantSizes: []int{1000}, wantOversized: loadtest.OpSummary{Succeeded: 1}},
+		{name: "malformed", malformedChance: 1, wantErr: loadtest.ErrSkipped, wantMalformed: loadtest.OpSummary{Failed: 1, ErrorRate: 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sizes = nil
+			ha := loadtest.NewHammerAnalyser(func() uint64 { return 0 })
+			aw := adversarialWriter(w, malformed, test.oversizedChance, 1000, test.malformedChance, ha)
+			if _, err := aw(t.Context(), []byte("leaf")); !errors.Is(err, test.wantErr) {
+				t.Errorf("Got error %v, want %v", err, test.wantErr)
+			}
+			if len(sizes) != len(test.wantSizes) || (len(sizes) > 0 && sizes[0] != test.wantSizes[0]) {
+				t.Errorf("Wrote leaves of sizes %v, want %v", sizes, test.wantSizes)
+			}
+			if got := ha.Oversized.Summary(0); got.Succeeded != test.wantOversized.Succeeded || got.Failed != test.wantOversized.Failed {
+				t.Errorf("Got oversized %v, want %v", got, test.wantOversized)
+			}
+			if got := ha.Malformed.Summary(0); got.Succeeded != test.wantMalformed.Succeeded || got.Failed != test.wantMalformed.Failed {
+				t.Errorf("Got malformed %v, want %v", got, test.wantMalformed)
+			}
+		})
+	}
+}
+
+func TestMalformedWriter(t *testing.T) {
+	var posts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		posts++
+		_, _ = w.Write([]byte("0"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL + "/add")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mw := malformedWriter(u, srv.Client(), "")
+	for range 20 {
+		if _, err := mw(t.Context(), []byte("leaf")); err == nil {
+			t.Error("Malformed request was accepted")
+		}
+	}
+	if posts != 0 {
+		t.Errorf("Server read %d complete POST bodies, want 0", posts)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/hammer/loadtest"
+	"k8s.io/klog/v2"
+)
+
+// coordinatorStartDelay is how long after the last hammer joins a coordinator that
+// they all start hammering.
+const coordinatorStartDelay = 5 * time.Second
+
+// runCoordinator serves a coordinator for distributed hammering on addr, and
+// reports the merged results once every hammer has sent its report.
+func runCoordinator(addr string, opts loadtest.CoordinatorOpts) {
+	if opts.Hammers < 1 {
+		klog.Exitf("--coordinator_hammers must be at least 1, got %d", opts.Hammers)
+	}
+	c := loadtest.NewCoordinator(opts)
+	mux := http.NewServeMux()
+	c.RegisterHandlers(mux)
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			klog.Exitf("Coordinator failed: %v", err)
+		}
+	}()
+	klog.Infof("Coordinator listening on %s, waiting for %d hammers", addr, opts.Hammers)
+
+	ctx := context.Background()
+	summary, err := c.Wait(ctx)
+	if err != nil {
+		klog.Exitf("Failed waiting for reports: %v", err)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		klog.Warningf("Failed to shut down coordinator: %v", err)
+	}
+	if err := reportSummary(summary); err != nil {
+		klog.Exitf("Failed to write summary: %v", err)
+	}
+}
+
+// mustJoinCoordinator joins the coordinator at u, applies its assignment to the
+// rate flags, and waits until it's time to start. It returns a leaf generator
+// seed which is unique to this hammer.
+func mustJoinCoordinator(ctx context.Context, u string) uint64 {
+	cURL, err := url.Parse(u)
+	if err != nil {
+		klog.Exitf("Invalid coordinator URL %q: %v", u, err)
+	}
+	klog.Infof("Waiting for all hammers to join coordinator at %s", cURL)
+	// Joining blocks until every hammer has joined, so mustn't be subject to --http_timeout.
+	a, err := loadtest.Join(ctx, http.DefaultClient, cURL)
+	if err != nil {
+		klog.Exitf("Failed to join coordinator: %v", err)
+	}
+	klog.Infof("Joined as hammer %d of %d with max_read_ops=%d and max_write_ops=%d; starting in %s", a.Hammer+1, a.Hammers, a.MaxReadOpsPerSecond, a.MaxWriteOpsPerSecond, a.StartIn)
+	*maxReadOpsPerSecond, *maxWriteOpsPerSecond = a.MaxReadOpsPerSecond, a.MaxWriteOpsPerSecond
+	time.Sleep(a.StartIn)
+	return uint64(a.Hammer) + 1
+}
+
+// sendCoordinatorReport sends the results of this hammer's run to the coordinator at u.
+func sendCoordinatorReport(ctx context.Context, u string, r loadtest.Report) error {
+	cURL, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid coordinator URL %q: %v", u, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, *httpTimeout)
+	defer cancel()
+	return loadtest.SendReport(ctx, http.DefaultClient, cURL, r)
+}
@@ -57,13 +57,29 @@ var (
 	numReadersFull      = flag.Int("num_readers_full", 4, "The number of readers downloading the whole log")
 
 	maxWriteOpsPerSecond = flag.Int("max_write_ops", 0, "The maximum number of write operations per second")
+	adaptiveWriteRate    = flag.Bool("adaptive_write_rate", false, "Adapt the write rate to pushback from the log, up to --max_write_ops, and report the rate it sustained")
+	readWriteRatio       = flag.Float64("read_write_ratio", 0, "If set, --max_read_ops is set to this many reads for every write allowed by --max_write_ops")
 	numWriters           = flag.Int("num_writers", 0, "The number of independent write tasks to run")
 
 	leafMinSize = flag.Int("leaf_min_size", 0, "Minimum size in bytes of individual leaves")
+	leafMaxSize = flag.Int("leaf_max_size", 0, "Maximum size in bytes of individual leaves. If greater than --leaf_min_size, leaf sizes are uniformly distributed between the two")
 	dupChance   = flag.Float64("dup_chance", 0.1, "The probability of a generated leaf being a duplicate of a previous value")
 
+	oversizedChance = flag.Float64("oversized_chance", 0, "The probability of a write being replaced by a leaf of --oversized_size bytes, which the log may reject")
+	oversizedSize   = flag.Int("oversized_size", 1<<20, "Size in bytes of oversized leaves")
+	verifyChance    = flag.Float64("verify_chance", 0, "The probability of a successful write being checked for inclusion in the log at the index returned, once a checkpoint covers it")
+	malformedChance = flag.Float64("malformed_chance", 0, "The probability of a write being replaced by a malformed request, which the log should reject")
+
 	leafWriteGoal = flag.Int64("leaf_write_goal", 0, "Exit after writing this number of leaves, or 0 to keep going indefinitely")
 	maxRunTime    = flag.Duration("max_runtime", 0, "Fail after this amount of time has passed, or 0 to keep going indefinitely")
+	rampUp        = flag.Duration("ramp_up", 0, "Raise read and write traffic linearly to --max_read_ops and --max_write_ops over this duration, or 0 to start at full rate")
+
+	summaryFile   = flag.String("summary_file", "", "If set, a summary of the run is written to this file on exit, or to stdout if set to -")
+	summaryFormat = flag.String("summary_format", "json", "Format of the summary written to --summary_file: json or csv")
+
+	coordinatorListen  = flag.String("coordinator_listen", "", "If set, run as a coordinator for distributed hammering on this address:port instead of hammering")
+	coordinatorHammers = flag.Int("coordinator_hammers", 2, "The number of hammers which must join the coordinator before they all start")
+	coordinatorURL     = flag.String("coordinator_url", "", "If set, join the coordinator at this URL, which sets this hammer's share of --max_read_ops and --max_write_ops and when to start")
 
 	showUI = flag.Bool("show_ui", true, "Set to false to disable the text-based UI")
 
@@ -100,6 +116,35 @@ func main() {
 		}
 	}
 
+	if *verifyChance < 0 || *verifyChance > 1 {
+		klog.Exitf("--verify_chance must be between 0 and 1, got %v", *verifyChance)
+	}
+	if *oversizedChance < 0 || *malformedChance < 0 || *oversizedChance+*malformedChance > 1 {
+		klog.Exitf("--oversized_chance and --malformed_chance must not be negative, and must add up to at most 1")
+	}
+	if *adaptiveWriteRate && *rampUp > 0 {
+		klog.Exit("--adaptive_write_rate and --ramp_up can't be used together")
+	}
+	if *readWriteRatio < 0 {
+		klog.Exitf("--read_write_ratio must not be negative, got %v", *readWriteRatio)
+	}
+	if *readWriteRatio > 0 {
+		*maxReadOpsPerSecond = int(*readWriteRatio * float64(*maxWriteOpsPerSecond))
+	}
+	if *summaryFormat != "json" && *summaryFormat != "csv" {
+		klog.Exitf("Unsupported --summary_format %q, must be json or csv", *summaryFormat)
+	}
+
+	if *coordinatorListen != "" {
+		runCoordinator(*coordinatorListen, loadtest.CoordinatorOpts{
+			Hammers:              *coordinatorHammers,
+			MaxReadOpsPerSecond:  *maxReadOpsPerSecond,
+			MaxWriteOpsPerSecond: *maxWriteOpsPerSecond,
+			StartDelay:           coordinatorStartDelay,
+		})
+		return
+	}
+
 	// If bearerTokenWrite is unset, default it to whatever bearerToken has (which may too be unset).
 	if *bearerTokenWrite == "" {
 		*bearerTokenWrite = *bearerToken
@@ -112,15 +157,18 @@ func main() {
 		klog.Exitf("failed to create verifier: %v", err)
 	}
 
-	r := mustCreateReaders(logURL)
+	var tracker *client.LogStateTracker
+	ha := loadtest.NewHammerAnalyser(func() uint64 { return tracker.Latest().Size })
+
+	// All reads, including the checkpoint and tiles fetched by the tracker, are recorded by the analyser.
+	r := ha.RecordReads(mustCreateReaders(logURL))
 	if len(writeLogURL) == 0 {
 		writeLogURL = logURL
 	}
-	w := mustCreateWriters(writeLogURL)
 
 	var cpRaw []byte
 	cons := client.UnilateralConsensus(r.ReadCheckpoint)
-	tracker, err := client.NewLogStateTracker(ctx, r.ReadTile, cpRaw, logSigV, logSigV.Name(), cons)
+	tracker, err = client.NewLogStateTracker(ctx, r.ReadTile, cpRaw, logSigV, logSigV.Name(), cons)
 	if err != nil {
 		klog.Exitf("Failed to create LogStateTracker: %v", err)
 	}
@@ -130,18 +178,37 @@ func main() {
 		klog.Exitf("Failed to get initial state of the log: %v", err)
 	}
 
-	ha := loadtest.NewHammerAnalyser(func() uint64 { return tracker.Latest().Size })
+	verifier := loadtest.NewInclusionVerifier(tracker, r.ReadTile, r.ReadEntryBundle, *verifyChance, &ha.Inclusion)
+	w := adversarialWriter(
+		verifier.RecordWrites(ha.RecordWrites(mustCreateWriters(writeLogURL, func(u *url.URL) loadtest.LeafWriter { return httpWriter(u, hc, *bearerTokenWrite) }))),
+		mustCreateWriters(writeLogURL, func(u *url.URL) loadtest.LeafWriter { return malformedWriter(u, hc, *bearerTokenWrite) }),
+		*oversizedChance, *oversizedSize, *malformedChance, ha)
+
+	// seed distinguishes the leaves generated by hammers which share a coordinator.
+	var seed uint64
+	if *coordinatorURL != "" {
+		seed = mustJoinCoordinator(ctx, *coordinatorURL)
+	}
+
 	ha.Run(ctx)
+	if *verifyChance > 0 {
+		go verifier.Run(ctx)
+	}
 
-	gen := newLeafGenerator(tracker.Latest().Size, *leafMinSize, *dupChance)
+	gen := newLeafGenerator(tracker.Latest().Size, seed, *leafMinSize, *leafMaxSize, *dupChance)
 	opts := loadtest.HammerOpts{
 		MaxReadOpsPerSecond:  *maxReadOpsPerSecond,
 		MaxWriteOpsPerSecond: *maxWriteOpsPerSecond,
 		NumReadersRandom:     *numReadersRandom,
 		NumReadersFull:       *numReadersFull,
 		NumWriters:           *numWriters,
+		RampUp:               *rampUp,
 	}
 	hammer := loadtest.NewHammer(tracker, r.ReadEntryBundle, w, gen, ha.SeqLeafChan, ha.ErrChan, opts)
+	var aimd *loadtest.AIMDController
+	if *adaptiveWriteRate {
+		aimd = loadtest.NewAIMDController(hammer, &ha.Writes, *maxWriteOpsPerSecond)
+	}
 
 	exitCode := 0
 	if *leafWriteGoal > 0 {
@@ -182,6 +249,9 @@ func main() {
 		}()
 	}
 	hammer.Run(ctx)
+	if aimd != nil {
+		go aimd.Run(ctx)
+	}
 
 	if *showUI {
 		c := loadtest.NewController(hammer, ha)
@@ -189,29 +259,103 @@ func main() {
 	} else {
 		<-ctx.Done()
 	}
+	report := ha.Report()
+	if aimd != nil {
+		report.SustainableWriteQPS = aimd.Sustainable()
+	}
+	if *coordinatorURL != "" {
+		// The run's context has been cancelled, so use a new one to report back.
+		if err := sendCoordinatorReport(context.Background(), *coordinatorURL, report); err != nil {
+			klog.Errorf("Failed to send report to coordinator: %v", err)
+			exitCode = 1
+		}
+	}
+	summary := loadtest.MergeReports([]loadtest.Report{report})
+	if err := reportSummary(summary); err != nil {
+		klog.Errorf("Failed to write summary: %v", err)
+		exitCode = 1
+	}
+	if summary.Inclusion.Failed > 0 {
+		klog.Errorf("%d leaves failed inclusion checks", summary.Inclusion.Failed)
+		exitCode = 1
+	}
 	os.Exit(exitCode)
 }
 
+// reportSummary logs s, and writes it to --summary_file if set.
+func reportSummary(s loadtest.Summary) error {
+	klog.Infof("Writes: %s", s.Writes)
+	klog.Infof("Reads: %s", s.Reads)
+	if s.Oversized != (loadtest.OpSummary{}) {
+		klog.Infof("Oversized: %s", s.Oversized)
+	}
+	if s.Malformed != (loadtest.OpSummary{}) {
+		klog.Infof("Malformed: %s", s.Malformed)
+	}
+	if s.Inclusion != (loadtest.OpSummary{}) {
+		klog.Infof("Inclusion checks: %s", s.Inclusion)
+	}
+	klog.Infof("Integration: %s", s.Integration)
+	if s.SustainableWriteQPS > 0 {
+		klog.Infof("Sustainable write rate: %.1fqps", s.SustainableWriteQPS)
+	}
+	if *summaryFile == "" {
+		return nil
+	}
+	return writeSummary(*summaryFile, *summaryFormat, s)
+}
+
+// writeSummary writes s to the named file, or stdout if name is "-", in the given format.
+func writeSummary(name, format string, s loadtest.Summary) error {
+	out := os.Stdout
+	if name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to create %q: %v", name, err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				klog.Errorf("Failed to close %q: %v", name, err)
+			}
+		}()
+		out = f
+	}
+	if format == "csv" {
+		return s.WriteCSV(out)
+	}
+	return s.WriteJSON(out)
+}
+
 // newLeafGenerator returns a function that generates values to append to a log.
-// The leaves are constructed to be at least minLeafSize bytes long.
+// The leaves are constructed to be at least minLeafSize bytes long. If maxLeafSize is
+// greater than minLeafSize, the size of each leaf is chosen uniformly between the two.
 // The generator can be used by concurrent threads.
 //
 // dupChance provides the probability that a new leaf will be a duplicate of a previous entry.
 // Leaves will be unique if dupChance is 0, and if set to 1 then all values will be duplicates.
 // startSize should be set to the initial size of the log so that repeated runs of the
 // hammer can start seeding leaves to avoid duplicates with previous runs.
-func newLeafGenerator(startSize uint64, minLeafSize int, dupChance float64) func() []byte {
+// Hammers running concurrently against the same log must use different seeds to avoid
+// generating the same leaves as each other.
+func newLeafGenerator(startSize, seed uint64, minLeafSize, maxLeafSize int, dupChance float64) func() []byte {
 	// genLeaf MUST be determinstic given n
 	genLeaf := func(n uint64) []byte {
+		source := rand.New(rand.NewPCG(seed, n))
+		size := minLeafSize
+		if maxLeafSize > minLeafSize {
+			size += source.IntN(maxLeafSize - minLeafSize + 1)
+		}
 		// Make a slice with half the number of requested bytes since we'll
 		// hex-encode them below which gets us back up to the full amount.
-		filler := make([]byte, minLeafSize/2)
-		source := rand.New(rand.NewPCG(0, n))
+		filler := make([]byte, size/2)
 		for i := range filler {
 			// This throws away a lot of the generated data. An exercise to a future
 			// coder is to fill in multiple bytes at a time.
 			filler[i] = byte(source.Int())
 		}
+		if seed != 0 {
+			return fmt.Appendf(nil, "%x %d/%d", filler, seed, n)
+		}
 		return fmt.Appendf(nil, "%x %d", filler, n)
 	}
 
@@ -263,7 +407,9 @@ func mustCreateReaders(us []string) loadtest.LogReader {
 	return loadtest.NewRoundRobinReader(r)
 }
 
-func mustCreateWriters(us []string) loadtest.LeafWriter {
+// mustCreateWriters returns a LeafWriter which spreads writes over LeafWriters
+// created by newWriter for the add endpoint of each of the logs at us.
+func mustCreateWriters(us []string, newWriter func(*url.URL) loadtest.LeafWriter) loadtest.LeafWriter {
 	w := []loadtest.LeafWriter{}
 	for _, u := range us {
 		if !strings.HasSuffix(u, "/") {
@@ -274,7 +420,7 @@ func mustCreateWriters(us []string) loadtest.LeafWriter {
 		if err != nil {
 			klog.Exitf("Invalid log writer URL %q: %v", u, err)
 		}
-		w = append(w, httpWriter(wURL, hc, *bearerTokenWrite))
+		w = append(w, newWriter(wURL))
 	}
 	return loadtest.NewRoundRobinWriter(w)
 }
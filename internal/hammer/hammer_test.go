@@ -15,12 +15,13 @@
 package main
 
 import (
+	"bytes"
 	"testing"
 )
 
 func TestLeafGenerator(t *testing.T) {
 	// Always generate new values
-	gN := newLeafGenerator(0, 100, 0)
+	gN := newLeafGenerator(0, 0, 100, 0, 0)
 	vs := make(map[string]bool)
 	for range 256 {
 		v := string(gN())
@@ -28,10 +29,53 @@ func TestLeafGenerator(t *testing.T) {
 	}
 
 	// Always generate duplicate
-	gD := newLeafGenerator(256, 100, 1.0)
+	gD := newLeafGenerator(256, 0, 100, 0, 1.0)
 	for range 256 {
 		if !vs[string(gD())] {
 			t.Error("Expected duplicate")
 		}
 	}
 }
+
+func TestLeafGeneratorSizes(t *testing.T) {
+	for _, test := range []struct {
+		name             string
+		minSize, maxSize int
+	}{
+		{name: "fixed", minSize: 100},
+		{name: "max below min", minSize: 100, maxSize: 50},
+		{name: "range", minSize: 100, maxSize: 1000},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			g := newLeafGenerator(0, 0, test.minSize, test.maxSize, 0)
+			upper := max(test.minSize, test.maxSize)
+			sizes := make(map[int]bool)
+			for range 256 {
+				v := g()
+				// Leaves are the filler followed by a space and the decimal leaf number.
+				filler := len(bytes.SplitN(v, []byte(" "), 2)[0])
+				if filler < test.minSize-1 || filler > upper {
+					t.Errorf("Got leaf with %d bytes of filler, want between %d and %d", filler, test.minSize-1, upper)
+				}
+				sizes[filler] = true
+			}
+			if spread := len(sizes) > 1; spread != (test.maxSize > test.minSize) {
+				t.Errorf("Got %d distinct sizes", len(sizes))
+			}
+		})
+	}
+}
+
+func TestLeafGeneratorSeeds(t *testing.T) {
+	vs := make(map[string]bool)
+	for seed := range uint64(3) {
+		g := newLeafGenerator(0, seed, 0, 0, 0)
+		for range 256 {
+			v := string(g())
+			if vs[v] {
+				t.Errorf("Generator with seed %d generated %q, which another seed already generated", seed, v)
+			}
+			vs[v] = true
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// sustainableWindow is the number of most recent seconds over which the achieved
+// write rate is averaged to find the sustainable rate.
+const sustainableWindow = 30
+
+// NewAIMDController returns a controller which adapts the write rate of h based on
+// pushback recorded in s, using additive-increase/multiplicative-decrease.
+// The rate starts at 1 op/s, and is never raised above maxOpsPerSecond.
+func NewAIMDController(h *Hammer, s *OpStats, maxOpsPerSecond int) *AIMDController {
+	h.writeThrottle.set(min(maxOpsPerSecond, 1))
+	return &AIMDController{
+		throttle: h.writeThrottle,
+		s:        s,
+		max:      maxOpsPerSecond,
+		increase: max(maxOpsPerSecond/50, 1),
+	}
+}
+
+// AIMDController finds the write rate that a log can sustain.
+// Each second, if the log pushed back on any writes the rate is cut by a quarter, otherwise,
+// if the writers used all of their allowance, it's raised by 2% of the maximum.
+type AIMDController struct {
+	throttle *Throttle
+	s        *OpStats
+	max      int
+	increase int
+
+	mu sync.Mutex
+	// window holds the achieved write rate for each of the most recent seconds.
+	window []float64
+}
+
+// Run adapts the write rate until ctx is done. This should be called in a goroutine.
+func (c *AIMDController) Run(ctx context.Context) {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	last := c.s.Counts()
+	lastAt := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-tick.C:
+			cur := c.s.Counts()
+			c.update(float64(cur.Succeeded-last.Succeeded)/now.Sub(lastAt).Seconds(), cur.Pushback > last.Pushback)
+			last, lastAt = cur, now
+		}
+	}
+}
+
+// update adjusts the rate given the write rate achieved in the last interval, and
+// whether the log pushed back on any of those writes.
+func (c *AIMDController) update(qps float64, pushback bool) {
+	c.throttle.mu.Lock()
+	ops, starved := c.throttle.opsPerSecond, c.throttle.oversupply == 0
+	switch {
+	case pushback:
+		c.throttle.opsPerSecond = max(ops*3/4, 1)
+	case starved:
+		c.throttle.opsPerSecond = min(ops+c.increase, c.max)
+	}
+	newOps := c.throttle.opsPerSecond
+	c.throttle.mu.Unlock()
+	if newOps != ops {
+		klog.V(1).Infof("AIMD: write rate %d -> %d ops/s (achieved %.1fqps, pushback=%t)", ops, newOps, qps, pushback)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = append(c.window, qps)
+	if len(c.window) > sustainableWindow {
+		c.window = c.window[1:]
+	}
+}
+
+// Sustainable returns the average write rate achieved over the last sustainableWindow
+// seconds. Once the controller has converged, the rate oscillates around the highest
+// rate that the log accepts without pushback, so this is a slightly conservative
+// estimate of the log's sustainable throughput.
+func (c *AIMDController) Sustainable() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.window) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, q := range c.window {
+		sum += q
+	}
+	return sum / float64(len(c.window))
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import "testing"
+
+func TestAIMDController(t *testing.T) {
+	h := &Hammer{writeThrottle: NewThrottle(100)}
+	c := NewAIMDController(h, &OpStats{}, 100)
+	rate := func() int {
+		h.writeThrottle.mu.Lock()
+		defer h.writeThrottle.mu.Unlock()
+		return h.writeThrottle.opsPerSecond
+	}
+	if got := rate(); got != 1 {
+		t.Fatalf("Got initial rate %d, want 1", got)
+	}
+
+	for _, step := range []struct {
+		name       string
+		oversupply int
+		pushback   bool
+		wantRate   int
+	}{
+		{name: "increase", wantRate: 3},
+		{name: "increase again", wantRate: 5},
+		{name: "no increase while writers can't keep up", oversupply: 1, wantRate: 5},
+		{name: "decrease on pushback", pushback: true, wantRate: 3},
+		{name: "decrease again", pushback: true, wantRate: 2},
+		{name: "never below 1", pushback: true, wantRate: 1},
+		{name: "never below 1 again", pushback: true, wantRate: 1},
+	} {
+		h.writeThrottle.oversupply = step.oversupply
+		c.update(10, step.pushback)
+		if got := rate(); got != step.wantRate {
+			t.Errorf("%s: got rate %d, want %d", step.name, got, step.wantRate)
+		}
+	}
+
+	for range 100 {
+		c.update(10, false)
+	}
+	if got := rate(); got != 100 {
+		t.Errorf("Got rate %d, want capped at 100", got)
+	}
+}
+
+func TestAIMDControllerSustainable(t *testing.T) {
+	c := NewAIMDController(&Hammer{writeThrottle: NewThrottle(100)}, &OpStats{}, 100)
+	if got := c.Sustainable(); got != 0 {
+		t.Errorf("Got sustainable rate %f before any updates, want 0", got)
+	}
+	for range sustainableWindow {
+		c.update(1000, false)
+	}
+	// Only the most recent window counts.
+	for i := range sustainableWindow {
+		c.update(float64(10+i%2*10), i%2 == 1)
+	}
+	if got := c.Sustainable(); got != 15 {
+		t.Errorf("Got sustainable rate %f, want 15", got)
+	}
+}
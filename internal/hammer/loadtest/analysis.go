@@ -23,6 +23,11 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// maxPendingSamples is the maximum number of leaf samples to hold while waiting
+// for them to be integrated. Once reached, new samples are left in SeqLeafChan,
+// and are dropped by the writers when it's full.
+const maxPendingSamples = 10000
+
 func NewHammerAnalyser(treeSizeFn func() uint64) *HammerAnalyser {
 	leafSampleChan := make(chan LeafTime, 100)
 	errChan := make(chan error, 20)
@@ -43,9 +48,95 @@ type HammerAnalyser struct {
 
 	QueueTime       *movingaverage.ConcurrentMovingAverage
 	IntegrationTime *movingaverage.ConcurrentMovingAverage
+
+	// Writes records the outcome of every write made through a LeafWriter
+	// returned by RecordWrites.
+	Writes OpStats
+	// Reads records the outcome of every read made through a LogReader
+	// returned by RecordReads.
+	Reads OpStats
+	// Oversized and Malformed record the outcomes of adversarial writes, where
+	// success means that the log accepted the request.
+	Oversized OpStats
+	Malformed OpStats
+	// Inclusion records the outcome of spot-checks by an InclusionVerifier,
+	// and the time from a leaf being written to its inclusion being verified.
+	Inclusion OpStats
+	// Integration records the time taken for each leaf sampled via SeqLeafChan
+	// to be covered by a checkpoint, measured from when it was sent to the log.
+	Integration LatencyStats
+
+	start     time.Time
+	startSize uint64
+}
+
+// RecordWrites returns a LeafWriter which records the outcome and latency of
+// each write made through w in a.Writes.
+// Writes which fail because ctx was cancelled, e.g. at the end of a run, are not recorded.
+func (a *HammerAnalyser) RecordWrites(w LeafWriter) LeafWriter {
+	return func(ctx context.Context, data []byte) (uint64, error) {
+		return record(ctx, &a.Writes, func() (uint64, error) { return w(ctx, data) })
+	}
+}
+
+// RecordReads returns a LogReader which records the outcome and latency of
+// each read made through r in a.Reads.
+// As with RecordWrites, reads which fail because their context was cancelled are not recorded.
+func (a *HammerAnalyser) RecordReads(r LogReader) LogReader {
+	return &recordingReader{r: r, s: &a.Reads}
+}
+
+type recordingReader struct {
+	r LogReader
+	s *OpStats
+}
+
+func (rr *recordingReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	return record(ctx, rr.s, func() ([]byte, error) { return rr.r.ReadCheckpoint(ctx) })
+}
+
+func (rr *recordingReader) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
+	return record(ctx, rr.s, func() ([]byte, error) { return rr.r.ReadTile(ctx, l, i, p) })
+}
+
+func (rr *recordingReader) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
+	return record(ctx, rr.s, func() ([]byte, error) { return rr.r.ReadEntryBundle(ctx, i, p) })
+}
+
+// record calls f, and records its outcome in s unless ctx has been cancelled.
+func record[T any](ctx context.Context, s *OpStats, f func() (T, error)) (T, error) {
+	start := time.Now()
+	v, err := f()
+	if ctx.Err() == nil {
+		s.Record(time.Since(start), err)
+	}
+	return v, err
+}
+
+// Summary returns a summary of the run so far.
+func (a *HammerAnalyser) Summary() Summary {
+	return MergeReports([]Report{a.Report()})
+}
+
+// Report returns the results of the run so far, in a form which can be merged
+// with those of other hammers.
+func (a *HammerAnalyser) Report() Report {
+	return Report{
+		Start:       a.start,
+		Duration:    time.Since(a.start).Seconds(),
+		StartSize:   a.startSize,
+		EndSize:     a.treeSizeFn(),
+		Writes:      a.Writes.Counts(),
+		Reads:       a.Reads.Counts(),
+		Oversized:   a.Oversized.Counts(),
+		Malformed:   a.Malformed.Counts(),
+		Inclusion:   a.Inclusion.Counts(),
+		Integration: a.Integration.Counts(),
+	}
 }
 
 func (a *HammerAnalyser) Run(ctx context.Context) {
+	a.start, a.startSize = time.Now(), a.treeSizeFn()
 	go a.updateStatsLoop(ctx)
 	go a.errorLoop(ctx)
 }
@@ -53,12 +144,26 @@ func (a *HammerAnalyser) Run(ctx context.Context) {
 func (a *HammerAnalyser) updateStatsLoop(ctx context.Context) {
 	tick := time.NewTicker(100 * time.Millisecond)
 	size := a.treeSizeFn()
+	// pending holds the samples which are not yet known to be integrated.
+	var pending []LeafTime
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-tick.C:
 		}
+	ReadLoop:
+		for len(pending) < maxPendingSamples {
+			select {
+			case l, ok := <-a.SeqLeafChan:
+				if !ok {
+					break ReadLoop
+				}
+				pending = append(pending, l)
+			default:
+				break ReadLoop
+			}
+		}
 		newSize := a.treeSizeFn()
 		if newSize <= size {
 			continue
@@ -67,26 +172,15 @@ func (a *HammerAnalyser) updateStatsLoop(ctx context.Context) {
 		totalLatency := time.Duration(0)
 		queueLatency := time.Duration(0)
 		numLeaves := 0
-		var sample *LeafTime
-	ReadLoop:
-		for {
-			if sample == nil {
-				select {
-				case l, ok := <-a.SeqLeafChan:
-					if !ok {
-						break ReadLoop
-					}
-					sample = &l
-				default:
-					break ReadLoop
-				}
-			}
-			// Stop considering leaf times once we've caught up with that cross
-			// either the current checkpoint or "now":
+		remaining := pending[:0]
+		for _, sample := range pending {
+			// Only consider leaves which are covered by the current checkpoint, and were assigned by "now":
 			// - leaves with indices beyond the tree size we're considering are not integrated yet, so we can't calculate their TTI
 			// - leaves which were queued before "now", but not assigned by "now" should also be ignored as they don't fall into this epoch (and would contribute a -ve latency if they were included).
+			// Any others are kept until a later checkpoint covers them.
 			if sample.Index >= newSize || sample.AssignedAt.After(now) {
-				break
+				remaining = append(remaining, sample)
+				continue
 			}
 			queueLatency += sample.AssignedAt.Sub(sample.QueuedAt)
 			// totalLatency is skewed towards being higher than perhaps it may technically be by:
@@ -95,10 +189,11 @@ func (a *HammerAnalyser) updateStatsLoop(ctx context.Context) {
 			// - any latency in writes to the log becoming visible for reads.
 			// But it's probably good enough for now.
 			totalLatency += now.Sub(sample.QueuedAt)
+			a.Integration.Record(now.Sub(sample.QueuedAt))
 
 			numLeaves++
-			sample = nil
 		}
+		pending = remaining
 		if numLeaves > 0 {
 			a.IntegrationTime.Add(float64(totalLatency/time.Millisecond) / float64(numLeaves))
 			a.QueueTime.Add(float64(queueLatency/time.Millisecond) / float64(numLeaves))
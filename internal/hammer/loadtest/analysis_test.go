@@ -47,6 +47,34 @@ func TestHammerAnalyser_Stats(t *testing.T) {
 	}
 }
 
+func TestHammerAnalyser_PendingSamples(t *testing.T) {
+	ctx := t.Context()
+
+	var treeSize treeSizeState
+	ha := NewHammerAnalyser(treeSize.getSize)
+
+	go ha.updateStatsLoop(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+
+	now := time.Now()
+	for i := range 10 {
+		ha.SeqLeafChan <- LeafTime{Index: uint64(i), QueuedAt: now, AssignedAt: now}
+	}
+	// Only the first half of the samples are integrated at first, the rest must
+	// be held until a later checkpoint covers them.
+	treeSize.setSize(5)
+	time.Sleep(300 * time.Millisecond)
+	if got := ha.Integration.Summary().Samples; got != 5 {
+		t.Errorf("Got %d integrated samples, want 5", got)
+	}
+	treeSize.setSize(10)
+	time.Sleep(300 * time.Millisecond)
+	if got := ha.Integration.Summary().Samples; got != 10 {
+		t.Errorf("Got %d integrated samples, want 10", got)
+	}
+}
+
 type treeSizeState struct {
 	size uint64
 	mux  sync.RWMutex
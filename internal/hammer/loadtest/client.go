@@ -22,6 +22,11 @@ import (
 
 var ErrRetry = errors.New("retry")
 
+// ErrSkipped may be returned by a LeafWriter which used its turn for something
+// other than writing the leaf it was given, e.g. sending an adversarial request.
+// The leaf will be retried on the writer's next turn.
+var ErrSkipped = errors.New("skipped")
+
 // NewRoundRobinReader creates a new LogReader which will spread read requests over the passed-in LogReaders.
 func NewRoundRobinReader(r []LogReader) LogReader {
 	return &roundRobinReader{r: r}
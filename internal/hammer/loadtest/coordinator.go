@@ -0,0 +1,268 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Report holds the results of a single hammer's run in a form which can be
+// merged with those of other hammers by MergeReports.
+type Report struct {
+	Start       time.Time      `json:"start"`
+	Duration    float64        `json:"duration_seconds"`
+	StartSize   uint64         `json:"start_size"`
+	EndSize     uint64         `json:"end_size"`
+	Writes      OpCounts       `json:"writes"`
+	Reads       OpCounts       `json:"reads"`
+	Oversized   OpCounts       `json:"oversized"`
+	Malformed   OpCounts       `json:"malformed"`
+	Inclusion   OpCounts       `json:"inclusion"`
+	Integration map[int]uint64 `json:"integration"`
+	// SustainableWriteQPS is the write rate found by an AIMDController, if one was used.
+	SustainableWriteQPS float64 `json:"sustainable_write_qps,omitempty"`
+}
+
+// MergeReports returns a summary of the combined results of hammers which ran
+// concurrently against the same log.
+// The run is taken to span from the earliest start to the latest finish.
+func MergeReports(rs []Report) Summary {
+	if len(rs) == 0 {
+		return Summary{}
+	}
+	m := Report{Start: rs[0].Start, StartSize: rs[0].StartSize}
+	var end time.Time
+	for _, r := range rs {
+		if r.Start.Before(m.Start) {
+			m.Start = r.Start
+		}
+		if e := r.Start.Add(time.Duration(r.Duration * float64(time.Second))); e.After(end) {
+			end = e
+		}
+		m.StartSize = min(m.StartSize, r.StartSize)
+		m.EndSize = max(m.EndSize, r.EndSize)
+		m.Writes = m.Writes.merge(r.Writes)
+		m.Reads = m.Reads.merge(r.Reads)
+		m.Oversized = m.Oversized.merge(r.Oversized)
+		m.Malformed = m.Malformed.merge(r.Malformed)
+		m.Inclusion = m.Inclusion.merge(r.Inclusion)
+		m.Integration = mergeBuckets(m.Integration, r.Integration)
+		m.SustainableWriteQPS += r.SustainableWriteQPS
+	}
+	elapsed := end.Sub(m.Start)
+	return Summary{
+		Start:               m.Start,
+		Duration:            elapsed.Seconds(),
+		StartSize:           m.StartSize,
+		EndSize:             m.EndSize,
+		Writes:              m.Writes.summary(elapsed),
+		Reads:               m.Reads.summary(elapsed),
+		Oversized:           m.Oversized.summary(elapsed),
+		Malformed:           m.Malformed.summary(elapsed),
+		Inclusion:           m.Inclusion.summary(elapsed),
+		Integration:         latencySummary(m.Integration),
+		SustainableWriteQPS: m.SustainableWriteQPS,
+	}
+}
+
+// CoordinatorOpts configures a Coordinator.
+type CoordinatorOpts struct {
+	// Hammers is the number of hammers which must join before any of them start.
+	Hammers int
+	// MaxReadOpsPerSecond and MaxWriteOpsPerSecond are the target rates for
+	// all hammers combined, which are split evenly between them.
+	MaxReadOpsPerSecond  int
+	MaxWriteOpsPerSecond int
+	// StartDelay is how long after the last hammer joins that they all start,
+	// which should be long enough for every hammer to receive its Assignment.
+	StartDelay time.Duration
+}
+
+// Assignment tells a hammer which joined a Coordinator how to run.
+type Assignment struct {
+	// Hammer is the index of this hammer, out of Hammers.
+	Hammer  int `json:"hammer"`
+	Hammers int `json:"hammers"`
+	// MaxReadOpsPerSecond and MaxWriteOpsPerSecond are this hammer's share of the target rates.
+	MaxReadOpsPerSecond  int `json:"max_read_ops"`
+	MaxWriteOpsPerSecond int `json:"max_write_ops"`
+	// StartIn is how long the hammer should wait before starting. It's relative
+	// so that hammers don't depend on their clocks agreeing.
+	StartIn time.Duration `json:"start_in"`
+}
+
+// NewCoordinator returns a Coordinator, which allows hammers on different
+// machines to share a target rate, start together, and have their results merged.
+func NewCoordinator(opts CoordinatorOpts) *Coordinator {
+	return &Coordinator{
+		opts:      opts,
+		allJoined: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Coordinator is the server side of distributed hammering.
+// Hammers call Join to get their Assignment, and SendReport when they've finished.
+type Coordinator struct {
+	opts CoordinatorOpts
+
+	mu        sync.Mutex
+	joined    int
+	start     time.Time
+	reports   []Report
+	allJoined chan struct{}
+	done      chan struct{}
+}
+
+// RegisterHandlers registers the coordinator's /join and /report handlers on mux.
+func (c *Coordinator) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /join", c.handleJoin)
+	mux.HandleFunc("POST /report", c.handleReport)
+}
+
+// Wait blocks until every hammer has sent its report, and returns their merged results.
+func (c *Coordinator) Wait(ctx context.Context) (Summary, error) {
+	select {
+	case <-ctx.Done():
+		return Summary{}, ctx.Err()
+	case <-c.done:
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return MergeReports(c.reports), nil
+}
+
+func (c *Coordinator) handleJoin(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	if c.joined >= c.opts.Hammers {
+		c.mu.Unlock()
+		http.Error(w, fmt.Sprintf("all %d hammers have already joined", c.opts.Hammers), http.StatusConflict)
+		return
+	}
+	i := c.joined
+	c.joined++
+	klog.Infof("Hammer %d of %d joined from %s", i+1, c.opts.Hammers, r.RemoteAddr)
+	if c.joined == c.opts.Hammers {
+		c.start = time.Now().Add(c.opts.StartDelay)
+		close(c.allJoined)
+	}
+	c.mu.Unlock()
+
+	// Hold the request until every hammer has joined, so that they all learn the start time together.
+	select {
+	case <-r.Context().Done():
+		return
+	case <-c.allJoined:
+	}
+	writeJSON(w, Assignment{
+		Hammer:               i,
+		Hammers:              c.opts.Hammers,
+		MaxReadOpsPerSecond:  share(c.opts.MaxReadOpsPerSecond, i, c.opts.Hammers),
+		MaxWriteOpsPerSecond: share(c.opts.MaxWriteOpsPerSecond, i, c.opts.Hammers),
+		StartIn:              max(time.Until(c.start), 0),
+	})
+}
+
+func (c *Coordinator) handleReport(w http.ResponseWriter, r *http.Request) {
+	var rep Report
+	if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+		http.Error(w, fmt.Sprintf("invalid report: %v", err), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.reports) >= c.opts.Hammers {
+		http.Error(w, fmt.Sprintf("all %d hammers have already reported", c.opts.Hammers), http.StatusConflict)
+		return
+	}
+	c.reports = append(c.reports, rep)
+	klog.Infof("Received report %d of %d from %s", len(c.reports), c.opts.Hammers, r.RemoteAddr)
+	if len(c.reports) == c.opts.Hammers {
+		close(c.done)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// share returns hammer i's share of total when split between n hammers.
+func share(total, i, n int) int {
+	s := total / n
+	if i < total%n {
+		s++
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Warningf("Failed to write response: %v", err)
+	}
+}
+
+// Join joins the coordinator at u, and returns its Assignment once all of the hammers have joined.
+// hc must not have a timeout shorter than the time it may take for the other hammers to join.
+func Join(ctx context.Context, hc *http.Client, u *url.URL) (Assignment, error) {
+	var a Assignment
+	body, err := post(ctx, hc, u.JoinPath("join"), nil)
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal(body, &a); err != nil {
+		return a, fmt.Errorf("failed to parse assignment %q: %v", body, err)
+	}
+	return a, nil
+}
+
+// SendReport sends the results of this hammer's run to the coordinator at u.
+func SendReport(ctx context.Context, hc *http.Client, u *url.URL, r Report) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %v", err)
+	}
+	_, err = post(ctx, hc, u.JoinPath("report"), b)
+	return err
+}
+
+func post(ctx context.Context, hc *http.Client, u *url.URL, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to POST to %s: %v", u, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %v", u, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("POST to %s returned status %d: %q", u, resp.StatusCode, b)
+	}
+	return b, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoordinator(t *testing.T) {
+	ctx := t.Context()
+	c := NewCoordinator(CoordinatorOpts{Hammers: 3, MaxReadOpsPerSecond: 10, MaxWriteOpsPerSecond: 100, StartDelay: time.Second})
+	mux := http.NewServeMux()
+	c.RegisterHandlers(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := make([]Assignment, 3)
+	var wg sync.WaitGroup
+	for i := range as {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, err := Join(ctx, srv.Client(), u)
+			if err != nil {
+				t.Errorf("Join: %v", err)
+			}
+			as[i] = a
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[int]bool)
+	var reads, writes int
+	for _, a := range as {
+		seen[a.Hammer] = true
+		reads += a.MaxReadOpsPerSecond
+		writes += a.MaxWriteOpsPerSecond
+		if a.Hammers != 3 || a.StartIn <= 0 || a.StartIn > time.Second {
+			t.Errorf("Got assignment %+v, want 3 hammers and to start within 1s", a)
+		}
+	}
+	if len(seen) != 3 || reads != 10 || writes != 100 {
+		t.Errorf("Got assignments %+v, want 3 distinct hammers sharing 10 reads/s and 100 writes/s", as)
+	}
+	if _, err := Join(ctx, srv.Client(), u); err == nil {
+		t.Error("Join succeeded after all hammers joined")
+	}
+
+	start := time.Unix(1000, 0)
+	for i := range 3 {
+		var w OpStats
+		w.Record(time.Duration(i+1)*time.Millisecond, nil)
+		w.Record(time.Millisecond, ErrRetry)
+		r := Report{Start: start.Add(time.Duration(i) * time.Second), Duration: 10, StartSize: uint64(i), EndSize: uint64(100 + i), Writes: w.Counts()}
+		if err := SendReport(ctx, srv.Client(), u, r); err != nil {
+			t.Fatalf("SendReport: %v", err)
+		}
+	}
+	got, err := c.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if !got.Start.Equal(start) || got.Duration != 12 || got.StartSize != 0 || got.EndSize != 102 {
+		t.Errorf("Got run %v for %vs from size %d to %d, want %v for 12s from 0 to 102", got.Start, got.Duration, got.StartSize, got.EndSize, start)
+	}
+	if w := got.Writes; w.Succeeded != 3 || w.Pushback != 3 || w.QPS != 0.25 || w.LatencyP99Ms < 3 || w.LatencyP99Ms > 3*latencyBucketGrowth {
+		t.Errorf("Got merged writes %+v, want 3 succeeded and 3 pushback at 0.25qps, with p99 3ms", w)
+	}
+}
+
+func TestShare(t *testing.T) {
+	for _, test := range []struct {
+		total, n int
+		want     []int
+	}{
+		{total: 10, n: 1, want: []int{10}},
+		{total: 10, n: 3, want: []int{4, 3, 3}},
+		{total: 1, n: 2, want: []int{1, 0}},
+		{total: 0, n: 2, want: []int{0, 0}},
+	} {
+		for i, want := range test.want {
+			if got := share(test.total, i, test.n); got != want {
+				t.Errorf("share(%d, %d, %d) = %d, want %d", test.total, i, test.n, got, want)
+			}
+		}
+	}
+}
@@ -31,11 +31,19 @@ type HammerOpts struct {
 	NumReadersRandom int
 	NumReadersFull   int
 	NumWriters       int
+
+	// RampUp is the duration over which the read and write throttles are raised
+	// linearly from 1 op/s to their configured maximums, or 0 to start at full rate.
+	RampUp time.Duration
 }
 
 func NewHammer(tracker *client.LogStateTracker, f client.EntryBundleFetcherFunc, w LeafWriter, gen func() []byte, seqLeafChan chan<- LeafTime, errChan chan<- error, opts HammerOpts) *Hammer {
 	readThrottle := NewThrottle(opts.MaxReadOpsPerSecond)
 	writeThrottle := NewThrottle(opts.MaxWriteOpsPerSecond)
+	if opts.RampUp > 0 {
+		readThrottle.set(min(opts.MaxReadOpsPerSecond, 1))
+		writeThrottle.set(min(opts.MaxWriteOpsPerSecond, 1))
+	}
 
 	randomReaders := NewWorkerPool(func() Worker {
 		return NewLeafReader(tracker, f, RandomNextLeaf(), readThrottle.TokenChan, errChan)
@@ -87,6 +95,31 @@ func (h *Hammer) Run(ctx context.Context) {
 	go h.writeThrottle.Run(ctx)
 
 	go h.updateCheckpointLoop(ctx)
+	if h.opts.RampUp > 0 {
+		go h.rampUpLoop(ctx)
+	}
+}
+
+// rampUpLoop raises the throttles towards their configured maximums in proportion
+// to the time elapsed, until RampUp has passed.
+func (h *Hammer) rampUpLoop(ctx context.Context) {
+	start := time.Now()
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		f := min(float64(time.Since(start))/float64(h.opts.RampUp), 1)
+		h.readThrottle.set(max(int(f*float64(h.opts.MaxReadOpsPerSecond)), min(h.opts.MaxReadOpsPerSecond, 1)))
+		h.writeThrottle.set(max(int(f*float64(h.opts.MaxWriteOpsPerSecond)), min(h.opts.MaxWriteOpsPerSecond, 1)))
+		if f >= 1 {
+			klog.Infof("Ramp up complete after %s", time.Since(start))
+			return
+		}
+	}
 }
 
 func (h *Hammer) updateCheckpointLoop(ctx context.Context) {
@@ -0,0 +1,305 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBucketGrowth is the ratio between the bounds of successive latency
+// histogram buckets, so reported percentiles are accurate to within 5%.
+const latencyBucketGrowth = 1.05
+
+// OpStats accumulates the outcomes and latencies of one kind of operation.
+// It is safe for concurrent use.
+type OpStats struct {
+	mu        sync.Mutex
+	succeeded uint64
+	failed    uint64
+	pushback  uint64
+	// latencies holds the latencies of successful operations only.
+	latencies latencyHistogram
+}
+
+// Record records the outcome of an operation which took d to complete.
+// Errors wrapping ErrRetry are counted as pushback from the log, and only the
+// latencies of successful operations are recorded.
+func (s *OpStats) Record(d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case err == nil:
+		s.succeeded++
+		s.latencies.add(d)
+	case errors.Is(err, ErrRetry):
+		s.pushback++
+	default:
+		s.failed++
+	}
+}
+
+// Summary returns a summary of the operations recorded over elapsed.
+func (s *OpStats) Summary(elapsed time.Duration) OpSummary {
+	return s.Counts().summary(elapsed)
+}
+
+// Counts returns a snapshot of the outcomes and latencies recorded so far.
+func (s *OpStats) Counts() OpCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return OpCounts{
+		Succeeded: s.succeeded,
+		Failed:    s.failed,
+		Pushback:  s.pushback,
+		Latencies: maps.Clone(s.latencies.buckets),
+	}
+}
+
+// OpCounts is a snapshot of OpStats which, unlike OpSummary, can be merged
+// with the counts from other hammers.
+type OpCounts struct {
+	Succeeded uint64 `json:"succeeded"`
+	Failed    uint64 `json:"failed"`
+	Pushback  uint64 `json:"pushback"`
+	// Latencies is the latency histogram of successful operations, keyed by bucket.
+	Latencies map[int]uint64 `json:"latencies"`
+}
+
+func (c OpCounts) merge(o OpCounts) OpCounts {
+	c.Succeeded += o.Succeeded
+	c.Failed += o.Failed
+	c.Pushback += o.Pushback
+	c.Latencies = mergeBuckets(c.Latencies, o.Latencies)
+	return c
+}
+
+func (c OpCounts) summary(elapsed time.Duration) OpSummary {
+	r := OpSummary{
+		Succeeded: c.Succeeded,
+		Failed:    c.Failed,
+		Pushback:  c.Pushback,
+	}
+	if elapsed > 0 {
+		r.QPS = float64(c.Succeeded) / elapsed.Seconds()
+	}
+	if total := c.Succeeded + c.Failed + c.Pushback; total > 0 {
+		r.ErrorRate = float64(c.Failed) / float64(total)
+		r.PushbackRate = float64(c.Pushback) / float64(total)
+	}
+	h := latencyHistogram{n: c.Succeeded, buckets: c.Latencies}
+	r.LatencyP50Ms, r.LatencyP95Ms, r.LatencyP99Ms = h.percentile(0.5), h.percentile(0.95), h.percentile(0.99)
+	return r
+}
+
+// LatencyStats accumulates latencies.
+// It is safe for concurrent use.
+type LatencyStats struct {
+	mu        sync.Mutex
+	latencies latencyHistogram
+}
+
+// Record records a latency of d.
+func (s *LatencyStats) Record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies.add(d)
+}
+
+// Summary returns a summary of the latencies recorded so far.
+func (s *LatencyStats) Summary() LatencySummary {
+	return latencySummary(s.Counts())
+}
+
+// Counts returns a snapshot of the latency histogram, keyed by bucket, which
+// can be merged with those from other hammers.
+func (s *LatencyStats) Counts() map[int]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.latencies.buckets)
+}
+
+func latencySummary(buckets map[int]uint64) LatencySummary {
+	h := latencyHistogram{buckets: buckets}
+	for _, n := range buckets {
+		h.n += n
+	}
+	return LatencySummary{
+		Samples:      h.n,
+		LatencyP50Ms: h.percentile(0.5),
+		LatencyP95Ms: h.percentile(0.95),
+		LatencyP99Ms: h.percentile(0.99),
+	}
+}
+
+// mergeBuckets returns the sum of the latency histograms a and b.
+func mergeBuckets(a, b map[int]uint64) map[int]uint64 {
+	r := maps.Clone(a)
+	if r == nil {
+		r = make(map[int]uint64, len(b))
+	}
+	for k, n := range b {
+		r[k] += n
+	}
+	return r
+}
+
+// latencyHistogram is a histogram of latencies with exponentially sized buckets.
+type latencyHistogram struct {
+	n uint64
+	// buckets holds the number of latencies in each bucket, keyed by the index
+	// returned by latencyBucket.
+	buckets map[int]uint64
+}
+
+func (h *latencyHistogram) add(d time.Duration) {
+	if h.buckets == nil {
+		h.buckets = make(map[int]uint64)
+	}
+	h.buckets[latencyBucket(d)]++
+	h.n++
+}
+
+// percentile returns the latency in milliseconds below which the fraction q
+// of the latencies in h fall.
+func (h *latencyHistogram) percentile(q float64) float64 {
+	var seen uint64
+	for _, k := range slices.Sorted(maps.Keys(h.buckets)) {
+		seen += h.buckets[k]
+		if float64(seen) >= q*float64(h.n) {
+			return latencyBucketBound(k)
+		}
+	}
+	return 0
+}
+
+// latencyBucket returns the index of the histogram bucket for d.
+func latencyBucket(d time.Duration) int {
+	us := max(float64(d)/float64(time.Microsecond), 1)
+	return int(math.Ceil(math.Log(us) / math.Log(latencyBucketGrowth)))
+}
+
+// latencyBucketBound returns the upper bound, in milliseconds, of the latencies
+// in bucket i.
+func latencyBucketBound(i int) float64 {
+	return math.Pow(latencyBucketGrowth, float64(i)) / 1000
+}
+
+// LatencySummary summarises a set of latencies.
+type LatencySummary struct {
+	Samples      uint64  `json:"samples"`
+	LatencyP50Ms float64 `json:"latency_p50_ms"`
+	LatencyP95Ms float64 `json:"latency_p95_ms"`
+	LatencyP99Ms float64 `json:"latency_p99_ms"`
+}
+
+func (s LatencySummary) String() string {
+	return fmt.Sprintf("%d samples, latency %.0fms/%.0fms/%.0fms (p50/p95/p99)",
+		s.Samples, s.LatencyP50Ms, s.LatencyP95Ms, s.LatencyP99Ms)
+}
+
+// OpSummary summarises the operations of one kind made during a hammer run.
+type OpSummary struct {
+	Succeeded uint64 `json:"succeeded"`
+	Failed    uint64 `json:"failed"`
+	// Pushback is the number of operations rejected by the log because it was
+	// overloaded, e.g. with a 503 or 429 response.
+	Pushback uint64 `json:"pushback"`
+	// QPS is the achieved rate of successful operations.
+	QPS          float64 `json:"qps"`
+	ErrorRate    float64 `json:"error_rate"`
+	PushbackRate float64 `json:"pushback_rate"`
+	LatencyP50Ms float64 `json:"latency_p50_ms"`
+	LatencyP95Ms float64 `json:"latency_p95_ms"`
+	LatencyP99Ms float64 `json:"latency_p99_ms"`
+}
+
+func (s OpSummary) String() string {
+	return fmt.Sprintf("%d ok, %d failed, %d pushback, %.1fqps, latency %.0fms/%.0fms/%.0fms (p50/p95/p99)",
+		s.Succeeded, s.Failed, s.Pushback, s.QPS, s.LatencyP50Ms, s.LatencyP95Ms, s.LatencyP99Ms)
+}
+
+// Summary is a machine-readable summary of a hammer run, suitable for
+// tracking performance regressions between runs.
+type Summary struct {
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration_seconds"`
+	// StartSize and EndSize are the sizes of the log seen at the start and end of the run.
+	StartSize uint64    `json:"start_size"`
+	EndSize   uint64    `json:"end_size"`
+	Writes    OpSummary `json:"writes"`
+	// Reads covers all reads of checkpoints, tiles, and entry bundles.
+	Reads OpSummary `json:"reads"`
+	// Oversized and Malformed cover adversarial writes. For these, Succeeded is
+	// the number of requests that the log accepted, and Failed the number it rejected.
+	Oversized OpSummary `json:"oversized"`
+	Malformed OpSummary `json:"malformed"`
+	// Inclusion covers spot-checks that written leaves are in the log at the
+	// index it returned. Any failures indicate a bug in the log.
+	Inclusion OpSummary `json:"inclusion"`
+	// Integration is the time from a sample of successful writes being sent to
+	// the log until they were covered by a verified checkpoint.
+	Integration LatencySummary `json:"integration"`
+	// SustainableWriteQPS is the write rate that the log sustained, if the write
+	// rate was adapted to pushback by an AIMDController.
+	SustainableWriteQPS float64 `json:"sustainable_write_qps,omitempty"`
+}
+
+// WriteJSON writes the summary to w as JSON.
+func (s Summary) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(s)
+}
+
+// WriteCSV writes the summary to w as CSV, with a header row followed by one
+// row per kind of operation, and a final row for integration latency.
+func (s Summary) WriteCSV(w io.Writer) error {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	u := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	row := func(op string, o OpSummary) []string {
+		return []string{
+			s.Start.UTC().Format(time.RFC3339), f(s.Duration), u(s.StartSize), u(s.EndSize), f(s.SustainableWriteQPS), op,
+			u(o.Succeeded), u(o.Failed), u(o.Pushback), f(o.QPS), f(o.ErrorRate), f(o.PushbackRate),
+			f(o.LatencyP50Ms), f(o.LatencyP95Ms), f(o.LatencyP99Ms),
+		}
+	}
+	c := csv.NewWriter(w)
+	if err := c.WriteAll([][]string{
+		{"start", "duration_seconds", "start_size", "end_size", "sustainable_write_qps", "op", "succeeded", "failed", "pushback", "qps", "error_rate", "pushback_rate", "latency_p50_ms", "latency_p95_ms", "latency_p99_ms"},
+		row("write", s.Writes),
+		row("read", s.Reads),
+		row("oversized", s.Oversized),
+		row("malformed", s.Malformed),
+		row("inclusion", s.Inclusion),
+		{
+			s.Start.UTC().Format(time.RFC3339), f(s.Duration), u(s.StartSize), u(s.EndSize), f(s.SustainableWriteQPS), "integration",
+			u(s.Integration.Samples), "", "", "", "", "",
+			f(s.Integration.LatencyP50Ms), f(s.Integration.LatencyP95Ms), f(s.Integration.LatencyP99Ms),
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOpStats(t *testing.T) {
+	var s OpStats
+	for i := 1; i <= 100; i++ {
+		s.Record(time.Duration(i)*time.Millisecond, nil)
+	}
+	for range 20 {
+		s.Record(time.Second, fmt.Errorf("log not available %w", ErrRetry))
+	}
+	for range 5 {
+		s.Record(time.Second, errors.New("boom"))
+	}
+
+	got := s.Summary(10 * time.Second)
+	if got.Succeeded != 100 || got.Failed != 5 || got.Pushback != 20 {
+		t.Errorf("Got counts %d/%d/%d, want 100/5/20", got.Succeeded, got.Failed, got.Pushback)
+	}
+	if got.QPS != 10 {
+		t.Errorf("Got QPS %f, want 10", got.QPS)
+	}
+	if got.ErrorRate != 0.04 || got.PushbackRate != 0.16 {
+		t.Errorf("Got error rate %f and pushback rate %f, want 0.04 and 0.16", got.ErrorRate, got.PushbackRate)
+	}
+	for _, p := range []struct {
+		name      string
+		got, want float64
+	}{
+		{name: "p50", got: got.LatencyP50Ms, want: 50},
+		{name: "p95", got: got.LatencyP95Ms, want: 95},
+		{name: "p99", got: got.LatencyP99Ms, want: 99},
+	} {
+		// Latencies are bucketed, so percentiles are only accurate to within the bucket width.
+		if p.got < p.want || p.got > p.want*latencyBucketGrowth {
+			t.Errorf("Got %s %fms, want %fms", p.name, p.got, p.want)
+		}
+	}
+}
+
+func TestOpStatsEmpty(t *testing.T) {
+	var s OpStats
+	if got := s.Summary(0); got != (OpSummary{}) {
+		t.Errorf("Got summary %+v, want zero", got)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var s LatencyStats
+	if got := s.Summary(); got != (LatencySummary{}) {
+		t.Errorf("Got summary %+v before any samples, want zero", got)
+	}
+	for i := 1; i <= 10; i++ {
+		s.Record(time.Duration(i) * time.Second)
+	}
+	got := s.Summary()
+	if got.Samples != 10 || got.LatencyP50Ms < 5000 || got.LatencyP99Ms < 10000 || got.LatencyP99Ms > 10000*latencyBucketGrowth {
+		t.Errorf("Got summary %+v, want 10 samples with p50 5s and p99 10s", got)
+	}
+}
+
+func TestSummaryFormats(t *testing.T) {
+	var s OpStats
+	s.Record(10*time.Millisecond, nil)
+	summary := Summary{Start: time.Unix(1000, 0), Duration: 2, StartSize: 5, EndSize: 6, Writes: s.Summary(2 * time.Second)}
+
+	b := &bytes.Buffer{}
+	if err := summary.WriteJSON(b); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var got Summary
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !got.Start.Equal(summary.Start) || got.Writes != summary.Writes || got.EndSize != 6 {
+		t.Errorf("JSON round trip got %+v, want %+v", got, summary)
+	}
+
+	b.Reset()
+	if err := summary.WriteCSV(b); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	rows, err := csv.NewReader(b).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(rows) != 7 || len(rows[0]) != len(rows[1]) {
+		t.Fatalf("Got CSV rows %q, want header and a row each for writes, reads, oversized, malformed, inclusion, and integration", rows)
+	}
+	row := make(map[string]string)
+	for i, h := range rows[0] {
+		row[h] = rows[1][i]
+	}
+	if row["op"] != "write" || row["succeeded"] != "1" || row["qps"] != "0.5" {
+		t.Errorf("Got CSV row %v", row)
+	}
+	if p50 := summary.Writes.LatencyP50Ms; math.Abs(p50-10) > 10*(latencyBucketGrowth-1) {
+		t.Errorf("Got p50 %fms, want 10ms", p50)
+	}
+}
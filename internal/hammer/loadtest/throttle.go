@@ -55,6 +55,12 @@ func (t *Throttle) Decrease() {
 	t.opsPerSecond = tokenCount - int(delta)
 }
 
+func (t *Throttle) set(opsPerSecond int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.opsPerSecond = opsPerSecond
+}
+
 func (t *Throttle) Run(ctx context.Context) {
 	interval := time.Second
 	ticker := time.NewTicker(interval)
@@ -146,7 +146,22 @@ func (c *tuiController) updateStatsLoop(ctx context.Context, interval time.Durat
 				formatMovingAverage(c.analyser.QueueTime))
 			integrateLine := fmt.Sprintf("Observed-time-to-integrate: %s",
 				formatMovingAverage(c.analyser.IntegrationTime))
-			text := strings.Join([]string{readWorkersLine, writeWorkersLine, treeSizeLine, queueLine, integrateLine}, "\n")
+			elapsed := time.Since(c.analyser.start)
+			writeStatsLine := fmt.Sprintf("Writes: %s", c.analyser.Writes.Summary(elapsed))
+			readStatsLine := fmt.Sprintf("Reads: %s", c.analyser.Reads.Summary(elapsed))
+			integrationStatsLine := fmt.Sprintf("Integration: %s", c.analyser.Integration.Summary())
+			lines := []string{readWorkersLine, writeWorkersLine, treeSizeLine, queueLine, integrateLine, writeStatsLine, readStatsLine, integrationStatsLine}
+			// Adversarial writes and inclusion checks are only shown if they've been enabled.
+			if o := c.analyser.Oversized.Summary(elapsed); o != (OpSummary{}) {
+				lines = append(lines, fmt.Sprintf("Oversized: %s", o))
+			}
+			if m := c.analyser.Malformed.Summary(elapsed); m != (OpSummary{}) {
+				lines = append(lines, fmt.Sprintf("Malformed: %s", m))
+			}
+			if i := c.analyser.Inclusion.Summary(elapsed); i != (OpSummary{}) {
+				lines = append(lines, fmt.Sprintf("Inclusion checks: %s", i))
+			}
+			text := strings.Join(lines, "\n")
 			c.statusView.SetText(text)
 			c.app.Draw()
 		}
@@ -0,0 +1,162 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"k8s.io/klog/v2"
+)
+
+// NewInclusionVerifier creates an InclusionVerifier which spot-checks the given fraction of
+// successful writes, and records the results in s.
+func NewInclusionVerifier(tracker *client.LogStateTracker, tf client.TileFetcherFunc, bf client.EntryBundleFetcherFunc, chance float64, s *OpStats) *InclusionVerifier {
+	return &InclusionVerifier{
+		tracker:   tracker,
+		tf:        tf,
+		bf:        bf,
+		chance:    chance,
+		s:         s,
+		submitted: make(chan submittedLeaf, 100),
+	}
+}
+
+// InclusionVerifier checks that leaves which the log claimed to have sequenced do
+// end up in the log at the index it returned, by fetching the entry and verifying
+// its inclusion in the latest checkpoint once that checkpoint covers it.
+//
+// Consistency between successive checkpoints is already checked by the tracker.
+type InclusionVerifier struct {
+	tracker   *client.LogStateTracker
+	tf        client.TileFetcherFunc
+	bf        client.EntryBundleFetcherFunc
+	chance    float64
+	s         *OpStats
+	submitted chan submittedLeaf
+}
+
+type submittedLeaf struct {
+	index uint64
+	data  []byte
+	at    time.Time
+	// fetchFailures is the number of attempts to verify this leaf which failed
+	// because the data needed couldn't be fetched.
+	fetchFailures int
+}
+
+// maxFetchFailures is the number of times that fetching the data needed to verify
+// a leaf may fail before the leaf is counted as failing verification.
+const maxFetchFailures = 3
+
+// errFetch is returned by InclusionVerifier.verify when the data needed couldn't be fetched.
+var errFetch = errors.New("fetch failed")
+
+// RecordWrites returns a LeafWriter which samples successful writes made through w for verification.
+func (v *InclusionVerifier) RecordWrites(w LeafWriter) LeafWriter {
+	return func(ctx context.Context, data []byte) (uint64, error) {
+		i, err := w(ctx, data)
+		if err == nil && rand.Float64() < v.chance {
+			select {
+			case v.submitted <- submittedLeaf{index: i, data: data, at: time.Now()}:
+			default:
+				// Don't slow down writers if verification can't keep up.
+			}
+		}
+		return i, err
+	}
+}
+
+// Run verifies sampled leaves until ctx is done. This should be called in a goroutine.
+func (v *InclusionVerifier) Run(ctx context.Context) {
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	var pending []submittedLeaf
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	ReadLoop:
+		for len(pending) < maxPendingSamples {
+			select {
+			case l := <-v.submitted:
+				pending = append(pending, l)
+			default:
+				break ReadLoop
+			}
+		}
+		cp := v.tracker.Latest()
+		if len(pending) == 0 || cp.Size == 0 {
+			continue
+		}
+		pb, err := client.NewProofBuilder(ctx, cp.Size, v.tf)
+		if err != nil {
+			klog.Warningf("Failed to create proof builder for tree size %d: %v", cp.Size, err)
+			continue
+		}
+		remaining := pending[:0]
+		for _, l := range pending {
+			if l.index >= cp.Size {
+				remaining = append(remaining, l)
+				continue
+			}
+			err := v.verify(ctx, pb, cp.Size, cp.Hash, l)
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, errFetch) {
+				if l.fetchFailures++; l.fetchFailures < maxFetchFailures {
+					klog.Warningf("Will retry inclusion check: %v", err)
+					remaining = append(remaining, l)
+					continue
+				}
+			}
+			if err != nil {
+				klog.Errorf("Inclusion check failed: %v", err)
+			}
+			v.s.Record(time.Since(l.at), err)
+		}
+		pending = remaining
+	}
+}
+
+// verify checks that l is in the tree with the given size and root hash.
+func (v *InclusionVerifier) verify(ctx context.Context, pb *client.ProofBuilder, size uint64, root []byte, l submittedLeaf) error {
+	bundle, err := client.GetEntryBundle(ctx, v.bf, l.index/layout.EntryBundleWidth, size)
+	if err != nil {
+		return fmt.Errorf("failed to get entry bundle for leaf %d: %v: %w", l.index, err, errFetch)
+	}
+	if got := bundle.Entries[l.index%layout.EntryBundleWidth]; !bytes.Equal(got, l.data) {
+		return fmt.Errorf("leaf %d is %q, but the log returned this index for %q", l.index, got, l.data)
+	}
+	p, err := pb.InclusionProof(ctx, l.index)
+	if err != nil {
+		return fmt.Errorf("failed to get inclusion proof for leaf %d in tree size %d: %v: %w", l.index, size, err, errFetch)
+	}
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, l.index, size, rfc6962.DefaultHasher.HashLeaf(l.data), p, root); err != nil {
+		return fmt.Errorf("leaf %d is not included in tree size %d: %v", l.index, size, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+func TestInclusionVerifier(t *testing.T) {
+	ctx := t.Context()
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(1, time.Millisecond))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 100*time.Millisecond)
+	var futures []tessera.IndexFuture
+	for i := range 300 {
+		futures = append(futures, tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	if _, _, err := awaiter.AwaitAll(ctx, futures); err != nil {
+		t.Fatalf("AwaitAll: %v", err)
+	}
+	tracker, err := client.NewLogStateTracker(ctx, tl.LogReader.ReadTile, nil, tl.SigVerifier, tl.SigVerifier.Name(), client.UnilateralConsensus(tl.LogReader.ReadCheckpoint))
+	if err != nil {
+		t.Fatalf("NewLogStateTracker: %v", err)
+	}
+	if _, _, _, err := tracker.Update(ctx); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	var s OpStats
+	v := NewInclusionVerifier(tracker, tl.LogReader.ReadTile, tl.LogReader.ReadEntryBundle, 1, &s)
+	// This writer claims to have written each leaf at the index in its data, or
+	// at index 0 for data which isn't in the log.
+	w := v.RecordWrites(func(_ context.Context, data []byte) (uint64, error) {
+		var i uint64
+		_, _ = fmt.Sscanf(string(data), "entry %d", &i)
+		return i, nil
+	})
+	for _, d := range []string{"entry 3", "entry 257", "entry 299", "not in the log"} {
+		if _, err := w(ctx, []byte(d)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go v.Run(runCtx)
+	for s.Summary(0).Succeeded+s.Summary(0).Failed < 4 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for leaves to be verified")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if got := s.Summary(0); got.Succeeded != 3 || got.Failed != 1 {
+		t.Errorf("Got %d verified and %d failed, want 3 and 1", got.Succeeded, got.Failed)
+	}
+}
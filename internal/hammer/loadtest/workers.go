@@ -209,6 +209,9 @@ func (w *LogWriter) Run(ctx context.Context) {
 		}
 		lt := LeafTime{QueuedAt: time.Now()}
 		index, err := w.writer(ctx, newLeaf)
+		if errors.Is(err, ErrSkipped) {
+			continue
+		}
 		if err != nil {
 			w.errChan <- fmt.Errorf("failed to create request: %w", err)
 			continue
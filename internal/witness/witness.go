@@ -29,6 +29,8 @@ import (
 	"sync"
 
 	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/client"
 	"github.com/transparency-dev/tessera/internal/parse"
 	"golang.org/x/mod/sumdb/note"
@@ -60,8 +62,8 @@ type WitnessGroup interface {
 // NewWitnessGateway returns a WitnessGateway that will send out new checkpoints to witnesses
 // in the group, and will ensure that the policy is satisfied before returning. All outbound
 // requests will be done using the given client. The tile fetcher is used for constructing
-// consistency proofs for the witnesses.
-func NewWitnessGateway(group WitnessGroup, client *http.Client, fetchTiles client.TileFetcherFunc) WitnessGateway {
+// consistency proofs for the witnesses, using the provided hasher and the log's tiling.
+func NewWitnessGateway(group WitnessGroup, client *http.Client, fetchTiles client.TileFetcherFunc, h merkle.LogHasher, tl layout.Tiling) WitnessGateway {
 	endpoints := group.Endpoints()
 	witnesses := make([]*witness, 0, len(endpoints))
 	for u, v := range endpoints {
@@ -76,6 +78,8 @@ func NewWitnessGateway(group WitnessGroup, client *http.Client, fetchTiles clien
 		group:     group,
 		witnesses: witnesses,
 		fetchTile: fetchTiles,
+		hasher:    h,
+		tiling:    tl,
 	}
 }
 
@@ -84,6 +88,8 @@ type WitnessGateway struct {
 	group     WitnessGroup
 	witnesses []*witness
 	fetchTile client.TileFetcherFunc
+	hasher    merkle.LogHasher
+	tiling    layout.Tiling
 }
 
 // Witness sends out a new checkpoint (which must be signed by the log), to all witnesses
@@ -106,7 +112,7 @@ func (wg *WitnessGateway) Witness(ctx context.Context, cp []byte) ([]byte, error
 		Size:   size,
 		Hash:   hash,
 	}
-	pb, err := client.NewProofBuilder(ctx, logCP.Size, wg.fetchTile)
+	pb, err := client.NewProofBuilderWithTiling(ctx, logCP.Size, wg.fetchTile, wg.hasher, wg.tiling)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build proof builder: %v", err)
 	}
@@ -271,7 +277,7 @@ func (w *witness) update(ctx context.Context, cp []byte, size uint64, fetchProof
 		ct := httpResp.Header["Content-Type"]
 		if len(ct) == 1 && ct[0] == "text/x.tlog.size" {
 			bodyStr := string(rb)
-			newWitSize, err := strconv.ParseUint(bodyStr, 10, 64)
+			newWitSize, err := strconv.ParseUint(strings.TrimSuffix(bodyStr, "\n"), 10, 64)
 			if err != nil {
 				return nil, fmt.Errorf("witness at %q replied with x.tlog.size but body %q could not be parsed as decimal", w.url, bodyStr)
 			}
@@ -0,0 +1,142 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// verifierKeyer is implemented by note.Signers which know the verifier key corresponding to their signing key.
+type verifierKeyer interface {
+	VerifierKey() string
+}
+
+// verifiableSigner is a note.Signer with a known verifier key.
+type verifiableSigner struct {
+	note.Signer
+	vkey string
+}
+
+func (s verifiableSigner) VerifierKey() string {
+	return s.vkey
+}
+
+// NewVerifiableSigner returns a note.Signer which delegates to s, and which reports vkey as its verifier key
+// from Appender.CheckpointVerifierKeys.
+//
+// This is only needed for signers which don't already provide a VerifierKey() string method, such as
+// those created by note.NewSigner; signers created by the signer package do.
+func NewVerifiableSigner(s note.Signer, vkey string) (note.Signer, error) {
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verifier key: %v", err)
+	}
+	if v.Name() != s.Name() || v.KeyHash() != s.KeyHash() {
+		return nil, fmt.Errorf("verifier key %q does not match signer %s+%08x", vkey, s.Name(), s.KeyHash())
+	}
+	return verifiableSigner{Signer: s, vkey: vkey}, nil
+}
+
+// checkpointSigners holds the set of signers used to sign checkpoints, and manages rotation of the primary signer.
+type checkpointSigners struct {
+	now func() time.Time
+
+	mu         sync.RWMutex
+	primary    note.Signer
+	additional []note.Signer
+	// retiring, if set, is the previous primary signer, which continues to sign checkpoints until retireAt.
+	retiring note.Signer
+	retireAt time.Time
+}
+
+// signers returns the signers which should currently be used to sign checkpoints, starting with the primary.
+func (c *checkpointSigners) signers() []note.Signer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r := make([]note.Signer, 0, 2+len(c.additional))
+	r = append(r, c.primary)
+	r = append(r, c.additional...)
+	if c.retiring != nil && c.now().Before(c.retireAt) {
+		r = append(r, c.retiring)
+	}
+	return r
+}
+
+// rotate makes s the primary signer, with the current primary continuing to sign checkpoints for the duration
+// of the overlap.
+func (c *checkpointSigners) rotate(s note.Signer, overlap time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.Name() != c.primary.Name() {
+		return fmt.Errorf("new signer name (%q) does not match current signer name (%q)", s.Name(), c.primary.Name())
+	}
+	if s.KeyHash() == c.primary.KeyHash() {
+		return fmt.Errorf("new signer has the same key hash (%08x) as the current signer", s.KeyHash())
+	}
+	now := c.now()
+	if c.retiring != nil && now.Before(c.retireAt) {
+		return fmt.Errorf("previous key rotation is still in progress until %v", c.retireAt)
+	}
+	c.retiring, c.retireAt = c.primary, now.Add(overlap)
+	c.primary = s
+	return nil
+}
+
+// RotateCheckpointSigner replaces the primary checkpoint signer provided to WithCheckpointSigner with s.
+//
+// For the duration of the overlap, checkpoints continue to be signed by the previous primary signer as well
+// as by s, so that clients have time to begin trusting the new key before signatures from the old key stop
+// appearing. Another rotation cannot be started until the overlap has ended.
+//
+// The name of s must match that of the current signer. Rotation only affects this Appender; where several
+// instances of a log share its storage, each of them must be rotated.
+func (a *Appender) RotateCheckpointSigner(ctx context.Context, s note.Signer, overlap time.Duration) error {
+	if a.signers == nil {
+		return errors.New("appender was not created by NewAppender with WithCheckpointSigner")
+	}
+	old := a.signers.signers()[0]
+	err := a.signers.rotate(s, overlap)
+	a.audit.record(AuditOpKeyRotation, fmt.Sprintf("%s+%08x -> %s+%08x, overlap %v", old.Name(), old.KeyHash(), s.Name(), s.KeyHash(), overlap), err)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Rotated checkpoint signing key from %08x to %08x, old key will sign for %v", old.KeyHash(), s.KeyHash(), overlap)
+	return nil
+}
+
+// CheckpointVerifierKeys returns the note verifier keys for each of the signers which are currently signing
+// checkpoints, starting with the primary signer, so that they can be served to clients.
+//
+// During a key rotation, both the old and new keys are returned. Signers whose verifier key isn't known,
+// i.e. which don't implement a VerifierKey() string method, are omitted; see NewVerifiableSigner.
+func (a *Appender) CheckpointVerifierKeys() []string {
+	if a.signers == nil {
+		return nil
+	}
+	r := []string{}
+	for _, s := range a.signers.signers() {
+		if v, ok := s.(verifierKeyer); ok {
+			r = append(r, v.VerifierKey())
+		}
+	}
+	return r
+}
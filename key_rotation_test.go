@@ -0,0 +1,152 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// newTestSigner returns a signer, which knows its verifier key, for a new key with the given name.
+func newTestSigner(t *testing.T, name string) (note.Signer, string) {
+	t.Helper()
+	sk, vk, err := note.GenerateKey(nil, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	vs, err := NewVerifiableSigner(s, vk)
+	if err != nil {
+		t.Fatalf("NewVerifiableSigner: %v", err)
+	}
+	return vs, vk
+}
+
+// sigKeyHashes returns the key hashes of the signatures on a checkpoint created by opts.
+func sigKeyHashes(t *testing.T, opts *AppendOptions) []uint32 {
+	t.Helper()
+	cp, err := opts.newCP(context.Background(), 0, nil)
+	if err != nil {
+		t.Fatalf("newCP: %v", err)
+	}
+	// With no known verifiers, all of the signatures are returned as unverified.
+	var unverified *note.UnverifiedNoteError
+	if _, err := note.Open(cp, note.VerifierList()); !errors.As(err, &unverified) {
+		t.Fatalf("note.Open: got err %v, want UnverifiedNoteError", err)
+	}
+	r := []uint32{}
+	for _, s := range unverified.Note.UnverifiedSigs {
+		r = append(r, s.Hash)
+	}
+	return r
+}
+
+func TestRotateCheckpointSigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldS, oldV := newTestSigner(t, "example.com/log")
+	newS, newV := newTestSigner(t, "example.com/log")
+	nextS, _ := newTestSigner(t, "example.com/log")
+	otherS, _ := newTestSigner(t, "example.com/other")
+
+	l := NewAuditLog(0)
+	opts := NewAppendOptions().WithCheckpointSigner(oldS).WithAuditLog(l)
+	now := time.Unix(1000, 0)
+	opts.checkpointSigners.now = func() time.Time { return now }
+	a, _, _, err := NewAppender(ctx, &fakeDriver{}, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+
+	if got, want := sigKeyHashes(t, opts), []uint32{oldS.KeyHash()}; !slices.Equal(got, want) {
+		t.Errorf("before rotation: got signatures from %08x, want %08x", got, want)
+	}
+
+	for _, s := range []note.Signer{otherS, oldS} {
+		if err := a.RotateCheckpointSigner(ctx, s, time.Hour); err == nil {
+			t.Errorf("RotateCheckpointSigner(%s+%08x): got no error, want error", s.Name(), s.KeyHash())
+		}
+	}
+	if err := a.RotateCheckpointSigner(ctx, newS, time.Hour); err != nil {
+		t.Fatalf("RotateCheckpointSigner: %v", err)
+	}
+	if got, want := sigKeyHashes(t, opts), []uint32{newS.KeyHash(), oldS.KeyHash()}; !slices.Equal(got, want) {
+		t.Errorf("during overlap: got signatures from %08x, want %08x", got, want)
+	}
+	if got, want := a.CheckpointVerifierKeys(), []string{newV, oldV}; !slices.Equal(got, want) {
+		t.Errorf("during overlap: got verifier keys %q, want %q", got, want)
+	}
+	if err := a.RotateCheckpointSigner(ctx, nextS, time.Hour); err == nil {
+		t.Error("RotateCheckpointSigner during overlap: got no error, want error")
+	}
+
+	now = now.Add(time.Hour)
+	if got, want := sigKeyHashes(t, opts), []uint32{newS.KeyHash()}; !slices.Equal(got, want) {
+		t.Errorf("after overlap: got signatures from %08x, want %08x", got, want)
+	}
+	if got, want := a.CheckpointVerifierKeys(), []string{newV}; !slices.Equal(got, want) {
+		t.Errorf("after overlap: got verifier keys %q, want %q", got, want)
+	}
+
+	evs := l.Query(AuditQuery{Ops: []AuditOp{AuditOpKeyRotation}})
+	var failed int
+	for _, ev := range evs {
+		if ev.Error != "" {
+			failed++
+		}
+	}
+	if len(evs) != 4 || failed != 3 {
+		t.Errorf("got %d key rotation audit events with %d failures, want 4 with 3 failures", len(evs), failed)
+	}
+}
+
+func TestNewVerifiableSigner(t *testing.T) {
+	sk, vk, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	_, otherVK, err := note.GenerateKey(nil, "example.com/log")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	for _, test := range []struct {
+		name    string
+		vkey    string
+		wantErr bool
+	}{
+		{name: "matching", vkey: vk},
+		{name: "different key", vkey: otherVK, wantErr: true},
+		{name: "invalid", vkey: "not a key", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewVerifiableSigner(s, test.vkey); (err != nil) != test.wantErr {
+				t.Errorf("NewVerifiableSigner: got err %v, want err %t", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -48,6 +48,16 @@ type LogReader interface {
 	// The expected usage and corresponding behaviours are similar to ReadTile.
 	ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error)
 
+	// ReadEntryBundles returns the count full entry bundles starting at index fromBundle, in order.
+	//
+	// This is intended for bulk readers, such as followers and exporters, and implementations fetch
+	// the bundles in as few requests as the underlying storage allows. Partial bundles are not
+	// returned by this method, so readers should use ReadEntryBundle for the bundle at the right
+	// hand edge of the tree.
+	// If any of the requested bundles doesn't exist then an error wrapping os.ErrNotExist, or
+	// ErrEntryBundleExpired if it's been expunged, is returned.
+	ReadEntryBundles(ctx context.Context, fromBundle, count uint64) ([][]byte, error)
+
 	// NextIndex returns the first as-yet unassigned index.
 	//
 	// In a quiescent log, this will be the same as the checkpoint size. In a log with entries actively
@@ -65,11 +75,54 @@ type LogReader interface {
 	// reading the checkpoint when only data which has been publicly committed to by the
 	// log should be used. If in doubt, use ReadCheckpoint instead.
 	IntegratedSize(ctx context.Context) (uint64, error)
+
+	// ReadInternalCheckpoint returns the size and root hash of the current integrated tree.
+	//
+	// As with IntegratedSize, the returned tree state may not yet be committed to by a signed, witnessed,
+	// or published checkpoint, and is intended only for processes internal to the operation of the log which
+	// need to trail the tree more closely than the checkpoint interval allows (e.g. antispam or search
+	// indexers). It MUST NOT be exposed to, or relied upon by, parties outside of the log operator.
+	ReadInternalCheckpoint(ctx context.Context) (InternalCheckpoint, error)
+
+	// RootAt returns the root hash of the log's Merkle tree when it contained size entries, computed from the
+	// stored tiles.
+	//
+	// This allows personalities to answer queries about historical tree sizes, e.g. to serve a proof against
+	// an older checkpoint, without building proofs themselves. If size is larger than the integrated tree,
+	// an error wrapping os.ErrNotExist is returned.
+	RootAt(ctx context.Context, size uint64) ([]byte, error)
+}
+
+// InternalCheckpoint describes the state of the integrated tree, as returned by LogReader.ReadInternalCheckpoint.
+//
+// It is unsigned and unpublished, and is NOT a checkpoint in the sense of https://c2sp.org/tlog-checkpoint.
+type InternalCheckpoint struct {
+	// Size is the number of entries in the integrated tree.
+	Size uint64
+	// Hash is the root hash of the integrated tree.
+	Hash []byte
+}
+
+// LogWatcher is an optional interface which may be implemented by a LogReader which can tell when the log has
+// changed, e.g. by watching its storage, so that followers needn't poll it.
+type LogWatcher interface {
+	// Watch returns a channel on which the state of the log is sent when it may have changed. Notifications
+	// are coalesced, so a receiver which falls behind only sees the latest state, and the channel is closed
+	// once ctx is done.
+	Watch(ctx context.Context) <-chan LogChange
+}
+
+// LogChange describes the state of a log, as sent by a LogWatcher.
+type LogChange struct {
+	// IntegratedSize is the size of the integrated tree, as returned by LogReader.IntegratedSize.
+	IntegratedSize uint64
+	// Checkpoint is the latest published checkpoint, or nil if none has been published.
+	Checkpoint []byte
 }
 
 // Follower describes the contract of an entity which tracks the contents of the local log.
 //
-// Currently, this is only used by anti-spam.
+// This is used by anti-spam, and NewFollower provides a framework for building custom followers.
 type Follower interface {
 	// Name returns a human readable name for this follower.
 	Name() string
@@ -104,19 +157,22 @@ func identityHash(data []byte) []byte {
 	return h[:]
 }
 
-// defaultIDHasher returns a list of identity hashes corresponding to entries in the provided bundle.
-// Currently, these are simply SHA256 hashes of the raw byte of each entry.
-func defaultIDHasher(bundle []byte) ([][]byte, error) {
-	eb := &api.EntryBundle{}
-	if err := eb.UnmarshalText(bundle); err != nil {
-		return nil, fmt.Errorf("unmarshal: %v", err)
-	}
-	r := make([][]byte, 0, len(eb.Entries))
-	for _, e := range eb.Entries {
-		h := identityHash(e)
-		r = append(r, h[:])
+// newIDHasher returns a function which parses a C2SP tlog-tiles bundle and returns the identity hashes, as
+// calculated by h, of each entry it contains.
+//
+// By default, h is identityHash, i.e. the identities are simply SHA256 hashes of the raw bytes of each entry.
+func newIDHasher(h func([]byte) []byte) func(bundle []byte) ([][]byte, error) {
+	return func(bundle []byte) ([][]byte, error) {
+		eb := &api.EntryBundle{}
+		if err := eb.UnmarshalText(bundle); err != nil {
+			return nil, fmt.Errorf("unmarshal: %v", err)
+		}
+		r := make([][]byte, 0, len(eb.Entries))
+		for _, e := range eb.Entries {
+			r = append(r, h(e))
+		}
+		return r, nil
 	}
-	return r, nil
 }
 
 // defaultMerkleLeafHasher parses a C2SP tlog-tile bundle and returns the Merkle leaf hashes of each entry it contains.
@@ -29,5 +29,38 @@ import (
 // Personalities should check for this error using `errors.Is(e, ErrPushback)`.
 var ErrPushback = errors.New("pushback")
 
+// ErrLogNotActive is returned when a new entry cannot be accepted because the log has been quiesced
+// or frozen (sealed). Unlike ErrPushback, this condition is not expected to clear up by itself.
+//
+// Personalities encountering this error should reject the entry in an appropriate manner (e.g. for HTTP
+// services, return a 503 without a Retry-After header, or a 410 if the log is permanently frozen).
+//
+// Personalities should check for this error using `errors.Is(e, ErrLogNotActive)`.
+var ErrLogNotActive = errors.New("log is not active")
+
+// ErrDuplicate is returned, along with the Index previously assigned to an identical entry, when an entry is
+// found to be a duplicate and the Appender was configured using WithDuplicateErrors.
+// By default, duplicates are not treated as errors and are instead reported by setting Index.IsDup.
+//
+// Personalities should check for this error using `errors.Is(e, ErrDuplicate)`.
+var ErrDuplicate = errors.New("duplicate entry")
+
+// ErrEntryTooLarge is returned when an entry cannot be accepted because its data is larger than the log allows.
+//
+// Personalities encountering this error should reject the entry in an appropriate manner (e.g. for HTTP
+// services, return a 413).
+//
+// Personalities should check for this error using `errors.Is(e, ErrEntryTooLarge)`.
+var ErrEntryTooLarge = errors.New("entry too large")
+
+// ErrStorageUnavailable is returned when an entry could not be sequenced because the underlying storage
+// failed, e.g. because a database could not be reached. Retrying later may succeed.
+//
+// Personalities encountering this error should fail the request in an appropriate manner (e.g. for HTTP
+// services, return a 503).
+//
+// Personalities should check for this error using `errors.Is(e, ErrStorageUnavailable)`.
+var ErrStorageUnavailable = errors.New("storage unavailable")
+
 // Driver is the implementation-specific parts of Tessera. No methods are on here as this is not for public use.
 type Driver any
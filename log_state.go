@@ -0,0 +1,366 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// logStatePollInterval is how often the log state is re-read from storage, so that state changes made
+	// by other instances of the log are picked up.
+	logStatePollInterval = time.Second
+	// drainPollInterval is how often Quiesce and Freeze check whether all sequenced entries are integrated and published.
+	drainPollInterval = 100 * time.Millisecond
+)
+
+// errLogFrozen is returned by the CheckpointPublisher once the log is frozen.
+var errLogFrozen = fmt.Errorf("%w: log is %v", ErrLogNotActive, LogStateFrozen)
+
+// LogState describes where a log is in its lifecycle.
+//
+// A log starts out ACTIVE, and may be moved to QUIESCING, and from there either back to ACTIVE,
+// or on to FROZEN. FROZEN is a terminal state.
+type LogState uint8
+
+const (
+	// LogStateActive is the normal state of a log, in which new entries are accepted.
+	LogStateActive LogState = iota
+	// LogStateQuiescing is the state of a log which is not accepting new entries, but which continues to
+	// integrate, and publish checkpoints for, entries which have already been sequenced.
+	LogStateQuiescing
+	// LogStateFrozen is the final state of a log. No new entries are accepted, and the last checkpoint
+	// published, which commits to every entry in the log, will not be replaced.
+	LogStateFrozen
+)
+
+func (s LogState) String() string {
+	switch s {
+	case LogStateActive:
+		return "ACTIVE"
+	case LogStateQuiescing:
+		return "QUIESCING"
+	case LogStateFrozen:
+		return "FROZEN"
+	default:
+		return fmt.Sprintf("LogState(%d)", s)
+	}
+}
+
+// logStateStorage is implemented by drivers which are able to durably store the lifecycle state of the log.
+//
+// ReadLogState must return LogStateActive if no state has been stored.
+type logStateStorage interface {
+	ReadLogState(ctx context.Context) (LogState, error)
+	WriteLogState(ctx context.Context, s LogState) error
+}
+
+// logLifecycle manages the lifecycle state of a log on behalf of an Appender.
+type logLifecycle struct {
+	// store is used to persist the state. It may be nil if the driver doesn't support this, in which
+	// case the state is held only in memory and is lost when the process exits.
+	store  logStateStorage
+	reader LogReader
+	// frozen is shared with the AppendOptions.CheckpointPublisher, and prevents any new checkpoints from
+	// being published once set.
+	frozen *atomic.Bool
+	// settle is how long to wait after leaving the ACTIVE state before checking that all entries have been
+	// integrated. This allows entries which were accepted just before the transition to make their way
+	// through the driver's sequencing queue.
+	settle       time.Duration
+	pollInterval time.Duration
+
+	// transitionMu serialises state transitions.
+	transitionMu sync.Mutex
+	// mu guards state. It's held for reading while Add requests are delegated so that, once a state
+	// transition has completed, no Add requests will be in-flight.
+	mu    sync.RWMutex
+	state LogState
+}
+
+// newLogLifecycle creates a logLifecycle, reading the initial state from storage if the driver supports it.
+func newLogLifecycle(ctx context.Context, d Driver, r LogReader, opts *AppendOptions) (*logLifecycle, error) {
+	l := &logLifecycle{
+		reader:       r,
+		frozen:       opts.frozen,
+		settle:       opts.BatchMaxAge(),
+		pollInterval: logStatePollInterval,
+	}
+	if l.frozen == nil {
+		l.frozen = &atomic.Bool{}
+	}
+	if s, ok := d.(logStateStorage); ok {
+		l.store = s
+		// Other instances may change the state, so allow time for them to notice too.
+		l.settle += l.pollInterval
+	} else {
+		klog.Infof("Driver %T does not support storing log state, state changes will not be persisted", d)
+	}
+	if err := l.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// refresh re-reads the state from storage, if possible.
+func (l *logLifecycle) refresh(ctx context.Context) error {
+	if l.store == nil {
+		return nil
+	}
+	s, err := l.store.ReadLogState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read log state: %v", err)
+	}
+	l.set(s)
+	return nil
+}
+
+// poll periodically refreshes the state from storage.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (l *logLifecycle) poll(ctx context.Context) {
+	if l.store == nil {
+		return
+	}
+	t := time.NewTicker(l.pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		l.transitionMu.Lock()
+		if err := l.refresh(ctx); err != nil {
+			klog.Warningf("logLifecycle: %v", err)
+		}
+		l.transitionMu.Unlock()
+	}
+}
+
+func (l *logLifecycle) set(s LogState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.state = s
+	l.frozen.Store(s == LogStateFrozen)
+}
+
+func (l *logLifecycle) get() LogState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.state
+}
+
+// transition durably moves the log into state s.
+func (l *logLifecycle) transition(ctx context.Context, s LogState) error {
+	if l.store != nil {
+		if err := l.store.WriteLogState(ctx, s); err != nil {
+			return fmt.Errorf("failed to store log state %v: %v", s, err)
+		}
+	}
+	l.set(s)
+	klog.Infof("Log is now %v", s)
+	return nil
+}
+
+// decorator returns an AddFn which rejects entries unless the log is active.
+func (l *logLifecycle) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, entry *Entry) IndexFuture {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+		if s := l.state; s != LogStateActive {
+			return func() (Index, error) { return Index{}, fmt.Errorf("%w: log is %v", ErrLogNotActive, s) }
+		}
+		return delegate(ctx, entry)
+	}
+}
+
+// quiesce moves an active log into the QUIESCING state.
+func (l *logLifecycle) quiesce(ctx context.Context) error {
+	l.transitionMu.Lock()
+	defer l.transitionMu.Unlock()
+	if err := l.refresh(ctx); err != nil {
+		return err
+	}
+	switch s := l.get(); s {
+	case LogStateActive:
+		return l.transition(ctx, LogStateQuiescing)
+	case LogStateQuiescing:
+		return nil
+	default:
+		return fmt.Errorf("cannot quiesce log which is %v", s)
+	}
+}
+
+// awaitDrained blocks until all sequenced entries have been integrated and committed to by a published checkpoint.
+func (l *logLifecycle) awaitDrained(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(l.settle):
+	}
+	for {
+		drained, err := l.drained(ctx)
+		if err != nil {
+			klog.Warningf("awaitDrained: %v", err)
+		}
+		if drained {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+// drained returns true if all sequenced entries are integrated and committed to by the published checkpoint.
+func (l *logLifecycle) drained(ctx context.Context) (bool, error) {
+	next, err := l.reader.NextIndex(ctx)
+	if err != nil {
+		return false, fmt.Errorf("NextIndex: %v", err)
+	}
+	if next == 0 {
+		return true, nil
+	}
+	cp, err := l.reader.ReadCheckpoint(ctx)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ReadCheckpoint: %v", err)
+	}
+	_, size, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	klog.V(1).Infof("Waiting for published checkpoint to reach size %d (currently %d)", next, size)
+	return size >= next, nil
+}
+
+// State returns the current lifecycle state of the log.
+func (a *Appender) State() LogState {
+	if a.lifecycle == nil {
+		return LogStateActive
+	}
+	return a.lifecycle.get()
+}
+
+// Quiesce stops the log from accepting new entries, and blocks until all entries which have already been
+// sequenced are integrated and committed to by a published checkpoint.
+//
+// Once quiesced, calls to Add will fail with ErrLogNotActive. The log may be returned to service by calling
+// Resume, or retired permanently by calling Freeze.
+//
+// If the storage driver supports it, the new state is stored durably and is shared with any other instances
+// of the log.
+func (a *Appender) Quiesce(ctx context.Context) error {
+	if a.lifecycle == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	if err := a.auditToTree(ctx, AuditOpQuiesce); err != nil {
+		return err
+	}
+	err := a.quiesce(ctx)
+	a.audit.record(AuditOpQuiesce, "", err)
+	return err
+}
+
+func (a *Appender) quiesce(ctx context.Context) error {
+	if err := a.lifecycle.quiesce(ctx); err != nil {
+		return err
+	}
+	return a.lifecycle.awaitDrained(ctx)
+}
+
+// auditToTree adds an audit entry for op to the log, if it's configured to do so and the log is active.
+func (a *Appender) auditToTree(ctx context.Context, op AuditOp) error {
+	if a.lifecycle.get() != LogStateActive {
+		return nil
+	}
+	return a.audit.addToTree(ctx, op, "")
+}
+
+// Freeze permanently retires the log: any sequenced entries are integrated and published as for Quiesce,
+// after which no further checkpoints will be published, and the storage will not be modified further.
+//
+// Freeze may be called on either an active or a quiesced log. Frozen logs cannot be resumed.
+func (a *Appender) Freeze(ctx context.Context) error {
+	if a.lifecycle == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	if err := a.auditToTree(ctx, AuditOpFreeze); err != nil {
+		return err
+	}
+	err := a.freeze(ctx)
+	a.audit.record(AuditOpFreeze, "", err)
+	return err
+}
+
+func (a *Appender) freeze(ctx context.Context) error {
+	if err := a.quiesce(ctx); err != nil {
+		return err
+	}
+	l := a.lifecycle
+	l.transitionMu.Lock()
+	defer l.transitionMu.Unlock()
+	if err := l.refresh(ctx); err != nil {
+		return err
+	}
+	if s := l.get(); s != LogStateQuiescing {
+		return fmt.Errorf("log state changed to %v while freezing", s)
+	}
+	return l.transition(ctx, LogStateFrozen)
+}
+
+// Resume returns a quiesced log to service, allowing new entries to be added once again.
+func (a *Appender) Resume(ctx context.Context) error {
+	if a.lifecycle == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	err := a.resume(ctx)
+	a.audit.record(AuditOpResume, "", err)
+	if err != nil {
+		return err
+	}
+	return a.auditToTree(ctx, AuditOpResume)
+}
+
+func (a *Appender) resume(ctx context.Context) error {
+	l := a.lifecycle
+	l.transitionMu.Lock()
+	defer l.transitionMu.Unlock()
+	if err := l.refresh(ctx); err != nil {
+		return err
+	}
+	switch s := l.get(); s {
+	case LogStateActive:
+		return nil
+	case LogStateQuiescing:
+		return l.transition(ctx, LogStateActive)
+	default:
+		return fmt.Errorf("cannot resume log which is %v", s)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestLogStateTransitions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithBatching(1, time.Millisecond)
+	a, _, r, err := NewAppender(ctx, &fakeDriver{}, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	publish := opts.CheckpointPublisher(r, http.DefaultClient)
+
+	add := func() error {
+		_, err := a.Add(ctx, NewEntry([]byte("entry")))()
+		return err
+	}
+	expect := func(want LogState, wantAddErr bool) {
+		t.Helper()
+		if got := a.State(); got != want {
+			t.Fatalf("State() = %v, want %v", got, want)
+		}
+		if err := add(); wantAddErr != (err != nil) {
+			t.Fatalf("Add: got err %v, want err %t", err, wantAddErr)
+		} else if err != nil && !errors.Is(err, ErrLogNotActive) {
+			t.Fatalf("Add: got err %v, want ErrLogNotActive", err)
+		}
+	}
+
+	expect(LogStateActive, false)
+	if err := a.Quiesce(ctx); err != nil {
+		t.Fatalf("Quiesce: %v", err)
+	}
+	expect(LogStateQuiescing, true)
+	if err := a.Resume(ctx); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	expect(LogStateActive, false)
+	if _, err := publish(ctx, 0, nil); err != nil {
+		t.Fatalf("CheckpointPublisher: %v", err)
+	}
+	if err := a.Freeze(ctx); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	expect(LogStateFrozen, true)
+	if _, err := publish(ctx, 0, nil); !errors.Is(err, ErrLogNotActive) {
+		t.Errorf("CheckpointPublisher on frozen log: got err %v, want ErrLogNotActive", err)
+	}
+	if err := a.Resume(ctx); err == nil {
+		t.Error("Resume on frozen log succeeded, want error")
+	}
+	if err := a.Quiesce(ctx); err == nil {
+		t.Error("Quiesce on frozen log succeeded, want error")
+	}
+}
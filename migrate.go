@@ -28,10 +28,13 @@ import (
 
 type setEntryBundleFunc func(ctx context.Context, index uint64, partial uint8, bundle []byte) error
 
-func newCopier(numWorkers uint, setEntryBundle setEntryBundleFunc, getEntryBundle client.EntryBundleFetcherFunc) *copier {
+// newCopier returns a copier which copies bundles from getEntryBundle to setEntryBundle using numWorkers workers.
+// Requests to the source log are subject to the limits in l, which may be nil.
+func newCopier(numWorkers uint, setEntryBundle setEntryBundleFunc, getEntryBundle client.EntryBundleFetcherFunc, l *sourceLimiter) *copier {
 	return &copier{
 		setEntryBundle: setEntryBundle,
 		getEntryBundle: getEntryBundle,
+		limiter:        l,
 		todo:           make(chan bundle, numWorkers),
 	}
 }
@@ -40,12 +43,17 @@ func newCopier(numWorkers uint, setEntryBundle setEntryBundleFunc, getEntryBundl
 type copier struct {
 	setEntryBundle setEntryBundleFunc
 	getEntryBundle client.EntryBundleFetcherFunc
+	limiter        *sourceLimiter
 
 	// todo contains work items to be completed.
 	todo chan bundle
 
 	// bundlesCopied is the number of entry bundles copied so far.
 	bundlesCopied atomic.Uint64
+	// retries is the number of failed attempts to copy an entry bundle.
+	retries atomic.Uint64
+	// copied tracks how many leading entries have been copied. It's set by Copy.
+	copied *copiedTracker
 }
 
 // bundle represents the address of an individual entry bundle.
@@ -67,6 +75,7 @@ func (c *copier) Copy(ctx context.Context, fromSize uint64, sourceSize uint64) e
 		return fmt.Errorf("from size %d > source size %d", fromSize, sourceSize)
 	}
 
+	c.copied = newCopiedTracker(fromSize)
 	go c.populateWork(fromSize, sourceSize)
 
 	// Do the copying
@@ -88,6 +97,19 @@ func (c *copier) BundlesCopied() uint64 {
 	return c.bundlesCopied.Load()
 }
 
+// Copied returns the number of leading entries which have been copied so far.
+func (c *copier) Copied() uint64 {
+	if c.copied == nil {
+		return 0
+	}
+	return c.copied.copied()
+}
+
+// Retries returns the number of failed attempts to copy an entry bundle.
+func (c *copier) Retries() uint64 {
+	return c.retries.Load()
+}
+
 // populateWork sends entries to the `todo` work channel.
 // Each entry corresponds to an individual entryBundle which needs to be copied.
 func (m *copier) populateWork(from, treeSize uint64) {
@@ -106,15 +128,23 @@ func (m *copier) populateWork(from, treeSize uint64) {
 func (m *copier) worker(ctx context.Context) error {
 	for b := range m.todo {
 		n, err := backoff.Retry(ctx, func() (uint64, error) {
+			if err := m.limiter.waitRequest(ctx); err != nil {
+				return 0, backoff.Permanent(err)
+			}
 			d, err := m.getEntryBundle(ctx, b.Index, uint8(b.Partial))
 			if err != nil {
 				wErr := fmt.Errorf("failed to fetch entrybundle %d (p=%d): %v", b.Index, b.Partial, err)
 				klog.Infof("%v", wErr)
+				m.retries.Add(1)
 				return 0, wErr
 			}
+			if err := m.limiter.waitBytes(ctx, len(d)); err != nil {
+				return 0, backoff.Permanent(err)
+			}
 			if err := m.setEntryBundle(ctx, b.Index, b.Partial, d); err != nil {
 				wErr := fmt.Errorf("failed to store entrybundle %d (p=%d): %v", b.Index, b.Partial, err)
 				klog.Infof("%v", wErr)
+				m.retries.Add(1)
 				return 0, wErr
 			}
 			return 1, nil
@@ -126,6 +156,7 @@ func (m *copier) worker(ctx context.Context) error {
 			return err
 		}
 		m.bundlesCopied.Add(n)
+		m.copied.add(b.Index, b.Partial)
 	}
 	return nil
 }
@@ -19,15 +19,21 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/client"
 	"github.com/transparency-dev/tessera/internal/migrate"
+	"golang.org/x/mod/sumdb/note"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/klog/v2"
 )
 
+// migrationProgressInterval is how often the progress of a migration is stored, if a MigrationProgressStore
+// is configured.
+const migrationProgressInterval = 10 * time.Second
+
 // NewMigrationTarget returns a MigrationTarget, which allows a personality to "import" a C2SP
 // tlog-tiles or static-ct compliant log into a Tessera instance.
 func NewMigrationTarget(ctx context.Context, d Driver, opts *MigrationOptions) (*MigrationTarget, error) {
@@ -43,17 +49,21 @@ func NewMigrationTarget(ctx context.Context, d Driver, opts *MigrationOptions) (
 		return nil, fmt.Errorf("failed to init MigrationTarget lifecycle: %v", err)
 	}
 	return &MigrationTarget{
-		writer:    mw,
-		reader:    r,
-		followers: opts.followers,
+		writer:       mw,
+		reader:       r,
+		followers:    opts.followers,
+		auditLog:     opts.auditLog,
+		reportSigner: opts.reportSigner,
+		progress:     opts.progress,
+		limiter:      newSourceLimiter(opts.maxSourceQPS, opts.maxSourceBandwidth),
 	}, nil
 }
 
 func NewMigrationOptions() *MigrationOptions {
 	return &MigrationOptions{
-		entriesPath:      layout.EntriesPath,
-		bundleIDHasher:   defaultIDHasher,
-		bundleLeafHasher: defaultMerkleLeafHasher,
+		entriesPath:       layout.EntriesPath,
+		newBundleIDHasher: newIDHasher,
+		bundleLeafHasher:  defaultMerkleLeafHasher,
 	}
 }
 
@@ -61,19 +71,54 @@ func NewMigrationOptions() *MigrationOptions {
 type MigrationOptions struct {
 	// entriesPath knows how to format entry bundle paths.
 	entriesPath func(n uint64, p uint8) string
-	// bundleIDHasher knows how to create antispam leaf identities for entries in a serialised bundle.
+	// newBundleIDHasher knows how to create a function which creates antispam leaf identities, using the
+	// provided identity hash function, for entries in a serialised bundle.
 	// This field's value must not be updated once configured or weird and probably unwanted antispam behaviour is likely to occur.
-	bundleIDHasher func([]byte) ([][]byte, error)
+	newBundleIDHasher func(func([]byte) []byte) func([]byte) ([][]byte, error)
+	// identityHash, if set, overrides the default function used to calculate antispam identity hashes.
+	identityHash func([]byte) []byte
 	// bundleLeafHasher knows how to create Merkle leaf hashes for the entries in a serialised bundle.
 	// This field's value must not be updated once configured or weird and probably unwanted integration behaviour is likely to occur.
 	bundleLeafHasher func([]byte) ([][]byte, error)
-	followers        []Follower
+	// entryBundleEncoding is the content encoding used to store entry bundles, or empty if they're stored as-is.
+	entryBundleEncoding string
+	// entryBundleKeys, if set, wraps the keys used to encrypt entry bundles at rest.
+	entryBundleKeys EntryBundleKeyProvider
+	followers       []Follower
+	// auditLog, if set, records migrations.
+	auditLog *AuditLog
+	// reportSigner, if set, signs migration reports.
+	reportSigner note.Signer
+	// progress, if set, stores how far migrations have got.
+	progress MigrationProgressStore
+	// maxSourceQPS and maxSourceBandwidth limit requests to the source log, if non-zero.
+	maxSourceQPS       float64
+	maxSourceBandwidth uint64
 }
 
 func (o MigrationOptions) EntriesPath() func(uint64, uint8) string {
 	return o.entriesPath
 }
 
+// bundleIDHasher returns a function which knows how to create antispam leaf identities for entries in a serialised bundle.
+func (o *MigrationOptions) bundleIDHasher() func([]byte) ([][]byte, error) {
+	h := o.identityHash
+	if h == nil {
+		h = identityHash
+	}
+	return o.newBundleIDHasher(h)
+}
+
+// WithIdentityHash configures the function used to calculate the antispam identity hash of the entries
+// being migrated. This must match the function used by the Appender which will subsequently use the
+// populated antispam storage.
+//
+// See AppendOptions.WithIdentityHash for more details.
+func (o *MigrationOptions) WithIdentityHash(f func([]byte) []byte) *MigrationOptions {
+	o.identityHash = f
+	return o
+}
+
 func (o *MigrationOptions) LeafHasher() func([]byte) ([][]byte, error) {
 	return o.bundleLeafHasher
 }
@@ -85,16 +130,25 @@ func (o *MigrationOptions) LeafHasher() func([]byte) ([][]byte, error) {
 // of the source tree and so no attempt is made to reject/deduplicate entries.
 func (o *MigrationOptions) WithAntispam(as Antispam) *MigrationOptions {
 	if as != nil {
-		o.followers = append(o.followers, as.Follower(o.bundleIDHasher))
+		// Resolve the hasher lazily so that options may be specified in any order.
+		o.followers = append(o.followers, as.Follower(func(b []byte) ([][]byte, error) { return o.bundleIDHasher()(b) }))
 	}
 	return o
 }
 
 // MigrationTarget handles the process of migrating/importing a source log into a Tessera instance.
 type MigrationTarget struct {
-	writer    migrate.MigrationWriter
-	reader    LogReader
-	followers []Follower
+	writer       migrate.MigrationWriter
+	reader       LogReader
+	followers    []Follower
+	auditLog     *AuditLog
+	reportSigner note.Signer
+	progress     MigrationProgressStore
+	limiter      *sourceLimiter
+
+	reportMu     sync.Mutex
+	report       *MigrationReport
+	signedReport []byte
 }
 
 // Migrate performs the work of importing a source log into the local Tessera instance.
@@ -106,17 +160,71 @@ type MigrationTarget struct {
 // An error will be returned if there is an unrecoverable problem encountered during the migration
 // process, or if, once all entries have been copied and integrated into the local tree, the local
 // root hash does not match the provided sourceRoot.
+//
+// A MigrationReport describing the outcome is available from LatestReport once Migrate returns.
 func (mt *MigrationTarget) Migrate(ctx context.Context, numWorkers uint, sourceSize uint64, sourceRoot []byte, getEntries client.EntryBundleFetcherFunc) error {
+	r := &MigrationReport{Start: time.Now(), SourceSize: sourceSize, SourceRoot: sourceRoot}
+	err := mt.migrate(ctx, numWorkers, sourceSize, sourceRoot, getEntries, r)
+	mt.auditLog.Record(AuditOpMigration, fmt.Sprintf("size %d, root %x", sourceSize, sourceRoot), err)
+	mt.setReport(ctx, r, err)
+	return err
+}
+
+// setReport completes the report r of a migration which returned err, and makes it available from LatestReport.
+func (mt *MigrationTarget) setReport(ctx context.Context, r *MigrationReport, err error) {
+	r.Duration = time.Since(r.Start).Seconds()
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if r.DestinationRoot == nil {
+		// The migration didn't complete, but it's still useful to know how far it got.
+		if s, sErr := mt.writer.IntegratedSize(ctx); sErr == nil {
+			r.DestinationSize = s
+		}
+	}
+	if r.DestinationSize > r.ResumedFrom {
+		r.EntriesCopied = r.DestinationSize - r.ResumedFrom
+	}
+	var raw []byte
+	if mt.reportSigner != nil {
+		var sErr error
+		if raw, sErr = signReport(r, mt.reportSigner); sErr != nil {
+			klog.Warningf("Failed to sign migration report: %v", sErr)
+		}
+	}
+	mt.reportMu.Lock()
+	defer mt.reportMu.Unlock()
+	mt.report, mt.signedReport = r, raw
+}
+
+func (mt *MigrationTarget) migrate(ctx context.Context, numWorkers uint, sourceSize uint64, sourceRoot []byte, getEntries client.EntryBundleFetcherFunc, r *MigrationReport) error {
 	cctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	c := newCopier(numWorkers, mt.writer.SetEntryBundle, getEntries)
+	c := newCopier(numWorkers, mt.writer.SetEntryBundle, getEntries, mt.limiter)
 
 	fromSize, err := mt.writer.IntegratedSize(ctx)
 	if err != nil {
 		return fmt.Errorf("fetching integrated size failed: %v", err)
 	}
-	c.bundlesCopied.Store(fromSize / layout.EntryBundleWidth)
+	r.ResumedFrom = fromSize
+	copyFrom := fromSize
+	if mt.progress != nil {
+		copied, err := mt.progress.ReadCopied(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read migration progress: %v", err)
+		}
+		if copied > fromSize && copied <= sourceSize {
+			klog.Infof("Resuming copy from %d, integrated size is %d", copied, fromSize)
+			copyFrom = copied
+		}
+		go mt.storeProgress(cctx, c, copyFrom)
+	}
+	c.bundlesCopied.Store(copyFrom / layout.EntryBundleWidth)
+	defer func() {
+		r.BundlesCopied = c.BundlesCopied() - copyFrom/layout.EntryBundleWidth
+		r.Retries = c.Retries()
+	}()
 
 	// Print stats
 	go func() {
@@ -155,7 +263,14 @@ func (mt *MigrationTarget) Migrate(ctx context.Context, numWorkers uint, sourceS
 	// go integrate
 	errG := errgroup.Group{}
 	errG.Go(func() error {
-		return c.Copy(cctx, fromSize, sourceSize)
+		err := c.Copy(cctx, copyFrom, sourceSize)
+		// Store the final progress even if the copy failed, so that a retry needn't repeat it.
+		if n := c.Copied(); mt.progress != nil && n > copyFrom {
+			if err := mt.progress.WriteCopied(ctx, n); err != nil {
+				klog.Warningf("Failed to store migration progress: %v", err)
+			}
+		}
+		return err
 	})
 
 	var calculatedRoot []byte
@@ -178,14 +293,38 @@ func (mt *MigrationTarget) Migrate(ctx context.Context, numWorkers uint, sourceS
 		return fmt.Errorf("migrate failed: %v", err)
 	}
 
+	r.DestinationSize, r.DestinationRoot = sourceSize, calculatedRoot
 	if !bytes.Equal(calculatedRoot, sourceRoot) {
 		return fmt.Errorf("migration completed, but local root hash %x != source root hash %x", calculatedRoot, sourceRoot)
 	}
 
+	r.Verified = true
 	klog.Infof("Migration successful.")
 	return nil
 }
 
+// storeProgress periodically stores the number of leading entries which c has copied, starting from size.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (mt *MigrationTarget) storeProgress(ctx context.Context, c *copier, size uint64) {
+	t := time.NewTicker(migrationProgressInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if n := c.Copied(); n > size {
+			if err := mt.progress.WriteCopied(ctx, n); err != nil {
+				klog.Warningf("Failed to store migration progress: %v", err)
+				continue
+			}
+			size = n
+		}
+	}
+}
+
 // awaitFollower returns a function which will block until the provided follower has processed
 // at least as far as the provided index.
 func awaitFollower(ctx context.Context, f Follower, i uint64) func() error {
@@ -0,0 +1,198 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/time/rate"
+)
+
+// MigrationProgressStore durably stores how far a migration has got, so that it can be resumed without
+// copying entry bundles again. See MigrationOptions.WithProgressStore.
+type MigrationProgressStore interface {
+	// ReadCopied returns the size most recently passed to WriteCopied, or zero if it has never been called.
+	ReadCopied(ctx context.Context) (uint64, error)
+	// WriteCopied durably stores the number of leading entries of the source log whose entry bundles have
+	// been stored in the target log.
+	WriteCopied(ctx context.Context, size uint64) error
+}
+
+// MigrationProgressFile is a MigrationProgressStore which stores progress in the file at Path.
+type MigrationProgressFile struct {
+	Path string
+}
+
+func (f MigrationProgressFile) ReadCopied(_ context.Context) (uint64, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid progress in %q: %v", f.Path, err)
+	}
+	return n, nil
+}
+
+func (f MigrationProgressFile) WriteCopied(_ context.Context, size uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Ignore errors: the file is gone once it's been renamed.
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := fmt.Fprintf(tmp, "%d\n", size); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// WithProgressStore configures the migration target to periodically store how many leading entries of the
+// source log have had their entry bundles copied, so that a migration which is interrupted, or stopped in
+// order to pause it, resumes copying from there rather than from the size of the target's integrated tree.
+//
+// This matters for large migrations, where integration can fall far behind copying. Bundles which have been
+// copied are integrated from the target's storage, so they needn't be fetched from the source log again.
+// The store must only be used for migrations into the same target log.
+func (o *MigrationOptions) WithProgressStore(s MigrationProgressStore) *MigrationOptions {
+	o.progress = s
+	return o
+}
+
+// WithMaxSourceQPS configures the migration target to make no more than qps requests per second to the source
+// log, including requests which are retried. A value of zero (the default) disables this limit.
+func (o *MigrationOptions) WithMaxSourceQPS(qps float64) *MigrationOptions {
+	o.maxSourceQPS = qps
+	return o
+}
+
+// WithMaxSourceBandwidth configures the migration target to fetch no more than bytesPerSecond bytes of entry
+// bundles per second from the source log, averaged over one second. A value of zero (the default) disables
+// this limit.
+func (o *MigrationOptions) WithMaxSourceBandwidth(bytesPerSecond uint64) *MigrationOptions {
+	o.maxSourceBandwidth = bytesPerSecond
+	return o
+}
+
+// sourceLimiter enforces the MaxSourceQPS and MaxSourceBandwidth limits.
+type sourceLimiter struct {
+	// qps and bandwidth are nil if the corresponding limit is disabled.
+	qps       *rate.Limiter
+	bandwidth *rate.Limiter
+}
+
+func newSourceLimiter(qps float64, bytesPerSecond uint64) *sourceLimiter {
+	l := &sourceLimiter{}
+	if qps > 0 {
+		l.qps = rate.NewLimiter(rate.Limit(qps), max(1, int(math.Ceil(qps))))
+	}
+	if bytesPerSecond > 0 {
+		l.bandwidth = rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, math.MaxInt32)))
+	}
+	return l
+}
+
+// waitRequest blocks until another request may be made to the source log.
+func (l *sourceLimiter) waitRequest(ctx context.Context) error {
+	if l == nil || l.qps == nil {
+		return nil
+	}
+	return l.qps.Wait(ctx)
+}
+
+// waitBytes blocks until n more bytes fetched from the source log are within the bandwidth limit.
+func (l *sourceLimiter) waitBytes(ctx context.Context, n int) error {
+	if l == nil || l.bandwidth == nil {
+		return nil
+	}
+	// Bundles can be larger than the limiter's burst, which WaitN doesn't allow.
+	for n > 0 {
+		k := min(n, l.bandwidth.Burst())
+		if err := l.bandwidth.WaitN(ctx, k); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
+
+// copiedTracker tracks the number of leading entries of the source log whose bundles have been copied, as
+// bundles are copied out of order.
+type copiedTracker struct {
+	mu sync.Mutex
+	// size is the number of leading entries copied.
+	size uint64
+	// done holds the end of the range of entries in each bundle which has been copied beyond size, keyed by the
+	// index of the first entry in the bundle.
+	done map[uint64]uint64
+}
+
+func newCopiedTracker(size uint64) *copiedTracker {
+	return &copiedTracker{size: size, done: make(map[uint64]uint64)}
+}
+
+// add records that the bundle with the given index and partial size has been copied.
+func (t *copiedTracker) add(index uint64, partial uint8) {
+	start := index * layout.EntryBundleWidth
+	end := start + layout.EntryBundleWidth
+	if partial > 0 {
+		end = start + uint64(partial)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// The first bundle copied may contain entries before size, if size isn't at the start of a bundle.
+	if start <= t.size {
+		t.size = max(t.size, end)
+	} else {
+		t.done[start] = end
+	}
+	for {
+		end, ok := t.done[t.size]
+		if !ok {
+			return
+		}
+		delete(t.done, t.size)
+		t.size = end
+	}
+}
+
+// copied returns the number of leading entries copied.
+func (t *copiedTracker) copied() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.size
+}
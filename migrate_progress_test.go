@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopiedTracker(t *testing.T) {
+	type bundle struct {
+		index   uint64
+		partial uint8
+	}
+	for _, test := range []struct {
+		name    string
+		from    uint64
+		bundles []bundle
+		want    uint64
+	}{
+		{
+			name:    "in order",
+			bundles: []bundle{{0, 0}, {1, 0}, {2, 10}},
+			want:    522,
+		}, {
+			name:    "out of order",
+			bundles: []bundle{{2, 0}, {0, 0}, {1, 0}},
+			want:    768,
+		}, {
+			name:    "gap",
+			bundles: []bundle{{0, 0}, {2, 0}, {3, 0}},
+			want:    256,
+		}, {
+			name:    "from middle of bundle",
+			from:    300,
+			bundles: []bundle{{2, 0}, {1, 0}},
+			want:    768,
+		}, {
+			name:    "from end of partial bundle",
+			from:    300,
+			bundles: []bundle{{1, 100}},
+			want:    356,
+		}, {
+			name:    "nothing copied",
+			from:    300,
+			bundles: []bundle{{2, 0}},
+			want:    300,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCopiedTracker(test.from)
+			for _, b := range test.bundles {
+				c.add(b.index, b.partial)
+			}
+			if got := c.copied(); got != test.want {
+				t.Errorf("copied() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMigrationProgressFile(t *testing.T) {
+	ctx := t.Context()
+	f := MigrationProgressFile{Path: filepath.Join(t.TempDir(), "progress")}
+	if got, err := f.ReadCopied(ctx); err != nil || got != 0 {
+		t.Fatalf("ReadCopied() before WriteCopied = %d, %v, want 0, nil", got, err)
+	}
+	for _, n := range []uint64{256, 1 << 40} {
+		if err := f.WriteCopied(ctx, n); err != nil {
+			t.Fatalf("WriteCopied(%d): %v", n, err)
+		}
+		if got, err := f.ReadCopied(ctx); err != nil || got != n {
+			t.Fatalf("ReadCopied() = %d, %v, want %d, nil", got, err, n)
+		}
+	}
+	if m, _ := filepath.Glob(f.Path + ".*"); len(m) > 0 {
+		t.Errorf("temporary files left behind: %v", m)
+	}
+}
+
+func TestSourceLimiterBandwidth(t *testing.T) {
+	ctx := t.Context()
+	l := newSourceLimiter(0, 100_000)
+	start := time.Now()
+	// The first second's worth is allowed immediately, so this should take 1.5s.
+	if err := l.waitBytes(ctx, 250_000); err != nil {
+		t.Fatalf("waitBytes: %v", err)
+	}
+	if d := time.Since(start); d < 1400*time.Millisecond {
+		t.Errorf("waitBytes took %v, want at least 1.5s", d)
+	}
+	if err := (*sourceLimiter)(nil).waitBytes(ctx, 1); err != nil {
+		t.Errorf("waitBytes on nil limiter: %v", err)
+	}
+}
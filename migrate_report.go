@@ -0,0 +1,122 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// MigrationReportPrefix is the first line of the text of a signed MigrationReport. The remainder of the text
+// is the JSON serialisation of the report.
+const MigrationReportPrefix = "tessera-migration-report/v1\n"
+
+// MigrationReport describes the outcome of a call to MigrationTarget.Migrate.
+//
+// A report signed by the operator of the target log, see MigrationOptions.WithReportSigner, can be kept as
+// evidence that the target log holds exactly the same entries as the source log.
+type MigrationReport struct {
+	// Start is when the migration started.
+	Start time.Time `json:"start"`
+	// Duration is how long the migration took, in seconds.
+	Duration float64 `json:"duration_seconds"`
+	// SourceSize and SourceRoot are the size and root hash of the source log which was being migrated.
+	SourceSize uint64 `json:"source_size"`
+	SourceRoot []byte `json:"source_root"`
+	// ResumedFrom is the size of the target log when the migration started, which is non-zero if an earlier
+	// migration was interrupted.
+	ResumedFrom uint64 `json:"resumed_from"`
+	// EntriesCopied and BundlesCopied are the number of entries integrated, and entry bundles stored, by this
+	// migration.
+	EntriesCopied uint64 `json:"entries_copied"`
+	BundlesCopied uint64 `json:"bundles_copied"`
+	// Retries is the number of attempts to copy an entry bundle which failed. Failed attempts are retried
+	// several times before the migration fails.
+	Retries uint64 `json:"retries"`
+	// DestinationSize and DestinationRoot are the size and root hash of the target log when the migration ended.
+	DestinationSize uint64 `json:"destination_size"`
+	DestinationRoot []byte `json:"destination_root,omitempty"`
+	// Verified is true if the target log's root hash was found to match SourceRoot. Since the target log
+	// built its tree from the copied entries, this verifies every entry and tile in the target log.
+	Verified bool `json:"verified"`
+	// Error is set if the migration failed.
+	Error string `json:"error,omitempty"`
+}
+
+// WithReportSigner configures the migration target to sign the MigrationReport created by each call to
+// MigrationTarget.Migrate with s, so that it's available from MigrationTarget.LatestReport and ReportHandler.
+func (o *MigrationOptions) WithReportSigner(s note.Signer) *MigrationOptions {
+	o.reportSigner = s
+	return o
+}
+
+// ParseMigrationReport returns the report held in the signed note raw, having checked its signature
+// using v.
+func ParseMigrationReport(raw []byte, v note.Verifier) (*MigrationReport, error) {
+	n, err := note.Open(raw, note.VerifierList(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify report: %v", err)
+	}
+	b, ok := bytes.CutPrefix([]byte(n.Text), []byte(MigrationReportPrefix))
+	if !ok {
+		return nil, errors.New("note is not a migration report")
+	}
+	r := &MigrationReport{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %v", err)
+	}
+	return r, nil
+}
+
+// signReport returns r as a note signed by s.
+func signReport(r *MigrationReport, s note.Signer) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report: %v", err)
+	}
+	return note.Sign(&note.Note{Text: MigrationReportPrefix + string(b) + "\n"}, s)
+}
+
+// LatestReport returns the report of the most recent call to Migrate, and the report signed by the signer
+// configured with MigrationOptions.WithReportSigner. The report is nil if Migrate hasn't been called, and the
+// signed report is nil if no signer is configured.
+func (mt *MigrationTarget) LatestReport() (*MigrationReport, []byte) {
+	mt.reportMu.Lock()
+	defer mt.reportMu.Unlock()
+	return mt.report, mt.signedReport
+}
+
+// ReportHandler returns an http.Handler which serves the signed report of the most recent call to Migrate,
+// or a 404 if there isn't one.
+func (mt *MigrationTarget) ReportHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, raw := mt.LatestReport()
+		if raw == nil {
+			http.Error(w, "no signed migration report", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write(raw); err != nil {
+			klog.Warningf("ReportHandler: failed to write response: %v", err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"github.com/transparency-dev/tessera/testonly"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestMigrationReport(t *testing.T) {
+	ctx := t.Context()
+	const size = 300
+	src, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(size, time.Millisecond))
+	var futures []tessera.IndexFuture
+	for i := range size {
+		futures = append(futures, src.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	if _, _, err := tessera.NewPublicationAwaiter(ctx, src.LogReader.ReadCheckpoint, 50*time.Millisecond).AwaitAll(ctx, futures); err != nil {
+		t.Fatalf("AwaitAll: %v", err)
+	}
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	cpRaw, err := src.LogReader.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	cp, _, _, err := log.ParseCheckpoint(cpRaw, src.SigVerifier.Name(), src.SigVerifier)
+	if err != nil {
+		t.Fatalf("ParseCheckpoint: %v", err)
+	}
+
+	sk, vk, err := note.GenerateKey(nil, "example.com/migration")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	for _, test := range []struct {
+		name         string
+		root         []byte
+		wantErr      bool
+		wantVerified bool
+	}{
+		{
+			name:         "verified",
+			root:         cp.Hash,
+			wantVerified: true,
+		}, {
+			name:    "root mismatch",
+			root:    make([]byte, 32),
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := posix.New(ctx, posix.Config{Path: t.TempDir()})
+			if err != nil {
+				t.Fatalf("posix.New: %v", err)
+			}
+			mt, err := tessera.NewMigrationTarget(ctx, d, tessera.NewMigrationOptions().WithReportSigner(s))
+			if err != nil {
+				t.Fatalf("NewMigrationTarget: %v", err)
+			}
+			if r, raw := mt.LatestReport(); r != nil || raw != nil {
+				t.Fatalf("LatestReport before Migrate: %v, %q, want nil", r, raw)
+			}
+			err = mt.Migrate(ctx, 4, cp.Size, test.root, client.FileFetcher{Root: src.Root}.ReadEntryBundle)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Migrate: %v, want error %t", err, test.wantErr)
+			}
+
+			r, raw := mt.LatestReport()
+			if r.Verified != test.wantVerified || (r.Error != "") != test.wantErr {
+				t.Errorf("report Verified=%t Error=%q, want Verified=%t and error %t", r.Verified, r.Error, test.wantVerified, test.wantErr)
+			}
+			if r.SourceSize != size || r.DestinationSize != size || r.EntriesCopied != size || r.BundlesCopied != 2 {
+				t.Errorf("report sizes %+v, want %d entries in 2 bundles copied", r, size)
+			}
+
+			rec := httptest.NewRecorder()
+			mt.ReportHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			body, _ := io.ReadAll(rec.Result().Body)
+			if rec.Code != http.StatusOK || string(body) != string(raw) {
+				t.Fatalf("ReportHandler: %d %q, want %q", rec.Code, body, raw)
+			}
+			got, err := tessera.ParseMigrationReport(body, v)
+			if err != nil {
+				t.Fatalf("ParseMigrationReport: %v", err)
+			}
+			if got.Verified != r.Verified || string(got.DestinationRoot) != string(r.DestinationRoot) || got.Error != r.Error {
+				t.Errorf("ParseMigrationReport: %+v, want %+v", got, r)
+			}
+			if _, err := tessera.ParseMigrationReport(body, src.SigVerifier); err == nil {
+				t.Error("ParseMigrationReport with wrong verifier succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// MultiLog hosts many logs, each identified by its origin, within a single process.
+//
+// Logs are created lazily, the first time they're requested, so a single binary can serve a large number of
+// logs without paying the cost of starting all of them up front.
+type MultiLog struct {
+	// ctx is the context used for the lifetime of the logs' Appenders.
+	ctx       context.Context
+	newDriver func(ctx context.Context, origin string) (Driver, error)
+	newOpts   func(origin string) (*AppendOptions, error)
+
+	mu   sync.Mutex
+	logs map[string]*multiLogEntry
+}
+
+// multiLogEntry holds a log which has been, or is being, created by a MultiLog.
+type multiLogEntry struct {
+	// ready is closed once the log has been created, or has failed to be created.
+	ready    chan struct{}
+	appender *Appender
+	shutdown func(context.Context) error
+	reader   LogReader
+	err      error
+}
+
+// NewMultiLog returns a MultiLog which manages a set of logs.
+//
+// newDriver is called to create the storage driver for a given origin, and will typically apply some shared
+// configuration, e.g. a common database, bucket, or root directory, with a per-log prefix or path derived
+// from the origin. newOpts is called to create the AppendOptions for the log with the given origin, allowing
+// each log to have its own signer, batching, and so on.
+//
+// The provided context is used for the lifetime of the logs' Appenders.
+func NewMultiLog(ctx context.Context, newDriver func(ctx context.Context, origin string) (Driver, error), newOpts func(origin string) (*AppendOptions, error)) *MultiLog {
+	return &MultiLog{
+		ctx:       ctx,
+		newDriver: newDriver,
+		newOpts:   newOpts,
+		logs:      make(map[string]*multiLogEntry),
+	}
+}
+
+// Appender returns the Appender and LogReader for the log with the given origin, creating it if necessary.
+//
+// Concurrent calls for the same origin will share the same instance. If the log can't be created, the error
+// is returned and creation will be attempted again on the next call.
+func (m *MultiLog) Appender(ctx context.Context, origin string) (*Appender, LogReader, error) {
+	m.mu.Lock()
+	e, ok := m.logs[origin]
+	if !ok {
+		e = &multiLogEntry{ready: make(chan struct{})}
+		m.logs[origin] = e
+		go m.create(origin, e)
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-e.ready:
+	}
+	if e.err != nil {
+		return nil, nil, e.err
+	}
+	return e.appender, e.reader, nil
+}
+
+// create creates the log with the given origin, storing the result in e.
+func (m *MultiLog) create(origin string, e *multiLogEntry) {
+	defer close(e.ready)
+	e.err = func() error {
+		opts, err := m.newOpts(origin)
+		if err != nil {
+			return fmt.Errorf("failed to create options for log %q: %v", origin, err)
+		}
+		d, err := m.newDriver(m.ctx, origin)
+		if err != nil {
+			return fmt.Errorf("failed to create driver for log %q: %v", origin, err)
+		}
+		e.appender, e.shutdown, e.reader, err = NewAppender(m.ctx, d, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create appender for log %q: %v", origin, err)
+		}
+		return nil
+	}()
+	if e.err != nil {
+		// Forget about the failed log so that the next request tries again.
+		m.mu.Lock()
+		delete(m.logs, origin)
+		m.mu.Unlock()
+	}
+}
+
+// Origins returns the origins of the logs which have been created, in sorted order.
+func (m *MultiLog) Origins() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := make([]string, 0, len(m.logs))
+	for o, e := range m.logs {
+		select {
+		case <-e.ready:
+			if e.err == nil {
+				r = append(r, o)
+			}
+		default:
+		}
+	}
+	slices.Sort(r)
+	return r
+}
+
+// Shutdown shuts down all of the logs which have been created, waiting for any outstanding calls to Add on
+// them to be resolved. See the shutdown function returned by NewAppender for details.
+//
+// The MultiLog must not be used once Shutdown has been called.
+func (m *MultiLog) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	logs := maps.Clone(m.logs)
+	m.mu.Unlock()
+
+	var errs []error
+	for o, e := range logs {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.ready:
+		}
+		if e.err != nil {
+			continue
+		}
+		if err := e.shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("log %q: %v", o, err))
+		}
+	}
+	return errors.Join(errs...)
+}
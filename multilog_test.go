@@ -0,0 +1,113 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestMultiLog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	drivers := make(map[string]*fakeDriver)
+	failOpts := true
+	m := NewMultiLog(ctx,
+		func(_ context.Context, origin string) (Driver, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			d := &fakeDriver{}
+			drivers[origin] = d
+			return d, nil
+		},
+		func(origin string) (*AppendOptions, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if origin == "flaky" && failOpts {
+				failOpts = false
+				return nil, errors.New("bang")
+			}
+			sk, _, err := note.GenerateKey(nil, origin)
+			if err != nil {
+				return nil, err
+			}
+			s, err := note.NewSigner(sk)
+			if err != nil {
+				return nil, err
+			}
+			return NewAppendOptions().WithCheckpointSigner(s), nil
+		})
+
+	if got := m.Origins(); len(got) != 0 {
+		t.Errorf("Origins() = %v before any logs were requested", got)
+	}
+
+	// Concurrent requests for the same log should share a single instance.
+	var wg sync.WaitGroup
+	as := make([]*Appender, 10)
+	for i := range as {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, _, err := m.Appender(ctx, "one")
+			if err != nil {
+				t.Errorf("Appender: %v", err)
+			}
+			as[i] = a
+		}()
+	}
+	wg.Wait()
+	for _, a := range as[1:] {
+		if a != as[0] {
+			t.Fatal("concurrent calls to Appender returned different instances")
+		}
+	}
+	if _, err := as[0].Add(ctx, NewEntry([]byte("hello")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, _, err := m.Appender(ctx, "two"); err != nil {
+		t.Fatalf("Appender: %v", err)
+	}
+
+	// Failure to create a log should be retried on the next request.
+	if _, _, err := m.Appender(ctx, "flaky"); err == nil {
+		t.Fatal("Appender(flaky) succeeded, want error")
+	}
+	if diff := cmp.Diff([]string{"one", "two"}, m.Origins()); diff != "" {
+		t.Errorf("unexpected Origins() (-want +got):\n%s", diff)
+	}
+	if _, _, err := m.Appender(ctx, "flaky"); err != nil {
+		t.Fatalf("Appender(flaky) retry: %v", err)
+	}
+
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff([][]string{{"hello"}}, drivers["one"].batches); diff != "" {
+		t.Errorf("unexpected batches for log one (-want +got):\n%s", diff)
+	}
+	if got := len(drivers["two"].batches); got != 0 {
+		t.Errorf("log two got %d batches, want 0", got)
+	}
+}
@@ -27,5 +27,10 @@ var (
 )
 
 var (
-	followerNameKey = attribute.Key("tessera.follower.name")
+	followerNameKey          = attribute.Key("tessera.follower.name")
+	divergenceKindKey        = attribute.Key("tessera.watchdog.divergence")
+	teeDivergenceKindKey     = attribute.Key("tessera.tee.divergence")
+	checkpointDestinationKey = attribute.Key("tessera.checkpoint.destination")
+	walReplayResultKey       = attribute.Key("tessera.wal.replay.result")
+	dedupeNameKey            = attribute.Key("tessera.dedupe.name")
 )
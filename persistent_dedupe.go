@@ -0,0 +1,168 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/future"
+	"k8s.io/klog/v2"
+)
+
+// KVStore is a minimal key-value store interface used by PersistentDedupe.
+//
+// This is intended to be easy to implement using an embedded or external database, e.g. Badger, Redis, or SQLite.
+type KVStore interface {
+	// Get returns the value stored against key, and true, or false if there is no such value.
+	Get(ctx context.Context, key []byte) ([]byte, bool, error)
+	// Set stores value against key, overwriting any existing value.
+	Set(ctx context.Context, key, value []byte) error
+}
+
+// PersistentDedupe wraps an Add function to prevent duplicate entries being written to the underlying
+// storage, by recording the index assigned to each entry's identity in the provided KVStore.
+//
+// Unlike the follower-based Antispam implementations, which are eventually consistent, this records each
+// entry's index as soon as it has been sequenced, and entries with the same identity which are added while
+// the first is still being sequenced wait for its result. Duplicates are therefore always detected before
+// sequencing, provided that a given KVStore is only used by a single Appender at a time. The cost of this is a
+// KVStore lookup during every call to Add.
+//
+// Where an entry is found to be a duplicate, the returned IndexFuture will resolve to the previously
+// assigned index with IsDup set.
+func PersistentDedupe(delegate AddFn, kv KVStore) AddFn {
+	return newPersistentDedupe(delegate, kv).add
+}
+
+func newPersistentDedupe(delegate AddFn, kv KVStore) *persistentDedupe {
+	return &persistentDedupe{
+		delegate: delegate,
+		kv:       kv,
+		inflight: make(map[string]*future.FutureErr[Index]),
+	}
+}
+
+// WithPersistentDedupe configures the Appender to deduplicate entries using PersistentDedupe with kv, and to
+// report its statistics via Appender.DedupeStats and metrics.
+//
+// If this is used along with WithAntispam, the in-memory cache configured there is consulted first.
+func (o *AppendOptions) WithPersistentDedupe(kv KVStore) *AppendOptions {
+	p := &persistentDedupes{kv: kv}
+	o.addDecorators = append(o.addDecorators, p.decorator)
+	o.dedupers = append(o.dedupers, namedDedupe{name: "persistent", stats: p})
+	return o
+}
+
+// persistentDedupes creates persistent dedupes using kv, and reports the statistics of the one it created
+// most recently.
+type persistentDedupes struct {
+	kv     KVStore
+	latest atomic.Pointer[persistentDedupe]
+}
+
+func (p *persistentDedupes) decorator(delegate AddFn) AddFn {
+	d := newPersistentDedupe(delegate, p.kv)
+	p.latest.Store(d)
+	return d.add
+}
+
+// DedupeStats returns the statistics of the most recently created dedupe. The index size isn't known, since
+// KVStore can't report it.
+func (p *persistentDedupes) DedupeStats(_ context.Context) (DedupeStatistics, error) {
+	d := p.latest.Load()
+	if d == nil {
+		return DedupeStatistics{}, nil
+	}
+	return d.counters.Statistics(0), nil
+}
+
+type persistentDedupe struct {
+	delegate AddFn
+	kv       KVStore
+	counters DedupeCounters
+
+	mu sync.Mutex
+	// inflight holds the futures for entries which are being looked up or sequenced, keyed by identity.
+	inflight map[string]*future.FutureErr[Index]
+}
+
+func (d *persistentDedupe) add(ctx context.Context, e *Entry) IndexFuture {
+	ctx, span := tracer.Start(ctx, "tessera.Appender.persistentDedupe.Add")
+	defer span.End()
+
+	key := e.Identity()
+	id := string(key)
+
+	d.mu.Lock()
+	if f, ok := d.inflight[id]; ok {
+		d.mu.Unlock()
+		return func() (Index, error) {
+			i, err := f.Get()
+			i.IsDup = true
+			return i, err
+		}
+	}
+	f, set := future.NewFutureErr[Index]()
+	d.inflight[id] = f
+	d.mu.Unlock()
+	// The future is shared with any duplicates added while it's in flight, so it mustn't fail because this
+	// caller's context is cancelled.
+	ctx = context.WithoutCancel(ctx)
+
+	done := func(i Index, err error) {
+		set(i, err)
+		d.mu.Lock()
+		delete(d.inflight, id)
+		d.mu.Unlock()
+	}
+
+	start := time.Now()
+	v, ok, err := d.kv.Get(ctx, key)
+	if err == nil {
+		d.counters.RecordLookup(time.Since(start), ok)
+	}
+	switch {
+	case err != nil:
+		done(Index{}, fmt.Errorf("%w: dedupe lookup failed: %v", ErrStorageUnavailable, err))
+		return f.Get
+	case ok:
+		if len(v) != 8 {
+			done(Index{}, fmt.Errorf("dedupe lookup returned invalid value %x", v))
+			return f.Get
+		}
+		done(Index{Index: binary.BigEndian.Uint64(v), IsDup: true}, nil)
+		return f.Get
+	}
+
+	df := d.delegate(ctx, e)
+	// Resolve the delegate's future in the background so that the index is recorded even if the caller
+	// never resolves the future we return.
+	go func() {
+		i, err := df()
+		if err == nil && !i.IsDup {
+			if err := d.kv.Set(ctx, key, binary.BigEndian.AppendUint64(nil, i.Index)); err != nil {
+				// The entry has been sequenced, so report success, but it won't be recognised as a duplicate.
+				klog.Warningf("persistentDedupe: failed to record index %d: %v", i.Index, err)
+			}
+		}
+		done(i, err)
+	}()
+	return f.Get
+}
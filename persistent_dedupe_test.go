@@ -0,0 +1,132 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// mapKV is a KVStore backed by a map.
+type mapKV struct {
+	mu     sync.Mutex
+	m      map[string][]byte
+	getErr error
+}
+
+func (k *mapKV) Get(_ context.Context, key []byte) ([]byte, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.getErr != nil {
+		return nil, false, k.getErr
+	}
+	v, ok := k.m[string(key)]
+	return v, ok, nil
+}
+
+func (k *mapKV) Set(_ context.Context, key, value []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.m[string(key)] = value
+	return nil
+}
+
+func TestPersistentDedupe(t *testing.T) {
+	ctx := context.Background()
+	kv := &mapKV{m: make(map[string][]byte)}
+
+	// release is used to hold sequencing of entries until we're ready.
+	release := make(chan struct{})
+	var mu sync.Mutex
+	next := uint64(0)
+	delegate := func(_ context.Context, _ *Entry) IndexFuture {
+		mu.Lock()
+		i := next
+		next++
+		mu.Unlock()
+		return func() (Index, error) {
+			<-release
+			return Index{Index: i}, nil
+		}
+	}
+	add := PersistentDedupe(delegate, kv)
+
+	// Both of these are added before the first has been sequenced.
+	f1 := add(ctx, NewEntry([]byte("one")))
+	f2 := add(ctx, NewEntry([]byte("one")))
+	f3 := add(ctx, NewEntry([]byte("two")))
+	close(release)
+	for _, test := range []struct {
+		f    IndexFuture
+		want Index
+	}{
+		{f: f1, want: Index{Index: 0}},
+		{f: f2, want: Index{Index: 0, IsDup: true}},
+		{f: f3, want: Index{Index: 1}},
+	} {
+		got, err := test.f()
+		if err != nil {
+			t.Fatalf("future: %v", err)
+		}
+		if got != test.want {
+			t.Errorf("got %+v, want %+v", got, test.want)
+		}
+	}
+
+	// A fresh decorator using the same store should still find the duplicates.
+	add = PersistentDedupe(delegate, kv)
+	if got, err := add(ctx, NewEntry([]byte("two")))(); err != nil || got != (Index{Index: 1, IsDup: true}) {
+		t.Errorf("got %+v, %v, want duplicate of index 1", got, err)
+	}
+	if got, err := add(ctx, NewEntry([]byte("three")))(); err != nil || got != (Index{Index: 2}) {
+		t.Errorf("got %+v, %v, want new index 2", got, err)
+	}
+
+	kv.getErr = errors.New("bang")
+	if _, err := add(ctx, NewEntry([]byte("four")))(); !errors.Is(err, ErrStorageUnavailable) {
+		t.Errorf("got err %v, want ErrStorageUnavailable", err)
+	}
+}
+
+func TestPersistentDedupeCancelledCaller(t *testing.T) {
+	kv := &mapKV{m: make(map[string][]byte)}
+	release := make(chan struct{})
+	delegate := func(ctx context.Context, _ *Entry) IndexFuture {
+		return func() (Index, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				return Index{}, err
+			}
+			return Index{Index: 0}, nil
+		}
+	}
+	add := PersistentDedupe(delegate, kv)
+
+	// The first caller gives up before the entry is sequenced, while a duplicate is waiting on the same future.
+	ctx, cancel := context.WithCancel(context.Background())
+	f1 := add(ctx, NewEntry([]byte("one")))
+	f2 := add(context.Background(), NewEntry([]byte("one")))
+	cancel()
+	close(release)
+
+	if got, err := f2(); err != nil || got != (Index{Index: 0, IsDup: true}) {
+		t.Errorf("duplicate: got %+v, %v, want duplicate of index 0", got, err)
+	}
+	if got, err := f1(); err != nil || got != (Index{Index: 0}) {
+		t.Errorf("cancelled caller: got %+v, %v, want index 0", got, err)
+	}
+}
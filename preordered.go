@@ -0,0 +1,98 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// WithPreorderedEntries configures the Appender to only accept entries whose position in the log has already
+// been decided by the caller, e.g. because the log is an exact mirror of an upstream log, or is being rebuilt
+// deterministically from a known sequence of entries.
+//
+// Entries must be created with NewPreorderedEntry, and must be added in order with no gaps, starting from
+// the log's next available index. Calls to Add which don't meet these requirements fail immediately. If an
+// entry fails to be sequenced for any reason (e.g. ErrPushback), subsequently queued entries will also fail
+// and the caller should resume adding entries from the log's NextIndex.
+//
+// Unlike MigrationTarget, which copies complete entry bundles from a source log, this mode sequences and
+// integrates entries in the usual way, so all of the other Appender functionality remains available.
+//
+// This option cannot be used with WithAntispam, since deduplicating entries would change their positions.
+func (o *AppendOptions) WithPreorderedEntries() *AppendOptions {
+	o.preordered = true
+	return o
+}
+
+// preorderedChecker enforces that preordered entries are added contiguously.
+type preorderedChecker struct {
+	nextIndex func(context.Context) (uint64, error)
+
+	mu sync.Mutex
+	// next is the index expected for the next entry to be added.
+	next uint64
+	// resync is true if next must be re-read from the log before it can be trusted.
+	resync bool
+}
+
+func newPreorderedChecker(nextIndex func(context.Context) (uint64, error)) *preorderedChecker {
+	return &preorderedChecker{nextIndex: nextIndex, resync: true}
+}
+
+// decorator returns an AddFn which checks that entries are preordered and contiguous before delegating to
+// the provided function.
+func (p *preorderedChecker) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, entry *Entry) IndexFuture {
+		idx := entry.PreorderedIndex()
+		if idx == nil {
+			return func() (Index, error) { return Index{}, errors.New("entry has no preordered index") }
+		}
+		p.mu.Lock()
+		// The delegate is called with the lock held so that entries are passed to storage in order.
+		defer p.mu.Unlock()
+		if p.resync {
+			n, err := p.nextIndex(ctx)
+			if err != nil {
+				return func() (Index, error) { return Index{}, fmt.Errorf("failed to read next index: %v", err) }
+			}
+			p.next, p.resync = n, false
+		}
+		if want := p.next; *idx != want {
+			return func() (Index, error) {
+				return Index{}, fmt.Errorf("preordered entry index %d is not contiguous, want index %d", *idx, want)
+			}
+		}
+		p.next++
+		f := delegate(ctx, entry)
+		return func() (Index, error) {
+			i, err := f()
+			if err != nil {
+				// Storage will also refuse any entries queued after this one, so the caller will need to
+				// resume from the log's next index, which we'll need to re-read.
+				p.mu.Lock()
+				p.resync = true
+				p.mu.Unlock()
+				return i, err
+			}
+			if i.Index != *idx {
+				return Index{}, fmt.Errorf("preordered entry was assigned index %d, want %d", i.Index, *idx)
+			}
+			return i, nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestPreorderedEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	if _, _, _, err := NewAppender(ctx, &fakeDriver{}, NewAppendOptions().WithCheckpointSigner(s).WithPreorderedEntries().WithAntispam(10, nil)); err == nil {
+		t.Error("NewAppender with WithPreorderedEntries and WithAntispam succeeded, want error")
+	}
+
+	a, _, _, err := NewAppender(ctx, &fakeDriver{}, NewAppendOptions().WithCheckpointSigner(s).WithPreorderedEntries())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	idx := func(i uint64) *uint64 { return &i }
+	for _, test := range []struct {
+		name    string
+		index   *uint64
+		wantErr bool
+	}{
+		{name: "first", index: idx(0)},
+		{name: "second", index: idx(1)},
+		{name: "no index", wantErr: true},
+		{name: "gap", index: idx(3), wantErr: true},
+		{name: "repeat", index: idx(1), wantErr: true},
+		{name: "third", index: idx(2)},
+	} {
+		e := NewEntry([]byte(test.name))
+		if test.index != nil {
+			e = NewPreorderedEntry([]byte(test.name), *test.index)
+		}
+		got, err := a.Add(ctx, e)()
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Fatalf("%s: Add got err %v, want err %t", test.name, err, test.wantErr)
+		}
+		if err == nil && got.Index != *test.index {
+			t.Errorf("%s: Add got index %d, want %d", test.name, got.Index, *test.index)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+// unintegratedPollInterval is how often the unintegrated limiter refreshes its view of the log.
+const unintegratedPollInterval = 100 * time.Millisecond
+
+var (
+	errAddQPSExceeded      = fmt.Errorf("max add QPS exceeded: %w", ErrPushback)
+	errTooManyUnintegrated = fmt.Errorf("too many unintegrated entries: %w", ErrPushback)
+)
+
+// WithMaxAddQPS configures the Appender to reject calls to Add with ErrPushback once they arrive faster
+// than qps calls per second, averaged over one second.
+//
+// This limit is enforced by the Appender itself, and so applies uniformly regardless of the storage driver
+// in use. Entries which are found to be duplicates by antispam are not counted against this limit.
+//
+// A value of zero (the default) disables this limit. It can be changed while the log is running with
+// Appender.SetMaxAddQPS.
+func (o *AppendOptions) WithMaxAddQPS(qps float64) *AppendOptions {
+	o.maxAddQPS = qps
+	return o
+}
+
+// WithMaxUnintegrated configures the Appender to reject calls to Add with ErrPushback while there are
+// maxUnintegrated or more entries which have been sequenced but not yet integrated into the tree.
+//
+// Unlike WithPushback, which is a hint to the storage driver about how it should manage its own internal
+// queues, this limit is enforced by the Appender itself, and so applies uniformly regardless of the storage
+// driver in use. Entries which are found to be duplicates by antispam are not counted against this limit.
+//
+// A value of zero (the default) disables this limit. It can be changed while the log is running with
+// Appender.SetMaxUnintegrated.
+func (o *AppendOptions) WithMaxUnintegrated(maxUnintegrated uint64) *AppendOptions {
+	o.maxUnintegrated = maxUnintegrated
+	return o
+}
+
+// newQPSLimiter returns a decorator which fails Add requests with ErrPushback once they exceed qps per second.
+func newQPSLimiter(qps float64) func(AddFn) AddFn {
+	q := &qpsLimiter{}
+	q.set(qps)
+	return q.decorator
+}
+
+// qpsLimiter fails Add requests with ErrPushback once they exceed a configurable rate.
+type qpsLimiter struct {
+	// l is nil if the limit is disabled.
+	l atomic.Pointer[rate.Limiter]
+}
+
+// set changes the limit to qps calls per second. A value of zero disables the limit.
+func (q *qpsLimiter) set(qps float64) {
+	if qps <= 0 {
+		q.l.Store(nil)
+		return
+	}
+	q.l.Store(rate.NewLimiter(rate.Limit(qps), max(1, int(math.Ceil(qps)))))
+}
+
+// get returns the current limit, or zero if it's disabled.
+func (q *qpsLimiter) get() float64 {
+	if l := q.l.Load(); l != nil {
+		return float64(l.Limit())
+	}
+	return 0
+}
+
+// decorator returns an AddFn which enforces the limit before delegating to the provided function.
+func (q *qpsLimiter) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, entry *Entry) IndexFuture {
+		if l := q.l.Load(); l != nil && !l.Allow() {
+			return func() (Index, error) { return Index{}, errAddQPSExceeded }
+		}
+		return delegate(ctx, entry)
+	}
+}
+
+// unintegratedLimiter fails Add requests with ErrPushback while too many entries are awaiting integration.
+type unintegratedLimiter struct {
+	// max is the limit, or zero if it's disabled. It must be accessed atomically, so that it can be changed
+	// while the log is running.
+	max uint64
+	// unintegrated is the number of unintegrated entries seen when the log was last polled.
+	unintegrated atomic.Uint64
+	// added is the number of calls to Add which have been allowed through since the log was last polled.
+	added atomic.Uint64
+}
+
+// poll periodically updates the limiter's view of the number of unintegrated entries in the log.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (u *unintegratedLimiter) poll(ctx context.Context, r LogReader) {
+	t := time.NewTicker(unintegratedPollInterval)
+	defer t.Stop()
+	for {
+		// Avoid reading from the log when the limit is disabled.
+		if atomic.LoadUint64(&u.max) > 0 {
+			if err := u.update(ctx, r); err != nil {
+				klog.Errorf("unintegratedLimiter: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// update refreshes the number of unintegrated entries from the log.
+func (u *unintegratedLimiter) update(ctx context.Context, r LogReader) error {
+	// Read the integrated size first so that the difference can never be negative.
+	s, err := r.IntegratedSize(ctx)
+	if err != nil {
+		return fmt.Errorf("IntegratedSize: %v", err)
+	}
+	n, err := r.NextIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("NextIndex: %v", err)
+	}
+	var d uint64
+	if n > s {
+		d = n - s
+	}
+	u.unintegrated.Store(d)
+	u.added.Store(0)
+	return nil
+}
+
+// decorator returns an AddFn which enforces the limit before delegating to the provided function.
+func (u *unintegratedLimiter) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, entry *Entry) IndexFuture {
+		m := atomic.LoadUint64(&u.max)
+		if m == 0 {
+			return delegate(ctx, entry)
+		}
+		// Account for entries added since the last poll too, so that a burst of requests can't
+		// sneak in between polls.
+		if u.unintegrated.Load()+u.added.Add(1) > m {
+			u.added.Add(^uint64(0))
+			return func() (Index, error) { return Index{}, errTooManyUnintegrated }
+		}
+		return delegate(ctx, entry)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// sizesLogReader is a LogReader which reports fixed NextIndex and IntegratedSize values.
+type sizesLogReader struct {
+	fakeLogReader
+	next, integrated uint64
+}
+
+func (r *sizesLogReader) NextIndex(_ context.Context) (uint64, error) {
+	return r.next, nil
+}
+
+func (r *sizesLogReader) IntegratedSize(_ context.Context) (uint64, error) {
+	return r.integrated, nil
+}
+
+// countAdds returns an AddFn which succeeds, and counts how many times it has been called.
+func countAdds(n *int) AddFn {
+	return func(_ context.Context, _ *Entry) IndexFuture {
+		*n++
+		return func() (Index, error) { return Index{}, nil }
+	}
+}
+
+func TestQPSLimiter(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		qps      float64
+		adds     int
+		wantPass int
+	}{
+		{qps: 10, adds: 5, wantPass: 5},
+		{qps: 10, adds: 20, wantPass: 10},
+		{qps: 0.5, adds: 3, wantPass: 1},
+	} {
+		t.Run(fmt.Sprintf("%v-%d", test.qps, test.adds), func(t *testing.T) {
+			var n int
+			add := newQPSLimiter(test.qps)(countAdds(&n))
+			pushedBack := 0
+			for range test.adds {
+				if _, err := add(ctx, NewEntry(nil))(); err != nil {
+					if !errors.Is(err, ErrPushback) {
+						t.Fatalf("got error %v, want ErrPushback", err)
+					}
+					pushedBack++
+				}
+			}
+			if n != test.wantPass {
+				t.Errorf("%d adds passed through, want %d", n, test.wantPass)
+			}
+			if got, want := pushedBack, test.adds-test.wantPass; got != want {
+				t.Errorf("%d adds were pushed back, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestUnintegratedLimiter(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name             string
+		max              uint64
+		next, integrated uint64
+		adds             int
+		wantPass         int
+	}{
+		{name: "under limit", max: 10, next: 5, integrated: 5, adds: 10, wantPass: 10},
+		{name: "reaches limit", max: 10, next: 105, integrated: 100, adds: 10, wantPass: 5},
+		{name: "at limit", max: 10, next: 110, integrated: 100, adds: 3, wantPass: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			u := &unintegratedLimiter{max: test.max}
+			if err := u.update(ctx, &sizesLogReader{next: test.next, integrated: test.integrated}); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			var n int
+			add := u.decorator(countAdds(&n))
+			for range test.adds {
+				if _, err := add(ctx, NewEntry(nil))(); err != nil && !errors.Is(err, ErrPushback) {
+					t.Fatalf("got error %v, want ErrPushback", err)
+				}
+			}
+			if n != test.wantPass {
+				t.Errorf("%d adds passed through, want %d", n, test.wantPass)
+			}
+
+			// Once the entries are integrated, adds should be allowed again.
+			if err := u.update(ctx, &sizesLogReader{next: test.next, integrated: test.next}); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if _, err := add(ctx, NewEntry(nil))(); err != nil {
+				t.Errorf("Add after integration: %v", err)
+			}
+		})
+	}
+}
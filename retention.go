@@ -0,0 +1,219 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// retentionInterval is how often the retention policy is evaluated.
+const retentionInterval = 10 * time.Second
+
+// maxRetentionSamples bounds the number of integrated size samples held over MaxAge, and so the size of the
+// state stored by the driver.
+const maxRetentionSamples = 1024
+
+// ErrEntryBundleExpired is returned by LogReader.ReadEntryBundle when the requested entry bundle has been
+// expunged from the log by the retention policy. The tiles and checkpoints which commit to the entries
+// in the bundle are retained.
+//
+// Personalities should check for this error using `errors.Is(e, ErrEntryBundleExpired)`.
+var ErrEntryBundleExpired = errors.New("entry bundle has expired")
+
+// RetentionPolicy describes which entry bundles may be expunged from the log.
+//
+// Only full entry bundles are ever expunged, and only once all of the entries they contain have been integrated.
+type RetentionPolicy struct {
+	// MaxAge is how long entries are retained once they have been integrated. Zero means that entries are not
+	// expunged because of their age.
+	//
+	// The integration time of entries is tracked by periodically sampling the integrated size of the log, and
+	// the samples are stored by the driver so that they survive restarts. Entries may be expunged up to
+	// MaxAge/1024 later than their age alone would allow, and entries which were already integrated when the
+	// policy was first applied are treated as having been integrated at that time.
+	MaxAge time.Duration
+	// FrozenExpungeBelow, if non-zero, causes entry bundles containing only entries with indices below this
+	// value to be expunged once the log has been frozen.
+	FrozenExpungeBelow uint64
+}
+
+// WithRetention configures the Appender to expunge entry bundles from the log according to the provided
+// policy, for operators who are subject to data-retention limits.
+//
+// Once expunged, reads of the entry bundle will fail with ErrEntryBundleExpired. Note that this includes reads
+// made by followers, such as antispam, so they must not be allowed to fall behind by more than the retention
+// period.
+//
+// NewAppender returns an error if the storage implementation doesn't support expunging entry bundles.
+func (o *AppendOptions) WithRetention(p RetentionPolicy) *AppendOptions {
+	o.retention = &p
+	return o
+}
+
+// entryBundleExpunger is implemented by LogReaders which are able to delete entry bundles from the log.
+type entryBundleExpunger interface {
+	// ExpungeEntryBundles deletes all full entry bundles which contain only entries with indices below size.
+	// Subsequent reads of those bundles must return an error which wraps ErrEntryBundleExpired.
+	ExpungeEntryBundles(ctx context.Context, size uint64) error
+	// ReadRetentionSamples returns the opaque state most recently stored by WriteRetentionSamples, or nil if
+	// none has been stored.
+	ReadRetentionSamples(ctx context.Context) ([]byte, error)
+	// WriteRetentionSamples durably stores the provided opaque state.
+	WriteRetentionSamples(ctx context.Context, data []byte) error
+}
+
+// sizeSample records the integrated size of the log at a point in time.
+type sizeSample struct {
+	At   time.Time `json:"at"`
+	Size uint64    `json:"size"`
+}
+
+// retention applies a RetentionPolicy to a log.
+type retention struct {
+	policy   RetentionPolicy
+	reader   LogReader
+	expunger entryBundleExpunger
+	state    func() LogState
+	now      func() time.Time
+	// audit, if set, records expunged entry bundles.
+	audit *AuditLog
+	// bundleWidth is the number of entries in each full entry bundle.
+	bundleWidth uint64
+
+	// samples holds the integrated size of the log over the last MaxAge, oldest first, and loaded is true once
+	// they've been read from storage.
+	samples []sizeSample
+	loaded  bool
+	// expunged is the size below which entry bundles have been expunged by this instance.
+	expunged uint64
+}
+
+// run periodically applies the retention policy.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (r *retention) run(ctx context.Context) {
+	t := time.NewTicker(retentionInterval)
+	defer t.Stop()
+	for {
+		if err := r.apply(ctx); err != nil {
+			klog.Warningf("retention: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// apply expunges any entry bundles which the policy no longer requires to be retained.
+func (r *retention) apply(ctx context.Context) error {
+	size, err := r.reader.IntegratedSize(ctx)
+	if err != nil {
+		return fmt.Errorf("IntegratedSize: %v", err)
+	}
+	if r.policy.MaxAge > 0 && !r.loaded {
+		if err := r.loadSamples(ctx); err != nil {
+			return err
+		}
+	}
+	below, changed := r.expungeBelow(size)
+	if changed {
+		if err := r.storeSamples(ctx); err != nil {
+			return err
+		}
+	}
+	// Round down to a bundle boundary, since only full bundles are expunged.
+	below -= below % r.bundleWidth
+	if below <= r.expunged {
+		return nil
+	}
+	if err := r.expunger.ExpungeEntryBundles(ctx, below); err != nil {
+		err = fmt.Errorf("ExpungeEntryBundles(%d): %v", below, err)
+		r.audit.Record(AuditOpRetention, fmt.Sprintf("entry bundles below index %d", below), err)
+		return err
+	}
+	r.audit.Record(AuditOpRetention, fmt.Sprintf("entry bundles below index %d", below), nil)
+	klog.Infof("retention: expunged entry bundles below index %d", below)
+	r.expunged = below
+	return nil
+}
+
+// expungeBelow records the current integrated size, and returns the size below which the policy allows entries to
+// be expunged, and whether the samples have changed.
+func (r *retention) expungeBelow(size uint64) (uint64, bool) {
+	var below uint64
+	changed := false
+	if r.policy.MaxAge > 0 {
+		now := r.now()
+		// Only the earliest time at which the log reached a size is useful, and samples are spaced out so that
+		// there are never more than maxRetentionSamples of them.
+		if n := len(r.samples); n == 0 || (size > r.samples[n-1].Size && now.Sub(r.samples[n-1].At) >= r.policy.MaxAge/maxRetentionSamples) {
+			r.samples = append(r.samples, sizeSample{At: now, Size: size})
+			changed = true
+		}
+		// Find the newest sample which is older than MaxAge: everything integrated at that point has expired.
+		cutoff := now.Add(-r.policy.MaxAge)
+		i := 0
+		for i < len(r.samples) && !r.samples[i].At.After(cutoff) {
+			i++
+		}
+		if i > 0 {
+			below = r.samples[i-1].Size
+			// Older samples are no longer needed.
+			if i > 1 {
+				r.samples = r.samples[i-1:]
+				changed = true
+			}
+		}
+	}
+	if r.policy.FrozenExpungeBelow > 0 && r.state() == LogStateFrozen {
+		below = max(below, min(r.policy.FrozenExpungeBelow, size))
+	}
+	return below, changed
+}
+
+// loadSamples reads the samples previously stored by storeSamples.
+func (r *retention) loadSamples(ctx context.Context) error {
+	raw, err := r.expunger.ReadRetentionSamples(ctx)
+	if err != nil {
+		return fmt.Errorf("ReadRetentionSamples: %v", err)
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &r.samples); err != nil {
+			return fmt.Errorf("failed to parse retention samples: %v", err)
+		}
+	}
+	r.loaded = true
+	return nil
+}
+
+// storeSamples durably stores the current samples, so that entries are expunged on time across restarts.
+func (r *retention) storeSamples(ctx context.Context) error {
+	raw, err := json.Marshal(r.samples)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retention samples: %v", err)
+	}
+	if err := r.expunger.WriteRetentionSamples(ctx, raw); err != nil {
+		return fmt.Errorf("WriteRetentionSamples: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// recordingExpunger is an entryBundleExpunger which records the size it was last asked to expunge below, and
+// holds the retention samples in memory.
+type recordingExpunger struct {
+	size    uint64
+	samples []byte
+}
+
+func (e *recordingExpunger) ExpungeEntryBundles(_ context.Context, size uint64) error {
+	e.size = size
+	return nil
+}
+
+func (e *recordingExpunger) ReadRetentionSamples(_ context.Context) ([]byte, error) {
+	return e.samples, nil
+}
+
+func (e *recordingExpunger) WriteRetentionSamples(_ context.Context, data []byte) error {
+	e.samples = data
+	return nil
+}
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1000, 0)
+	type step struct {
+		after      time.Duration
+		integrated uint64
+		state      LogState
+		want       uint64
+	}
+	for _, test := range []struct {
+		name   string
+		policy RetentionPolicy
+		steps  []step
+	}{
+		{
+			name:   "max age",
+			policy: RetentionPolicy{MaxAge: time.Hour},
+			steps: []step{
+				{after: 0, integrated: 1000, want: 0},
+				{after: 30 * time.Minute, integrated: 2000, want: 0},
+				// Everything integrated when we started has now expired.
+				{after: time.Hour, integrated: 3000, want: 768},
+				// As has everything integrated 30 minutes in.
+				{after: 90 * time.Minute, integrated: 3000, want: 1792},
+			},
+		}, {
+			name:   "frozen",
+			policy: RetentionPolicy{FrozenExpungeBelow: 2000},
+			steps: []step{
+				{integrated: 3000, state: LogStateActive, want: 0},
+				{integrated: 3000, state: LogStateQuiescing, want: 0},
+				{integrated: 3000, state: LogStateFrozen, want: 1792},
+			},
+		}, {
+			name:   "frozen clamped to integrated size",
+			policy: RetentionPolicy{FrozenExpungeBelow: 2000},
+			steps: []step{
+				{integrated: 600, state: LogStateFrozen, want: 512},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var now time.Time
+			var state LogState
+			lr := &sizesLogReader{}
+			e := &recordingExpunger{}
+			r := &retention{
+				policy:      test.policy,
+				reader:      lr,
+				expunger:    e,
+				state:       func() LogState { return state },
+				now:         func() time.Time { return now },
+				bundleWidth: layout.EntryBundleWidth,
+			}
+			for i, s := range test.steps {
+				now, state, lr.integrated = start.Add(s.after), s.state, s.integrated
+				if err := r.apply(ctx); err != nil {
+					t.Fatalf("step %d: apply: %v", i, err)
+				}
+				if e.size != s.want {
+					t.Errorf("step %d: expunged below %d, want %d", i, e.size, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRetention_Unsupported(t *testing.T) {
+	s, _ := mustCreateKeys(t, "test")
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithRetention(RetentionPolicy{MaxAge: time.Hour})
+	if _, _, _, err := NewAppender(context.Background(), &fakeDriver{}, opts); err == nil {
+		t.Fatal("NewAppender: got nil error for driver which doesn't support expunging entry bundles")
+	}
+}
+
+func TestRetentionSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1000, 0)
+	now := start
+	lr := &sizesLogReader{}
+	e := &recordingExpunger{}
+	newRetention := func() *retention {
+		return &retention{
+			policy:      RetentionPolicy{MaxAge: time.Hour},
+			reader:      lr,
+			expunger:    e,
+			state:       func() LogState { return LogStateActive },
+			now:         func() time.Time { return now },
+			bundleWidth: layout.EntryBundleWidth,
+		}
+	}
+	lr.integrated = 1000
+	if err := newRetention().apply(ctx); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	// Restarting more often than MaxAge mustn't stop entries from expiring.
+	for _, after := range []time.Duration{20 * time.Minute, 40 * time.Minute, time.Hour} {
+		now, lr.integrated = start.Add(after), lr.integrated+1000
+		if err := newRetention().apply(ctx); err != nil {
+			t.Fatalf("apply after %v: %v", after, err)
+		}
+	}
+	if got, want := e.size, uint64(768); got != want {
+		t.Errorf("expunged below %d, want %d", got, want)
+	}
+}
+
+func TestRetentionSamplesBounded(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	lr := &sizesLogReader{}
+	r := &retention{
+		policy:      RetentionPolicy{MaxAge: time.Hour},
+		reader:      lr,
+		expunger:    &recordingExpunger{},
+		state:       func() LogState { return LogStateActive },
+		now:         func() time.Time { return now },
+		bundleWidth: layout.EntryBundleWidth,
+	}
+	for range 10 * maxRetentionSamples {
+		now, lr.integrated = now.Add(time.Second), lr.integrated+1
+		if err := r.apply(ctx); err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+	}
+	if got := len(r.samples); got > maxRetentionSamples+1 {
+		t.Errorf("got %d samples, want at most %d", got, maxRetentionSamples+1)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// RuntimeConfig holds the settings of an Appender which can be changed while the log is running.
+type RuntimeConfig struct {
+	// CheckpointInterval is the interval at which checkpoints are published, see WithCheckpointInterval.
+	CheckpointInterval time.Duration
+	// MaxAddQPS is the limit on the rate of calls to Add, see WithMaxAddQPS.
+	MaxAddQPS float64
+	// MaxUnintegrated is the limit on the number of entries awaiting integration, see WithMaxUnintegrated.
+	MaxUnintegrated uint64
+}
+
+// RuntimeConfig returns the current values of the settings which can be changed while the log is running.
+func (a *Appender) RuntimeConfig() (RuntimeConfig, error) {
+	if a.checkpointInterval == nil {
+		return RuntimeConfig{}, errors.New("appender was not created by NewAppender")
+	}
+	return RuntimeConfig{
+		CheckpointInterval: time.Duration(a.checkpointInterval.Load()),
+		MaxAddQPS:          a.qps.get(),
+		MaxUnintegrated:    atomic.LoadUint64(&a.unintegrated.max),
+	}, nil
+}
+
+// SetCheckpointInterval changes the interval at which checkpoints are published, as originally set by
+// WithCheckpointInterval.
+//
+// The new interval takes effect once the next checkpoint has been published. Storage implementations
+// which have a minimum checkpoint interval will use that instead of any shorter interval.
+func (a *Appender) SetCheckpointInterval(interval time.Duration) error {
+	if a.checkpointInterval == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("invalid checkpoint interval %v", interval)
+	}
+	old := time.Duration(a.checkpointInterval.Swap(int64(interval)))
+	a.recordConfig("checkpoint interval", old, interval)
+	return nil
+}
+
+// SetMaxAddQPS changes the limit on the rate of calls to Add, as originally set by WithMaxAddQPS.
+// A value of zero disables the limit.
+func (a *Appender) SetMaxAddQPS(qps float64) error {
+	if a.qps == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	if qps < 0 {
+		return fmt.Errorf("invalid max add QPS %v", qps)
+	}
+	old := a.qps.get()
+	a.qps.set(qps)
+	a.recordConfig("max add QPS", old, qps)
+	return nil
+}
+
+// SetMaxUnintegrated changes the limit on the number of entries awaiting integration, as originally set
+// by WithMaxUnintegrated. A value of zero disables the limit.
+func (a *Appender) SetMaxUnintegrated(maxUnintegrated uint64) error {
+	if a.unintegrated == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	old := atomic.SwapUint64(&a.unintegrated.max, maxUnintegrated)
+	a.recordConfig("max unintegrated", old, maxUnintegrated)
+	return nil
+}
+
+// SetWitnesses changes the witness policy used when publishing checkpoints, as originally set by
+// WithWitnesses.
+//
+// The new policy is used from the next checkpoint to be published onwards.
+func (a *Appender) SetWitnesses(witnesses WitnessGroup, opts *WitnessOptions) error {
+	if a.witnesses == nil {
+		return errors.New("appender was not created by NewAppender")
+	}
+	if witnesses.N < 0 || witnesses.N > len(witnesses.Components) {
+		return fmt.Errorf("invalid witness threshold %d for %d components", witnesses.N, len(witnesses.Components))
+	}
+	if opts == nil {
+		opts = &WitnessOptions{}
+	}
+	old := (AppendOptions{witnesses: a.witnesses}).witnessPolicy()
+	a.witnesses.Store(&witnessPolicy{group: witnesses, opts: *opts})
+	a.recordConfig("witnesses", describeWitnesses(old.group), describeWitnesses(witnesses))
+	return nil
+}
+
+// describeWitnesses summarises a witness policy for logging.
+func describeWitnesses(g WitnessGroup) string {
+	urls := slices.Sorted(maps.Keys(g.Endpoints()))
+	return fmt.Sprintf("%d of %d %v", g.N, len(g.Components), urls)
+}
+
+// recordConfig logs and audits a change to a setting.
+func (a *Appender) recordConfig(name string, from, to any) {
+	detail := fmt.Sprintf("%s %v -> %v", name, from, to)
+	a.audit.record(AuditOpConfiguration, detail, nil)
+	klog.Infof("Changed %s", detail)
+}
+
+// newDuration returns an atomic holding d, for settings which can be changed while the log is running.
+func newDuration(d time.Duration) *atomic.Int64 {
+	i := &atomic.Int64{}
+	i.Store(int64(d))
+	return i
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/merkle/rfc6962"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestRuntimeConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	l := NewAuditLog(0)
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithCheckpointInterval(time.Second).WithMaxAddQPS(1).WithAuditLog(l)
+	a, _, _, err := NewAppender(ctx, &fakeDriver{}, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	add := func() error {
+		_, err := a.Add(ctx, NewEntry([]byte("entry")))()
+		return err
+	}
+
+	if got, err := a.RuntimeConfig(); err != nil || got != (RuntimeConfig{CheckpointInterval: time.Second, MaxAddQPS: 1}) {
+		t.Fatalf("RuntimeConfig() = %+v, %v, want initial options", got, err)
+	}
+	if err := add(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := add(); !errors.Is(err, ErrPushback) {
+		t.Fatalf("Add over QPS limit: got err %v, want ErrPushback", err)
+	}
+
+	if err := a.SetMaxAddQPS(0); err != nil {
+		t.Fatalf("SetMaxAddQPS: %v", err)
+	}
+	if err := add(); err != nil {
+		t.Fatalf("Add with QPS limit disabled: %v", err)
+	}
+	if err := a.SetMaxUnintegrated(1); err != nil {
+		t.Fatalf("SetMaxUnintegrated: %v", err)
+	}
+	if err := a.SetCheckpointInterval(5 * time.Second); err != nil {
+		t.Fatalf("SetCheckpointInterval: %v", err)
+	}
+	if got := opts.CheckpointInterval(); got != 5*time.Second {
+		t.Errorf("CheckpointInterval() = %v, want the new interval to be visible to the driver", got)
+	}
+	want := RuntimeConfig{CheckpointInterval: 5 * time.Second, MaxUnintegrated: 1}
+	if got, err := a.RuntimeConfig(); err != nil || got != want {
+		t.Errorf("RuntimeConfig() = %+v, %v, want %+v", got, err, want)
+	}
+
+	for _, f := range []func() error{
+		func() error { return a.SetCheckpointInterval(0) },
+		func() error { return a.SetMaxAddQPS(-1) },
+	} {
+		if err := f(); err == nil {
+			t.Error("Invalid setting succeeded")
+		}
+	}
+	if got := len(l.Query(AuditQuery{Ops: []AuditOp{AuditOpConfiguration}})); got != 3 {
+		t.Errorf("Got %d configuration audit events, want 3", got)
+	}
+
+	if _, err := (&Appender{}).RuntimeConfig(); err == nil {
+		t.Error("RuntimeConfig() on Appender not created by NewAppender succeeded")
+	}
+}
+
+func TestSetWitnesses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	opts := NewAppendOptions().WithCheckpointSigner(s)
+	a, _, _, err := NewAppender(ctx, &fakeDriver{}, opts)
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	publish := opts.CheckpointPublisher(&fakeLogReader{}, http.DefaultClient)
+
+	// A witness which refuses every checkpoint.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	_, wvk, err := note.GenerateKey(nil, "witness")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	w, err := NewWitness(wvk, u)
+	if err != nil {
+		t.Fatalf("NewWitness: %v", err)
+	}
+
+	root := rfc6962.DefaultHasher.EmptyRoot()
+	for _, test := range []struct {
+		name     string
+		group    WitnessGroup
+		opts     *WitnessOptions
+		wantFail bool
+	}{
+		{name: "witness required", group: NewWitnessGroup(1, w), wantFail: true},
+		{name: "witness fail open", group: NewWitnessGroup(1, w), opts: &WitnessOptions{FailOpen: true}},
+		{name: "no witnesses", group: WitnessGroup{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := a.SetWitnesses(test.group, test.opts); err != nil {
+				t.Fatalf("SetWitnesses: %v", err)
+			}
+			_, err := publish(ctx, 0, root)
+			if gotFail := err != nil; gotFail != test.wantFail {
+				t.Errorf("publish: got err %v, want failure %t", err, test.wantFail)
+			}
+		})
+	}
+
+	if err := a.SetWitnesses(WitnessGroup{N: 1}, nil); err == nil {
+		t.Error("SetWitnesses with threshold above the number of witnesses succeeded")
+	}
+	if err := (&Appender{}).SetWitnesses(WitnessGroup{}, nil); err == nil {
+		t.Error("SetWitnesses on Appender not created by NewAppender succeeded")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultLookupLimit is the number of results returned by a lookup if the request doesn't specify a limit.
+	DefaultLookupLimit = 100
+	// MaxLookupLimit is the largest number of results returned by a lookup.
+	MaxLookupLimit = 1000
+)
+
+// LookupResponse holds the entries with a term, together with proofs of their inclusion in the log.
+type LookupResponse struct {
+	// Checkpoint is the published checkpoint which the proofs are relative to. Only entries committed to by
+	// this checkpoint are returned.
+	Checkpoint []byte `json:"checkpoint"`
+	// Results hold the matching entries, in ascending order of index.
+	Results []LookupResult `json:"results"`
+	// Next, if set, is the from parameter which should be used to fetch more results.
+	Next *uint64 `json:"next,omitempty"`
+}
+
+// LookupResult is an entry with a term.
+type LookupResult struct {
+	// Index is the index of the entry in the log.
+	Index uint64 `json:"index"`
+	// InclusionProof proves the inclusion of the entry at Index in the tree committed to by the checkpoint.
+	InclusionProof [][]byte `json:"inclusion_proof"`
+}
+
+// Handler returns an http.Handler which serves lookups against the index, with inclusion proofs built from the
+// log read by r:
+//   - GET /lookup/{field}/{value}?from=<index>&limit=<n> returns a JSON encoded LookupResponse for the term.
+//
+// The entries themselves aren't returned; clients can fetch them from the log's entry bundles, and verify them
+// using the inclusion proofs.
+func (i *Index) Handler(r tessera.LogReader) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lookup/{field}/{value...}", func(w http.ResponseWriter, req *http.Request) {
+		i.handleLookup(w, req, r)
+	})
+	return mux
+}
+
+func (i *Index) handleLookup(w http.ResponseWriter, req *http.Request, r tessera.LogReader) {
+	t := Term{Field: req.PathValue("field"), Value: req.PathValue("value")}
+	var from uint64
+	limit := DefaultLookupLimit
+	if v := req.FormValue("from"); v != "" {
+		f, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+		from = f
+	}
+	if v := req.FormValue("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		limit = min(l, MaxLookupLimit)
+	}
+	resp, err := i.lookup(req.Context(), r, t, from, limit)
+	if err != nil {
+		klog.Warningf("Lookup: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// lookup returns the entries with term t committed to by the log's published checkpoint.
+func (i *Index) lookup(ctx context.Context, r tessera.LogReader, t Term, from uint64, limit int) (*LookupResponse, error) {
+	cp, err := r.ReadCheckpoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	_, size, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	indices, err := i.store.Lookup(ctx, t, from, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %q: %v", t.Field, err)
+	}
+	resp := &LookupResponse{Checkpoint: cp, Results: []LookupResult{}}
+	if len(indices) == 0 {
+		return resp, nil
+	}
+	pb, err := client.NewProofBuilder(ctx, size, r.ReadTile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	for _, idx := range indices {
+		if idx >= size {
+			// The index is ahead of the published checkpoint.
+			return resp, nil
+		}
+		p, err := pb.InclusionProof(ctx, idx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build inclusion proof for %d: %v", idx, err)
+		}
+		resp.Results = append(resp.Results, LookupResult{Index: idx, InclusionProof: p})
+	}
+	if len(indices) == limit {
+		next := indices[len(indices)-1] + 1
+		resp.Next = &next
+	}
+	return resp, nil
+}
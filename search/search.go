@@ -0,0 +1,169 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package search maintains an index of the entries of a Tessera log by the values of fields extracted from
+// them, and serves lookups of the entries with a given value, together with proofs of their inclusion in
+// the log.
+//
+// An Index follows the log, passing each entry to a user supplied ExtractFunc, and stores the index of the
+// entry under each of the terms that it returns. The index can be held in memory, or in a SQL database such as
+// SQLite or PostgreSQL.
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// Term is a value of a field, under which log entries are indexed.
+type Term struct {
+	Field string
+	Value string
+}
+
+// ExtractFunc returns the terms under which the log entry at the provided index should be indexed.
+//
+// Entries which shouldn't be indexed should return nil. Returning an error prevents the index from
+// making progress until the function succeeds, so should be reserved for transient failures.
+type ExtractFunc func(index uint64, entry []byte) ([]Term, error)
+
+// Posting records that the log entry at Index has a Term.
+type Posting struct {
+	Term  Term
+	Index uint64
+}
+
+// Store holds the postings of an index, along with the number of log entries that they were derived from.
+type Store interface {
+	// Position returns the size most recently passed to Add, or zero if it has never been called.
+	Position(ctx context.Context) (uint64, error)
+	// Add stores postings, and records that the index has been derived from the first size entries of the
+	// log. Implementations should do so atomically where possible. Postings which have already been stored
+	// must be ignored, since a batch of entries may be indexed again if the process restarts.
+	Add(ctx context.Context, postings []Posting, size uint64) error
+	// Lookup returns, in ascending order, the indices of at most limit entries with term t, starting with
+	// the first at or after from.
+	Lookup(ctx context.Context, t Term, from uint64, limit int) ([]uint64, error)
+}
+
+// Index is an index of the entries of a log.
+type Index struct {
+	store   Store
+	extract ExtractFunc
+}
+
+// New returns an Index which extracts terms from log entries using fn, and stores them in store.
+//
+// Only a single Index may use a given Store at a time.
+func New(store Store, fn ExtractFunc) *Index {
+	return &Index{store: store, extract: fn}
+}
+
+// Follower returns a tessera.Follower which keeps the index up to date with the log.
+//
+// The follower's position is stored with the index itself. Indices which are relied upon by external parties
+// should set FollowerOptions.FollowPublished, so that lookups only return entries committed to by a published
+// checkpoint.
+func (i *Index) Follower(name string, opts *tessera.FollowerOptions) tessera.Follower {
+	return tessera.NewFollower(name, (*indexPosition)(i), i.process, opts)
+}
+
+// indexPosition implements tessera.FollowerPositionStore using the position of the index's store.
+type indexPosition Index
+
+func (p *indexPosition) Position(ctx context.Context) (uint64, error) {
+	return p.store.Position(ctx)
+}
+
+// SetPosition checks that pos matches the position of the store, which was updated by process.
+func (p *indexPosition) SetPosition(ctx context.Context, pos uint64) error {
+	got, err := p.store.Position(ctx)
+	if err != nil {
+		return err
+	}
+	if got != pos {
+		return fmt.Errorf("position %d does not match index position %d", pos, got)
+	}
+	return nil
+}
+
+// process indexes a batch of log entries.
+func (i *Index) process(ctx context.Context, first uint64, entries [][]byte) error {
+	var postings []Posting
+	for j, e := range entries {
+		idx := first + uint64(j)
+		terms, err := i.extract(idx, e)
+		if err != nil {
+			return fmt.Errorf("failed to extract terms from entry %d: %v", idx, err)
+		}
+		for _, t := range terms {
+			postings = append(postings, Posting{Term: t, Index: idx})
+		}
+	}
+	if err := i.store.Add(ctx, postings, first+uint64(len(entries))); err != nil {
+		return fmt.Errorf("failed to store postings: %v", err)
+	}
+	return nil
+}
+
+// Lookup returns, in ascending order, the indices of at most limit entries with term t, starting with the first
+// at or after from.
+func (i *Index) Lookup(ctx context.Context, t Term, from uint64, limit int) ([]uint64, error) {
+	return i.store.Lookup(ctx, t, from, limit)
+}
+
+// NewMemoryStore returns a Store which holds the index in memory.
+//
+// This is intended for small logs, or for testing.
+func NewMemoryStore() Store {
+	return &memStore{postings: make(map[Term][]uint64)}
+}
+
+type memStore struct {
+	mu       sync.RWMutex
+	size     uint64
+	postings map[Term][]uint64
+}
+
+func (s *memStore) Position(_ context.Context) (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.size, nil
+}
+
+func (s *memStore) Add(_ context.Context, postings []Posting, size uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range postings {
+		l := s.postings[p.Term]
+		i, found := slices.BinarySearch(l, p.Index)
+		if !found {
+			s.postings[p.Term] = slices.Insert(l, i, p.Index)
+		}
+	}
+	s.size = size
+	return nil
+}
+
+func (s *memStore) Lookup(_ context.Context, t Term, from uint64, limit int) ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := s.postings[t]
+	i, _ := slices.BinarySearch(l, from)
+	return slices.Clone(l[i:min(len(l), i+limit)]), nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/testonly"
+)
+
+var mysqlURI = flag.String("mysql_uri", "", "Connection string for a MySQL database used to test SQLStore. If unset, the test is skipped.")
+
+// testStore checks the behaviour of an empty Store.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	a, b := Term{Field: "name", Value: "a"}, Term{Field: "name", Value: "b"}
+	// Terms with the same concatenation of field and value must be distinct.
+	ab := Term{Field: "namea", Value: ""}
+
+	if pos, err := s.Position(ctx); err != nil || pos != 0 {
+		t.Fatalf("Position: %d, %v, want 0", pos, err)
+	}
+	if err := s.Add(ctx, []Posting{{a, 0}, {b, 1}, {a, 2}, {ab, 3}}, 4); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// Adding the same postings again, as happens if a batch is reprocessed, should have no effect.
+	if err := s.Add(ctx, []Posting{{a, 2}, {ab, 3}}, 4); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add(ctx, []Posting{{a, 4}, {a, 6}}, 7); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add(ctx, nil, 10); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if pos, err := s.Position(ctx); err != nil || pos != 10 {
+		t.Fatalf("Position: %d, %v, want 10", pos, err)
+	}
+
+	for _, test := range []struct {
+		term  Term
+		from  uint64
+		limit int
+		want  []uint64
+	}{
+		{term: a, limit: 10, want: []uint64{0, 2, 4, 6}},
+		{term: a, limit: 2, want: []uint64{0, 2}},
+		{term: a, from: 3, limit: 10, want: []uint64{4, 6}},
+		{term: a, from: 7, limit: 10},
+		{term: b, limit: 10, want: []uint64{1}},
+		{term: ab, limit: 10, want: []uint64{3}},
+		{term: Term{Field: "other", Value: "a"}, limit: 10},
+	} {
+		got, err := s.Lookup(ctx, test.term, test.from, test.limit)
+		if err != nil {
+			t.Fatalf("Lookup(%v, %d, %d): %v", test.term, test.from, test.limit, err)
+		}
+		if d := cmp.Diff(test.want, got, cmpEmpty); d != "" {
+			t.Errorf("Lookup(%v, %d, %d): diff (-want +got):\n%s", test.term, test.from, test.limit, d)
+		}
+	}
+}
+
+// cmpEmpty treats nil and empty slices as equal.
+var cmpEmpty = cmp.FilterValues(func(a, b []uint64) bool { return len(a) == 0 && len(b) == 0 }, cmp.Ignore())
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestSQLStore(t *testing.T) {
+	if *mysqlURI == "" {
+		t.Skip("--mysql_uri not set")
+	}
+	ctx := context.Background()
+	db, err := sql.Open("mysql", *mysqlURI)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	for _, q := range []string{"DROP TABLE IF EXISTS search_postings", "DROP TABLE IF EXISTS search_position"} {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	s, err := NewSQLStore(ctx, db, MySQL)
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+	testStore(t, s)
+}
+
+func TestDialectBind(t *testing.T) {
+	const q = "SELECT idx FROM t WHERE a = ? AND b >= ? LIMIT ?"
+	for _, test := range []struct {
+		d    Dialect
+		want string
+	}{
+		{d: SQLite, want: q},
+		{d: MySQL, want: q},
+		{d: Postgres, want: "SELECT idx FROM t WHERE a = $1 AND b >= $2 LIMIT $3"},
+	} {
+		if got := test.d.bind(q); got != test.want {
+			t.Errorf("%s: bind(%q) = %q, want %q", test.d.name, q, got, test.want)
+		}
+	}
+}
+
+// testExtract indexes entries of the form "<name> <n>" by name, and by the parity of n.
+func testExtract(_ uint64, entry []byte) ([]Term, error) {
+	var name string
+	var n int
+	if _, err := fmt.Sscanf(string(entry), "%s %d", &name, &n); err != nil {
+		return nil, nil
+	}
+	parity := "even"
+	if n%2 == 1 {
+		parity = "odd"
+	}
+	return []Term{{Field: "name", Value: name}, {Field: "parity", Value: parity}}, nil
+}
+
+func TestIndex(t *testing.T) {
+	ctx := t.Context()
+	idx := New(NewMemoryStore(), testExtract)
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithFollower(idx.Follower("search", &tessera.FollowerOptions{
+			BatchSize:       7,
+			PollInterval:    10 * time.Millisecond,
+			FollowPublished: true,
+		})))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	const numEntries = 300
+	names := []string{"alice", "bob", "carol/dave"}
+	entries := make([][]byte, 0, numEntries)
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 10*time.Millisecond)
+	var last tessera.IndexFuture
+	for i := range numEntries {
+		e := fmt.Appendf(nil, "%s %d", names[i%len(names)], i)
+		entries = append(entries, e)
+		last = tl.Appender.Add(ctx, tessera.NewEntry(e))
+	}
+	if _, _, err := awaiter.Await(ctx, last); err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+	for {
+		pos, err := idx.store.Position(ctx)
+		if err != nil {
+			t.Fatalf("Position: %v", err)
+		}
+		if pos == numEntries {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srv := httptest.NewServer(idx.Handler(tl.LogReader))
+	defer srv.Close()
+	for _, test := range []struct {
+		name     string
+		path     string
+		wantCode int
+		// want is the expected indices, and wantNext the expected Next.
+		want     []uint64
+		wantNext *uint64
+	}{
+		{
+			name:     "name",
+			path:     "/lookup/name/bob?limit=5",
+			wantCode: http.StatusOK,
+			want:     []uint64{1, 4, 7, 10, 13},
+			wantNext: ptr(uint64(14)),
+		}, {
+			name:     "name from",
+			path:     "/lookup/name/bob?from=290",
+			wantCode: http.StatusOK,
+			want:     []uint64{292, 295, 298},
+		}, {
+			name:     "escaped value",
+			path:     "/lookup/name/" + url.PathEscape("carol/dave") + "?from=294&limit=2",
+			wantCode: http.StatusOK,
+			want:     []uint64{296, 299},
+			wantNext: ptr(uint64(300)),
+		}, {
+			name:     "parity",
+			path:     "/lookup/parity/odd?from=280&limit=3",
+			wantCode: http.StatusOK,
+			want:     []uint64{281, 283, 285},
+			wantNext: ptr(uint64(286)),
+		}, {
+			name:     "no matches",
+			path:     "/lookup/name/erin",
+			wantCode: http.StatusOK,
+		}, {
+			name:     "bad limit",
+			path:     "/lookup/name/bob?limit=0",
+			wantCode: http.StatusBadRequest,
+		}, {
+			name:     "bad from",
+			path:     "/lookup/name/bob?from=x",
+			wantCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := srv.Client().Get(srv.URL + test.path)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != test.wantCode {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, test.wantCode)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+			var lr LookupResponse
+			if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			_, size, root, err := parse.CheckpointUnsafe(lr.Checkpoint)
+			if err != nil {
+				t.Fatalf("CheckpointUnsafe: %v", err)
+			}
+			var got []uint64
+			for _, r := range lr.Results {
+				got = append(got, r.Index)
+				if err := proof.VerifyInclusion(rfc6962.DefaultHasher, r.Index, size, rfc6962.DefaultHasher.HashLeaf(entries[r.Index]), r.InclusionProof, root); err != nil {
+					t.Errorf("VerifyInclusion(%d): %v", r.Index, err)
+				}
+			}
+			if d := cmp.Diff(test.want, got, cmpEmpty); d != "" {
+				t.Errorf("indices: diff (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(test.wantNext, lr.Next); d != "" {
+				t.Errorf("next: diff (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
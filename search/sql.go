@@ -0,0 +1,171 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect describes the SQL dialect spoken by a database used by a SQLStore.
+type Dialect struct {
+	name string
+	// bytesType is the column type used for term hashes.
+	bytesType string
+	// insertPosting inserts a posting, ignoring it if it already exists.
+	insertPosting string
+	// upsertPosition sets the position.
+	upsertPosition string
+	// numberedParams is true if the dialect uses $1, $2, ... rather than ? for parameters.
+	numberedParams bool
+}
+
+var (
+	// SQLite is the dialect of SQLite databases.
+	SQLite = Dialect{
+		name:           "sqlite",
+		bytesType:      "BLOB",
+		insertPosting:  "INSERT INTO search_postings (term_hash, idx) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		upsertPosition: "INSERT INTO search_position (id, size) VALUES (0, ?) ON CONFLICT (id) DO UPDATE SET size = excluded.size",
+	}
+	// Postgres is the dialect of PostgreSQL databases.
+	Postgres = Dialect{
+		name:           "postgres",
+		bytesType:      "BYTEA",
+		insertPosting:  "INSERT INTO search_postings (term_hash, idx) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		upsertPosition: "INSERT INTO search_position (id, size) VALUES (0, ?) ON CONFLICT (id) DO UPDATE SET size = excluded.size",
+		numberedParams: true,
+	}
+	// MySQL is the dialect of MySQL databases.
+	MySQL = Dialect{
+		name:           "mysql",
+		bytesType:      "VARBINARY(32)",
+		insertPosting:  "INSERT IGNORE INTO search_postings (term_hash, idx) VALUES (?, ?)",
+		upsertPosition: "INSERT INTO search_position (id, size) VALUES (0, ?) ON DUPLICATE KEY UPDATE size = VALUES(size)",
+	}
+)
+
+// bind rewrites the parameters of query for the dialect.
+func (d Dialect) bind(query string) string {
+	if !d.numberedParams {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLStore is a Store which holds the index in a SQL database.
+//
+// Terms are stored as SHA-256 hashes, so values of any length can be indexed, but the terms of an entry can't be
+// listed from the database.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore returns a SQLStore which holds the index in db, creating the tables it needs if they don't exist.
+// The driver for db must be imported by the caller.
+func NewSQLStore(ctx context.Context, db *sql.DB, d Dialect) (*SQLStore, error) {
+	for _, q := range []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS search_postings (term_hash %s NOT NULL, idx BIGINT NOT NULL, PRIMARY KEY (term_hash, idx))", d.bytesType),
+		"CREATE TABLE IF NOT EXISTS search_position (id INT NOT NULL PRIMARY KEY, size BIGINT NOT NULL)",
+	} {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return nil, fmt.Errorf("failed to create %s schema: %v", d.name, err)
+		}
+	}
+	return &SQLStore{db: db, dialect: d}, nil
+}
+
+func (s *SQLStore) Position(ctx context.Context) (uint64, error) {
+	var size uint64
+	err := s.db.QueryRowContext(ctx, "SELECT size FROM search_position WHERE id = 0").Scan(&size)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return size, err
+}
+
+func (s *SQLStore) Add(ctx context.Context, postings []Posting, size uint64) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if len(postings) > 0 {
+		stmt, err := tx.PrepareContext(ctx, s.dialect.bind(s.dialect.insertPosting))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = stmt.Close()
+		}()
+		for _, p := range postings {
+			if _, err := stmt.ExecContext(ctx, termHash(p.Term), p.Index); err != nil {
+				return fmt.Errorf("failed to insert posting: %v", err)
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, s.dialect.bind(s.dialect.upsertPosition), size); err != nil {
+		return fmt.Errorf("failed to update position: %v", err)
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) Lookup(ctx context.Context, t Term, from uint64, limit int) ([]uint64, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.bind("SELECT idx FROM search_postings WHERE term_hash = ? AND idx >= ? ORDER BY idx LIMIT ?"), termHash(t), from, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var r []uint64
+	for rows.Next() {
+		var i uint64
+		if err := rows.Scan(&i); err != nil {
+			return nil, err
+		}
+		r = append(r, i)
+	}
+	return r, rows.Err()
+}
+
+// termHash returns the hash under which postings for t are stored.
+func termHash(t Term) []byte {
+	h := sha256.New()
+	// Prefix the field with its length, so that distinct terms can't have the same encoding.
+	h.Write([]byte(strconv.Itoa(len(t.Field)) + ":" + t.Field))
+	h.Write([]byte(t.Value))
+	return h.Sum(nil)
+}
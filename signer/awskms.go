@@ -0,0 +1,151 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/cenkalti/backoff/v5"
+)
+
+// NewAWSKMS returns a Signer with the given note key name which signs using an Ed25519 (ECC_NIST_EDWARDS25519)
+// key held in AWS KMS.
+//
+// keyID may be any identifier for the key accepted by KMS, e.g. a key ID, key ARN, or alias ARN.
+//
+// The region, credentials, and HTTP client are taken from cfg, e.g. as returned by
+// github.com/aws/aws-sdk-go-v2/config.LoadDefaultConfig. If cfg.BaseEndpoint is set, it is used in place of the
+// regional KMS endpoint. The public key is fetched from KMS when the Signer is created. Requests which are
+// throttled or fail because KMS is unavailable are retried with backoff, as the AWS SDK does.
+func NewAWSKMS(ctx context.Context, cfg aws.Config, name, keyID string, opts Options) (*Signer, error) {
+	if cfg.Credentials == nil {
+		return nil, errors.New("no credentials provided in AWS config")
+	}
+	domain := "amazonaws.com"
+	if strings.HasPrefix(cfg.Region, "cn-") {
+		domain = "amazonaws.com.cn"
+	}
+	k := &awsKMS{
+		cfg:      cfg,
+		endpoint: fmt.Sprintf("https://kms.%s.%s/", cfg.Region, domain),
+		signer:   v4.NewSigner(),
+		client:   http.DefaultClient,
+	}
+	if cfg.BaseEndpoint != nil {
+		k.endpoint = *cfg.BaseEndpoint
+	}
+	if cfg.HTTPClient != nil {
+		k.client = cfg.HTTPClient
+	}
+
+	var pk struct {
+		PublicKey []byte `json:"PublicKey"`
+		KeySpec   string `json:"KeySpec"`
+	}
+	if err := k.call(ctx, "GetPublicKey", struct {
+		KeyId string `json:"KeyId"`
+	}{KeyId: keyID}, &pk); err != nil {
+		return nil, fmt.Errorf("failed to get public key for %q: %v", keyID, err)
+	}
+	if pk.KeySpec != "ECC_NIST_EDWARDS25519" {
+		return nil, fmt.Errorf("unsupported key spec %q, only ECC_NIST_EDWARDS25519 is supported", pk.KeySpec)
+	}
+	pub, err := parseDERPublicKey(pk.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := func(ctx context.Context, msg []byte) ([]byte, error) {
+		var resp struct {
+			Signature []byte `json:"Signature"`
+		}
+		if err := k.call(ctx, "Sign", struct {
+			KeyId            string `json:"KeyId"`
+			Message          []byte `json:"Message"`
+			MessageType      string `json:"MessageType"`
+			SigningAlgorithm string `json:"SigningAlgorithm"`
+		}{
+			KeyId:            keyID,
+			Message:          msg,
+			MessageType:      "RAW",
+			SigningAlgorithm: "ED25519_SHA_512",
+		}, &resp); err != nil {
+			return nil, fmt.Errorf("Sign: %w", err)
+		}
+		return resp.Signature, nil
+	}
+	return New(name, pub, sign, opts)
+}
+
+// awsKMS knows how to make calls to the AWS KMS JSON API.
+type awsKMS struct {
+	cfg      aws.Config
+	endpoint string
+	signer   *v4.Signer
+	client   httpDoer
+}
+
+// call invokes the named KMS API operation with the JSON serialisation of in, and unmarshals the result into out.
+func (k *awsKMS) call(ctx context.Context, op string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(body)
+	return retry(ctx, isAWSTransient, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "TrentService."+op)
+
+		// Each attempt is signed afresh, since signatures are only valid for a short time.
+		creds, err := k.cfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to retrieve credentials: %v", err))
+		}
+		if err := k.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(h[:]), "kms", k.cfg.Region, time.Now()); err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to sign request: %v", err))
+		}
+		return doJSON(k.client, req, out)
+	})
+}
+
+// isAWSTransient returns true if err is likely to be resolved by retrying the request. As well as the errors
+// which isTransient reports, KMS reports that a request was throttled with a 400 status.
+func isAWSTransient(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) && se.code == http.StatusBadRequest {
+		var e struct {
+			Type string `json:"__type"`
+		}
+		if json.Unmarshal(se.body, &e) == nil && strings.HasSuffix(e.Type, "ThrottlingException") {
+			return true
+		}
+	}
+	return isTransient(err)
+}
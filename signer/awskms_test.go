@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// fakeAWSKMS returns a server which implements the parts of the AWS KMS API used by NewAWSKMS. It throttles the
+// first failures requests.
+func fakeAWSKMS(t *testing.T, priv ed25519.PrivateKey, keySpec string, failures int) *httptest.Server {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	s := httptest.NewServer(failFirst(failures, http.StatusBadRequest, `{"__type":"ThrottlingException"}`, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			http.Error(w, `{"__type":"MissingAuthenticationTokenException"}`, http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			KeyId            string
+			Message          []byte
+			SigningAlgorithm string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.KeyId != "alias/log" {
+			http.Error(w, `{"__type":"NotFoundException"}`, http.StatusBadRequest)
+			return
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			_ = json.NewEncoder(w).Encode(map[string]any{"PublicKey": der, "KeySpec": keySpec})
+		case "TrentService.Sign":
+			if req.SigningAlgorithm != "ED25519_SHA_512" {
+				http.Error(w, `{"__type":"ValidationException"}`, http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"Signature": ed25519.Sign(priv, req.Message)})
+		default:
+			http.Error(w, `{"__type":"UnknownOperationException"}`, http.StatusBadRequest)
+		}
+	})))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestAWSKMS(t *testing.T) {
+	_, priv := mustKey(t)
+	for _, test := range []struct {
+		name     string
+		keySpec  string
+		keyID    string
+		failures int
+		wantErr  bool
+	}{
+		{name: "ok", keySpec: "ECC_NIST_EDWARDS25519", keyID: "alias/log"},
+		{name: "wrong key spec", keySpec: "ECC_NIST_P256", keyID: "alias/log", wantErr: true},
+		{name: "unknown key", keySpec: "ECC_NIST_EDWARDS25519", keyID: "alias/other", wantErr: true},
+		{name: "throttled, then ok", keySpec: "ECC_NIST_EDWARDS25519", keyID: "alias/log", failures: maxAttempts - 1},
+		{name: "throttled", keySpec: "ECC_NIST_EDWARDS25519", keyID: "alias/log", failures: maxAttempts, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := fakeAWSKMS(t, priv, test.keySpec, test.failures)
+			cfg := aws.Config{
+				Region:       "us-east-1",
+				Credentials:  credentials.NewStaticCredentialsProvider("id", "secret", ""),
+				BaseEndpoint: aws.String(srv.URL),
+				HTTPClient:   srv.Client(),
+			}
+			s, err := NewAWSKMS(context.Background(), cfg, "example.com/log", test.keyID, Options{})
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("NewAWSKMS: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			verifySigner(t, s)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+)
+
+// gcpKMSEndpoint is the base URL of the Cloud KMS REST API.
+const gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+
+const (
+	// maxAttempts is the number of times a KMS request is tried before giving up, as in the AWS SDK's default
+	// retryer.
+	maxAttempts = 3
+	// initialRetryInterval is the delay before the first retry of a failed KMS request, which then backs off
+	// exponentially.
+	initialRetryInterval = 100 * time.Millisecond
+)
+
+// NewGCPKMS returns a Signer with the given note key name which signs using an Ed25519 (EC_SIGN_ED25519) key
+// version held in Google Cloud KMS.
+//
+// keyVersion is the resource name of the key version, of the form
+// projects/P/locations/L/keyRings/R/cryptoKeys/K/cryptoKeyVersions/V.
+//
+// The provided client must attach credentials to requests, e.g. a client created with
+// golang.org/x/oauth2/google.DefaultClient using the https://www.googleapis.com/auth/cloudkms scope.
+// The public key is fetched from KMS when the Signer is created. Requests which fail because KMS is
+// unavailable or rate limiting them are retried with backoff, as the Cloud KMS client library does.
+func NewGCPKMS(ctx context.Context, c *http.Client, name, keyVersion string, opts Options) (*Signer, error) {
+	return newGCPKMS(ctx, c, gcpKMSEndpoint, name, keyVersion, opts)
+}
+
+func newGCPKMS(ctx context.Context, c *http.Client, endpoint, name, keyVersion string, opts Options) (*Signer, error) {
+	base := strings.TrimSuffix(endpoint, "/") + "/" + keyVersion
+
+	var pk struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := retry(ctx, isTransient, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/publicKey", nil)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		return doJSON(c, req, &pk)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get public key for %q: %v", keyVersion, err)
+	}
+	if pk.Algorithm != "EC_SIGN_ED25519" {
+		return nil, fmt.Errorf("unsupported key algorithm %q, only EC_SIGN_ED25519 is supported", pk.Algorithm)
+	}
+	pub, err := parsePublicKey(pk.PEM)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := func(ctx context.Context, msg []byte) ([]byte, error) {
+		body, err := json.Marshal(struct {
+			Data []byte `json:"data"`
+		}{Data: msg})
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Signature []byte `json:"signature"`
+		}
+		if err := retry(ctx, isTransient, func() error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+":asymmetricSign", bytes.NewReader(body))
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			return doJSON(c, req, &resp)
+		}); err != nil {
+			return nil, fmt.Errorf("asymmetricSign: %w", err)
+		}
+		return resp.Signature, nil
+	}
+	return New(name, pub, sign, opts)
+}
+
+// parsePublicKey parses a PEM encoded Ed25519 public key.
+func parsePublicKey(p string) (ed25519.PublicKey, error) {
+	b, _ := pem.Decode([]byte(p))
+	if b == nil {
+		return nil, errors.New("failed to decode public key PEM")
+	}
+	return parseDERPublicKey(b.Bytes)
+}
+
+// parseDERPublicKey parses a DER encoded SubjectPublicKeyInfo holding an Ed25519 public key.
+func parseDERPublicKey(der []byte) (ed25519.PublicKey, error) {
+	k, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T, only Ed25519 keys are supported", k)
+	}
+	return pub, nil
+}
+
+// httpDoer is satisfied by *http.Client, and the HTTP clients used by the AWS SDK.
+type httpDoer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// statusError is returned by doJSON when the server responds with a status other than 200 OK.
+type statusError struct {
+	code int
+	body []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("got status %d: %s", e.code, e.body)
+}
+
+// isTransient returns true if err is likely to be resolved by retrying the request: the server is overloaded,
+// unavailable, or couldn't be reached.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// retry calls f until it succeeds, it fails with an error which transient doesn't consider retryable or which
+// is a backoff.PermanentError, or it has been tried maxAttempts times.
+func retry(ctx context.Context, transient func(error) bool, f func() error) error {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = initialRetryInterval
+	_, err := backoff.Retry(ctx, func() (struct{}, error) {
+		err := f()
+		if err != nil && !transient(err) {
+			err = backoff.Permanent(err)
+		}
+		return struct{}{}, err
+	}, backoff.WithBackOff(bo), backoff.WithMaxTries(maxAttempts))
+	return err
+}
+
+// doJSON performs the request, and unmarshals the JSON response into out.
+func doJSON(c httpDoer, req *http.Request, out any) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode, body: bytes.TrimSpace(body)}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return backoff.Permanent(fmt.Errorf("failed to unmarshal response: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+const testKeyVersion = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+// fakeGCPKMS returns a server which implements the parts of the Cloud KMS API used by NewGCPKMS. It responds to the
+// first failures requests with a 503 error.
+func fakeGCPKMS(t *testing.T, priv ed25519.PrivateKey, algorithm string, failures int) *httptest.Server {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/"+testKeyVersion+"/publicKey", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"pem":       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			"algorithm": algorithm,
+		})
+	})
+	mux.HandleFunc("POST /v1/"+testKeyVersion+":asymmetricSign", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Data []byte `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]byte{"signature": ed25519.Sign(priv, req.Data)})
+	})
+	s := httptest.NewServer(failFirst(failures, http.StatusServiceUnavailable, `{"error":{"status":"UNAVAILABLE"}}`, mux))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// failFirst returns a handler which responds to the first n requests with the given error, and passes the rest
+// on to h.
+func failFirst(n, code int, body string, h http.Handler) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fail := n > 0
+		n--
+		mu.Unlock()
+		if fail {
+			http.Error(w, body, code)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestGCPKMS(t *testing.T) {
+	_, priv := mustKey(t)
+	for _, test := range []struct {
+		name      string
+		algorithm string
+		failures  int
+		wantErr   bool
+	}{
+		{name: "ok", algorithm: "EC_SIGN_ED25519"},
+		{name: "wrong algorithm", algorithm: "EC_SIGN_P256_SHA256", wantErr: true},
+		{name: "unavailable, then ok", algorithm: "EC_SIGN_ED25519", failures: maxAttempts - 1},
+		{name: "unavailable", algorithm: "EC_SIGN_ED25519", failures: maxAttempts, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := fakeGCPKMS(t, priv, test.algorithm, test.failures)
+			s, err := newGCPKMS(context.Background(), srv.Client(), srv.URL+"/v1/", "example.com/log", testKeyVersion, Options{})
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("newGCPKMS: got err %v, want err %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			verifySigner(t, s)
+		})
+	}
+}
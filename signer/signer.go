@@ -0,0 +1,239 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer provides note.Signer implementations for checkpoint signing keys which are held
+// remotely, e.g. in a cloud KMS or an HSM, so that private keys never need to live on the
+// personality host.
+//
+// The signers created by this package can be passed to tessera.AppendOptions.WithCheckpointSigner.
+// Since a checkpoint is signed every checkpoint interval, each signer bounds the time spent on a
+// single signing call, and caches recent signatures so that re-signing an unchanged checkpoint
+// doesn't require a remote call.
+//
+// Only Ed25519 keys are supported, as these are the only keys with a standard note signature type.
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/mod/sumdb/note"
+)
+
+const (
+	// DefaultTimeout is the default maximum duration of a single signing call.
+	DefaultTimeout = 10 * time.Second
+	// DefaultCacheSize is the default number of recent signatures which are cached.
+	DefaultCacheSize = 16
+
+	// algEd25519 and algEd25519CosignatureV1 are the note signature types for Ed25519 keys, and Ed25519 keys
+	// producing timestamped c2sp.org/tlog-cosignature signatures.
+	algEd25519              = 1
+	algEd25519CosignatureV1 = 4
+)
+
+// SignFunc signs msg with an Ed25519 private key held elsewhere, returning the raw signature.
+type SignFunc func(ctx context.Context, msg []byte) ([]byte, error)
+
+// Options control the behaviour of a Signer.
+type Options struct {
+	// Timeout is the maximum duration of a single signing call. If zero, DefaultTimeout is used.
+	Timeout time.Duration
+	// CacheSize is the number of recent signatures to cache. If zero, DefaultCacheSize is used, and
+	// if negative, signatures are not cached.
+	CacheSize int
+}
+
+// Signer is a note.Signer which delegates signing to a SignFunc.
+//
+// Every signature returned by the SignFunc is verified against the public key before it is used,
+// so that a misconfigured key cannot result in invalid checkpoints being published.
+type Signer struct {
+	name      string
+	hash      uint32
+	pub       ed25519.PublicKey
+	verifier  string
+	sign      SignFunc
+	timeout   time.Duration
+	sigsCache *lru.Cache[[sha256.Size]byte, []byte]
+	// now is set if the signer produces cosignature/v1 signatures, and returns the time to include in them.
+	now func() time.Time
+}
+
+// New returns a Signer with the given note key name which uses sign to create signatures
+// which can be verified with pub.
+func New(name string, pub ed25519.PublicKey, sign SignFunc, opts Options) (*Signer, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key length %d", len(pub))
+	}
+	vkey, err := note.NewEd25519VerifierKey(name, pub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	// Parsing the verifier key checks that the name is valid.
+	if _, err := note.NewVerifier(vkey); err != nil {
+		return nil, fmt.Errorf("invalid key name %q: %v", name, err)
+	}
+	s := &Signer{
+		name:     name,
+		hash:     keyHash(name, algEd25519, pub),
+		pub:      pub,
+		verifier: vkey,
+		sign:     sign,
+		timeout:  opts.Timeout,
+	}
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+	size := opts.CacheSize
+	if size == 0 {
+		size = DefaultCacheSize
+	}
+	if size > 0 {
+		if s.sigsCache, err = lru.New[[sha256.Size]byte, []byte](size); err != nil {
+			return nil, fmt.Errorf("failed to create cache: %v", err)
+		}
+	}
+	return s, nil
+}
+
+// NewFromCryptoSigner returns a Signer which uses the provided crypto.Signer, which must hold an
+// Ed25519 key, to create signatures.
+//
+// This can be used with keys held in an HSM which are exposed via PKCS#11 by libraries such as
+// github.com/ThalesGroup/crypto11, or with any other remote key exposed as a crypto.Signer.
+//
+// Since crypto.Signer doesn't accept a context, a signing call which exceeds the timeout is
+// abandoned rather than cancelled.
+func NewFromCryptoSigner(name string, cs crypto.Signer, opts Options) (*Signer, error) {
+	pub, ok := cs.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T, only Ed25519 keys are supported", cs.Public())
+	}
+	sign := func(ctx context.Context, msg []byte) ([]byte, error) {
+		type result struct {
+			sig []byte
+			err error
+		}
+		c := make(chan result, 1)
+		go func() {
+			sig, err := cs.Sign(nil, msg, crypto.Hash(0))
+			c <- result{sig: sig, err: err}
+		}()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case r := <-c:
+			return r.sig, r.err
+		}
+	}
+	return New(name, pub, sign, opts)
+}
+
+// NewCosignatureV1 returns a Signer which uses the same key as s, but which produces timestamped
+// cosignature/v1 signatures as described by https://c2sp.org/tlog-cosignature. This allows a checkpoint
+// to carry a signed statement of the time at which it was signed.
+//
+// As required by that spec, the returned Signer has a different key hash to s. Its VerifierKey is the
+// same as that of s, and should be used with
+// github.com/transparency-dev/formats/note.NewVerifierForCosignatureV1.
+//
+// The returned Signer doesn't cache signatures, so that each one carries the time it was made.
+func NewCosignatureV1(s *Signer) *Signer {
+	c := *s
+	c.hash = keyHash(s.name, algEd25519CosignatureV1, s.pub)
+	c.sigsCache = nil
+	c.now = time.Now
+	return &c
+}
+
+// Name returns the name of the key.
+func (s *Signer) Name() string {
+	return s.name
+}
+
+// KeyHash returns the note key hash of the key.
+func (s *Signer) KeyHash() uint32 {
+	return s.hash
+}
+
+// VerifierKey returns the note verifier key string for the key, which clients can use to verify
+// checkpoints signed by it.
+//
+// For Signers returned by NewCosignatureV1, this is the verifier key of the underlying Ed25519 key.
+func (s *Signer) VerifierKey() string {
+	return s.verifier
+}
+
+// Sign returns a signature for msg.
+func (s *Signer) Sign(msg []byte) ([]byte, error) {
+	return s.SignContext(context.Background(), msg)
+}
+
+// SignContext returns a signature for msg, abandoning the attempt if ctx becomes done.
+//
+// The tessera Appender uses this in preference to Sign, so that signing is bound to the lifetime
+// of the request to publish a checkpoint.
+func (s *Signer) SignContext(ctx context.Context, msg []byte) ([]byte, error) {
+	h := sha256.Sum256(msg)
+	if s.sigsCache != nil {
+		if sig, ok := s.sigsCache.Get(h); ok {
+			return sig, nil
+		}
+	}
+
+	// Timestamped signatures are over a message which includes the time, and are prefixed with it.
+	var prefix []byte
+	if s.now != nil {
+		t := uint64(s.now().Unix())
+		msg = fmt.Appendf(nil, "cosignature/v1\ntime %d\n%s", t, msg)
+		prefix = binary.BigEndian.AppendUint64(nil, t)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	sig, err := s.sign(ctx, msg)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("signing with %q timed out after %v: %w", s.name, s.timeout, err)
+		}
+		return nil, fmt.Errorf("signing with %q failed: %w", s.name, err)
+	}
+	if !ed25519.Verify(s.pub, msg, sig) {
+		return nil, fmt.Errorf("signature from %q does not verify with its public key", s.name)
+	}
+
+	sig = append(prefix, sig...)
+	if s.sigsCache != nil {
+		s.sigsCache.Add(h, sig)
+	}
+	return sig, nil
+}
+
+// keyHash calculates the note key hash for an Ed25519 public key used with the given signature type.
+func keyHash(name string, alg byte, pub ed25519.PublicKey) uint32 {
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte("\n"))
+	h.Write([]byte{alg})
+	h.Write(pub)
+	return binary.BigEndian.Uint32(h.Sum(nil))
+}
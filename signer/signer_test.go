@@ -0,0 +1,188 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	f_note "github.com/transparency-dev/formats/note"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func mustKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return pub, priv
+}
+
+// verifySigner checks that a note signed by s verifies with its verifier key.
+func verifySigner(t *testing.T, s *Signer) {
+	t.Helper()
+	msg, err := note.Sign(&note.Note{Text: "example.com/log\n1\nAAAA\n"}, s)
+	if err != nil {
+		t.Fatalf("note.Sign: %v", err)
+	}
+	v, err := note.NewVerifier(s.VerifierKey())
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	if _, err := note.Open(msg, note.VerifierList(v)); err != nil {
+		t.Fatalf("note.Open: %v", err)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	pub, priv := mustKey(t)
+	_, otherPriv := mustKey(t)
+
+	for _, test := range []struct {
+		name      string
+		opts      Options
+		sign      SignFunc
+		wantErr   error
+		wantCalls int64
+	}{
+		{
+			name:      "cached",
+			sign:      func(_ context.Context, msg []byte) ([]byte, error) { return ed25519.Sign(priv, msg), nil },
+			wantCalls: 1,
+		}, {
+			name:      "cache disabled",
+			opts:      Options{CacheSize: -1},
+			sign:      func(_ context.Context, msg []byte) ([]byte, error) { return ed25519.Sign(priv, msg), nil },
+			wantCalls: 3,
+		}, {
+			name: "timeout",
+			opts: Options{Timeout: 10 * time.Millisecond},
+			sign: func(ctx context.Context, _ []byte) ([]byte, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantErr:   context.DeadlineExceeded,
+			wantCalls: 3,
+		}, {
+			name:      "wrong key",
+			sign:      func(_ context.Context, msg []byte) ([]byte, error) { return ed25519.Sign(otherPriv, msg), nil },
+			wantErr:   errors.New(""),
+			wantCalls: 3,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var calls atomic.Int64
+			s, err := New("example.com/log", pub, func(ctx context.Context, msg []byte) ([]byte, error) {
+				calls.Add(1)
+				return test.sign(ctx, msg)
+			}, test.opts)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			for range 3 {
+				sig, err := s.Sign([]byte("checkpoint"))
+				if gotErr := err != nil; gotErr != (test.wantErr != nil) {
+					t.Fatalf("Sign: got err %v, want err %v", err, test.wantErr)
+				}
+				if err != nil {
+					if errors.Is(test.wantErr, context.DeadlineExceeded) && !errors.Is(err, context.DeadlineExceeded) {
+						t.Fatalf("Sign: got err %v, want %v", err, test.wantErr)
+					}
+					continue
+				}
+				if !ed25519.Verify(pub, []byte("checkpoint"), sig) {
+					t.Fatal("signature does not verify")
+				}
+			}
+			if got := calls.Load(); got != test.wantCalls {
+				t.Errorf("got %d calls to SignFunc, want %d", got, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSignerNoteCompatible(t *testing.T) {
+	pub, priv := mustKey(t)
+	s, err := New("example.com/log", pub, func(_ context.Context, msg []byte) ([]byte, error) { return ed25519.Sign(priv, msg), nil }, Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	verifySigner(t, s)
+}
+
+func TestNewErrors(t *testing.T) {
+	pub, _ := mustKey(t)
+	for _, test := range []struct {
+		name    string
+		keyName string
+		pub     ed25519.PublicKey
+	}{
+		{name: "bad name", keyName: "bad+name", pub: pub},
+		{name: "bad key", keyName: "example.com/log", pub: pub[1:]},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New(test.keyName, test.pub, nil, Options{}); err == nil {
+				t.Error("New: got no error, want error")
+			}
+		})
+	}
+}
+
+func TestNewFromCryptoSigner(t *testing.T) {
+	_, priv := mustKey(t)
+	s, err := NewFromCryptoSigner("example.com/log", priv, Options{})
+	if err != nil {
+		t.Fatalf("NewFromCryptoSigner: %v", err)
+	}
+	verifySigner(t, s)
+}
+
+func TestNewCosignatureV1(t *testing.T) {
+	pub, priv := mustKey(t)
+	s, err := New("example.com/log", pub, func(_ context.Context, msg []byte) ([]byte, error) { return ed25519.Sign(priv, msg), nil }, Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := NewCosignatureV1(s)
+	if c.KeyHash() == s.KeyHash() {
+		t.Error("cosignature/v1 signer has the same key hash as the underlying signer")
+	}
+
+	msg, err := note.Sign(&note.Note{Text: "example.com/log\n1\nAAAA\n"}, c)
+	if err != nil {
+		t.Fatalf("note.Sign: %v", err)
+	}
+	v, err := f_note.NewVerifierForCosignatureV1(c.VerifierKey())
+	if err != nil {
+		t.Fatalf("NewVerifierForCosignatureV1: %v", err)
+	}
+	n, err := note.Open(msg, note.VerifierList(v))
+	if err != nil {
+		t.Fatalf("note.Open: %v", err)
+	}
+	ts, err := f_note.CoSigV1Timestamp(n.Sigs[0])
+	if err != nil {
+		t.Fatalf("CoSigV1Timestamp: %v", err)
+	}
+	if d := time.Since(ts); d < 0 || d > time.Minute {
+		t.Errorf("got timestamp %v, want around now", ts)
+	}
+}
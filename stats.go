@@ -0,0 +1,344 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultStatsSampleInterval is used by LogStats if StatsOptions.SampleInterval is not set.
+	DefaultStatsSampleInterval = time.Minute
+	// DefaultStatsRetention is used by LogStats if StatsOptions.Retention is not set.
+	DefaultStatsRetention = 24 * time.Hour
+	// DefaultStatsRateWindow is used by LogStats if StatsOptions.RateWindow is not set.
+	DefaultStatsRateWindow = time.Hour
+
+	// statsLatencySamples is the number of the most recent integration latencies used to calculate percentiles.
+	statsLatencySamples = 1024
+)
+
+// StatsOptions configures the statistics collected by a LogStats.
+type StatsOptions struct {
+	// SampleInterval is the minimum interval between the samples of the integrated tree size which are kept in
+	// its history. Defaults to DefaultStatsSampleInterval.
+	SampleInterval time.Duration
+	// Retention is how long samples of the integrated tree size are kept for. Defaults to DefaultStatsRetention.
+	Retention time.Duration
+	// RateWindow is the period over which the growth rate of the log is calculated. Defaults to
+	// DefaultStatsRateWindow.
+	RateWindow time.Duration
+	// Store, if set, durably stores the history of the integrated tree size, so that it survives restarts.
+	// Otherwise, the history is only held in memory.
+	Store StatsStore
+}
+
+// SizeSample records the size of the integrated tree at a point in time.
+type SizeSample struct {
+	Time time.Time `json:"time"`
+	Size uint64    `json:"size"`
+}
+
+// StatsStore durably stores the history of the integrated tree size collected by a LogStats.
+// See StatsOptions.Store.
+type StatsStore interface {
+	// ReadSizeHistory returns the samples most recently passed to WriteSizeHistory, or none if it has never been
+	// called.
+	ReadSizeHistory(ctx context.Context) ([]SizeSample, error)
+	// WriteSizeHistory durably stores the samples, replacing any stored previously.
+	WriteSizeHistory(ctx context.Context, samples []SizeSample) error
+}
+
+// StatsFile is a StatsStore which stores the history, as JSON, in the file at Path.
+type StatsFile struct {
+	Path string
+}
+
+func (f StatsFile) ReadSizeHistory(_ context.Context) ([]SizeSample, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var s []SizeSample
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("invalid history in %q: %v", f.Path, err)
+	}
+	return s, nil
+}
+
+func (f StatsFile) WriteSizeHistory(_ context.Context, samples []SizeSample) error {
+	b, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Ignore errors: the file is gone once it's been renamed.
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// LogStatistics is a snapshot of the statistics collected by a LogStats.
+type LogStatistics struct {
+	// Time is when the snapshot was taken, and Size the size of the integrated tree at that time.
+	Time time.Time `json:"time"`
+	Size uint64    `json:"size"`
+	// History holds samples of the integrated tree size, oldest first.
+	History []SizeSample `json:"history"`
+	// EntriesPerSecond is the rate at which the integrated tree grew over the StatsOptions.RateWindow, or the
+	// period covered by History if that's shorter.
+	EntriesPerSecond float64 `json:"entries_per_second"`
+	// Bundles describes how full the entry bundles of the integrated tree are.
+	Bundles BundleStatistics `json:"bundles"`
+	// Integration describes the time from entries being added to their being integrated.
+	Integration LatencyStatistics `json:"integration"`
+}
+
+// BundleStatistics describes how full the entry bundles of a tree are.
+type BundleStatistics struct {
+	// Full is the number of full entry bundles.
+	Full uint64 `json:"full"`
+	// PartialEntries is the number of entries in the partial entry bundle at the right edge of the tree, if
+	// there is one, and PartialFill the fraction of the bundle that they fill.
+	PartialEntries uint64  `json:"partial_entries"`
+	PartialFill    float64 `json:"partial_fill"`
+}
+
+// LatencyStatistics summarises a set of latencies.
+type LatencyStatistics struct {
+	Samples      int     `json:"samples"`
+	LatencyP50Ms float64 `json:"latency_p50_ms"`
+	LatencyP95Ms float64 `json:"latency_p95_ms"`
+	LatencyP99Ms float64 `json:"latency_p99_ms"`
+}
+
+// LogStats collects statistics about the growth of a log, for dashboards which can't use the metrics exported
+// via OpenTelemetry. Use AppendOptions.WithStats to have an Appender populate it, and Handler to serve them.
+type LogStats struct {
+	opts StatsOptions
+	now  func() time.Time
+
+	// sample holds the index and time of an entry whose integration latency is being measured. Like
+	// integrationStats, only one entry is sampled at a time.
+	sample atomic.Pointer[idxAt]
+
+	mu sync.Mutex
+	// history holds samples of the integrated tree size, oldest first.
+	history []SizeSample
+	// latest is the most recent size of the integrated tree seen, which may be newer than the last sample.
+	latest SizeSample
+	// latencies is a ring buffer of recent integration latencies, and next the position in it to write to.
+	latencies []time.Duration
+	next      int
+}
+
+// NewLogStats returns a LogStats configured by opts, with the history of the integrated tree size read from
+// opts.Store if it's set.
+func NewLogStats(ctx context.Context, opts StatsOptions) (*LogStats, error) {
+	if opts.SampleInterval <= 0 {
+		opts.SampleInterval = DefaultStatsSampleInterval
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultStatsRetention
+	}
+	if opts.RateWindow <= 0 {
+		opts.RateWindow = DefaultStatsRateWindow
+	}
+	s := &LogStats{opts: opts, now: time.Now}
+	if opts.Store != nil {
+		h, err := opts.Store.ReadSizeHistory(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read size history: %v", err)
+		}
+		s.history = h
+		if len(h) > 0 {
+			s.latest = h[len(h)-1]
+		}
+	}
+	return s, nil
+}
+
+// WithStats configures the Appender to record statistics about the log's growth in s.
+//
+// The Appender records the size of the integrated tree each time it grows, at most once per
+// StatsOptions.SampleInterval, and measures the time taken for a sample of the entries it adds to be integrated.
+func (o *AppendOptions) WithStats(s *LogStats) *AppendOptions {
+	o.addDecorators = append(o.addDecorators, s.decorator)
+	return o.WithOnIntegrate(func(ctx context.Context, _, to uint64) {
+		s.integrated(ctx, to)
+	})
+}
+
+// decorator samples the time at which entries were added, so that their integration latency can be measured.
+func (s *LogStats) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, entry *Entry) IndexFuture {
+		start := s.now()
+		f := delegate(ctx, entry)
+		return func() (Index, error) {
+			idx, err := f()
+			if err == nil && !idx.IsDup {
+				s.sample.CompareAndSwap(nil, &idxAt{idx: idx.Index, at: start})
+			}
+			return idx, err
+		}
+	}
+}
+
+// integrated records that the integrated tree has grown to size.
+func (s *LogStats) integrated(ctx context.Context, size uint64) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ia := s.sample.Load(); ia != nil && ia.idx < size {
+		s.sample.Store(nil)
+		if len(s.latencies) < statsLatencySamples {
+			s.latencies = append(s.latencies, now.Sub(ia.at))
+		} else {
+			s.latencies[s.next] = now.Sub(ia.at)
+		}
+		s.next = (s.next + 1) % statsLatencySamples
+	}
+
+	s.latest = SizeSample{Time: now, Size: size}
+	if len(s.history) > 0 && now.Sub(s.history[len(s.history)-1].Time) < s.opts.SampleInterval {
+		return
+	}
+	s.history = append(s.history, s.latest)
+	if i := slices.IndexFunc(s.history, func(h SizeSample) bool { return now.Sub(h.Time) <= s.opts.Retention }); i > 0 {
+		s.history = slices.Delete(s.history, 0, i)
+	}
+	if s.opts.Store != nil {
+		if err := s.opts.Store.WriteSizeHistory(ctx, s.history); err != nil {
+			klog.Warningf("LogStats: failed to store size history: %v", err)
+		}
+	}
+}
+
+// Snapshot returns the statistics collected so far, along with those derived from the current size of the
+// integrated tree in r.
+func (s *LogStats) Snapshot(ctx context.Context, r LogReader) (LogStatistics, error) {
+	size, err := r.IntegratedSize(ctx)
+	if err != nil {
+		return LogStatistics{}, fmt.Errorf("failed to read integrated size: %v", err)
+	}
+	tl := layout.DefaultTiling
+	if tr, ok := r.(TileHeightReader); ok {
+		if tl, err = client.FetchTiling(ctx, tr.ReadTileHeight); err != nil {
+			return LogStatistics{}, fmt.Errorf("failed to read tile height: %v", err)
+		}
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	history := slices.Clone(s.history)
+	latencies := slices.Clone(s.latencies)
+	s.mu.Unlock()
+
+	st := LogStatistics{
+		Time:        now,
+		Size:        size,
+		History:     history,
+		Bundles:     bundleStatistics(size, tl.Width()),
+		Integration: latencyStatistics(latencies),
+	}
+	if st.History == nil {
+		st.History = []SizeSample{}
+	}
+	// Measure growth from the most recent sample taken at least RateWindow ago, or the oldest if there isn't one.
+	if len(history) > 0 {
+		from := history[0]
+		for _, h := range history {
+			if now.Sub(h.Time) < s.opts.RateWindow {
+				break
+			}
+			from = h
+		}
+		if d := now.Sub(from.Time); d > 0 && size > from.Size {
+			st.EntriesPerSecond = float64(size-from.Size) / d.Seconds()
+		}
+	}
+	return st, nil
+}
+
+// Handler returns an http.Handler which serves a Snapshot of the statistics of the log read by r, as JSON.
+func (s *LogStats) Handler(r LogReader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		st, err := s.Snapshot(req.Context(), r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(st); err != nil {
+			klog.Warningf("LogStats: failed to write response: %v", err)
+		}
+	})
+}
+
+func bundleStatistics(size, width uint64) BundleStatistics {
+	b := BundleStatistics{Full: size / width}
+	if p := size % width; p > 0 {
+		b.PartialEntries = p
+		b.PartialFill = float64(p) / float64(width)
+	}
+	return b
+}
+
+func latencyStatistics(l []time.Duration) LatencyStatistics {
+	r := LatencyStatistics{Samples: len(l)}
+	if len(l) == 0 {
+		return r
+	}
+	slices.Sort(l)
+	p := func(q float64) float64 {
+		i := min(int(q*float64(len(l))), len(l)-1)
+		return float64(l[i]) / float64(time.Millisecond)
+	}
+	r.LatencyP50Ms, r.LatencyP95Ms, r.LatencyP99Ms = p(0.5), p(0.95), p(0.99)
+	return r
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogStats(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Unix(1700000000, 0).UTC()
+
+	// integration is a growth of the integrated tree to size, seen at offset from t0.
+	type integration struct {
+		offset time.Duration
+		size   uint64
+	}
+	for _, test := range []struct {
+		name         string
+		opts         StatsOptions
+		integrations []integration
+		// now is the offset from t0 at which the snapshot is taken, and size the integrated size at that point.
+		now         time.Duration
+		size        uint64
+		wantHistory []SizeSample
+		wantRate    float64
+		wantBundles BundleStatistics
+	}{
+		{
+			name:        "empty",
+			now:         time.Minute,
+			wantHistory: []SizeSample{},
+		}, {
+			name: "sampled at interval",
+			integrations: []integration{
+				{0, 100},
+				{30 * time.Second, 200},
+				{time.Minute, 300},
+				{90 * time.Second, 400},
+			},
+			now:  2 * time.Minute,
+			size: 600,
+			wantHistory: []SizeSample{
+				{Time: t0, Size: 100},
+				{Time: t0.Add(time.Minute), Size: 300},
+			},
+			// The history covers less than the rate window, so the rate is measured from the oldest sample.
+			wantRate:    500.0 / 120,
+			wantBundles: BundleStatistics{Full: 2, PartialEntries: 88, PartialFill: 88.0 / 256},
+		}, {
+			name: "rate window",
+			opts: StatsOptions{RateWindow: 2 * time.Minute},
+			integrations: []integration{
+				{0, 100},
+				{time.Minute, 1000},
+				{2 * time.Minute, 1100},
+			},
+			now:  3 * time.Minute,
+			size: 1024,
+			wantHistory: []SizeSample{
+				{Time: t0, Size: 100},
+				{Time: t0.Add(time.Minute), Size: 1000},
+				{Time: t0.Add(2 * time.Minute), Size: 1100},
+			},
+			wantRate:    24.0 / 120,
+			wantBundles: BundleStatistics{Full: 4},
+		}, {
+			name: "retention",
+			opts: StatsOptions{Retention: 90 * time.Second},
+			integrations: []integration{
+				{0, 100},
+				{time.Minute, 200},
+				{2 * time.Minute, 300},
+			},
+			now:  2 * time.Minute,
+			size: 300,
+			wantHistory: []SizeSample{
+				{Time: t0.Add(time.Minute), Size: 200},
+				{Time: t0.Add(2 * time.Minute), Size: 300},
+			},
+			wantRate:    100.0 / 60,
+			wantBundles: BundleStatistics{Full: 1, PartialEntries: 44, PartialFill: 44.0 / 256},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := NewLogStats(ctx, test.opts)
+			if err != nil {
+				t.Fatalf("NewLogStats: %v", err)
+			}
+			for _, i := range test.integrations {
+				s.now = func() time.Time { return t0.Add(i.offset) }
+				s.integrated(ctx, i.size)
+			}
+			s.now = func() time.Time { return t0.Add(test.now) }
+			got, err := s.Snapshot(ctx, &fakeLogReader{size: test.size})
+			if err != nil {
+				t.Fatalf("Snapshot: %v", err)
+			}
+			want := LogStatistics{
+				Time:             t0.Add(test.now),
+				Size:             test.size,
+				History:          test.wantHistory,
+				EntriesPerSecond: test.wantRate,
+				Bundles:          test.wantBundles,
+			}
+			if d := cmp.Diff(want, got); d != "" {
+				t.Errorf("Snapshot: diff (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestLogStatsIntegrationLatency(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLogStats(ctx, StatsOptions{})
+	if err != nil {
+		t.Fatalf("NewLogStats: %v", err)
+	}
+	var now time.Time
+	s.now = func() time.Time { return now }
+	var next uint64
+	add := s.decorator(func(_ context.Context, _ *Entry) IndexFuture {
+		i := next
+		next++
+		return func() (Index, error) { return Index{Index: i}, nil }
+	})
+
+	for i := range 100 {
+		// Entry 2i is sampled, and 2i+1 isn't since a sample is already held.
+		for range 2 {
+			if _, err := add(ctx, NewEntry(nil))(); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+		}
+		now = now.Add(time.Duration(i+1) * time.Millisecond)
+		s.integrated(ctx, next)
+	}
+
+	got, err := s.Snapshot(ctx, &fakeLogReader{size: next})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	want := LatencyStatistics{Samples: 100, LatencyP50Ms: 51, LatencyP95Ms: 96, LatencyP99Ms: 100}
+	if d := cmp.Diff(want, got.Integration); d != "" {
+		t.Errorf("Integration: diff (-want +got):\n%s", d)
+	}
+}
+
+func TestLogStatsStore(t *testing.T) {
+	ctx := context.Background()
+	store := StatsFile{Path: filepath.Join(t.TempDir(), "stats")}
+	t0 := time.Unix(1700000000, 0).UTC()
+
+	s, err := NewLogStats(ctx, StatsOptions{Store: store})
+	if err != nil {
+		t.Fatalf("NewLogStats: %v", err)
+	}
+	for i := range 3 {
+		s.now = func() time.Time { return t0.Add(time.Duration(i) * time.Hour) }
+		s.integrated(ctx, uint64(i+1)*10)
+	}
+
+	// A new LogStats should pick up the history where the last one left off.
+	s, err = NewLogStats(ctx, StatsOptions{Store: store})
+	if err != nil {
+		t.Fatalf("NewLogStats: %v", err)
+	}
+	s.now = func() time.Time { return t0.Add(3 * time.Hour) }
+	got, err := s.Snapshot(ctx, &fakeLogReader{size: 40})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	want := []SizeSample{
+		{Time: t0, Size: 10},
+		{Time: t0.Add(time.Hour), Size: 20},
+		{Time: t0.Add(2 * time.Hour), Size: 30},
+	}
+	if d := cmp.Diff(want, got.History); d != "" {
+		t.Errorf("History: diff (-want +got):\n%s", d)
+	}
+	if got, want := got.EntriesPerSecond, 10.0/3600; got != want {
+		t.Errorf("EntriesPerSecond: got %v, want %v", got, want)
+	}
+}
@@ -83,9 +83,9 @@ type AntispamStorage struct {
 	// When pushBack is true, the decorator will start returning a wrapped ErrPushback to all calls.
 	pushBack atomic.Bool
 
-	numLookups atomic.Uint64
-	numWrites  atomic.Uint64
-	numHits    atomic.Uint64
+	// counters records the lookups made by the decorator.
+	counters  tessera.DedupeCounters
+	numWrites atomic.Uint64
 }
 
 // NewAntispam returns an antispam driver which uses a MySQL table to maintain a mapping of
@@ -190,17 +190,30 @@ func (s *AntispamStorage) checkDataCompatibility(ctx context.Context) error {
 
 // index returns the index (if any) previously associated with the provided hash
 func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error) {
-	d.numLookups.Add(1)
+	start := time.Now()
 	row := d.dbPool.QueryRowContext(ctx, "SELECT idx FROM AntispamIDSeq WHERE h = ?", h)
 
 	var idx uint64
 	if err := row.Scan(&idx); err == sql.ErrNoRows {
+		d.counters.RecordLookup(time.Since(start), false)
 		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read antispam index: %v", err)
 	}
-	d.numHits.Add(1)
+	d.counters.RecordLookup(time.Since(start), true)
 	return &idx, nil
 }
 
+// DedupeStats returns statistics about the lookups made by the antispam decorator, along with the number of
+// log entries indexed by the follower.
+func (d *AntispamStorage) DedupeStats(ctx context.Context) (tessera.DedupeStatistics, error) {
+	n, err := (&follower{as: d}).EntriesProcessed(ctx)
+	if err != nil {
+		return tessera.DedupeStatistics{}, err
+	}
+	return d.counters.Statistics(n), nil
+}
+
 // Decorator returns a function which will wrap an underlying Add delegate with
 // code to dedup against the stored data.
 func (d *AntispamStorage) Decorator() func(f tessera.AddFn) tessera.AddFn {
@@ -328,8 +341,8 @@ func (f *follower) Follow(ctx context.Context, lr tessera.LogReader) {
 					sizeFn := func(_ context.Context) (uint64, error) {
 						return logSize, nil
 					}
-					numFetchers := uint(10)
-					next, stop = iter.Pull2(client.Entries(client.EntryBundles(ctx, numFetchers, sizeFn, lr.ReadEntryBundle, followFrom, logSize-followFrom), f.bundleHasher))
+					bundlesPerRead := uint(10)
+					next, stop = iter.Pull2(client.Entries(client.BulkEntryBundles(ctx, bundlesPerRead, sizeFn, lr.ReadEntryBundles, lr.ReadEntryBundle, followFrom, logSize-followFrom), f.bundleHasher))
 				}
 
 				bs := uint64(f.as.opts.MaxBatchSize)
@@ -32,6 +32,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto"
 	"database/sql"
 	"encoding/base64"
 	"encoding/gob"
@@ -43,6 +44,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -51,12 +53,16 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
 	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bundlecrypt"
+	"github.com/transparency-dev/tessera/internal/compress"
 	"github.com/transparency-dev/tessera/internal/fetcher"
 	"github.com/transparency-dev/tessera/internal/migrate"
+	"github.com/transparency-dev/tessera/internal/otel"
 	"github.com/transparency-dev/tessera/internal/parse"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"golang.org/x/sync/errgroup"
@@ -71,6 +77,8 @@ const (
 	logCacheControl       = "max-age=604800,immutable"
 	ckptCacheControl      = "no-cache"
 	minCheckpointInterval = time.Second
+	// maxBundleReads is the maximum number of entry bundles ReadEntryBundles will fetch in parallel.
+	maxBundleReads = 32
 
 	DefaultPushbackMaxOutstanding = 4096
 	DefaultIntegrationSizeLimit   = 5 * 4096
@@ -88,14 +96,24 @@ const (
 // Storage is an AWS based storage implementation for Tessera.
 type Storage struct {
 	cfg Config
+	// seq is set when the Appender lifecycle is created.
+	seq *mySQLSequencer
 }
 
 // objStore describes a type which can store and retrieve objects.
 type objStore interface {
 	getObject(ctx context.Context, obj string) ([]byte, error)
+	readObject(ctx context.Context, obj string) ([]byte, objMeta, error)
 	setObject(ctx context.Context, obj string, data []byte, contType string, cacheControl string) error
-	setObjectIfNoneMatch(ctx context.Context, obj string, data []byte, contType string, cacheControl string) error
+	setObjectIfNoneMatch(ctx context.Context, obj string, data []byte, contType string, contEnc string, cacheControl string) error
 	deleteObjectsWithPrefix(ctx context.Context, prefix string) error
+	deleteObject(ctx context.Context, obj string) error
+}
+
+// objMeta is the metadata with which an object was stored.
+type objMeta struct {
+	contType string
+	contEnc  string
 }
 
 // sequencer describes a type which knows how to sequence entries.
@@ -151,9 +169,38 @@ type Config struct {
 	MaxOpenConns int
 	// Maximum idle database connections in the connection pool.
 	MaxIdleConns int
+	// FailoverRetryTimeout is how long batches of entries which fail to be sequenced because the database is
+	// failing over to a new writer, e.g. when an Aurora cluster's writer is replaced, are retried for before
+	// the error is returned to the caller of Add. Batches are never sequenced twice, even if the connection is
+	// lost while they're being committed.
+	//
+	// If zero, DefaultFailoverRetryTimeout is used. If negative, batches are not retried.
+	// Batches sequenced with ExperimentalSequencingShards are not retried.
+	FailoverRetryTimeout time.Duration
+
+	// VerifyWritesFraction is the fraction of tiles and entry bundles which are read back after being written,
+	// and checked to have been stored intact before their integration is acknowledged. This defends against
+	// silent corruption by the object store, at the cost of additional integration latency and reads.
+	//
+	// If zero, writes are not verified. If 1 or more, every write is verified.
+	VerifyWritesFraction float64
 
 	// HTTPClient will be used for other HTTP requests. If unset, Tessera will use the net/http DefaultClient.
 	HTTPClient *http.Client
+
+	// ExperimentalSequencingShards, if greater than 1, enables sharded sequencing with this many shards.
+	//
+	// Entries are spread across the shards, each of which buffers and stores batches independently, and
+	// the pending shard batches are periodically merged into the log in a deterministic order. This avoids
+	// contention on the single row which tracks the next available index, at the cost of additional add
+	// latency while batches wait to be merged.
+	//
+	// Sharded sequencing cannot be used with preordered entries, or with entries whose bundle data depends
+	// on their index (e.g. WithCTLayout), and entries passed to AddBatch are only guaranteed to be contiguous
+	// if they fit within a single sequencing batch.
+	//
+	// EXPERIMENTAL: this is subject to change, or removal, and the stored format is not yet stable.
+	ExperimentalSequencingShards uint
 }
 
 // New creates a new instance of the AWS based Storage.
@@ -186,6 +233,14 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create MySQL sequencer: %v", err)
 	}
+	if err := seq.checkTreeHash(ctx, opts.HashFunction()); err != nil {
+		return nil, nil, err
+	}
+	seq.failoverTimeout = s.cfg.FailoverRetryTimeout
+	if seq.failoverTimeout == 0 {
+		seq.failoverTimeout = DefaultFailoverRetryTimeout
+	}
+	s.seq = seq
 
 	s3Store := &s3Storage{
 		s3Client:     s3.NewFromConfig(*s.cfg.SDKConfig, s.cfg.S3Options),
@@ -193,15 +248,39 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 		bucketPrefix: s.cfg.BucketPrefix,
 	}
 
-	a, lr, err := s.newAppender(ctx, s3Store, seq, opts)
+	a, lr, err := s.newAppender(ctx, withWriteVerification(s3Store, s.cfg.VerifyWritesFraction), seq, opts)
 	if err != nil {
 		return nil, nil, err
 	}
 	return &tessera.Appender{
-		Add: a.Add,
+		Add:      a.Add,
+		AddBatch: a.AddBatch,
+		GarbageCollect: func(ctx context.Context) error {
+			return a.garbageCollect(ctx, opts.RecordAudit)
+		},
 	}, lr, nil
 }
 
+// ReadLogState returns the lifecycle state of the log.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) ReadLogState(ctx context.Context) (tessera.LogState, error) {
+	if s.seq == nil {
+		return 0, errors.New("storage has not been initialised")
+	}
+	return s.seq.readLogState(ctx)
+}
+
+// WriteLogState stores the lifecycle state of the log.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) WriteLogState(ctx context.Context, state tessera.LogState) error {
+	if s.seq == nil {
+		return errors.New("storage has not been initialised")
+	}
+	return s.seq.writeLogState(ctx, state)
+}
+
 // newAppender creates and initialises an Appender struct with the provided underlying storage implementations.
 func (s *Storage) newAppender(ctx context.Context, o objStore, seq sequencer, opts *tessera.AppendOptions) (*Appender, tessera.LogReader, error) {
 	if opts.CheckpointInterval() < minCheckpointInterval {
@@ -209,12 +288,12 @@ func (s *Storage) newAppender(ctx context.Context, o objStore, seq sequencer, op
 	}
 
 	logStore := &logResourceStore{
-		objStore:    o,
-		entriesPath: opts.EntriesPath(),
-		integratedSize: func(context.Context) (uint64, error) {
-			s, _, err := seq.currentTree(ctx)
-			return s, err
-		},
+		objStore:       o,
+		entriesPath:    opts.EntriesPath(),
+		hasher:         opts.Hasher(),
+		bundleEncoding: opts.EntryBundleEncoding(),
+		bundleCipher:   bundlecrypt.New(opts.EntryBundleKeyProvider()),
+		currentTree:    seq.currentTree,
 		nextIndex: func(context.Context) (uint64, error) {
 			return seq.nextIndex(ctx)
 		},
@@ -223,10 +302,25 @@ func (s *Storage) newAppender(ctx context.Context, o objStore, seq sequencer, op
 	r := &Appender{
 		logStore:    logStore,
 		sequencer:   seq,
-		queue:       storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), seq.assignEntries),
 		newCP:       opts.CheckpointPublisher(logStore, s.cfg.HTTPClient),
 		treeUpdated: make(chan struct{}),
 	}
+	if n := s.cfg.ExperimentalSequencingShards; n > 1 {
+		ss, ok := seq.(shardedSequencer)
+		if !ok {
+			return nil, nil, errors.New("sequencer does not support sharded sequencing")
+		}
+		klog.Warningf("Using EXPERIMENTAL sharded sequencing with %d shards", n)
+		for shard := range n {
+			assign := func(ctx context.Context, entries []*tessera.Entry) error {
+				return ss.assignShardEntries(ctx, shard, entries)
+			}
+			r.shards = append(r.shards, storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.WithSequenceHook(storage.WithSpanLinks(assign, &r.sequenced), opts.SequenceHook())))
+		}
+		go r.mergeShardsJob(ctx, ss)
+	} else {
+		r.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.WithSequenceHook(storage.WithSpanLinks(seq.assignEntries, &r.sequenced), opts.SequenceHook()))
+	}
 
 	if err := r.init(ctx); err != nil {
 		return nil, nil, fmt.Errorf("failed to initialise log storage: %v", err)
@@ -236,10 +330,10 @@ func (s *Storage) newAppender(ctx context.Context, o objStore, seq sequencer, op
 	go r.integrateEntriesJob(ctx)
 
 	// Kick off go-routine which handles the publication of checkpoints.
-	go r.publishCheckpointJob(ctx, opts.CheckpointInterval())
+	go r.publishCheckpointJob(ctx, opts)
 
 	if i := opts.GarbageCollectionInterval(); i > 0 {
-		go r.garbageCollectorJob(ctx, i)
+		go r.garbageCollectorJob(ctx, i, opts.RecordAudit)
 	}
 
 	return r, r.logStore, nil
@@ -253,8 +347,15 @@ type Appender struct {
 	logStore  *logResourceStore
 
 	queue *storage.Queue
+	// shards, if set, are used instead of queue when sharded sequencing is enabled.
+	shards    []*storage.Queue
+	nextShard atomic.Uint64
 
 	treeUpdated chan struct{}
+
+	// sequenced and integrated link the spans of each asynchronous stage to those of the previous stage
+	// which processed the same entries.
+	sequenced, integrated storage.SpanLinks
 }
 
 // integrateEntriesJob periodically appends newly sequenced entries to the log.
@@ -271,6 +372,9 @@ func (a *Appender) integrateEntriesJob(ctx context.Context) {
 		}
 
 		func() {
+			ctx, span := tracer.Start(ctx, "tessera.storage.aws.integrateEntriesJob")
+			defer span.End()
+
 			// Don't quickloop for now, it causes issues updating checkpoint too frequently.
 			cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 			defer cancel()
@@ -288,11 +392,12 @@ func (a *Appender) integrateEntriesJob(ctx context.Context) {
 }
 
 // publishCheckpointJob periodically attempts to publish a new checkpoint representing the current state
-// of the tree, once per interval.
+// of the tree, once per interval, which is re-read after each attempt.
 //
 // This function does not return until the passed in context is done.
-func (a *Appender) publishCheckpointJob(ctx context.Context, interval time.Duration) {
-	t := time.NewTicker(interval)
+func (a *Appender) publishCheckpointJob(ctx context.Context, opts *tessera.AppendOptions) {
+	i := max(opts.CheckpointInterval(), minCheckpointInterval)
+	t := time.NewTicker(i)
 	defer t.Stop()
 	for {
 		select {
@@ -301,60 +406,91 @@ func (a *Appender) publishCheckpointJob(ctx context.Context, interval time.Durat
 		case <-a.treeUpdated:
 		case <-t.C:
 		}
-		if err := a.sequencer.publishCheckpoint(ctx, interval, a.publishCheckpoint); err != nil {
-			klog.Warningf("publishCheckpoint: %v", err)
+		// The interval may be changed while the log is running.
+		if n := max(opts.CheckpointInterval(), minCheckpointInterval); n != i {
+			i = n
+			t.Reset(i)
 		}
+		func() {
+			ctx, span := tracer.Start(ctx, "tessera.storage.aws.publishCheckpointJob")
+			defer span.End()
+			if err := a.sequencer.publishCheckpoint(ctx, storage.CheckpointMinStaleness(ctx, opts, minCheckpointInterval, a.logStore.ReadCheckpoint, a.sequencer.currentTree), a.publishCheckpoint); err != nil {
+				if errors.Is(err, tessera.ErrLogNotActive) {
+					// The log is frozen, so there's nothing more to publish.
+					return
+				}
+				klog.Warningf("publishCheckpoint: %v", err)
+			}
+		}()
 	}
 }
 
 // garbageCollectorJob is a long-running function which handles the removal of obsolete partial tiles
-// and entry bundles.
+// and entry bundles, recording each run with the provided audit function.
 // Blocks until ctx is done.
-func (a *Appender) garbageCollectorJob(ctx context.Context, i time.Duration) {
+func (a *Appender) garbageCollectorJob(ctx context.Context, i time.Duration, audit func(tessera.AuditOp, string, error)) {
 	t := time.NewTicker(i)
 	defer t.Stop()
 
-	// Entirely arbitrary number.
-	maxBundlesPerRun := uint(100)
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-t.C:
 		}
-		func() {
-			ctx, span := tracer.Start(ctx, "tessera.storage.aws.garbageCollectJob")
-			defer span.End()
+		if err := a.garbageCollect(ctx, audit); err != nil {
+			klog.Warningf("GarbageCollect failed: %v", err)
+		}
+	}
 
-			// Figure out the size of the latest published checkpoint - we can't be removing partial tiles implied by
-			// that checkpoint just because we've done an integration and know about a larger (but as yet unpublished)
-			// checkpoint!
-			cp, err := a.logStore.ReadCheckpoint(ctx)
-			if err != nil {
-				klog.Warningf("Failed to get published checkpoint: %v", err)
-				return
-			}
-			_, pubSize, _, err := parse.CheckpointUnsafe(cp)
-			if err != nil {
-				klog.Warningf("Failed to parse published checkpoint: %v", err)
-				return
-			}
+}
 
-			if err := a.sequencer.garbageCollect(ctx, pubSize, maxBundlesPerRun, a.logStore.objStore.deleteObjectsWithPrefix); err != nil {
-				klog.Warningf("GarbageCollect failed: %v", err)
-				return
-			}
-		}()
+// garbageCollect runs a single pass of the garbage collector, recording it with the provided audit function.
+func (a *Appender) garbageCollect(ctx context.Context, audit func(tessera.AuditOp, string, error)) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.garbageCollectJob")
+	defer span.End()
+
+	// Entirely arbitrary number.
+	maxBundlesPerRun := uint(100)
+
+	// Figure out the size of the latest published checkpoint - we can't be removing partial tiles implied by
+	// that checkpoint just because we've done an integration and know about a larger (but as yet unpublished)
+	// checkpoint!
+	cp, err := a.logStore.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get published checkpoint: %v", err)
+	}
+	_, pubSize, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return fmt.Errorf("failed to parse published checkpoint: %v", err)
 	}
 
+	err = a.sequencer.garbageCollect(ctx, pubSize, maxBundlesPerRun, a.logStore.objStore.deleteObjectsWithPrefix)
+	audit(tessera.AuditOpGarbageCollection, fmt.Sprintf("published size %d", pubSize), err)
+	return err
 }
 
 // Add is the entrypoint for adding entries to a sequencing log.
 func (a *Appender) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFuture {
+	if len(a.shards) > 0 {
+		return a.pickShard().Add(ctx, e)
+	}
 	return a.queue.Add(ctx, e)
 }
 
+// AddBatch is the entrypoint for adding a batch of entries to a sequencing log.
+func (a *Appender) AddBatch(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+	if len(a.shards) > 0 {
+		return a.pickShard().AddBatch(ctx, entries)
+	}
+	return a.queue.AddBatch(ctx, entries)
+}
+
+// pickShard returns the queue for the next sequencing shard, in round-robin order.
+func (a *Appender) pickShard() *storage.Queue {
+	return a.shards[(a.nextShard.Add(1)-1)%uint64(len(a.shards))]
+}
+
 // init ensures that the storage represents a log in a valid state.
 func (a *Appender) init(ctx context.Context) error {
 	_, err := a.logStore.ReadCheckpoint(ctx)
@@ -381,16 +517,21 @@ func (a *Appender) init(ctx context.Context) error {
 }
 
 func (a *Appender) publishCheckpoint(ctx context.Context, size uint64, root []byte) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.publishCheckpoint")
+	defer span.End()
+	span.SetAttributes(treeSizeKey.Int64(otel.Clamp64(size)))
+	a.integrated.Link(span, size)
+
 	cpRaw, err := a.newCP(ctx, size, root)
 	if err != nil {
-		return fmt.Errorf("newCP: %v", err)
+		return fmt.Errorf("newCP: %w", err)
 	}
 
 	if err := a.logStore.setCheckpoint(ctx, cpRaw); err != nil {
 		return fmt.Errorf("writeCheckpoint: %v", err)
 	}
 
-	klog.V(2).Infof("Published latest checkpoint: %d, %x", size, root)
+	klog.V(2).InfoS("Published latest checkpoint", "size", size, "root", fmt.Sprintf("%x", root))
 
 	return nil
 }
@@ -399,6 +540,12 @@ func (a *Appender) publishCheckpoint(ctx context.Context, size uint64, root []by
 //
 // Returns the new root hash of the log with the entries added.
 func (a *Appender) integrateEntries(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.integrateEntries")
+	defer span.End()
+	span.SetAttributes(fromSizeKey.Int64(otel.Clamp64(fromSeq)), numEntriesKey.Int(len(entries)))
+	newSize := fromSeq + uint64(len(entries))
+	a.sequenced.Link(span, newSize)
+
 	var newRoot []byte
 
 	errG := errgroup.Group{}
@@ -423,8 +570,11 @@ func (a *Appender) integrateEntries(ctx context.Context, fromSeq uint64, entries
 		return nil
 	})
 
-	err := errG.Wait()
-	return newRoot, err
+	if err := errG.Wait(); err != nil {
+		return nil, err
+	}
+	a.integrated.Add(ctx, newSize)
+	return newRoot, nil
 }
 
 // updateEntryBundles adds the entries being integrated into the entry bundles.
@@ -495,17 +645,23 @@ func (a *Appender) updateEntryBundles(ctx context.Context, fromSeq uint64, entri
 // MigrationWriter creates a new AWS storage for the MigrationWriter lifecycle mode.
 func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOptions) (migrate.MigrationWriter, tessera.LogReader, error) {
 	logStore := &logResourceStore{
-		objStore: &s3Storage{
+		objStore: withWriteVerification(&s3Storage{
 			s3Client:     s3.NewFromConfig(*s.cfg.SDKConfig, s.cfg.S3Options),
 			bucket:       s.cfg.Bucket,
 			bucketPrefix: s.cfg.BucketPrefix,
-		},
-		entriesPath: opts.EntriesPath(),
+		}, s.cfg.VerifyWritesFraction),
+		entriesPath:    opts.EntriesPath(),
+		hasher:         rfc6962.DefaultHasher,
+		bundleEncoding: opts.EntryBundleEncoding(),
+		bundleCipher:   bundlecrypt.New(opts.EntryBundleKeyProvider()),
 	}
 	seq, err := newMySQLSequencer(ctx, s.cfg.DSN, DefaultPushbackMaxOutstanding, s.cfg.MaxOpenConns, s.cfg.MaxIdleConns)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create MySQL sequencer: %v", err)
 	}
+	if err := seq.checkTreeHash(ctx, crypto.SHA256); err != nil {
+		return nil, nil, err
+	}
 	m := &MigrationStorage{
 		s:            s,
 		dbPool:       seq.dbPool,
@@ -665,10 +821,15 @@ func (m *MigrationStorage) buildTree(ctx context.Context, sourceSize uint64) (ui
 
 // logResourceStore knows how to read and write entries which represent a tiles log inside an objStore.
 type logResourceStore struct {
-	objStore       objStore
-	entriesPath    func(uint64, uint8) string
-	integratedSize func(context.Context) (uint64, error)
-	nextIndex      func(context.Context) (uint64, error)
+	objStore    objStore
+	entriesPath func(uint64, uint8) string
+	hasher      merkle.LogHasher
+	currentTree func(context.Context) (uint64, []byte, error)
+	nextIndex   func(context.Context) (uint64, error)
+	// bundleEncoding is the content encoding used to store entry bundles, or empty if they're stored as-is.
+	bundleEncoding string
+	// bundleCipher, if set, encrypts entry bundles at rest.
+	bundleCipher *bundlecrypt.Cipher
 }
 
 func (lr *logResourceStore) ReadCheckpoint(ctx context.Context) ([]byte, error) {
@@ -682,6 +843,43 @@ func (lr *logResourceStore) ReadCheckpoint(ctx context.Context) ([]byte, error)
 	return r, err
 }
 
+func (lr *logResourceStore) ReadArchivedCheckpoint(ctx context.Context, size uint64) ([]byte, error) {
+	return lr.getArchiveObject(ctx, layout.ArchivedCheckpointPath(size))
+}
+
+func (lr *logResourceStore) ReadCheckpointArchiveIndex(ctx context.Context) ([]byte, error) {
+	return lr.getArchiveObject(ctx, layout.CheckpointArchiveIndexPath)
+}
+
+// ArchiveCheckpoint adds the checkpoint with the given tree size to the log's archive of checkpoints.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithCheckpointArchive instead.
+func (lr *logResourceStore) ArchiveCheckpoint(ctx context.Context, size uint64, cp []byte, retain uint) error {
+	return storage.ArchiveCheckpoint(ctx, storage.CheckpointArchiveStore{
+		Get: lr.getArchiveObject,
+		Set: func(ctx context.Context, path string, data []byte, immutable bool) error {
+			cacheControl := ckptCacheControl
+			if immutable {
+				cacheControl = logCacheControl
+			}
+			return lr.objStore.setObject(ctx, path, data, ckptContType, cacheControl)
+		},
+		Delete: lr.objStore.deleteObject,
+	}, size, cp, retain)
+}
+
+// getArchiveObject returns the object in the checkpoint archive at the given path.
+func (lr *logResourceStore) getArchiveObject(ctx context.Context, path string) ([]byte, error) {
+	r, err := lr.get(ctx, path)
+	if err != nil {
+		var nske *types.NoSuchKey
+		if errors.As(err, &nske) {
+			return r, os.ErrNotExist
+		}
+	}
+	return r, err
+}
+
 func (lr *logResourceStore) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
 	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
 		return lr.get(ctx, layout.TilePath(l, i, p))
@@ -690,12 +888,46 @@ func (lr *logResourceStore) ReadTile(ctx context.Context, l, i uint64, p uint8)
 
 func (lr *logResourceStore) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]byte, error) {
 	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
-		return lr.get(ctx, lr.entriesPath(i, p))
+		return lr.getEntryBundle(ctx, i, p)
 	})
 }
 
+// ReadEntryBundles returns count full entry bundles, starting with the bundle at index fromBundle.
+//
+// The bundles are fetched from S3 in parallel.
+func (lr *logResourceStore) ReadEntryBundles(ctx context.Context, fromBundle, count uint64) ([][]byte, error) {
+	r := make([][]byte, count)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxBundleReads)
+	for i := range r {
+		eg.Go(func() error {
+			b, err := lr.getEntryBundle(ctx, fromBundle+uint64(i), 0)
+			r[i] = b
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 func (lr *logResourceStore) IntegratedSize(ctx context.Context) (uint64, error) {
-	return lr.integratedSize(ctx)
+	s, _, err := lr.currentTree(ctx)
+	return s, err
+}
+
+func (lr *logResourceStore) ReadInternalCheckpoint(ctx context.Context) (tessera.InternalCheckpoint, error) {
+	s, r, err := lr.currentTree(ctx)
+	return tessera.InternalCheckpoint{Size: s, Hash: r}, err
+}
+
+func (lr *logResourceStore) RootAt(ctx context.Context, size uint64) ([]byte, error) {
+	integrated, _, err := lr.currentTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.RootAt(ctx, size, integrated, lr.ReadTile, lr.hasher, layout.DefaultTiling)
 }
 
 func (lr *logResourceStore) NextIndex(ctx context.Context) (uint64, error) {
@@ -725,7 +957,7 @@ func (lrs *logResourceStore) setTile(ctx context.Context, level, index, logSize
 	tPath := layout.TilePath(level, index, layout.PartialTileSize(level, index, logSize))
 	klog.V(2).Infof("StoreTile: %s (%d entries)", tPath, len(tile.Nodes))
 
-	return lrs.objStore.setObjectIfNoneMatch(ctx, tPath, data, logContType, logCacheControl)
+	return lrs.objStore.setObjectIfNoneMatch(ctx, tPath, data, logContType, "", logCacheControl)
 }
 
 // getTiles returns the tiles with the given tile-coords for the specified log size.
@@ -770,7 +1002,7 @@ func (lrs *logResourceStore) getTiles(ctx context.Context, tileIDs []storage.Til
 // Returns a wrapped os.ErrNotExist if the bundle does not exist.
 func (lrs *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint64, p uint8) ([]byte, error) {
 	objName := lrs.entriesPath(bundleIndex, p)
-	data, err := lrs.objStore.getObject(ctx, objName)
+	data, meta, err := lrs.objStore.readObject(ctx, objName)
 	if err != nil {
 		// Do not use errors.Is. Keep errors.As to compare by type and not by value.
 		var nske *types.NoSuchKey
@@ -781,17 +1013,43 @@ func (lrs *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uin
 		}
 		return nil, err
 	}
+	enc := meta.contEnc
+	if meta.contType == bundlecrypt.ContentType {
+		if data, enc, err = lrs.bundleCipher.Open(ctx, objName, data); err != nil {
+			return nil, err
+		}
+	}
 
-	return data, nil
+	return compress.DecodeEntryBundle(enc, data)
 }
 
 // setEntryBundle idempotently stores the serialised entry bundle at the location implied by the bundleIndex and treeSize.
 func (lrs *logResourceStore) setEntryBundle(ctx context.Context, bundleIndex uint64, p uint8, bundleRaw []byte) error {
 	objName := lrs.entriesPath(bundleIndex, p)
+	data, err := compress.EncodeEntryBundle(lrs.bundleEncoding, bundleRaw)
+	if err != nil {
+		return err
+	}
+	contType, contEnc := logContType, lrs.bundleEncoding
+	if lrs.bundleCipher != nil {
+		// Encrypted bundles are opaque, so mustn't be served with a Content-Encoding. Their content type
+		// records that they're encrypted.
+		contType, contEnc = bundlecrypt.ContentType, ""
+		if data, err = lrs.bundleCipher.Seal(ctx, objName, lrs.bundleEncoding, data); err != nil {
+			return err
+		}
+	}
 	// Note that setObject does an idempotent interpretation of IfNoneMatch - it only
 	// returns an error if the named object exists _and_ contains different data to what's
 	// passed in here.
-	if err := lrs.objStore.setObjectIfNoneMatch(ctx, objName, bundleRaw, logContType, logCacheControl); err != nil {
+	if err := lrs.objStore.setObjectIfNoneMatch(ctx, objName, data, contType, contEnc, logCacheControl); err != nil {
+		// Encrypting the same bundle twice gives different ciphertexts, so compare what's already stored with
+		// what we're trying to write after decrypting it.
+		if lrs.bundleCipher != nil {
+			if existing, gErr := lrs.getEntryBundle(ctx, bundleIndex, p); gErr == nil && bytes.Equal(existing, bundleRaw) {
+				return nil
+			}
+		}
 		return fmt.Errorf("setObjectIfNoneMatch(%q): %v", objName, err)
 
 	}
@@ -808,7 +1066,7 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, lrs *logResourc
 		return n, nil
 	}
 
-	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh)
+	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh, lrs.hasher, layout.DefaultTiling)
 	if err != nil {
 		return nil, fmt.Errorf("storage.Integrate: %v", err)
 	}
@@ -823,7 +1081,7 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, lrs *logResourc
 	if err := errG.Wait(); err != nil {
 		return nil, err
 	}
-	klog.V(1).Infof("New tree: %d, %x", newSize, newRoot)
+	klog.V(1).InfoS("New tree", "size", newSize, "root", fmt.Sprintf("%x", newRoot))
 	return newRoot, nil
 }
 
@@ -832,6 +1090,8 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, lrs *logResourc
 type mySQLSequencer struct {
 	dbPool         *sql.DB
 	maxOutstanding uint64
+	// failoverTimeout is how long assignEntries retries batches which fail due to a failover, if positive.
+	failoverTimeout time.Duration
 }
 
 // newMySQLSequencer returns a new mysqlSequencer struct which uses the provided
@@ -900,6 +1160,18 @@ func (s *mySQLSequencer) checkDataCompatibility(ctx context.Context) error {
 //   - IntCoord
 //     This table coordinates integration of the batches of entries stored in
 //     Seq into the committed tree state.
+//   - LogState
+//     This table only ever contains a single row which holds the lifecycle
+//     state of the log.
+//   - TreeHash
+//     This table contains at most a single row which holds the name of the
+//     hash function used to build the log's Merkle tree.
+//   - ShardCoord
+//     This table tracks the next batch number for each shard when using
+//     experimental sharded sequencing.
+//   - ShardSeq
+//     This table holds batches of entries sequenced into a shard, until they
+//     have been merged into Seq.
 func (s *mySQLSequencer) initDB(ctx context.Context) error {
 	if _, err := s.dbPool.ExecContext(ctx,
 		`CREATE TABLE IF NOT EXISTS Tessera (
@@ -955,6 +1227,45 @@ func (s *mySQLSequencer) initDB(ctx context.Context) error {
 		return err
 	}
 
+	if _, err := s.dbPool.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS LogState(
+			id INT UNSIGNED NOT NULL,
+			state TINYINT UNSIGNED NOT NULL,
+			PRIMARY KEY (id)
+		)`); err != nil {
+		return err
+	}
+
+	if _, err := s.dbPool.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS TreeHash(
+			id INT UNSIGNED NOT NULL,
+			hash VARCHAR(32) NOT NULL,
+			PRIMARY KEY (id)
+		)`); err != nil {
+		return err
+	}
+
+	if _, err := s.dbPool.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS ShardCoord(
+			shard INT UNSIGNED NOT NULL,
+			next BIGINT UNSIGNED NOT NULL,
+			PRIMARY KEY (shard)
+		)`); err != nil {
+		return err
+	}
+
+	if _, err := s.dbPool.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS ShardSeq(
+			shard INT UNSIGNED NOT NULL,
+			batch BIGINT UNSIGNED NOT NULL,
+			num INT UNSIGNED NOT NULL,
+			v LONGBLOB,
+			seq BIGINT UNSIGNED,
+			PRIMARY KEY (shard, batch)
+		)`); err != nil {
+		return err
+	}
+
 	// Set default values for a newly initialised schema - these rows being present are a precondition for
 	// sequencing and integration to occur.
 	// Note that this will only succeed if no row exists, so there's no danger
@@ -979,6 +1290,65 @@ func (s *mySQLSequencer) initDB(ctx context.Context) error {
 		`INSERT IGNORE INTO GCCoord (id, fromSize) VALUES (0, 0)`); err != nil {
 		return err
 	}
+	if _, err := s.dbPool.ExecContext(ctx,
+		`INSERT IGNORE INTO LogState (id, state) VALUES (0, ?)`, tessera.LogStateActive); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkTreeHash checks that the log's Merkle tree was built using the hash function h.
+//
+// If no hash function has been recorded yet and the tree is empty, h is recorded as the one to use from now on.
+// Otherwise, a missing record means that the log was created before the hash function was recorded, and so
+// must be using SHA-256.
+func (s *mySQLSequencer) checkTreeHash(ctx context.Context, h crypto.Hash) error {
+	tx, err := s.dbPool.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin Tx: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			klog.Errorf("failed to rollback Tx: %v", err)
+		}
+	}()
+
+	var size uint64
+	if err := tx.QueryRowContext(ctx, "SELECT seq FROM IntCoord WHERE id = ? FOR UPDATE", 0).Scan(&size); err != nil {
+		return fmt.Errorf("failed to read IntCoord: %v", err)
+	}
+	var stored string
+	if err := tx.QueryRowContext(ctx, "SELECT hash FROM TreeHash WHERE id = ?", 0).Scan(&stored); err == sql.ErrNoRows {
+		if size > 0 {
+			return storage.CheckHashFunction("", h)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO TreeHash (id, hash) VALUES (0, ?)", h.String()); err != nil {
+			return fmt.Errorf("failed to write TreeHash: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE IntCoord SET rootHash=? WHERE id=?", rfc6962.New(h).EmptyRoot(), 0); err != nil {
+			return fmt.Errorf("failed to update IntCoord: %v", err)
+		}
+		return tx.Commit()
+	} else if err != nil {
+		return fmt.Errorf("failed to read TreeHash: %v", err)
+	}
+	return storage.CheckHashFunction(stored, h)
+}
+
+// readLogState returns the lifecycle state of the log from the LogState table.
+func (s *mySQLSequencer) readLogState(ctx context.Context) (tessera.LogState, error) {
+	var state tessera.LogState
+	if err := s.dbPool.QueryRowContext(ctx, "SELECT state FROM LogState WHERE id = ?", 0).Scan(&state); err != nil {
+		return 0, fmt.Errorf("failed to read LogState: %v", err)
+	}
+	return state, nil
+}
+
+// writeLogState stores the lifecycle state of the log in the LogState table.
+func (s *mySQLSequencer) writeLogState(ctx context.Context, state tessera.LogState) error {
+	if _, err := s.dbPool.ExecContext(ctx, "UPDATE LogState SET state=? WHERE id=?", state, 0); err != nil {
+		return fmt.Errorf("failed to update LogState: %v", err)
+	}
 	return nil
 }
 
@@ -987,21 +1357,52 @@ func (s *mySQLSequencer) initDB(ctx context.Context) error {
 // Entries are allocated contiguous indices, in the order in which they appear in the entries parameter.
 // This is achieved by storing the passed-in entries in the Seq table in MySQL, keyed by the
 // index assigned to the first entry in the batch.
+//
+// Batches which fail because the database is failing over to a new writer are retried for up to
+// failoverTimeout, taking care not to store a batch twice if the outcome of committing it is unknown.
+// If they still fail, the error returned wraps tessera.ErrStorageUnavailable.
 func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.Entry) error {
+	f := func(ctx context.Context) error {
+		return s.assignEntriesOnce(ctx, entries)
+	}
+	var err error
+	if s.failoverTimeout <= 0 {
+		err = f(ctx)
+	} else {
+		err = retryOnFailover(ctx, s.failoverTimeout, f, s.batchStored)
+	}
+	if isFailoverError(err) {
+		return fmt.Errorf("%w: %w", tessera.ErrStorageUnavailable, err)
+	}
+	return err
+}
+
+// assignEntriesOnce makes a single attempt to store the passed-in entries in the Seq table.
+//
+// Errors caused by a failover are wrapped so that isFailoverError can identify them, and a commitError is
+// returned if the outcome of committing the batch is unknown.
+func (s *mySQLSequencer) assignEntriesOnce(ctx context.Context, entries []*tessera.Entry) (err error) {
+	// Use a dedicated connection so that it can be discarded if it's to a writer which is no longer the writer.
+	conn, err := s.dbPool.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() { discardOnFailover(conn, err) }()
+
 	// First grab the treeSize in a non-locking read-only fashion (we don't want to block/collide with integration).
 	// We'll use this value to determine whether we need to apply back-pressure.
 	var treeSize uint64
-	row := s.dbPool.QueryRowContext(ctx, "SELECT seq FROM IntCoord WHERE id = ?", 0)
+	row := conn.QueryRowContext(ctx, "SELECT seq FROM IntCoord WHERE id = ?", 0)
 	if err := row.Scan(&treeSize); err == sql.ErrNoRows {
 		return nil
 	} else if err != nil {
-		return fmt.Errorf("failed to read integration coordination info: %v", err)
+		return fmt.Errorf("failed to read integration coordination info: %w", err)
 	}
 
 	// Now move on with sequencing in a single transaction
-	tx, err := s.dbPool.BeginTx(ctx, nil)
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin Tx: %v", err)
+		return fmt.Errorf("failed to begin Tx: %w", err)
 	}
 	defer func() {
 		if tx != nil {
@@ -1015,7 +1416,7 @@ func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.E
 	var next, id uint64
 	r := tx.QueryRowContext(ctx, "SELECT id, next FROM SeqCoord WHERE id = ? FOR UPDATE", 0)
 	if err := r.Scan(&id, &next); err != nil {
-		return fmt.Errorf("failed to read seqcoord: %v", err)
+		return fmt.Errorf("failed to read seqcoord: %w", err)
 	}
 
 	// Check whether there are too many outstanding entries and we should apply
@@ -1023,6 +1424,9 @@ func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.E
 	if outstanding := next - treeSize; outstanding > s.maxOutstanding {
 		return tessera.ErrPushback
 	}
+	if err := storage.CheckPreordered(next, entries); err != nil {
+		return err
+	}
 
 	sequencedEntries := make([]storage.SequencedEntry, len(entries))
 	// Assign provisional sequence numbers to entries.
@@ -1045,14 +1449,18 @@ func (s *mySQLSequencer) assignEntries(ctx context.Context, entries []*tessera.E
 
 	// Insert our newly sequenced batch of entries into Seq,
 	if _, err := tx.ExecContext(ctx, "INSERT INTO Seq(id, seq, v) VALUES(?, ?, ?)", 0, next, data); err != nil {
-		return fmt.Errorf("insert into seq: %v", err)
+		return fmt.Errorf("insert into seq: %w", err)
 	}
 	// and update the next-available sequence number row in SeqCoord.
 	if _, err := tx.ExecContext(ctx, "UPDATE SeqCoord SET next = ? WHERE ID = ?", next+num, 0); err != nil {
-		return fmt.Errorf("update seqcoord: %v", err)
+		return fmt.Errorf("update seqcoord: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
+		tx = nil
+		if isFailoverError(err) {
+			return commitError{seq: next, data: data, err: err}
+		}
 		return fmt.Errorf("failed to commit Tx: %v", err)
 	}
 	tx = nil
@@ -1325,6 +1733,12 @@ type s3Storage struct {
 
 // getObject returns the data of the specified object, or an error.
 func (s *s3Storage) getObject(ctx context.Context, obj string) ([]byte, error) {
+	d, _, err := s.readObject(ctx, obj)
+	return d, err
+}
+
+// readObject returns the data of the specified object and the metadata with which it was stored, or an error.
+func (s *s3Storage) readObject(ctx context.Context, obj string) ([]byte, objMeta, error) {
 	if s.bucketPrefix != "" {
 		obj = filepath.Join(s.bucketPrefix, obj)
 	}
@@ -1334,14 +1748,14 @@ func (s *s3Storage) getObject(ctx context.Context, obj string) ([]byte, error) {
 		Key:    aws.String(obj),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("getObject: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
+		return nil, objMeta{}, fmt.Errorf("getObject: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
 	}
 
 	d, err := io.ReadAll(r.Body)
 	if err != nil {
-		return nil, fmt.Errorf("getObject: failed to read %q: %v", obj, err)
+		return nil, objMeta{}, fmt.Errorf("getObject: failed to read %q: %v", obj, err)
 	}
-	return d, r.Body.Close()
+	return d, objMeta{contType: aws.ToString(r.ContentType), contEnc: aws.ToString(r.ContentEncoding)}, r.Body.Close()
 }
 
 // setObject stores the provided data in the specified object.
@@ -1370,7 +1784,7 @@ func (s *s3Storage) setObject(ctx context.Context, objName string, data []byte,
 // iff no object exists under this key already. If an object already exists under the same key,
 // an error will be returned *unless*  the currently stored data is bit-for-bit identical to the
 // data to-be-written. This is intended to provide idempotentency for writes.
-func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, data []byte, contType string, cacheControl string) error {
+func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, data []byte, contType string, contEnc string, cacheControl string) error {
 	if s.bucketPrefix != "" {
 		objName = filepath.Join(s.bucketPrefix, objName)
 	}
@@ -1384,6 +1798,9 @@ func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, da
 		// "*" is the expected character for this condition
 		IfNoneMatch: aws.String("*"),
 	}
+	if contEnc != "" {
+		put.ContentEncoding = aws.String(contEnc)
+	}
 
 	if _, err := s.s3Client.PutObject(ctx, put); err != nil {
 
@@ -1410,6 +1827,25 @@ func (s *s3Storage) setObjectIfNoneMatch(ctx context.Context, objName string, da
 	return nil
 }
 
+// deleteObject deletes the specified object.
+func (s *s3Storage) deleteObject(ctx context.Context, obj string) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.aws.deleteObject")
+	defer span.End()
+
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+	span.SetAttributes(objectPathKey.String(obj))
+
+	if _, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(obj),
+	}); err != nil {
+		return fmt.Errorf("failed to delete object %q in bucket %q: %v", obj, s.bucket, err)
+	}
+	return nil
+}
+
 // deleteObjectsWithPrefix removes any objects with the provided prefix from S3.
 func (s *s3Storage) deleteObjectsWithPrefix(ctx context.Context, objPrefix string) error {
 	ctx, span := tracer.Start(ctx, "tessera.storage.aws.deleteObject")
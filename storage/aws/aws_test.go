@@ -44,6 +44,7 @@ import (
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/fsck"
+	"github.com/transparency-dev/tessera/internal/bundlecrypt"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"golang.org/x/mod/sumdb/note"
 	"k8s.io/klog/v2"
@@ -107,7 +108,7 @@ func mustDropTables(t *testing.T, ctx context.Context) {
 		}
 	}()
 
-	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS `Seq`, `SeqCoord`, `IntCoord`, `PubCoord`, `GCCoord`"); err != nil {
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS `Seq`, `SeqCoord`, `IntCoord`, `PubCoord`, `GCCoord`, `TreeHash`, `ShardCoord`, `ShardSeq`"); err != nil {
 		t.Fatalf("failed to drop all tables: %v", err)
 	}
 }
@@ -372,6 +373,93 @@ func TestBundleRoundtrip(t *testing.T) {
 	}
 }
 
+func TestEncryptedEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	kp, err := tessera.NewAESKeyProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewAESKeyProvider: %v", err)
+	}
+	// This bundle starts with the same bytes as an encrypted one, but it's the content type with which a
+	// bundle is stored that determines whether it's decrypted.
+	bundle := []byte("\x00\x00tenc\x01")
+	for _, test := range []struct {
+		name     string
+		cipher   *bundlecrypt.Cipher
+		contType string
+	}{
+		{name: "unencrypted", contType: logContType},
+		{name: "encrypted", cipher: bundlecrypt.New(kp), contType: bundlecrypt.ContentType},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := newMemObjStore()
+			s := &logResourceStore{
+				objStore:     m,
+				entriesPath:  layout.EntriesPath,
+				bundleCipher: test.cipher,
+			}
+			if err := s.setEntryBundle(ctx, 0, 1, bundle); err != nil {
+				t.Fatalf("setEntryBundle: %v", err)
+			}
+			if got := m.meta[layout.EntriesPath(0, 1)].contType; got != test.contType {
+				t.Errorf("got content type %q, want %q", got, test.contType)
+			}
+			// Writing the same bundle again must succeed, even though it may encrypt differently.
+			if err := s.setEntryBundle(ctx, 0, 1, bundle); err != nil {
+				t.Errorf("setEntryBundle again: %v", err)
+			}
+			got, err := s.getEntryBundle(ctx, 0, 1)
+			if err != nil {
+				t.Fatalf("getEntryBundle: %v", err)
+			}
+			if !bytes.Equal(got, bundle) {
+				t.Errorf("getEntryBundle: got %q, want %q", got, bundle)
+			}
+		})
+	}
+}
+
+func TestReadEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	m := newMemObjStore()
+	s := &logResourceStore{
+		objStore:    m,
+		entriesPath: layout.EntriesPath,
+	}
+
+	const numBundles = 5
+	bundles := make([][]byte, numBundles)
+	for i := range bundles {
+		bundles[i] = makeBundle(t, uint64(i), 0)
+		if err := s.setEntryBundle(ctx, uint64(i), 0, bundles[i]); err != nil {
+			t.Fatalf("setEntryBundle(%d): %v", i, err)
+		}
+	}
+
+	for _, test := range []struct {
+		name        string
+		from, count uint64
+		wantErr     error
+	}{
+		{name: "all", from: 0, count: numBundles},
+		{name: "middle", from: 1, count: 3},
+		{name: "none", from: 2, count: 0},
+		{name: "beyond end", from: 3, count: 3, wantErr: os.ErrNotExist},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := s.ReadEntryBundles(ctx, test.from, test.count)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("ReadEntryBundles: got err %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+			if want := bundles[test.from : test.from+test.count]; !cmp.Equal(got, want) {
+				t.Fatalf("ReadEntryBundles returned different bundles")
+			}
+		})
+	}
+}
+
 func TestPublishTree(t *testing.T) {
 	ctx := context.Background()
 	if canSkipMySQLTest(t, ctx) {
@@ -573,12 +661,14 @@ func expectedPartialPrefixes(size uint64) []string {
 
 type memObjStore struct {
 	sync.RWMutex
-	mem map[string][]byte
+	mem  map[string][]byte
+	meta map[string]objMeta
 }
 
 func newMemObjStore() *memObjStore {
 	return &memObjStore{
-		mem: make(map[string][]byte),
+		mem:  make(map[string][]byte),
+		meta: make(map[string]objMeta),
 	}
 }
 
@@ -593,16 +683,22 @@ func (m *memObjStore) getObject(_ context.Context, obj string) ([]byte, error) {
 	return d, nil
 }
 
-// TODO(phboneff): add content type tests
-func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, _, _ string) error {
+func (m *memObjStore) readObject(ctx context.Context, obj string) ([]byte, objMeta, error) {
+	d, err := m.getObject(ctx, obj)
+	m.RLock()
+	defer m.RUnlock()
+	return d, m.meta[obj], err
+}
+
+func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, contType, _ string) error {
 	m.Lock()
 	defer m.Unlock()
 	m.mem[obj] = data
+	m.meta[obj] = objMeta{contType: contType}
 	return nil
 }
 
-// TODO(phboneff): add content type tests
-func (m *memObjStore) setObjectIfNoneMatch(_ context.Context, obj string, data []byte, _, _ string) error {
+func (m *memObjStore) setObjectIfNoneMatch(_ context.Context, obj string, data []byte, contType, contEnc, _ string) error {
 	m.Lock()
 	defer m.Unlock()
 
@@ -611,6 +707,16 @@ func (m *memObjStore) setObjectIfNoneMatch(_ context.Context, obj string, data [
 		return &smithy.GenericAPIError{Code: "PreconditionFailed"}
 	}
 	m.mem[obj] = data
+	m.meta[obj] = objMeta{contType: contType, contEnc: contEnc}
+	return nil
+}
+
+func (m *memObjStore) deleteObject(_ context.Context, obj string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.mem, obj)
+	delete(m.meta, obj)
 	return nil
 }
 
@@ -621,11 +727,43 @@ func (m *memObjStore) deleteObjectsWithPrefix(_ context.Context, prefix string)
 	for k := range m.mem {
 		if strings.HasPrefix(k, prefix) {
 			delete(m.mem, k)
+			delete(m.meta, k)
 		}
 	}
 	return nil
 }
 
+func TestCheckpointArchive(t *testing.T) {
+	ctx := context.Background()
+	lr := &logResourceStore{objStore: newMemObjStore()}
+
+	// Expiring the checkpoint with size 1 must not remove the one with size 10, despite sharing its prefix.
+	for _, size := range []uint64{1, 10, 100} {
+		if err := lr.ArchiveCheckpoint(ctx, size, fmt.Appendf(nil, "checkpoint %d", size), 2); err != nil {
+			t.Fatalf("ArchiveCheckpoint(%d): %v", size, err)
+		}
+	}
+	raw, err := lr.ReadCheckpointArchiveIndex(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpointArchiveIndex: %v", err)
+	}
+	idx := api.CheckpointArchiveIndex{}
+	if err := idx.UnmarshalText(raw); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if d := cmp.Diff([]uint64{10, 100}, idx.Sizes); d != "" {
+		t.Errorf("Archived sizes mismatch (-want +got):\n%s", d)
+	}
+	for _, size := range idx.Sizes {
+		if got, err := lr.ReadArchivedCheckpoint(ctx, size); err != nil || string(got) != fmt.Sprintf("checkpoint %d", size) {
+			t.Errorf("ReadArchivedCheckpoint(%d) = %q, %v", size, got, err)
+		}
+	}
+	if _, err := lr.ReadArchivedCheckpoint(ctx, 1); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadArchivedCheckpoint(1) of expired checkpoint: got err %v, want os.ErrNotExist", err)
+	}
+}
+
 func mustGenerateKeys(t *testing.T) (note.Signer, note.Verifier) {
 	sk, vk, err := note.GenerateKey(nil, "testlog")
 	if err != nil {
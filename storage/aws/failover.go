@@ -0,0 +1,170 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"k8s.io/klog/v2"
+)
+
+// DefaultFailoverRetryTimeout is used if Config.FailoverRetryTimeout is not set.
+const DefaultFailoverRetryTimeout = time.Minute
+
+const (
+	// MySQL error numbers returned by a writer which has been demoted, or is shutting down.
+	errOptionPreventsStatement = 1290 // e.g. --read-only
+	errReadOnlyTransaction     = 1792
+	errReadOnlyMode            = 1836
+	errServerShutdown          = 1053
+
+	minFailoverBackoff = 100 * time.Millisecond
+	maxFailoverBackoff = 5 * time.Second
+)
+
+// errUnknownOutcome is returned if a batch's commit may have succeeded, but it's no longer possible to tell.
+var errUnknownOutcome = errors.New("outcome of commit is unknown")
+
+// commitError is returned when committing a sequenced batch failed due to a failover, in which case the batch
+// may or may not have been stored.
+type commitError struct {
+	// seq is the index assigned to the first entry in the batch, and data the batch's value in the Seq table.
+	seq  uint64
+	data []byte
+	err  error
+}
+
+func (e commitError) Error() string {
+	return fmt.Sprintf("failed to commit Tx: %v", e.err)
+}
+
+func (e commitError) Unwrap() error {
+	return e.err
+}
+
+// isFailoverError returns true if err is one of those seen while an Aurora cluster fails over to a new writer:
+// the connection being reset or refused, or the old writer rejecting writes because it's become read-only.
+func isFailoverError(err error) bool {
+	var mErr *mysqldriver.MySQLError
+	if errors.As(err, &mErr) {
+		switch mErr.Number {
+		case errOptionPreventsStatement, errReadOnlyTransaction, errReadOnlyMode, errServerShutdown:
+			return true
+		}
+		return false
+	}
+	var nErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysqldriver.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		(errors.As(err, &nErr) && !nErr.Timeout())
+}
+
+// discardOnFailover closes c instead of returning it to the pool if err is a failover error, so that a
+// connection to a demoted writer isn't reused.
+func discardOnFailover(c *sql.Conn, err error) {
+	if isFailoverError(err) {
+		_ = c.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	if err := c.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+		klog.Warningf("failed to close connection: %v", err)
+	}
+}
+
+// retryOnFailover calls f until it succeeds, returns an error which isn't caused by a failover, or timeout has
+// passed.
+//
+// If f returns a commitError then the batch may already have been stored, so stored is used to check whether it
+// was before f is called again. This guards against the same batch being sequenced twice.
+func retryOnFailover(ctx context.Context, timeout time.Duration, f func(context.Context) error, stored func(ctx context.Context, seq uint64, data []byte) (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	backoff := minFailoverBackoff
+	// wait returns false if there's no time left to retry after err.
+	wait := func(err error) bool {
+		if !isFailoverError(err) || time.Now().Add(backoff).After(deadline) {
+			return false
+		}
+		klog.Warningf("Retrying sequencing in %v after failover error: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxFailoverBackoff)
+		return true
+	}
+
+	for {
+		err := f(ctx)
+		var cErr commitError
+		if !errors.As(err, &cErr) {
+			if err == nil || !wait(err) {
+				return err
+			}
+			continue
+		}
+		for {
+			ok, sErr := stored(ctx, cErr.seq, cErr.data)
+			if sErr == nil {
+				if ok {
+					return nil
+				}
+				break
+			}
+			if !wait(sErr) {
+				return fmt.Errorf("%v, and failed to check whether batch was stored: %v", err, sErr)
+			}
+		}
+		if !wait(err) {
+			return err
+		}
+	}
+}
+
+// batchStored returns true if the Seq table holds data as the batch starting at seq.
+//
+// If there's no batch at seq in the table, then false is returned if it hasn't yet been integrated, or
+// errUnknownOutcome if it has, since the batch that was integrated may or may not have been this one.
+func (s *mySQLSequencer) batchStored(ctx context.Context, seq uint64, data []byte) (bool, error) {
+	var v []byte
+	if err := s.dbPool.QueryRowContext(ctx, "SELECT v FROM Seq WHERE id = ? AND seq = ?", 0, seq).Scan(&v); err == nil {
+		return bytes.Equal(v, data), nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("failed to read seq: %w", err)
+	}
+	// Entries are removed from Seq in the same transaction as IntCoord is updated to show that they've been
+	// integrated, so reading it after Seq tells us whether the batch could have been removed.
+	var treeSize uint64
+	if err := s.dbPool.QueryRowContext(ctx, "SELECT seq FROM IntCoord WHERE id = ?", 0).Scan(&treeSize); err != nil {
+		return false, fmt.Errorf("failed to read integration coordination info: %w", err)
+	}
+	if treeSize > seq {
+		return false, errUnknownOutcome
+	}
+	return false, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/transparency-dev/tessera"
+)
+
+func TestIsFailoverError(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "read-only", err: fmt.Errorf("insert into seq: %w", &mysqldriver.MySQLError{Number: errOptionPreventsStatement}), want: true},
+		{name: "read-only mode", err: &mysqldriver.MySQLError{Number: errReadOnlyMode}, want: true},
+		{name: "shutdown", err: &mysqldriver.MySQLError{Number: errServerShutdown}, want: true},
+		{name: "bad conn", err: fmt.Errorf("failed to begin Tx: %w", driver.ErrBadConn), want: true},
+		{name: "invalid conn", err: mysqldriver.ErrInvalidConn, want: true},
+		{name: "reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: true},
+		{name: "commit", err: commitError{err: mysqldriver.ErrInvalidConn}, want: true},
+		{name: "duplicate key", err: &mysqldriver.MySQLError{Number: 1062}},
+		{name: "pushback", err: tessera.ErrPushback},
+		{name: "deadline", err: context.DeadlineExceeded},
+		{name: "other", err: errors.New("boom")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isFailoverError(test.err); got != test.want {
+				t.Errorf("isFailoverError(%v) = %t, want %t", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryOnFailover(t *testing.T) {
+	errReadOnly := fmt.Errorf("insert into seq: %w", &mysqldriver.MySQLError{Number: errOptionPreventsStatement})
+	errOther := errors.New("boom")
+	errCommit := commitError{seq: 10, data: []byte("batch"), err: mysqldriver.ErrInvalidConn}
+	// stored is the result of a call to check whether a batch was stored.
+	type stored struct {
+		ok  bool
+		err error
+	}
+	for _, test := range []struct {
+		name    string
+		timeout time.Duration
+		// attempts are the results of successive attempts to sequence the batch, and checks those of successive
+		// checks of whether it was stored.
+		attempts     []error
+		checks       []stored
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "success",
+			attempts:     []error{nil},
+			wantAttempts: 1,
+		}, {
+			name:         "not failover",
+			attempts:     []error{errOther},
+			wantErr:      true,
+			wantAttempts: 1,
+		}, {
+			name:         "retried",
+			attempts:     []error{errReadOnly, driver.ErrBadConn, nil},
+			wantAttempts: 3,
+		}, {
+			name:         "timeout",
+			timeout:      150 * time.Millisecond,
+			attempts:     []error{errReadOnly, errReadOnly, errReadOnly},
+			wantErr:      true,
+			wantAttempts: 2,
+		}, {
+			name:         "commit succeeded",
+			attempts:     []error{errCommit},
+			checks:       []stored{{ok: true}},
+			wantAttempts: 1,
+		}, {
+			name:         "commit failed",
+			attempts:     []error{errCommit, nil},
+			checks:       []stored{{ok: false}},
+			wantAttempts: 2,
+		}, {
+			name:         "check retried",
+			attempts:     []error{errCommit},
+			checks:       []stored{{err: driver.ErrBadConn}, {ok: true}},
+			wantAttempts: 1,
+		}, {
+			name:         "commit unknown",
+			attempts:     []error{errCommit},
+			checks:       []stored{{err: errUnknownOutcome}},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			timeout := test.timeout
+			if timeout == 0 {
+				timeout = time.Minute
+			}
+			attempts, checks := 0, 0
+			err := retryOnFailover(t.Context(), timeout, func(context.Context) error {
+				attempts++
+				return test.attempts[attempts-1]
+			}, func(_ context.Context, seq uint64, data []byte) (bool, error) {
+				if seq != errCommit.seq || string(data) != string(errCommit.data) {
+					t.Errorf("stored called with (%d, %q), want (%d, %q)", seq, data, errCommit.seq, errCommit.data)
+				}
+				checks++
+				return test.checks[checks-1].ok, test.checks[checks-1].err
+			})
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("retryOnFailover: %v, want error %t", err, test.wantErr)
+			}
+			if attempts != test.wantAttempts {
+				t.Errorf("got %d attempts, want %d", attempts, test.wantAttempts)
+			}
+			if checks != len(test.checks) {
+				t.Errorf("got %d checks, want %d", checks, len(test.checks))
+			}
+		})
+	}
+}
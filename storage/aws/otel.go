@@ -27,4 +27,7 @@ var (
 
 var (
 	objectPathKey = attribute.Key("tessera.objectPath")
+	treeSizeKey   = attribute.Key("tessera.treeSize")
+	fromSizeKey   = attribute.Key("tessera.fromSize")
+	numEntriesKey = attribute.Key("tessera.numEntries")
 )
@@ -0,0 +1,264 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// shardMergeInterval is how often pending shard batches are merged into the log.
+	shardMergeInterval = 100 * time.Millisecond
+	// shardPollInterval is how often a shard checks whether its pending batch has been merged.
+	shardPollInterval = 25 * time.Millisecond
+	// shardMergeLimit is the maximum number of shard batches which will be merged in a single transaction.
+	shardMergeLimit = 1024
+)
+
+// shardedSequencer describes a type which can sequence entries into a number of independent shards, and
+// later merge them into the log.
+type shardedSequencer interface {
+	// assignShardEntries durably stores the provided entries in the given shard, and waits until they have
+	// been merged into the log before assigning each of them its index.
+	assignShardEntries(ctx context.Context, shard uint, entries []*tessera.Entry) error
+	// mergeShards allocates contiguous indices in the log to pending shard batches, in a deterministic order.
+	// Returns true if any batches were merged.
+	mergeShards(ctx context.Context) (bool, error)
+}
+
+// mergeShardsJob periodically merges pending shard batches into the log.
+//
+// This function does not return until the passed context is done.
+func (a *Appender) mergeShardsJob(ctx context.Context, s shardedSequencer) {
+	t := time.NewTicker(shardMergeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		func() {
+			cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+
+			if _, err := s.mergeShards(cctx); err != nil {
+				klog.Errorf("mergeShards: %v", err)
+			}
+		}()
+	}
+}
+
+// assignShardEntries stores the passed-in entries as a single batch in the ShardSeq table, and then waits for
+// the batch to be merged into the log.
+//
+// Only the row in ShardCoord for the given shard is locked while storing the batch, so shards do not contend
+// with one another, or with the merge process, for the SeqCoord row.
+func (s *mySQLSequencer) assignShardEntries(ctx context.Context, shard uint, entries []*tessera.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, e := range entries {
+		if e.PreorderedIndex() != nil {
+			return errors.New("preordered entries cannot be used with sharded sequencing")
+		}
+	}
+	// The entry bundle data is serialised before the entries' indices are known, so we can only support
+	// formats where the serialisation doesn't depend on the index.
+	if !bytes.Equal(entries[0].MarshalBundleData(0), entries[0].MarshalBundleData(1)) {
+		return errors.New("sharded sequencing cannot be used with entries whose bundle data depends on their index")
+	}
+
+	// Apply back-pressure using non-locking reads, as assignEntries does.
+	var treeSize, next uint64
+	if err := s.dbPool.QueryRowContext(ctx, "SELECT seq FROM IntCoord WHERE id = ?", 0).Scan(&treeSize); err != nil {
+		return fmt.Errorf("failed to read integration coordination info: %v", err)
+	}
+	if err := s.dbPool.QueryRowContext(ctx, "SELECT next FROM SeqCoord WHERE id = ?", 0).Scan(&next); err != nil {
+		return fmt.Errorf("failed to read seqcoord: %v", err)
+	}
+	if outstanding := next - treeSize; outstanding > s.maxOutstanding {
+		return tessera.ErrPushback
+	}
+
+	sequencedEntries := make([]storage.SequencedEntry, len(entries))
+	for i, e := range entries {
+		sequencedEntries[i] = storage.SequencedEntry{
+			BundleData: e.MarshalBundleData(0),
+			LeafHash:   e.LeafHash(),
+		}
+	}
+	b := &bytes.Buffer{}
+	if err := gob.NewEncoder(b).Encode(sequencedEntries); err != nil {
+		return fmt.Errorf("failed to serialise batch: %v", err)
+	}
+
+	batch, err := s.insertShardBatch(ctx, shard, uint64(len(entries)), b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	base, err := s.awaitShardMerge(ctx, shard, batch)
+	if err != nil {
+		return err
+	}
+	if _, err := s.dbPool.ExecContext(ctx, "DELETE FROM ShardSeq WHERE shard = ? AND batch = ?", shard, batch); err != nil {
+		// This is only tidying up, the entries are safely in the log.
+		klog.Warningf("failed to delete merged shard batch %d/%d: %v", shard, batch, err)
+	}
+	for i, e := range entries {
+		e.MarshalBundleData(base + uint64(i))
+	}
+	return nil
+}
+
+// insertShardBatch stores a serialised batch of num entries in the given shard, and returns the batch number
+// it was assigned.
+func (s *mySQLSequencer) insertShardBatch(ctx context.Context, shard uint, num uint64, data []byte) (uint64, error) {
+	tx, err := s.dbPool.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin Tx: %v", err)
+	}
+	defer func() {
+		if tx != nil {
+			if err := tx.Rollback(); err != nil {
+				klog.Errorf("failed to rollback Tx: %v", err)
+			}
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO ShardCoord (shard, next) VALUES (?, 0)", shard); err != nil {
+		return 0, fmt.Errorf("failed to init shardcoord: %v", err)
+	}
+	var batch uint64
+	if err := tx.QueryRowContext(ctx, "SELECT next FROM ShardCoord WHERE shard = ? FOR UPDATE", shard).Scan(&batch); err != nil {
+		return 0, fmt.Errorf("failed to read shardcoord: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO ShardSeq (shard, batch, num, v) VALUES (?, ?, ?, ?)", shard, batch, num, data); err != nil {
+		return 0, fmt.Errorf("insert into shardseq: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE ShardCoord SET next = ? WHERE shard = ?", batch+1, shard); err != nil {
+		return 0, fmt.Errorf("update shardcoord: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit Tx: %v", err)
+	}
+	tx = nil
+	return batch, nil
+}
+
+// awaitShardMerge waits until the given shard batch has been merged into the log, and returns the index
+// assigned to its first entry.
+//
+// If ctx becomes done before then, the batch will still be merged into the log, but an error is returned.
+func (s *mySQLSequencer) awaitShardMerge(ctx context.Context, shard uint, batch uint64) (uint64, error) {
+	t := time.NewTicker(shardPollInterval)
+	defer t.Stop()
+	for {
+		var seq sql.NullInt64
+		if err := s.dbPool.QueryRowContext(ctx, "SELECT seq FROM ShardSeq WHERE shard = ? AND batch = ?", shard, batch).Scan(&seq); err != nil {
+			return 0, fmt.Errorf("failed to read shardseq: %v", err)
+		}
+		if seq.Valid {
+			return uint64(seq.Int64), nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// mergeShards moves pending batches from the ShardSeq table into the Seq table, allocating them contiguous
+// indices in order of their batch number and then shard.
+//
+// The SeqCoord row is updated once per call, regardless of how many shards or batches are merged.
+func (s *mySQLSequencer) mergeShards(ctx context.Context) (bool, error) {
+	tx, err := s.dbPool.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin Tx: %v", err)
+	}
+	defer func() {
+		if tx != nil {
+			if err := tx.Rollback(); err != nil {
+				klog.Errorf("failed to rollback Tx: %v", err)
+			}
+		}
+	}()
+
+	var next uint64
+	if err := tx.QueryRowContext(ctx, "SELECT next FROM SeqCoord WHERE id = ? FOR UPDATE", 0).Scan(&next); err != nil {
+		return false, fmt.Errorf("failed to read seqcoord: %v", err)
+	}
+
+	type shardBatch struct {
+		shard, batch, num uint64
+		v                 []byte
+	}
+	var pending []shardBatch
+	if err := func() error {
+		rows, err := tx.QueryContext(ctx, "SELECT shard, batch, num, v FROM ShardSeq WHERE seq IS NULL ORDER BY batch, shard LIMIT ? FOR UPDATE", shardMergeLimit)
+		if err != nil {
+			return fmt.Errorf("failed to read ShardSeq: %v", err)
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				klog.Warningf("rows.Close: %v", err)
+			}
+		}()
+		for rows.Next() {
+			var b shardBatch
+			if err := rows.Scan(&b.shard, &b.batch, &b.num, &b.v); err != nil {
+				return fmt.Errorf("failed to scan ShardSeq row: %v", err)
+			}
+			pending = append(pending, b)
+		}
+		return rows.Err()
+	}(); err != nil {
+		return false, err
+	}
+	if len(pending) == 0 {
+		return false, nil
+	}
+
+	for _, b := range pending {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO Seq(id, seq, v) VALUES(?, ?, ?)", 0, next, b.v); err != nil {
+			return false, fmt.Errorf("insert into seq: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE ShardSeq SET seq = ?, v = NULL WHERE shard = ? AND batch = ?", next, b.shard, b.batch); err != nil {
+			return false, fmt.Errorf("update shardseq: %v", err)
+		}
+		next += b.num
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE SeqCoord SET next = ? WHERE ID = ?", next, 0); err != nil {
+		return false, fmt.Errorf("update seqcoord: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit Tx: %v", err)
+	}
+	tx = nil
+	return true, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+func TestMySQLSequencerShards(t *testing.T) {
+	ctx := context.Background()
+	if canSkipMySQLTest(t, ctx) {
+		klog.Warningf("MySQL not available, skipping %s", t.Name())
+		t.Skip("MySQL not available, skipping test")
+	}
+	// Clean tables in case there's already something in there.
+	mustDropTables(t, ctx)
+
+	s, err := newMySQLSequencer(ctx, *mySQLURI, 100000, 0, 0)
+	if err != nil {
+		t.Fatalf("newMySQLSequencer: %v", err)
+	}
+
+	const numShards, batchesPerShard, batchSize = 4, 5, 10
+	mctx, cancel := context.WithCancel(ctx)
+	merged := make(chan struct{})
+	defer func() {
+		cancel()
+		<-merged
+	}()
+	go func() {
+		defer close(merged)
+		for mctx.Err() == nil {
+			if _, err := s.mergeShards(mctx); err != nil && mctx.Err() == nil {
+				t.Errorf("mergeShards: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	eg := errgroup.Group{}
+	all := make([][]*tessera.Entry, numShards)
+	for shard := range uint(numShards) {
+		eg.Go(func() error {
+			for b := range batchesPerShard {
+				entries := make([]*tessera.Entry, 0, batchSize)
+				for i := range batchSize {
+					entries = append(entries, tessera.NewEntry(fmt.Appendf(nil, "shard %d batch %d item %d", shard, b, i)))
+				}
+				if err := s.assignShardEntries(ctx, shard, entries); err != nil {
+					return err
+				}
+				all[shard] = append(all[shard], entries...)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("assignShardEntries: %v", err)
+	}
+
+	// Every entry should have been assigned a distinct index, and together they should form a contiguous range.
+	const total = numShards * batchesPerShard * batchSize
+	byIndex := make(map[uint64]*tessera.Entry)
+	for _, entries := range all {
+		for _, e := range entries {
+			if e.Index() == nil {
+				t.Fatalf("entry %q was not assigned an index", e.Data())
+			}
+			if o, ok := byIndex[*e.Index()]; ok {
+				t.Fatalf("entries %q and %q were both assigned index %d", o.Data(), e.Data(), *e.Index())
+			}
+			byIndex[*e.Index()] = e
+		}
+	}
+	for i := range uint64(total) {
+		if _, ok := byIndex[i]; !ok {
+			t.Fatalf("no entry was assigned index %d", i)
+		}
+	}
+	if got, err := s.nextIndex(ctx); err != nil || got != total {
+		t.Errorf("nextIndex() = %d, %v, want %d", got, err, total)
+	}
+
+	// The merged entries should be integrated in index order.
+	seen := uint64(0)
+	f := func(_ context.Context, fromSeq uint64, entries []storage.SequencedEntry) ([]byte, error) {
+		for i, e := range entries {
+			if got, want := string(e.LeafHash), string(byIndex[fromSeq+uint64(i)].LeafHash()); got != want {
+				return nil, fmt.Errorf("entry at %d has unexpected leaf hash", fromSeq+uint64(i))
+			}
+			seen++
+		}
+		return []byte("newroot"), nil
+	}
+	if _, err := s.consumeEntries(ctx, total, f, false); err != nil {
+		t.Fatalf("consumeEntries: %v", err)
+	}
+	if seen != total {
+		t.Errorf("consumed %d entries, want %d", seen, total)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+
+	storage "github.com/transparency-dev/tessera/storage/internal"
+)
+
+// verifyingObjStore is an objStore which re-reads a sample of the tiles and entry bundles written through it,
+// to check that the object store stored them intact, before the write is considered to have succeeded.
+//
+// Only writes made with setObjectIfNoneMatch, i.e. of immutable resources, are verified.
+type verifyingObjStore struct {
+	objStore
+	v *storage.WriteVerifier
+}
+
+// withWriteVerification returns o, wrapped so as to verify the given fraction of writes of tiles and entry
+// bundles if fraction is positive.
+func withWriteVerification(o objStore, fraction float64) objStore {
+	v := storage.NewWriteVerifier(fraction)
+	if v == nil {
+		return o
+	}
+	return &verifyingObjStore{objStore: o, v: v}
+}
+
+func (s *verifyingObjStore) setObjectIfNoneMatch(ctx context.Context, obj string, data []byte, contType string, contEnc string, cacheControl string) error {
+	if err := s.objStore.setObjectIfNoneMatch(ctx, obj, data, contType, contEnc, cacheControl); err != nil {
+		return err
+	}
+	return s.v.Verify(ctx, obj, data, func(ctx context.Context) ([]byte, error) {
+		return s.getObject(ctx, obj)
+	})
+}
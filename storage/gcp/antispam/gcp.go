@@ -63,6 +63,15 @@ type AntispamOpts struct {
 	// When the antispam follower is at least this many entries behind the size of the locally integrated tree,
 	// the antispam decorator will return a wrapped tessera.ErrPushback for every Add request.
 	PushbackThreshold uint
+
+	// EmulatorCompatible avoids Spanner features which aren't supported by the Spanner emulator, so that the
+	// antispam storage can be used for local development, e.g. alongside the GCP storage driver pointed at the
+	// emulator via the SPANNER_EMULATOR_HOST environment variable.
+	//
+	// Rather than using BatchWrite, the antispam index is updated within the follower's transaction, after
+	// reading the index to skip entries which are already present. This costs an extra read per batch, so
+	// shouldn't be used with real Spanner instances.
+	EmulatorCompatible bool
 }
 
 // NewAntispam returns an antispam driver which uses Spanner to maintain a mapping of
@@ -116,9 +125,9 @@ type AntispamStorage struct {
 	// When pushBack is true, the decorator will start returning a wrapped ErrPushback to all calls.
 	pushBack atomic.Bool
 
-	numLookups atomic.Uint64
-	numWrites  atomic.Uint64
-	numHits    atomic.Uint64
+	// counters records the lookups made by the decorator.
+	counters  tessera.DedupeCounters
+	numWrites atomic.Uint64
 }
 
 // index returns the index (if any) previously associated with the provided hash
@@ -126,11 +135,12 @@ func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error)
 	ctx, span := tracer.Start(ctx, "tessera.antispam.gcp.index")
 	defer span.End()
 
-	d.numLookups.Add(1)
+	start := time.Now()
 	var idx int64
 	if row, err := d.dbPool.Single().ReadRow(ctx, "IDSeq", spanner.Key{h}, []string{"idx"}); err != nil {
 		if c := spanner.ErrCode(err); c == codes.NotFound {
 			span.AddEvent("tessera.miss")
+			d.counters.RecordLookup(time.Since(start), false)
 			return nil, nil
 		}
 		return nil, err
@@ -140,11 +150,21 @@ func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error)
 		}
 		idx := uint64(idx)
 		span.AddEvent("tessera.hit")
-		d.numHits.Add(1)
+		d.counters.RecordLookup(time.Since(start), true)
 		return &idx, nil
 	}
 }
 
+// DedupeStats returns statistics about the lookups made by the antispam decorator, along with the number of
+// log entries indexed by the follower.
+func (d *AntispamStorage) DedupeStats(ctx context.Context) (tessera.DedupeStatistics, error) {
+	n, err := (&follower{as: d}).EntriesProcessed(ctx)
+	if err != nil {
+		return tessera.DedupeStatistics{}, err
+	}
+	return d.counters.Statistics(n), nil
+}
+
 // Decorator returns a function which will wrap an underlying Add delegate with
 // code to dedup against the stored data.
 func (d *AntispamStorage) Decorator() func(f tessera.AddFn) tessera.AddFn {
@@ -186,10 +206,12 @@ func (d *AntispamStorage) Follower(b func([]byte) ([][]byte, error)) tessera.Fol
 		as:           d,
 		bundleHasher: b,
 	}
-	// Use the "normal" BatchWrite mechanism to update the antispam index.
-	// This will be overriden by the test to use an "inline" mechanism since spannertest
-	// does not support BatchWrite :(
+	// Use the "normal" BatchWrite mechanism to update the antispam index, unless we've been asked to avoid
+	// it since the Spanner emulator does not support BatchWrite :(
 	f.updateIndex = f.batchUpdateIndex
+	if d.opts.EmulatorCompatible {
+		f.updateIndex = txUpdateIndex
+	}
 
 	return f
 }
@@ -199,15 +221,16 @@ func (d *AntispamStorage) Follower(b func([]byte) ([][]byte, error)) tessera.Fol
 type follower struct {
 	as *AntispamStorage
 
-	// updateIndex knows how to apply the provided slice of mutations to the underlying Spanner DB.
+	// updateIndex knows how to add the provided identity hashes, for entries starting at index from, to the
+	// underlying Spanner DB.
 	//
-	// In normal operation this simply points to the batchUpdateIndex func below, but spannertest
+	// In normal operation this simply points to the batchUpdateIndex func below, but the Spanner emulator
 	// does not support either:
 	//   - BatchWrite operations, or
 	//   - nested transactions
-	// so we use this member as a hook to fallback to
-	// a regular transaction for tests.
-	updateIndex func(context.Context, *spanner.ReadWriteTransaction, []*spanner.Mutation) error
+	// so when AntispamOpts.EmulatorCompatible is set this points to txUpdateIndex, which uses the regular
+	// transaction instead.
+	updateIndex func(ctx context.Context, txn *spanner.ReadWriteTransaction, hashes [][]byte, from uint64) error
 
 	bundleHasher func([]byte) ([][]byte, error)
 }
@@ -290,8 +313,8 @@ func (f *follower) Follow(ctx context.Context, lr tessera.LogReader) {
 					sizeFn := func(_ context.Context) (uint64, error) {
 						return logSize, nil
 					}
-					numFetchers := uint(10)
-					next, stop = iter.Pull2(client.Entries(client.EntryBundles(ctx, numFetchers, sizeFn, lr.ReadEntryBundle, followFrom, logSize-followFrom), f.bundleHasher))
+					bundlesPerRead := uint(10)
+					next, stop = iter.Pull2(client.Entries(client.BulkEntryBundles(ctx, bundlesPerRead, sizeFn, lr.ReadEntryBundles, lr.ReadEntryBundle, followFrom, logSize-followFrom), f.bundleHasher))
 				}
 
 				if curIndex == followFrom && curEntries != nil {
@@ -331,14 +354,8 @@ func (f *follower) Follow(ctx context.Context, lr tessera.LogReader) {
 				}
 
 				// Now update the index.
-				{
-					ms := make([]*spanner.Mutation, 0, len(curEntries))
-					for i, e := range curEntries {
-						ms = append(ms, spanner.Insert("IDSeq", []string{"h", "idx"}, []any{e, int64(curIndex + uint64(i))}))
-					}
-					if err := f.updateIndex(ctx, txn, ms); err != nil {
-						return err
-					}
+				if err := f.updateIndex(ctx, txn, curEntries, curIndex); err != nil {
+					return err
 				}
 
 				numAdded := uint64(len(curEntries))
@@ -383,14 +400,14 @@ func (f *follower) Follow(ctx context.Context, lr tessera.LogReader) {
 //   - Perform reads for each of the hashes we're about to write, and use that to filter writes.
 //     This would work, but would also incur an extra round-trip of data which isn't really necessary but would
 //     slow the process down considerably and add extra load to Spanner for no benefit.
-func (f *follower) batchUpdateIndex(ctx context.Context, _ *spanner.ReadWriteTransaction, ms []*spanner.Mutation) error {
+func (f *follower) batchUpdateIndex(ctx context.Context, _ *spanner.ReadWriteTransaction, hashes [][]byte, from uint64) error {
 	ctx, span := tracer.Start(ctx, "tessera.antispam.gcp.batchUpdateIndex")
 	defer span.End()
 
-	mgs := make([]*spanner.MutationGroup, 0, len(ms))
-	for _, m := range ms {
+	mgs := make([]*spanner.MutationGroup, 0, len(hashes))
+	for i, h := range hashes {
 		mgs = append(mgs, &spanner.MutationGroup{
-			Mutations: []*spanner.Mutation{m},
+			Mutations: []*spanner.Mutation{spanner.Insert("IDSeq", []string{"h", "idx"}, []any{h, int64(from + uint64(i))})},
 		})
 	}
 
@@ -404,6 +421,43 @@ func (f *follower) batchUpdateIndex(ctx context.Context, _ *spanner.ReadWriteTra
 	})
 }
 
+// txUpdateIndex adds the provided identity hashes to the antispam index within txn, for use with the Spanner
+// emulator which doesn't support BatchWrite.
+//
+// Inserting a hash which is already present would fail the whole transaction, so the index is read first and
+// only hashes which aren't present are inserted, keeping the earliest index known for each entry.
+func txUpdateIndex(ctx context.Context, txn *spanner.ReadWriteTransaction, hashes [][]byte, from uint64) error {
+	ctx, span := tracer.Start(ctx, "tessera.antispam.gcp.txUpdateIndex")
+	defer span.End()
+
+	keys := make([]spanner.KeySet, 0, len(hashes))
+	for _, h := range hashes {
+		keys = append(keys, spanner.Key{h})
+	}
+	seen := make(map[string]bool, len(hashes))
+	if err := txn.Read(ctx, "IDSeq", spanner.KeySets(keys...), []string{"h"}).Do(func(r *spanner.Row) error {
+		var h []byte
+		if err := r.Column(0, &h); err != nil {
+			return err
+		}
+		seen[string(h)] = true
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to read antispam index: %v", err)
+	}
+
+	ms := make([]*spanner.Mutation, 0, len(hashes))
+	for i, h := range hashes {
+		if seen[string(h)] {
+			continue
+		}
+		// This also skips later copies of hashes which appear more than once in this batch.
+		seen[string(h)] = true
+		ms = append(ms, spanner.Insert("IDSeq", []string{"h", "idx"}, []any{h, int64(from + uint64(i))}))
+	}
+	return txn.BufferWrite(ms)
+}
+
 // EntriesProcessed returns the total number of log entries processed.
 func (f *follower) EntriesProcessed(ctx context.Context) (uint64, error) {
 	row, err := f.as.dbPool.Single().ReadRow(ctx, "FollowCoord", spanner.Key{0}, []string{"nextIdx"})
@@ -65,6 +65,8 @@ func TestAntispamStorage(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			closeDB := newSpannerDB(t)
 			defer closeDB()
+			// spannertest doesn't support BatchWrites.
+			test.opts.EmulatorCompatible = true
 			as, err := NewAntispam(t.Context(), "projects/p/instances/i/databases/d", test.opts)
 			if err != nil {
 				t.Fatalf("NewAntispam: %v", err)
@@ -78,8 +80,6 @@ func TestAntispamStorage(t *testing.T) {
 			}()
 
 			f := as.Follower(testBundleHasher)
-			// Hack in a workaround for spannertest not supporting BatchWrites
-			f.(*follower).updateIndex = updateIndexTx
 
 			go f.Follow(t.Context(), fl.LogReader)
 
@@ -155,6 +155,8 @@ func TestAntispamPushbackRecovers(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			closeDB := newSpannerDB(t)
 			defer closeDB()
+			// spannertest doesn't support BatchWrites.
+			test.opts.EmulatorCompatible = true
 			as, err := NewAntispam(t.Context(), "projects/p/instances/i/databases/d", test.opts)
 			if err != nil {
 				t.Fatalf("NewAntispam: %v", err)
@@ -168,8 +170,6 @@ func TestAntispamPushbackRecovers(t *testing.T) {
 			}()
 
 			f := as.Follower(testBundleHasher)
-			// Hack in a workaround for spannertest not supporting BatchWrites
-			f.(*follower).updateIndex = updateIndexTx
 
 			entryIndex := make(map[string]uint64)
 			a := tessera.NewPublicationAwaiter(t.Context(), fl.LogReader.ReadCheckpoint, 100*time.Millisecond)
@@ -220,6 +220,39 @@ func TestAntispamPushbackRecovers(t *testing.T) {
 	}
 }
 
+func TestTxUpdateIndex(t *testing.T) {
+	closeDB := newSpannerDB(t)
+	defer closeDB()
+	as, err := NewAntispam(t.Context(), "projects/p/instances/i/databases/d", AntispamOpts{EmulatorCompatible: true})
+	if err != nil {
+		t.Fatalf("NewAntispam: %v", err)
+	}
+	for _, b := range []struct {
+		entries []string
+		from    uint64
+	}{
+		{entries: []string{"one", "two"}, from: 0},
+		// "two" is already in the index, and "three" appears twice in the batch.
+		{entries: []string{"two", "three", "three"}, from: 2},
+	} {
+		var hashes [][]byte
+		for _, e := range b.entries {
+			hashes = append(hashes, testIDHash([]byte(e)))
+		}
+		if _, err := as.dbPool.ReadWriteTransaction(t.Context(), func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			return txUpdateIndex(ctx, txn, hashes, b.from)
+		}); err != nil {
+			t.Fatalf("txUpdateIndex(%q): %v", b.entries, err)
+		}
+	}
+	for e, want := range map[string]uint64{"one": 0, "two": 1, "three": 3} {
+		idx, err := as.index(t.Context(), testIDHash([]byte(e)))
+		if err != nil || idx == nil || *idx != want {
+			t.Errorf("index(%q): got (%v, %v), want %d", e, idx, err, want)
+		}
+	}
+}
+
 func newSpannerDB(t *testing.T) func() {
 	t.Helper()
 	srv, err := spannertest.NewServer("localhost:0")
@@ -249,10 +282,3 @@ func testBundleHasher(b []byte) ([][]byte, error) {
 	}
 	return r, err
 }
-
-// updateIndexTx is a workaround for spannertest not supporting BatchWrites.
-// We use this func as a replacement for follower's updateIndex hook, and simply commit the index
-// updates inline with the larger transaction.
-func updateIndexTx(_ context.Context, txn *spanner.ReadWriteTransaction, ms []*spanner.Mutation) error {
-	return txn.BufferWrite(ms)
-}
@@ -31,6 +31,7 @@ package gcp
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"encoding/gob"
 	"errors"
 	"fmt"
@@ -48,10 +49,13 @@ import (
 
 	gcs "cloud.google.com/go/storage"
 	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bundlecrypt"
+	"github.com/transparency-dev/tessera/internal/compress"
 	"github.com/transparency-dev/tessera/internal/fetcher"
 	"github.com/transparency-dev/tessera/internal/migrate"
 	"github.com/transparency-dev/tessera/internal/otel"
@@ -77,6 +81,9 @@ const (
 	logCacheControl  = "max-age=604800,immutable"
 	ckptCacheControl = "no-cache"
 
+	// maxBundleReads is the maximum number of entry bundles ReadEntryBundles will fetch in parallel.
+	maxBundleReads = 32
+
 	DefaultIntegrationSizeLimit = 5 * 4096
 
 	// SchemaCompatibilityVersion represents the expected version (e.g. layout & serialisation) of stored data.
@@ -138,6 +145,13 @@ type Config struct {
 	BucketPrefix string
 	// Spanner is the GCP resource URI of the spanner database instance to use.
 	Spanner string
+
+	// VerifyWritesFraction is the fraction of tiles and entry bundles which are read back after being written,
+	// and checked to have been stored intact before their integration is acknowledged. This defends against
+	// silent corruption by GCS, at the cost of additional integration latency and reads.
+	//
+	// If zero, writes are not verified. If 1 or more, every write is verified.
+	VerifyWritesFraction float64
 }
 
 // New creates a new instance of the GCP based Storage.
@@ -151,9 +165,9 @@ func New(ctx context.Context, cfg Config) (tessera.Driver, error) {
 }
 
 type LogReader struct {
-	lrs            logResourceStore
-	integratedSize func(context.Context) (uint64, error)
-	nextIndex      func(context.Context) (uint64, error)
+	lrs         logResourceStore
+	currentTree func(context.Context) (uint64, []byte, error)
+	nextIndex   func(context.Context) (uint64, error)
 }
 
 func (lr *LogReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
@@ -169,6 +183,40 @@ func (lr *LogReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
 	return r, err
 }
 
+func (lr *LogReader) ReadArchivedCheckpoint(ctx context.Context, size uint64) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadArchivedCheckpoint")
+	defer span.End()
+
+	return lr.lrs.getArchiveObject(ctx, layout.ArchivedCheckpointPath(size))
+}
+
+func (lr *LogReader) ReadCheckpointArchiveIndex(ctx context.Context) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadCheckpointArchiveIndex")
+	defer span.End()
+
+	return lr.lrs.getArchiveObject(ctx, layout.CheckpointArchiveIndexPath)
+}
+
+// ArchiveCheckpoint adds the checkpoint with the given tree size to the log's archive of checkpoints.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithCheckpointArchive instead.
+func (lr *LogReader) ArchiveCheckpoint(ctx context.Context, size uint64, cp []byte, retain uint) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ArchiveCheckpoint")
+	defer span.End()
+
+	return storage.ArchiveCheckpoint(ctx, storage.CheckpointArchiveStore{
+		Get: lr.lrs.getArchiveObject,
+		Set: func(ctx context.Context, path string, data []byte, immutable bool) error {
+			cacheCtl := ckptCacheControl
+			if immutable {
+				cacheCtl = logCacheControl
+			}
+			return lr.lrs.objStore.setObject(ctx, path, data, nil, ckptContType, "", cacheCtl)
+		},
+		Delete: lr.lrs.objStore.deleteObject,
+	}, size, cp, retain)
+}
+
 func (lr *LogReader) ReadTile(ctx context.Context, l, i uint64, p uint8) ([]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadTile")
 	defer span.End()
@@ -187,11 +235,54 @@ func (lr *LogReader) ReadEntryBundle(ctx context.Context, i uint64, p uint8) ([]
 	})
 }
 
+// ReadEntryBundles returns count full entry bundles, starting with the bundle at index fromBundle.
+//
+// The bundles are fetched from GCS in parallel.
+func (lr *LogReader) ReadEntryBundles(ctx context.Context, fromBundle, count uint64) ([][]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadEntryBundles")
+	defer span.End()
+
+	r := make([][]byte, count)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxBundleReads)
+	for i := range r {
+		eg.Go(func() error {
+			b, err := lr.lrs.getEntryBundle(ctx, fromBundle+uint64(i), 0)
+			r[i] = b
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 func (lr *LogReader) IntegratedSize(ctx context.Context) (uint64, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.IntegratedSize")
 	defer span.End()
 
-	return lr.integratedSize(ctx)
+	s, _, err := lr.currentTree(ctx)
+	return s, err
+}
+
+func (lr *LogReader) ReadInternalCheckpoint(ctx context.Context) (tessera.InternalCheckpoint, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.ReadInternalCheckpoint")
+	defer span.End()
+
+	s, r, err := lr.currentTree(ctx)
+	return tessera.InternalCheckpoint{Size: s, Hash: r}, err
+}
+
+func (lr *LogReader) RootAt(ctx context.Context, size uint64) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.RootAt")
+	defer span.End()
+
+	integrated, _, err := lr.currentTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.RootAt(ctx, size, integrated, lr.ReadTile, lr.lrs.hasher, layout.DefaultTiling)
 }
 
 func (lr *LogReader) NextIndex(ctx context.Context) (uint64, error) {
@@ -223,7 +314,7 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 			return nil, nil, fmt.Errorf("failed to connect to Spanner: %v", err)
 		}
 	}
-	if err := initDB(ctx, s.cfg.Spanner); err != nil {
+	if err := initDB(ctx, s.cfg.Spanner, opts.HashFunction()); err != nil {
 		return nil, nil, fmt.Errorf("failed to verify/init Spanner schema: %v", err)
 	}
 
@@ -231,16 +322,54 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create Spanner coordinator: %v", err)
 	}
+	if err := seq.checkTreeHash(ctx, opts.HashFunction()); err != nil {
+		return nil, nil, err
+	}
 
-	a, lr, err := s.newAppender(ctx, gs, seq, opts)
+	a, lr, err := s.newAppender(ctx, withWriteVerification(gs, s.cfg.VerifyWritesFraction), seq, opts)
 	if err != nil {
 		return nil, nil, err
 	}
 	return &tessera.Appender{
-		Add: a.Add,
+		Add:      a.Add,
+		AddBatch: a.AddBatch,
+		GarbageCollect: func(ctx context.Context) error {
+			return a.garbageCollect(ctx, opts.RecordAudit)
+		},
 	}, lr, nil
 }
 
+// ReadLogState returns the lifecycle state of the log from the LogState table.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) ReadLogState(ctx context.Context) (tessera.LogState, error) {
+	if s.cfg.SpannerClient == nil {
+		return 0, errors.New("storage has not been initialised")
+	}
+	row, err := s.cfg.SpannerClient.Single().ReadRow(ctx, "LogState", spanner.Key{0}, []string{"state"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read LogState: %v", err)
+	}
+	var state int64
+	if err := row.Columns(&state); err != nil {
+		return 0, fmt.Errorf("failed to parse LogState: %v", err)
+	}
+	return tessera.LogState(state), nil
+}
+
+// WriteLogState stores the lifecycle state of the log in the LogState table.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) WriteLogState(ctx context.Context, state tessera.LogState) error {
+	if s.cfg.SpannerClient == nil {
+		return errors.New("storage has not been initialised")
+	}
+	if _, err := s.cfg.SpannerClient.Apply(ctx, []*spanner.Mutation{spanner.InsertOrUpdate("LogState", []string{"id", "state"}, []any{0, int64(state)})}); err != nil {
+		return fmt.Errorf("failed to update LogState: %v", err)
+	}
+	return nil
+}
+
 // newAppender creates and initialises a tessera.Appender struct with the provided underlying storage implementations.
 func (s *Storage) newAppender(ctx context.Context, o objStore, seq *spannerCoordinator, opts *tessera.AppendOptions) (*Appender, tessera.LogReader, error) {
 	if opts.CheckpointInterval() < minCheckpointInterval {
@@ -249,20 +378,20 @@ func (s *Storage) newAppender(ctx context.Context, o objStore, seq *spannerCoord
 
 	a := &Appender{
 		logStore: &logResourceStore{
-			objStore:    o,
-			entriesPath: opts.EntriesPath(),
+			objStore:       o,
+			entriesPath:    opts.EntriesPath(),
+			hasher:         opts.Hasher(),
+			bundleEncoding: opts.EntryBundleEncoding(),
+			bundleCipher:   bundlecrypt.New(opts.EntryBundleKeyProvider()),
 		},
 		sequencer: seq,
 		cpUpdated: make(chan struct{}),
 	}
-	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), a.sequencer.assignEntries)
+	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.WithSequenceHook(storage.WithSpanLinks(a.sequencer.assignEntries, &a.sequenced), opts.SequenceHook()))
 
 	reader := &LogReader{
-		lrs: *a.logStore,
-		integratedSize: func(context.Context) (uint64, error) {
-			s, _, err := a.sequencer.currentTree(ctx)
-			return s, err
-		},
+		lrs:         *a.logStore,
+		currentTree: a.sequencer.currentTree,
 		nextIndex: func(context.Context) (uint64, error) {
 			return a.sequencer.nextIndex(ctx)
 		},
@@ -274,9 +403,9 @@ func (s *Storage) newAppender(ctx context.Context, o objStore, seq *spannerCoord
 	}
 
 	go a.integrateEntriesJob(ctx)
-	go a.publishCheckpointJob(ctx, opts.CheckpointInterval())
+	go a.publishCheckpointJob(ctx, opts)
 	if i := opts.GarbageCollectionInterval(); i > 0 {
-		go a.garbageCollectorJob(ctx, i)
+		go a.garbageCollectorJob(ctx, i, opts.RecordAudit)
 	}
 
 	return a, reader, nil
@@ -292,6 +421,10 @@ type Appender struct {
 	queue *storage.Queue
 
 	cpUpdated chan struct{}
+
+	// sequenced and integrated link the spans of each asynchronous stage to those of the previous stage
+	// which processed the same entries.
+	sequenced, integrated storage.SpanLinks
 }
 
 // Add is the entrypoint for adding entries to a sequencing log.
@@ -302,6 +435,14 @@ func (a *Appender) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFutur
 	return a.queue.Add(ctx, e)
 }
 
+// AddBatch is the entrypoint for adding a batch of entries to a sequencing log.
+func (a *Appender) AddBatch(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.AddBatch")
+	defer span.End()
+
+	return a.queue.AddBatch(ctx, entries)
+}
+
 // integrateEntriesJob periodically append newly sequenced entries.
 //
 // Blocks until ctx is done.
@@ -336,10 +477,11 @@ func (a *Appender) integrateEntriesJob(ctx context.Context) {
 }
 
 // publishCheckpointJob periodically attempts to publish a new checkpoint representing the current state
-// of the tree, once per interval.
+// of the tree, once per interval, which is re-read after each attempt.
 //
 // Blocks until ctx is done.
-func (a *Appender) publishCheckpointJob(ctx context.Context, i time.Duration) {
+func (a *Appender) publishCheckpointJob(ctx context.Context, opts *tessera.AppendOptions) {
+	i := max(opts.CheckpointInterval(), minCheckpointInterval)
 	t := time.NewTicker(i)
 	defer t.Stop()
 	for {
@@ -349,10 +491,19 @@ func (a *Appender) publishCheckpointJob(ctx context.Context, i time.Duration) {
 		case <-a.cpUpdated:
 		case <-t.C:
 		}
+		// The interval may be changed while the log is running.
+		if n := max(opts.CheckpointInterval(), minCheckpointInterval); n != i {
+			i = n
+			t.Reset(i)
+		}
 		func() {
 			ctx, span := tracer.Start(ctx, "tessera.storage.gcp.publishCheckpointJob")
 			defer span.End()
-			if err := a.sequencer.publishCheckpoint(ctx, i, a.publishCheckpoint); err != nil {
+			if err := a.sequencer.publishCheckpoint(ctx, storage.CheckpointMinStaleness(ctx, opts, minCheckpointInterval, a.logStore.getCheckpoint, a.sequencer.currentTree), a.publishCheckpoint); err != nil {
+				if errors.Is(err, tessera.ErrLogNotActive) {
+					// The log is frozen, so there's nothing more to publish.
+					return
+				}
 				klog.Warningf("publishCheckpoint failed: %v", err)
 			}
 		}()
@@ -360,46 +511,48 @@ func (a *Appender) publishCheckpointJob(ctx context.Context, i time.Duration) {
 }
 
 // garbageCollectorJob is a long-running function which handles the removal of obsolete partial tiles
-// and entry bundles.
+// and entry bundles, recording each run with the provided audit function.
 // Blocks until ctx is done.
-func (a *Appender) garbageCollectorJob(ctx context.Context, i time.Duration) {
+func (a *Appender) garbageCollectorJob(ctx context.Context, i time.Duration, audit func(tessera.AuditOp, string, error)) {
 	t := time.NewTicker(i)
 	defer t.Stop()
 
-	// Entirely arbitrary number.
-	maxBundlesPerRun := uint(100)
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-t.C:
 		}
-		func() {
-			ctx, span := tracer.Start(ctx, "tessera.storage.gcp.garbageCollectTask")
-			defer span.End()
+		if err := a.garbageCollect(ctx, audit); err != nil {
+			klog.Warningf("GarbageCollect failed: %v", err)
+		}
+	}
 
-			// Figure out the size of the latest published checkpoint - we can't be removing partial tiles implied by
-			// that checkpoint just because we've done an integration and know about a larger (but as yet unpublished)
-			// checkpoint!
-			cp, err := a.logStore.getCheckpoint(ctx)
-			if err != nil {
-				klog.Warningf("Failed to get published checkpoint: %v", err)
-				return
-			}
-			_, pubSize, _, err := parse.CheckpointUnsafe(cp)
-			if err != nil {
-				klog.Warningf("Failed to parse published checkpoint: %v", err)
-				return
-			}
+}
 
-			if err := a.sequencer.garbageCollect(ctx, pubSize, maxBundlesPerRun, a.logStore.objStore.deleteObjectsWithPrefix); err != nil {
-				klog.Warningf("GarbageCollect failed: %v", err)
-				return
-			}
-		}()
+// garbageCollect runs a single pass of the garbage collector, recording it with the provided audit function.
+func (a *Appender) garbageCollect(ctx context.Context, audit func(tessera.AuditOp, string, error)) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.garbageCollectTask")
+	defer span.End()
+
+	// Entirely arbitrary number.
+	maxBundlesPerRun := uint(100)
+
+	// Figure out the size of the latest published checkpoint - we can't be removing partial tiles implied by
+	// that checkpoint just because we've done an integration and know about a larger (but as yet unpublished)
+	// checkpoint!
+	cp, err := a.logStore.getCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get published checkpoint: %v", err)
+	}
+	_, pubSize, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return fmt.Errorf("failed to parse published checkpoint: %v", err)
 	}
 
+	err = a.sequencer.garbageCollect(ctx, pubSize, maxBundlesPerRun, a.logStore.objStore.deleteObjectsWithPrefix)
+	audit(tessera.AuditOpGarbageCollection, fmt.Sprintf("published size %d", pubSize), err)
+	return err
 }
 
 // init ensures that the storage represents a log in a valid state.
@@ -430,17 +583,18 @@ func (a *Appender) publishCheckpoint(ctx context.Context, size uint64, root []by
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.publishCheckpoint")
 	defer span.End()
 	span.SetAttributes(treeSizeKey.Int64(otel.Clamp64(size)))
+	a.integrated.Link(span, size)
 
 	cpRaw, err := a.newCP(ctx, size, root)
 	if err != nil {
-		return fmt.Errorf("newCP: %v", err)
+		return fmt.Errorf("newCP: %w", err)
 	}
 
 	if err := a.logStore.setCheckpoint(ctx, cpRaw); err != nil {
 		return fmt.Errorf("writeCheckpoint: %v", err)
 	}
 
-	klog.V(2).Infof("Published latest checkpoint: %d, %x", size, root)
+	klog.V(2).InfoS("Published latest checkpoint", "size", size, "root", fmt.Sprintf("%x", root))
 
 	return nil
 
@@ -449,18 +603,31 @@ func (a *Appender) publishCheckpoint(ctx context.Context, size uint64, root []by
 // objStore describes a type which can store and retrieve objects.
 type objStore interface {
 	getObject(ctx context.Context, obj string) ([]byte, int64, error)
-	setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, contType string, cacheCtl string) error
+	readObject(ctx context.Context, obj string) ([]byte, objMeta, error)
+	setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, contType string, contEnc string, cacheCtl string) error
 	deleteObjectsWithPrefix(ctx context.Context, prefix string) error
+	deleteObject(ctx context.Context, obj string) error
+}
+
+// objMeta is the metadata with which an object was stored.
+type objMeta struct {
+	contType string
+	contEnc  string
 }
 
 // logResourceStore knows how to read and write entries which represent a tiles log inside an objStore.
 type logResourceStore struct {
 	objStore    objStore
 	entriesPath func(uint64, uint8) string
+	hasher      merkle.LogHasher
+	// bundleEncoding is the content encoding used to store entry bundles, or empty if they're stored as-is.
+	bundleEncoding string
+	// bundleCipher, if set, encrypts entry bundles at rest.
+	bundleCipher *bundlecrypt.Cipher
 }
 
 func (lrs *logResourceStore) setCheckpoint(ctx context.Context, cpRaw []byte) error {
-	return lrs.objStore.setObject(ctx, layout.CheckpointPath, cpRaw, nil, ckptContType, ckptCacheControl)
+	return lrs.objStore.setObject(ctx, layout.CheckpointPath, cpRaw, nil, ckptContType, "", ckptCacheControl)
 }
 
 func (lrs *logResourceStore) getCheckpoint(ctx context.Context) ([]byte, error) {
@@ -468,12 +635,21 @@ func (lrs *logResourceStore) getCheckpoint(ctx context.Context) ([]byte, error)
 	return r, err
 }
 
+// getArchiveObject returns the object in the checkpoint archive at the given path.
+func (lrs *logResourceStore) getArchiveObject(ctx context.Context, path string) ([]byte, error) {
+	r, _, err := lrs.objStore.getObject(ctx, path)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil, os.ErrNotExist
+	}
+	return r, err
+}
+
 // setTile idempotently stores the provided tile at the location implied by the given level, index, and treeSize.
 //
 // The location to which the tile is written is defined by the tile layout spec.
 func (s *logResourceStore) setTile(ctx context.Context, level, index uint64, partial uint8, data []byte) error {
 	tPath := layout.TilePath(level, index, partial)
-	return s.objStore.setObject(ctx, tPath, data, &gcs.Conditions{DoesNotExist: true}, logContType, logCacheControl)
+	return s.objStore.setObject(ctx, tPath, data, &gcs.Conditions{DoesNotExist: true}, logContType, "", logCacheControl)
 }
 
 // getTile retrieves the raw tile from the provided location.
@@ -528,7 +704,7 @@ func (s *logResourceStore) getTiles(ctx context.Context, tileIDs []storage.TileI
 // Returns a wrapped os.ErrNotExist if the bundle does not exist.
 func (s *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint64, p uint8) ([]byte, error) {
 	objName := s.entriesPath(bundleIndex, p)
-	data, _, err := s.objStore.getObject(ctx, objName)
+	data, meta, err := s.objStore.readObject(ctx, objName)
 	if err != nil {
 		if errors.Is(err, gcs.ErrObjectNotExist) {
 			// Return the generic NotExist error so that higher levels can differentiate
@@ -537,17 +713,43 @@ func (s *logResourceStore) getEntryBundle(ctx context.Context, bundleIndex uint6
 		}
 		return nil, err
 	}
+	enc := meta.contEnc
+	if meta.contType == bundlecrypt.ContentType {
+		if data, enc, err = s.bundleCipher.Open(ctx, objName, data); err != nil {
+			return nil, err
+		}
+	}
 
-	return data, nil
+	return compress.DecodeEntryBundle(enc, data)
 }
 
 // setEntryBundle idempotently stores the serialised entry bundle at the location implied by the bundleIndex and treeSize.
 func (s *logResourceStore) setEntryBundle(ctx context.Context, bundleIndex uint64, p uint8, bundleRaw []byte) error {
 	objName := s.entriesPath(bundleIndex, p)
+	data, err := compress.EncodeEntryBundle(s.bundleEncoding, bundleRaw)
+	if err != nil {
+		return err
+	}
+	contType, contEnc := logContType, s.bundleEncoding
+	if s.bundleCipher != nil {
+		// Encrypted bundles are opaque, so mustn't be served with a Content-Encoding. Their content type
+		// records that they're encrypted.
+		contType, contEnc = bundlecrypt.ContentType, ""
+		if data, err = s.bundleCipher.Seal(ctx, objName, s.bundleEncoding, data); err != nil {
+			return err
+		}
+	}
 	// Note that setObject does an idempotent interpretation of DoesNotExist - it only
 	// returns an error if the named object exists _and_ contains different data to what's
 	// passed in here.
-	if err := s.objStore.setObject(ctx, objName, bundleRaw, &gcs.Conditions{DoesNotExist: true}, logContType, logCacheControl); err != nil {
+	if err := s.objStore.setObject(ctx, objName, data, &gcs.Conditions{DoesNotExist: true}, contType, contEnc, logCacheControl); err != nil {
+		// Encrypting the same bundle twice gives different ciphertexts, so compare what's already stored with
+		// what we're trying to write after decrypting it.
+		if s.bundleCipher != nil {
+			if existing, gErr := s.getEntryBundle(ctx, bundleIndex, p); gErr == nil && bytes.Equal(existing, bundleRaw) {
+				return nil
+			}
+		}
 		return fmt.Errorf("setObject(%q): %v", objName, err)
 
 	}
@@ -560,6 +762,8 @@ func (s *logResourceStore) setEntryBundle(ctx context.Context, bundleIndex uint6
 func (a *Appender) integrateEntries(ctx context.Context, fromSeq uint64, entries []storage.SequencedEntry) ([]byte, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.integrateEntries")
 	defer span.End()
+	newSize := fromSeq + uint64(len(entries))
+	a.sequenced.Link(span, newSize)
 
 	var newRoot []byte
 
@@ -587,6 +791,7 @@ func (a *Appender) integrateEntries(ctx context.Context, fromSeq uint64, entries
 	if err := errG.Wait(); err != nil {
 		return nil, err
 	}
+	a.integrated.Add(ctx, newSize)
 	return newRoot, nil
 }
 
@@ -606,7 +811,7 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logRe
 		return n, nil
 	}
 
-	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh)
+	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh, logStore.hasher, layout.DefaultTiling)
 	if err != nil {
 		return nil, fmt.Errorf("storage.Integrate: %v", err)
 	}
@@ -624,7 +829,7 @@ func integrate(ctx context.Context, fromSeq uint64, lh [][]byte, logStore *logRe
 	if err := errG.Wait(); err != nil {
 		return nil, err
 	}
-	klog.V(1).Infof("New tree: %d, %x", newSize, newRoot)
+	klog.V(1).InfoS("New tree", "size", newSize, "root", fmt.Sprintf("%x", newRoot))
 
 	return newRoot, nil
 }
@@ -730,7 +935,15 @@ func newSpannerCoordinator(ctx context.Context, dbPool *spanner.Client, maxOutst
 //   - IntCoord
 //     This table coordinates integration of the batches of entries stored in
 //     Seq into the committed tree state.
-func initDB(ctx context.Context, spannerDB string) error {
+//   - LogState
+//     This table only ever contains a single row which holds the lifecycle
+//     state of the log.
+//   - TreeHash
+//     This table only ever contains a single row which holds the name of the
+//     hash function used to build the log's Merkle tree.
+//
+// The h param is the hash function used to calculate the root of the empty tree.
+func initDB(ctx context.Context, spannerDB string, h crypto.Hash) error {
 	return createAndPrepareTables(
 		ctx, spannerDB,
 		[]string{
@@ -740,13 +953,16 @@ func initDB(ctx context.Context, spannerDB string) error {
 			"CREATE TABLE IF NOT EXISTS IntCoord (id INT64 NOT NULL, seq INT64 NOT NULL, rootHash BYTES(32)) PRIMARY KEY (id)",
 			"CREATE TABLE IF NOT EXISTS PubCoord (id INT64 NOT NULL, publishedAt TIMESTAMP NOT NULL) PRIMARY KEY (id)",
 			"CREATE TABLE IF NOT EXISTS GCCoord (id INT64 NOT NULL, fromSize INT64 NOT NULL) PRIMARY KEY (id)",
+			"CREATE TABLE IF NOT EXISTS LogState (id INT64 NOT NULL, state INT64 NOT NULL) PRIMARY KEY (id)",
+			"CREATE TABLE IF NOT EXISTS TreeHash (id INT64 NOT NULL, hash STRING(32) NOT NULL) PRIMARY KEY (id)",
 		},
 		[][]*spanner.Mutation{
 			{spanner.Insert("Tessera", []string{"id", "compatibilityVersion"}, []any{0, SchemaCompatibilityVersion})},
 			{spanner.Insert("SeqCoord", []string{"id", "next"}, []any{0, 0})},
-			{spanner.Insert("IntCoord", []string{"id", "seq", "rootHash"}, []any{0, 0, rfc6962.DefaultHasher.EmptyRoot()})},
+			{spanner.Insert("IntCoord", []string{"id", "seq", "rootHash"}, []any{0, 0, rfc6962.New(h).EmptyRoot()})},
 			{spanner.Insert("PubCoord", []string{"id", "publishedAt"}, []any{0, time.Unix(0, 0)})},
 			{spanner.Insert("GCCoord", []string{"id", "fromSize"}, []any{0, 0})},
+			{spanner.Insert("LogState", []string{"id", "state"}, []any{0, int64(tessera.LogStateActive)})},
 		},
 	)
 }
@@ -769,6 +985,42 @@ func (s *spannerCoordinator) checkDataCompatibility(ctx context.Context) error {
 	return nil
 }
 
+// checkTreeHash checks that the log's Merkle tree was built using the hash function h.
+//
+// If no hash function has been recorded yet and the tree is empty, h is recorded as the one to use from now on.
+// Otherwise, a missing record means that the log was created before the hash function was recorded, and so
+// must be using SHA-256.
+func (s *spannerCoordinator) checkTreeHash(ctx context.Context, h crypto.Hash) error {
+	var stored string
+	_, err := s.dbPool.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		stored = ""
+		row, err := txn.ReadRow(ctx, "TreeHash", spanner.Key{0}, []string{"hash"})
+		if err == nil {
+			return row.Columns(&stored)
+		}
+		if spanner.ErrCode(err) != codes.NotFound {
+			return fmt.Errorf("failed to read TreeHash: %v", err)
+		}
+		row, err = txn.ReadRow(ctx, "IntCoord", spanner.Key{0}, []string{"seq"})
+		if err != nil {
+			return fmt.Errorf("failed to read IntCoord: %v", err)
+		}
+		var size int64
+		if err := row.Columns(&size); err != nil {
+			return fmt.Errorf("failed to parse IntCoord: %v", err)
+		}
+		if size > 0 {
+			return nil
+		}
+		stored = h.String()
+		return txn.BufferWrite([]*spanner.Mutation{spanner.Insert("TreeHash", []string{"id", "hash"}, []any{0, stored})})
+	})
+	if err != nil {
+		return err
+	}
+	return storage.CheckHashFunction(stored, h)
+}
+
 // assignEntries durably assigns each of the passed-in entries an index in the log.
 //
 // Entries are allocated contiguous indices, in the order in which they appear in the entries parameter.
@@ -812,6 +1064,9 @@ func (s *spannerCoordinator) assignEntries(ctx context.Context, entries []*tesse
 		}
 
 		next := uint64(next) // Shadow next with a uint64 version of the same value to save on casts.
+		if err := storage.CheckPreordered(next, entries); err != nil {
+			return err
+		}
 		sequencedEntries := make([]storage.SequencedEntry, len(entries))
 		// Assign provisional sequence numbers to entries.
 		// We need to do this here in order to support serialisations which include the log position.
@@ -891,7 +1146,7 @@ func (s *spannerCoordinator) consumeEntries(ctx context.Context, limit uint64, f
 			endSeq = l
 		}
 
-		klog.V(1).Infof("Consuming bundles start from %d to at most %d", fromSeq, endSeq)
+		klog.V(1).InfoS("Consuming sequenced entries", "from", fromSeq, "to", endSeq)
 
 		// Now read the sequenced starting at the index we got above.
 		rows := txn.ReadWithOptions(ctx, "Seq",
@@ -1120,6 +1375,18 @@ type gcsStorage struct {
 
 // getObject returns the data and generation of the specified object, or an error.
 func (s *gcsStorage) getObject(ctx context.Context, obj string) ([]byte, int64, error) {
+	d, attrs, err := s.read(ctx, obj)
+	return d, attrs.Generation, err
+}
+
+// readObject returns the data of the specified object and the metadata with which it was stored, or an error.
+func (s *gcsStorage) readObject(ctx context.Context, obj string) ([]byte, objMeta, error) {
+	d, attrs, err := s.read(ctx, obj)
+	return d, objMeta{contType: attrs.ContentType, contEnc: attrs.ContentEncoding}, err
+}
+
+// read returns the data and attributes of the specified object, or an error.
+func (s *gcsStorage) read(ctx context.Context, obj string) ([]byte, gcs.ReaderObjectAttrs, error) {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.getObject")
 	defer span.End()
 
@@ -1129,16 +1396,19 @@ func (s *gcsStorage) getObject(ctx context.Context, obj string) ([]byte, int64,
 
 	span.SetAttributes(objectPathKey.String(obj))
 
-	r, err := s.gcsClient.Bucket(s.bucket).Object(obj).NewReader(ctx)
+	// Read objects exactly as they were stored, rather than having GCS decompress gzip encoded entry bundles,
+	// so that they can be compared with data being written by setObject. Entry bundles are then decoded
+	// according to the Content-Encoding recorded in the object's attributes.
+	r, err := s.gcsClient.Bucket(s.bucket).Object(obj).ReadCompressed(true).NewReader(ctx)
 	if err != nil {
-		return nil, -1, fmt.Errorf("getObject: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
+		return nil, gcs.ReaderObjectAttrs{Generation: -1}, fmt.Errorf("getObject: failed to create reader for object %q in bucket %q: %w", obj, s.bucket, err)
 	}
 
 	d, err := io.ReadAll(r)
 	if err != nil {
-		return nil, -1, fmt.Errorf("failed to read %q: %v", obj, err)
+		return nil, gcs.ReaderObjectAttrs{Generation: -1}, fmt.Errorf("failed to read %q: %v", obj, err)
 	}
-	return d, r.Attrs.Generation, r.Close()
+	return d, r.Attrs, r.Close()
 }
 
 // setObject stores the provided data in the specified object, optionally gated by a condition.
@@ -1149,7 +1419,7 @@ func (s *gcsStorage) getObject(ctx context.Context, obj string) ([]byte, int64,
 // Note that when preconditions are specified and are not met, an error will be returned *unless*
 // the currently stored data is bit-for-bit identical to the data to-be-written.
 // This is intended to provide idempotentency for writes.
-func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte, cond *gcs.Conditions, contType string, cacheCtl string) error {
+func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte, cond *gcs.Conditions, contType string, contEnc string, cacheCtl string) error {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.setObject")
 	defer span.End()
 
@@ -1170,6 +1440,7 @@ func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte,
 		w = obj.If(*cond).NewWriter(ctx)
 	}
 	w.ContentType = contType
+	w.ContentEncoding = contEnc
 	w.CacheControl = cacheCtl
 	// Limit the amount of memory used for buffers, see https://pkg.go.dev/cloud.google.com/go/storage#Writer
 	w.ChunkSize = len(data) + 1024
@@ -1211,6 +1482,25 @@ func (s *gcsStorage) setObject(ctx context.Context, objName string, data []byte,
 	return nil
 }
 
+// deleteObject deletes the specified object.
+func (s *gcsStorage) deleteObject(ctx context.Context, obj string) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.deleteObject")
+	defer span.End()
+
+	if s.bucketPrefix != "" {
+		obj = filepath.Join(s.bucketPrefix, obj)
+	}
+	span.SetAttributes(objectPathKey.String(obj))
+
+	if err := s.gcsClient.Bucket(s.bucket).Object(obj).Delete(ctx); err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return os.ErrNotExist
+		}
+		return fmt.Errorf("failed to delete object %q in bucket %q: %v", obj, s.bucket, err)
+	}
+	return nil
+}
+
 // deleteObjectsWithPrefix removes any objects with the provided prefix from GCS.
 func (s *gcsStorage) deleteObjectsWithPrefix(ctx context.Context, objPrefix string) error {
 	ctx, span := tracer.Start(ctx, "tessera.storage.gcp.deleteObject")
@@ -1258,7 +1548,7 @@ func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOp
 			return nil, nil, fmt.Errorf("failed to connect to Spanner: %v", err)
 		}
 	}
-	if err := initDB(ctx, s.cfg.Spanner); err != nil {
+	if err := initDB(ctx, s.cfg.Spanner, crypto.SHA256); err != nil {
 		return nil, nil, fmt.Errorf("failed to verify/init Spanner schema: %v", err)
 	}
 
@@ -1266,27 +1556,30 @@ func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOp
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create Spanner sequencer: %v", err)
 	}
+	if err := seq.checkTreeHash(ctx, crypto.SHA256); err != nil {
+		return nil, nil, err
+	}
 	m := &MigrationStorage{
 		s:            s,
 		dbPool:       seq.dbPool,
 		bundleHasher: opts.LeafHasher(),
 		sequencer:    seq,
 		logStore: &logResourceStore{
-			objStore: &gcsStorage{
+			objStore: withWriteVerification(&gcsStorage{
 				gcsClient:    s.cfg.GCSClient,
 				bucket:       s.cfg.Bucket,
 				bucketPrefix: s.cfg.BucketPrefix,
-			},
-			entriesPath: opts.EntriesPath(),
+			}, s.cfg.VerifyWritesFraction),
+			entriesPath:    opts.EntriesPath(),
+			hasher:         rfc6962.DefaultHasher,
+			bundleEncoding: opts.EntryBundleEncoding(),
+			bundleCipher:   bundlecrypt.New(opts.EntryBundleKeyProvider()),
 		},
 	}
 
 	r := &LogReader{
-		lrs: *m.logStore,
-		integratedSize: func(context.Context) (uint64, error) {
-			s, _, err := m.sequencer.currentTree(ctx)
-			return s, err
-		},
+		lrs:         *m.logStore,
+		currentTree: m.sequencer.currentTree,
 		nextIndex: func(context.Context) (uint64, error) {
 			return 0, nil
 		},
@@ -17,6 +17,7 @@ package gcp
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -37,6 +38,7 @@ import (
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/fsck"
+	"github.com/transparency-dev/tessera/internal/bundlecrypt"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"golang.org/x/mod/sumdb/note"
 )
@@ -52,7 +54,7 @@ func newSpannerDB(t *testing.T) (*spanner.Client, func()) {
 	}
 
 	id := "projects/p/instances/i/databases/d"
-	if err := initDB(t.Context(), id); err != nil {
+	if err := initDB(t.Context(), id, crypto.SHA256); err != nil {
 		t.Fatalf("initDB: %v", err)
 	}
 
@@ -242,6 +244,65 @@ func TestCheckDataCompatibility(t *testing.T) {
 	}
 }
 
+func TestCheckTreeHash(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		treeSize int64
+		first    crypto.Hash
+		second   crypto.Hash
+		wantErr  bool
+	}{
+		{
+			desc:   "new log, same hash",
+			first:  crypto.SHA512_256,
+			second: crypto.SHA512_256,
+		},
+		{
+			desc:    "new log, different hash",
+			first:   crypto.SHA512_256,
+			second:  crypto.SHA256,
+			wantErr: true,
+		},
+		{
+			desc:     "legacy log, default hash",
+			treeSize: 10,
+			first:    crypto.SHA256,
+			second:   crypto.SHA256,
+		},
+		{
+			desc:     "legacy log, different hash",
+			treeSize: 10,
+			first:    crypto.SHA3_256,
+			second:   crypto.SHA3_256,
+			wantErr:  true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			db, close := newSpannerDB(t)
+			defer close()
+
+			s, err := newSpannerCoordinator(ctx, db, 1000)
+			if err != nil {
+				t.Fatalf("newSpannerCoordinator: %v", err)
+			}
+			if test.treeSize > 0 {
+				// Simulate a log created before the hash function was recorded.
+				if _, err := s.dbPool.Apply(ctx, []*spanner.Mutation{spanner.InsertOrUpdate("IntCoord", []string{"id", "seq"}, []any{0, test.treeSize})}); err != nil {
+					t.Fatalf("Failed to set tree size: %v", err)
+				}
+			}
+			err = s.checkTreeHash(ctx, test.first)
+			if err == nil {
+				err = s.checkTreeHash(ctx, test.second)
+			}
+			if gotErr := err != nil; test.wantErr != gotErr {
+				t.Fatalf("checkTreeHash: %v, wantErr %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
 func makeTile(t *testing.T, size uint64) *api.HashTile {
 	t.Helper()
 	r := &api.HashTile{Nodes: make([][]byte, size)}
@@ -301,6 +362,38 @@ func TestTileRoundtrip(t *testing.T) {
 	}
 }
 
+func TestCheckpointArchive(t *testing.T) {
+	ctx := context.Background()
+	m := newMemObjStore()
+	lr := &LogReader{lrs: logResourceStore{objStore: m}}
+
+	// Expiring the checkpoint with size 1 must not remove the one with size 10, despite sharing its prefix.
+	for _, size := range []uint64{1, 10, 100} {
+		if err := lr.ArchiveCheckpoint(ctx, size, fmt.Appendf(nil, "checkpoint %d", size), 2); err != nil {
+			t.Fatalf("ArchiveCheckpoint(%d): %v", size, err)
+		}
+	}
+	raw, err := lr.ReadCheckpointArchiveIndex(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpointArchiveIndex: %v", err)
+	}
+	idx := api.CheckpointArchiveIndex{}
+	if err := idx.UnmarshalText(raw); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if d := cmp.Diff([]uint64{10, 100}, idx.Sizes); d != "" {
+		t.Errorf("Archived sizes mismatch (-want +got):\n%s", d)
+	}
+	for _, size := range idx.Sizes {
+		if got, err := lr.ReadArchivedCheckpoint(ctx, size); err != nil || string(got) != fmt.Sprintf("checkpoint %d", size) {
+			t.Errorf("ReadArchivedCheckpoint(%d) = %q, %v", size, got, err)
+		}
+	}
+	if _, err := lr.ReadArchivedCheckpoint(ctx, 1); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadArchivedCheckpoint(1) of expired checkpoint: got err %v, want os.ErrNotExist", err)
+	}
+}
+
 func makeBundle(t *testing.T, idx uint64, size int) []byte {
 	t.Helper()
 	r := &bytes.Buffer{}
@@ -360,6 +453,49 @@ func TestBundleRoundtrip(t *testing.T) {
 	}
 }
 
+func TestReadEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	m := newMemObjStore()
+	s := &logResourceStore{
+		objStore:    m,
+		entriesPath: layout.EntriesPath,
+	}
+	lr := &LogReader{lrs: *s}
+
+	const numBundles = 5
+	bundles := make([][]byte, numBundles)
+	for i := range bundles {
+		bundles[i] = makeBundle(t, uint64(i), 0)
+		if err := s.setEntryBundle(ctx, uint64(i), 0, bundles[i]); err != nil {
+			t.Fatalf("setEntryBundle(%d): %v", i, err)
+		}
+	}
+
+	for _, test := range []struct {
+		name        string
+		from, count uint64
+		wantErr     error
+	}{
+		{name: "all", from: 0, count: numBundles},
+		{name: "middle", from: 1, count: 3},
+		{name: "none", from: 2, count: 0},
+		{name: "beyond end", from: 3, count: 3, wantErr: os.ErrNotExist},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := lr.ReadEntryBundles(ctx, test.from, test.count)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("ReadEntryBundles: got err %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+			if want := bundles[test.from : test.from+test.count]; !cmp.Equal(got, want) {
+				t.Fatalf("ReadEntryBundles returned different bundles")
+			}
+		})
+	}
+}
+
 func TestPublishTree(t *testing.T) {
 	ctx := context.Background()
 	for _, test := range []struct {
@@ -418,7 +554,7 @@ func TestPublishTree(t *testing.T) {
 				t.Fatalf("publishTree: %v", err)
 			}
 			cpOld := []byte("bananas")
-			if err := m.setObject(ctx, layout.CheckpointPath, cpOld, nil, "", ""); err != nil {
+			if err := m.setObject(ctx, layout.CheckpointPath, cpOld, nil, "", "", ""); err != nil {
 				t.Fatalf("setObject(bananas): %v", err)
 			}
 			updatesSeen := 0
@@ -548,12 +684,14 @@ func expectedPartialPrefixes(size uint64) []string {
 
 type memObjStore struct {
 	sync.RWMutex
-	mem map[string][]byte
+	mem  map[string][]byte
+	meta map[string]objMeta
 }
 
 func newMemObjStore() *memObjStore {
 	return &memObjStore{
-		mem: make(map[string][]byte),
+		mem:  make(map[string][]byte),
+		meta: make(map[string]objMeta),
 	}
 }
 
@@ -568,8 +706,14 @@ func (m *memObjStore) getObject(_ context.Context, obj string) ([]byte, int64, e
 	return d, 1, nil
 }
 
-// TODO(phboneff): add content type tests
-func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, cond *gcs.Conditions, _, _ string) error {
+func (m *memObjStore) readObject(ctx context.Context, obj string) ([]byte, objMeta, error) {
+	d, _, err := m.getObject(ctx, obj)
+	m.RLock()
+	defer m.RUnlock()
+	return d, m.meta[obj], err
+}
+
+func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, cond *gcs.Conditions, contType, contEnc, _ string) error {
 	m.Lock()
 	defer m.Unlock()
 
@@ -583,6 +727,16 @@ func (m *memObjStore) setObject(_ context.Context, obj string, data []byte, cond
 		}
 	}
 	m.mem[obj] = data
+	m.meta[obj] = objMeta{contType: contType, contEnc: contEnc}
+	return nil
+}
+
+func (m *memObjStore) deleteObject(_ context.Context, obj string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.mem, obj)
+	delete(m.meta, obj)
 	return nil
 }
 
@@ -594,6 +748,7 @@ func (m *memObjStore) deleteObjectsWithPrefix(_ context.Context, prefix string)
 		if strings.HasPrefix(k, prefix) {
 			log.Printf("DELETE: %s", k)
 			delete(m.mem, k)
+			delete(m.meta, k)
 		}
 	}
 	return nil
@@ -628,3 +783,145 @@ func defaultMerkleLeafHasher(bundle []byte) ([][]byte, error) {
 	}
 	return r, nil
 }
+
+// corruptingObjStore is an objStore which silently flips a bit in every object written with a condition.
+type corruptingObjStore struct {
+	*memObjStore
+}
+
+func (c corruptingObjStore) setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, contType, contEnc, cacheCtl string) error {
+	if cond != nil {
+		data = append([]byte{data[0] ^ 1}, data[1:]...)
+	}
+	return c.memObjStore.setObject(ctx, obj, data, cond, contType, contEnc, cacheCtl)
+}
+
+func TestVerifyingObjStore(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name     string
+		store    objStore
+		fraction float64
+		wantErr  bool
+	}{
+		{name: "intact", store: newMemObjStore(), fraction: 1},
+		{name: "corrupt", store: corruptingObjStore{newMemObjStore()}, fraction: 1, wantErr: true},
+		{name: "corrupt unverified", store: corruptingObjStore{newMemObjStore()}, fraction: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := &logResourceStore{
+				objStore:    withWriteVerification(test.store, test.fraction),
+				entriesPath: layout.EntriesPath,
+			}
+			if err := s.setEntryBundle(ctx, 0, 1, []byte("bundle")); (err != nil) != test.wantErr {
+				t.Errorf("setEntryBundle: %v, want error %t", err, test.wantErr)
+			}
+			if err := s.setTile(ctx, 0, 0, 1, []byte("tile")); (err != nil) != test.wantErr {
+				t.Errorf("setTile: %v, want error %t", err, test.wantErr)
+			}
+			// The checkpoint is never verified.
+			if err := s.setCheckpoint(ctx, []byte("checkpoint")); err != nil {
+				t.Errorf("setCheckpoint: %v", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	kp, err := tessera.NewAESKeyProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewAESKeyProvider: %v", err)
+	}
+	m := newMemObjStore()
+	newStore := func() *logResourceStore {
+		// Each store has its own data key, as if it were running in a different process.
+		return &logResourceStore{
+			objStore:       m,
+			entriesPath:    layout.EntriesPath,
+			bundleEncoding: "zstd",
+			bundleCipher:   bundlecrypt.New(kp),
+		}
+	}
+	bundle := []byte("bundle")
+	if err := newStore().setEntryBundle(ctx, 0, 1, bundle); err != nil {
+		t.Fatalf("setEntryBundle: %v", err)
+	}
+	if stored := m.mem[layout.EntriesPath(0, 1)]; bytes.Contains(stored, bundle) {
+		t.Errorf("stored bundle %x is not encrypted", stored)
+	}
+	if got := m.meta[layout.EntriesPath(0, 1)].contType; got != bundlecrypt.ContentType {
+		t.Errorf("got content type %q, want %q", got, bundlecrypt.ContentType)
+	}
+	// Writing the same bundle again must succeed, even though it encrypts differently.
+	if err := newStore().setEntryBundle(ctx, 0, 1, bundle); err != nil {
+		t.Errorf("setEntryBundle again: %v", err)
+	}
+	if err := newStore().setEntryBundle(ctx, 0, 1, []byte("different")); err == nil {
+		t.Errorf("setEntryBundle with different bundle succeeded")
+	}
+	got, err := newStore().getEntryBundle(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("getEntryBundle: %v", err)
+	}
+	if !bytes.Equal(got, bundle) {
+		t.Errorf("getEntryBundle: got %q, want %q", got, bundle)
+	}
+
+	// Unencrypted bundles are read as-is, whatever they start with.
+	plain := &logResourceStore{objStore: newMemObjStore(), entriesPath: layout.EntriesPath}
+	bundle = []byte("\x00\x00tenc\x01")
+	if err := plain.setEntryBundle(ctx, 0, 2, bundle); err != nil {
+		t.Fatalf("setEntryBundle: %v", err)
+	}
+	if got, err := plain.getEntryBundle(ctx, 0, 2); err != nil || !bytes.Equal(got, bundle) {
+		t.Errorf("getEntryBundle: got %q, %v, want %q", got, err, bundle)
+	}
+}
+
+// encodingObjStore is an objStore which records the content encoding of each object written.
+type encodingObjStore struct {
+	*memObjStore
+	contEnc map[string]string
+}
+
+func (e encodingObjStore) setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, contType, contEnc, cacheCtl string) error {
+	e.contEnc[obj] = contEnc
+	return e.memObjStore.setObject(ctx, obj, data, cond, contType, contEnc, cacheCtl)
+}
+
+func TestCompressedEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	bundle := bytes.Repeat([]byte("\x00\x10{\"name\": \"entry\"}"), 256)
+	for _, enc := range []string{"", "zstd", "gzip"} {
+		t.Run(enc, func(t *testing.T) {
+			m := encodingObjStore{memObjStore: newMemObjStore(), contEnc: make(map[string]string)}
+			s := &logResourceStore{
+				objStore:       m,
+				entriesPath:    layout.EntriesPath,
+				bundleEncoding: enc,
+			}
+			if err := s.setEntryBundle(ctx, 0, 0, bundle); err != nil {
+				t.Fatalf("setEntryBundle: %v", err)
+			}
+			// Compression is deterministic, so rewriting the same bundle is idempotent.
+			if err := s.setEntryBundle(ctx, 0, 0, bundle); err != nil {
+				t.Errorf("setEntryBundle again: %v", err)
+			}
+			obj := layout.EntriesPath(0, 0)
+			if got := m.contEnc[obj]; got != enc {
+				t.Errorf("got content encoding %q, want %q", got, enc)
+			}
+			if stored := m.mem[obj]; enc != "" && len(stored) >= len(bundle) {
+				t.Errorf("stored bundle is %d bytes, want fewer than %d", len(stored), len(bundle))
+			}
+			got, err := s.getEntryBundle(ctx, 0, 0)
+			if err != nil {
+				t.Fatalf("getEntryBundle: %v", err)
+			}
+			if !bytes.Equal(got, bundle) {
+				t.Errorf("getEntryBundle: got %q, want %q", got, bundle)
+			}
+		})
+	}
+}
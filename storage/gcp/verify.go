@@ -0,0 +1,54 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+
+	gcs "cloud.google.com/go/storage"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+)
+
+// verifyingObjStore is an objStore which re-reads a sample of the tiles and entry bundles written through it,
+// to check that GCS stored them intact, before the write is considered to have succeeded.
+type verifyingObjStore struct {
+	objStore
+	v *storage.WriteVerifier
+}
+
+// withWriteVerification returns o, wrapped so as to verify the given fraction of writes of tiles and entry
+// bundles if fraction is positive.
+func withWriteVerification(o objStore, fraction float64) objStore {
+	v := storage.NewWriteVerifier(fraction)
+	if v == nil {
+		return o
+	}
+	return &verifyingObjStore{objStore: o, v: v}
+}
+
+func (s *verifyingObjStore) setObject(ctx context.Context, obj string, data []byte, cond *gcs.Conditions, contType string, contEnc string, cacheCtl string) error {
+	if err := s.objStore.setObject(ctx, obj, data, cond, contType, contEnc, cacheCtl); err != nil {
+		return err
+	}
+	// Only immutable resources, i.e. tiles and entry bundles, are written with conditions. The checkpoint is
+	// rewritten frequently, and may already have been replaced by the time it's read back.
+	if cond == nil {
+		return nil
+	}
+	return s.v.Verify(ctx, obj, data, func(ctx context.Context) ([]byte, error) {
+		d, _, err := s.getObject(ctx, obj)
+		return d, err
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"k8s.io/klog/v2"
+)
+
+// CheckpointArchiveStore provides access to the objects in which a driver stores its archive of checkpoints.
+type CheckpointArchiveStore struct {
+	// Get returns the object at path, or an error which wraps os.ErrNotExist if there is none.
+	Get func(ctx context.Context, path string) ([]byte, error)
+	// Set creates or replaces the object at path. Immutable is true for archived checkpoints, which never
+	// change once written, and false for the archive's index.
+	Set func(ctx context.Context, path string, data []byte, immutable bool) error
+	// Delete removes the object at path.
+	Delete func(ctx context.Context, path string) error
+}
+
+// ArchiveCheckpoint adds the checkpoint with the given tree size to the archive held in s, and records it in the
+// archive's index, unless a checkpoint with that size has already been archived. If retain is non-zero, the
+// oldest checkpoints are then removed so that no more than retain remain. The whole index is rewritten each
+// time, so the Appender only allows retain to be up to tessera.MaxArchivedCheckpoints.
+//
+// The checkpoint is written before the index, so every size in the index can be read from the archive.
+// Callers must serialise calls, e.g. by only archiving checkpoints while holding the lock used to publish them.
+func ArchiveCheckpoint(ctx context.Context, s CheckpointArchiveStore, size uint64, cp []byte, retain uint) error {
+	idx := api.CheckpointArchiveIndex{}
+	raw, err := s.Get(ctx, layout.CheckpointArchiveIndexPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read checkpoint archive index: %v", err)
+	default:
+		if err := idx.UnmarshalText(raw); err != nil {
+			return fmt.Errorf("invalid checkpoint archive index: %v", err)
+		}
+	}
+	if n := len(idx.Sizes); n > 0 {
+		last := idx.Sizes[n-1]
+		if size == last {
+			return nil
+		}
+		if size < last {
+			return fmt.Errorf("checkpoint size %d is smaller than that of the latest archived checkpoint %d", size, last)
+		}
+	}
+
+	if err := s.Set(ctx, layout.ArchivedCheckpointPath(size), cp, true); err != nil {
+		return fmt.Errorf("failed to write archived checkpoint: %v", err)
+	}
+	idx.Sizes = append(idx.Sizes, size)
+	var expired []uint64
+	if n := uint(len(idx.Sizes)); retain > 0 && n > retain {
+		expired, idx.Sizes = idx.Sizes[:n-retain], idx.Sizes[n-retain:]
+	}
+	raw, err = idx.MarshalText()
+	if err != nil {
+		return err
+	}
+	if err := s.Set(ctx, layout.CheckpointArchiveIndexPath, raw, false); err != nil {
+		return fmt.Errorf("failed to write checkpoint archive index: %v", err)
+	}
+	// Expired checkpoints are no longer listed in the index, so failing to delete them only wastes space.
+	for _, e := range expired {
+		if err := s.Delete(ctx, layout.ArchivedCheckpointPath(e)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			klog.Warningf("Failed to delete expired archived checkpoint %d: %v", e, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+)
+
+// memArchive is an in-memory CheckpointArchiveStore.
+type memArchive map[string][]byte
+
+func (m memArchive) store() storage.CheckpointArchiveStore {
+	return storage.CheckpointArchiveStore{
+		Get: func(_ context.Context, path string) ([]byte, error) {
+			d, ok := m[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return d, nil
+		},
+		Set: func(_ context.Context, path string, data []byte, _ bool) error {
+			m[path] = data
+			return nil
+		},
+		Delete: func(_ context.Context, path string) error {
+			delete(m, path)
+			return nil
+		},
+	}
+}
+
+func TestArchiveCheckpoint(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		sizes     []uint64
+		retain    uint
+		wantSizes []uint64
+		wantErr   bool
+	}{
+		{
+			name:      "keep all",
+			sizes:     []uint64{1, 10, 100, 1000},
+			wantSizes: []uint64{1, 10, 100, 1000},
+		}, {
+			name:      "retain",
+			sizes:     []uint64{1, 10, 100, 1000},
+			retain:    2,
+			wantSizes: []uint64{100, 1000},
+		}, {
+			name:      "same size is archived once",
+			sizes:     []uint64{1, 10, 10},
+			wantSizes: []uint64{1, 10},
+		}, {
+			name:    "smaller size",
+			sizes:   []uint64{10, 1},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := memArchive{}
+			var err error
+			for i, s := range test.sizes {
+				if err = storage.ArchiveCheckpoint(ctx, m.store(), s, fmt.Appendf(nil, "checkpoint %d/%d", s, i), test.retain); err != nil {
+					break
+				}
+			}
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("ArchiveCheckpoint: %v, wantErr %t", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			idx := api.CheckpointArchiveIndex{}
+			if err := idx.UnmarshalText(m[layout.CheckpointArchiveIndexPath]); err != nil {
+				t.Fatalf("UnmarshalText: %v", err)
+			}
+			if d := cmp.Diff(test.wantSizes, idx.Sizes); d != "" {
+				t.Errorf("Archived sizes mismatch (-want +got):\n%s", d)
+			}
+			// Only the indexed checkpoints should remain, and each should be the first one archived with its size.
+			if got, want := len(m), len(test.wantSizes)+1; got != want {
+				t.Errorf("Archive holds %d objects, want %d", got, want)
+			}
+			for _, s := range test.wantSizes {
+				cp := m[layout.ArchivedCheckpointPath(s)]
+				var gotSize, i uint64
+				if _, err := fmt.Sscanf(string(cp), "checkpoint %d/%d", &gotSize, &i); err != nil || gotSize != s {
+					t.Errorf("Archived checkpoint %d = %q", s, cp)
+				}
+				if s == 10 && i != 1 {
+					t.Errorf("Archived checkpoint %d was replaced by a later one: %q", s, cp)
+				}
+			}
+		})
+	}
+}
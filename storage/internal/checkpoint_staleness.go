@@ -0,0 +1,52 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+// CheckpointMinStaleness returns how old the published checkpoint must be before it's replaced, taking into
+// account any growth trigger set with tessera.WithCheckpointGrowthTrigger, but never less than minInterval.
+//
+// readCheckpoint returns the currently published checkpoint, and currentTree returns the size and root hash of
+// the integrated tree. If either can't be read, the checkpoint interval from opts is used.
+func CheckpointMinStaleness(ctx context.Context, opts *tessera.AppendOptions, minInterval time.Duration, readCheckpoint func(context.Context) ([]byte, error), currentTree func(context.Context) (uint64, []byte, error)) time.Duration {
+	interval := max(opts.CheckpointInterval(), minInterval)
+	if opts.CheckpointGrowthTrigger() == 0 {
+		return interval
+	}
+	cp, err := readCheckpoint(ctx)
+	if err != nil || cp == nil {
+		// There's no checkpoint to compare against, so let the caller's publishing deal with it.
+		return interval
+	}
+	_, pubSize, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		klog.Warningf("CheckpointMinStaleness: failed to parse published checkpoint: %v", err)
+		return interval
+	}
+	size, _, err := currentTree(ctx)
+	if err != nil {
+		klog.Warningf("CheckpointMinStaleness: currentTree: %v", err)
+		return interval
+	}
+	return max(opts.CheckpointMinStaleness(pubSize, size), minInterval)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+)
+
+func TestCheckpointMinStaleness(t *testing.T) {
+	const (
+		interval    = time.Minute
+		minInterval = time.Second
+	)
+	checkpoint := []byte("example.com/log\n10\nAAAA\n\n— example.com/log sig\n")
+	for _, test := range []struct {
+		name     string
+		trigger  uint64
+		interval time.Duration
+		cp       []byte
+		cpErr    error
+		size     uint64
+		sizeErr  error
+		want     time.Duration
+	}{
+		{
+			name:     "no trigger",
+			interval: interval,
+			cp:       checkpoint,
+			size:     100,
+			want:     interval,
+		}, {
+			name:     "grown past trigger",
+			trigger:  5,
+			interval: interval,
+			cp:       checkpoint,
+			size:     15,
+			want:     minInterval,
+		}, {
+			name:     "not grown enough",
+			trigger:  5,
+			interval: interval,
+			cp:       checkpoint,
+			size:     14,
+			want:     interval,
+		}, {
+			name:     "interval below minimum",
+			interval: time.Millisecond,
+			cp:       checkpoint,
+			size:     10,
+			want:     minInterval,
+		}, {
+			name:     "no checkpoint",
+			trigger:  5,
+			interval: interval,
+			cpErr:    os.ErrNotExist,
+			size:     100,
+			want:     interval,
+		}, {
+			name:     "nil checkpoint",
+			trigger:  5,
+			interval: interval,
+			size:     100,
+			want:     interval,
+		}, {
+			name:     "invalid checkpoint",
+			trigger:  5,
+			interval: interval,
+			cp:       []byte("not a checkpoint"),
+			size:     100,
+			want:     interval,
+		}, {
+			name:     "current tree error",
+			trigger:  5,
+			interval: interval,
+			cp:       checkpoint,
+			sizeErr:  errors.New("boom"),
+			want:     interval,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := tessera.NewAppendOptions().WithCheckpointInterval(test.interval).WithCheckpointGrowthTrigger(test.trigger)
+			readCheckpoint := func(context.Context) ([]byte, error) { return test.cp, test.cpErr }
+			currentTree := func(context.Context) (uint64, []byte, error) { return test.size, nil, test.sizeErr }
+			if got := storage.CheckpointMinStaleness(t.Context(), opts, minInterval, readCheckpoint, currentTree); got != test.want {
+				t.Errorf("CheckpointMinStaleness() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
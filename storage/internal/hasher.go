@@ -0,0 +1,36 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto"
+	"fmt"
+)
+
+// CheckHashFunction returns an error if a log whose Merkle tree was built using the hash function named stored
+// cannot be opened with the hash function want.
+//
+// Storage implementations should persist want.String() when a log is first created, and pass that value back
+// here each time it is subsequently opened. An empty stored value is treated as SHA-256, as that was the only
+// hash function supported by logs created before the hash function was recorded.
+func CheckHashFunction(stored string, want crypto.Hash) error {
+	if stored == "" {
+		stored = crypto.SHA256.String()
+	}
+	if stored != want.String() {
+		return fmt.Errorf("log was created with hash function %s, but %s was requested", stored, want)
+	}
+	return nil
+}
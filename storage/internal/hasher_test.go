@@ -0,0 +1,42 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto"
+	"testing"
+)
+
+func TestCheckHashFunction(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		stored  string
+		want    crypto.Hash
+		wantErr bool
+	}{
+		{name: "legacy log", stored: "", want: crypto.SHA256},
+		{name: "legacy log, other hash", stored: "", want: crypto.SHA512_256, wantErr: true},
+		{name: "match", stored: "SHA-512/256", want: crypto.SHA512_256},
+		{name: "mismatch", stored: "SHA3-256", want: crypto.SHA512_256, wantErr: true},
+		{name: "stored default", stored: "SHA-256", want: crypto.SHA256},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckHashFunction(test.stored, test.want)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("CheckHashFunction(%q, %v) = %v, wantErr %t", test.stored, test.want, err, test.wantErr)
+			}
+		})
+	}
+}
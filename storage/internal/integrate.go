@@ -20,8 +20,8 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/compact"
-	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/internal/otel"
@@ -37,8 +37,12 @@ type SequencedEntry struct {
 	LeafHash []byte
 }
 
-func Integrate(ctx context.Context, getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error), fromSize uint64, leafHashes [][]byte) (newSize uint64, rootHash []byte, tiles map[TileID]*api.HashTile, err error) {
-	tb := newTreeBuilder(getTiles)
+// Integrate adds the provided leaf hashes to the tree of size fromSize, using h to calculate the tree's internal nodes,
+// and dividing the tree into tiles as described by tl.
+//
+// It returns the size and root hash of the new tree, along with the set of tiles which have been created or updated.
+func Integrate(ctx context.Context, getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error), fromSize uint64, leafHashes [][]byte, h merkle.LogHasher, tl layout.Tiling) (newSize uint64, rootHash []byte, tiles map[TileID]*api.HashTile, err error) {
+	tb := newTreeBuilder(getTiles, h, tl)
 	return tb.integrate(ctx, fromSize, leafHashes)
 }
 
@@ -55,17 +59,22 @@ type getPopulatedTileFunc func(ctx context.Context, tileID TileID, treeSize uint
 type treeBuilder struct {
 	readCache *tileReadCache
 	rf        *compact.RangeFactory
+	hasher    merkle.LogHasher
+	tiling    layout.Tiling
 }
 
 // newTreeBuilder creates a new instance of treeBuilder.
 //
 // The getTiles param must know how to fetch the specified tiles from storage. It must return tiles in the same order as the
 // provided tileIDs, substituing nil for any tiles which were not found.
-func newTreeBuilder(getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error)) *treeBuilder {
-	readCache := newTileReadCache(getTiles)
+// The h param is the hasher used to calculate the tree's internal nodes, and tl describes the tiles which hold them.
+func newTreeBuilder(getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error), h merkle.LogHasher, tl layout.Tiling) *treeBuilder {
+	readCache := newTileReadCache(getTiles, h, tl)
 	r := &treeBuilder{
 		readCache: &readCache,
-		rf:        &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren},
+		rf:        &compact.RangeFactory{Hash: h.HashChildren},
+		hasher:    h,
+		tiling:    tl,
 	}
 
 	return r
@@ -76,7 +85,7 @@ func (t *treeBuilder) newRange(ctx context.Context, treeSize uint64) (*compact.R
 	rangeNodes := compact.RangeNodes(0, treeSize, nil)
 	toFetch := make(map[TileID]struct{})
 	for _, id := range rangeNodes {
-		tLevel, tIndex, _, _ := layout.NodeCoordsToTileAddress(uint64(id.Level), id.Index)
+		tLevel, tIndex, _, _ := t.tiling.NodeCoordsToTileAddress(uint64(id.Level), id.Index)
 		toFetch[TileID{Level: tLevel, Index: tIndex}] = struct{}{}
 	}
 	if err := t.readCache.Prewarm(ctx, maps.Keys(toFetch), treeSize); err != nil {
@@ -85,7 +94,7 @@ func (t *treeBuilder) newRange(ctx context.Context, treeSize uint64) (*compact.R
 
 	hashes := make([][]byte, 0, len(rangeNodes))
 	for _, id := range rangeNodes {
-		tLevel, tIndex, nLevel, nIndex := layout.NodeCoordsToTileAddress(uint64(id.Level), id.Index)
+		tLevel, tIndex, nLevel, nIndex := t.tiling.NodeCoordsToTileAddress(uint64(id.Level), id.Index)
 		ft, err := t.readCache.Get(ctx, TileID{Level: tLevel, Index: tIndex}, treeSize)
 		if err != nil {
 			return nil, err
@@ -120,7 +129,7 @@ func (t *treeBuilder) integrate(ctx context.Context, fromSize uint64, leafHashes
 		// C2SP.org/log-tiles says all Merkle operations are those from RFC6962, we need to override
 		// the root of the empty tree to match (compact.Range will return an empty slice).
 		if fromSize == 0 {
-			r = rfc6962.DefaultHasher.EmptyRoot()
+			r = t.hasher.EmptyRoot()
 		}
 		// Nothing to do, nothing done.
 		return fromSize, r, nil, nil
@@ -130,7 +139,7 @@ func (t *treeBuilder) integrate(ctx context.Context, fromSize uint64, leafHashes
 	klog.V(1).Infof("Loaded state with roothash %x", r)
 	// Create a new compact range which represents the update to the tree
 	newRange := t.rf.NewEmptyRange(fromSize)
-	tc := newTileWriteCache(fromSize, t.readCache.Get)
+	tc := newTileWriteCache(fromSize, t.readCache.Get, t.tiling)
 	visitor := tc.Visitor(ctx)
 	for _, e := range leafHashes {
 		// Update range and set nodes
@@ -166,7 +175,7 @@ func (t *treeBuilder) integrate(ctx context.Context, fromSize uint64, leafHashes
 
 	// All calculation is now complete, all that remains is to store the new
 	// tiles and updated log state.
-	klog.V(1).Infof("New log state: size 0x%x hash: %x", baseRange.End(), newRoot)
+	klog.V(1).InfoS("New log state", "size", baseRange.End(), "root", fmt.Sprintf("%x", newRoot))
 
 	return baseRange.End(), newRoot, tc.Tiles(), nil
 
@@ -176,12 +185,16 @@ func (t *treeBuilder) integrate(ctx context.Context, fromSize uint64, leafHashes
 type tileReadCache struct {
 	entries  map[string]*populatedTile
 	getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error)
+	hasher   merkle.LogHasher
+	tiling   layout.Tiling
 }
 
-func newTileReadCache(getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error)) tileReadCache {
+func newTileReadCache(getTiles func(ctx context.Context, tileIDs []TileID, treeSize uint64) ([]*api.HashTile, error), h merkle.LogHasher, tl layout.Tiling) tileReadCache {
 	return tileReadCache{
 		entries:  make(map[string]*populatedTile),
 		getTiles: getTiles,
+		hasher:   h,
+		tiling:   tl,
 	}
 }
 
@@ -192,7 +205,7 @@ func (r *tileReadCache) Get(ctx context.Context, tileID TileID, treeSize uint64)
 
 	span.SetAttributes(indexKey.Int64(otel.Clamp64(tileID.Index)), levelKey.Int64(otel.Clamp64(tileID.Level)), treeSizeKey.Int64(otel.Clamp64(treeSize)))
 
-	k := layout.TilePath(uint64(tileID.Level), tileID.Index, layout.PartialTileSize(tileID.Level, tileID.Index, treeSize))
+	k := r.tiling.TilePath(tileID.Level, tileID.Index, r.tiling.PartialTileSize(tileID.Level, tileID.Index, treeSize))
 	e, ok := r.entries[k]
 	if !ok {
 		klog.V(1).Infof("Readcache miss: %q", k)
@@ -201,7 +214,7 @@ func (r *tileReadCache) Get(ctx context.Context, tileID TileID, treeSize uint64)
 		if err != nil {
 			return nil, err
 		}
-		e, err = newPopulatedTile(t[0])
+		e, err = newPopulatedTile(t[0], r.hasher, r.tiling)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create fulltile: %v", err)
 		}
@@ -222,11 +235,11 @@ func (r *tileReadCache) Prewarm(ctx context.Context, tileIDs []TileID, treeSize
 		return err
 	}
 	for i, tile := range t {
-		e, err := newPopulatedTile(tile)
+		e, err := newPopulatedTile(tile, r.hasher, r.tiling)
 		if err != nil {
 			return fmt.Errorf("failed to create fulltile: %v", err)
 		}
-		k := layout.TilePath(uint64(tileIDs[i].Level), tileIDs[i].Index, layout.PartialTileSize(tileIDs[i].Level, tileIDs[i].Index, treeSize))
+		k := r.tiling.TilePath(tileIDs[i].Level, tileIDs[i].Index, r.tiling.PartialTileSize(tileIDs[i].Level, tileIDs[i].Index, treeSize))
 		r.entries[k] = e
 	}
 	return nil
@@ -246,15 +259,17 @@ type tileWriteCache struct {
 
 	treeSize uint64
 	getTile  getPopulatedTileFunc
+	tiling   layout.Tiling
 }
 
 // newtileWriteCache creates a new cache for the given treeSize, and uses the provided
 // function to fetch existing tiles which are being updated by the Visitor func.
-func newTileWriteCache(treeSize uint64, getTile getPopulatedTileFunc) *tileWriteCache {
+func newTileWriteCache(treeSize uint64, getTile getPopulatedTileFunc, tl layout.Tiling) *tileWriteCache {
 	return &tileWriteCache{
 		m:        make(map[TileID]*populatedTile),
 		treeSize: treeSize,
 		getTile:  getTile,
+		tiling:   tl,
 	}
 }
 
@@ -268,9 +283,9 @@ func (tc *tileWriteCache) Err() error {
 }
 
 // minImpliedTreeSize returns the smallest possible tree size implied by the existence of a tile
-// with the given ID.
-func minImpliedTreeSize(id TileID) uint64 {
-	return (id.Index * layout.TileWidth) << (id.Level * 8)
+// with the given ID in tl.
+func minImpliedTreeSize(id TileID, tl layout.Tiling) uint64 {
+	return (id.Index * tl.Width()) << (id.Level * uint64(tl.Height))
 }
 
 // Visitor returns a function suitable for use with the compact.Range visitor pattern.
@@ -279,7 +294,7 @@ func minImpliedTreeSize(id TileID) uint64 {
 // to their corresponding hash values.
 func (tc *tileWriteCache) Visitor(ctx context.Context) compact.VisitFn {
 	return func(id compact.NodeID, hash []byte) {
-		tileLevel, tileIndex, nodeLevel, nodeIndex := layout.NodeCoordsToTileAddress(uint64(id.Level), uint64(id.Index))
+		tileLevel, tileIndex, nodeLevel, nodeIndex := tc.tiling.NodeCoordsToTileAddress(uint64(id.Level), uint64(id.Index))
 		tileID := TileID{Level: tileLevel, Index: tileIndex}
 		tile := tc.m[tileID]
 		if tile == nil {
@@ -288,7 +303,7 @@ func (tc *tileWriteCache) Visitor(ctx context.Context) compact.VisitFn {
 			// need to try to fetch the tile since it probably doesn't exist.
 			// If it _does_ exist, e.g. due to an earlier crash during integration, we'll discover
 			// any non-idempotency issues when we come to flush these new tiles out.
-			if iSize := minImpliedTreeSize(tileID); iSize <= tc.treeSize {
+			if iSize := minImpliedTreeSize(tileID, tc.tiling); iSize <= tc.treeSize {
 				tile, err = tc.getTile(ctx, tileID, tc.treeSize)
 				if err != nil {
 					tc.err = append(tc.err, err)
@@ -297,7 +312,7 @@ func (tc *tileWriteCache) Visitor(ctx context.Context) compact.VisitFn {
 			}
 			if tile == nil {
 				// No tile found in storage: this is a brand new tile being created due to tree growth.
-				tile, err = newPopulatedTile(nil)
+				tile, err = newPopulatedTile(nil, nil, tc.tiling)
 				if err != nil {
 					tc.err = append(tc.err, err)
 					return
@@ -325,18 +340,22 @@ func (tc *tileWriteCache) Tiles() map[TileID]*api.HashTile {
 type populatedTile struct {
 	inner  map[compact.NodeID][]byte
 	leaves [][]byte
+	// width is the maximum number of leaves the tile can hold.
+	width uint64
 }
 
-// newPopulatedTile creates and populates a fullTile struct based on the passed in HashTile data.
-func newPopulatedTile(h *api.HashTile) (*populatedTile, error) {
+// newPopulatedTile creates and populates a fullTile struct based on the passed in HashTile data, using hasher
+// to calculate the tile's internal nodes. The tile is one of those described by tl.
+func newPopulatedTile(h *api.HashTile, hasher merkle.LogHasher, tl layout.Tiling) (*populatedTile, error) {
 	ft := &populatedTile{
 		inner:  make(map[compact.NodeID][]byte),
-		leaves: make([][]byte, 0, layout.TileWidth),
+		leaves: make([][]byte, 0, tl.Width()),
+		width:  tl.Width(),
 	}
 
 	if h != nil {
 		// TODO: it might be better if we calculate (and cache) nodes in get, so we don't do more work that necessary.
-		r := (&compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}).NewEmptyRange(0)
+		r := (&compact.RangeFactory{Hash: hasher.HashChildren}).NewEmptyRange(0)
 		for _, h := range h.Nodes {
 			if err := r.Append(h, ft.Set); err != nil {
 				return nil, fmt.Errorf("failed to append to range: %v", err)
@@ -350,7 +369,7 @@ func newPopulatedTile(h *api.HashTile) (*populatedTile, error) {
 // It's intended to be used as a visitor for compact.Range.
 func (f *populatedTile) Set(id compact.NodeID, hash []byte) {
 	if id.Level == 0 {
-		if id.Index > 255 {
+		if id.Index >= f.width {
 			panic(fmt.Sprintf("Weird node ID: %v", id))
 		}
 		if l, idx := uint64(len(f.leaves)), id.Index; idx >= l {
@@ -16,12 +16,16 @@ package storage
 
 import (
 	"context"
+	"crypto"
+	_ "crypto/sha3"
+	_ "crypto/sha512"
 	"fmt"
 	"reflect"
 	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/compact"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
@@ -32,8 +36,8 @@ import (
 
 func TestNewRangeFetchesTiles(t *testing.T) {
 	ctx := context.Background()
-	m := newMemTileStore[api.HashTile]()
-	tb := newTreeBuilder(m.getTiles)
+	m := newMemTileStore[api.HashTile](layout.DefaultTiling)
+	tb := newTreeBuilder(m.getTiles, rfc6962.DefaultHasher, layout.DefaultTiling)
 
 	treeSize := uint64(0x102030)
 	wantIDs := []TileID{
@@ -56,7 +60,7 @@ func TestNewRangeFetchesTiles(t *testing.T) {
 
 func TestTileVisit(t *testing.T) {
 	ctx := context.Background()
-	m := newMemTileStore[populatedTile]()
+	m := newMemTileStore[populatedTile](layout.DefaultTiling)
 	treeSize := uint64(0x102030)
 
 	for _, test := range []struct {
@@ -89,7 +93,7 @@ func TestTileVisit(t *testing.T) {
 			},
 		},
 	} {
-		twc := newTileWriteCache(treeSize, m.getTile)
+		twc := newTileWriteCache(treeSize, m.getTile, layout.DefaultTiling)
 		v := twc.Visitor(ctx)
 		for id, k := range test.visits {
 			v(id, k)
@@ -120,51 +124,67 @@ func TestTileVisit(t *testing.T) {
 }
 
 func TestIntegrate(t *testing.T) {
-	ctx := context.Background()
-	m := newMemTileStore[api.HashTile]()
+	for _, test := range []struct {
+		name   string
+		hasher merkle.LogHasher
+		tiling layout.Tiling
+	}{
+		{name: "SHA-256", hasher: rfc6962.DefaultHasher, tiling: layout.DefaultTiling},
+		{name: "SHA-512/256", hasher: rfc6962.New(crypto.SHA512_256), tiling: layout.DefaultTiling},
+		{name: "SHA3-256", hasher: rfc6962.New(crypto.SHA3_256), tiling: layout.DefaultTiling},
+		{name: "height 4", hasher: rfc6962.DefaultHasher, tiling: layout.Tiling{Height: 4}},
+		{name: "height 1", hasher: rfc6962.DefaultHasher, tiling: layout.Tiling{Height: 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := newMemTileStore[api.HashTile](test.tiling)
 
-	cr := (&compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}).NewEmptyRange(0)
+			cr := (&compact.RangeFactory{Hash: test.hasher.HashChildren}).NewEmptyRange(0)
 
-	chunkSize := 200
-	numChunks := 1000
-	seq := uint64(0)
-	for chunk := range numChunks {
-		oldSeq := seq
-		c := make([][]byte, chunkSize)
-		for i := range c {
-			leaf := []byte{byte(seq)}
-			entry := tessera.NewEntry(leaf)
-			c[i] = entry.LeafHash()
-			if err := cr.Append(rfc6962.DefaultHasher.HashLeaf(leaf), nil); err != nil {
-				t.Fatalf("compact Append: %v", err)
+			chunkSize := 200
+			numChunks := 1000
+			seq := uint64(0)
+			for chunk := range numChunks {
+				oldSeq := seq
+				c := make([][]byte, chunkSize)
+				for i := range c {
+					leaf := []byte{byte(seq)}
+					c[i] = test.hasher.HashLeaf(leaf)
+					if err := cr.Append(c[i], nil); err != nil {
+						t.Fatalf("compact Append: %v", err)
+					}
+					seq++
+				}
+				wantRoot, err := cr.GetRootHash(nil)
+				if err != nil {
+					t.Fatalf("[%d] compactRange: %v", chunk, err)
+				}
+				gotSize, gotRoot, gotTiles, err := Integrate(ctx, m.getTiles, oldSeq, c, test.hasher, test.tiling)
+				if err != nil {
+					t.Fatalf("[%d] Integrate: %v", chunk, err)
+				}
+				if wantSize := seq; gotSize != wantSize {
+					t.Errorf("[%d] Got size %d, want %d", chunk, gotSize, wantSize)
+				}
+				if !cmp.Equal(gotRoot, wantRoot) {
+					t.Errorf("[%d] Got root %x, want %x", chunk, gotRoot, wantRoot)
+				}
+				for k, tile := range gotTiles {
+					if l := uint64(len(tile.Nodes)); l > test.tiling.Width() {
+						t.Fatalf("[%d] tile %v has %d nodes, want at most %d", chunk, k, l, test.tiling.Width())
+					}
+					if err := m.setTile(ctx, k, seq, tile); err != nil {
+						t.Fatalf("setTile: %v", err)
+					}
+				}
 			}
-			seq++
-		}
-		wantRoot, err := cr.GetRootHash(nil)
-		if err != nil {
-			t.Fatalf("[%d] compactRange: %v", chunk, err)
-		}
-		gotSize, gotRoot, gotTiles, err := Integrate(ctx, m.getTiles, oldSeq, c)
-		if err != nil {
-			t.Fatalf("[%d] Integrate: %v", chunk, err)
-		}
-		if wantSize := seq; gotSize != wantSize {
-			t.Errorf("[%d] Got size %d, want %d", chunk, gotSize, wantSize)
-		}
-		if !cmp.Equal(gotRoot, wantRoot) {
-			t.Errorf("[%d] Got root %x, want %x", chunk, gotRoot, wantRoot)
-		}
-		for k, tile := range gotTiles {
-			if err := m.setTile(ctx, k, seq, tile); err != nil {
-				t.Fatalf("setTile: %v", err)
-			}
-		}
+		})
 	}
 }
 
 func BenchmarkIntegrate(b *testing.B) {
 	ctx := context.Background()
-	m := newMemTileStore[api.HashTile]()
+	m := newMemTileStore[api.HashTile](layout.DefaultTiling)
 
 	chunkSize := 200
 	seq := uint64(0)
@@ -177,7 +197,7 @@ func BenchmarkIntegrate(b *testing.B) {
 			c[i] = entry.LeafHash()
 			seq++
 		}
-		_, _, gotTiles, err := Integrate(ctx, m.getTiles, oldSeq, c)
+		_, _, gotTiles, err := Integrate(ctx, m.getTiles, oldSeq, c, rfc6962.DefaultHasher, layout.DefaultTiling)
 		if err != nil {
 			b.Fatalf("[%d] Integrate: %v", chunk, err)
 		}
@@ -202,12 +222,14 @@ func zeroTile(size uint64) *api.HashTile {
 
 type memTileStore[T any] struct {
 	sync.RWMutex
-	mem map[string]*T
+	mem    map[string]*T
+	tiling layout.Tiling
 }
 
-func newMemTileStore[T any]() *memTileStore[T] {
+func newMemTileStore[T any](tl layout.Tiling) *memTileStore[T] {
 	return &memTileStore[T]{
-		mem: make(map[string]*T),
+		mem:    make(map[string]*T),
+		tiling: tl,
 	}
 }
 
@@ -215,7 +237,7 @@ func (m *memTileStore[T]) getTile(_ context.Context, id TileID, treeSize uint64)
 	m.RLock()
 	defer m.RUnlock()
 
-	k := layout.TilePath(id.Level, id.Index, layout.PartialTileSize(id.Level, id.Index, treeSize))
+	k := m.tiling.TilePath(id.Level, id.Index, m.tiling.PartialTileSize(id.Level, id.Index, treeSize))
 	d := m.mem[k]
 	return d, nil
 }
@@ -226,7 +248,7 @@ func (m *memTileStore[T]) getTiles(_ context.Context, ids []TileID, treeSize uin
 
 	r := make([]*T, len(ids))
 	for i, id := range ids {
-		k := layout.TilePath(id.Level, id.Index, layout.PartialTileSize(id.Level, id.Index, treeSize))
+		k := m.tiling.TilePath(id.Level, id.Index, m.tiling.PartialTileSize(id.Level, id.Index, treeSize))
 		klog.V(1).Infof("mem.getTile(%q, %d)", k, treeSize)
 		d, ok := m.mem[k]
 		if !ok {
@@ -241,7 +263,7 @@ func (m *memTileStore[T]) setTile(_ context.Context, id TileID, treeSize uint64,
 	m.Lock()
 	defer m.Unlock()
 
-	k := layout.TilePath(id.Level, id.Index, layout.PartialTileSize(id.Level, id.Index, treeSize))
+	k := m.tiling.TilePath(id.Level, id.Index, m.tiling.PartialTileSize(id.Level, id.Index, treeSize))
 	klog.V(1).Infof("mem.setTile(%q, %d)", k, treeSize)
 	_, ok := m.mem[k]
 	if ok {
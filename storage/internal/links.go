@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/transparency-dev/tessera"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxPendingLinks is the most spans which SpanLinks will remember. It matches the
+// OpenTelemetry SDK's default limit on the number of links a span may have.
+const maxPendingLinks = 128
+
+// SpanLinks remembers the spans which sequenced or integrated entries, so that the spans of
+// the asynchronous stages which later process those entries can be linked to them. This lets
+// the time taken by an Add be followed through sequencing, integration, and publication.
+//
+// Only sampled spans from the current process are remembered, and at most maxPendingLinks of
+// them, so links may be missing if the stages run in different processes, or fall far behind.
+//
+// The zero value is ready to use, and it's safe for concurrent use.
+type SpanLinks struct {
+	mu      sync.Mutex
+	pending []pendingLink
+}
+
+type pendingLink struct {
+	// size is the smallest tree size which contains all of the entries processed by the span.
+	size uint64
+	sc   trace.SpanContext
+}
+
+// Add remembers the span in ctx, if it's sampled, as having processed entries below size.
+func (l *SpanLinks) Add(ctx context.Context, size uint64) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) == maxPendingLinks {
+		l.pending = l.pending[1:]
+	}
+	l.pending = append(l.pending, pendingLink{size: size, sc: sc})
+}
+
+// Link adds links to span for the remembered spans whose entries are all contained by a tree
+// of the given size, and forgets them. If span isn't recording, the spans are kept so that
+// they can be linked to a later span.
+func (l *SpanLinks) Link(span trace.Span, size uint64) {
+	if !span.IsRecording() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keep := l.pending[:0]
+	for _, p := range l.pending {
+		if p.size > size {
+			keep = append(keep, p)
+			continue
+		}
+		span.AddLink(trace.Link{SpanContext: p.sc})
+	}
+	l.pending = keep
+}
+
+// WithSpanLinks returns a FlushFunc which calls f, and then, if f succeeded, adds the span in its
+// context to l as having sequenced the entries which were flushed.
+//
+// Storage implementations which integrate entries asynchronously should use this so that their
+// integration spans can be linked to the spans which sequenced the entries.
+func WithSpanLinks(f FlushFunc, l *SpanLinks) FlushFunc {
+	return func(ctx context.Context, entries []*tessera.Entry) error {
+		if err := f(ctx, entries); err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			if i := entries[len(entries)-1].Index(); i != nil {
+				l.Add(ctx, *i+1)
+			}
+		}
+		return nil
+	}
+}
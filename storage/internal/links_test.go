@@ -0,0 +1,101 @@
+// Copyright 2024 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/transparency-dev/tessera"
+	storage "github.com/transparency-dev/tessera/storage/internal"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanLinks(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	ctx := t.Context()
+
+	l := &storage.SpanLinks{}
+	add := func(size uint64) trace.SpanID {
+		ctx, span := tracer.Start(ctx, "add")
+		defer span.End()
+		l.Add(ctx, size)
+		return span.SpanContext().SpanID()
+	}
+	first, second := add(10), add(20)
+	// Spans which aren't sampled are never linked to.
+	l.Add(context.Background(), 5)
+
+	links := func(size uint64) []trace.SpanID {
+		_, span := tracer.Start(ctx, "link")
+		l.Link(span, size)
+		span.End()
+		r := []trace.SpanID{}
+		for _, link := range sr.Ended()[len(sr.Ended())-1].Links() {
+			r = append(r, link.SpanContext.SpanID())
+		}
+		return r
+	}
+	for _, test := range []struct {
+		size uint64
+		want []trace.SpanID
+	}{
+		{size: 9, want: []trace.SpanID{}},
+		{size: 15, want: []trace.SpanID{first}},
+		// Spans are forgotten once they've been linked to.
+		{size: 25, want: []trace.SpanID{second}},
+		{size: 25, want: []trace.SpanID{}},
+	} {
+		if got := links(test.size); len(got) != len(test.want) || (len(got) > 0 && got[0] != test.want[0]) {
+			t.Errorf("Link(%d) linked to %v, want %v", test.size, got, test.want)
+		}
+	}
+}
+
+func TestWithSpanLinks(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+	ctx, span := tracer.Start(t.Context(), "flush")
+	defer span.End()
+
+	l := &storage.SpanLinks{}
+	f := storage.WithSpanLinks(func(_ context.Context, entries []*tessera.Entry) error {
+		for i, e := range entries {
+			_ = e.MarshalBundleData(uint64(40 + i))
+		}
+		return nil
+	}, l)
+	if err := f(ctx, []*tessera.Entry{tessera.NewEntry([]byte("a")), tessera.NewEntry([]byte("b"))}); err != nil {
+		t.Fatalf("FlushFunc: %v", err)
+	}
+
+	for _, test := range []struct {
+		size      uint64
+		wantLinks int
+	}{
+		{size: 41, wantLinks: 0},
+		{size: 42, wantLinks: 1},
+	} {
+		_, s := tracer.Start(t.Context(), "integrate")
+		l.Link(s, test.size)
+		s.End()
+		if got := len(sr.Ended()[len(sr.Ended())-1].Links()); got != test.wantLinks {
+			t.Errorf("Link(%d) added %d links, want %d", test.size, got, test.wantLinks)
+		}
+	}
+}
@@ -17,14 +17,46 @@ package storage
 import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
 )
 
 const name = "github.com/transparency-dev/tessera/storage"
 
 var (
+	meter  = otel.Meter(name)
 	tracer = otel.Tracer(name)
 )
 
+var (
+	queueFlushSize metric.Int64Histogram
+	verifyCount    metric.Int64Counter
+
+	// Batch sizes are bounded by the configured maximum, which defaults to 256.
+	batchSizeBuckets = []float64{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096}
+)
+
+func init() {
+	var err error
+
+	queueFlushSize, err = meter.Int64Histogram(
+		"tessera.appender.batch.size",
+		metric.WithDescription("Number of entries in each batch passed to storage for sequencing"),
+		metric.WithUnit("{entry}"),
+		metric.WithExplicitBucketBoundaries(batchSizeBuckets...))
+	if err != nil {
+		klog.Exitf("Failed to create queueFlushSize metric: %v", err)
+	}
+
+	verifyCount, err = meter.Int64Counter(
+		"tessera.storage.verify.count",
+		metric.WithDescription("Number of objects re-read after being written to verify their contents, by result"),
+		metric.WithUnit("{object}"))
+	if err != nil {
+		klog.Exitf("Failed to create verifyCount metric: %v", err)
+	}
+}
+
 var (
 	fromSizeKey   = attribute.Key("tessera.fromSize")
 	numEntriesKey = attribute.Key("tessera.numEntries")
@@ -32,4 +64,6 @@ var (
 	treeSizeKey = attribute.Key("tessera.treeSize")
 	indexKey    = attribute.Key("tessera.index")
 	levelKey    = attribute.Key("tessera.level")
+
+	verifyResultKey = attribute.Key("tessera.storage.verify.result")
 )
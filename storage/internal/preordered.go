@@ -0,0 +1,34 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/transparency-dev/tessera"
+)
+
+// CheckPreordered returns an error if any of the provided entries, which are about to be assigned contiguous
+// indices starting at first, must be assigned a different index.
+//
+// Storage implementations should call this before durably sequencing a batch of entries.
+func CheckPreordered(first uint64, entries []*tessera.Entry) error {
+	for i, e := range entries {
+		if want := e.PreorderedIndex(); want != nil && *want != first+uint64(i) {
+			return fmt.Errorf("preordered entry with index %d would be assigned index %d", *want, first+uint64(i))
+		}
+	}
+	return nil
+}
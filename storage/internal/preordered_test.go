@@ -0,0 +1,51 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/transparency-dev/tessera"
+)
+
+func TestCheckPreordered(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		first   uint64
+		entries []*tessera.Entry
+		wantErr bool
+	}{
+		{
+			name:    "not preordered",
+			first:   10,
+			entries: []*tessera.Entry{tessera.NewEntry(nil), tessera.NewEntry(nil)},
+		}, {
+			name:    "matching",
+			first:   10,
+			entries: []*tessera.Entry{tessera.NewPreorderedEntry(nil, 10), tessera.NewEntry(nil), tessera.NewPreorderedEntry(nil, 12)},
+		}, {
+			name:    "mismatch",
+			first:   11,
+			entries: []*tessera.Entry{tessera.NewPreorderedEntry(nil, 11), tessera.NewPreorderedEntry(nil, 13)},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := CheckPreordered(test.first, test.entries); (err != nil) != test.wantErr {
+				t.Errorf("CheckPreordered: got err %v, want err %t", err, test.wantErr)
+			}
+		})
+	}
+}
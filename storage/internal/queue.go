@@ -17,12 +17,23 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/internal/future"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Queue knows how to queue up a number of entries in order.
@@ -30,15 +41,35 @@ import (
 // When the buffered queue grows past a defined size, or the age of the oldest entry in the
 // queue reaches a defined threshold, the queue will call a provided FlushFunc with
 // a slice containing all queued entries in the same order as they were added.
+//
+// If the context passed to Add or AddBatch is done before the entry has been handed to the
+// FlushFunc, the entry is removed from the queue and its future resolves to the context's error.
 type Queue struct {
 	maxSize uint
 	maxAge  time.Duration
 
-	timer *time.Timer
-	work  chan []queueItem
+	// ctx is the context passed to NewQueue, once it's done the worker no longer receives from work.
+	ctx  context.Context
+	work chan []*queueItem
 
 	mu    sync.Mutex
-	items []queueItem
+	timer *time.Timer
+	items []*queueItem
+	// nextSeq is the sequence number which will be given to the next batch taken from items.
+	nextSeq uint64
+
+	// sendMu guards sendSeq, and sendCond is signalled each time it changes.
+	// Batches are taken from items while holding mu, but are sent to the worker after mu has been released
+	// so that a slow flush doesn't hold up Add calls. Batches are sent strictly in sequence number order.
+	sendMu   sync.Mutex
+	sendCond *sync.Cond
+	sendSeq  uint64
+}
+
+// batch is a set of queued items which have been taken from the queue to be flushed.
+type batch struct {
+	seq   uint64
+	items []*queueItem
 }
 
 // FlushFunc is the signature of a function which will receive the slice of queued entries.
@@ -48,6 +79,26 @@ type Queue struct {
 // See the comment on Entry.MarshalBundleData for further info.
 type FlushFunc func(ctx context.Context, entries []*tessera.Entry) error
 
+// WithSequenceHook returns a FlushFunc which calls f, and then, if f succeeded and hook is not nil, calls hook
+// with the entries which were flushed.
+//
+// Storage implementations should use this to wrap their FlushFunc with the hook returned by
+// AppendOptions.SequenceHook.
+func WithSequenceHook(f FlushFunc, hook func(context.Context, []*tessera.Entry)) FlushFunc {
+	if hook == nil {
+		return f
+	}
+	return func(ctx context.Context, entries []*tessera.Entry) error {
+		if err := f(ctx, entries); err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			hook(ctx, entries)
+		}
+		return nil
+	}
+}
+
 // NewQueue creates a new queue with the specified maximum age and size.
 //
 // The provided FlushFunc will be called with a slice containing the contents of the queue, in
@@ -57,9 +108,11 @@ func NewQueue(ctx context.Context, maxAge time.Duration, maxSize uint, f FlushFu
 	q := &Queue{
 		maxSize: maxSize,
 		maxAge:  maxAge,
-		work:    make(chan []queueItem, 1),
-		items:   make([]queueItem, 0, maxSize),
+		ctx:     ctx,
+		work:    make(chan []*queueItem, 1),
+		items:   make([]*queueItem, 0, maxSize),
 	}
+	q.sendCond = sync.NewCond(&q.sendMu)
 
 	// Spin off a worker thread to write the queue flushes to storage.
 	go func(ctx context.Context) {
@@ -77,11 +130,15 @@ func NewQueue(ctx context.Context, maxAge time.Duration, maxSize uint, f FlushFu
 
 // Add places e into the queue, and returns a func which should be called to retrieve the assigned index.
 func (q *Queue) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFuture {
-	qi := newEntry(e)
+	if err := ctx.Err(); err != nil {
+		return func() (tessera.Index, error) { return tessera.Index{}, err }
+	}
+	qi := newEntry(ctx, e)
 
 	q.mu.Lock()
 
 	q.items = append(q.items, qi)
+	q.cancelOnDone(ctx, qi)
 
 	// If this is the first item, start the timer.
 	if len(q.items) == 1 {
@@ -89,33 +146,99 @@ func (q *Queue) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFuture {
 	}
 
 	// If we've reached max size, flush.
-	var itemsToFlush []queueItem
+	var b *batch
 	if len(q.items) >= int(q.maxSize) {
-		itemsToFlush = q.flushLocked()
+		b = q.flushLocked()
 	}
 	q.mu.Unlock()
 
-	if itemsToFlush != nil {
-		q.work <- itemsToFlush
+	if b != nil {
+		q.send(b)
 	}
 
 	return qi.f
 }
 
+// AddBatch places all of the provided entries into the queue contiguously and in order, and returns a slice
+// of funcs, one per entry, which should be called to retrieve the assigned indices.
+//
+// This is cheaper than calling Add for each entry, as the queue lock is only taken once.
+func (q *Queue) AddBatch(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+	if err := ctx.Err(); err != nil {
+		r := make([]tessera.IndexFuture, 0, len(entries))
+		for range entries {
+			r = append(r, func() (tessera.Index, error) { return tessera.Index{}, err })
+		}
+		return r
+	}
+	r := make([]tessera.IndexFuture, 0, len(entries))
+	var toFlush []*batch
+
+	q.mu.Lock()
+	for _, e := range entries {
+		qi := newEntry(ctx, e)
+		q.items = append(q.items, qi)
+		q.cancelOnDone(ctx, qi)
+		r = append(r, qi.f)
+
+		if len(q.items) == 1 {
+			q.timer = time.AfterFunc(q.maxAge, q.flush)
+		}
+		if len(q.items) >= int(q.maxSize) {
+			toFlush = append(toFlush, q.flushLocked())
+		}
+	}
+	q.mu.Unlock()
+
+	for _, b := range toFlush {
+		q.send(b)
+	}
+
+	return r
+}
+
+// cancelOnDone arranges for qi to be removed from the queue if ctx becomes done before qi is flushed.
+// Decorators which share one queued entry between several callers, such as deduplication, pass a context
+// without cancellation so that one caller giving up doesn't fail the entry for the others.
+//
+// Must be called with q.mu held.
+func (q *Queue) cancelOnDone(ctx context.Context, qi *queueItem) {
+	if ctx.Done() == nil {
+		return
+	}
+	qi.stop = context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		i := slices.Index(q.items, qi)
+		if i < 0 {
+			// Already flushed, so it's too late to back out.
+			q.mu.Unlock()
+			return
+		}
+		q.items = slices.Delete(q.items, i, i+1)
+		if len(q.items) == 0 && q.timer != nil {
+			q.timer.Stop()
+			q.timer = nil
+		}
+		q.mu.Unlock()
+		qi.set(tessera.Index{}, ctx.Err())
+	})
+}
+
 // flush is called by the timer to flush the buffer.
 func (q *Queue) flush() {
 	q.mu.Lock()
-	itemsToFlush := q.flushLocked()
+	b := q.flushLocked()
 	q.mu.Unlock()
 
-	if itemsToFlush != nil {
-		q.work <- itemsToFlush
+	if b != nil {
+		q.send(b)
 	}
 }
 
 // flushLocked must be called with q.mu held.
-// It prepares items for flushing and returns them.
-func (q *Queue) flushLocked() []queueItem {
+// It takes the queued items out of the queue and returns them as the next batch to be passed to send, or
+// nil if the queue is empty.
+func (q *Queue) flushLocked() *batch {
 	if len(q.items) == 0 {
 		return nil
 	}
@@ -126,22 +249,68 @@ func (q *Queue) flushLocked() []queueItem {
 	}
 
 	itemsToFlush := q.items
-	q.items = make([]queueItem, 0, q.maxSize)
+	q.items = make([]*queueItem, 0, q.maxSize)
+	for _, qi := range itemsToFlush {
+		// These entries are committed to being flushed, so can no longer be cancelled.
+		if qi.stop != nil {
+			qi.stop()
+		}
+	}
 
-	return itemsToFlush
+	b := &batch{seq: q.nextSeq, items: itemsToFlush}
+	q.nextSeq++
+	return b
 }
 
-// doFlush handles the queue flush, and sending notifications of assigned log indices.
-func (q *Queue) doFlush(ctx context.Context, f FlushFunc, entries []queueItem) {
-	ctx, span := tracer.Start(ctx, "tessera.storage.queue.doFlush")
-	defer span.End()
+// send hands b to the worker once all batches taken before it have been handed over, so that batches
+// are flushed in the same order as their entries were added.
+//
+// send must not be called with q.mu held. If the queue's context is done before the worker accepts b,
+// the items in b are failed with the context's error.
+func (q *Queue) send(b *batch) {
+	q.sendMu.Lock()
+	for q.sendSeq != b.seq {
+		q.sendCond.Wait()
+	}
+	q.sendMu.Unlock()
 
+	select {
+	case q.work <- b.items:
+	case <-q.ctx.Done():
+		for _, qi := range b.items {
+			qi.set(tessera.Index{}, q.ctx.Err())
+		}
+	}
+
+	q.sendMu.Lock()
+	q.sendSeq++
+	q.sendCond.Broadcast()
+	q.sendMu.Unlock()
+}
+
+// doFlush handles the queue flush, and sending notifications of assigned log indices.
+//
+// The flush runs in the queue's context rather than that of any of the Add calls, so its span is
+// linked to the spans of the Add calls whose entries it contains.
+func (q *Queue) doFlush(ctx context.Context, f FlushFunc, entries []*queueItem) {
 	entriesData := make([]*tessera.Entry, 0, len(entries))
+	links := []trace.Link{}
+	linked := make(map[trace.SpanID]bool)
 	for _, e := range entries {
 		entriesData = append(entriesData, e.entry)
+		// Entries added by a single AddBatch call share a span.
+		if e.sc.IsSampled() && !linked[e.sc.SpanID()] {
+			linked[e.sc.SpanID()] = true
+			links = append(links, trace.Link{SpanContext: e.sc})
+		}
 	}
 
-	err := f(ctx, entriesData)
+	ctx, span := tracer.Start(ctx, "tessera.storage.queue.doFlush", trace.WithLinks(links...))
+	defer span.End()
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
+	queueFlushSize.Record(ctx, int64(len(entriesData)))
+
+	err := classifyFlushError(f(ctx, entriesData))
 
 	// Send assigned indices to all the waiting Add() requests
 	for _, e := range entries {
@@ -149,6 +318,35 @@ func (q *Queue) doFlush(ctx context.Context, f FlushFunc, entries []queueItem) {
 	}
 }
 
+// classifyFlushError returns err wrapped in tessera.ErrStorageUnavailable if it's one of the errors known to be
+// caused by storage being temporarily unreachable. Any other error is returned unchanged, so that errors which
+// retrying won't fix, e.g. logic errors, aren't reported as storage being unavailable.
+func classifyFlushError(err error) error {
+	if err == nil || errors.Is(err, tessera.ErrStorageUnavailable) || !isTransient(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", tessera.ErrStorageUnavailable, err)
+}
+
+// isTransient returns true if err was caused by a failure to reach storage, or storage reporting that it's
+// unavailable.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var nErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ETIMEDOUT) ||
+		errors.As(err, &nErr) ||
+		status.Code(err) == codes.Unavailable
+}
+
 // queueItem represents an in-flight queueItem in the queue.
 //
 // The f field acts as a future for the queueItem's assigned index/error, and will
@@ -157,15 +355,20 @@ type queueItem struct {
 	entry *tessera.Entry
 	f     tessera.IndexFuture
 	set   func(tessera.Index, error)
+	// stop, if set, prevents the queueItem from being cancelled when its context is done.
+	stop func() bool
+	// sc is the span context of the call which added the entry, if any.
+	sc trace.SpanContext
 }
 
-// newEntry creates a new entry for the provided data.
-func newEntry(data *tessera.Entry) queueItem {
+// newEntry creates a new entry for the provided data, added by a call with the provided context.
+func newEntry(ctx context.Context, data *tessera.Entry) *queueItem {
 	f, set := future.NewFutureErr[tessera.Index]()
-	e := queueItem{
+	e := &queueItem{
 		entry: data,
 		f:     f.Get,
 		set:   set,
+		sc:    trace.SpanContextFromContext(ctx),
 	}
 	return e
 }
@@ -16,14 +16,20 @@ package storage_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"reflect"
+	"slices"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/transparency-dev/tessera"
 	storage "github.com/transparency-dev/tessera/storage/internal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestQueue(t *testing.T) {
@@ -124,3 +130,299 @@ func BenchmarkQueue(b *testing.B) {
 		}
 	}
 }
+
+func TestQueueAddBatch(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		next    uint64
+		flushes int
+	)
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		flushes++
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+		}
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Second, 10, flushFunc)
+
+	entries := make([]*tessera.Entry, 25)
+	for i := range entries {
+		entries[i] = tessera.NewEntry(fmt.Appendf(nil, "item %d", i))
+	}
+	fs := q.AddBatch(ctx, entries)
+	if got, want := len(fs), len(entries); got != want {
+		t.Fatalf("got %d futures, want %d", got, want)
+	}
+	for i, f := range fs {
+		idx, err := f()
+		if err != nil {
+			t.Fatalf("future %d: %v", i, err)
+		}
+		// The batch is the only thing added to the queue, so indices should be assigned contiguously and in order.
+		if got, want := idx.Index, uint64(i); got != want {
+			t.Errorf("future %d: got index %d, want %d", i, got, want)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := flushes, 3; got != want {
+		t.Errorf("got %d flushes, want %d", got, want)
+	}
+}
+
+func TestQueueAddBatchOrderedWithTimer(t *testing.T) {
+	ctx := t.Context()
+	var (
+		mu   sync.Mutex
+		next uint64
+	)
+	flushFunc := func(_ context.Context, entries []*tessera.Entry) error {
+		// Flushing slowly means full batches queue up behind the worker while the timer fires.
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+		}
+		return nil
+	}
+	q := storage.NewQueue(ctx, time.Millisecond, 2, flushFunc)
+
+	var wg sync.WaitGroup
+	for b := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entries := make([]*tessera.Entry, 5)
+			for i := range entries {
+				entries[i] = tessera.NewEntry(fmt.Appendf(nil, "batch %d item %d", b, i))
+			}
+			fs := q.AddBatch(ctx, entries)
+			first, err := fs[0]()
+			if err != nil {
+				t.Errorf("batch %d future 0: %v", b, err)
+				return
+			}
+			for i, f := range fs {
+				idx, err := f()
+				if err != nil {
+					t.Errorf("batch %d future %d: %v", b, i, err)
+					return
+				}
+				if got, want := idx.Index, first.Index+uint64(i); got != want {
+					t.Errorf("batch %d future %d: got index %d, want %d", b, i, got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWithSequenceHook(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name      string
+		flushErr  error
+		wantCalls int
+	}{
+		{name: "flush succeeds", wantCalls: 1},
+		{name: "flush fails", flushErr: fmt.Errorf("bang"), wantCalls: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			f := storage.WithSequenceHook(
+				func(_ context.Context, _ []*tessera.Entry) error { return test.flushErr },
+				func(_ context.Context, entries []*tessera.Entry) {
+					calls++
+					if len(entries) != 2 {
+						t.Errorf("hook called with %d entries, want 2", len(entries))
+					}
+				})
+			if err := f(ctx, []*tessera.Entry{tessera.NewEntry(nil), tessera.NewEntry(nil)}); err != test.flushErr {
+				t.Errorf("got error %v, want %v", err, test.flushErr)
+			}
+			if calls != test.wantCalls {
+				t.Errorf("hook called %d times, want %d", calls, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestQueueErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		flushErr error
+		want     []error
+	}{
+		{name: "storage failure", flushErr: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: []error{tessera.ErrStorageUnavailable}},
+		{name: "network failure", flushErr: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, want: []error{tessera.ErrStorageUnavailable}},
+		{name: "grpc unavailable", flushErr: status.Error(codes.Unavailable, "try again"), want: []error{tessera.ErrStorageUnavailable}},
+		{name: "logic error", flushErr: errors.New("preordered index mismatch")},
+		{name: "pushback", flushErr: fmt.Errorf("too busy: %w", tessera.ErrPushback), want: []error{tessera.ErrPushback}},
+		{name: "not active", flushErr: tessera.ErrLogNotActive, want: []error{tessera.ErrLogNotActive}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			q := storage.NewQueue(ctx, time.Millisecond, 10, func(_ context.Context, entries []*tessera.Entry) error {
+				for i, e := range entries {
+					_ = e.MarshalBundleData(uint64(i))
+				}
+				return test.flushErr
+			})
+			_, err := q.Add(ctx, tessera.NewEntry(nil))()
+			for _, w := range test.want {
+				if !errors.Is(err, w) {
+					t.Errorf("got error %v, want %v", err, w)
+				}
+			}
+			if !errors.Is(err, test.flushErr) {
+				t.Errorf("got error %v, want it to wrap %v", err, test.flushErr)
+			}
+			if !slices.Contains(test.want, tessera.ErrStorageUnavailable) && errors.Is(err, tessera.ErrStorageUnavailable) {
+				t.Errorf("error %v should not be reported as storage unavailable", err)
+			}
+		})
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var flushed []string
+	q := storage.NewQueue(ctx, 100*time.Millisecond, 3, func(_ context.Context, entries []*tessera.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, e := range entries {
+			flushed = append(flushed, string(e.Data()))
+			_ = e.MarshalBundleData(uint64(i))
+		}
+		return nil
+	})
+
+	doneCtx, doneCancel := context.WithCancel(ctx)
+	doneCancel()
+	if _, err := q.Add(doneCtx, tessera.NewEntry([]byte("done")))(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Add with done context: got error %v, want %v", err, context.Canceled)
+	}
+
+	aCtx, aCancel := context.WithCancel(ctx)
+	a := q.Add(ctx, tessera.NewEntry([]byte("a")))
+	b := q.Add(aCtx, tessera.NewEntry([]byte("b")))
+	aCancel()
+	if _, err := b(); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled entry: got error %v, want %v", err, context.Canceled)
+	}
+	c := q.Add(ctx, tessera.NewEntry([]byte("c")))
+	d := q.Add(ctx, tessera.NewEntry([]byte("d")))
+
+	for i, f := range []tessera.IndexFuture{a, c, d} {
+		idx, err := f()
+		if err != nil {
+			t.Fatalf("f(): %v", err)
+		}
+		if got, want := idx.Index, uint64(i); got != want {
+			t.Errorf("got index %d, want %d", got, want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"a", "c", "d"}; !reflect.DeepEqual(flushed, want) {
+		t.Errorf("flushed %q, want %q", flushed, want)
+	}
+}
+
+func TestQueueAddWhileFlushBlocked(t *testing.T) {
+	ctx := t.Context()
+	release := make(chan struct{})
+	var next uint64
+	q := storage.NewQueue(ctx, time.Hour, 2, func(_ context.Context, entries []*tessera.Entry) error {
+		<-release
+		for _, e := range entries {
+			_ = e.MarshalBundleData(next)
+			next++
+		}
+		return nil
+	})
+
+	// The first batch is taken by the worker, which blocks in the flush, and the second waits for it.
+	fs := q.AddBatch(ctx, []*tessera.Entry{
+		tessera.NewEntry([]byte("a")), tessera.NewEntry([]byte("b")),
+		tessera.NewEntry([]byte("c")), tessera.NewEntry([]byte("d")),
+	})
+	// The third batch can't be handed to the worker until the flush completes.
+	sent := make(chan []tessera.IndexFuture)
+	go func() {
+		sent <- q.AddBatch(ctx, []*tessera.Entry{tessera.NewEntry([]byte("e")), tessera.NewEntry([]byte("f"))})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Adding and cancelling entries must not be held up behind the blocked flush.
+	gCtx, gCancel := context.WithCancel(ctx)
+	added := make(chan tessera.IndexFuture)
+	go func() {
+		added <- q.Add(gCtx, tessera.NewEntry([]byte("g")))
+	}()
+	select {
+	case g := <-added:
+		gCancel()
+		if _, err := g(); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled entry: got error %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Add blocked while flush was in progress")
+	}
+
+	close(release)
+	fs = append(fs, <-sent...)
+	for i, f := range fs {
+		idx, err := f()
+		if err != nil {
+			t.Fatalf("future %d: %v", i, err)
+		}
+		if got, want := idx.Index, uint64(i); got != want {
+			t.Errorf("future %d: got index %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestQueueStoppedWhileSending(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	release := make(chan struct{})
+	defer close(release)
+	q := storage.NewQueue(ctx, time.Hour, 1, func(_ context.Context, entries []*tessera.Entry) error {
+		<-release
+		for _, e := range entries {
+			_ = e.MarshalBundleData(0)
+		}
+		return nil
+	})
+
+	q.Add(ctx, tessera.NewEntry([]byte("a")))
+	q.Add(ctx, tessera.NewEntry([]byte("b")))
+	added := make(chan tessera.IndexFuture)
+	go func() {
+		added <- q.Add(ctx, tessera.NewEntry([]byte("c")))
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// The worker has gone once the queue's context is done, so the blocked Add must give up.
+	cancel()
+	select {
+	case c := <-added:
+		if _, err := c(); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Add blocked after queue context was done")
+	}
+}
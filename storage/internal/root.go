@@ -0,0 +1,61 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+)
+
+// RootAt returns the root hash of the tree of the given size, using readTile to fetch the tiles which hold
+// the nodes of its compact range, and h to combine them. tl describes the tiles which the tree is divided into.
+//
+// integrated is the current size of the integrated tree, for which all tiles are known to exist. Tiles
+// are only written at the sizes the tree is integrated to, so when the partial tile implied by size was
+// never written, the current version of that tile is used instead: it begins with the same hashes.
+func RootAt(ctx context.Context, size, integrated uint64, readTile client.TileFetcherFunc, h merkle.LogHasher, tl layout.Tiling) ([]byte, error) {
+	if size > integrated {
+		return nil, fmt.Errorf("size %d is larger than the integrated tree size %d: %w", size, integrated, os.ErrNotExist)
+	}
+	if size == 0 {
+		return h.EmptyRoot(), nil
+	}
+	fetch := func(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+		t, err := readTile(ctx, level, index, p)
+		if errors.Is(err, os.ErrNotExist) {
+			if cp := uint8(tl.PartialTileSize(level, index, integrated)); cp != 0 && cp != p {
+				return readTile(ctx, level, index, cp)
+			}
+		}
+		return t, err
+	}
+	nodes, err := client.FetchRangeNodesWithTiling(ctx, size, fetch, h, tl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch compact range: %v", err)
+	}
+	rf := compact.RangeFactory{Hash: h.HashChildren}
+	r, err := rf.NewRange(0, size, nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compact range: %v", err)
+	}
+	return r.GetRootHash(nil)
+}
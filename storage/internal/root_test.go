@@ -0,0 +1,103 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/fetcher"
+)
+
+func TestRootAt(t *testing.T) {
+	for _, tl := range []layout.Tiling{layout.DefaultTiling, {Height: 3}} {
+		t.Run(fmt.Sprintf("height %d", tl.Height), func(t *testing.T) {
+			testRootAt(t, tl)
+		})
+	}
+}
+
+func testRootAt(t *testing.T, tl layout.Tiling) {
+	ctx := context.Background()
+	h := rfc6962.DefaultHasher
+	m := newMemTileStore[api.HashTile](tl)
+	// tiles holds every version of every tile written, keyed by path, as a storage driver would.
+	tiles := make(map[string][]byte)
+	readTile := func(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+		return fetcher.PartialOrFullResource(ctx, p, func(_ context.Context, p uint8) ([]byte, error) {
+			t, ok := tiles[tl.TilePath(level, index, uint64(p))]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return t, nil
+		})
+	}
+
+	// Integrate the tree in uneven batches, so that many sizes have no partial tiles of their own.
+	cr := (&compact.RangeFactory{Hash: h.HashChildren}).NewEmptyRange(0)
+	roots := [][]byte{h.EmptyRoot()}
+	size := uint64(0)
+	for _, n := range []int{1, 99, 200, 300, 7, 1000} {
+		leaves := make([][]byte, n)
+		for i := range leaves {
+			leaves[i] = h.HashLeaf([]byte{byte(size + uint64(i))})
+			if err := cr.Append(leaves[i], nil); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			root, err := cr.GetRootHash(nil)
+			if err != nil {
+				t.Fatalf("GetRootHash: %v", err)
+			}
+			roots = append(roots, root)
+		}
+		newSize, _, newTiles, err := Integrate(ctx, m.getTiles, size, leaves, h, tl)
+		if err != nil {
+			t.Fatalf("Integrate: %v", err)
+		}
+		size = newSize
+		for id, tile := range newTiles {
+			if err := m.setTile(ctx, id, size, tile); err != nil {
+				t.Fatalf("setTile: %v", err)
+			}
+			raw, err := tile.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText: %v", err)
+			}
+			tiles[tl.TilePath(id.Level, id.Index, tl.PartialTileSize(id.Level, id.Index, size))] = raw
+		}
+	}
+
+	for _, s := range []uint64{0, 1, 2, 50, 100, 255, 256, 257, 300, 512, 600, 607, 1000, 1537, 1600, size} {
+		got, err := RootAt(ctx, s, size, readTile, h, tl)
+		if err != nil {
+			t.Errorf("RootAt(%d): %v", s, err)
+			continue
+		}
+		if !bytes.Equal(got, roots[s]) {
+			t.Errorf("RootAt(%d) = %x, want %x", s, got, roots[s])
+		}
+	}
+	if _, err := RootAt(ctx, size+1, size, readTile, h, tl); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("RootAt(%d) beyond integrated size: got %v, want os.ErrNotExist", size+1, err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+// WriteVerifier re-reads a sample of the objects written to an object store, and checks that they were stored
+// intact, to detect silent corruption by the store before integration is acknowledged.
+//
+// A nil *WriteVerifier verifies nothing.
+type WriteVerifier struct {
+	fraction float64
+	// sample returns true if the next object should be verified.
+	sample func() bool
+}
+
+// NewWriteVerifier returns a WriteVerifier which verifies the given fraction of objects, or nil if fraction
+// is not positive. Fractions of 1 or more verify every object.
+func NewWriteVerifier(fraction float64) *WriteVerifier {
+	if fraction <= 0 {
+		return nil
+	}
+	v := &WriteVerifier{fraction: fraction}
+	v.sample = func() bool { return v.fraction >= 1 || rand.Float64() < v.fraction }
+	return v
+}
+
+// Verify re-reads the object obj using read, if it's sampled, and returns an error if it could not be read or
+// does not contain data.
+func (v *WriteVerifier) Verify(ctx context.Context, obj string, data []byte, read func(context.Context) ([]byte, error)) error {
+	if v == nil || !v.sample() {
+		return nil
+	}
+	ctx, span := tracer.Start(ctx, "tessera.storage.VerifyWrite")
+	defer span.End()
+
+	got, err := read(ctx)
+	if err != nil {
+		verifyCount.Add(ctx, 1, metric.WithAttributes(verifyResultKey.String("error")))
+		return fmt.Errorf("failed to re-read %q to verify it: %v", obj, err)
+	}
+	if !bytes.Equal(got, data) {
+		verifyCount.Add(ctx, 1, metric.WithAttributes(verifyResultKey.String("mismatch")))
+		klog.Errorf("Object %q was corrupted by the store: read back %d bytes which differ from the %d written", obj, len(got), len(data))
+		return fmt.Errorf("object %q read back after writing does not match the data written", obj)
+	}
+	verifyCount.Add(ctx, 1, metric.WithAttributes(verifyResultKey.String("ok")))
+	return nil
+}
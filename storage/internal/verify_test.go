@@ -0,0 +1,61 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWriteVerifier(t *testing.T) {
+	ctx := context.Background()
+	data := []byte("tile")
+	for _, test := range []struct {
+		name     string
+		fraction float64
+		// skip, if true, causes the object not to be sampled.
+		skip     bool
+		stored   []byte
+		readErr  error
+		wantRead bool
+		wantErr  bool
+	}{
+		{name: "disabled", fraction: 0, stored: []byte("corrupt")},
+		{name: "not sampled", fraction: 0.5, skip: true, stored: []byte("corrupt")},
+		{name: "intact", fraction: 1, stored: data, wantRead: true},
+		{name: "corrupt", fraction: 1, stored: []byte("corrupt"), wantRead: true, wantErr: true},
+		{name: "truncated", fraction: 1, stored: data[:2], wantRead: true, wantErr: true},
+		{name: "read fails", fraction: 1, readErr: errors.New("unavailable"), wantRead: true, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			v := NewWriteVerifier(test.fraction)
+			if test.skip {
+				v.sample = func() bool { return false }
+			}
+			read := false
+			err := v.Verify(ctx, "tile/0/000", data, func(context.Context) ([]byte, error) {
+				read = true
+				return test.stored, test.readErr
+			})
+			if read != test.wantRead {
+				t.Errorf("Verify read object: %t, want %t", read, test.wantRead)
+			}
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("Verify: %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	tessera "github.com/transparency-dev/tessera"
+	"k8s.io/klog/v2"
+)
+
+const (
+	selectFollowerPositionSQL          = "SELECT `position` FROM `FollowerPosition` WHERE `name` = ?"
+	selectFollowerPositionForUpdateSQL = selectFollowerPositionSQL + " FOR UPDATE"
+	upsertFollowerPositionSQL          = "INSERT INTO `FollowerPosition` (`name`, `position`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `position` = VALUES(`position`)"
+)
+
+// NewFollowerPositionStore returns a tessera.FollowerTxPositionStore which keeps the position of the named
+// follower in the FollowerPosition table of db, for use with tessera.NewTxFollower.
+//
+// db should be the database holding the follower's output, which needn't be the log's own database, and must
+// contain the FollowerPosition table from schema.sql. The follower's process function is passed the *sql.Tx
+// in which the position will be updated, and should make all of its changes through it.
+func NewFollowerPositionStore(db *sql.DB, name string) tessera.FollowerTxPositionStore[*sql.Tx] {
+	return &followerPositionStore{db: db, name: name}
+}
+
+type followerPositionStore struct {
+	db   *sql.DB
+	name string
+}
+
+func (s *followerPositionStore) Position(ctx context.Context) (uint64, error) {
+	return readFollowerPosition(s.db.QueryRowContext(ctx, selectFollowerPositionSQL, s.name))
+}
+
+func (s *followerPositionStore) Update(ctx context.Context, from, to uint64, f func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			klog.Errorf("Failed to rollback in followerPositionStore.Update: %v", err)
+		}
+	}()
+	// Lock the position row, so that another instance of the follower can't apply the same batch concurrently.
+	pos, err := readFollowerPosition(tx.QueryRowContext(ctx, selectFollowerPositionForUpdateSQL, s.name))
+	if err != nil {
+		return err
+	}
+	if pos != from {
+		return fmt.Errorf("position of follower %q is %d, not %d", s.name, pos, from)
+	}
+	if err := f(ctx, tx); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, upsertFollowerPositionSQL, s.name, to); err != nil {
+		return fmt.Errorf("failed to update FollowerPosition: %v", err)
+	}
+	return tx.Commit()
+}
+
+// readFollowerPosition scans the position from row, treating a missing row as a follower which hasn't started.
+func readFollowerPosition(row *sql.Row) (uint64, error) {
+	var pos uint64
+	if err := row.Scan(&pos); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read FollowerPosition: %v", err)
+	}
+	return pos, nil
+}
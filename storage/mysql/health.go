@@ -0,0 +1,236 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultCircuitBreakerFailureThreshold is used if CircuitBreakerOptions.FailureThreshold is not set.
+	DefaultCircuitBreakerFailureThreshold = 5
+	// DefaultCircuitBreakerOpenDuration is used if CircuitBreakerOptions.OpenDuration is not set.
+	DefaultCircuitBreakerOpenDuration = 10 * time.Second
+)
+
+// errCircuitOpen is returned to callers of Add while the circuit breaker is open.
+var errCircuitOpen = fmt.Errorf("mysql: database is unhealthy: %w", tessera.ErrPushback)
+
+// Config holds optional settings for MySQL storage, passed to NewWithConfig.
+type Config struct {
+	// MaxOpenConns, MaxIdleConns, ConnMaxLifetime, and ConnMaxIdleTime configure the database's connection pool
+	// if they're set, as with the sql.DB methods of the same names. Otherwise the pool's settings are left alone.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// CircuitBreaker, if set, enables a circuit breaker which stops new entries being accepted while the database
+	// is unhealthy.
+	CircuitBreaker *CircuitBreakerOptions
+
+	// LeaderElection, if set, enables leader election between the instances of the log which share the
+	// database, so that only one of them sequences entries at a time.
+	LeaderElection *LeaderElectionOptions
+
+	// ReadReplica, if set, is a read replica of the database, from which the LogReader returned by
+	// Storage.ReplicaLogReader reads. Its connection pool is configured in the same way as the primary's.
+	ReadReplica *sql.DB
+}
+
+// CircuitBreakerOptions configures the circuit breaker enabled with Config.CircuitBreaker.
+//
+// The breaker opens once FailureThreshold consecutive batches of entries have failed to be sequenced, e.g.
+// because the database is unreachable or timing out. While it's open, Add returns a wrapped tessera.ErrPushback
+// without queueing the entry, so that personalities can shed load rather than requests queueing up for a
+// database which can't serve them. After OpenDuration, the next batch is let through as a probe: if it
+// succeeds the breaker closes again, and otherwise it reopens for another OpenDuration.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failed batches which open the breaker.
+	// Defaults to DefaultCircuitBreakerFailureThreshold.
+	FailureThreshold uint
+	// OpenDuration is how long the breaker stays open before probing the database again.
+	// Defaults to DefaultCircuitBreakerOpenDuration.
+	OpenDuration time.Duration
+}
+
+// circuitState is the state of a circuitBreaker, reported by the tessera.mysql.circuit_breaker.state metric.
+type circuitState int64
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// circuitBreaker tracks whether sequencing is failing. A nil *circuitBreaker never opens.
+type circuitBreaker struct {
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures uint
+	openedAt time.Time
+	// probing is true while a probe batch is in flight in the half-open state.
+	probing bool
+}
+
+func newCircuitBreaker(opts CircuitBreakerOptions) *circuitBreaker {
+	if opts.FailureThreshold == 0 {
+		opts.FailureThreshold = DefaultCircuitBreakerFailureThreshold
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = DefaultCircuitBreakerOpenDuration
+	}
+	return &circuitBreaker{opts: opts, now: time.Now}
+}
+
+// rejecting returns true if new entries should be turned away because the breaker is open.
+func (b *circuitBreaker) rejecting(ctx context.Context) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := b.state == circuitOpen && b.now().Before(b.openedAt.Add(b.opts.OpenDuration))
+	if r {
+		mysqlCircuitBreakerRejections.Add(ctx, 1)
+	}
+	return r
+}
+
+// acquire returns an error if a batch shouldn't be sent to the database, because the breaker is open, or is
+// half-open and already probing the database with another batch.
+//
+// Each successful call must be followed by a call to record with the outcome of the batch.
+func (b *circuitBreaker) acquire(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.state == circuitOpen && !b.now().Before(b.openedAt.Add(b.opts.OpenDuration)):
+		b.state, b.probing = circuitHalfOpen, true
+		return nil
+	case b.state == circuitOpen, b.state == circuitHalfOpen && b.probing:
+		mysqlCircuitBreakerRejections.Add(ctx, 1)
+		return errCircuitOpen
+	case b.state == circuitHalfOpen:
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a batch: healthy is false if it failed because the database
+// is unhealthy.
+func (b *circuitBreaker) record(ctx context.Context, healthy bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if healthy {
+		if b.state != circuitClosed {
+			klog.Infof("MySQL circuit breaker closed: database is healthy again")
+		}
+		b.state, b.failures = circuitClosed, 0
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.opts.FailureThreshold {
+		if b.state != circuitOpen {
+			klog.Warningf("MySQL circuit breaker opened after %d consecutive failures: rejecting entries for %v", b.failures, b.opts.OpenDuration)
+			mysqlCircuitBreakerTrips.Add(ctx, 1)
+		}
+		b.state, b.openedAt = circuitOpen, b.now()
+	}
+}
+
+// current returns the state of the breaker.
+func (b *circuitBreaker) current() circuitState {
+	if b == nil {
+		return circuitClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// configurePool applies the pool settings in cfg to db.
+func configurePool(db *sql.DB, cfg Config) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+// observeHealth registers a callback which reports the state of the connection pool, circuit breaker, and
+// leader election.
+func observeHealth(db *sql.DB, b *circuitBreaker, l *leaderElector) error {
+	_, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		st := db.Stats()
+		o.ObserveInt64(mysqlPoolConnections, int64(st.InUse), metric.WithAttributes(connStateKey.String("in_use")))
+		o.ObserveInt64(mysqlPoolConnections, int64(st.Idle), metric.WithAttributes(connStateKey.String("idle")))
+		o.ObserveInt64(mysqlPoolWaits, st.WaitCount)
+		o.ObserveInt64(mysqlCircuitBreakerState, int64(b.current()))
+		var leading int64
+		if l.leading() {
+			leading = 1
+		}
+		o.ObserveInt64(mysqlLeader, leading)
+		return nil
+	}, mysqlPoolConnections, mysqlPoolWaits, mysqlCircuitBreakerState, mysqlLeader)
+	if err != nil {
+		return fmt.Errorf("failed to register metrics callback: %v", err)
+	}
+	return nil
+}
+
+// isUnhealthy returns true if err, returned from an attempt to sequence a batch, may be due to the database
+// being unhealthy.
+func isUnhealthy(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, tessera.ErrPushback)
+}
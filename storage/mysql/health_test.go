@@ -0,0 +1,154 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	// step is one event seen by the breaker.
+	type step struct {
+		// advance moves the breaker's clock forward before the step.
+		advance time.Duration
+		// acquire, if true, attempts to send a batch, which succeeds unless wantAcquireErr is set.
+		acquire        bool
+		wantAcquireErr bool
+		// record, if set, records the outcome of a batch.
+		record *bool
+		// wantRejecting is whether Add should turn entries away after the step.
+		wantRejecting bool
+		wantState     circuitState
+	}
+	ok, fail := true, false
+	for _, test := range []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "healthy",
+			steps: []step{
+				{acquire: true, record: &ok, wantState: circuitClosed},
+				{acquire: true, record: &ok, wantState: circuitClosed},
+			},
+		}, {
+			name: "trips after threshold",
+			steps: []step{
+				{acquire: true, record: &fail, wantState: circuitClosed},
+				{acquire: true, record: &fail, wantState: circuitClosed},
+				{acquire: true, record: &fail, wantRejecting: true, wantState: circuitOpen},
+				{acquire: true, wantAcquireErr: true, wantRejecting: true, wantState: circuitOpen},
+			},
+		}, {
+			name: "success resets failures",
+			steps: []step{
+				{acquire: true, record: &fail, wantState: circuitClosed},
+				{acquire: true, record: &fail, wantState: circuitClosed},
+				{acquire: true, record: &ok, wantState: circuitClosed},
+				{acquire: true, record: &fail, wantState: circuitClosed},
+				{acquire: true, record: &fail, wantState: circuitClosed},
+			},
+		}, {
+			name: "probe succeeds",
+			steps: []step{
+				{acquire: true, record: &fail},
+				{acquire: true, record: &fail},
+				{acquire: true, record: &fail, wantRejecting: true, wantState: circuitOpen},
+				{advance: 10 * time.Second, wantState: circuitOpen},
+				{acquire: true, wantState: circuitHalfOpen},
+				{acquire: true, wantAcquireErr: true, wantState: circuitHalfOpen},
+				{record: &ok, wantState: circuitClosed},
+				{acquire: true, record: &ok, wantState: circuitClosed},
+			},
+		}, {
+			name: "probe fails",
+			steps: []step{
+				{acquire: true, record: &fail},
+				{acquire: true, record: &fail},
+				{acquire: true, record: &fail, wantRejecting: true, wantState: circuitOpen},
+				{advance: 10 * time.Second, acquire: true, wantState: circuitHalfOpen},
+				{record: &fail, wantRejecting: true, wantState: circuitOpen},
+				{advance: 5 * time.Second, acquire: true, wantAcquireErr: true, wantRejecting: true, wantState: circuitOpen},
+				{advance: 5 * time.Second, acquire: true, wantState: circuitHalfOpen},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			b := newCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 3, OpenDuration: 10 * time.Second})
+			b.now = func() time.Time { return now }
+			for i, st := range test.steps {
+				now = now.Add(st.advance)
+				if st.acquire {
+					err := b.acquire(ctx)
+					if gotErr := err != nil; gotErr != st.wantAcquireErr {
+						t.Fatalf("step %d: acquire: %v, want error %t", i, err, st.wantAcquireErr)
+					}
+					if err != nil && !errors.Is(err, tessera.ErrPushback) {
+						t.Fatalf("step %d: acquire: %v, want ErrPushback", i, err)
+					}
+				}
+				if st.record != nil {
+					b.record(ctx, *st.record)
+				}
+				if got := b.rejecting(ctx); got != st.wantRejecting {
+					t.Errorf("step %d: rejecting: %t, want %t", i, got, st.wantRejecting)
+				}
+				if got := b.current(); got != st.wantState {
+					t.Errorf("step %d: state: %v, want %v", i, got, st.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerNil(t *testing.T) {
+	ctx := context.Background()
+	var b *circuitBreaker
+	for range 10 {
+		if err := b.acquire(ctx); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		b.record(ctx, false)
+	}
+	if b.rejecting(ctx) || b.current() != circuitClosed {
+		t.Errorf("nil breaker opened")
+	}
+}
+
+func TestIsUnhealthy(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: context.Canceled, want: false},
+		{err: tessera.ErrPushback, want: false},
+		{err: errCircuitOpen, want: false},
+		{err: context.DeadlineExceeded, want: true},
+		{err: errors.New("connection refused"), want: true},
+	} {
+		if got := isUnhealthy(test.err); got != test.want {
+			t.Errorf("isUnhealthy(%v): %t, want %t", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultLeaseDuration is used if LeaderElectionOptions.LeaseDuration is not set.
+	DefaultLeaseDuration = 10 * time.Second
+
+	leaseID = 0
+
+	// The lease's expiry is judged by the database's clock, so that the clocks of the instances don't matter.
+	// The holder is only replaced if the lease has expired, and the expiry is only extended by its holder.
+	upsertLeaseSQL = "INSERT INTO `Lease` (`id`, `holder`, `expiry`) VALUES (?, ?, ROUND(UNIX_TIMESTAMP(NOW(3)) * 1000) + ?) " +
+		"ON DUPLICATE KEY UPDATE " +
+		"`holder` = IF(`expiry` < ROUND(UNIX_TIMESTAMP(NOW(3)) * 1000) OR `holder` = VALUES(`holder`), VALUES(`holder`), `holder`), " +
+		"`expiry` = IF(`holder` = VALUES(`holder`), VALUES(`expiry`), `expiry`)"
+	selectLeaseHolderSQL = "SELECT `holder` FROM `Lease` WHERE `id` = ?"
+	releaseLeaseSQL      = "UPDATE `Lease` SET `expiry` = 0 WHERE `id` = ? AND `holder` = ?"
+)
+
+// ErrNotLeader is returned to callers of Add on instances which don't hold the leader lease, when leader election
+// is enabled with Config.LeaderElection. It wraps tessera.ErrPushback, so the entry can be retried, ideally on
+// another instance.
+var ErrNotLeader = fmt.Errorf("mysql: this instance is not the leader: %w", tessera.ErrPushback)
+
+// LeaderElectionOptions configures the leader election enabled with Config.LeaderElection.
+//
+// Instances of a log which share a database campaign for a lease recorded in the Lease table. Only the instance
+// holding it sequences and integrates entries and publishes checkpoints; the others reject entries with
+// ErrNotLeader. The leader renews the lease every third of LeaseDuration, and releases it when the context
+// passed to Appender is done, so that another instance can take over straight away.
+type LeaderElectionOptions struct {
+	// Holder identifies this instance in the Lease table, and must be unique among the instances of the log.
+	// Defaults to the hostname and process ID.
+	Holder string
+	// LeaseDuration is how long the lease lasts without being renewed, which bounds how long the log goes
+	// without a leader if the leader fails. Defaults to DefaultLeaseDuration.
+	LeaseDuration time.Duration
+}
+
+// leaderElector campaigns for the leader lease. A nil *leaderElector always leads.
+type leaderElector struct {
+	db   *sql.DB
+	opts LeaderElectionOptions
+	now  func() time.Time
+
+	mu sync.Mutex
+	// until is the time before which this instance is sure it holds the lease, or zero if it doesn't.
+	until time.Time
+}
+
+func newLeaderElector(db *sql.DB, opts LeaderElectionOptions) *leaderElector {
+	if opts.Holder == "" {
+		host, _ := os.Hostname()
+		opts.Holder = fmt.Sprintf("%s/%d", host, os.Getpid())
+	}
+	if opts.LeaseDuration <= 0 {
+		opts.LeaseDuration = DefaultLeaseDuration
+	}
+	return &leaderElector{db: db, opts: opts, now: time.Now}
+}
+
+// leading returns true if this instance holds the lease.
+func (l *leaderElector) leading() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.now().Before(l.until)
+}
+
+// run campaigns for the lease until ctx is done, and then releases it.
+func (l *leaderElector) run(ctx context.Context) {
+	t := time.NewTicker(l.opts.LeaseDuration / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// The context is done, so use a fresh one to release the lease.
+			rctx, cancel := context.WithTimeout(context.Background(), l.opts.LeaseDuration)
+			defer cancel()
+			if err := l.release(rctx); err != nil {
+				klog.Warningf("Failed to release leader lease: %v", err)
+			}
+			return
+		case <-t.C:
+		}
+		if err := l.campaign(ctx); err != nil {
+			klog.Warningf("Failed to campaign for leader lease: %v", err)
+		}
+	}
+}
+
+// campaign acquires the lease if it has expired, or renews it if this instance already holds it.
+func (l *leaderElector) campaign(ctx context.Context) error {
+	// The database's expiry is at least LeaseDuration after this, so this instance stops believing it's the
+	// leader before any other instance can take over.
+	start := l.now()
+	if _, err := l.db.ExecContext(ctx, upsertLeaseSQL, leaseID, l.opts.Holder, l.opts.LeaseDuration.Milliseconds()); err != nil {
+		return fmt.Errorf("failed to update Lease: %v", err)
+	}
+	var holder string
+	if err := l.db.QueryRowContext(ctx, selectLeaseHolderSQL, leaseID).Scan(&holder); err != nil {
+		return fmt.Errorf("failed to read Lease: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	wasLeading := l.now().Before(l.until)
+	if holder != l.opts.Holder {
+		if wasLeading {
+			klog.Warningf("Lost leader lease to %q", holder)
+		}
+		l.until = time.Time{}
+		return nil
+	}
+	if !wasLeading {
+		klog.Infof("Acquired leader lease as %q", l.opts.Holder)
+	}
+	l.until = start.Add(l.opts.LeaseDuration)
+	return nil
+}
+
+// release gives up the lease, if this instance holds it.
+func (l *leaderElector) release(ctx context.Context) error {
+	l.mu.Lock()
+	l.until = time.Time{}
+	l.mu.Unlock()
+	if _, err := l.db.ExecContext(ctx, releaseLeaseSQL, leaseID, l.opts.Holder); err != nil {
+		return fmt.Errorf("failed to release Lease: %v", err)
+	}
+	return nil
+}
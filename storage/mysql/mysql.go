@@ -18,6 +18,7 @@ package mysql
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/sha256"
 	"database/sql"
 	"errors"
@@ -28,12 +29,14 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
 	"github.com/transparency-dev/tessera/internal/migrate"
+	"github.com/transparency-dev/tessera/internal/otel"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"k8s.io/klog/v2"
 )
@@ -50,10 +53,36 @@ const (
 	replaceSubtreeSQL                = "REPLACE INTO `Subtree` (`level`, `index`, `nodes`) VALUES (?, ?, ?)"
 	selectTiledLeavesSQL             = "SELECT `size`, `data` FROM `TiledLeaves` WHERE `tile_index` = ?"
 	streamTiledLeavesSQL             = "SELECT `tile_index`, `size`, `data` FROM `TiledLeaves` WHERE `tile_index` >= ? ORDER BY `tile_index` ASC"
+	selectTiledLeavesRangeSQL        = "SELECT `tile_index`, `size`, `data` FROM `TiledLeaves` WHERE `tile_index` >= ? AND `tile_index` < ? ORDER BY `tile_index` ASC"
 	replaceTiledLeavesSQL            = "REPLACE INTO `TiledLeaves` (`tile_index`, `size`, `data`) VALUES (?, ?, ?)"
+	selectLogStateByIDSQL            = "SELECT `state` FROM `LogState` WHERE `id` = ?"
+	replaceLogStateSQL               = "REPLACE INTO `LogState` (`id`, `state`) VALUES (?, ?)"
+	selectRetentionByIDSQL           = "SELECT `expunged_below` FROM `Retention` WHERE `id` = ?"
+	upsertRetentionSQL               = "INSERT INTO `Retention` (`id`, `expunged_below`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `expunged_below` = GREATEST(`expunged_below`, VALUES(`expunged_below`))"
+	selectRetentionSamplesByIDSQL    = "SELECT `samples` FROM `RetentionSamples` WHERE `id` = ?"
+	replaceRetentionSamplesSQL       = "REPLACE INTO `RetentionSamples` (`id`, `samples`) VALUES (?, ?)"
+	deleteTiledLeavesBelowSQL        = "DELETE FROM `TiledLeaves` WHERE `tile_index` < ?"
+	selectTreeHashByIDSQL            = "SELECT `hash` FROM `TreeHash` WHERE `id` = ?"
+	replaceTreeHashSQL               = "REPLACE INTO `TreeHash` (`id`, `hash`) VALUES (?, ?)"
+	selectIdempotencyKeySQL          = "SELECT `idx` FROM `IdempotencyKey` WHERE `key_hash` = ? AND `expiry` >= ? AND `idx` IS NOT NULL"
+	claimIdempotencyKeySQL           = "INSERT INTO `IdempotencyKey` (`key_hash`, `idx`, `expiry`) VALUES (?, NULL, ?) ON DUPLICATE KEY UPDATE `idx` = IF(`expiry` < ?, NULL, `idx`), `expiry` = IF(`expiry` < ?, VALUES(`expiry`), `expiry`)"
+	releaseIdempotencyKeySQL         = "DELETE FROM `IdempotencyKey` WHERE `key_hash` = ? AND `idx` IS NULL"
+	upsertIdempotencyKeySQL          = "INSERT INTO `IdempotencyKey` (`key_hash`, `idx`, `expiry`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `idx` = VALUES(`idx`), `expiry` = VALUES(`expiry`)"
+	deleteIdempotencyKeysBeforeSQL   = "DELETE FROM `IdempotencyKey` WHERE `expiry` < ?"
+	selectArchivedCheckpointSQL      = "SELECT `note` FROM `ArchivedCheckpoint` WHERE `size` = ?"
+	selectArchivedSizesSQL           = "SELECT `size` FROM `ArchivedCheckpoint` ORDER BY `size` ASC"
+	selectMaxArchivedSizeSQL         = "SELECT MAX(`size`) FROM `ArchivedCheckpoint`"
+	insertArchivedCheckpointSQL      = "INSERT IGNORE INTO `ArchivedCheckpoint` (`size`, `note`) VALUES (?, ?)"
+	deleteExpiredArchivedSQL         = "DELETE FROM `ArchivedCheckpoint` WHERE `size` < (SELECT `size` FROM (SELECT `size` FROM `ArchivedCheckpoint` ORDER BY `size` DESC LIMIT 1 OFFSET ?) AS `retained`)"
 
 	checkpointID = 0
 	treeStateID  = 0
+	logStateID   = 0
+	retentionID  = 0
+	treeHashID   = 0
+
+	// errNoSuchTable is the MySQL error number returned when a table does not exist.
+	errNoSuchTable = 1146
 
 	schemaCompatibilityVersion = 1
 
@@ -63,12 +92,46 @@ const (
 // Storage is a MySQL-based storage implementation for Tessera.
 type Storage struct {
 	db *sql.DB
+	// logReader reads from db, which is the primary database.
+	logReader
+	// replica reads from the read replica, or from db if there isn't one.
+	replica *logReader
+	// breaker is nil unless a circuit breaker was configured.
+	breaker *circuitBreaker
+	// elector is nil unless leader election was configured.
+	elector *leaderElector
 }
 
 // New creates a new instance of the MySQL-based Storage.
 func New(ctx context.Context, db *sql.DB) (*Storage, error) {
+	return NewWithConfig(ctx, db, Config{})
+}
+
+// NewWithConfig creates a new instance of the MySQL-based Storage, with the connection pool and circuit breaker
+// configured by cfg.
+//
+// The state of the connection pool and circuit breaker are reported by the tessera.mysql.pool.* and
+// tessera.mysql.circuit_breaker.* metrics.
+func NewWithConfig(ctx context.Context, db *sql.DB, cfg Config) (*Storage, error) {
+	configurePool(db, cfg)
 	s := &Storage{
-		db: db,
+		db:        db,
+		logReader: logReader{db: db, hasher: rfc6962.DefaultHasher},
+	}
+	s.replica = &s.logReader
+	if cfg.ReadReplica != nil {
+		configurePool(cfg.ReadReplica, cfg)
+		if err := cfg.ReadReplica.Ping(); err != nil {
+			klog.Errorf("Failed to ping read replica: %v", err)
+			return nil, err
+		}
+		s.replica = &logReader{db: cfg.ReadReplica, hasher: rfc6962.DefaultHasher}
+	}
+	if cfg.CircuitBreaker != nil {
+		s.breaker = newCircuitBreaker(*cfg.CircuitBreaker)
+	}
+	if cfg.LeaderElection != nil {
+		s.elector = newLeaderElector(db, *cfg.LeaderElection)
 	}
 	if err := s.db.Ping(); err != nil {
 		klog.Errorf("Failed to ping database: %v", err)
@@ -77,27 +140,80 @@ func New(ctx context.Context, db *sql.DB) (*Storage, error) {
 	if err := s.ensureVersion(ctx, schemaCompatibilityVersion); err != nil {
 		return nil, fmt.Errorf("incompatible schema version: %v", err)
 	}
+	if err := observeHealth(db, s.breaker, s.elector); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
+// logReader implements the tessera.LogReader contract by reading from a single database.
+type logReader struct {
+	db *sql.DB
+	// hasher is used by RootAt to combine the nodes of the tree. It defaults to the RFC6962 SHA-256 hasher,
+	// and is replaced with the configured one by Appender.
+	hasher merkle.LogHasher
+}
+
+// ReplicaLogReader returns a LogReader which serves all of its reads from the read replica set by
+// Config.ReadReplica, or from the primary database if there isn't one.
+//
+// This is intended for serving the log's read API, so that heavy tile and entry bundle reads don't contend
+// with sequencing and integration, which keep using the primary through the LogReader returned by Appender.
+// The replica lags behind the primary, so this LogReader may return an older checkpoint, but so long as the
+// replica applies transactions in the order they were committed (the default since MySQL 8.0.27), the tiles
+// and entry bundles covered by the checkpoint it returns are always available from it too.
+func (s *Storage) ReplicaLogReader() tessera.LogReader {
+	return s.replica
+}
+
+// IsLeader returns true if this instance holds the leader lease, or if leader election isn't enabled.
+// This could be used as a readiness check, so that entries are only sent to the leader.
+func (s *Storage) IsLeader() bool {
+	return s.elector.leading()
+}
+
 // Note that `tessera.WithCheckpointSigner()` is mandatory in the `opts` argument.
 func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
 	if opts.CheckpointInterval() < minCheckpointInterval {
 		return nil, nil, fmt.Errorf("requested CheckpointInterval too low - %v < %v", opts.CheckpointInterval(), minCheckpointInterval)
 	}
+	if enc := opts.EntryBundleEncoding(); enc != "" {
+		return nil, nil, fmt.Errorf("entry bundle encoding %q is not supported by MySQL storage", enc)
+	}
+	if opts.EntryBundleKeyProvider() != nil {
+		return nil, nil, errors.New("entry bundle encryption is not supported by MySQL storage")
+	}
 
 	a := &appender{
 		s:             s,
 		newCheckpoint: opts.CheckpointPublisher(s, http.DefaultClient),
 		cpUpdated:     make(chan struct{}, 1),
+		hasher:        opts.Hasher(),
 	}
-	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), a.sequenceBatch)
+	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.WithSequenceHook(a.sequenceBatch, opts.SequenceHook()))
 
-	if err := s.maybeInitTree(ctx); err != nil {
+	if err := s.maybeInitTree(ctx, opts.HashFunction()); err != nil {
 		return nil, nil, fmt.Errorf("maybeInitTree: %v", err)
 	}
+	s.logReader.hasher, s.replica.hasher = a.hasher, a.hasher
 	a.cpUpdated <- struct{}{}
 
+	if s.elector != nil {
+		// Campaign straight away, so that a lone instance can accept entries as soon as it's started.
+		if err := s.elector.campaign(ctx); err != nil {
+			klog.Warningf("Failed to campaign for leader lease: %v", err)
+		}
+		go s.elector.run(ctx)
+	}
+
+	// wait returns how long to wait between attempts to publish a checkpoint.
+	wait := func() time.Duration {
+		if opts.CheckpointGrowthTrigger() > 0 {
+			// Check regularly, in case the tree grew too soon after the last checkpoint to publish straight away.
+			return minCheckpointInterval
+		}
+		return max(opts.CheckpointInterval(), minCheckpointInterval)
+	}
 	go func(ctx context.Context, i time.Duration) {
 		t := time.NewTicker(i)
 		defer t.Stop()
@@ -108,17 +224,261 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 			case <-a.cpUpdated:
 			case <-t.C:
 			}
-			if err := a.publishCheckpoint(ctx, i); err != nil {
+			// The interval may be changed while the log is running.
+			if n := wait(); n != i {
+				i = n
+				t.Reset(i)
+			}
+			if !s.elector.leading() {
+				// The leader publishes checkpoints, so that witnesses aren't asked to cosign by every instance.
+				continue
+			}
+			if err := a.publishCheckpoint(ctx, storage.CheckpointMinStaleness(ctx, opts, minCheckpointInterval, a.s.ReadCheckpoint, a.currentTree)); err != nil {
+				if errors.Is(err, tessera.ErrLogNotActive) {
+					// The log is frozen, so there's nothing more to publish.
+					continue
+				}
 				klog.Warningf("publishCheckpoint: %v", err)
 			}
 		}
-	}(ctx, opts.CheckpointInterval())
+	}(ctx, wait())
 
 	return &tessera.Appender{
-		Add: a.Add,
+		Add:      a.Add,
+		AddBatch: a.AddBatch,
 	}, s, nil
 }
 
+// ReadLogState returns the lifecycle state of the log from the LogState table.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) ReadLogState(ctx context.Context) (tessera.LogState, error) {
+	var state tessera.LogState
+	if err := s.db.QueryRowContext(ctx, selectLogStateByIDSQL, logStateID).Scan(&state); err != nil {
+		var mErr *mysqldriver.MySQLError
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &mErr) && mErr.Number == errNoSuchTable) {
+			// The state has never been written (the table may not exist for logs created before it was
+			// added to the schema), so the log has never left the active state.
+			return tessera.LogStateActive, nil
+		}
+		return 0, fmt.Errorf("failed to read LogState: %v", err)
+	}
+	return state, nil
+}
+
+// WriteLogState stores the lifecycle state of the log in the LogState table.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) WriteLogState(ctx context.Context, state tessera.LogState) error {
+	if _, err := s.db.ExecContext(ctx, replaceLogStateSQL, logStateID, state); err != nil {
+		return fmt.Errorf("failed to update LogState: %v", err)
+	}
+	return nil
+}
+
+// ReadIdempotencyKey returns the index stored for the given idempotency key in the IdempotencyKey table,
+// if it hasn't expired.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ReadIdempotencyKey(ctx context.Context, key string) (uint64, bool, error) {
+	h := sha256.Sum256([]byte(key))
+	var idx uint64
+	if err := s.db.QueryRowContext(ctx, selectIdempotencyKeySQL, h[:], time.Now().UnixMilli()).Scan(&idx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to read IdempotencyKey: %v", err)
+	}
+	return idx, true, nil
+}
+
+// ClaimIdempotencyKey inserts a row without an index for the given idempotency key into the IdempotencyKey
+// table, unless there's already one which hasn't expired, and returns whether it did so.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ClaimIdempotencyKey(ctx context.Context, key string, expiry time.Time) (bool, error) {
+	h := sha256.Sum256([]byte(key))
+	now := time.Now().UnixMilli()
+	r, err := s.db.ExecContext(ctx, claimIdempotencyKeySQL, h[:], expiry.UnixMilli(), now, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim IdempotencyKey: %v", err)
+	}
+	// An existing row is only taken over once it has expired; the assignment to `idx` comes first since it
+	// uses the row's previous `expiry`. MySQL reports no affected rows if the existing row was left unchanged.
+	n, err := r.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read rows affected by claiming IdempotencyKey: %v", err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseIdempotencyKey deletes the row for the given idempotency key from the IdempotencyKey table, if it
+// hasn't been assigned an index.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	h := sha256.Sum256([]byte(key))
+	if _, err := s.db.ExecContext(ctx, releaseIdempotencyKeySQL, h[:]); err != nil {
+		return fmt.Errorf("failed to release IdempotencyKey: %v", err)
+	}
+	return nil
+}
+
+// WriteIdempotencyKey stores the index assigned to the entry with the given idempotency key in the
+// IdempotencyKey table, until expiry.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) WriteIdempotencyKey(ctx context.Context, key string, index uint64, expiry time.Time) error {
+	h := sha256.Sum256([]byte(key))
+	if _, err := s.db.ExecContext(ctx, upsertIdempotencyKeySQL, h[:], index, expiry.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to update IdempotencyKey: %v", err)
+	}
+	return nil
+}
+
+// ExpireIdempotencyKeys deletes the idempotency keys which expired before the given time.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ExpireIdempotencyKeys(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, deleteIdempotencyKeysBeforeSQL, before.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to delete expired IdempotencyKeys: %v", err)
+	}
+	return nil
+}
+
+// ReadArchivedCheckpoint returns the checkpoint with the given tree size from the ArchivedCheckpoint table.
+// If the checkpoint is not found, it returns os.ErrNotExist.
+func (lr *logReader) ReadArchivedCheckpoint(ctx context.Context, size uint64) ([]byte, error) {
+	var cp []byte
+	if err := lr.db.QueryRowContext(ctx, selectArchivedCheckpointSQL, size).Scan(&cp); err != nil {
+		var mErr *mysqldriver.MySQLError
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &mErr) && mErr.Number == errNoSuchTable) {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("failed to read ArchivedCheckpoint: %v", err)
+	}
+	return cp, nil
+}
+
+// ReadCheckpointArchiveIndex returns the api.CheckpointArchiveIndex listing the sizes of the checkpoints in
+// the ArchivedCheckpoint table. If there are none, it returns os.ErrNotExist.
+func (lr *logReader) ReadCheckpointArchiveIndex(ctx context.Context) ([]byte, error) {
+	rows, err := lr.db.QueryContext(ctx, selectArchivedSizesSQL)
+	if err != nil {
+		var mErr *mysqldriver.MySQLError
+		if errors.As(err, &mErr) && mErr.Number == errNoSuchTable {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("failed to read ArchivedCheckpoint sizes: %v", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			klog.Warningf("Failed to close rows: %v", err)
+		}
+	}()
+	idx := api.CheckpointArchiveIndex{}
+	for rows.Next() {
+		var size uint64
+		if err := rows.Scan(&size); err != nil {
+			return nil, fmt.Errorf("scan ArchivedCheckpoint size: %v", err)
+		}
+		idx.Sizes = append(idx.Sizes, size)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ArchivedCheckpoint sizes: %v", err)
+	}
+	if len(idx.Sizes) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return idx.MarshalText()
+}
+
+// ArchiveCheckpoint stores the checkpoint with the given tree size in the ArchivedCheckpoint table, unless one
+// with that size is already stored. If retain is non-zero, all but the retain largest checkpoints are then deleted.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithCheckpointArchive instead.
+func (s *Storage) ArchiveCheckpoint(ctx context.Context, size uint64, cp []byte, retain uint) error {
+	var last sql.Null[uint64]
+	if err := s.db.QueryRowContext(ctx, selectMaxArchivedSizeSQL).Scan(&last); err != nil {
+		return fmt.Errorf("failed to read ArchivedCheckpoint size: %v", err)
+	}
+	if last.Valid && size < last.V {
+		return fmt.Errorf("checkpoint size %d is smaller than that of the latest archived checkpoint %d", size, last.V)
+	}
+	if _, err := s.db.ExecContext(ctx, insertArchivedCheckpointSQL, size, cp); err != nil {
+		return fmt.Errorf("failed to insert ArchivedCheckpoint: %v", err)
+	}
+	if retain > 0 {
+		// The query uses a derived table, since MySQL doesn't allow the table being deleted from in a subquery.
+		if _, err := s.db.ExecContext(ctx, deleteExpiredArchivedSQL, retain-1); err != nil {
+			return fmt.Errorf("failed to delete expired ArchivedCheckpoints: %v", err)
+		}
+	}
+	return nil
+}
+
+// ExpungeEntryBundles deletes all full entry bundles which contain only entries with indices below size.
+//
+// This is used by the Tessera Appender retention policy; personalities should use tessera.WithRetention instead.
+func (s *Storage) ExpungeEntryBundles(ctx context.Context, size uint64) error {
+	size -= size % layout.EntryBundleWidth
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			klog.Errorf("Failed to rollback in ExpungeEntryBundles: %v", err)
+		}
+	}()
+	if _, err := tx.ExecContext(ctx, deleteTiledLeavesBelowSQL, size/layout.EntryBundleWidth); err != nil {
+		return fmt.Errorf("failed to delete entry bundles: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, upsertRetentionSQL, retentionID, size); err != nil {
+		return fmt.Errorf("failed to update Retention: %v", err)
+	}
+	return tx.Commit()
+}
+
+// ReadRetentionSamples returns the state most recently stored by WriteRetentionSamples in the RetentionSamples
+// table, or nil if there is none.
+//
+// This is used by the Tessera Appender retention policy; personalities should use tessera.WithRetention instead.
+func (s *Storage) ReadRetentionSamples(ctx context.Context) ([]byte, error) {
+	var samples []byte
+	if err := s.db.QueryRowContext(ctx, selectRetentionSamplesByIDSQL, retentionID).Scan(&samples); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read RetentionSamples: %v", err)
+	}
+	return samples, nil
+}
+
+// WriteRetentionSamples stores the state used by the retention policy to track when entries were integrated in
+// the RetentionSamples table.
+//
+// This is used by the Tessera Appender retention policy; personalities should use tessera.WithRetention instead.
+func (s *Storage) WriteRetentionSamples(ctx context.Context, data []byte) error {
+	if _, err := s.db.ExecContext(ctx, replaceRetentionSamplesSQL, retentionID, data); err != nil {
+		return fmt.Errorf("failed to update RetentionSamples: %v", err)
+	}
+	return nil
+}
+
+// readExpungedBelow returns the size below which entry bundles have been expunged, or zero if nothing has been.
+func (lr *logReader) readExpungedBelow(ctx context.Context) (uint64, error) {
+	var size uint64
+	if err := lr.db.QueryRowContext(ctx, selectRetentionByIDSQL, retentionID).Scan(&size); err != nil {
+		var mErr *mysqldriver.MySQLError
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &mErr) && mErr.Number == errNoSuchTable) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read Retention: %v", err)
+	}
+	return size, nil
+}
+
 func (s *Storage) ensureVersion(ctx context.Context, wantVersion uint8) error {
 	row := s.db.QueryRowContext(ctx, selectCompatibilityVersionSQL)
 	if row.Err() != nil {
@@ -135,14 +495,16 @@ func (s *Storage) ensureVersion(ctx context.Context, wantVersion uint8) error {
 }
 
 // maybeInitTree will insert an initial "empty tree" row into the
-// TreeState table iff no row already exists.
+// TreeState table iff no row already exists, recording h as the hash function
+// used to build the tree. If the tree already exists, it checks that it was
+// built using h.
 //
 // This method doesn't also publish this new empty tree as a Checkpoint,
 // rather, such a checkpoint will be published asynchronously by the
 // same mechanism used to publish future checkpoints. Although in _this_
 // case it would be expected to happen in very short order given that it's
 // likely that no row currently exists in the Checkpoints table either.
-func (s *Storage) maybeInitTree(ctx context.Context) error {
+func (s *Storage) maybeInitTree(ctx context.Context, h crypto.Hash) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("being tx init tree state: %v", err)
@@ -160,7 +522,10 @@ func (s *Storage) maybeInitTree(ctx context.Context) error {
 	}
 	if treeState == nil {
 		klog.Infof("Initializing tree state")
-		if err := s.writeTreeState(ctx, tx, 0, rfc6962.DefaultHasher.EmptyRoot()); err != nil {
+		if _, err := tx.ExecContext(ctx, replaceTreeHashSQL, treeHashID, h.String()); err != nil {
+			return fmt.Errorf("failed to write TreeHash: %v", err)
+		}
+		if err := s.writeTreeState(ctx, tx, 0, rfc6962.New(h).EmptyRoot()); err != nil {
 			klog.Errorf("Failed to write initial tree state: %v", err)
 			return err
 		}
@@ -169,14 +534,33 @@ func (s *Storage) maybeInitTree(ctx context.Context) error {
 		if err := tx.Commit(); err != nil {
 			return fmt.Errorf("commit init tree state: %v", err)
 		}
+		return nil
 	}
-	return nil
+	stored, err := readTreeHash(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return storage.CheckHashFunction(stored, h)
+}
+
+// readTreeHash returns the name of the hash function used to build the tree, or an empty string if it was
+// created before this was recorded.
+func readTreeHash(ctx context.Context, tx *sql.Tx) (string, error) {
+	var h string
+	if err := tx.QueryRowContext(ctx, selectTreeHashByIDSQL, treeHashID).Scan(&h); err != nil {
+		var mErr *mysqldriver.MySQLError
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &mErr) && mErr.Number == errNoSuchTable) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read TreeHash: %v", err)
+	}
+	return h, nil
 }
 
 // ReadCheckpoint returns the latest stored checkpoint.
 // If the checkpoint is not found, it returns os.ErrNotExist.
-func (s *Storage) ReadCheckpoint(ctx context.Context) ([]byte, error) {
-	row := s.db.QueryRowContext(ctx, selectCheckpointByIDSQL, checkpointID)
+func (lr *logReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	row := lr.db.QueryRowContext(ctx, selectCheckpointByIDSQL, checkpointID)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
@@ -199,8 +583,8 @@ type treeState struct {
 
 // readTreeState returns the currently stored state information.
 // If there is no stored tree state, it returns os.ErrNotExist.
-func (s *Storage) readTreeState(ctx context.Context) (*treeState, error) {
-	row := s.db.QueryRowContext(ctx, selectTreeStateByIDSQL, treeStateID)
+func (lr *logReader) readTreeState(ctx context.Context) (*treeState, error) {
+	row := lr.db.QueryRowContext(ctx, selectTreeStateByIDSQL, treeStateID)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
@@ -249,8 +633,8 @@ func (s *Storage) writeTreeState(ctx context.Context, tx *sql.Tx, size uint64, r
 // Note that if a partial tile is requested, but a larger tile is available, this
 // will return the largest tile available. This could be trimmed to return only the
 // number of entries specifically requested if this behaviour becomes problematic.
-func (s *Storage) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
-	row := s.db.QueryRowContext(ctx, selectSubtreeByLevelAndIndexSQL, level, index)
+func (lr *logReader) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	row := lr.db.QueryRowContext(ctx, selectSubtreeByLevelAndIndexSQL, level, index)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
@@ -293,8 +677,8 @@ func (s *Storage) writeTile(ctx context.Context, tx *sql.Tx, level, index uint64
 // Note that if a partial tile is requested, but a larger tile is available, this
 // will return the largest tile available. This could be trimmed to return only the
 // number of entries specifically requested if this behaviour becomes problematic.
-func (s *Storage) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
-	row := s.db.QueryRowContext(ctx, selectTiledLeavesSQL, index)
+func (lr *logReader) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	row := lr.db.QueryRowContext(ctx, selectTiledLeavesSQL, index)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
@@ -303,6 +687,14 @@ func (s *Storage) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([
 	var entryBundle []byte
 	if err := row.Scan(&size, &entryBundle); err != nil {
 		if err == sql.ErrNoRows {
+			// The bundle may be missing because it has been expunged.
+			expunged, err := lr.readExpungedBelow(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if index < expunged/layout.EntryBundleWidth {
+				return nil, fmt.Errorf("entry bundle %d: %w", index, tessera.ErrEntryBundleExpired)
+			}
 			return nil, os.ErrNotExist
 		}
 		return nil, fmt.Errorf("scan entry bundle: %v", err)
@@ -320,23 +712,91 @@ func (s *Storage) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([
 	return entryBundle, nil
 }
 
+// ReadEntryBundles returns count full entry bundles, starting with the bundle at index fromBundle.
+// The bundles are read with a single query.
+//
+// If any of the bundles is not found, or is not yet full, it returns an error wrapping os.ErrNotExist.
+func (lr *logReader) ReadEntryBundles(ctx context.Context, fromBundle, count uint64) ([][]byte, error) {
+	rows, err := lr.db.QueryContext(ctx, selectTiledLeavesRangeSQL, fromBundle, fromBundle+count)
+	if err != nil {
+		return nil, fmt.Errorf("query entry bundles: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	r := make([][]byte, 0, count)
+	for rows.Next() {
+		var idx uint64
+		var size uint32
+		var entryBundle []byte
+		if err := rows.Scan(&idx, &size, &entryBundle); err != nil {
+			return nil, fmt.Errorf("scan entry bundle: %v", err)
+		}
+		want := fromBundle + uint64(len(r))
+		if idx != want {
+			break
+		}
+		if size < layout.EntryBundleWidth {
+			return nil, fmt.Errorf("entry bundle %d has only %d entries: %w", idx, size, os.ErrNotExist)
+		}
+		r = append(r, entryBundle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read entry bundles: %v", err)
+	}
+	if uint64(len(r)) == count {
+		return r, nil
+	}
+
+	// The first missing bundle may be missing because it has been expunged.
+	missing := fromBundle + uint64(len(r))
+	expunged, err := lr.readExpungedBelow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if missing < expunged/layout.EntryBundleWidth {
+		return nil, fmt.Errorf("entry bundle %d: %w", missing, tessera.ErrEntryBundleExpired)
+	}
+	return nil, fmt.Errorf("entry bundle %d: %w", missing, os.ErrNotExist)
+}
+
 // IntegratedSize returns the current size of the integrated tree.
 //
 // This is part of the tessera LogReader contract.
-func (s *Storage) IntegratedSize(ctx context.Context) (uint64, error) {
-	ts, err := s.readTreeState(ctx)
+func (lr *logReader) IntegratedSize(ctx context.Context) (uint64, error) {
+	ts, err := lr.readTreeState(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("readTreeState: %v", err)
 	}
 	return ts.size, nil
 }
 
+// ReadInternalCheckpoint returns the size and root hash of the current integrated tree.
+//
+// This is part of the tessera LogReader contract.
+func (lr *logReader) ReadInternalCheckpoint(ctx context.Context) (tessera.InternalCheckpoint, error) {
+	ts, err := lr.readTreeState(ctx)
+	if err != nil {
+		return tessera.InternalCheckpoint{}, fmt.Errorf("readTreeState: %v", err)
+	}
+	return tessera.InternalCheckpoint{Size: ts.size, Hash: ts.root}, nil
+}
+
+// RootAt returns the root hash of the tree when it contained size entries.
+// This is part of the tessera LogReader contract.
+func (lr *logReader) RootAt(ctx context.Context, size uint64) ([]byte, error) {
+	integrated, err := lr.IntegratedSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.RootAt(ctx, size, integrated, lr.ReadTile, lr.hasher, layout.DefaultTiling)
+}
+
 // NextIndex returns the next available leaf index.
 //
 // Currently, this is the same as the integrated size since new leaves are integrated synchronously.
 // This is part of the tessera LogReader contract.
-func (s *Storage) NextIndex(ctx context.Context) (uint64, error) {
-	return s.IntegratedSize(ctx)
+func (lr *logReader) NextIndex(ctx context.Context) (uint64, error) {
+	return lr.IntegratedSize(ctx)
 }
 
 // dbExecContext describes something which can support the sql ExecContext function.
@@ -359,12 +819,27 @@ type appender struct {
 	s             *Storage
 	queue         *storage.Queue
 	newCheckpoint func(context.Context, uint64, []byte) ([]byte, error)
+	hasher        merkle.LogHasher
 	cpUpdated     chan struct{}
+	// integrated links the spans which publish checkpoints to those which integrated the entries they cover.
+	integrated storage.SpanLinks
+}
+
+// currentTree returns the size and root hash of the integrated tree.
+func (a *appender) currentTree(ctx context.Context) (uint64, []byte, error) {
+	ts, err := a.s.readTreeState(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return ts.size, ts.root, nil
 }
 
 // publishCheckpoint creates a new checkpoint for the given size and root hash, and stores it in the
 // Checkpoint table.
 func (a *appender) publishCheckpoint(ctx context.Context, interval time.Duration) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.mysql.publishCheckpoint")
+	defer span.End()
+
 	tx, err := a.s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %v", err)
@@ -390,6 +865,8 @@ func (a *appender) publishCheckpoint(ctx context.Context, interval time.Duration
 	if err != nil {
 		return fmt.Errorf("readTreeState: %v", err)
 	}
+	span.SetAttributes(treeSizeKey.Int64(otel.Clamp64(treeState.size)))
+	a.integrated.Link(span, treeState.size)
 
 	rawCheckpoint, err := a.newCheckpoint(ctx, treeState.size, treeState.root)
 	if err != nil {
@@ -400,16 +877,41 @@ func (a *appender) publishCheckpoint(ctx context.Context, interval time.Duration
 		return err
 	}
 
-	klog.V(2).Infof("Published latest checkpoint: %d, %x", treeState.size, treeState.root)
+	klog.V(2).InfoS("Published latest checkpoint", "size", treeState.size, "root", fmt.Sprintf("%x", treeState.root))
 
 	return tx.Commit()
 }
 
 // Add is the entrypoint for adding entries to a sequencing log.
 func (a *appender) Add(ctx context.Context, entry *tessera.Entry) tessera.IndexFuture {
+	if !a.s.elector.leading() {
+		return func() (tessera.Index, error) { return tessera.Index{}, ErrNotLeader }
+	}
+	if a.s.breaker.rejecting(ctx) {
+		return func() (tessera.Index, error) { return tessera.Index{}, errCircuitOpen }
+	}
 	return a.queue.Add(ctx, entry)
 }
 
+// AddBatch queues all of the provided entries for inclusion in the log, contiguously and in order.
+func (a *appender) AddBatch(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+	if !a.s.elector.leading() {
+		r := make([]tessera.IndexFuture, len(entries))
+		for i := range r {
+			r[i] = func() (tessera.Index, error) { return tessera.Index{}, ErrNotLeader }
+		}
+		return r
+	}
+	if a.s.breaker.rejecting(ctx) {
+		r := make([]tessera.IndexFuture, len(entries))
+		for i := range r {
+			r[i] = func() (tessera.Index, error) { return tessera.Index{}, errCircuitOpen }
+		}
+		return r
+	}
+	return a.queue.AddBatch(ctx, entries)
+}
+
 // sequenceBatch writes the entries from the provided batch into the entry bundle files of the log.
 //
 // This func starts filling entries bundles at the next available slot in the log, ensuring that the
@@ -418,11 +920,30 @@ func (a *appender) Add(ctx context.Context, entry *tessera.Entry) tessera.IndexF
 // than one-by-one.
 //
 // TODO(#21): Separate sequencing and integration for better performance.
-func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry) error {
+func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry) (err error) {
+	ctx, span := tracer.Start(ctx, "tessera.storage.mysql.sequenceBatch")
+	defer span.End()
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
+
 	// Return when there is no entry to sequence.
 	if len(entries) == 0 {
 		return nil
 	}
+	// Batches queued before leadership was lost are rejected too. Sequencing is safe without this, since the
+	// tree state is locked by each batch's transaction, but only the leader should be writing to the log.
+	if !a.s.elector.leading() {
+		return ErrNotLeader
+	}
+
+	if err := a.s.breaker.acquire(ctx); err != nil {
+		return err
+	}
+	// misordered is set if the batch is rejected because of its preordered entries, which says nothing about the
+	// health of the database.
+	misordered := false
+	defer func() {
+		a.s.breaker.record(ctx, misordered || !isUnhealthy(err))
+	}()
 
 	// Get a Tx for making transaction requests.
 	tx, err := a.s.db.BeginTx(ctx, nil)
@@ -445,6 +966,10 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 	if err := row.Scan(&state.size, &state.root); err != nil {
 		return fmt.Errorf("failed to read tree state: %w", err)
 	}
+	if err := storage.CheckPreordered(state.size, entries); err != nil {
+		misordered = true
+		return err
+	}
 
 	// Integrate the new entries into the entry bundle (TiledLeaves table) and tile (Subtree table).
 	if err := a.appendEntries(ctx, tx, state.size, entries); err != nil {
@@ -453,6 +978,9 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 
 	// Commit the transaction.
 	err = tx.Commit()
+	if err == nil {
+		a.integrated.Add(ctx, state.size+uint64(len(entries)))
+	}
 
 	select {
 	case a.cpUpdated <- struct{}{}:
@@ -532,7 +1060,7 @@ func (a *appender) appendEntries(ctx context.Context, tx *sql.Tx, fromSeq uint64
 	for i, e := range sequencedEntries {
 		lh[i] = e.LeafHash
 	}
-	newSize, newRoot, err := integrate(ctx, tx, fromSeq, lh, a.s.writeTile)
+	newSize, newRoot, err := integrate(ctx, tx, fromSeq, lh, a.s.writeTile, a.hasher)
 	if err != nil {
 		return fmt.Errorf("integrate: %v", err)
 	}
@@ -542,7 +1070,7 @@ func (a *appender) appendEntries(ctx context.Context, tx *sql.Tx, fromSeq uint64
 		return fmt.Errorf("writeCheckpoint: %w", err)
 	}
 
-	klog.V(1).Infof("New tree: %d, %x", newSize, newRoot)
+	klog.V(1).InfoS("New tree", "size", newSize, "root", fmt.Sprintf("%x", newRoot))
 	return nil
 }
 
@@ -597,11 +1125,11 @@ func getTiles(ctx context.Context, tx *sql.Tx, tileIDs []storage.TileID, _ uint6
 }
 
 // integrate adds the provided leaf hashes to the merkle tree, starting at the provided location.
-func integrate(ctx context.Context, tx *sql.Tx, fromSeq uint64, lh [][]byte, writeTile func(context.Context, *sql.Tx, uint64, uint64, []byte) error) (uint64, []byte, error) {
+func integrate(ctx context.Context, tx *sql.Tx, fromSeq uint64, lh [][]byte, writeTile func(context.Context, *sql.Tx, uint64, uint64, []byte) error, h merkle.LogHasher) (uint64, []byte, error) {
 	getTiles := func(ctx context.Context, tileIDs []storage.TileID, treeSize uint64) ([]*api.HashTile, error) {
 		return getTiles(ctx, tx, tileIDs, treeSize)
 	}
-	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh)
+	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, lh, h, layout.DefaultTiling)
 	if err != nil {
 		return 0, nil, fmt.Errorf("storage.Integrate: %v", err)
 	}
@@ -621,7 +1149,13 @@ func integrate(ctx context.Context, tx *sql.Tx, fromSeq uint64, lh [][]byte, wri
 
 // MigrationWriter creates a new MySQL storage for the MigrationTarget lifecycle mode.
 func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOptions) (migrate.MigrationWriter, tessera.LogReader, error) {
-	if err := s.maybeInitTree(ctx); err != nil {
+	if enc := opts.EntryBundleEncoding(); enc != "" {
+		return nil, nil, fmt.Errorf("entry bundle encoding %q is not supported by MySQL storage", enc)
+	}
+	if opts.EntryBundleKeyProvider() != nil {
+		return nil, nil, errors.New("entry bundle encryption is not supported by MySQL storage")
+	}
+	if err := s.maybeInitTree(ctx, crypto.SHA256); err != nil {
 		return nil, nil, fmt.Errorf("maybeInitTree: %v", err)
 	}
 
@@ -746,7 +1280,7 @@ func (m *MigrationStorage) integrateBatch(ctx context.Context, fromSeq uint64, l
 		}
 	}()
 
-	newSize, newRoot, err := integrate(ctx, tx, fromSeq, lh, m.s.writeTile)
+	newSize, newRoot, err := integrate(ctx, tx, fromSeq, lh, m.s.writeTile, rfc6962.DefaultHasher)
 	if err != nil {
 		return 0, nil, fmt.Errorf("integrate: %v", err)
 	}
@@ -29,6 +29,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"slices"
 	"testing"
 	"time"
 
@@ -101,7 +102,7 @@ func TestMain(m *testing.M) {
 // `multiStatements=true` in the data source name allows multiple statements in one query.
 // This is not being used in the actual MySQL storage implementation.
 func initDatabaseSchema(ctx context.Context) {
-	dropTablesSQL := "DROP TABLE IF EXISTS `Checkpoint`, `Subtree`, `TiledLeaves`, `TreeState`"
+	dropTablesSQL := "DROP TABLE IF EXISTS `Checkpoint`, `Subtree`, `TiledLeaves`, `TreeState`, `TreeHash`, `ArchivedCheckpoint`, `Lease`, `FollowerPosition`"
 
 	rawSchema, err := os.ReadFile("schema.sql")
 	if err != nil {
@@ -459,6 +460,232 @@ func TestEntryBundleRoundTrip(t *testing.T) {
 	}
 }
 
+func TestReadEntryBundles(t *testing.T) {
+	ctx := context.Background()
+	addFn, r, _ := newTestMySQLStorage(t, ctx)
+
+	// Fill two full bundles, and leave a partial one after them.
+	for i := range 2*layout.EntryBundleWidth + 10 {
+		if _, err := addFn(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	for _, test := range []struct {
+		name        string
+		from, count uint64
+		wantErr     error
+	}{
+		{name: "all full", from: 0, count: 2},
+		{name: "one", from: 1, count: 1},
+		{name: "partial", from: 1, count: 2, wantErr: os.ErrNotExist},
+		{name: "missing", from: 5, count: 2, wantErr: os.ErrNotExist},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := r.ReadEntryBundles(ctx, test.from, test.count)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("ReadEntryBundles: got err %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+			if uint64(len(got)) != test.count {
+				t.Fatalf("got %d bundles, want %d", len(got), test.count)
+			}
+			for i, b := range got {
+				want, err := r.ReadEntryBundle(ctx, test.from+uint64(i), 0)
+				if err != nil {
+					t.Fatalf("ReadEntryBundle: %v", err)
+				}
+				if !bytes.Equal(b, want) {
+					t.Errorf("bundle %d differs from ReadEntryBundle", test.from+uint64(i))
+				}
+			}
+		})
+	}
+}
+
+func TestReplicaLogReader(t *testing.T) {
+	ctx := context.Background()
+	initDatabaseSchema(ctx)
+
+	// The test database stands in for its own replica, through a separate connection pool.
+	replica, err := sql.Open("mysql", *mysqlURI)
+	if err != nil {
+		t.Fatalf("Failed to open replica: %v", err)
+	}
+	defer func() { _ = replica.Close() }()
+	s, err := NewWithConfig(ctx, testDB, Config{ReadReplica: replica})
+	if err != nil {
+		t.Fatalf("Failed to create mysql.Storage: %v", err)
+	}
+	if got := s.ReplicaLogReader().(*logReader).db; got != replica {
+		t.Errorf("ReplicaLogReader reads from %v, want the replica", got)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, s, tessera.NewAppendOptions().
+		WithCheckpointSigner(noteSigner).
+		WithCheckpointInterval(time.Second).
+		WithBatching(128, 100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		if _, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	rr := s.ReplicaLogReader()
+	size, err := rr.IntegratedSize(ctx)
+	if err != nil {
+		t.Fatalf("IntegratedSize: %v", err)
+	}
+	if size != 10 {
+		t.Errorf("IntegratedSize = %d, want 10", size)
+	}
+	want, err := r.ReadEntryBundle(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	}
+	got, err := rr.ReadEntryBundle(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("replica ReadEntryBundle: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("replica ReadEntryBundle = %x, want %x", got, want)
+	}
+
+	// Without a replica, the primary is used.
+	s, err = New(ctx, testDB)
+	if err != nil {
+		t.Fatalf("Failed to create mysql.Storage: %v", err)
+	}
+	if got := s.ReplicaLogReader().(*logReader).db; got != testDB {
+		t.Errorf("ReplicaLogReader reads from %v, want the primary", got)
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initDatabaseSchema(ctx)
+
+	a := newLeaderElector(testDB, LeaderElectionOptions{Holder: "a", LeaseDuration: time.Second})
+	b := newLeaderElector(testDB, LeaderElectionOptions{Holder: "b", LeaseDuration: time.Second})
+	campaign := func(l *leaderElector, wantLeading bool) {
+		t.Helper()
+		if err := l.campaign(ctx); err != nil {
+			t.Fatalf("campaign(%s): %v", l.opts.Holder, err)
+		}
+		if got := l.leading(); got != wantLeading {
+			t.Fatalf("%s leading = %t, want %t", l.opts.Holder, got, wantLeading)
+		}
+	}
+
+	campaign(a, true)
+	campaign(b, false)
+	// Renewing keeps the lease.
+	campaign(a, true)
+	campaign(b, false)
+
+	// Releasing the lease lets another instance take over straight away.
+	if err := a.release(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if a.leading() {
+		t.Error("a still leading after release")
+	}
+	campaign(b, true)
+	campaign(a, false)
+
+	// An expired lease can be taken over.
+	time.Sleep(1100 * time.Millisecond)
+	if b.leading() {
+		t.Error("b still leading after lease expired")
+	}
+	campaign(a, true)
+	campaign(b, false)
+
+	// Instances which aren't the leader reject entries. a's lease is extended so that it can't expire first.
+	campaign(newLeaderElector(testDB, LeaderElectionOptions{Holder: "a", LeaseDuration: time.Minute}), true)
+	s, err := NewWithConfig(ctx, testDB, Config{LeaderElection: &LeaderElectionOptions{Holder: "b", LeaseDuration: time.Minute}})
+	if err != nil {
+		t.Fatalf("Failed to create mysql.Storage: %v", err)
+	}
+	appender, _, _, err := tessera.NewAppender(ctx, s, tessera.NewAppendOptions().
+		WithCheckpointSigner(noteSigner).
+		WithCheckpointInterval(time.Second))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	if s.IsLeader() {
+		t.Fatal("IsLeader() = true while a holds the lease")
+	}
+	if _, err := appender.Add(ctx, tessera.NewEntry([]byte("entry")))(); !errors.Is(err, ErrNotLeader) || !errors.Is(err, tessera.ErrPushback) {
+		t.Errorf("Add on non-leader: got %v, want ErrNotLeader", err)
+	}
+}
+
+func TestFollowerPositionStore(t *testing.T) {
+	ctx := context.Background()
+	initDatabaseSchema(ctx)
+	if _, err := testDB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS `FollowerOutput` (`idx` BIGINT UNSIGNED NOT NULL, PRIMARY KEY(`idx`))"); err != nil {
+		t.Fatalf("Failed to create output table: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := testDB.ExecContext(ctx, "DROP TABLE IF EXISTS `FollowerOutput`"); err != nil {
+			t.Errorf("Failed to drop output table: %v", err)
+		}
+	})
+	write := func(first, to uint64) func(context.Context, *sql.Tx) error {
+		return func(ctx context.Context, tx *sql.Tx) error {
+			for i := first; i < to; i++ {
+				if _, err := tx.ExecContext(ctx, "INSERT INTO `FollowerOutput` (`idx`) VALUES (?)", i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	outputs := func() uint64 {
+		var n uint64
+		if err := testDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM `FollowerOutput`").Scan(&n); err != nil {
+			t.Fatalf("Failed to count output: %v", err)
+		}
+		return n
+	}
+
+	s := NewFollowerPositionStore(testDB, "test")
+	if got, err := s.Position(ctx); err != nil || got != 0 {
+		t.Fatalf("Position() = %d, %v, want 0, nil", got, err)
+	}
+	if err := s.Update(ctx, 0, 10, write(0, 10)); err != nil {
+		t.Fatalf("Update(0, 10): %v", err)
+	}
+	// A failed batch must leave neither its output nor a new position behind.
+	if err := s.Update(ctx, 10, 20, func(ctx context.Context, tx *sql.Tx) error {
+		if err := write(10, 15)(ctx, tx); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}); err == nil {
+		t.Fatal("Update with failing f succeeded")
+	}
+	// Nor may a batch be applied from a stale position.
+	if err := s.Update(ctx, 0, 10, write(0, 10)); err == nil {
+		t.Fatal("Update from stale position succeeded")
+	}
+	if got, err := s.Position(ctx); err != nil || got != 10 {
+		t.Errorf("Position() = %d, %v, want 10, nil", got, err)
+	}
+	if got := outputs(); got != 10 {
+		t.Errorf("got %d rows of output, want 10", got)
+	}
+	if got, err := NewFollowerPositionStore(testDB, "other").Position(ctx); err != nil || got != 0 {
+		t.Errorf("Position() of other follower = %d, %v, want 0, nil", got, err)
+	}
+}
+
 func newTestMySQLStorage(t *testing.T, ctx context.Context) (tessera.AddFn, tessera.LogReader, *Storage) {
 	t.Helper()
 	initDatabaseSchema(ctx)
@@ -477,3 +704,97 @@ func newTestMySQLStorage(t *testing.T, ctx context.Context) (tessera.AddFn, tess
 	}
 	return a.Add, r, s
 }
+
+func TestIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	_, _, s := newTestMySQLStorage(t, ctx)
+
+	now := time.Now()
+	if err := s.WriteIdempotencyKey(ctx, "live", 42, now.Add(time.Hour)); err != nil {
+		t.Fatalf("WriteIdempotencyKey: %v", err)
+	}
+	if err := s.WriteIdempotencyKey(ctx, "expired", 7, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("WriteIdempotencyKey: %v", err)
+	}
+	for _, test := range []struct {
+		key     string
+		wantIdx uint64
+		wantOK  bool
+	}{
+		{key: "live", wantIdx: 42, wantOK: true},
+		{key: "expired"},
+		{key: "missing"},
+	} {
+		t.Run(test.key, func(t *testing.T) {
+			idx, ok, err := s.ReadIdempotencyKey(ctx, test.key)
+			if err != nil {
+				t.Fatalf("ReadIdempotencyKey: %v", err)
+			}
+			if idx != test.wantIdx || ok != test.wantOK {
+				t.Errorf("ReadIdempotencyKey: got (%d, %t), want (%d, %t)", idx, ok, test.wantIdx, test.wantOK)
+			}
+		})
+	}
+	if err := s.ExpireIdempotencyKeys(ctx, now); err != nil {
+		t.Fatalf("ExpireIdempotencyKeys: %v", err)
+	}
+	if _, ok, err := s.ReadIdempotencyKey(ctx, "live"); err != nil || !ok {
+		t.Errorf("ReadIdempotencyKey(live) after expiry: got (%t, %v), want present", ok, err)
+	}
+
+	for _, test := range []struct {
+		key       string
+		wantClaim bool
+	}{
+		{key: "live"},
+		{key: "missing", wantClaim: true},
+		{key: "missing"},
+	} {
+		if ok, err := s.ClaimIdempotencyKey(ctx, test.key, now.Add(time.Minute)); err != nil || ok != test.wantClaim {
+			t.Errorf("ClaimIdempotencyKey(%s): got (%t, %v), want (%t, nil)", test.key, ok, err, test.wantClaim)
+		}
+	}
+	if _, ok, err := s.ReadIdempotencyKey(ctx, "missing"); err != nil || ok {
+		t.Errorf("ReadIdempotencyKey(missing) after claim: got (%t, %v), want absent", ok, err)
+	}
+	if err := s.ReleaseIdempotencyKey(ctx, "missing"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey: %v", err)
+	}
+	if ok, err := s.ClaimIdempotencyKey(ctx, "missing", now.Add(time.Minute)); err != nil || !ok {
+		t.Errorf("ClaimIdempotencyKey(missing) after release: got (%t, %v), want (true, nil)", ok, err)
+	}
+}
+
+func TestCheckpointArchive(t *testing.T) {
+	ctx := context.Background()
+	_, _, s := newTestMySQLStorage(t, ctx)
+
+	if _, err := s.ReadCheckpointArchiveIndex(ctx); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadCheckpointArchiveIndex of empty archive: got err %v, want os.ErrNotExist", err)
+	}
+	for _, size := range []uint64{1, 10, 100, 100} {
+		if err := s.ArchiveCheckpoint(ctx, size, fmt.Appendf(nil, "checkpoint %d", size), 2); err != nil {
+			t.Fatalf("ArchiveCheckpoint(%d): %v", size, err)
+		}
+	}
+	if err := s.ArchiveCheckpoint(ctx, 5, []byte("checkpoint 5"), 2); err == nil {
+		t.Error("ArchiveCheckpoint of smaller checkpoint: got nil error")
+	}
+	raw, err := s.ReadCheckpointArchiveIndex(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpointArchiveIndex: %v", err)
+	}
+	idx := api.CheckpointArchiveIndex{}
+	if err := idx.UnmarshalText(raw); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if got, want := idx.Sizes, []uint64{10, 100}; !slices.Equal(got, want) {
+		t.Errorf("Archived sizes = %v, want %v", got, want)
+	}
+	if got, err := s.ReadArchivedCheckpoint(ctx, 100); err != nil || string(got) != "checkpoint 100" {
+		t.Errorf("ReadArchivedCheckpoint(100) = %q, %v", got, err)
+	}
+	if _, err := s.ReadArchivedCheckpoint(ctx, 1); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadArchivedCheckpoint(1) of expired checkpoint: got err %v, want os.ErrNotExist", err)
+	}
+}
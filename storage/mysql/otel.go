@@ -0,0 +1,94 @@
+// Copyright 2024 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const name = "github.com/transparency-dev/tessera/storage/mysql"
+
+var (
+	meter  = otel.Meter(name)
+	tracer = otel.Tracer(name)
+)
+
+var (
+	treeSizeKey   = attribute.Key("tessera.treeSize")
+	numEntriesKey = attribute.Key("tessera.numEntries")
+	connStateKey  = attribute.Key("tessera.mysql.conn.state")
+)
+
+var (
+	mysqlPoolConnections          metric.Int64ObservableGauge
+	mysqlPoolWaits                metric.Int64ObservableCounter
+	mysqlCircuitBreakerState      metric.Int64ObservableGauge
+	mysqlCircuitBreakerTrips      metric.Int64Counter
+	mysqlCircuitBreakerRejections metric.Int64Counter
+	mysqlLeader                   metric.Int64ObservableGauge
+)
+
+func init() {
+	var err error
+
+	mysqlPoolConnections, err = meter.Int64ObservableGauge(
+		"tessera.mysql.pool.connections",
+		metric.WithDescription("Number of connections in the MySQL connection pool, by state"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		klog.Exitf("Failed to create mysqlPoolConnections metric: %v", err)
+	}
+
+	mysqlPoolWaits, err = meter.Int64ObservableCounter(
+		"tessera.mysql.pool.waits",
+		metric.WithDescription("Number of times a connection to MySQL had to be waited for because the pool was exhausted"),
+		metric.WithUnit("{wait}"))
+	if err != nil {
+		klog.Exitf("Failed to create mysqlPoolWaits metric: %v", err)
+	}
+
+	mysqlCircuitBreakerState, err = meter.Int64ObservableGauge(
+		"tessera.mysql.circuit_breaker.state",
+		metric.WithDescription("State of the MySQL circuit breaker: 0 closed, 1 half-open, 2 open"))
+	if err != nil {
+		klog.Exitf("Failed to create mysqlCircuitBreakerState metric: %v", err)
+	}
+
+	mysqlCircuitBreakerTrips, err = meter.Int64Counter(
+		"tessera.mysql.circuit_breaker.trips",
+		metric.WithDescription("Number of times the MySQL circuit breaker opened"),
+		metric.WithUnit("{trip}"))
+	if err != nil {
+		klog.Exitf("Failed to create mysqlCircuitBreakerTrips metric: %v", err)
+	}
+
+	mysqlCircuitBreakerRejections, err = meter.Int64Counter(
+		"tessera.mysql.circuit_breaker.rejections",
+		metric.WithDescription("Number of entries or batches rejected because the MySQL circuit breaker was open"),
+		metric.WithUnit("{call}"))
+	if err != nil {
+		klog.Exitf("Failed to create mysqlCircuitBreakerRejections metric: %v", err)
+	}
+
+	mysqlLeader, err = meter.Int64ObservableGauge(
+		"tessera.mysql.leader",
+		metric.WithDescription("Whether this instance holds the leader lease: 1 if it does, or if leader election is disabled, and 0 otherwise"))
+	if err != nil {
+		klog.Exitf("Failed to create mysqlLeader metric: %v", err)
+	}
+}
@@ -71,3 +71,80 @@ CREATE TABLE IF NOT EXISTS `TiledLeaves` (
   `data`       LONGBLOB NOT NULL,
   PRIMARY KEY(`tile_index`)
 );
+
+-- "LogState" table stores a single row that records the lifecycle state of the log (0 = active, 1 = quiescing, 2 = frozen).
+CREATE TABLE IF NOT EXISTS `LogState` (
+  -- id is expected to be always 0 to maintain a maximum of a single row.
+  `id`    TINYINT UNSIGNED NOT NULL,
+  -- state is the lifecycle state of the log.
+  `state` TINYINT UNSIGNED NOT NULL,
+  PRIMARY KEY(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `Retention` (
+  -- id is expected to be always 0 to maintain a maximum of a single row.
+  `id`             TINYINT UNSIGNED NOT NULL,
+  -- expunged_below is the tree size below which entry bundles have been expunged by the retention policy.
+  `expunged_below` BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY(`id`)
+);
+
+-- "RetentionSamples" table stores a single row that records the integrated size samples used by the retention policy.
+CREATE TABLE IF NOT EXISTS `RetentionSamples` (
+  -- id is expected to be always 0 to maintain a maximum of a single row.
+  `id`      TINYINT UNSIGNED NOT NULL,
+  -- samples is the opaque state written by the Tessera Appender.
+  `samples` MEDIUMBLOB NOT NULL,
+  PRIMARY KEY(`id`)
+);
+
+-- "TreeHash" table stores a single row that records the name of the hash function used to build the log's Merkle tree.
+CREATE TABLE IF NOT EXISTS `TreeHash` (
+  -- id is expected to be always 0 to maintain a maximum of a single row.
+  `id`   TINYINT UNSIGNED NOT NULL,
+  -- hash is the name of the hash function, e.g. "SHA-256".
+  `hash` VARCHAR(32) NOT NULL,
+  PRIMARY KEY(`id`)
+);
+
+-- "IdempotencyKey" table stores the index assigned to each entry added with an idempotency key, until it expires.
+-- It's required by logs configured WithIdempotencyKeys.
+CREATE TABLE IF NOT EXISTS `IdempotencyKey` (
+  -- key_hash is the SHA-256 hash of the idempotency key.
+  `key_hash` BINARY(32) NOT NULL,
+  -- idx is the index assigned to the entry, or NULL while the entry is being sequenced.
+  `idx`      BIGINT UNSIGNED NULL,
+  -- expiry is the millisecond UNIX timestamp after which the key may be forgotten.
+  `expiry`   BIGINT NOT NULL,
+  PRIMARY KEY(`key_hash`),
+  INDEX(`expiry`)
+);
+
+-- "ArchivedCheckpoint" table stores every checkpoint published by a log configured WithCheckpointArchive.
+CREATE TABLE IF NOT EXISTS `ArchivedCheckpoint` (
+  -- size is the tree size committed to by the checkpoint.
+  `size` BIGINT UNSIGNED NOT NULL,
+  -- note is the text signed by one or more keys in the checkpoint format. See https://c2sp.org/tlog-checkpoint and https://c2sp.org/signed-note.
+  `note` MEDIUMBLOB NOT NULL,
+  PRIMARY KEY(`size`)
+);
+
+-- "Lease" table stores a single row that records which instance of the log holds the leader lease, if leader election is enabled.
+CREATE TABLE IF NOT EXISTS `Lease` (
+  -- id is expected to be always 0 to maintain a maximum of a single row.
+  `id`     TINYINT UNSIGNED NOT NULL,
+  -- holder identifies the instance which holds, or most recently held, the lease.
+  `holder` VARCHAR(255) NOT NULL,
+  -- expiry is the millisecond UNIX timestamp, by the database's clock, at which the lease expires unless it's renewed.
+  `expiry` BIGINT NOT NULL,
+  PRIMARY KEY(`id`)
+);
+
+-- "FollowerPosition" table stores the progress of followers created with NewFollowerPositionStore, in the same database as their output.
+CREATE TABLE IF NOT EXISTS `FollowerPosition` (
+  -- name is the name of the follower.
+  `name`     VARCHAR(255) NOT NULL,
+  -- position is the number of log entries processed by the follower.
+  `position` BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY(`name`)
+);
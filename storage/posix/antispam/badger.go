@@ -122,9 +122,9 @@ type AntispamStorage struct {
 	// When pushBack is true, the decorator will start returning ErrPushback to all calls.
 	pushBack atomic.Bool
 
-	numLookups atomic.Uint64
-	numWrites  atomic.Uint64
-	numHits    atomic.Uint64
+	// counters records the lookups made by the decorator.
+	counters  tessera.DedupeCounters
+	numWrites atomic.Uint64
 }
 
 // index returns the index (if any) previously associated with the provided hash
@@ -132,16 +132,19 @@ func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error)
 	_, span := tracer.Start(ctx, "tessera.antispam.badger.index")
 	defer span.End()
 
-	d.numLookups.Add(1)
+	start := time.Now()
 	var idx *uint64
 	err := d.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(h)
 		if err == badger.ErrKeyNotFound {
 			span.AddEvent("tessera.miss")
+			d.counters.RecordLookup(time.Since(start), false)
 			return nil
+		} else if err != nil {
+			return err
 		}
 		span.AddEvent("tessera.hit")
-		d.numHits.Add(1)
+		d.counters.RecordLookup(time.Since(start), true)
 
 		return item.Value(func(v []byte) error {
 			i := binary.BigEndian.Uint64(v)
@@ -152,6 +155,16 @@ func (d *AntispamStorage) index(ctx context.Context, h []byte) (*uint64, error)
 	return idx, err
 }
 
+// DedupeStats returns statistics about the lookups made by the antispam decorator, along with the number of
+// log entries indexed by the follower.
+func (d *AntispamStorage) DedupeStats(ctx context.Context) (tessera.DedupeStatistics, error) {
+	n, err := (&follower{as: d}).EntriesProcessed(ctx)
+	if err != nil {
+		return tessera.DedupeStatistics{}, err
+	}
+	return d.counters.Statistics(n), nil
+}
+
 // Decorator returns a function which will wrap an underlying Add delegate with
 // code to dedup against the stored data.
 func (d *AntispamStorage) Decorator() func(f tessera.AddFn) tessera.AddFn {
@@ -296,8 +309,8 @@ func (f *follower) Follow(ctx context.Context, lr tessera.LogReader) {
 					sizeFn := func(_ context.Context) (uint64, error) {
 						return logSize, nil
 					}
-					numFetchers := uint(10)
-					next, stop = iter.Pull2(client.Entries(client.EntryBundles(ctx, numFetchers, sizeFn, lr.ReadEntryBundle, followFrom, logSize-followFrom), f.bundleHasher))
+					bundlesPerRead := uint(10)
+					next, stop = iter.Pull2(client.Entries(client.BulkEntryBundles(ctx, bundlesPerRead, sizeFn, lr.ReadEntryBundles, lr.ReadEntryBundle, followFrom, logSize-followFrom), f.bundleHasher))
 				}
 
 				if curIndex == followFrom && curEntries != nil {
@@ -123,6 +123,20 @@ func TestAntispamStorage(t *testing.T) {
 					t.Errorf("got index %d, want %d from looking up hash %x", gotIndex, wantIndex, e.entryHash)
 				}
 			}
+
+			stats, err := as.DedupeStats(t.Context())
+			if err != nil {
+				t.Fatalf("DedupeStats: %v", err)
+			}
+			wantHits := uint64(0)
+			for _, e := range test.lookupEntries {
+				if !e.wantNotFound {
+					wantHits++
+				}
+			}
+			if stats.Lookups != uint64(len(test.lookupEntries)) || stats.Hits != wantHits || stats.IndexSize < uint64(len(test.logEntries)) {
+				t.Errorf("DedupeStats() = %+v, want %d lookups, %d hits, and an index of at least %d entries", stats, len(test.lookupEntries), wantHits, len(test.logEntries))
+			}
 		})
 	}
 }
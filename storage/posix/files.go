@@ -16,6 +16,7 @@ package posix
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,12 +30,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/transparency-dev/merkle"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bundlecrypt"
+	"github.com/transparency-dev/tessera/internal/bundleformat"
+	"github.com/transparency-dev/tessera/internal/compress"
 	"github.com/transparency-dev/tessera/internal/fetcher"
 	"github.com/transparency-dev/tessera/internal/migrate"
+	"github.com/transparency-dev/tessera/internal/otel"
 	"github.com/transparency-dev/tessera/internal/parse"
 	storage "github.com/transparency-dev/tessera/storage/internal"
 	"go.opentelemetry.io/otel/metric"
@@ -55,12 +61,20 @@ const (
 	gcStateFile = "gcState"
 	// gcStateLock must be held when performing GC operations and updating the gcState file.
 	gcStateLock = gcStateFile + ".lock"
+	// logStateFile contains the lifecycle state of the log.
+	logStateFile = "logState"
+	// retentionStateFile contains the size below which entry bundles have been expunged by the retention policy.
+	retentionStateFile = "retentionState"
+	// retentionSamplesFile contains the integrated size samples used by the retention policy.
+	retentionSamplesFile = "retentionSamples"
 	// publishLock must be held when checking/updating the published checkpoint.
 	publishLock = "publish.lock"
 	// treeStateFile contains the integrated (but not necessarily published) state of the tree.
 	treeStateFile = "treeState"
 	// treeStateLock must be held when integrating entries into the tree or writing to the treeState file.
 	treeStateLock = treeStateFile + ".lock"
+	// treeHashFile contains the name of the hash function used to build the log's Merkle tree.
+	treeHashFile = "treeHash"
 
 	minCheckpointInterval = time.Second
 )
@@ -78,10 +92,13 @@ type appender struct {
 	logStorage *logResourceStorage
 	queue      *storage.Queue
 
-	curSize uint64
-	newCP   func(context.Context, uint64, []byte) ([]byte, error) // May be nil for mirrored logs.
+	curSize      uint64
+	newCP        func(context.Context, uint64, []byte) ([]byte, error) // May be nil for mirrored logs.
+	hashFunction crypto.Hash
 
 	cpUpdated chan struct{}
+	// integrated links the spans which publish checkpoints to those which integrated the entries they cover.
+	integrated storage.SpanLinks
 }
 
 // logResourceStorage knows how to read and write tiled log resources via a
@@ -89,6 +106,16 @@ type appender struct {
 type logResourceStorage struct {
 	s           *Storage
 	entriesPath func(uint64, uint8) string
+	hasher      merkle.LogHasher
+	// tiling describes the tiles and entry bundles which the log is divided into.
+	tiling layout.Tiling
+	// bundleEncoding is the content encoding used to store entry bundles, or empty if they're stored as-is.
+	bundleEncoding string
+	// bundleCipher, if set, encrypts entry bundles at rest.
+	bundleCipher *bundlecrypt.Cipher
+	// bundleFormats, if set, records the formats in which the log's entry bundles are stored. Otherwise, they're
+	// read from the log's state directory whenever bundles are read.
+	bundleFormats *bundleformat.History
 }
 
 // NewTreeFunc is the signature of a function which receives information about newly integrated trees.
@@ -115,8 +142,12 @@ func New(ctx context.Context, cfg Config) (tessera.Driver, error) {
 
 func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
 	logStorage := &logResourceStorage{
-		s:           s,
-		entriesPath: opts.EntriesPath(),
+		s:              s,
+		entriesPath:    opts.EntriesPath(),
+		hasher:         opts.Hasher(),
+		tiling:         opts.Tiling(),
+		bundleEncoding: opts.EntryBundleEncoding(),
+		bundleCipher:   bundlecrypt.New(opts.EntryBundleKeyProvider()),
 	}
 
 	a, lr, err := s.newAppender(ctx, logStorage, opts)
@@ -125,7 +156,9 @@ func (s *Storage) Appender(ctx context.Context, opts *tessera.AppendOptions) (*t
 	}
 
 	return &tessera.Appender{
-		Add: a.Add,
+		Add:            a.Add,
+		AddBatch:       a.AddBatch,
+		GarbageCollect: a.garbageCollect,
 	}, lr, nil
 }
 
@@ -135,29 +168,40 @@ func (s *Storage) newAppender(ctx context.Context, o *logResourceStorage, opts *
 	}
 
 	a := &appender{
-		s:          s,
-		logStorage: o,
-		cpUpdated:  make(chan struct{}),
-		newCP:      opts.CheckpointPublisher(o, s.cfg.HTTPClient),
+		s:            s,
+		logStorage:   o,
+		cpUpdated:    make(chan struct{}),
+		newCP:        opts.CheckpointPublisher(o, s.cfg.HTTPClient),
+		hashFunction: opts.HashFunction(),
 	}
 	if err := a.initialise(ctx); err != nil {
 		return nil, nil, err
 	}
-	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), a.sequenceBatch)
+	a.queue = storage.NewQueue(ctx, opts.BatchMaxAge(), opts.BatchMaxSize(), storage.WithSequenceHook(a.sequenceBatch, opts.SequenceHook()))
 
-	go func(ctx context.Context, i time.Duration) {
+	go func(ctx context.Context) {
 		for {
+			// The interval is re-read each time, since it may be changed while the log is running.
+			wait := max(opts.CheckpointInterval(), minCheckpointInterval)
+			if opts.CheckpointGrowthTrigger() > 0 {
+				// Check regularly, in case the tree grew too soon after the last checkpoint to publish straight away.
+				wait = minCheckpointInterval
+			}
 			select {
 			case <-ctx.Done():
 				return
 			case <-a.cpUpdated:
-			case <-time.After(i):
+			case <-time.After(wait):
 			}
-			if err := a.publishCheckpoint(ctx, i); err != nil {
+			if err := a.publishCheckpoint(ctx, storage.CheckpointMinStaleness(ctx, opts, minCheckpointInterval, a.logStorage.ReadCheckpoint, a.s.readTreeState)); err != nil {
+				if errors.Is(err, tessera.ErrLogNotActive) {
+					// The log is frozen, so there's nothing more to publish.
+					continue
+				}
 				klog.Warningf("publishCheckpoint: %v", err)
 			}
 		}
-	}(ctx, opts.CheckpointInterval())
+	}(ctx)
 	if i := opts.GarbageCollectionInterval(); i > 0 {
 		go a.garbageCollectorJob(ctx, i)
 	}
@@ -220,6 +264,12 @@ func (a *appender) Add(ctx context.Context, e *tessera.Entry) tessera.IndexFutur
 	return a.queue.Add(ctx, e)
 }
 
+// AddBatch queues all of the provided entries for inclusion in the log, contiguously and in order.
+// The returned futures behave as those returned by Add.
+func (a *appender) AddBatch(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+	return a.queue.AddBatch(ctx, entries)
+}
+
 func (l *logResourceStorage) ReadCheckpoint(_ context.Context) ([]byte, error) {
 	r, err := os.ReadFile(filepath.Join(l.s.cfg.Path, layout.CheckpointPath))
 	if errors.Is(err, fs.ErrNotExist) {
@@ -228,11 +278,167 @@ func (l *logResourceStorage) ReadCheckpoint(_ context.Context) ([]byte, error) {
 	return r, err
 }
 
+// ReadArchivedCheckpoint returns the archived checkpoint with the given tree size.
+func (l *logResourceStorage) ReadArchivedCheckpoint(_ context.Context, size uint64) ([]byte, error) {
+	return l.readArchive(layout.ArchivedCheckpointPath(size))
+}
+
+// ReadCheckpointArchiveIndex returns the index of the archived checkpoints.
+func (l *logResourceStorage) ReadCheckpointArchiveIndex(_ context.Context) ([]byte, error) {
+	return l.readArchive(layout.CheckpointArchiveIndexPath)
+}
+
+// ArchiveCheckpoint adds the checkpoint with the given tree size to the log's archive of checkpoints.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithCheckpointArchive instead.
+func (l *logResourceStorage) ArchiveCheckpoint(ctx context.Context, size uint64, cp []byte, retain uint) error {
+	return storage.ArchiveCheckpoint(ctx, storage.CheckpointArchiveStore{
+		Get: func(_ context.Context, p string) ([]byte, error) { return l.readArchive(p) },
+		Set: func(_ context.Context, p string, d []byte, _ bool) error { return l.s.createOverwrite(p, d) },
+		Delete: func(_ context.Context, p string) error {
+			return os.Remove(filepath.Join(l.s.cfg.Path, p))
+		},
+	}, size, cp, retain)
+}
+
+func (l *logResourceStorage) readArchive(p string) ([]byte, error) {
+	r, err := l.s.readAll(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, os.ErrNotExist
+	}
+	return r, err
+}
+
 // ReadEntryBundle retrieves the Nth entries bundle for a log of the given size.
 func (l *logResourceStorage) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
-	return fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
-		return os.ReadFile(filepath.Join(l.s.cfg.Path, l.entriesPath(index, p)))
+	formats, err := l.formats()
+	if err != nil {
+		return nil, err
+	}
+	b, err := fetcher.PartialOrFullResource(ctx, p, func(ctx context.Context, p uint8) ([]byte, error) {
+		bf := l.entriesPath(index, p)
+		b, err := os.ReadFile(filepath.Join(l.s.cfg.Path, bf))
+		if err != nil {
+			return nil, err
+		}
+		f := formats.For(index, p)
+		enc := f.Encoding
+		if f.Encrypted {
+			if b, enc, err = l.bundleCipher.Open(ctx, bf, b); err != nil {
+				return nil, err
+			}
+		}
+		return compress.DecodeEntryBundle(enc, b)
 	})
+	if errors.Is(err, os.ErrNotExist) {
+		// The bundle may be missing because it has been expunged.
+		expunged, rErr := l.s.readRetentionState()
+		if rErr != nil {
+			return nil, rErr
+		}
+		if index < expunged/l.tiling.Width() {
+			return nil, fmt.Errorf("entry bundle %d: %w", index, tessera.ErrEntryBundleExpired)
+		}
+	}
+	return b, err
+}
+
+// formats returns the history of the formats in which the log's entry bundles are stored.
+func (l *logResourceStorage) formats() (bundleformat.History, error) {
+	if l.bundleFormats != nil {
+		return *l.bundleFormats, nil
+	}
+	return bundleformat.Read(l.s.cfg.Path)
+}
+
+// ReadEntryBundles retrieves count full entry bundles, starting with the bundle at index fromBundle.
+//
+// The bundles are files on local disk, so they're simply read in turn.
+func (l *logResourceStorage) ReadEntryBundles(ctx context.Context, fromBundle, count uint64) ([][]byte, error) {
+	r := make([][]byte, 0, count)
+	for i := fromBundle; i < fromBundle+count; i++ {
+		b, err := l.ReadEntryBundle(ctx, i, 0)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, b)
+	}
+	return r, nil
+}
+
+// ExpungeEntryBundles deletes all full entry bundles which contain only entries with indices below size.
+//
+// This is used by the Tessera Appender retention policy; personalities should use tessera.WithRetention instead.
+func (l *logResourceStorage) ExpungeEntryBundles(ctx context.Context, size uint64) error {
+	unlock, err := l.s.lockFile(ctx, gcStateLock)
+	if err != nil {
+		return fmt.Errorf("lockFile(%s): %v", gcStateLock, err)
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			klog.Warningf("unlock(%s): %v", gcStateLock, err)
+		}
+	}()
+
+	from, err := l.s.readRetentionState()
+	if err != nil {
+		return err
+	}
+	if size <= from {
+		return nil
+	}
+	w := l.tiling.Width()
+	for i := from / w; i < size/w; i++ {
+		p := l.entriesPath(i, 0)
+		if err := os.Remove(filepath.Join(l.s.cfg.Path, p)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove entry bundle %q: %v", p, err)
+		}
+		if err := l.s.removeDirAll(p + ".p/"); err != nil {
+			return err
+		}
+		if (i+1)%layout.TileWidth == 0 {
+			// Record progress periodically so that an interrupted run doesn't start again from the beginning.
+			if err := l.s.writeRetentionState((i + 1) * w); err != nil {
+				return err
+			}
+		}
+	}
+	return l.s.writeRetentionState(size - size%w)
+}
+
+// ReadRetentionSamples returns the state most recently stored by WriteRetentionSamples, or nil if there is none.
+//
+// This is used by the Tessera Appender retention policy; personalities should use tessera.WithRetention instead.
+func (l *logResourceStorage) ReadRetentionSamples(_ context.Context) ([]byte, error) {
+	p := filepath.Join(l.s.cfg.Path, stateDir, retentionSamplesFile)
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error in ReadFile(%q): %w", p, err)
+	}
+	return raw, nil
+}
+
+// WriteRetentionSamples stores the state used by the retention policy to track when entries were integrated.
+//
+// This is used by the Tessera Appender retention policy; personalities should use tessera.WithRetention instead.
+func (l *logResourceStorage) WriteRetentionSamples(_ context.Context, data []byte) error {
+	if err := l.s.createOverwrite(filepath.Join(stateDir, retentionSamplesFile), data); err != nil {
+		return fmt.Errorf("failed to create/overwrite private retention samples file: %w", err)
+	}
+	return nil
+}
+
+// ReadTileHeight returns the contents of the file recording the height of the log's tiles, or os.ErrNotExist
+// if the log uses layout.DefaultTiling.
+func (l *logResourceStorage) ReadTileHeight(_ context.Context) ([]byte, error) {
+	r, err := l.s.readAll(layout.TileHeightPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, os.ErrNotExist
+	}
+	return r, err
 }
 
 func (l *logResourceStorage) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
@@ -246,6 +452,19 @@ func (l *logResourceStorage) IntegratedSize(ctx context.Context) (uint64, error)
 	return size, err
 }
 
+func (l *logResourceStorage) ReadInternalCheckpoint(ctx context.Context) (tessera.InternalCheckpoint, error) {
+	size, root, err := l.s.readTreeState(ctx)
+	return tessera.InternalCheckpoint{Size: size, Hash: root}, err
+}
+
+func (l *logResourceStorage) RootAt(ctx context.Context, size uint64) ([]byte, error) {
+	integrated, err := l.IntegratedSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.RootAt(ctx, size, integrated, l.ReadTile, l.hasher, l.tiling)
+}
+
 func (l *logResourceStorage) NextIndex(ctx context.Context) (uint64, error) {
 	return l.IntegratedSize(ctx)
 }
@@ -257,6 +476,10 @@ func (l *logResourceStorage) NextIndex(ctx context.Context) (uint64, error) {
 // We try to minimise the number of partially complete entry bundles by writing entries in chunks rather
 // than one-by-one.
 func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.posix.sequenceBatch")
+	defer span.End()
+	span.SetAttributes(numEntriesKey.Int(len(entries)))
+
 	// Double locking:
 	// - The mutex `Lock()` ensures that multiple concurrent calls to this function within a task are serialised.
 	// - The POSIX `lockFile()` ensures that distinct tasks are serialised.
@@ -280,17 +503,21 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 		size = 0
 	}
 	a.curSize = size
-	klog.V(1).Infof("Sequencing from %d", a.curSize)
+	klog.V(1).InfoS("Sequencing entries", "from", a.curSize, "count", len(entries))
 
 	if len(entries) == 0 {
 		return nil
 	}
+	if err := storage.CheckPreordered(a.curSize, entries); err != nil {
+		return err
+	}
 	currTile := &bytes.Buffer{}
 	seq := a.curSize
-	bundleIndex, entriesInBundle := seq/layout.EntryBundleWidth, seq%layout.EntryBundleWidth
+	bundleWidth := a.logStorage.tiling.Width()
+	bundleIndex, entriesInBundle := seq/bundleWidth, seq%bundleWidth
 	if entriesInBundle > 0 {
 		// If the latest bundle is partial, we need to read the data it contains in for our newer, larger, bundle.
-		part, err := a.logStorage.ReadEntryBundle(ctx, bundleIndex, uint8(a.curSize%layout.EntryBundleWidth))
+		part, err := a.logStorage.ReadEntryBundle(ctx, bundleIndex, uint8(entriesInBundle))
 		if err != nil {
 			return err
 		}
@@ -312,7 +539,7 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 		leafHashes = append(leafHashes, e.LeafHash())
 
 		entriesInBundle++
-		if entriesInBundle == layout.EntryBundleWidth {
+		if entriesInBundle == bundleWidth {
 			//  This bundle is full, so we need to write it out...
 			// ... and prepare the next entry bundle for any remaining entries in the batch
 			if err := writeBundle(bundleIndex, 0); err != nil {
@@ -329,8 +556,8 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 		// This check should be redundant since this is [currently] checked above, but an overflow around the uint8 below could
 		// potentially be bad news if that check was broken/defeated as we'd be writing invalid bundle data, so do a belt-and-braces
 		// check and bail if need be.
-		if entriesInBundle > layout.EntryBundleWidth {
-			return fmt.Errorf("logic error: entriesInBundle(%d) > max bundle size %d", entriesInBundle, layout.EntryBundleWidth)
+		if entriesInBundle > bundleWidth {
+			return fmt.Errorf("logic error: entriesInBundle(%d) > max bundle size %d", entriesInBundle, bundleWidth)
 		}
 		if err := writeBundle(bundleIndex, uint8(entriesInBundle)); err != nil {
 			return err
@@ -347,6 +574,7 @@ func (a *appender) sequenceBatch(ctx context.Context, entries []*tessera.Entry)
 	if err := a.s.writeTreeState(ctx, newSize, newRoot); err != nil {
 		return fmt.Errorf("failed to write new tree state: %v", err)
 	}
+	a.integrated.Add(ctx, newSize)
 	// Notify that we know for sure there's a new checkpoint, but don't block if there's already
 	// an outstanding notification in the channel.
 	select {
@@ -366,7 +594,7 @@ func doIntegrate(ctx context.Context, fromSeq uint64, leafHashes [][]byte, ls *l
 		return n, nil
 	}
 
-	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, leafHashes)
+	newSize, newRoot, tiles, err := storage.Integrate(ctx, getTiles, fromSeq, leafHashes, ls.hasher, ls.tiling)
 	if err != nil {
 		klog.Errorf("Integrate: %v", err)
 		return 0, nil, fmt.Errorf("error in Integrate: %v", err)
@@ -377,7 +605,7 @@ func doIntegrate(ctx context.Context, fromSeq uint64, leafHashes [][]byte, ls *l
 		}
 	}
 
-	klog.V(1).Infof("New tree: %d, %x", newSize, newRoot)
+	klog.V(1).InfoS("New tree", "size", newSize, "root", fmt.Sprintf("%x", newRoot))
 
 	return newSize, newRoot, nil
 }
@@ -385,7 +613,7 @@ func doIntegrate(ctx context.Context, fromSeq uint64, leafHashes [][]byte, ls *l
 func (lrs *logResourceStorage) readTiles(ctx context.Context, tileIDs []storage.TileID, treeSize uint64) ([]*api.HashTile, error) {
 	r := make([]*api.HashTile, 0, len(tileIDs))
 	for _, id := range tileIDs {
-		t, err := lrs.readTile(ctx, id.Level, id.Index, layout.PartialTileSize(id.Level, id.Index, treeSize))
+		t, err := lrs.readTile(ctx, id.Level, id.Index, lrs.partialTileSize(id.Level, id.Index, treeSize))
 		if err != nil {
 			return nil, err
 		}
@@ -425,15 +653,22 @@ func (lrs *logResourceStorage) readTile(ctx context.Context, level, index uint64
 func (lrs *logResourceStorage) storeTile(ctx context.Context, level, index, logSize uint64, tile *api.HashTile) error {
 	tileSize := uint64(len(tile.Nodes))
 	klog.V(2).Infof("StoreTile: level %d index %x ts: %x", level, index, tileSize)
-	if tileSize == 0 || tileSize > layout.TileWidth {
-		return fmt.Errorf("tileSize %d must be > 0 and <= %d", tileSize, layout.TileWidth)
+	if w := lrs.tiling.Width(); tileSize == 0 || tileSize > w {
+		return fmt.Errorf("tileSize %d must be > 0 and <= %d", tileSize, w)
 	}
 	t, err := tile.MarshalText()
 	if err != nil {
 		return fmt.Errorf("failed to marshal tile: %w", err)
 	}
 
-	return lrs.writeTile(ctx, level, index, layout.PartialTileSize(level, index, logSize), t)
+	return lrs.writeTile(ctx, level, index, lrs.partialTileSize(level, index, logSize), t)
+}
+
+// partialTileSize returns the partial size of the tile at the given level and index in a tree of logSize,
+// or 0 if the tile is full. The size fits in a uint8 since tessera.AppendOptions limits the tile height
+// to layout.TileHeight.
+func (lrs *logResourceStorage) partialTileSize(level, index, logSize uint64) uint8 {
+	return uint8(lrs.tiling.PartialTileSize(level, index, logSize))
 }
 
 func (lrs *logResourceStorage) writeTile(ctx context.Context, level, index uint64, partial uint8, t []byte) error {
@@ -472,8 +707,16 @@ func (lrs *logResourceStorage) writeTile(ctx context.Context, level, index uint6
 }
 
 // writeBundle takes care of writing out the serialised entry bundle file.
-func (lrs *logResourceStorage) writeBundle(_ context.Context, index uint64, partial uint8, bundle []byte) error {
+func (lrs *logResourceStorage) writeBundle(ctx context.Context, index uint64, partial uint8, bundle []byte) error {
 	bf := lrs.entriesPath(index, partial)
+	bundle, err := compress.EncodeEntryBundle(lrs.bundleEncoding, bundle)
+	if err != nil {
+		return err
+	}
+	bundle, err = lrs.bundleCipher.Seal(ctx, bf, lrs.bundleEncoding, bundle)
+	if err != nil {
+		return err
+	}
 	if err := lrs.s.createOverwrite(bf, bundle); err != nil {
 		if !errors.Is(err, os.ErrExist) {
 			return err
@@ -514,7 +757,16 @@ func (a *appender) initialise(ctx context.Context) error {
 		}
 		// Create the directory structure and write out an empty checkpoint
 		klog.Infof("Initializing directory for POSIX log at %q (this should only happen ONCE per log!)", a.s.cfg.Path)
-		if err := a.s.writeTreeState(ctx, 0, rfc6962.DefaultHasher.EmptyRoot()); err != nil {
+		if err := a.s.ensureHashFunction(a.hashFunction, true); err != nil {
+			return err
+		}
+		if err := a.s.ensureTiling(a.logStorage.tiling, true); err != nil {
+			return err
+		}
+		if err := a.logStorage.ensureBundleFormat(0, true); err != nil {
+			return err
+		}
+		if err := a.s.writeTreeState(ctx, 0, a.logStorage.hasher.EmptyRoot()); err != nil {
 			return fmt.Errorf("failed to write tree-state checkpoint: %v", err)
 		}
 		if a.newCP != nil {
@@ -524,6 +776,15 @@ func (a *appender) initialise(ctx context.Context) error {
 		}
 		return nil
 	}
+	if err := a.s.ensureHashFunction(a.hashFunction, false); err != nil {
+		return err
+	}
+	if err := a.s.ensureTiling(a.logStorage.tiling, false); err != nil {
+		return err
+	}
+	if err := a.logStorage.ensureBundleFormat(curSize, true); err != nil {
+		return err
+	}
 	a.curSize = curSize
 
 	return nil
@@ -564,6 +825,89 @@ func (s *Storage) ensureVersion(version uint16) error {
 	return nil
 }
 
+// ensureHashFunction checks that the log's Merkle tree was built using the hash function h.
+//
+// If create is true, the log is being initialised and h is recorded as the hash function to use from now on.
+func (s *Storage) ensureHashFunction(h crypto.Hash, create bool) error {
+	hashFile := filepath.Join(stateDir, treeHashFile)
+	if create {
+		if err := s.createOverwrite(hashFile, []byte(h.String())); err != nil {
+			return fmt.Errorf("failed to create tree hash file: %v", err)
+		}
+		return nil
+	}
+	data, err := s.readAll(hashFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read tree hash file: %v", err)
+	}
+	return storage.CheckHashFunction(string(data), h)
+}
+
+// ensureTiling checks that the log's tiles are described by tl.
+//
+// If create is true, the log is being initialised and tl is recorded as the tiling to use from now on. It's
+// published at layout.TileHeightPath so that clients can find it, unless it's layout.DefaultTiling.
+func (s *Storage) ensureTiling(tl layout.Tiling, create bool) error {
+	if create {
+		if tl == layout.DefaultTiling {
+			return nil
+		}
+		raw, err := tl.MarshalText()
+		if err != nil {
+			return err
+		}
+		if err := s.createOverwrite(layout.TileHeightPath, raw); err != nil {
+			return fmt.Errorf("failed to create tile height file: %v", err)
+		}
+		return nil
+	}
+	got := layout.DefaultTiling
+	data, err := s.readAll(layout.TileHeightPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read tile height file: %v", err)
+	}
+	if err == nil {
+		if err := got.UnmarshalText(data); err != nil {
+			return err
+		}
+	}
+	if got != tl {
+		return fmt.Errorf("log has tile height %d, but was opened with tile height %d", got.Height, tl.Height)
+	}
+	return nil
+}
+
+// ensureBundleFormat checks that the log's entry bundles can be read with the configured options, and records
+// the format in which they'll be written once the log has grown beyond size entries. If change is false, the
+// format must not differ from the one in which bundles are already being written.
+//
+// The caller must hold the tree state lock.
+func (l *logResourceStorage) ensureBundleFormat(size uint64, change bool) error {
+	h, err := bundleformat.Read(l.s.cfg.Path)
+	if err != nil {
+		return err
+	}
+	f := bundleformat.Format{Encoding: l.bundleEncoding, Encrypted: l.bundleCipher != nil}
+	if !change && h.Latest() != f {
+		return fmt.Errorf("entry bundles are already being written as %+v, so can't be written as %+v", h.Latest(), f)
+	}
+	h, changed := h.Set(size, f)
+	if h.Encrypted() && l.bundleCipher == nil {
+		return errors.New("log has encrypted entry bundles, so a key provider must be configured with WithEntryBundleEncryption")
+	}
+	if changed {
+		raw, err := json.Marshal(h)
+		if err != nil {
+			return fmt.Errorf("error in Marshal: %v", err)
+		}
+		if err := l.s.createOverwrite(bundleformat.Path, raw); err != nil {
+			return fmt.Errorf("failed to create/overwrite entry bundle format file: %v", err)
+		}
+	}
+	l.bundleFormats = &h
+	return nil
+}
+
 // writeTreeState stores the current tree size and root hash on disk.
 func (s *Storage) writeTreeState(ctx context.Context, size uint64, root []byte) error {
 	now := time.Now()
@@ -603,6 +947,8 @@ func (s *Storage) readTreeState(ctx context.Context) (uint64, []byte, error) {
 // minStaleness old, and, if so, creates and published a fresh checkpoint from the current
 // stored tree state.
 func (a *appender) publishCheckpoint(ctx context.Context, minStaleness time.Duration) error {
+	ctx, span := tracer.Start(ctx, "tessera.storage.posix.publishCheckpoint")
+	defer span.End()
 	now := time.Now()
 
 	// Lock the destination "published" checkpoint location:
@@ -631,16 +977,18 @@ func (a *appender) publishCheckpoint(ctx context.Context, minStaleness time.Dura
 	if err != nil {
 		return fmt.Errorf("readTreeState: %v", err)
 	}
+	span.SetAttributes(treeSizeKey.Int64(otel.Clamp64(size)))
+	a.integrated.Link(span, size)
 	cpRaw, err := a.newCP(ctx, size, root)
 	if err != nil {
-		return fmt.Errorf("newCP: %v", err)
+		return fmt.Errorf("newCP: %w", err)
 	}
 
 	if err := a.s.createOverwrite(layout.CheckpointPath, cpRaw); err != nil {
 		return fmt.Errorf("createOverwrite(%s): %v", layout.CheckpointPath, err)
 	}
 
-	klog.V(2).Infof("Published latest checkpoint: %d, %x", size, root)
+	klog.V(2).InfoS("Published latest checkpoint", "size", size, "root", fmt.Sprintf("%x", root))
 
 	posixOpsHistogram.Record(ctx, time.Since(now).Milliseconds(), metric.WithAttributes(opNameKey.String("publishCheckpoint")))
 
@@ -654,9 +1002,6 @@ func (a *appender) garbageCollectorJob(ctx context.Context, i time.Duration) {
 	t := time.NewTicker(i)
 	defer t.Stop()
 
-	// Entirely arbitrary number.
-	maxBundlesPerRun := uint(100)
-
 	for {
 		select {
 		case <-ctx.Done():
@@ -664,27 +1009,32 @@ func (a *appender) garbageCollectorJob(ctx context.Context, i time.Duration) {
 		case <-t.C:
 		}
 
-		// Figure out the size of the latest published checkpoint - we can't be removing partial tiles implied by
-		// that checkpoint just because we've done an integration and know about a larger (but as yet unpublished)
-		// checkpoint!
-		cp, err := a.logStorage.ReadCheckpoint(ctx)
-		if err != nil {
-			klog.Warningf("Failed to get published checkpoint: %v", err)
-			continue
-		}
-		_, pubSize, _, err := parse.CheckpointUnsafe(cp)
-		if err != nil {
-			klog.Warningf("Failed to parse published checkpoint: %v", err)
-			continue
-		}
-
-		if err := a.s.garbageCollect(ctx, pubSize, maxBundlesPerRun); err != nil {
+		if err := a.garbageCollect(ctx); err != nil {
 			klog.Warningf("GarbageCollect failed: %v", err)
 			continue
 		}
 	}
 }
 
+// garbageCollect runs a single pass of the garbage collector.
+func (a *appender) garbageCollect(ctx context.Context) error {
+	// Entirely arbitrary number.
+	maxBundlesPerRun := uint(100)
+
+	// Figure out the size of the latest published checkpoint - we can't be removing partial tiles implied by
+	// that checkpoint just because we've done an integration and know about a larger (but as yet unpublished)
+	// checkpoint!
+	cp, err := a.logStorage.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get published checkpoint: %v", err)
+	}
+	_, pubSize, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return fmt.Errorf("failed to parse published checkpoint: %v", err)
+	}
+	return a.s.garbageCollect(ctx, pubSize, maxBundlesPerRun, a.logStorage.tiling)
+}
+
 // gcState represents a snapshot of how much of the log tree has been garbage collected.
 // This state structure is serialized into a private (but not sensitive) file in the log's .state directory.
 type gcState struct {
@@ -725,7 +1075,82 @@ func (s *Storage) readGCState() (uint64, error) {
 	return gs.FromSize, nil
 }
 
-func (s *Storage) garbageCollect(ctx context.Context, treeSize uint64, maxBundles uint) error {
+// retentionState represents how much of the log has been expunged by the retention policy.
+// This state structure is serialized into a private (but not sensitive) file in the log's .state directory.
+type retentionState struct {
+	ExpungedBelow uint64 `json:"expungedBelow"`
+}
+
+// writeRetentionState stores the size below which entry bundles have been expunged.
+func (s *Storage) writeRetentionState(size uint64) error {
+	raw, err := json.Marshal(retentionState{ExpungedBelow: size})
+	if err != nil {
+		return fmt.Errorf("error in Marshal: %v", err)
+	}
+	if err := s.createOverwrite(filepath.Join(stateDir, retentionStateFile), raw); err != nil {
+		return fmt.Errorf("failed to create/overwrite private retention state file: %w", err)
+	}
+	return nil
+}
+
+// readRetentionState returns the size below which entry bundles have been expunged, or zero if nothing has been.
+func (s *Storage) readRetentionState() (uint64, error) {
+	p := filepath.Join(s.cfg.Path, stateDir, retentionStateFile)
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error in ReadFile(%q): %w", p, err)
+	}
+	rs := &retentionState{}
+	if err := json.Unmarshal(raw, rs); err != nil {
+		return 0, fmt.Errorf("error in Unmarshal: %v", err)
+	}
+	return rs.ExpungedBelow, nil
+}
+
+// logState represents the lifecycle state of the log.
+// This state structure is serialized into a private (but not sensitive) file in the log's .state directory.
+type logState struct {
+	State tessera.LogState `json:"state"`
+}
+
+// ReadLogState returns the lifecycle state of the log.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) ReadLogState(_ context.Context) (tessera.LogState, error) {
+	p := filepath.Join(s.cfg.Path, stateDir, logStateFile)
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// No state has been stored, so the log has never left the active state.
+			return tessera.LogStateActive, nil
+		}
+		return 0, fmt.Errorf("error in ReadFile(%q): %w", p, err)
+	}
+	ls := &logState{}
+	if err := json.Unmarshal(raw, ls); err != nil {
+		return 0, fmt.Errorf("error in Unmarshal: %v", err)
+	}
+	return ls.State, nil
+}
+
+// WriteLogState stores the lifecycle state of the log.
+//
+// This is used by the Tessera Appender lifecycle; personalities should use the methods on tessera.Appender instead.
+func (s *Storage) WriteLogState(_ context.Context, state tessera.LogState) error {
+	raw, err := json.Marshal(logState{State: state})
+	if err != nil {
+		return fmt.Errorf("error in Marshal: %v", err)
+	}
+	if err := s.createOverwrite(filepath.Join(stateDir, logStateFile), raw); err != nil {
+		return fmt.Errorf("failed to create/overwrite private log state file: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) garbageCollect(ctx context.Context, treeSize uint64, maxBundles uint, tl layout.Tiling) error {
 	// Lock the gc location:
 	unlock, err := s.lockFile(ctx, gcStateLock)
 	if err != nil {
@@ -749,7 +1174,7 @@ func (s *Storage) garbageCollect(ctx context.Context, treeSize uint64, maxBundle
 
 	d := uint(0)
 	// GC the tree in "vertical" chunks defined by entry bundles.
-	for ri := range layout.Range(fromSize, treeSize-fromSize, treeSize) {
+	for ri := range tl.Range(fromSize, treeSize-fromSize, treeSize) {
 		// Only known-full bundles are in-scope for for GC, so exit if the current bundle is partial or
 		// we've reached our limit of chunks.
 		if ri.Partial > 0 || d > maxBundles {
@@ -763,7 +1188,7 @@ func (s *Storage) garbageCollect(ctx context.Context, treeSize uint64, maxBundle
 		if err := s.removeDirAll(layout.TilePath(0, ri.Index, 0) + ".p/"); err != nil {
 			return err
 		}
-		fromSize += uint64(ri.N)
+		fromSize += ri.N
 		d++
 
 		// Now consider (only) the part of the tree which sits above the bundle.
@@ -771,9 +1196,9 @@ func (s *Storage) garbageCollect(ctx context.Context, treeSize uint64, maxBundle
 		// edge of a perfect subtree.
 		// This gives the property we'll only visit each parent tile once, rather than up to 256 times.
 		pL, pIdx := uint64(0), ri.Index
-		for isLastLeafInParent(pIdx) {
+		for isLastLeafInParent(pIdx, tl) {
 			// Move our coordinates up to the parent
-			pL, pIdx = pL+1, pIdx>>layout.TileHeight
+			pL, pIdx = pL+1, pIdx>>tl.Height
 			// GC any partial versions of the parent tile.
 			if err := s.removeDirAll(layout.TilePath(pL, pIdx, 0) + ".p/"); err != nil {
 				return err
@@ -788,9 +1213,9 @@ func (s *Storage) garbageCollect(ctx context.Context, treeSize uint64, maxBundle
 }
 
 // isLastLeafInParent returns true if a tile with the provided index is the final child node of a
-// (hypothetical) full parent tile.
-func isLastLeafInParent(i uint64) bool {
-	return i%layout.TileWidth == layout.TileWidth-1
+// (hypothetical) full parent tile in tl.
+func isLastLeafInParent(i uint64, tl layout.Tiling) bool {
+	return i%tl.Width() == tl.Width()-1
 }
 
 // createExclusive atomically creates a file at the given path, relative to the root of the log, containing the provided data.
@@ -834,8 +1259,12 @@ func (s *Storage) MigrationWriter(ctx context.Context, opts *tessera.MigrationOp
 	r := &MigrationStorage{
 		s: s,
 		logStorage: &logResourceStorage{
-			entriesPath: opts.EntriesPath(),
-			s:           s,
+			entriesPath:    opts.EntriesPath(),
+			s:              s,
+			hasher:         rfc6962.DefaultHasher,
+			tiling:         layout.DefaultTiling,
+			bundleEncoding: opts.EntryBundleEncoding(),
+			bundleCipher:   bundlecrypt.New(opts.EntryBundleKeyProvider()),
 		},
 		bundleHasher: opts.LeafHasher(),
 	}
@@ -907,11 +1336,25 @@ func (m *MigrationStorage) initialise(ctx context.Context) error {
 		}
 		// Create the directory structure and write out an empty checkpoint
 		klog.Infof("Initializing directory for POSIX log at %q (this should only happen ONCE per log!)", m.s.cfg.Path)
+		if err := m.s.ensureHashFunction(crypto.SHA256, true); err != nil {
+			return err
+		}
+		if err := m.logStorage.ensureBundleFormat(0, true); err != nil {
+			return err
+		}
 		if err := m.s.writeTreeState(ctx, 0, rfc6962.DefaultHasher.EmptyRoot()); err != nil {
 			return fmt.Errorf("failed to write tree-state checkpoint: %v", err)
 		}
 		return nil
 	}
+	if err := m.s.ensureHashFunction(crypto.SHA256, false); err != nil {
+		return err
+	}
+	// Bundles are copied out of order, so some beyond curSize may already have been written in the log's
+	// current format.
+	if err := m.logStorage.ensureBundleFormat(curSize, false); err != nil {
+		return err
+	}
 	m.curSize = curSize
 
 	return nil
@@ -15,18 +15,29 @@
 package posix
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/proof"
 	"github.com/transparency-dev/merkle/rfc6962"
 	"github.com/transparency-dev/tessera"
 	"github.com/transparency-dev/tessera/api"
 	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
 	"github.com/transparency-dev/tessera/fsck"
 	"golang.org/x/mod/sumdb/note"
 )
@@ -53,6 +64,8 @@ func TestGarbageCollect(t *testing.T) {
 	logStorage := &logResourceStorage{
 		s:           s,
 		entriesPath: opts.EntriesPath(),
+		hasher:      opts.Hasher(),
+		tiling:      opts.Tiling(),
 	}
 	appender, lr, err := s.newAppender(ctx, logStorage, opts)
 	if err != nil {
@@ -87,7 +100,7 @@ func TestGarbageCollect(t *testing.T) {
 		}
 
 		t.Logf("Running GC at size  %d", size)
-		if err := s.garbageCollect(ctx, size, 1000); err != nil {
+		if err := s.garbageCollect(ctx, size, 1000, layout.DefaultTiling); err != nil {
 			t.Fatalf("garbageCollect: %v", err)
 		}
 
@@ -178,3 +191,791 @@ func defaultMerkleLeafHasher(bundle []byte) ([][]byte, error) {
 	}
 	return r, nil
 }
+
+func TestFreeze(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	d, err := New(ctx, Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	opts := func() *tessera.AppendOptions {
+		return tessera.NewAppendOptions().
+			WithCheckpointInterval(time.Second).
+			WithBatching(10, 10*time.Millisecond).
+			WithCheckpointSigner(sk)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, d, opts())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	const numEntries = 25
+	for i := range numEntries {
+		if _, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if err := a.Freeze(ctx); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	cp, err := r.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	if got, want := strings.Split(string(cp), "\n")[1], fmt.Sprint(numEntries); got != want {
+		t.Errorf("frozen checkpoint has size %s, want %s", got, want)
+	}
+
+	// The frozen state should survive restarts.
+	a, _, _, err = tessera.NewAppender(ctx, d, opts())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	if got, want := a.State(), tessera.LogStateFrozen; got != want {
+		t.Errorf("State() = %v, want %v", got, want)
+	}
+	if _, err := a.Add(ctx, tessera.NewEntry([]byte("too late")))(); !errors.Is(err, tessera.ErrLogNotActive) {
+		t.Errorf("Add to frozen log: got err %v, want ErrLogNotActive", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if got, err := r.ReadCheckpoint(ctx); err != nil || !bytes.Equal(got, cp) {
+		t.Errorf("checkpoint changed after log was frozen: %q, %v", got, err)
+	}
+}
+
+func TestExpungeEntryBundles(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	d, err := New(ctx, Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithBatching(1000, 10*time.Millisecond).
+		WithCheckpointSigner(sk))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	const numEntries = 2*layout.EntryBundleWidth + 10
+	fs := make([]tessera.IndexFuture, 0, numEntries)
+	for i := range numEntries {
+		fs = append(fs, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for _, f := range fs {
+		if _, err := f(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	l := r.(*logResourceStorage)
+	// Only the first bundle is entirely below this size.
+	if err := l.ExpungeEntryBundles(ctx, layout.EntryBundleWidth+10); err != nil {
+		t.Fatalf("ExpungeEntryBundles: %v", err)
+	}
+	// Expunging less than has already been expunged is a no-op.
+	if err := l.ExpungeEntryBundles(ctx, 10); err != nil {
+		t.Fatalf("ExpungeEntryBundles: %v", err)
+	}
+	if _, err := r.ReadEntryBundle(ctx, 0, 0); !errors.Is(err, tessera.ErrEntryBundleExpired) {
+		t.Errorf("ReadEntryBundle(0): got err %v, want ErrEntryBundleExpired", err)
+	}
+	if _, err := r.ReadEntryBundle(ctx, 1, 0); err != nil {
+		t.Errorf("ReadEntryBundle(1): %v", err)
+	}
+	if _, err := r.ReadEntryBundle(ctx, 3, 0); !errors.Is(err, os.ErrNotExist) || errors.Is(err, tessera.ErrEntryBundleExpired) {
+		t.Errorf("ReadEntryBundle(3): got err %v, want os.ErrNotExist", err)
+	}
+	if _, err := r.ReadTile(ctx, 0, 0, 0); err != nil {
+		t.Errorf("ReadTile(0, 0): %v", err)
+	}
+}
+
+func TestHashFunction(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	d, err := New(ctx, Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	opts := func(h crypto.Hash) *tessera.AppendOptions {
+		return tessera.NewAppendOptions().
+			WithCheckpointInterval(time.Second).
+			WithBatching(10, 10*time.Millisecond).
+			WithCheckpointSigner(sk).
+			WithHashFunction(h)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, d, opts(crypto.SHA512_256))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	h := rfc6962.New(crypto.SHA512_256)
+	cr := (&compact.RangeFactory{Hash: h.HashChildren}).NewEmptyRange(0)
+	const numEntries = 25
+	for i := range numEntries {
+		data := fmt.Appendf(nil, "entry %d", i)
+		if _, err := a.Add(ctx, tessera.NewEntry(data))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if err := cr.Append(h.HashLeaf(data), nil); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	wantRoot, err := cr.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash: %v", err)
+	}
+	l := r.(*logResourceStorage)
+	if size, root, err := l.s.readTreeState(ctx); err != nil {
+		t.Fatalf("readTreeState: %v", err)
+	} else if size != numEntries || !bytes.Equal(root, wantRoot) {
+		t.Errorf("readTreeState() = %d, %x, want %d, %x", size, root, numEntries, wantRoot)
+	}
+	if ic, err := r.ReadInternalCheckpoint(ctx); err != nil {
+		t.Fatalf("ReadInternalCheckpoint: %v", err)
+	} else if ic.Size != numEntries || !bytes.Equal(ic.Hash, wantRoot) {
+		t.Errorf("ReadInternalCheckpoint() = %d, %x, want %d, %x", ic.Size, ic.Hash, numEntries, wantRoot)
+	}
+
+	// The log must not be reopened with a different hash function.
+	if _, _, _, err := tessera.NewAppender(ctx, d, opts(crypto.SHA256)); err == nil {
+		t.Error("NewAppender with different hash function succeeded, want error")
+	}
+	if _, _, _, err := tessera.NewAppender(ctx, d, opts(crypto.SHA512_256)); err != nil {
+		t.Errorf("NewAppender with same hash function: %v", err)
+	}
+}
+
+func TestTileHeight(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	root := t.TempDir()
+	d, err := New(ctx, Config{Path: root})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	opts := func(h uint) *tessera.AppendOptions {
+		return tessera.NewAppendOptions().
+			WithCheckpointInterval(time.Second).
+			WithBatching(10, 10*time.Millisecond).
+			WithCheckpointSigner(sk).
+			WithTileHeight(h)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, d, opts(4))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	h := rfc6962.DefaultHasher
+	cr := (&compact.RangeFactory{Hash: h.HashChildren}).NewEmptyRange(0)
+	const numEntries = 300
+	leafHashes := make([][]byte, numEntries)
+	futures := make([]tessera.IndexFuture, 0, numEntries)
+	for i := range numEntries {
+		futures = append(futures, a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i))))
+	}
+	for i, f := range futures {
+		idx, err := f()
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		leafHashes[idx.Index] = h.HashLeaf(fmt.Appendf(nil, "entry %d", i))
+	}
+	for _, l := range leafHashes {
+		if err := cr.Append(l, nil); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	wantRoot, err := cr.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash: %v", err)
+	}
+	if ic, err := r.ReadInternalCheckpoint(ctx); err != nil {
+		t.Fatalf("ReadInternalCheckpoint: %v", err)
+	} else if ic.Size != numEntries || !bytes.Equal(ic.Hash, wantRoot) {
+		t.Errorf("ReadInternalCheckpoint() = %d, %x, want %d, %x", ic.Size, ic.Hash, numEntries, wantRoot)
+	}
+
+	// 300 entries fill 18 bundles of 16, with 12 entries left over. Their hashes fill 18 tiles at level 0, with
+	// a partial one of 12, and those tiles' roots fill one tile at level 1, with a partial one of 2.
+	for _, p := range []string{"tile/entries/017", "tile/entries/018.p/12", "tile/0/017", "tile/0/018.p/12", "tile/1/000", "tile/1/001.p/2", "tile/2/000.p/1"} {
+		if _, err := os.Stat(filepath.Join(root, p)); err != nil {
+			t.Errorf("Stat(%q): %v", p, err)
+		}
+	}
+	b, err := r.ReadEntryBundle(ctx, 17, 0)
+	if err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	}
+	var bundle api.EntryBundle
+	if err := bundle.UnmarshalText(b); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if got, want := len(bundle.Entries), 16; got != want {
+		t.Errorf("bundle 17 has %d entries, want %d", got, want)
+	}
+
+	// Clients find the tile height from the log, and use it to build proofs.
+	f := client.FileFetcher{Root: root}
+	tl, err := client.FetchTiling(ctx, f.ReadTileHeight)
+	if err != nil {
+		t.Fatalf("FetchTiling: %v", err)
+	}
+	if want := (layout.Tiling{Height: 4}); tl != want {
+		t.Fatalf("FetchTiling() = %v, want %v", tl, want)
+	}
+	pb, err := client.NewProofBuilderWithTiling(ctx, numEntries, f.ReadTile, h, tl)
+	if err != nil {
+		t.Fatalf("NewProofBuilderWithTiling: %v", err)
+	}
+	for _, i := range []uint64{0, 17, 255, 256, 299} {
+		p, err := pb.InclusionProof(ctx, i)
+		if err != nil {
+			t.Fatalf("InclusionProof(%d): %v", i, err)
+		}
+		if err := proof.VerifyInclusion(h, i, numEntries, leafHashes[i], p, wantRoot); err != nil {
+			t.Errorf("VerifyInclusion(%d): %v", i, err)
+		}
+	}
+	cr100 := (&compact.RangeFactory{Hash: h.HashChildren}).NewEmptyRange(0)
+	for _, l := range leafHashes[:100] {
+		if err := cr100.Append(l, nil); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	wantRoot100, err := cr100.GetRootHash(nil)
+	if err != nil {
+		t.Fatalf("GetRootHash: %v", err)
+	}
+	if got, err := r.RootAt(ctx, 100); err != nil {
+		t.Errorf("RootAt(100): %v", err)
+	} else if !bytes.Equal(got, wantRoot100) {
+		t.Errorf("RootAt(100) = %x, want %x", got, wantRoot100)
+	}
+
+	// The log must not be reopened with a different tile height.
+	if _, _, _, err := tessera.NewAppender(ctx, d, opts(layout.TileHeight)); err == nil {
+		t.Error("NewAppender with different tile height succeeded, want error")
+	}
+	if _, _, _, err := tessera.NewAppender(ctx, d, opts(4)); err != nil {
+		t.Errorf("NewAppender with same tile height: %v", err)
+	}
+}
+
+func TestEntryBundleCompression(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	dir := t.TempDir()
+	d, err := New(ctx, Config{Path: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	opts := func() *tessera.AppendOptions {
+		return tessera.NewAppendOptions().
+			WithCheckpointInterval(time.Second).
+			WithBatching(10, 10*time.Millisecond).
+			WithCheckpointSigner(sk)
+	}
+	// bundles holds the expected contents of each entry bundle.
+	var bundles [][]byte
+	var size uint64
+	add := func(a *tessera.Appender, n int) {
+		t.Helper()
+		for range n {
+			data := fmt.Appendf(nil, `{"name": "entry", "index": %d}`, size)
+			if _, err := a.Add(ctx, tessera.NewEntry(data))(); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			if size%layout.EntryBundleWidth == 0 {
+				bundles = append(bundles, nil)
+			}
+			bundles[len(bundles)-1] = append(bundles[len(bundles)-1], tessera.NewEntry(data).MarshalBundleData(size)...)
+			size++
+		}
+	}
+
+	a, _, r, err := tessera.NewAppender(ctx, d, opts().WithEntryBundleCompression())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	add(a, layout.EntryBundleWidth+10)
+
+	stored, err := os.ReadFile(filepath.Join(dir, layout.EntriesPath(0, 0)))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(stored) >= len(bundles[0]) {
+		t.Errorf("Stored bundle is %d bytes, want fewer than %d", len(stored), len(bundles[0]))
+	}
+	if got, err := r.ReadEntryBundle(ctx, 0, 0); err != nil {
+		t.Fatalf("ReadEntryBundle(0): %v", err)
+	} else if !bytes.Equal(got, bundles[0]) {
+		t.Errorf("ReadEntryBundle(0) = %q, want %q", got, bundles[0])
+	}
+
+	// Appending to a compressed partial bundle with compression disabled must work, and vice versa.
+	cancel()
+	ctx, cancel = context.WithCancel(t.Context())
+	defer cancel()
+	a, _, r, err = tessera.NewAppender(ctx, d, opts())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	add(a, 10)
+	if got, err := r.ReadEntryBundle(ctx, 1, 20); err != nil {
+		t.Fatalf("ReadEntryBundle(1.p/20): %v", err)
+	} else if !bytes.Equal(got, bundles[1]) {
+		t.Errorf("ReadEntryBundle(1.p/20) = %q, want %q", got, bundles[1])
+	}
+
+	cancel()
+	ctx, cancel = context.WithCancel(t.Context())
+	defer cancel()
+	a, _, r, err = tessera.NewAppender(ctx, d, opts().WithEntryBundleCompression())
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	add(a, 10)
+	if got, err := r.ReadEntryBundle(ctx, 1, 30); err != nil {
+		t.Fatalf("ReadEntryBundle(1.p/30): %v", err)
+	} else if !bytes.Equal(got, bundles[1]) {
+		t.Errorf("ReadEntryBundle(1.p/30) = %q, want %q", got, bundles[1])
+	}
+}
+
+func TestEntryBundleLooksCompressed(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		encode func([]byte) []byte
+	}{
+		{
+			name: "zstd",
+			encode: func(b []byte) []byte {
+				e, _ := zstd.NewWriter(nil, zstd.WithEncoderCRC(false))
+				return e.EncodeAll(b, nil)
+			},
+		}, {
+			// Any gzip member of 8077 bytes is also a bundle containing a single entry.
+			name: "gzip",
+			encode: func(b []byte) []byte {
+				var buf bytes.Buffer
+				w, _ := gzip.NewWriterLevel(&buf, gzip.NoCompression)
+				_, _ = w.Write(b)
+				_ = w.Close()
+				return buf.Bytes()
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			sk, _ := mustGenerateKeys(t)
+			d, err := New(ctx, Config{Path: t.TempDir()})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			a, shutdown, r, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+				WithCheckpointInterval(time.Second).
+				WithBatching(1, 10*time.Millisecond).
+				WithCheckpointSigner(sk))
+			if err != nil {
+				t.Fatalf("NewAppender: %v", err)
+			}
+			defer func() {
+				if err := shutdown(ctx); err != nil {
+					t.Errorf("shutdown: %v", err)
+				}
+			}()
+			// A serialised entry is prefixed with its length, which is read from the first two bytes of the
+			// encoded data. Find an incompressible payload which encodes to data exactly two bytes longer than
+			// that, so that a bundle containing only the rest of the data as an entry is itself validly encoded.
+			rnd := rand.New(rand.NewPCG(1, 2))
+			payload := make([]byte, 1<<16)
+			for i := range payload {
+				payload[i] = byte(rnd.Uint32())
+			}
+			f := test.encode(payload[:1])
+			size := int(f[0])<<8 | int(f[1]) + 2
+			var frame []byte
+			for n := size; frame == nil && n > 0; n-- {
+				if f := test.encode(payload[:n]); len(f) == size {
+					frame = f
+				}
+			}
+			if frame == nil {
+				t.Fatal("failed to find a suitable payload")
+			}
+			var bundle []byte
+			for _, data := range [][]byte{frame[2:], []byte("next")} {
+				e := tessera.NewEntry(data)
+				idx, err := a.Add(ctx, e)()
+				if err != nil {
+					t.Fatalf("Add: %v", err)
+				}
+				bundle = append(bundle, e.MarshalBundleData(idx.Index)...)
+			}
+			if !bytes.Equal(bundle[:len(frame)], frame) {
+				t.Fatalf("first entry isn't serialised as the encoded payload")
+			}
+			for p := 1; p <= 2; p++ {
+				want := bundle[:len(frame)]
+				if p == 2 {
+					want = bundle
+				}
+				if got, err := r.ReadEntryBundle(ctx, 0, uint8(p)); err != nil {
+					t.Fatalf("ReadEntryBundle(0.p/%d): %v", p, err)
+				} else if !bytes.Equal(got, want) {
+					t.Errorf("ReadEntryBundle(0.p/%d) = %x, want %x", p, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEntryBundleEncryption(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	kp, err := tessera.NewAESKeyProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewAESKeyProvider: %v", err)
+	}
+	dir := t.TempDir()
+	d, err := New(ctx, Config{Path: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var bundle []byte
+	add := func(n int) {
+		t.Helper()
+		// Each Appender uses a new data key, as if the log had been restarted.
+		a, _, _, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+			WithCheckpointInterval(time.Second).
+			WithBatching(10, 10*time.Millisecond).
+			WithCheckpointSigner(sk).
+			WithEntryBundleCompression().
+			WithEntryBundleEncryption(kp))
+		if err != nil {
+			t.Fatalf("NewAppender: %v", err)
+		}
+		for range n {
+			e := tessera.NewEntry(fmt.Appendf(nil, "secret %d", len(bundle)))
+			idx, err := a.Add(ctx, e)()
+			if err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			bundle = append(bundle, e.MarshalBundleData(idx.Index)...)
+		}
+	}
+	add(10)
+	add(10)
+
+	stored, err := os.ReadFile(filepath.Join(dir, layout.EntriesPath(0, 20)))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(stored, []byte("secret")) {
+		t.Errorf("Stored bundle contains plaintext entries")
+	}
+
+	r, err := NewLogReader(ctx, Config{Path: dir}, &LogReaderOptions{EntryBundleKeys: kp})
+	if err != nil {
+		t.Fatalf("NewLogReader: %v", err)
+	}
+	if got, err := r.ReadEntryBundle(ctx, 0, 20); err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	} else if !bytes.Equal(got, bundle) {
+		t.Errorf("ReadEntryBundle = %q, want %q", got, bundle)
+	}
+	r, err = NewLogReader(ctx, Config{Path: dir}, nil)
+	if err != nil {
+		t.Fatalf("NewLogReader: %v", err)
+	}
+	if _, err := r.ReadEntryBundle(ctx, 0, 20); err == nil {
+		t.Errorf("ReadEntryBundle without keys succeeded")
+	}
+}
+
+func TestEnableEntryBundleEncryption(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	kp, err := tessera.NewAESKeyProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewAESKeyProvider: %v", err)
+	}
+	dir := t.TempDir()
+	d, err := New(ctx, Config{Path: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var bundle []byte
+	add := func(kp tessera.EntryBundleKeyProvider, entries ...[]byte) {
+		t.Helper()
+		opts := tessera.NewAppendOptions().
+			WithCheckpointInterval(time.Second).
+			WithBatching(1, 10*time.Millisecond).
+			WithCheckpointSigner(sk)
+		if kp != nil {
+			opts.WithEntryBundleEncryption(kp)
+		}
+		a, shutdown, _, err := tessera.NewAppender(ctx, d, opts)
+		if err != nil {
+			t.Fatalf("NewAppender: %v", err)
+		}
+		for _, data := range entries {
+			e := tessera.NewEntry(data)
+			idx, err := a.Add(ctx, e)()
+			if err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			bundle = append(bundle, e.MarshalBundleData(idx.Index)...)
+		}
+		if err := shutdown(ctx); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+	}
+	// These entries make the unencrypted partial bundle start with the same bytes as an encrypted one, which
+	// mustn't stop it being read back when the following entries are integrated.
+	add(nil, []byte{}, append([]byte("nc\x01"), make([]byte, 29794)...), []byte("plaintext"))
+	add(kp, []byte("secret"), []byte("another secret"))
+
+	r, err := NewLogReader(ctx, Config{Path: dir}, &LogReaderOptions{EntryBundleKeys: kp})
+	if err != nil {
+		t.Fatalf("NewLogReader: %v", err)
+	}
+	for p := range 5 {
+		bf := layout.EntriesPath(0, uint8(p+1))
+		stored, err := os.ReadFile(filepath.Join(dir, bf))
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", bf, err)
+		}
+		got, err := r.ReadEntryBundle(ctx, 0, uint8(p+1))
+		if err != nil {
+			t.Fatalf("ReadEntryBundle(0.p/%d): %v", p+1, err)
+		}
+		if want := bundle[:len(got)]; !bytes.Equal(got, want) {
+			t.Errorf("ReadEntryBundle(0.p/%d) = %q, want %q", p+1, got, want)
+		}
+		// Only the bundles written once encryption was enabled are encrypted.
+		if encrypted := p >= 3; encrypted == bytes.Equal(stored, got) {
+			t.Errorf("%s: got encrypted %t, want %t", bf, !encrypted, encrypted)
+		}
+	}
+
+	// The log now has encrypted bundles, so can't be appended to without the key provider.
+	if _, _, _, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().WithCheckpointSigner(sk)); err == nil {
+		t.Errorf("NewAppender without key provider succeeded")
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	dir := t.TempDir()
+	d, err := New(ctx, Config{Path: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a, shutdown, _, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithBatching(1000, 10*time.Millisecond).
+		WithCheckpointSigner(sk).
+		WithIdempotencyKeys(time.Hour))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	// Wait for the entries to be published, so that the log isn't still being written to when the
+	// temporary directory is removed.
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+	first, err := a.Add(ctx, tessera.NewEntry([]byte("first")).WithIdempotencyKey("submission"))()
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := a.Add(ctx, tessera.NewEntry([]byte("other")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	retry, err := a.Add(ctx, tessera.NewEntry([]byte("retry")).WithIdempotencyKey("submission"))()
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if want := (tessera.Index{Index: first.Index, IsDup: true}); retry != want {
+		t.Errorf("retry got %+v, want %+v", retry, want)
+	}
+
+	// The key should be visible to other instances using the same storage.
+	d2, err := New(ctx, Config{Path: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s := d2.(*Storage)
+	if idx, ok, err := s.ReadIdempotencyKey(ctx, "submission"); err != nil || !ok || idx != first.Index {
+		t.Errorf("ReadIdempotencyKey: got (%d, %t, %v), want (%d, true, nil)", idx, ok, err, first.Index)
+	}
+	if err := s.ExpireIdempotencyKeys(ctx, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatalf("ExpireIdempotencyKeys: %v", err)
+	}
+	if _, ok, err := s.ReadIdempotencyKey(ctx, "submission"); err != nil || ok {
+		t.Errorf("ReadIdempotencyKey after expiry: got (%t, %v), want (false, nil)", ok, err)
+	}
+}
+
+func TestClaimIdempotencyKey(t *testing.T) {
+	ctx := t.Context()
+	d, err := New(ctx, Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s := d.(*Storage)
+	if err := os.MkdirAll(filepath.Join(s.cfg.Path, stateDir), dirPerm); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	expiry := time.Now().Add(time.Minute)
+	if ok, err := s.ClaimIdempotencyKey(ctx, "k", expiry); err != nil || !ok {
+		t.Fatalf("ClaimIdempotencyKey: got (%t, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := s.ClaimIdempotencyKey(ctx, "k", expiry); err != nil || ok {
+		t.Errorf("ClaimIdempotencyKey of claimed key: got (%t, %v), want (false, nil)", ok, err)
+	}
+	if _, ok, err := s.ReadIdempotencyKey(ctx, "k"); err != nil || ok {
+		t.Errorf("ReadIdempotencyKey of claimed key: got (%t, %v), want (false, nil)", ok, err)
+	}
+	if err := s.ReleaseIdempotencyKey(ctx, "k"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey: %v", err)
+	}
+	if ok, err := s.ClaimIdempotencyKey(ctx, "k", expiry); err != nil || !ok {
+		t.Errorf("ClaimIdempotencyKey of released key: got (%t, %v), want (true, nil)", ok, err)
+	}
+	if err := s.WriteIdempotencyKey(ctx, "k", 3, expiry); err != nil {
+		t.Fatalf("WriteIdempotencyKey: %v", err)
+	}
+	if err := s.ReleaseIdempotencyKey(ctx, "k"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey: %v", err)
+	}
+	if idx, ok, err := s.ReadIdempotencyKey(ctx, "k"); err != nil || !ok || idx != 3 {
+		t.Errorf("ReadIdempotencyKey of stored key: got (%d, %t, %v), want (3, true, nil)", idx, ok, err)
+	}
+}
+
+func TestCheckpointArchive(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	d, err := New(ctx, Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a, _, r, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithBatching(1000, 10*time.Millisecond).
+		WithCheckpointSigner(sk).
+		WithCheckpointArchive(2))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	const numEntries = 10
+	for i := range numEntries {
+		if _, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	var cp []byte
+	for cp == nil || strings.Split(string(cp), "\n")[1] != fmt.Sprint(numEntries) {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for checkpoint")
+		case <-time.After(100 * time.Millisecond):
+		}
+		if cp, err = r.ReadCheckpoint(ctx); err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("ReadCheckpoint: %v", err)
+		}
+	}
+
+	// The published checkpoint must have been archived.
+	ar := r.(tessera.CheckpointArchiveReader)
+	if got, err := ar.ReadArchivedCheckpoint(ctx, numEntries); err != nil || !bytes.Equal(got, cp) {
+		t.Errorf("ReadArchivedCheckpoint(%d) = %q, %v, want %q", numEntries, got, err, cp)
+	}
+
+	// Only the two most recent checkpoints should be retained.
+	l := r.(*logResourceStorage)
+	for _, s := range []uint64{numEntries + 1, numEntries + 2} {
+		if err := l.ArchiveCheckpoint(ctx, s, fmt.Appendf(nil, "checkpoint %d", s), 2); err != nil {
+			t.Fatalf("ArchiveCheckpoint(%d): %v", s, err)
+		}
+	}
+	raw, err := ar.ReadCheckpointArchiveIndex(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpointArchiveIndex: %v", err)
+	}
+	idx := api.CheckpointArchiveIndex{}
+	if err := idx.UnmarshalText(raw); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if got, want := idx.Sizes, []uint64{numEntries + 1, numEntries + 2}; !slices.Equal(got, want) {
+		t.Errorf("Archived sizes = %v, want %v", got, want)
+	}
+	if _, err := ar.ReadArchivedCheckpoint(ctx, numEntries); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadArchivedCheckpoint(%d) of expired checkpoint: got err %v, want os.ErrNotExist", numEntries, err)
+	}
+}
+
+func TestCheckpointGrowthTrigger(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	sk, _ := mustGenerateKeys(t)
+	d, err := New(ctx, Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// The interval is far longer than the test, so any new checkpoint must have been triggered by growth.
+	a, _, r, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Hour).
+		WithBatching(1000, 10*time.Millisecond).
+		WithCheckpointSigner(sk).
+		WithCheckpointGrowthTrigger(5))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	for i := range 5 {
+		if _, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	for {
+		cp, err := r.ReadCheckpoint(ctx)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("ReadCheckpoint: %v", err)
+		}
+		if cp != nil && strings.Split(string(cp), "\n")[1] == "5" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for checkpoint, latest is %q", cp)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Growth below the trigger shouldn't cause a new checkpoint to be published.
+	if _, err := a.Add(ctx, tessera.NewEntry([]byte("one more")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	time.Sleep(3 * time.Second)
+	cp, err := r.ReadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint: %v", err)
+	}
+	if got := strings.Split(string(cp), "\n")[1]; got != "5" {
+		t.Errorf("Published checkpoint has size %s, want 5", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// idempotencyDir contains a file for each stored idempotency key, named after the hex SHA-256 hash of the key.
+	idempotencyDir = "idempotency"
+	// idempotencyLock must be held when claiming, releasing, or removing idempotency keys.
+	idempotencyLock = idempotencyDir + ".lock"
+)
+
+// idempotencyKey is the index assigned to the entry with an idempotency key.
+// This structure is serialized into a private (but not sensitive) file in the log's .state directory.
+type idempotencyKey struct {
+	Index uint64 `json:"index"`
+	// Claimed is true while the entry is being sequenced, before its index is known.
+	Claimed bool `json:"claimed,omitempty"`
+	// Expiry is the UNIX time in nanoseconds after which the key may be forgotten.
+	Expiry int64 `json:"expiry"`
+}
+
+func idempotencyKeyPath(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(stateDir, idempotencyDir, hex.EncodeToString(h[:]))
+}
+
+// ReadIdempotencyKey returns the index stored for the given idempotency key, if it hasn't expired.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ReadIdempotencyKey(_ context.Context, key string) (uint64, bool, error) {
+	k, err := s.readIdempotencyKey(idempotencyKeyPath(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if k.Claimed || time.Now().UnixNano() > k.Expiry {
+		return 0, false, nil
+	}
+	return k.Index, true, nil
+}
+
+// ClaimIdempotencyKey records that the entry with the given idempotency key is being sequenced, until expiry,
+// unless the key is already claimed or stored and hasn't expired. It returns whether the key was claimed.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ClaimIdempotencyKey(ctx context.Context, key string, expiry time.Time) (bool, error) {
+	unlock, err := s.lockFile(ctx, idempotencyLock)
+	if err != nil {
+		return false, fmt.Errorf("lockFile(%s): %v", idempotencyLock, err)
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			klog.Warningf("unlock(%s): %v", idempotencyLock, err)
+		}
+	}()
+
+	p := idempotencyKeyPath(key)
+	k, err := s.readIdempotencyKey(p)
+	if err == nil && time.Now().UnixNano() <= k.Expiry {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if err := s.writeIdempotencyKey(p, idempotencyKey{Claimed: true, Expiry: expiry.UnixNano()}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseIdempotencyKey removes the claim on the given idempotency key, if no index has been stored for it.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	unlock, err := s.lockFile(ctx, idempotencyLock)
+	if err != nil {
+		return fmt.Errorf("lockFile(%s): %v", idempotencyLock, err)
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			klog.Warningf("unlock(%s): %v", idempotencyLock, err)
+		}
+	}()
+
+	p := idempotencyKeyPath(key)
+	k, err := s.readIdempotencyKey(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if !k.Claimed {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(s.cfg.Path, p)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove idempotency key: %v", err)
+	}
+	return nil
+}
+
+// WriteIdempotencyKey stores the index assigned to the entry with the given idempotency key, until expiry.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) WriteIdempotencyKey(_ context.Context, key string, index uint64, expiry time.Time) error {
+	return s.writeIdempotencyKey(idempotencyKeyPath(key), idempotencyKey{Index: index, Expiry: expiry.UnixNano()})
+}
+
+// ExpireIdempotencyKeys removes the stored idempotency keys which expired before the given time.
+//
+// This is used by the Tessera Appender; personalities should use tessera.WithIdempotencyKeys instead.
+func (s *Storage) ExpireIdempotencyKeys(ctx context.Context, before time.Time) error {
+	unlock, err := s.lockFile(ctx, idempotencyLock)
+	if err != nil {
+		return fmt.Errorf("lockFile(%s): %v", idempotencyLock, err)
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			klog.Warningf("unlock(%s): %v", idempotencyLock, err)
+		}
+	}()
+
+	dir := filepath.Join(stateDir, idempotencyDir)
+	des, err := os.ReadDir(filepath.Join(s.cfg.Path, dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to list idempotency keys: %v", err)
+	}
+	for _, de := range des {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p := filepath.Join(dir, de.Name())
+		k, err := s.readIdempotencyKey(p)
+		if err != nil {
+			// Temporary files left behind by createOverwrite, or keys removed by another instance.
+			klog.V(2).Infof("Skipping idempotency key file %q: %v", p, err)
+			continue
+		}
+		if k.Expiry < before.UnixNano() {
+			if err := os.Remove(filepath.Join(s.cfg.Path, p)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove expired idempotency key: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Storage) readIdempotencyKey(p string) (idempotencyKey, error) {
+	k := idempotencyKey{}
+	raw, err := s.readAll(p)
+	if err != nil {
+		return k, fmt.Errorf("error in ReadFile(%q): %w", p, err)
+	}
+	if err := json.Unmarshal(raw, &k); err != nil {
+		return k, fmt.Errorf("error in Unmarshal: %v", err)
+	}
+	return k, nil
+}
+
+func (s *Storage) writeIdempotencyKey(p string, k idempotencyKey) error {
+	raw, err := json.Marshal(k)
+	if err != nil {
+		return fmt.Errorf("error in Marshal: %v", err)
+	}
+	if err := s.createOverwrite(p, raw); err != nil {
+		return fmt.Errorf("failed to create/overwrite idempotency key file: %v", err)
+	}
+	return nil
+}
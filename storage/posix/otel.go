@@ -24,9 +24,12 @@ import (
 const name = "github.com/transparency-dev/tessera/storage/posix"
 
 var (
-	meter = otel.Meter(name)
+	meter  = otel.Meter(name)
+	tracer = otel.Tracer(name)
 
-	opNameKey = attribute.Key("op_name")
+	opNameKey     = attribute.Key("op_name")
+	treeSizeKey   = attribute.Key("tessera.treeSize")
+	numEntriesKey = attribute.Key("tessera.numEntries")
 )
 
 var (
@@ -0,0 +1,170 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/bundlecrypt"
+	"k8s.io/klog/v2"
+)
+
+// DefaultWatchPollInterval is used by NewLogReader if LogReaderOptions.PollInterval is not set.
+//
+// On Linux, changes to the log are noticed as they happen using inotify, and polling only guards against missed
+// notifications. On other platforms, polling is the only way that changes are noticed.
+const DefaultWatchPollInterval = 10 * time.Second
+
+// LogReaderOptions holds optional settings for NewLogReader.
+type LogReaderOptions struct {
+	// EntriesPath is the path of the entry bundles in the log. It must match the path that the log's Appender was
+	// configured with. If unset, layout.EntriesPath is used.
+	EntriesPath func(n uint64, p uint8) string
+	// PollInterval is how often the log's state is read, in addition to when it's seen to change.
+	// Defaults to DefaultWatchPollInterval.
+	PollInterval time.Duration
+	// EntryBundleKeys, if set, is used to decrypt entry bundles which were encrypted by an Appender configured
+	// with tessera.AppendOptions.WithEntryBundleEncryption.
+	EntryBundleKeys tessera.EntryBundleKeyProvider
+}
+
+// LogReader is a tessera.LogReader for a POSIX log which is written to by another process, e.g. to serve or
+// follow a log from the same filesystem as the process which appends to it.
+//
+// It implements tessera.LogWatcher by watching the log's directory for changes to the integrated tree and the
+// published checkpoint, so followers passed this LogReader, e.g. by a tessera.NewFollower, catch up as soon
+// as the log grows instead of polling it.
+type LogReader struct {
+	*logResourceStorage
+
+	pollInterval time.Duration
+
+	mu sync.Mutex
+	// latest is the most recent state of the log that was read, and watchers the channels which are
+	// notified when it changes.
+	latest   tessera.LogChange
+	watchers map[chan tessera.LogChange]bool
+}
+
+// NewLogReader returns a LogReader for the POSIX log at cfg.Path, which watches the log for changes until ctx
+// is done. The log need not have been created yet.
+func NewLogReader(ctx context.Context, cfg Config, opts *LogReaderOptions) (*LogReader, error) {
+	o := LogReaderOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.EntriesPath == nil {
+		o.EntriesPath = layout.EntriesPath
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultWatchPollInterval
+	}
+	if fi, err := os.Stat(cfg.Path); err != nil {
+		return nil, fmt.Errorf("failed to stat log directory: %v", err)
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", cfg.Path)
+	}
+	r := &LogReader{
+		logResourceStorage: &logResourceStorage{
+			s:            &Storage{cfg: cfg},
+			entriesPath:  o.EntriesPath,
+			tiling:       layout.DefaultTiling,
+			bundleCipher: bundlecrypt.New(o.EntryBundleKeys),
+		},
+		pollInterval: o.PollInterval,
+		watchers:     make(map[chan tessera.LogChange]bool),
+	}
+	changed, err := watchLogDir(ctx, cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch log directory: %v", err)
+	}
+	r.refresh(ctx)
+	go r.run(ctx, changed)
+	return r, nil
+}
+
+// Watch returns a channel on which the state of the log is sent each time its integrated size or published
+// checkpoint changes, starting with its current state.
+//
+// This implements tessera.LogWatcher.
+func (r *LogReader) Watch(ctx context.Context) <-chan tessera.LogChange {
+	c := make(chan tessera.LogChange, 1)
+	r.mu.Lock()
+	r.watchers[c] = true
+	c <- r.latest
+	r.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watchers, c)
+		close(c)
+	}()
+	return c
+}
+
+// run refreshes the state of the log each time changed delivers, and every poll interval, until ctx is done.
+func (r *LogReader) run(ctx context.Context, changed <-chan struct{}) {
+	t := time.NewTicker(r.pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-t.C:
+		}
+		r.refresh(ctx)
+	}
+}
+
+// refresh reads the state of the log, and notifies watchers if it has changed.
+func (r *LogReader) refresh(ctx context.Context) {
+	size, _, err := r.s.readTreeState(ctx)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			klog.Warningf("LogReader: failed to read tree state: %v", err)
+		}
+		return
+	}
+	cp, err := r.ReadCheckpoint(ctx)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		klog.Warningf("LogReader: failed to read checkpoint: %v", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if size == r.latest.IntegratedSize && bytes.Equal(cp, r.latest.Checkpoint) {
+		return
+	}
+	r.latest = tessera.LogChange{IntegratedSize: size, Checkpoint: cp}
+	for c := range r.watchers {
+		// Replace any notification the watcher hasn't received yet with the latest state.
+		select {
+		case <-c:
+		default:
+		}
+		c <- r.latest
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+// watchLogDir uses inotify to watch the log at root for changes to its checkpoint and tree state, until ctx is
+// done. A value is sent on the returned channel whenever one of them may have changed.
+func watchLogDir(ctx context.Context, root string) (<-chan struct{}, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify_init1: %v", err)
+	}
+	// Since fd is non-blocking, reads from f use the runtime poller, and are interrupted when f is closed.
+	f := os.NewFile(uintptr(fd), "inotify")
+	const mask = unix.IN_CREATE | unix.IN_MOVED_TO | unix.IN_CLOSE_WRITE
+	watch := func(dir string) (int, error) {
+		wd, err := unix.InotifyAddWatch(fd, dir, mask)
+		if err != nil {
+			return 0, fmt.Errorf("inotify_add_watch(%q): %w", dir, err)
+		}
+		return wd, nil
+	}
+	rootWD, err := watch(root)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	// The state directory is created when the log is initialised, which may not have happened yet, in which case
+	// it's watched once it's created.
+	stateWD := -1
+	if wd, err := watch(filepath.Join(root, stateDir)); err == nil {
+		stateWD = wd
+	} else if !errors.Is(err, unix.ENOENT) {
+		_ = f.Close()
+		return nil, err
+	}
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		_ = f.Close()
+	}()
+	go func() {
+		buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					klog.Errorf("LogReader: failed to read inotify events, relying on polling: %v", err)
+				}
+				return
+			}
+			for off := 0; off+unix.SizeofInotifyEvent <= n; {
+				ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				name := strings.TrimRight(string(buf[off+unix.SizeofInotifyEvent:off+unix.SizeofInotifyEvent+int(ev.Len)]), "\x00")
+				off += unix.SizeofInotifyEvent + int(ev.Len)
+
+				switch {
+				case ev.Mask&unix.IN_Q_OVERFLOW != 0:
+					// Some events were lost, so assume that anything could have changed.
+					notify()
+				case int(ev.Wd) == rootWD && name == stateDir && stateWD < 0:
+					if stateWD, err = watch(filepath.Join(root, stateDir)); err != nil {
+						klog.Warningf("LogReader: %v", err)
+						stateWD = -1
+					}
+					notify()
+				case int(ev.Wd) == rootWD && name == layout.CheckpointPath,
+					int(ev.Wd) == stateWD && name == treeStateFile:
+					notify()
+				}
+			}
+		}
+	}()
+	return changed, nil
+}
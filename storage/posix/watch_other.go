@@ -0,0 +1,25 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package posix
+
+import "context"
+
+// watchLogDir returns a channel which never delivers, since changes to the log can't be watched on this
+// platform, and so are only noticed by polling.
+func watchLogDir(_ context.Context, _ string) (<-chan struct{}, error) {
+	return nil, nil
+}
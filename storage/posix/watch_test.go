@@ -0,0 +1,110 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package posix
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+)
+
+func TestLogReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	dir := t.TempDir()
+
+	// On Linux, don't poll, so that the test checks that changes are noticed via inotify.
+	poll := time.Hour
+	if runtime.GOOS != "linux" {
+		poll = 50 * time.Millisecond
+	}
+	// The reader is created before the log, as it might be if the processes are started together.
+	r, err := NewLogReader(ctx, Config{Path: dir}, &LogReaderOptions{PollInterval: poll})
+	if err != nil {
+		t.Fatalf("NewLogReader: %v", err)
+	}
+	changes := r.Watch(ctx)
+
+	sk, _ := mustGenerateKeys(t)
+	d, err := New(ctx, Config{Path: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a, _, _, err := tessera.NewAppender(ctx, d, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithBatching(10, 10*time.Millisecond).
+		WithCheckpointSigner(sk))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+
+	processed := make(chan uint64, 100)
+	f := tessera.NewFollower("test", tessera.NewInMemoryPositionStore(), func(_ context.Context, first uint64, entries [][]byte) error {
+		processed <- first + uint64(len(entries))
+		return nil
+	}, &tessera.FollowerOptions{PollInterval: poll})
+	go f.Follow(ctx, r)
+
+	const numEntries = 25
+	for i := range numEntries {
+		if _, err := a.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))(); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	timeout := time.After(10 * time.Second)
+	var integrated, published bool
+	for !integrated || !published {
+		select {
+		case c := <-changes:
+			integrated = integrated || c.IntegratedSize == numEntries
+			published = published || (c.Checkpoint != nil && strings.Split(string(c.Checkpoint), "\n")[1] == fmt.Sprint(numEntries))
+		case <-timeout:
+			t.Fatalf("timed out waiting for changes: integrated %t, published %t", integrated, published)
+		}
+	}
+	for n := uint64(0); n < numEntries; {
+		select {
+		case n = <-processed:
+		case <-timeout:
+			t.Fatalf("timed out waiting for follower, which processed %d entries", n)
+		}
+	}
+
+	b, err := r.ReadEntryBundle(ctx, 0, numEntries)
+	if err != nil {
+		t.Fatalf("ReadEntryBundle: %v", err)
+	}
+	bundle := api.EntryBundle{}
+	if err := bundle.UnmarshalText(b); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if got, want := string(bundle.Entries[numEntries-1]), fmt.Sprintf("entry %d", numEntries-1); got != want {
+		t.Errorf("last entry is %q, want %q", got, want)
+	}
+}
+
+func TestNewLogReaderMissingDir(t *testing.T) {
+	if _, err := NewLogReader(t.Context(), Config{Path: filepath.Join(t.TempDir(), "missing")}, nil); err == nil {
+		t.Error("NewLogReader of missing directory succeeded, want error")
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// walSegmentRecords is the number of entries written to each WAL segment before a new one is started.
+	walSegmentRecords = 4096
+	walSuffix         = ".wal"
+
+	walRecordEntry = 'E'
+	walRecordDone  = 'D'
+
+	walFlagPreordered     = 1 << 0
+	walFlagIdempotencyKey = 1 << 1
+)
+
+// WithSubmissionWAL configures the Appender to record each entry passed to Add in a write-ahead log in dir,
+// which must be on a local filesystem, before Add returns. Entries are removed from the WAL once they've been
+// sequenced, or once their future has resolved to an error, e.g. because they were rejected with ErrPushback.
+// Any which haven't been when the process exits, because it crashed while they were waiting to be sequenced,
+// are added again when the next Appender using dir is created, before it returns. Entries are sequenced even if
+// the context passed to Add is cancelled once it has returned.
+//
+// This allows personalities to acknowledge entries as soon as Add returns, without waiting for them to be
+// sequenced, at the cost of a disk sync in each call to Add (syncs are shared by concurrent calls, and by the
+// entries in each call to AddBatch).
+//
+// Entries are added at least once: if the process exits after an entry is sequenced but before this is
+// recorded in the WAL, it will be added again, so this should be combined with WithAntispam unless duplicate
+// entries are harmless. Entries must have been created with NewEntry or NewPreorderedEntry, so this
+// can't be used with WithCTLayout.
+func (o *AppendOptions) WithSubmissionWAL(dir string) *AppendOptions {
+	o.submissionWALDir = dir
+	return o
+}
+
+// walRecord is an entry read back from the WAL.
+type walRecord struct {
+	id             uint64
+	data           []byte
+	preordered     *uint64
+	idempotencyKey string
+}
+
+// submissionWAL is the write-ahead log enabled by WithSubmissionWAL.
+//
+// The WAL is a sequence of segment files, each named after the ID of the first entry written to it. Each
+// entry record is followed, possibly in a later segment, by a done record with the same ID once it has been
+// sequenced. Segments are deleted, oldest first, once all of their entries are done.
+type submissionWAL struct {
+	dir string
+
+	mu sync.Mutex
+	f  *os.File
+	// segs holds the first ID of each segment in order, and outstanding the number of entries in each which
+	// aren't yet done.
+	segs        []uint64
+	outstanding map[uint64]int
+	nextID      uint64
+	// written counts the records written, so that sync can tell which have been synced.
+	written uint64
+	// replay holds the entries which weren't done when the WAL was opened.
+	replay []walRecord
+
+	// syncMu serialises syncs, and guards synced, the value of written as of the last sync.
+	syncMu sync.Mutex
+	synced uint64
+}
+
+// openSubmissionWAL opens the WAL in dir, creating it if necessary.
+func openSubmissionWAL(dir string) (*submissionWAL, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %v", err)
+	}
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WAL directory: %v", err)
+	}
+	w := &submissionWAL{dir: dir, outstanding: make(map[uint64]int)}
+	for _, de := range des {
+		n, ok := strings.CutSuffix(de.Name(), walSuffix)
+		if !ok {
+			continue
+		}
+		first, err := strconv.ParseUint(n, 16, 64)
+		if err != nil {
+			continue
+		}
+		w.segs = append(w.segs, first)
+	}
+	slices.Sort(w.segs)
+
+	entries := make(map[uint64]walRecord)
+	for _, s := range w.segs {
+		if err := readWALSegment(w.segPath(s), func(r walRecord, done bool) {
+			w.nextID = max(w.nextID, r.id+1)
+			if done {
+				delete(entries, r.id)
+			} else {
+				entries[r.id] = r
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range entries {
+		w.replay = append(w.replay, r)
+		w.outstanding[w.segOf(r.id)]++
+	}
+	slices.SortFunc(w.replay, func(a, b walRecord) int { return cmp.Compare(a.id, b.id) })
+
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+	w.gc()
+	return w, nil
+}
+
+// readWALSegment calls f with each record in the segment file at path.
+//
+// Reading stops at the first incomplete or corrupt record, which is expected if the process crashed while
+// writing it.
+func readWALSegment(path string, f func(r walRecord, done bool)) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read WAL segment: %v", err)
+	}
+	for len(raw) > 0 {
+		r, done, n, err := decodeWALRecord(raw)
+		if err != nil {
+			klog.Warningf("Ignoring the end of WAL segment %q: %v", path, err)
+			return nil
+		}
+		f(r, done)
+		raw = raw[n:]
+	}
+	return nil
+}
+
+// encodeWALRecord serialises an entry record, or a done record if e is nil.
+func encodeWALRecord(id uint64, e *Entry) []byte {
+	b := []byte{walRecordDone}
+	if e != nil {
+		b[0] = walRecordEntry
+	}
+	b = binary.BigEndian.AppendUint64(b, id)
+	if e != nil {
+		var flags byte
+		if e.preorderedIndex != nil {
+			flags |= walFlagPreordered
+		}
+		if e.idempotencyKey != "" {
+			flags |= walFlagIdempotencyKey
+		}
+		b = append(b, flags)
+		if e.preorderedIndex != nil {
+			b = binary.BigEndian.AppendUint64(b, *e.preorderedIndex)
+		}
+		if e.idempotencyKey != "" {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(e.idempotencyKey)))
+			b = append(b, e.idempotencyKey...)
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(len(e.Data())))
+		b = append(b, e.Data()...)
+	}
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+}
+
+// decodeWALRecord parses the record at the start of b, returning it and its length.
+func decodeWALRecord(b []byte) (walRecord, bool, int, error) {
+	r := walRecord{}
+	if len(b) < 9 {
+		return r, false, 0, io.ErrUnexpectedEOF
+	}
+	kind := b[0]
+	r.id = binary.BigEndian.Uint64(b[1:])
+	n := 9
+	switch kind {
+	case walRecordDone:
+	case walRecordEntry:
+		if len(b) < n+1 {
+			return r, false, 0, io.ErrUnexpectedEOF
+		}
+		flags := b[n]
+		n++
+		if flags&walFlagPreordered != 0 {
+			if len(b) < n+8 {
+				return r, false, 0, io.ErrUnexpectedEOF
+			}
+			idx := binary.BigEndian.Uint64(b[n:])
+			r.preordered = &idx
+			n += 8
+		}
+		if flags&walFlagIdempotencyKey != 0 {
+			if len(b) < n+4 {
+				return r, false, 0, io.ErrUnexpectedEOF
+			}
+			l := int(binary.BigEndian.Uint32(b[n:]))
+			n += 4
+			if len(b) < n+l {
+				return r, false, 0, io.ErrUnexpectedEOF
+			}
+			r.idempotencyKey = string(b[n : n+l])
+			n += l
+		}
+		if len(b) < n+4 {
+			return r, false, 0, io.ErrUnexpectedEOF
+		}
+		l := int(binary.BigEndian.Uint32(b[n:]))
+		n += 4
+		if len(b) < n+l {
+			return r, false, 0, io.ErrUnexpectedEOF
+		}
+		r.data = b[n : n+l]
+		n += l
+	default:
+		return r, false, 0, fmt.Errorf("unknown record type %q", kind)
+	}
+	if len(b) < n+4 {
+		return r, false, 0, io.ErrUnexpectedEOF
+	}
+	if got, want := binary.BigEndian.Uint32(b[n:]), crc32.ChecksumIEEE(b[:n]); got != want {
+		return r, false, 0, errors.New("checksum mismatch")
+	}
+	return r, kind == walRecordDone, n + 4, nil
+}
+
+func (w *submissionWAL) segPath(first uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%016x%s", first, walSuffix))
+}
+
+// segOf returns the first ID of the segment containing the entry with the given ID.
+func (w *submissionWAL) segOf(id uint64) uint64 {
+	i, found := slices.BinarySearch(w.segs, id)
+	if found {
+		return w.segs[i]
+	}
+	return w.segs[i-1]
+}
+
+// rotate starts a new segment. w.mu must be held, or w not yet shared.
+func (w *submissionWAL) rotate() error {
+	if w.f != nil {
+		if err := w.f.Sync(); err != nil {
+			return fmt.Errorf("failed to sync WAL segment: %v", err)
+		}
+		if err := w.f.Close(); err != nil {
+			return fmt.Errorf("failed to close WAL segment: %v", err)
+		}
+	}
+	if n := len(w.segs); n == 0 || w.segs[n-1] != w.nextID {
+		w.segs = append(w.segs, w.nextID)
+	}
+	f, err := os.OpenFile(w.segPath(w.nextID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create WAL segment: %v", err)
+	}
+	w.f = f
+	return nil
+}
+
+// gc deletes the oldest segments while all of their entries are done. w.mu must be held, or w not yet shared.
+func (w *submissionWAL) gc() {
+	for len(w.segs) > 1 && w.outstanding[w.segs[0]] == 0 {
+		if err := os.Remove(w.segPath(w.segs[0])); err != nil {
+			klog.Warningf("Failed to remove WAL segment: %v", err)
+			return
+		}
+		delete(w.outstanding, w.segs[0])
+		w.segs = w.segs[1:]
+	}
+}
+
+// append writes an entry record for e, returning its ID. The record isn't durable until sync is called.
+func (w *submissionWAL) append(e *Entry) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.nextID-w.segs[len(w.segs)-1] >= walSegmentRecords {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	id := w.nextID
+	if _, err := w.f.Write(encodeWALRecord(id, e)); err != nil {
+		return 0, fmt.Errorf("failed to write to WAL: %v", err)
+	}
+	w.nextID++
+	w.written++
+	w.outstanding[w.segs[len(w.segs)-1]]++
+	return id, nil
+}
+
+// sync makes all records written so far durable.
+func (w *submissionWAL) sync() error {
+	w.mu.Lock()
+	target := w.written
+	w.mu.Unlock()
+
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+	if w.synced >= target {
+		// Another call synced our records while we waited.
+		return nil
+	}
+	// Segments are synced when they're rotated, so only the current one needs to be synced here.
+	w.mu.Lock()
+	target, f := w.written, w.f
+	w.mu.Unlock()
+	// If the segment has been rotated since, it was synced before being closed.
+	if err := f.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("failed to sync WAL: %v", err)
+	}
+	w.synced = target
+	return nil
+}
+
+// done records that the entry with the given ID no longer needs to be replayed.
+//
+// Done records aren't synced, since losing one only causes the entry to be added again.
+func (w *submissionWAL) done(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.f.Write(encodeWALRecord(id, nil)); err != nil {
+		klog.Warningf("Failed to write to WAL: %v", err)
+		return
+	}
+	w.written++
+	w.outstanding[w.segOf(id)]--
+	w.gc()
+}
+
+// decorator returns an AddFn which records entries in the WAL before passing them to delegate.
+func (w *submissionWAL) decorator(delegate AddFn) AddFn {
+	return func(ctx context.Context, e *Entry) IndexFuture {
+		id, err := w.append(e)
+		if err != nil {
+			return func() (Index, error) { return Index{}, err }
+		}
+		// Entries added by AddBatch are synced together, before the batch is passed to the driver.
+		if _, batch := ctx.Value(batchCollectorKey{}).(*batchCollector); !batch {
+			if err := w.sync(); err != nil {
+				w.done(id)
+				return func() (Index, error) { return Index{}, err }
+			}
+		}
+		// The entry may already have been acknowledged, so it must be sequenced even if the caller goes away.
+		f := memoizeFuture(delegate(context.WithoutCancel(ctx), e))
+		// The caller may never wait for the future, so wait for it here to know when the entry is done with.
+		// Entries which fail are done with too: the error is returned to the caller, and keeping them would
+		// stop their segment from ever being removed.
+		go func() {
+			if _, err := f(); err != nil {
+				klog.V(1).Infof("Failed to add entry %d from submission WAL: %v", id, err)
+			}
+			w.done(id)
+		}()
+		return f
+	}
+}
+
+// replayEntries adds the entries which weren't done when the WAL was opened, using add.
+//
+// Entries for which add fails because storage is unavailable remain in the WAL, and will be replayed again next
+// time. Those which fail for any other reason, e.g. because they're rejected, would fail again, so are dropped.
+func (w *submissionWAL) replayEntries(ctx context.Context, add AddFn) {
+	if len(w.replay) == 0 {
+		return
+	}
+	klog.Infof("Replaying %d entries from submission WAL", len(w.replay))
+	for _, r := range w.replay {
+		e := NewEntry(r.data)
+		if r.preordered != nil {
+			e = NewPreorderedEntry(r.data, *r.preordered)
+		}
+		if r.idempotencyKey != "" {
+			e.WithIdempotencyKey(r.idempotencyKey)
+		}
+		f := memoizeFuture(add(ctx, e))
+		go func() {
+			if _, err := f(); err != nil {
+				appenderWALReplays.Add(ctx, 1, metric.WithAttributes(walReplayResultKey.String("failed")))
+				if errors.Is(err, ErrStorageUnavailable) {
+					klog.Errorf("Failed to replay entry %d from submission WAL, it will be retried on restart: %v", r.id, err)
+					return
+				}
+				klog.Errorf("Failed to replay entry %d from submission WAL, dropping it: %v", r.id, err)
+			} else {
+				appenderWALReplays.Add(ctx, 1, metric.WithAttributes(walReplayResultKey.String("ok")))
+			}
+			w.done(r.id)
+		}()
+	}
+	w.replay = nil
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestWALRecordRoundTrip(t *testing.T) {
+	idx := uint64(42)
+	for _, test := range []struct {
+		name     string
+		e        *Entry
+		corrupt  func([]byte) []byte
+		wantDone bool
+		wantErr  bool
+	}{
+		{
+			name: "entry",
+			e:    NewEntry([]byte("hello")),
+		}, {
+			name: "preordered entry",
+			e:    NewPreorderedEntry([]byte("hello"), idx),
+		}, {
+			name: "entry with idempotency key",
+			e:    NewPreorderedEntry([]byte("hello"), idx).WithIdempotencyKey("key"),
+		}, {
+			name: "empty entry",
+			e:    NewEntry(nil),
+		}, {
+			name:     "done",
+			wantDone: true,
+		}, {
+			name:    "truncated",
+			e:       NewEntry([]byte("hello")),
+			corrupt: func(b []byte) []byte { return b[:len(b)-1] },
+			wantErr: true,
+		}, {
+			name:    "corrupt",
+			e:       NewEntry([]byte("hello")),
+			corrupt: func(b []byte) []byte { b[12] ^= 1; return b },
+			wantErr: true,
+		}, {
+			name:    "unknown type",
+			e:       NewEntry([]byte("hello")),
+			corrupt: func(b []byte) []byte { b[0] = 'X'; return b },
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := encodeWALRecord(7, test.e)
+			if test.corrupt != nil {
+				b = test.corrupt(b)
+			}
+			r, done, n, err := decodeWALRecord(b)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("decodeWALRecord: %v, want error %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if n != len(b) {
+				t.Errorf("decodeWALRecord used %d bytes, want %d", n, len(b))
+			}
+			if r.id != 7 || done != test.wantDone {
+				t.Errorf("decodeWALRecord: got id %d done %t, want id 7 done %t", r.id, done, test.wantDone)
+			}
+			if test.e == nil {
+				return
+			}
+			if !slices.Equal(r.data, test.e.Data()) {
+				t.Errorf("got data %q, want %q", r.data, test.e.Data())
+			}
+			if got, want := r.idempotencyKey, test.e.IdempotencyKey(); got != want {
+				t.Errorf("got idempotency key %q, want %q", got, want)
+			}
+			if got, want := r.preordered, test.e.PreorderedIndex(); (got == nil) != (want == nil) || (got != nil && *got != *want) {
+				t.Errorf("got preordered index %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSubmissionWALReopen(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openSubmissionWAL(dir)
+	if err != nil {
+		t.Fatalf("openSubmissionWAL: %v", err)
+	}
+	ids := []uint64{}
+	for _, d := range []string{"zero", "one", "two"} {
+		id, err := w.append(NewEntry([]byte(d)))
+		if err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	w.done(ids[1])
+	if err := w.sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	// Simulate a crash part way through writing a record.
+	if _, err := w.f.Write(encodeWALRecord(ids[2]+1, NewEntry([]byte("torn")))[:5]); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	w, err = openSubmissionWAL(dir)
+	if err != nil {
+		t.Fatalf("openSubmissionWAL: %v", err)
+	}
+	got := []string{}
+	for _, r := range w.replay {
+		got = append(got, string(r.data))
+	}
+	if diff := cmp.Diff([]string{"zero", "two"}, got); diff != "" {
+		t.Errorf("unexpected entries to replay (-want +got):\n%s", diff)
+	}
+	if id, err := w.append(NewEntry([]byte("three"))); err != nil || id <= ids[2] {
+		t.Errorf("append: got ID %d (%v), want > %d", id, err, ids[2])
+	}
+}
+
+func TestSubmissionWALRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openSubmissionWAL(dir)
+	if err != nil {
+		t.Fatalf("openSubmissionWAL: %v", err)
+	}
+	for i := range walSegmentRecords + 10 {
+		id, err := w.append(NewEntry([]byte{byte(i)}))
+		if err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		if i != 0 {
+			w.done(id)
+		}
+	}
+	// The first segment can't be removed while its first entry is outstanding.
+	if got := walSegments(t, dir); got != 2 {
+		t.Errorf("got %d segments, want 2", got)
+	}
+	w.done(0)
+	if got := walSegments(t, dir); got != 1 {
+		t.Errorf("got %d segments, want 1", got)
+	}
+}
+
+func TestSubmissionWALDropsFailedEntries(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openSubmissionWAL(dir)
+	if err != nil {
+		t.Fatalf("openSubmissionWAL: %v", err)
+	}
+	proceed := make(chan struct{})
+	add := w.decorator(func(ctx context.Context, e *Entry) IndexFuture {
+		return func() (Index, error) {
+			<-proceed
+			switch string(e.Data()) {
+			case "pushback":
+				return Index{}, ErrPushback
+			case "too large":
+				return Index{}, ErrEntryTooLarge
+			}
+			return Index{}, ctx.Err()
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	var futures []IndexFuture
+	for _, d := range []string{"ok", "pushback", "too large", "cancelled"} {
+		futures = append(futures, add(ctx, NewEntry([]byte(d))))
+	}
+	// The caller goes away once its entries have been acknowledged.
+	cancel()
+	close(proceed)
+	for i, f := range futures {
+		if _, err := f(); (err != nil) != (i == 1 || i == 2) {
+			t.Errorf("future %d: %v", i, err)
+		}
+	}
+
+	// The rejected entries were reported to the caller, so none should be left to replay.
+	deadline := time.Now().Add(5 * time.Second)
+	for walOutstanding(w) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r, err := openSubmissionWAL(dir)
+	if err != nil {
+		t.Fatalf("openSubmissionWAL: %v", err)
+	}
+	if got := len(r.replay); got != 0 {
+		t.Errorf("got %d entries to replay, want 0", got)
+	}
+	// Nor should they keep the segment they were written to.
+	if _, err := os.Stat(w.segPath(0)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat(first segment): got %v, want it to have been removed", err)
+	}
+	if got := walSegments(t, dir); got != 1 {
+		t.Errorf("got %d segments, want 1", got)
+	}
+}
+
+func TestSubmissionWALReplayEntries(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		err      error
+		wantKept bool
+	}{
+		{name: "added"},
+		{name: "rejected", err: ErrEntryTooLarge},
+		{name: "storage unavailable", err: ErrStorageUnavailable, wantKept: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := openSubmissionWAL(dir)
+			if err != nil {
+				t.Fatalf("openSubmissionWAL: %v", err)
+			}
+			if _, err := w.append(NewEntry([]byte("entry"))); err != nil {
+				t.Fatalf("append: %v", err)
+			}
+			if err := w.sync(); err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			r, err := openSubmissionWAL(dir)
+			if err != nil {
+				t.Fatalf("openSubmissionWAL: %v", err)
+			}
+			var mu sync.Mutex
+			added := 0
+			r.replayEntries(t.Context(), func(_ context.Context, e *Entry) IndexFuture {
+				mu.Lock()
+				defer mu.Unlock()
+				added++
+				return func() (Index, error) { return Index{}, test.err }
+			})
+			want := 0
+			if test.wantKept {
+				want = 1
+			}
+			deadline := time.Now().Add(time.Second)
+			for walOutstanding(r) != want && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			// Give a replayed entry which is wrongly marked as done the chance to be.
+			time.Sleep(50 * time.Millisecond)
+			if got := walOutstanding(r); got != want {
+				t.Errorf("got %d outstanding entries, want %d", got, want)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if added != 1 {
+				t.Errorf("got %d calls to add, want 1", added)
+			}
+		})
+	}
+}
+
+func TestWithSubmissionWAL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	// Leave some entries in the WAL, as if the process had crashed before they were sequenced.
+	dir := t.TempDir()
+	w, err := openSubmissionWAL(dir)
+	if err != nil {
+		t.Fatalf("openSubmissionWAL: %v", err)
+	}
+	for _, d := range []string{"zero", "one"} {
+		if _, err := w.append(NewEntry([]byte(d))); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := w.sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	d := &fakeDriver{}
+	a, _, _, err := NewAppender(ctx, d, NewAppendOptions().WithCheckpointSigner(s).WithSubmissionWAL(dir))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	if _, err := a.Add(ctx, NewEntry([]byte("two")))(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	for i, f := range a.AddBatch(ctx, []*Entry{NewEntry([]byte("three")), NewEntry([]byte("four"))}) {
+		if _, err := f(); err != nil {
+			t.Fatalf("future %d: %v", i, err)
+		}
+	}
+	if diff := cmp.Diff([][]string{{"zero"}, {"one"}, {"two"}, {"three", "four"}}, d.batches); diff != "" {
+		t.Errorf("unexpected driver batches (-want +got):\n%s", diff)
+	}
+
+	// Once everything is done, a new WAL in the same directory should have nothing to replay.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w, err := openSubmissionWAL(dir)
+		if err != nil {
+			t.Fatalf("openSubmissionWAL: %v", err)
+		}
+		if len(w.replay) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d entries left to replay", len(w.replay))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWithSubmissionWALCTLayout(t *testing.T) {
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	opts := NewAppendOptions().WithCheckpointSigner(s).WithCTLayout().WithSubmissionWAL(t.TempDir())
+	if err := opts.valid(); err == nil {
+		t.Error("valid() succeeded, want error")
+	}
+}
+
+// walOutstanding returns the number of entries in w which aren't done.
+func walOutstanding(w *submissionWAL) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, o := range w.outstanding {
+		n += o
+	}
+	return n
+}
+
+func walSegments(t *testing.T, dir string) int {
+	t.Helper()
+	m, err := filepath.Glob(filepath.Join(dir, "*"+walSuffix))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	return len(m)
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sumdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/mod/module"
+)
+
+// FileIndex is an Index which stores the index of each module version's record in a file, named by the
+// hash of the module path and version, in a directory on the local filesystem.
+//
+// Hashing the names keeps them a fixed length and avoids case-sensitivity issues on some filesystems.
+type FileIndex struct {
+	dir string
+}
+
+// NewFileIndex returns a FileIndex which stores its files under dir, creating it if necessary.
+func NewFileIndex(dir string) (*FileIndex, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %v", err)
+	}
+	return &FileIndex{dir: dir}, nil
+}
+
+// path returns the path of the file for the module version, which is sharded by the first byte of the
+// hash so that no single directory becomes too large.
+func (f *FileIndex) path(m module.Version) string {
+	h := sha256.Sum256([]byte(m.Path + "@" + m.Version))
+	s := hex.EncodeToString(h[:])
+	return filepath.Join(f.dir, s[:2], s[2:])
+}
+
+// Get implements Index.
+func (f *FileIndex) Get(_ context.Context, m module.Version) (uint64, bool, error) {
+	b, err := os.ReadFile(f.path(m))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	i, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid index for %s: %v", m, err)
+	}
+	return i, true, nil
+}
+
+// Put implements Index.
+func (f *FileIndex) Put(_ context.Context, m module.Version, index uint64) error {
+	p := f.path(m)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a partially written index is never read.
+	t, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := t.WriteString(strconv.FormatUint(index, 10)); err != nil {
+		_ = t.Close()
+		return err
+	}
+	if err := t.Close(); err != nil {
+		return err
+	}
+	return os.Rename(t.Name(), p)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sumdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+const (
+	// maxModSize is the largest go.mod file which will be fetched from a proxy.
+	maxModSize = 16 << 20
+	// maxZipSize is the largest module zip which will be fetched from a proxy, matching the limit
+	// imposed by the go command.
+	maxZipSize = 500 << 20
+)
+
+// ProxyFetcher returns a FetchFunc which downloads module versions from the Go module proxy at proxyURL,
+// using the protocol described by https://go.dev/ref/mod#goproxy-protocol, and hashes them.
+//
+// If client is nil, http.DefaultClient is used.
+func ProxyFetcher(proxyURL string, client *http.Client) (FetchFunc, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %v", proxyURL, err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	f := &proxyFetcher{base: u, client: client}
+	return f.fetch, nil
+}
+
+type proxyFetcher struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func (f *proxyFetcher) fetch(ctx context.Context, m module.Version) (string, string, error) {
+	escPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return "", "", err
+	}
+	escVers, err := module.EscapeVersion(m.Version)
+	if err != nil {
+		return "", "", err
+	}
+	prefix := f.base.JoinPath(escPath, "@v", escVers).String()
+
+	body, err := f.get(ctx, prefix+".mod")
+	if err != nil {
+		return "", "", err
+	}
+	mod := &bytes.Buffer{}
+	if err := copyLimited(mod, body, maxModSize); err != nil {
+		return "", "", fmt.Errorf("failed to read go.mod: %v", err)
+	}
+	modHash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(mod.Bytes())), nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash go.mod: %v", err)
+	}
+
+	// Module zips can be large, so are written to a temporary file rather than held in memory.
+	zf, err := os.CreateTemp("", "sumdb-*.zip")
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		_ = zf.Close()
+		_ = os.Remove(zf.Name())
+	}()
+	if body, err = f.get(ctx, prefix+".zip"); err != nil {
+		return "", "", err
+	}
+	if err := copyLimited(zf, body, maxZipSize); err != nil {
+		return "", "", fmt.Errorf("failed to read module zip: %v", err)
+	}
+	if err := zf.Close(); err != nil {
+		return "", "", err
+	}
+	zipHash, err := dirhash.HashZip(zf.Name(), dirhash.Hash1)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash module zip: %v", err)
+	}
+	return zipHash, modHash, nil
+}
+
+// get fetches u, and returns its body if the request was successful.
+func (f *proxyFetcher) get(ctx context.Context, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", u, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound, http.StatusGone:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", u, os.ErrNotExist)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("failed to fetch %s: %s: %s", u, resp.Status, strings.TrimSpace(string(b)))
+}
+
+// copyLimited copies at most limit bytes from r to w, returning an error if r is larger.
+func copyLimited(w io.Writer, r io.ReadCloser, limit int64) error {
+	defer func() {
+		_ = r.Close()
+	}()
+	n, err := io.Copy(w, io.LimitReader(r, limit+1))
+	if err != nil {
+		return err
+	}
+	if n > limit {
+		return fmt.Errorf("response is larger than %d bytes", limit)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sumdb implements a Go checksum database, as described by https://go.dev/design/25530-sumdb,
+// on top of a Tessera log.
+//
+// Each entry in the log is a go.sum record for a single module version. The go command can use the
+// database by setting GOSUMDB to the key of the log's checkpoint signer, and the URL of the Server.
+package sumdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"golang.org/x/mod/module"
+	x_sumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// TreeOrigin is the origin line of the signed tree heads served by a checksum database.
+const TreeOrigin = "go.sum database tree"
+
+// CheckpointFormatter produces checkpoints in the format expected by the go command, and must be
+// passed to tessera.AppendOptions.WithCheckpointFormatter for logs served by a Server.
+var CheckpointFormatter tessera.CheckpointFormatter = checkpointFormatter{}
+
+type checkpointFormatter struct{}
+
+func (checkpointFormatter) Origin(string) string { return TreeOrigin }
+
+func (checkpointFormatter) Extensions(context.Context, uint64, []byte) ([]string, error) {
+	return nil, nil
+}
+
+// ErrConflict is returned by Server.Add if a different record for the module version is already in the log.
+var ErrConflict = errors.New("conflicting record already logged")
+
+// Index maps module versions to the index of their record in the log.
+type Index interface {
+	// Get returns the index of the record for the module version, or false if there is none.
+	Get(ctx context.Context, m module.Version) (uint64, bool, error)
+	// Put records the index of the record for the module version.
+	Put(ctx context.Context, m module.Version, index uint64) error
+}
+
+// FetchFunc returns the h1: hashes of the module zip and go.mod file for a module version which is
+// not yet in the log. It should return an error wrapping os.ErrNotExist if the module version is
+// unknown.
+type FetchFunc func(ctx context.Context, m module.Version) (zipHash, modHash string, err error)
+
+// Server serves a Go checksum database from a Tessera log.
+//
+// Module versions which are looked up but are not yet in the log are fetched using the FetchFunc,
+// if one was provided, and added to the log before the lookup is answered.
+type Server struct {
+	appender *tessera.Appender
+	reader   tessera.LogReader
+	awaiter  *tessera.PublicationAwaiter
+	index    Index
+	fetch    FetchFunc
+
+	// mu guards adding, which holds a lock for each module version which is being added, so that
+	// conflicting records for the same module version can't be added concurrently.
+	mu     sync.Mutex
+	adding map[module.Version]*versionLock
+}
+
+// versionLock serialises adds of a single module version.
+type versionLock struct {
+	sync.Mutex
+	// refs is the number of callers holding, or waiting for, the lock.
+	refs int
+}
+
+// NewServer returns a Server which adds records to the log using the provided Appender, and reads the
+// log using the LogReader. The PublicationAwaiter is used to wait for new records to be published.
+//
+// If fetch is nil, only module versions which have been added with Add are served.
+func NewServer(a *tessera.Appender, r tessera.LogReader, awaiter *tessera.PublicationAwaiter, index Index, fetch FetchFunc) *Server {
+	return &Server{appender: a, reader: r, awaiter: awaiter, index: index, fetch: fetch, adding: make(map[module.Version]*versionLock)}
+}
+
+// Handler returns an http.Handler which serves the checksum database protocol.
+func (s *Server) Handler() http.Handler {
+	srv := x_sumdb.NewServer(s)
+	mux := http.NewServeMux()
+	for _, p := range x_sumdb.ServerPaths {
+		mux.Handle(p, srv)
+	}
+	return mux
+}
+
+// Add adds the record for a module version to the log, waits for it to be published, and returns
+// its index.
+//
+// If the same record is already in the log, its index is returned. If a different record for the module
+// version is in the log, an error wrapping ErrConflict is returned.
+func (s *Server) Add(ctx context.Context, m module.Version, zipHash, modHash string) (uint64, error) {
+	if err := module.Check(m.Path, m.Version); err != nil {
+		return 0, err
+	}
+	record := fmt.Appendf(nil, "%s %s %s\n%s %s/go.mod %s\n", m.Path, m.Version, zipHash, m.Path, m.Version, modHash)
+	if _, err := tlog.FormatRecord(0, record); err != nil {
+		return 0, fmt.Errorf("invalid record: %v", err)
+	}
+	unlock := s.lock(m)
+	defer unlock()
+
+	idx, ok, err := s.index.Get(ctx, m)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index: %v", err)
+	}
+	if ok {
+		existing, err := s.ReadRecords(ctx, int64(idx), 1)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read record %d: %v", idx, err)
+		}
+		if !slices.Equal(existing[0], record) {
+			return 0, fmt.Errorf("%s: %w", m, ErrConflict)
+		}
+		return idx, nil
+	}
+
+	i, _, err := s.awaiter.Await(ctx, s.appender.Add(ctx, tessera.NewEntry(record)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to add record: %w", err)
+	}
+	// The index is only updated once the record is published, so that lookups never return a record
+	// which isn't committed to by the latest signed tree.
+	if err := s.index.Put(ctx, m, i.Index); err != nil {
+		return 0, fmt.Errorf("failed to update index: %v", err)
+	}
+	return i.Index, nil
+}
+
+// lock acquires the lock for the module version, and returns a function which releases it.
+func (s *Server) lock(m module.Version) func() {
+	s.mu.Lock()
+	l, ok := s.adding[m]
+	if !ok {
+		l = &versionLock{}
+		s.adding[m] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(s.adding, m)
+		}
+	}
+}
+
+// Signed implements sumdb.ServerOps.
+func (s *Server) Signed(ctx context.Context) ([]byte, error) {
+	return s.reader.ReadCheckpoint(ctx)
+}
+
+// ReadRecords implements sumdb.ServerOps.
+func (s *Server) ReadRecords(ctx context.Context, id, n int64) ([][]byte, error) {
+	cp, err := s.reader.ReadCheckpoint(ctx)
+	if err != nil {
+		return nil, notExist(err)
+	}
+	_, size, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %v", err)
+	}
+	if id < 0 || n < 0 || uint64(id+n) > size {
+		return nil, os.ErrNotExist
+	}
+
+	records := make([][]byte, 0, n)
+	for i := uint64(id); i < uint64(id+n); {
+		b := i / layout.EntryBundleWidth
+		raw, err := s.reader.ReadEntryBundle(ctx, b, layout.PartialTileSize(0, b, size))
+		if err != nil {
+			return nil, notExist(err)
+		}
+		bundle := api.EntryBundle{}
+		if err := bundle.UnmarshalText(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse entry bundle %d: %v", b, err)
+		}
+		for ; i < uint64(id+n) && i/layout.EntryBundleWidth == b; i++ {
+			j := i % layout.EntryBundleWidth
+			if j >= uint64(len(bundle.Entries)) {
+				return nil, fmt.Errorf("entry bundle %d has %d entries, want at least %d", b, len(bundle.Entries), j+1)
+			}
+			records = append(records, bundle.Entries[j])
+		}
+	}
+	return records, nil
+}
+
+// Lookup implements sumdb.ServerOps.
+func (s *Server) Lookup(ctx context.Context, m module.Version) (int64, error) {
+	idx, ok, err := s.index.Get(ctx, m)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index: %v", err)
+	}
+	if ok {
+		return int64(idx), nil
+	}
+	if s.fetch == nil {
+		return 0, os.ErrNotExist
+	}
+	zipHash, modHash, err := s.fetch(ctx, m)
+	if err != nil {
+		return 0, notExist(err)
+	}
+	idx, err = s.Add(ctx, m, zipHash, modHash)
+	if err != nil {
+		return 0, err
+	}
+	return int64(idx), nil
+}
+
+// ReadTileData implements sumdb.ServerOps.
+func (s *Server) ReadTileData(ctx context.Context, t tlog.Tile) ([]byte, error) {
+	// Tessera's tiles have the same layout as those of the checksum database, but only with a height of 8.
+	if t.H != layout.TileHeight || t.W <= 0 || t.W > layout.TileWidth {
+		return nil, os.ErrNotExist
+	}
+	p := uint8(t.W % layout.TileWidth)
+	tile, err := s.reader.ReadTile(ctx, uint64(t.L), uint64(t.N), p)
+	if err != nil {
+		return nil, notExist(err)
+	}
+	if len(tile) < t.W*tlog.HashSize {
+		return nil, os.ErrNotExist
+	}
+	return tile[:t.W*tlog.HashSize], nil
+}
+
+// notExist returns os.ErrNotExist if err wraps it, since sumdb.Server only serves a 404 for errors for
+// which os.IsNotExist is true, and returns err otherwise.
+func notExist(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return os.ErrNotExist
+	}
+	return err
+}
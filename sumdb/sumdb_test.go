@@ -0,0 +1,259 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sumdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+	"golang.org/x/mod/module"
+	x_sumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+	"golang.org/x/sync/errgroup"
+)
+
+// clientOps implements sumdb.ClientOps for a checksum database served at url.
+type clientOps struct {
+	url  string
+	vkey string
+
+	mu     sync.Mutex
+	config map[string][]byte
+}
+
+func (c *clientOps) ReadRemote(path string) ([]byte, error) {
+	resp, err := http.Get(c.url + path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s: %s", path, resp.Status, b)
+	}
+	return b, nil
+}
+
+func (c *clientOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(c.vkey), nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config[file], nil
+}
+
+func (c *clientOps) WriteConfig(file string, old, new []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !bytes.Equal(c.config[file], old) {
+		return x_sumdb.ErrWriteConflict
+	}
+	c.config[file] = new
+	return nil
+}
+
+func (c *clientOps) ReadCache(string) ([]byte, error) { return nil, os.ErrNotExist }
+func (c *clientOps) WriteCache(string, []byte)        {}
+func (c *clientOps) Log(string)                       {}
+func (c *clientOps) SecurityError(msg string)         { panic(msg) }
+
+// testZip returns a module zip containing a go.mod file for the module version.
+func testZip(t *testing.T, m module.Version, mod []byte) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	z := zip.NewWriter(b)
+	w, err := z.Create(m.Path + "@" + m.Version + "/go.mod")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := w.Write(mod); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return b.Bytes()
+}
+
+func TestServer(t *testing.T) {
+	ctx := t.Context()
+	skey, vkey, err := note.GenerateKey(nil, "sum.example.com")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	signer, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	dir := t.TempDir()
+	driver, err := posix.New(ctx, posix.Config{Path: filepath.Join(dir, "log")})
+	if err != nil {
+		t.Fatalf("posix.New: %v", err)
+	}
+	appender, shutdown, reader, err := tessera.NewAppender(ctx, driver, tessera.NewAppendOptions().
+		WithCheckpointSigner(signer).
+		WithCheckpointFormatter(CheckpointFormatter).
+		WithCheckpointInterval(time.Second).
+		WithBatching(256, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewAppender: %v", err)
+	}
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	proxied := module.Version{Path: "example.com/proxied", Version: "v1.0.0"}
+	mod := []byte("module example.com/proxied\n")
+	zipData := testZip(t, proxied, mod)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/proxied/@v/v1.0.0.mod":
+			_, _ = w.Write(mod)
+		case "/example.com/proxied/@v/v1.0.0.zip":
+			_, _ = w.Write(zipData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+	fetch, err := ProxyFetcher(proxy.URL, nil)
+	if err != nil {
+		t.Fatalf("ProxyFetcher: %v", err)
+	}
+	index, err := NewFileIndex(filepath.Join(dir, "index"))
+	if err != nil {
+		t.Fatalf("NewFileIndex: %v", err)
+	}
+	s := NewServer(appender, reader, tessera.NewPublicationAwaiter(ctx, reader.ReadCheckpoint, 50*time.Millisecond), index, fetch)
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	// Add enough records to span more than one tile.
+	const numDirect = 300
+	eg := errgroup.Group{}
+	for i := range numDirect {
+		eg.Go(func() error {
+			_, err := s.Add(ctx, module.Version{Path: fmt.Sprintf("example.com/m%d", i), Version: "v1.0.0"}, fmt.Sprintf("h1:zip%d=", i), fmt.Sprintf("h1:mod%d=", i))
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	client := x_sumdb.NewClient(&clientOps{url: srv.URL, vkey: vkey, config: make(map[string][]byte)})
+	for _, i := range []int{0, 255, 256, numDirect - 1} {
+		path := fmt.Sprintf("example.com/m%d", i)
+		// The client returns only the line for the requested version, or its go.mod file.
+		for vers, want := range map[string]string{
+			"v1.0.0":        fmt.Sprintf("%s v1.0.0 h1:zip%d=", path, i),
+			"v1.0.0/go.mod": fmt.Sprintf("%s v1.0.0/go.mod h1:mod%d=", path, i),
+		} {
+			lines, err := client.Lookup(path, vers)
+			if err != nil {
+				t.Fatalf("Lookup(%s, %s): %v", path, vers, err)
+			}
+			if len(lines) != 1 || lines[0] != want {
+				t.Errorf("Lookup(%s, %s) = %q, want [%q]", path, vers, lines, want)
+			}
+		}
+	}
+
+	t.Run("proxied", func(t *testing.T) {
+		lines, err := client.Lookup(proxied.Path, proxied.Version)
+		if err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+		zf := filepath.Join(t.TempDir(), "m.zip")
+		if err := os.WriteFile(zf, zipData, 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		zipHash, err := dirhash.HashZip(zf, dirhash.Hash1)
+		if err != nil {
+			t.Fatalf("HashZip: %v", err)
+		}
+		if want := fmt.Sprintf("%s %s %s", proxied.Path, proxied.Version, zipHash); len(lines) != 1 || lines[0] != want {
+			t.Errorf("Lookup = %q, want [%q]", lines, want)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := client.Lookup("example.com/unknown", "v1.0.0"); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("Lookup = %v, want 404 error", err)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		m := module.Version{Path: "example.com/m1", Version: "v1.0.0"}
+		if _, err := s.Add(ctx, m, "h1:zip1=", "h1:mod1="); err != nil {
+			t.Errorf("Add of identical record: %v", err)
+		}
+		if _, err := s.Add(ctx, m, "h1:other=", "h1:mod1="); !errors.Is(err, ErrConflict) {
+			t.Errorf("Add of conflicting record = %v, want %v", err, ErrConflict)
+		}
+	})
+
+	t.Run("tile heights", func(t *testing.T) {
+		if _, err := s.ReadTileData(ctx, tlog.Tile{H: 2, L: 0, N: 0, W: 4}); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("ReadTileData with height 2 = %v, want %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestFileIndex(t *testing.T) {
+	ctx := context.Background()
+	idx, err := NewFileIndex(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileIndex: %v", err)
+	}
+	m := module.Version{Path: "example.com/Mod", Version: "v1.0.0"}
+	if _, ok, err := idx.Get(ctx, m); ok || err != nil {
+		t.Fatalf("Get before Put = (%t, %v), want (false, nil)", ok, err)
+	}
+	if err := idx.Put(ctx, m, 42); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if i, ok, err := idx.Get(ctx, m); i != 42 || !ok || err != nil {
+		t.Errorf("Get = (%d, %t, %v), want (42, true, nil)", i, ok, err)
+	}
+	// Module paths which differ only in case must not collide.
+	if _, ok, err := idx.Get(ctx, module.Version{Path: "example.com/mod", Version: "v1.0.0"}); ok || err != nil {
+		t.Errorf("Get of differently cased path = (%t, %v), want (false, nil)", ok, err)
+	}
+}
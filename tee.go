@@ -0,0 +1,166 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// TeeDivergenceError means that only one of the logs added an entry, and the other returned an error.
+	TeeDivergenceError = "error"
+	// TeeDivergenceDuplicate means that only one of the logs considered an entry to be a duplicate.
+	TeeDivergenceDuplicate = "duplicate"
+	// TeeDivergenceIndex means that the offset between the indices assigned to an entry by the two logs
+	// changed, i.e. the logs no longer hold the same sequence of entries.
+	TeeDivergenceIndex = "index"
+)
+
+// TeeDivergence describes a difference in the way that the primary and shadow logs of a TeeAppender handled an
+// entry.
+type TeeDivergence struct {
+	// Kind is one of the TeeDivergence constants.
+	Kind string
+	// Primary and Shadow are the indices assigned to the entry by the two logs, if they added it.
+	Primary Index
+	Shadow  Index
+	// PrimaryErr and ShadowErr are the errors returned by the logs, if they failed to add the entry.
+	PrimaryErr error
+	ShadowErr  error
+}
+
+func (d TeeDivergence) String() string {
+	switch d.Kind {
+	case TeeDivergenceError:
+		if d.ShadowErr != nil {
+			return fmt.Sprintf("shadow log failed to add entry %d of the primary log: %v", d.Primary.Index, d.ShadowErr)
+		}
+		return fmt.Sprintf("primary log failed to add entry %d of the shadow log: %v", d.Shadow.Index, d.PrimaryErr)
+	case TeeDivergenceDuplicate:
+		return fmt.Sprintf("entry is a duplicate in only one log: primary %+v, shadow %+v", d.Primary, d.Shadow)
+	default:
+		return fmt.Sprintf("entry %d of the primary log is entry %d of the shadow log", d.Primary.Index, d.Shadow.Index)
+	}
+}
+
+// TeeOptions holds optional settings for TeeAppender.
+type TeeOptions struct {
+	// OnDivergence, if set, is called with each divergence found between the logs.
+	OnDivergence func(ctx context.Context, d TeeDivergence)
+}
+
+// TeeAppender returns an Appender which adds every entry to both the primary and shadow logs, so that a new
+// storage driver or configuration can be validated with production traffic before cutting over to it.
+//
+// Only the primary log is visible to callers: the futures returned by the Appender are those of the primary
+// log, and the shadow log's failures don't affect them. Each entry is passed to the shadow log immediately
+// after the primary, so that both logs see entries in the same order, and the futures of both logs are then
+// resolved and compared in the background. Cancelling the context passed to Add does not cancel the shadow's
+// copy of the entry.
+//
+// Differences in the way that the logs handle entries are logged as warnings, counted by the
+// tessera.appender.tee.divergences metric, and passed to TeeOptions.OnDivergence if it's set. Since the shadow
+// log will usually have been created more recently than the primary, the indices that the logs assign to
+// entries are expected to differ by a constant offset, and a divergence is reported if that offset changes,
+// e.g. after only one of the logs added an entry.
+//
+// Administrative operations on the returned Appender, such as key rotation and lifecycle changes, act on the
+// primary log only.
+func TeeAppender(primary, shadow *Appender, opts *TeeOptions) *Appender {
+	o := TeeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	t := &tee{opts: o}
+	a := *primary
+	a.Add = func(ctx context.Context, entry *Entry) IndexFuture {
+		// Clone the entry before the primary log sees it, as its storage may be marshalling it for a bundle
+		// concurrently with anything we do after that.
+		c := entry.clone()
+		pf := memoizeFuture(primary.Add(ctx, entry))
+		sf := shadow.Add(context.WithoutCancel(ctx), c)
+		go t.compare(context.WithoutCancel(ctx), pf, sf)
+		return pf
+	}
+	a.AddBatch = func(ctx context.Context, entries []*Entry) []IndexFuture {
+		clones := make([]*Entry, 0, len(entries))
+		for _, e := range entries {
+			clones = append(clones, e.clone())
+		}
+		pfs := primary.AddBatch(ctx, entries)
+		sfs := shadow.AddBatch(context.WithoutCancel(ctx), clones)
+		for i := range pfs {
+			pfs[i] = memoizeFuture(pfs[i])
+		}
+		go func() {
+			for i := range pfs {
+				t.compare(context.WithoutCancel(ctx), pfs[i], sfs[i])
+			}
+		}()
+		return pfs
+	}
+	return &a
+}
+
+// tee compares the indices assigned to entries by the primary and shadow logs of a TeeAppender.
+type tee struct {
+	opts TeeOptions
+
+	// offset is the difference between the shadow and primary indices of the most recently compared entry
+	// which was new to both, and haveOffset is true once there is one.
+	offset     atomic.Int64
+	haveOffset atomic.Bool
+}
+
+// compare resolves the futures of an entry added to the primary and shadow logs, and reports any divergence.
+func (t *tee) compare(ctx context.Context, primary, shadow IndexFuture) {
+	pi, perr := primary()
+	si, serr := shadow()
+	d, ok := t.check(pi, perr, si, serr)
+	if !ok {
+		return
+	}
+	klog.Warningf("TeeAppender: %s", d)
+	appenderTeeDivergences.Add(ctx, 1, metric.WithAttributes(teeDivergenceKindKey.String(d.Kind)))
+	if t.opts.OnDivergence != nil {
+		t.opts.OnDivergence(ctx, d)
+	}
+}
+
+// check returns the divergence, if any, between the results of adding an entry to the primary and shadow logs.
+func (t *tee) check(pi Index, perr error, si Index, serr error) (TeeDivergence, bool) {
+	switch {
+	case perr != nil && serr != nil:
+		// Neither log added the entry.
+		return TeeDivergence{}, false
+	case perr != nil || serr != nil:
+		return TeeDivergence{Kind: TeeDivergenceError, Primary: pi, Shadow: si, PrimaryErr: perr, ShadowErr: serr}, true
+	case pi.IsDup != si.IsDup:
+		return TeeDivergence{Kind: TeeDivergenceDuplicate, Primary: pi, Shadow: si}, true
+	case pi.IsDup:
+		// Duplicates keep the index they were first given, so their offset needn't match.
+		return TeeDivergence{}, false
+	}
+	offset := int64(si.Index - pi.Index)
+	if prev := t.offset.Swap(offset); t.haveOffset.Swap(true) && prev != offset {
+		return TeeDivergence{Kind: TeeDivergenceIndex, Primary: pi, Shadow: si}, true
+	}
+	return TeeDivergence{}, false
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/transparency-dev/tessera/ctonly"
+)
+
+func TestTeeCheck(t *testing.T) {
+	errFailed := errors.New("failed")
+	// step is the result of adding one entry to each of the logs.
+	type step struct {
+		primary, shadow       Index
+		primaryErr, shadowErr error
+		// wantKind is the kind of divergence expected, if any.
+		wantKind string
+	}
+	for _, test := range []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "in sync",
+			steps: []step{
+				{primary: Index{Index: 10}, shadow: Index{Index: 0}},
+				{primary: Index{Index: 11}, shadow: Index{Index: 1}},
+				{primary: Index{Index: 10, IsDup: true}, shadow: Index{Index: 0, IsDup: true}},
+				{primary: Index{Index: 12}, shadow: Index{Index: 2}},
+			},
+		}, {
+			name: "both failed",
+			steps: []step{
+				{primary: Index{Index: 0}, shadow: Index{Index: 0}},
+				{primaryErr: errFailed, shadowErr: errFailed},
+				{primary: Index{Index: 1}, shadow: Index{Index: 1}},
+			},
+		}, {
+			name: "shadow failed",
+			steps: []step{
+				{primary: Index{Index: 0}, shadow: Index{Index: 0}},
+				{primary: Index{Index: 1}, shadowErr: errFailed, wantKind: TeeDivergenceError},
+				{primary: Index{Index: 2}, shadow: Index{Index: 1}, wantKind: TeeDivergenceIndex},
+				{primary: Index{Index: 3}, shadow: Index{Index: 2}},
+			},
+		}, {
+			name: "primary failed",
+			steps: []step{
+				{primary: Index{Index: 0}, shadow: Index{Index: 0}},
+				{primaryErr: errFailed, shadow: Index{Index: 1}, wantKind: TeeDivergenceError},
+				{primary: Index{Index: 1}, shadow: Index{Index: 2}, wantKind: TeeDivergenceIndex},
+			},
+		}, {
+			name: "duplicate in one log",
+			steps: []step{
+				{primary: Index{Index: 0}, shadow: Index{Index: 0}},
+				{primary: Index{Index: 1}, shadow: Index{Index: 0, IsDup: true}, wantKind: TeeDivergenceDuplicate},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tee := &tee{}
+			for i, st := range test.steps {
+				d, ok := tee.check(st.primary, st.primaryErr, st.shadow, st.shadowErr)
+				if ok != (st.wantKind != "") || d.Kind != st.wantKind {
+					t.Errorf("step %d: check: got (%v, %t), want divergence %q", i, d, ok, st.wantKind)
+				}
+			}
+		})
+	}
+}
+
+// teeLog is a fake log for testing TeeAppender, which assigns successive indices to entries unless they're
+// listed in fail.
+type teeLog struct {
+	mu    sync.Mutex
+	fail  map[string]bool
+	added [][]byte
+}
+
+func (l *teeLog) appender() *Appender {
+	add := func(_ context.Context, e *Entry) IndexFuture {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.fail[string(e.Data())] {
+			return func() (Index, error) { return Index{}, errors.New("failed") }
+		}
+		idx := Index{Index: uint64(len(l.added))}
+		l.added = append(l.added, e.Data())
+		return func() (Index, error) { return idx, nil }
+	}
+	return &Appender{
+		Add: add,
+		AddBatch: func(ctx context.Context, entries []*Entry) []IndexFuture {
+			r := make([]IndexFuture, 0, len(entries))
+			for _, e := range entries {
+				r = append(r, add(ctx, e))
+			}
+			return r
+		},
+	}
+}
+
+func TestTeeAppender(t *testing.T) {
+	ctx := context.Background()
+	primary := &teeLog{}
+	shadow := &teeLog{fail: map[string]bool{"two": true}}
+	divergences := make(chan TeeDivergence, 10)
+	a := TeeAppender(primary.appender(), shadow.appender(), &TeeOptions{
+		OnDivergence: func(_ context.Context, d TeeDivergence) { divergences <- d },
+	})
+
+	futures := a.AddBatch(ctx, []*Entry{NewEntry([]byte("one")), NewEntry([]byte("two")), NewEntry([]byte("three"))})
+	for i, f := range futures {
+		idx, err := f()
+		if err != nil || idx.Index != uint64(i) {
+			t.Errorf("entry %d: got (%v, %v), want index %d from the primary log", i, idx, err, i)
+		}
+	}
+	if d := waitFor(t, divergences); d.Kind != TeeDivergenceError || d.Primary.Index != 1 || d.ShadowErr == nil {
+		t.Errorf("got divergence %+v, want shadow error for entry 1", d)
+	}
+	if d := waitFor(t, divergences); d.Kind != TeeDivergenceIndex || d.Primary.Index != 2 || d.Shadow.Index != 1 {
+		t.Errorf("got divergence %+v, want index divergence for entry 2", d)
+	}
+
+	// The shadow log now lags the primary by one entry, which is the new expected offset.
+	if idx, err := a.Add(ctx, NewEntry([]byte("four")))(); err != nil || idx.Index != 3 {
+		t.Errorf("Add: got (%v, %v), want index 3", idx, err)
+	}
+	if idx, err := a.Add(ctx, NewEntry([]byte("two")))(); err != nil || idx.Index != 4 {
+		t.Errorf("Add: got (%v, %v), want index 4", idx, err)
+	}
+	if d := waitFor(t, divergences); d.Kind != TeeDivergenceError || d.Primary.Index != 4 {
+		t.Errorf("got divergence %+v, want shadow error for entry 4", d)
+	}
+
+	if d := cmp.Diff([][]byte{[]byte("one"), []byte("three"), []byte("four")}, shadow.added); d != "" {
+		t.Errorf("shadow log entries: diff (-want +got):\n%s", d)
+	}
+}
+
+func TestTeeAppenderCTLayout(t *testing.T) {
+	ctx := context.Background()
+	// ctLog marshals each entry for its bundle concurrently with the other log, as storage does when it
+	// integrates entries, and records the leaf hash it gets.
+	type ctLog struct {
+		mu     sync.Mutex
+		next   uint64
+		hashes map[uint64][]byte
+		wg     sync.WaitGroup
+	}
+	appender := func(l *ctLog) *Appender {
+		return &Appender{Add: func(_ context.Context, e *Entry) IndexFuture {
+			l.mu.Lock()
+			idx := l.next
+			l.next++
+			l.mu.Unlock()
+			done := make(chan struct{})
+			l.wg.Add(1)
+			go func() {
+				defer l.wg.Done()
+				defer close(done)
+				e.MarshalBundleData(idx)
+				l.mu.Lock()
+				l.hashes[idx] = e.LeafHash()
+				l.mu.Unlock()
+			}()
+			return func() (Index, error) {
+				<-done
+				return Index{Index: idx}, nil
+			}
+		}}
+	}
+	primary := &ctLog{next: 10, hashes: make(map[uint64][]byte)}
+	shadow := &ctLog{hashes: make(map[uint64][]byte)}
+	a := TeeAppender(appender(primary), appender(shadow), nil)
+
+	entries := make([]*ctonly.Entry, 0, 20)
+	futures := make([]IndexFuture, 0, 20)
+	for i := range 20 {
+		e := &ctonly.Entry{Timestamp: uint64(i), Certificate: fmt.Appendf(nil, "cert %d", i)}
+		entries = append(entries, e)
+		futures = append(futures, NewCertificateTransparencyAppender(a)(ctx, e))
+	}
+	for i, f := range futures {
+		if _, err := f(); err != nil {
+			t.Fatalf("entry %d: %v", i, err)
+		}
+	}
+	// Only the primary's futures are returned, so wait for the shadow log to have finished too.
+	primary.wg.Wait()
+	shadow.wg.Wait()
+	for i, e := range entries {
+		for _, l := range []struct {
+			name string
+			log  *ctLog
+			idx  uint64
+		}{
+			{name: "primary", log: primary, idx: uint64(i) + 10},
+			{name: "shadow", log: shadow, idx: uint64(i)},
+		} {
+			l.log.mu.Lock()
+			got := l.log.hashes[l.idx]
+			l.log.mu.Unlock()
+			if want := e.MerkleLeafHash(l.idx); !bytes.Equal(got, want) {
+				t.Errorf("%s entry %d: got leaf hash %x, want %x", l.name, l.idx, got, want)
+			}
+		}
+	}
+}
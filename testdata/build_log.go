@@ -0,0 +1,67 @@
+//go:build ignore
+
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// build_log builds the small test log in testdata/log, using testonly.BuildLog.
+//
+// Run it from the root of the repository with:
+//
+//	go run ./testdata/build_log.go
+//
+// The state directory used by the POSIX driver is not rebuilt, since tests which open the log with
+// the driver rely on it matching the log's contents.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/transparency-dev/tessera/testonly"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+const (
+	logDir        = "testdata/log"
+	logPrivateKey = "PRIVATE+KEY+example.com/log/testdata+33d7b496+AeymY/SZAX0jZcJ8enZ5FY1Dz+wTML2yWSkK+9DSF3eg"
+)
+
+func main() {
+	s, err := note.NewSigner(logPrivateKey)
+	if err != nil {
+		klog.Exitf("NewSigner: %v", err)
+	}
+	l, err := testonly.BuildLog(nil, s)
+	if err != nil {
+		klog.Exitf("BuildLog: %v", err)
+	}
+	// Entries are added one at a time, so that the log contains the partial tiles and bundles of each size.
+	for _, e := range strings.Fields("one two three four five six seven eit nain ten ileven twelf threeten fourten fivten") {
+		if err := l.Append([]byte(e)); err != nil {
+			klog.Exitf("Append: %v", err)
+		}
+	}
+	if err := l.WriteDir(logDir); err != nil {
+		klog.Exitf("WriteDir: %v", err)
+	}
+	for size := range l.Size() + 1 {
+		p := filepath.Join(logDir, fmt.Sprintf("checkpoint.%d", size))
+		if err := os.WriteFile(p, l.Checkpoint(size), 0o644); err != nil {
+			klog.Exitf("WriteFile: %v", err)
+		}
+	}
+}
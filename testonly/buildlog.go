@@ -0,0 +1,230 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testonly
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/internal/fetcher"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// Log is a static https://c2sp.org/tlog-tiles log held in memory, built by BuildLog.
+//
+// Its Read methods have the signatures of the client package's fetcher functions, so it can be used as
+// the source of data for client tests without any storage or network access.
+type Log struct {
+	signer note.Signer
+	rf     *compact.RangeFactory
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// files holds the log's resources, keyed by their paths relative to the root of the log.
+	files map[string][]byte
+	// checkpoints holds every checkpoint which the log has published, keyed by size.
+	checkpoints map[uint64][]byte
+	// entries holds the entries of the log, in order.
+	entries [][]byte
+	// rows holds the nodes of each tile level, i.e. tree levels 0, 8, 16, ...
+	rows [][][]byte
+	cr   *compact.Range
+}
+
+// BuildLog builds a log containing the provided entries, whose checkpoints are signed by s.
+//
+// The origin of the log is the name of s, and leaves are hashed as in RFC6962.
+func BuildLog(entries [][]byte, s note.Signer) (*Log, error) {
+	rf := &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	l := &Log{
+		signer:      s,
+		rf:          rf,
+		files:       make(map[string][]byte),
+		checkpoints: make(map[uint64][]byte),
+		rows:        [][][]byte{{}},
+		cr:          rf.NewEmptyRange(0),
+	}
+	if err := l.Append(entries...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Append adds entries to the log, and publishes a new checkpoint.
+//
+// As in a real log, the partial tiles and entry bundles of the previous size are kept alongside the
+// larger versions which replace them.
+func (l *Log) Append(entries ...[]byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Only the right-most tile at each level, and those after it, change.
+	firstTiles := make([]uint64, len(l.rows))
+	for i, row := range l.rows {
+		firstTiles[i] = uint64(len(row) / layout.TileWidth)
+	}
+	firstBundle := uint64(len(l.entries) / layout.EntryBundleWidth)
+	for _, e := range entries {
+		if len(e) > 0xffff {
+			return fmt.Errorf("entry of %d bytes is too large for an entry bundle", len(e))
+		}
+		lh := rfc6962.DefaultHasher.HashLeaf(e)
+		if err := l.cr.Append(lh, nil); err != nil {
+			return fmt.Errorf("failed to append to compact range: %v", err)
+		}
+		l.entries = append(l.entries, e)
+		l.addNode(0, lh)
+	}
+	size := uint64(len(l.entries))
+
+	for level, row := range l.rows {
+		var first uint64
+		if level < len(firstTiles) {
+			first = firstTiles[level]
+		}
+		for i := first; i*layout.TileWidth < uint64(len(row)); i++ {
+			nodes := row[i*layout.TileWidth : min((i+1)*layout.TileWidth, uint64(len(row)))]
+			raw, err := api.HashTile{Nodes: nodes}.MarshalText()
+			if err != nil {
+				return fmt.Errorf("failed to marshal tile: %v", err)
+			}
+			l.files[layout.TilePath(uint64(level), i, layout.PartialTileSize(uint64(level), i, size))] = raw
+		}
+	}
+	for i := firstBundle; i*layout.EntryBundleWidth < size; i++ {
+		var b []byte
+		for _, e := range l.entries[i*layout.EntryBundleWidth : min((i+1)*layout.EntryBundleWidth, size)] {
+			b = binary.BigEndian.AppendUint16(b, uint16(len(e)))
+			b = append(b, e...)
+		}
+		l.files[layout.EntriesPath(i, layout.PartialTileSize(0, i, size))] = b
+	}
+
+	root := rfc6962.DefaultHasher.EmptyRoot()
+	if size > 0 {
+		var err error
+		if root, err = l.cr.GetRootHash(nil); err != nil {
+			return fmt.Errorf("failed to calculate root hash: %v", err)
+		}
+	}
+	cp, err := note.Sign(&note.Note{Text: string(f_log.Checkpoint{Origin: l.signer.Name(), Size: size, Hash: root}.Marshal())}, l.signer)
+	if err != nil {
+		return fmt.Errorf("failed to sign checkpoint: %v", err)
+	}
+	l.files[layout.CheckpointPath] = cp
+	l.checkpoints[size] = cp
+	return nil
+}
+
+// addNode appends h to the row of nodes at the given tile level, and if that completes a tile, adds the
+// tile's root to the level above.
+func (l *Log) addNode(level int, h []byte) {
+	for {
+		if len(l.rows) == level {
+			l.rows = append(l.rows, nil)
+		}
+		l.rows[level] = append(l.rows[level], h)
+		row := l.rows[level]
+		if len(row)%layout.TileWidth != 0 {
+			return
+		}
+		r := l.rf.NewEmptyRange(0)
+		for _, n := range row[len(row)-layout.TileWidth:] {
+			// The range is built from scratch, so appending to it can't fail.
+			_ = r.Append(n, nil)
+		}
+		h, _ = r.GetRootHash(nil)
+		level++
+	}
+}
+
+// Size returns the number of entries in the log.
+func (l *Log) Size() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return uint64(len(l.entries))
+}
+
+// Checkpoint returns the checkpoint which the log published when it was the given size, or nil if it
+// was never that size.
+func (l *Log) Checkpoint(size uint64) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.checkpoints[size]
+}
+
+// Files returns a copy of the log's resources, keyed by their paths relative to the root of the log.
+func (l *Log) Files() map[string][]byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := make(map[string][]byte, len(l.files))
+	for k, v := range l.files {
+		r[k] = v
+	}
+	return r
+}
+
+// WriteDir writes the log's resources to dir, e.g. a directory returned by testing.T.TempDir, so that
+// they can be served or read as files.
+func (l *Log) WriteDir(dir string) error {
+	for p, d := range l.Files() {
+		path := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %q: %v", p, err)
+		}
+		if err := os.WriteFile(path, d, 0o644); err != nil {
+			return fmt.Errorf("failed to write %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+func (l *Log) read(p string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d, ok := l.files[p]; ok {
+		return d, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// ReadCheckpoint returns the log's latest checkpoint.
+func (l *Log) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return l.read(layout.CheckpointPath)
+}
+
+// ReadTile returns the specified tile, falling back to the full tile if the partial one doesn't exist.
+func (l *Log) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	return fetcher.PartialOrFullResource(ctx, p, func(_ context.Context, p uint8) ([]byte, error) {
+		return l.read(layout.TilePath(level, index, p))
+	})
+}
+
+// ReadEntryBundle returns the specified entry bundle, falling back to the full bundle if the partial one
+// doesn't exist.
+func (l *Log) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	return fetcher.PartialOrFullResource(ctx, p, func(_ context.Context, p uint8) ([]byte, error) {
+		return l.read(layout.EntriesPath(index, p))
+	})
+}
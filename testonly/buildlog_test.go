@@ -0,0 +1,106 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testonly
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestBuildLog(t *testing.T) {
+	sk, vk, err := note.GenerateKey(nil, "example.com/testonly")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	for _, size := range []int{0, 1, layout.EntryBundleWidth, layout.TileWidth*layout.TileWidth + 1} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			entries := make([][]byte, 0, size)
+			for i := range size {
+				entries = append(entries, fmt.Appendf(nil, "entry %d", i))
+			}
+			l, err := BuildLog(entries, s)
+			if err != nil {
+				t.Fatalf("BuildLog: %v", err)
+			}
+			cp, _, _, err := f_log.ParseCheckpoint(l.Checkpoint(uint64(size)), s.Name(), v)
+			if err != nil {
+				t.Fatalf("ParseCheckpoint: %v", err)
+			}
+			if err := client.VerifyLog(t.Context(), client.Fetchers{Checkpoint: l.ReadCheckpoint, Tile: l.ReadTile, EntryBundle: l.ReadEntryBundle}, *cp); err != nil {
+				t.Errorf("VerifyLog: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildLogGolden(t *testing.T) {
+	// The golden test log was built by testdata/build_log.go, so BuildLog must reproduce it exactly.
+	s, err := note.NewSigner("PRIVATE+KEY+example.com/log/testdata+33d7b496+AeymY/SZAX0jZcJ8enZ5FY1Dz+wTML2yWSkK+9DSF3eg")
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	l, err := BuildLog(nil, s)
+	if err != nil {
+		t.Fatalf("BuildLog: %v", err)
+	}
+	for _, e := range []string{"one", "two", "three", "four", "five", "six", "seven", "eit", "nain", "ten", "ileven", "twelf", "threeten", "fourten", "fivten"} {
+		if err := l.Append([]byte(e)); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	dir := t.TempDir()
+	if err := l.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir: %v", err)
+	}
+	for p := range l.Files() {
+		got, err := os.ReadFile(filepath.Join(dir, p))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		want, err := os.ReadFile(filepath.Join("../testdata/log", p))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", p, got, want)
+		}
+	}
+	for size := range l.Size() + 1 {
+		want, err := os.ReadFile(filepath.Join("../testdata/log", fmt.Sprintf("checkpoint.%d", size)))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if got := l.Checkpoint(size); !bytes.Equal(got, want) {
+			t.Errorf("checkpoint %d: got %q, want %q", size, got, want)
+		}
+	}
+}
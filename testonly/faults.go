@@ -0,0 +1,200 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testonly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/storage/posix"
+)
+
+// ErrInjected is returned by the functions returned by FailEvery.
+var ErrInjected = errors.New("injected fault")
+
+// Operation identifies a storage operation which faults can be injected into.
+type Operation string
+
+// The operations which faults can be injected into. Other LogReader methods are unaffected by faults.
+const (
+	OpAdd              Operation = "add"
+	OpReadCheckpoint   Operation = "read_checkpoint"
+	OpReadTile         Operation = "read_tile"
+	OpReadEntryBundle  Operation = "read_entry_bundle"
+	OpReadEntryBundles Operation = "read_entry_bundles"
+)
+
+// Faults describes the faults which a test log's storage suffers from, so that personalities can test how
+// they handle slow or unreliable storage.
+//
+// Faults are injected beneath the Appender's own decorators, so antispam and pushback behave as they would in
+// front of a real driver, and into the TestLog's LogReader. Reads made directly from the log's files in Root
+// are unaffected.
+type Faults struct {
+	// Latency is added to every operation.
+	Latency time.Duration
+	// Err, if set, is called before every operation. If it returns an error, the operation fails with that
+	// error rather than being performed. Returning tessera.ErrPushback for OpAdd models an overloaded log.
+	Err func(op Operation) error
+	// CheckpointDelay delays the publication of checkpoints, as seen by the TestLog's LogReader, by at least
+	// this long after they were first read from storage. Until the log's first checkpoint is old enough,
+	// ReadCheckpoint returns os.ErrNotExist.
+	CheckpointDelay time.Duration
+}
+
+func (f Faults) none() bool {
+	return f.Latency == 0 && f.Err == nil && f.CheckpointDelay == 0
+}
+
+// inject applies the faults to a single operation.
+func (f Faults) inject(ctx context.Context, op Operation) error {
+	if f.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.Latency):
+		}
+	}
+	if f.Err != nil {
+		return f.Err(op)
+	}
+	return nil
+}
+
+// FailEvery returns a Faults.Err function which fails every nth operation of the given kinds with
+// ErrInjected, or every nth operation if none are given. This gives transient errors which occur
+// deterministically.
+func FailEvery(n uint64, ops ...Operation) func(Operation) error {
+	var count atomic.Uint64
+	return func(op Operation) error {
+		if len(ops) > 0 && !slices.Contains(ops, op) {
+			return nil
+		}
+		if count.Add(1)%n == 0 {
+			return ErrInjected
+		}
+		return nil
+	}
+}
+
+// faultyDriver is a POSIX driver whose Appender suffers from faults.
+//
+// It embeds the driver so that the optional capabilities which NewAppender looks for are still present.
+type faultyDriver struct {
+	*posix.Storage
+	f Faults
+}
+
+func (d *faultyDriver) Appender(ctx context.Context, opts *tessera.AppendOptions) (*tessera.Appender, tessera.LogReader, error) {
+	a, r, err := d.Storage.Appender(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	add, addBatch := a.Add, a.AddBatch
+	a.Add = func(ctx context.Context, e *tessera.Entry) tessera.IndexFuture {
+		if err := d.f.inject(ctx, OpAdd); err != nil {
+			return func() (tessera.Index, error) { return tessera.Index{}, err }
+		}
+		return add(ctx, e)
+	}
+	if addBatch != nil {
+		a.AddBatch = func(ctx context.Context, entries []*tessera.Entry) []tessera.IndexFuture {
+			if err := d.f.inject(ctx, OpAdd); err != nil {
+				r := make([]tessera.IndexFuture, len(entries))
+				for i := range r {
+					r[i] = func() (tessera.Index, error) { return tessera.Index{}, err }
+				}
+				return r
+			}
+			return addBatch(ctx, entries)
+		}
+	}
+	return a, r, nil
+}
+
+// faultyLogReader is a LogReader which suffers from faults.
+type faultyLogReader struct {
+	tessera.LogReader
+	f Faults
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// seen holds the checkpoints which have been read from storage but not yet published, oldest first.
+	seen []seenCheckpoint
+	// published is the latest checkpoint which is old enough to be returned.
+	published []byte
+}
+
+// seenCheckpoint is a checkpoint read from storage, along with the time it was first read.
+type seenCheckpoint struct {
+	raw []byte
+	at  time.Time
+}
+
+func (r *faultyLogReader) ReadCheckpoint(ctx context.Context) ([]byte, error) {
+	if err := r.f.inject(ctx, OpReadCheckpoint); err != nil {
+		return nil, err
+	}
+	cp, err := r.LogReader.ReadCheckpoint(ctx)
+	if err != nil || r.f.CheckpointDelay == 0 {
+		return cp, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	latest := r.published
+	if l := len(r.seen); l > 0 {
+		latest = r.seen[l-1].raw
+	}
+	if !bytes.Equal(cp, latest) {
+		r.seen = append(r.seen, seenCheckpoint{raw: cp, at: now})
+	}
+	for len(r.seen) > 0 && now.Sub(r.seen[0].at) >= r.f.CheckpointDelay {
+		r.published, r.seen = r.seen[0].raw, r.seen[1:]
+	}
+	if r.published == nil {
+		return nil, os.ErrNotExist
+	}
+	return r.published, nil
+}
+
+func (r *faultyLogReader) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	if err := r.f.inject(ctx, OpReadTile); err != nil {
+		return nil, err
+	}
+	return r.LogReader.ReadTile(ctx, level, index, p)
+}
+
+func (r *faultyLogReader) ReadEntryBundle(ctx context.Context, index uint64, p uint8) ([]byte, error) {
+	if err := r.f.inject(ctx, OpReadEntryBundle); err != nil {
+		return nil, err
+	}
+	return r.LogReader.ReadEntryBundle(ctx, index, p)
+}
+
+func (r *faultyLogReader) ReadEntryBundles(ctx context.Context, fromBundle, count uint64) ([][]byte, error) {
+	if err := r.f.inject(ctx, OpReadEntryBundles); err != nil {
+		return nil, err
+	}
+	return r.LogReader.ReadEntryBundles(ctx, fromBundle, count)
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testonly
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+)
+
+func TestFaultsAdd(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		faults   Faults
+		wantErrs []error
+	}{
+		{
+			name:     "no faults",
+			wantErrs: []error{nil, nil, nil, nil},
+		},
+		{
+			name:     "fail every other add",
+			faults:   Faults{Err: FailEvery(2, OpAdd)},
+			wantErrs: []error{nil, ErrInjected, nil, ErrInjected},
+		},
+		{
+			name:     "other operations don't count",
+			faults:   Faults{Err: FailEvery(1, OpReadTile)},
+			wantErrs: []error{nil, nil, nil, nil},
+		},
+		{
+			name: "pushback",
+			faults: Faults{Err: func(Operation) error {
+				return fmt.Errorf("overloaded: %w", tessera.ErrPushback)
+			}},
+			wantErrs: []error{tessera.ErrPushback, tessera.ErrPushback},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tl, shutdown := NewTestLogWithFaults(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second).WithBatching(1, time.Millisecond), test.faults)
+			defer func() {
+				if err := shutdown(t.Context()); err != nil {
+					t.Errorf("shutdown: %v", err)
+				}
+			}()
+			for i, want := range test.wantErrs {
+				_, err := tl.Appender.Add(t.Context(), tessera.NewEntry(fmt.Appendf(nil, "entry %d", i)))()
+				if !errors.Is(err, want) {
+					t.Errorf("Add %d: got error %v, want %v", i, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFaultsCheckpointDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	tl, shutdown := NewTestLogWithFaults(t, tessera.NewAppendOptions().WithCheckpointInterval(time.Second), Faults{CheckpointDelay: delay, Latency: time.Millisecond})
+	defer func() {
+		if err := shutdown(t.Context()); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	start := time.Now()
+	if _, err := tl.LogReader.ReadCheckpoint(t.Context()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReadCheckpoint before delay: got %v, want os.ErrNotExist", err)
+	}
+	if time.Since(start) < time.Millisecond {
+		t.Error("ReadCheckpoint returned before latency elapsed")
+	}
+	time.Sleep(delay)
+	if _, err := tl.LogReader.ReadCheckpoint(t.Context()); err != nil {
+		t.Fatalf("ReadCheckpoint after delay: %v", err)
+	}
+}
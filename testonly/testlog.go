@@ -31,6 +31,12 @@ import (
 // Returns an instance of TestLog containing the various structures created, and a shutdown function
 // which MUST be called when the test has finished with the log.
 func NewTestLog(t *testing.T, opts *tessera.AppendOptions) (*TestLog, func(context.Context) error) {
+	t.Helper()
+	return NewTestLogWithFaults(t, opts, Faults{})
+}
+
+// NewTestLogWithFaults is like NewTestLog, but creates a log whose storage suffers from the provided faults.
+func NewTestLogWithFaults(t *testing.T, opts *tessera.AppendOptions, f Faults) (*TestLog, func(context.Context) error) {
 	t.Helper()
 	sk, vk, err := note.GenerateKey(nil, "test")
 	if err != nil {
@@ -51,11 +57,18 @@ func NewTestLog(t *testing.T, opts *tessera.AppendOptions) (*TestLog, func(conte
 		t.Fatalf("posix.New: %v", err)
 	}
 
+	if !f.none() {
+		driver = &faultyDriver{Storage: driver.(*posix.Storage), f: f}
+	}
+
 	opts.WithCheckpointSigner(s)
 	a, shutdown, lr, err := tessera.NewAppender(t.Context(), driver, opts)
 	if err != nil {
 		t.Fatalf("NewAppender: %v", err)
 	}
+	if !f.none() {
+		lr = &faultyLogReader{LogReader: lr, f: f}
+	}
 
 	r := &TestLog{
 		Root:        root,
@@ -0,0 +1,90 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/transparency-dev/tessera/api/layout"
+)
+
+// WithTileHeight configures the number of Merkle tree levels held by each of the log's tiles, which must be
+// between 1 and layout.TileHeight. Shorter tiles make each tile and entry bundle smaller, at the cost of
+// more of them, which may suit logs whose entries are large or whose clients fetch few proofs.
+//
+// Logs which don't use the default height of layout.TileHeight don't conform to https://c2sp.org/tlog-tiles,
+// and so can only be read by clients which support other heights: the height is recorded by the storage
+// implementation when the log is created, and published at layout.TileHeightPath, so that clients can find it
+// with client.FetchTiling. It is an error to subsequently open the log with a different height.
+//
+// The driver must support other tile heights, or NewAppender will return an error. This option cannot be used
+// with WithCTLayout, followers such as WithAntispam, or entry bundle compression or encryption, all of which
+// assume the default entry bundle width.
+func (o *AppendOptions) WithTileHeight(h uint) *AppendOptions {
+	o.tiling = layout.Tiling{Height: h}
+	return o
+}
+
+// Tiling returns the tiling which storage implementations must use to divide the log's Merkle tree into tiles,
+// and its entries into entry bundles.
+func (o AppendOptions) Tiling() layout.Tiling {
+	if o.tiling.Height == 0 {
+		return layout.DefaultTiling
+	}
+	return o.tiling
+}
+
+// TileHeightReader is implemented by LogReaders which support logs configured WithTileHeight.
+type TileHeightReader interface {
+	// ReadTileHeight returns the contents of the log's layout.TileHeightPath file, which holds the height of
+	// its tiles encoded by layout.Tiling.MarshalText. If the log uses layout.DefaultTiling and has no such
+	// file then os.ErrNotExist should be returned.
+	ReadTileHeight(ctx context.Context) ([]byte, error)
+}
+
+// validTiling returns an error if the tiling configured by WithTileHeight can't be used with the other options.
+func (o AppendOptions) validTiling() error {
+	if o.tiling.Height == 0 || o.tiling == layout.DefaultTiling {
+		return nil
+	}
+	if err := o.tiling.Validate(); err != nil {
+		return err
+	}
+	if o.tiling.Height > layout.TileHeight {
+		return fmt.Errorf("WithTileHeight %d is greater than the maximum of %d", o.tiling.Height, layout.TileHeight)
+	}
+	switch {
+	case o.ctLayout:
+		return errors.New("WithTileHeight cannot be used with WithCTLayout")
+	case len(o.followers) > 0:
+		return errors.New("WithTileHeight cannot be used with followers")
+	case o.entryBundleEncoding != "" || o.entryBundleKeys != nil:
+		return errors.New("WithTileHeight cannot be used with entry bundle compression or encryption")
+	}
+	return nil
+}
+
+// checkTileHeightReader returns an error if the log uses a tile height which lr doesn't support.
+func (o AppendOptions) checkTileHeightReader(lr LogReader) error {
+	if o.Tiling() == layout.DefaultTiling {
+		return nil
+	}
+	if _, ok := lr.(TileHeightReader); !ok {
+		return fmt.Errorf("WithTileHeight is not supported by LogReader %T", lr)
+	}
+	return nil
+}
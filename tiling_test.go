@@ -0,0 +1,86 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"testing"
+
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestTileHeightValid(t *testing.T) {
+	sk, _, err := note.GenerateKey(nil, "test")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	for _, test := range []struct {
+		name       string
+		opts       func() *AppendOptions
+		wantTiling layout.Tiling
+		wantErr    bool
+	}{
+		{name: "default", opts: NewAppendOptions, wantTiling: layout.DefaultTiling},
+		{name: "height 4", opts: func() *AppendOptions { return NewAppendOptions().WithTileHeight(4) }, wantTiling: layout.Tiling{Height: 4}},
+		{name: "height 1", opts: func() *AppendOptions { return NewAppendOptions().WithTileHeight(1) }, wantTiling: layout.Tiling{Height: 1}},
+		{name: "too tall", opts: func() *AppendOptions { return NewAppendOptions().WithTileHeight(layout.TileHeight + 1) }, wantErr: true},
+		{name: "CT layout", opts: func() *AppendOptions { return NewAppendOptions().WithCTLayout().WithTileHeight(4) }, wantErr: true},
+		{name: "CT layout, default height", opts: func() *AppendOptions { return NewAppendOptions().WithCTLayout().WithTileHeight(layout.TileHeight) }, wantTiling: layout.DefaultTiling},
+		{name: "follower", opts: func() *AppendOptions { return NewAppendOptions().WithFollower(nopFollower{}).WithTileHeight(4) }, wantErr: true},
+		{name: "compression", opts: func() *AppendOptions { return NewAppendOptions().WithEntryBundleCompression().WithTileHeight(4) }, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			o := test.opts().WithCheckpointSigner(s)
+			err := o.valid()
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("valid() = %v, wantErr %t", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if got := o.Tiling(); got != test.wantTiling {
+				t.Errorf("Tiling() = %v, want %v", got, test.wantTiling)
+			}
+		})
+	}
+}
+
+func TestCheckTileHeightReader(t *testing.T) {
+	type tileHeightLogReader struct {
+		LogReader
+		TileHeightReader
+	}
+	for _, test := range []struct {
+		name    string
+		height  uint
+		lr      LogReader
+		wantErr bool
+	}{
+		{name: "default", lr: &sizesLogReader{}},
+		{name: "unsupported", height: 4, lr: &sizesLogReader{}, wantErr: true},
+		{name: "supported", height: 4, lr: tileHeightLogReader{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := NewAppendOptions().WithTileHeight(test.height).checkTileHeightReader(test.lr)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("checkTileHeightReader() = %v, wantErr %t", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithMaxEntrySize configures the Appender to reject entries whose data is larger than maxBytes with an
+// error wrapping ErrEntryTooLarge.
+//
+// A value of zero (the default) disables this limit.
+func (o *AppendOptions) WithMaxEntrySize(maxBytes uint) *AppendOptions {
+	o.maxEntrySize = maxBytes
+	return o
+}
+
+// WithEntryValidator registers a function which will be called with each entry passed to Add, before it is
+// checked for duplicates or sequenced. If the function returns an error, the entry is rejected and the error is
+// returned from the entry's IndexFuture.
+//
+// This allows personalities to reject malformed submissions in one place, rather than in each of their handlers.
+// Multiple validators may be registered, in which case they are called in the order they were registered.
+func (o *AppendOptions) WithEntryValidator(f func(*Entry) error) *AppendOptions {
+	o.entryValidators = append(o.entryValidators, f)
+	return o
+}
+
+// validationDecorator returns a decorator which rejects entries which fail the configured size limit or
+// validators, or nil if neither has been configured.
+func (o *AppendOptions) validationDecorator() func(AddFn) AddFn {
+	if o.maxEntrySize == 0 && len(o.entryValidators) == 0 {
+		return nil
+	}
+	maxSize, validators := o.maxEntrySize, o.entryValidators
+	return func(delegate AddFn) AddFn {
+		return func(ctx context.Context, entry *Entry) IndexFuture {
+			if maxSize > 0 && uint(len(entry.Data())) > maxSize {
+				return func() (Index, error) {
+					return Index{}, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrEntryTooLarge, len(entry.Data()), maxSize)
+				}
+			}
+			for _, v := range validators {
+				if err := v(entry); err != nil {
+					return func() (Index, error) { return Index{}, err }
+				}
+			}
+			return delegate(ctx, entry)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidationDecorator(t *testing.T) {
+	ctx := context.Background()
+	errNoPrefix := errors.New("missing prefix")
+	hasPrefix := func(e *Entry) error {
+		if !bytes.HasPrefix(e.Data(), []byte("ok:")) {
+			return errNoPrefix
+		}
+		return nil
+	}
+	for _, test := range []struct {
+		name    string
+		opts    *AppendOptions
+		data    string
+		wantErr error
+	}{
+		{name: "no limits", opts: NewAppendOptions(), data: "anything at all"},
+		{name: "within size", opts: NewAppendOptions().WithMaxEntrySize(5), data: "12345"},
+		{name: "too large", opts: NewAppendOptions().WithMaxEntrySize(5), data: "123456", wantErr: ErrEntryTooLarge},
+		{name: "valid", opts: NewAppendOptions().WithEntryValidator(hasPrefix), data: "ok:123"},
+		{name: "invalid", opts: NewAppendOptions().WithEntryValidator(hasPrefix), data: "123", wantErr: errNoPrefix},
+		{name: "size checked first", opts: NewAppendOptions().WithMaxEntrySize(2).WithEntryValidator(hasPrefix), data: "123", wantErr: ErrEntryTooLarge},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var n int
+			add := countAdds(&n)
+			if v := test.opts.validationDecorator(); v != nil {
+				add = v(add)
+			}
+			_, err := add(ctx, NewEntry([]byte(test.data)))()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got err %v, want %v", err, test.wantErr)
+			}
+			if got, want := n, map[bool]int{true: 1, false: 0}[test.wantErr == nil]; got != want {
+				t.Errorf("delegate called %d times, want %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+)
+
+// Handler returns an http.Handler which serves the map:
+//   - GET /root returns the latest signed map root.
+//   - GET /lookup/{key} returns a JSON encoded LookupResponse for the key, which must be encoded
+//     using unpadded URL-safe base64.
+func (m *Map) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /root", m.handleRoot)
+	mux.HandleFunc("GET /lookup/{key}", m.handleLookup)
+	return mux
+}
+
+func (m *Map) handleRoot(w http.ResponseWriter, r *http.Request) {
+	root, err := m.Root(r.Context())
+	if err != nil {
+		klog.Warningf("Root: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(root)
+}
+
+func (m *Map) handleLookup(w http.ResponseWriter, r *http.Request) {
+	key, err := base64.RawURLEncoding.DecodeString(r.PathValue("key"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid key: %v", err), http.StatusBadRequest)
+		return
+	}
+	resp, err := m.Lookup(r.Context(), key)
+	if err != nil {
+		klog.Warningf("Lookup: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
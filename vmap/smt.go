@@ -0,0 +1,150 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// The map is a sparse Merkle tree of depth 256, in which each key is stored at the leaf found by following
+// the bits of the SHA-256 hash of the key, most significant bit first, from the root.
+//
+// The hash of a leaf holding a value is SHA-256(0x00 || keyHash || SHA-256(value)), and the hash of an
+// interior node is SHA-256(0x01 || left || right). The hash of an empty subtree is 32 zero bytes, at every
+// height, so an interior node whose children are both empty is itself empty.
+
+const (
+	// depth is the number of levels below the root of the map.
+	depth = 256
+
+	leafPrefix     = 0x00
+	interiorPrefix = 0x01
+)
+
+// emptyHash is the hash of an empty subtree.
+var emptyHash = make([]byte, sha256.Size)
+
+// Proof proves the value, or absence, of a key in the map.
+type Proof struct {
+	// Siblings holds the hashes of the siblings of each node on the path from the key's leaf to the root,
+	// starting with the sibling of the leaf. Empty subtrees are represented by nil.
+	Siblings [][]byte `json:"siblings"`
+}
+
+// keyHash returns the position in the map of key.
+func keyHash(key []byte) [sha256.Size]byte {
+	return sha256.Sum256(key)
+}
+
+// leafHash returns the hash of the leaf for a key holding value, or emptyHash if value is nil.
+func leafHash(kh [sha256.Size]byte, value []byte) []byte {
+	if value == nil {
+		return emptyHash
+	}
+	vh := sha256.Sum256(value)
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(kh[:])
+	h.Write(vh[:])
+	return h.Sum(nil)
+}
+
+// interiorHash returns the hash of an interior node with the provided children.
+func interiorHash(left, right []byte) []byte {
+	if bytes.Equal(left, emptyHash) && bytes.Equal(right, emptyHash) {
+		return emptyHash
+	}
+	h := sha256.New()
+	h.Write([]byte{interiorPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// bit returns the bit of kh which selects the child at level d+1 of the node at level d on its path.
+func bit(kh [sha256.Size]byte, d int) byte {
+	return (kh[d/8] >> (7 - d%8)) & 1
+}
+
+// nodeID identifies a node in the map by its level, counting down from the root at 0, and the first level
+// bits of the key hashes beneath it. Bits of prefix beyond level are always zero.
+type nodeID struct {
+	level  int
+	prefix [sha256.Size]byte
+}
+
+// newNodeID returns the ID of the node at level d on the path to kh.
+func newNodeID(kh [sha256.Size]byte, d int) nodeID {
+	id := nodeID{level: d}
+	copy(id.prefix[:], kh[:d/8])
+	if d%8 != 0 {
+		id.prefix[d/8] = kh[d/8] & (0xff << (8 - d%8))
+	}
+	return id
+}
+
+// parent returns the ID of the node's parent.
+func (n nodeID) parent() nodeID {
+	return newNodeID(n.prefix, n.level-1)
+}
+
+// sibling returns the ID of the other child of the node's parent.
+func (n nodeID) sibling() nodeID {
+	s := n
+	i := n.level - 1
+	s.prefix[i/8] ^= 1 << (7 - i%8)
+	return s
+}
+
+// isRight returns true if the node is the right hand child of its parent.
+func (n nodeID) isRight() bool {
+	return bit(n.prefix, n.level-1) == 1
+}
+
+// storageKey returns the key under which the node's hash is stored.
+func (n nodeID) storageKey() []byte {
+	k := make([]byte, 0, 3+(n.level+7)/8)
+	k = append(k, nodePrefix, byte(n.level>>8), byte(n.level))
+	return append(k, n.prefix[:(n.level+7)/8]...)
+}
+
+// VerifyProof checks that the map with the provided root hash has value stored against key. If value is
+// nil, it checks that the map has no value for key.
+func VerifyProof(root, key, value []byte, p Proof) error {
+	if len(p.Siblings) != depth {
+		return fmt.Errorf("proof has %d siblings, want %d", len(p.Siblings), depth)
+	}
+	kh := keyHash(key)
+	h := leafHash(kh, value)
+	for i, s := range p.Siblings {
+		if s == nil {
+			s = emptyHash
+		} else if len(s) != sha256.Size {
+			return fmt.Errorf("sibling %d has length %d, want %d", i, len(s), sha256.Size)
+		}
+		if bit(kh, depth-1-i) == 1 {
+			h = interiorHash(s, h)
+		} else {
+			h = interiorHash(h, s)
+		}
+	}
+	if !bytes.Equal(h, root) {
+		return errors.New("proof does not match root")
+	}
+	return nil
+}
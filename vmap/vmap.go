@@ -0,0 +1,381 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vmap implements a verifiable map which holds the latest value of each key described by the
+// entries of a Tessera log.
+//
+// A Map follows the log, and after each batch of entries it has processed signs a map root which commits
+// to both the contents of the map and the log tree from which it was derived. Lookups return the value
+// of a key together with a proof which can be verified against the map root, so clients can be sure
+// that the value they see is the one that every other client sees, and that it was derived from the log.
+//
+// This functionality is experimental! The hashing scheme, storage format, and API may change.
+package vmap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// Prefixes of the keys used to store the map in a tessera.KVStore.
+const (
+	nodePrefix  = 'n'
+	valuePrefix = 'v'
+)
+
+// stateKey is the key under which the map's state is stored.
+var stateKey = []byte("state")
+
+// Update sets the value of a key in the map.
+type Update struct {
+	Key []byte
+	// Value is the new value of Key, or nil if Key should be removed from the map.
+	Value []byte
+}
+
+// MapFunc returns the updates to the map described by the log entry at the provided index.
+//
+// Entries which don't describe any updates should return nil. Returning an error prevents the map from
+// making progress until the function succeeds, so should be reserved for transient failures.
+type MapFunc func(index uint64, entry []byte) ([]Update, error)
+
+// Options holds optional settings for maps created by New.
+type Options struct {
+	// LeafHash returns the Merkle leaf hash of a log entry.
+	// If unset, the RFC 6962 leaf hash of the entry is used, as is the case for https://c2sp.org/tlog-tiles logs.
+	LeafHash func(entry []byte) []byte
+}
+
+// Root is the body of a signed map root.
+type Root struct {
+	// Origin is the name of the map, which is also the name of the key which signs its roots.
+	Origin string
+	// LogSize is the number of log entries from which the map was derived.
+	LogSize uint64
+	// LogRoot is the root hash of the log tree of size LogSize.
+	LogRoot []byte
+	// MapRoot is the root hash of the map.
+	MapRoot []byte
+}
+
+// Marshal returns the text encoding of the root, which has the same shape as a checkpoint body:
+//
+//	<origin>
+//	<log size>
+//	<base64 log root hash>
+//	<base64 map root hash>
+func (r Root) Marshal() []byte {
+	return fmt.Appendf(nil, "%s\n%d\n%s\n%s\n", r.Origin, r.LogSize, base64.StdEncoding.EncodeToString(r.LogRoot), base64.StdEncoding.EncodeToString(r.MapRoot))
+}
+
+// Unmarshal parses the text encoding of a root, as produced by Marshal.
+func (r *Root) Unmarshal(b []byte) error {
+	lines := strings.Split(string(b), "\n")
+	if len(lines) != 5 || lines[4] != "" {
+		return fmt.Errorf("map root has %d lines, want 4", len(lines)-1)
+	}
+	size, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid log size %q: %v", lines[1], err)
+	}
+	logRoot, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil {
+		return fmt.Errorf("invalid log root %q: %v", lines[2], err)
+	}
+	mapRoot, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil {
+		return fmt.Errorf("invalid map root %q: %v", lines[3], err)
+	}
+	*r = Root{Origin: lines[0], LogSize: size, LogRoot: logRoot, MapRoot: mapRoot}
+	return nil
+}
+
+// LookupResponse holds the value of a key together with the proof that it is in the map.
+type LookupResponse struct {
+	// Value is the value of the key, or nil if the key is not in the map.
+	Value []byte `json:"value"`
+	// Proof proves Value against the map root hash in Root.
+	Proof Proof `json:"proof"`
+	// Root is the signed map root which the proof is relative to.
+	Root []byte `json:"root"`
+}
+
+// state is the persisted state of a map, which is updated after every batch of log entries.
+type state struct {
+	// LogSize is the number of log entries which have been processed.
+	LogSize uint64 `json:"log_size"`
+	// Range holds the hashes of the compact range [0, LogSize) of the log tree.
+	Range [][]byte `json:"range"`
+	// MapRoot is the root hash of the map.
+	MapRoot []byte `json:"map_root"`
+	// SignedRoot is the signed map root for this state.
+	SignedRoot []byte `json:"signed_root"`
+}
+
+// Map is a verifiable map derived from the entries of a log.
+type Map struct {
+	kv       tessera.KVStore
+	signer   note.Signer
+	fn       MapFunc
+	leafHash func([]byte) []byte
+	rf       *compact.RangeFactory
+
+	// mu guards state, and ensures that lookups see the nodes of the map as of that state.
+	mu    sync.RWMutex
+	state state
+}
+
+// New returns a Map which stores its nodes and state in kv, and signs its roots with signer.
+//
+// Only a single Map may use a given KVStore at a time. If the KVStore does not apply writes atomically, a
+// crash part way through processing a batch of entries can leave nodes which don't match the signed root
+// until the batch has been processed again.
+func New(ctx context.Context, kv tessera.KVStore, signer note.Signer, fn MapFunc, opts *Options) (*Map, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.LeafHash == nil {
+		o.LeafHash = rfc6962.DefaultHasher.HashLeaf
+	}
+	m := &Map{
+		kv:       kv,
+		signer:   signer,
+		fn:       fn,
+		leafHash: o.LeafHash,
+		rf:       &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren},
+	}
+
+	raw, ok, err := kv.Get(ctx, stateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if ok {
+		if err := json.Unmarshal(raw, &m.state); err != nil {
+			return nil, fmt.Errorf("failed to parse state: %v", err)
+		}
+		return m, nil
+	}
+	signed, err := m.sign(Root{Origin: signer.Name(), LogSize: 0, LogRoot: rfc6962.DefaultHasher.EmptyRoot(), MapRoot: emptyHash})
+	if err != nil {
+		return nil, err
+	}
+	m.state = state{MapRoot: emptyHash, SignedRoot: signed}
+	return m, nil
+}
+
+// Follower returns a tessera.Follower which keeps the map up to date with the log.
+//
+// The follower's position is stored with the map itself. Maps which are relied upon by external parties
+// should set FollowerOptions.FollowPublished, so that every map root refers to a published log tree.
+func (m *Map) Follower(name string, opts *tessera.FollowerOptions) tessera.Follower {
+	return tessera.NewFollower(name, (*mapPosition)(m), m.process, opts)
+}
+
+// mapPosition implements tessera.FollowerPositionStore using the state of the map.
+type mapPosition Map
+
+func (p *mapPosition) Position(_ context.Context) (uint64, error) {
+	m := (*Map)(p)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.LogSize, nil
+}
+
+// SetPosition checks that pos matches the state of the map, which was stored by process.
+func (p *mapPosition) SetPosition(_ context.Context, pos uint64) error {
+	m := (*Map)(p)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if pos != m.state.LogSize {
+		return fmt.Errorf("position %d does not match map log size %d", pos, m.state.LogSize)
+	}
+	return nil
+}
+
+// Root returns the latest signed map root.
+func (m *Map) Root(_ context.Context) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.SignedRoot, nil
+}
+
+// Lookup returns the value of key, and a proof of it relative to the latest signed map root.
+func (m *Map) Lookup(ctx context.Context, key []byte) (*LookupResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	kh := keyHash(key)
+	v, ok, err := m.kv.Get(ctx, valueKey(kh))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read value: %v", err)
+	}
+	var value []byte
+	if ok && len(v) > 0 && v[0] == 1 {
+		value = v[1:]
+	}
+
+	p := Proof{Siblings: make([][]byte, 0, depth)}
+	for d := depth; d > 0; d-- {
+		h, err := m.node(ctx, newNodeID(kh, d).sibling())
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(h, emptyHash) {
+			h = nil
+		}
+		p.Siblings = append(p.Siblings, h)
+	}
+	return &LookupResponse{Value: value, Proof: p, Root: m.state.SignedRoot}, nil
+}
+
+// process applies the updates described by a batch of log entries to the map, and signs a new root.
+func (m *Map) process(ctx context.Context, first uint64, entries [][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if first != m.state.LogSize {
+		return fmt.Errorf("got entries starting at %d, want %d", first, m.state.LogSize)
+	}
+	r, err := m.rf.NewRange(0, m.state.LogSize, m.state.Range)
+	if err != nil {
+		return fmt.Errorf("failed to create compact range: %v", err)
+	}
+	updates := make(map[[sha256.Size]byte][]byte)
+	for i, e := range entries {
+		if err := r.Append(m.leafHash(e), nil); err != nil {
+			return fmt.Errorf("failed to append entry %d to compact range: %v", first+uint64(i), err)
+		}
+		us, err := m.fn(first+uint64(i), e)
+		if err != nil {
+			return fmt.Errorf("failed to map entry %d: %v", first+uint64(i), err)
+		}
+		// Later updates to a key replace earlier ones.
+		for _, u := range us {
+			updates[keyHash(u.Key)] = u.Value
+		}
+	}
+
+	mapRoot, err := m.update(ctx, updates)
+	if err != nil {
+		return err
+	}
+	logRoot, err := r.GetRootHash(nil)
+	if err != nil {
+		return fmt.Errorf("failed to calculate log root: %v", err)
+	}
+	signed, err := m.sign(Root{Origin: m.signer.Name(), LogSize: r.End(), LogRoot: logRoot, MapRoot: mapRoot})
+	if err != nil {
+		return err
+	}
+	s := state{LogSize: r.End(), Range: r.Hashes(), MapRoot: mapRoot, SignedRoot: signed}
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+	if err := m.kv.Set(ctx, stateKey, raw); err != nil {
+		return fmt.Errorf("failed to store state: %v", err)
+	}
+	m.state = s
+	return nil
+}
+
+// update stores the new values, and the nodes on their paths to the root, returning the new root hash of the map.
+func (m *Map) update(ctx context.Context, updates map[[sha256.Size]byte][]byte) ([]byte, error) {
+	if len(updates) == 0 {
+		return m.state.MapRoot, nil
+	}
+	level := make(map[nodeID][]byte, len(updates))
+	for kh, v := range updates {
+		// A leading byte distinguishes empty values from removed keys.
+		stored := []byte{0}
+		if v != nil {
+			stored = append([]byte{1}, v...)
+		}
+		if err := m.kv.Set(ctx, valueKey(kh), stored); err != nil {
+			return nil, fmt.Errorf("failed to store value: %v", err)
+		}
+		level[newNodeID(kh, depth)] = leafHash(kh, v)
+	}
+	// Work up the tree a level at a time, combining each updated node with its sibling, which is either
+	// also being updated or is unchanged in storage.
+	for d := depth; d > 0; d-- {
+		next := make(map[nodeID][]byte, len(level))
+		for id, h := range level {
+			if err := m.kv.Set(ctx, id.storageKey(), h); err != nil {
+				return nil, fmt.Errorf("failed to store node: %v", err)
+			}
+			p := id.parent()
+			if _, ok := next[p]; ok {
+				continue
+			}
+			sib := id.sibling()
+			sh, ok := level[sib]
+			if !ok {
+				var err error
+				if sh, err = m.node(ctx, sib); err != nil {
+					return nil, err
+				}
+			}
+			if id.isRight() {
+				next[p] = interiorHash(sh, h)
+			} else {
+				next[p] = interiorHash(h, sh)
+			}
+		}
+		level = next
+	}
+	return level[nodeID{}], nil
+}
+
+// node returns the hash of the node with the provided ID.
+func (m *Map) node(ctx context.Context, id nodeID) ([]byte, error) {
+	h, ok, err := m.kv.Get(ctx, id.storageKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node: %v", err)
+	}
+	if !ok {
+		return emptyHash, nil
+	}
+	if len(h) != sha256.Size {
+		return nil, errors.New("stored node has invalid length")
+	}
+	return h, nil
+}
+
+func (m *Map) sign(r Root) ([]byte, error) {
+	signed, err := note.Sign(&note.Note{Text: string(r.Marshal())}, m.signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign map root: %v", err)
+	}
+	return signed, nil
+}
+
+// valueKey returns the key under which the value of the key with hash kh is stored.
+func valueKey(kh [sha256.Size]byte) []byte {
+	return append([]byte{valuePrefix}, kh[:]...)
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"github.com/transparency-dev/tessera/testonly"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// mapKV is a tessera.KVStore backed by a map.
+type mapKV struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (k *mapKV) Get(_ context.Context, key []byte) ([]byte, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	v, ok := k.m[string(key)]
+	return v, ok, nil
+}
+
+func (k *mapKV) Set(_ context.Context, key, value []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.m[string(key)] = value
+	return nil
+}
+
+// testMapFunc maps entries of the form "key=value" to an update of key, and "-key" to its removal.
+func testMapFunc(_ uint64, entry []byte) ([]Update, error) {
+	if k, ok := strings.CutPrefix(string(entry), "-"); ok {
+		return []Update{{Key: []byte(k)}}, nil
+	}
+	k, v, ok := strings.Cut(string(entry), "=")
+	if !ok {
+		return nil, nil
+	}
+	return []Update{{Key: []byte(k), Value: []byte(v)}}, nil
+}
+
+func newTestSigner(t *testing.T) (note.Signer, note.Verifier) {
+	t.Helper()
+	skey, vkey, err := note.GenerateKey(nil, "example.com/map")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(skey)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vkey)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return s, v
+}
+
+// openRoot verifies the signature on a signed map root, and parses it.
+func openRoot(t *testing.T, v note.Verifier, signed []byte) Root {
+	t.Helper()
+	n, err := note.Open(signed, note.VerifierList(v))
+	if err != nil {
+		t.Fatalf("note.Open: %v", err)
+	}
+	r := Root{}
+	if err := r.Unmarshal([]byte(n.Text)); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return r
+}
+
+// checkLookup looks up key in m, and checks that it has the wanted value and a valid proof.
+func checkLookup(t *testing.T, m *Map, v note.Verifier, key string, want []byte) {
+	t.Helper()
+	resp, err := m.Lookup(t.Context(), []byte(key))
+	if err != nil {
+		t.Fatalf("Lookup(%q): %v", key, err)
+	}
+	if !bytes.Equal(resp.Value, want) || (resp.Value == nil) != (want == nil) {
+		t.Errorf("Lookup(%q) = %q, want %q", key, resp.Value, want)
+	}
+	r := openRoot(t, v, resp.Root)
+	if err := VerifyProof(r.MapRoot, []byte(key), resp.Value, resp.Proof); err != nil {
+		t.Errorf("VerifyProof(%q): %v", key, err)
+	}
+	// The proof must not also verify a different value.
+	if err := VerifyProof(r.MapRoot, []byte(key), []byte("bogus"), resp.Proof); err == nil {
+		t.Errorf("VerifyProof(%q) of wrong value succeeded", key)
+	}
+}
+
+func TestMap(t *testing.T) {
+	ctx := t.Context()
+	kv := &mapKV{m: make(map[string][]byte)}
+	s, v := newTestSigner(t)
+	m, err := New(ctx, kv, s, testMapFunc, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	checkLookup(t, m, v, "a", nil)
+
+	batches := [][]string{
+		{"a=1", "b=2", "c=3", "not an update"},
+		{"a=4", "-b", "d="},
+	}
+	first := uint64(0)
+	for _, b := range batches {
+		entries := make([][]byte, 0, len(b))
+		for _, e := range b {
+			entries = append(entries, []byte(e))
+		}
+		if err := m.process(ctx, first, entries); err != nil {
+			t.Fatalf("process: %v", err)
+		}
+		first += uint64(len(b))
+	}
+	if err := m.process(ctx, 0, [][]byte{[]byte("a=5")}); err == nil {
+		t.Error("process of already processed entries succeeded")
+	}
+
+	want := map[string][]byte{"a": []byte("4"), "b": nil, "c": []byte("3"), "d": {}, "e": nil}
+	for k, w := range want {
+		checkLookup(t, m, v, k, w)
+	}
+	root, err := m.Root(ctx)
+	if err != nil {
+		t.Fatalf("Root: %v", err)
+	}
+	if r := openRoot(t, v, root); r.LogSize != first {
+		t.Errorf("LogSize = %d, want %d", r.LogSize, first)
+	}
+
+	// A map opened on the same storage must pick up where the first one left off.
+	m2, err := New(ctx, kv, s, testMapFunc, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for k, w := range want {
+		checkLookup(t, m2, v, k, w)
+	}
+	if pos, err := (*mapPosition)(m2).Position(ctx); err != nil || pos != first {
+		t.Errorf("Position = (%d, %v), want (%d, nil)", pos, err, first)
+	}
+
+	// Removing every key must return the map to its empty state.
+	if err := m2.process(ctx, first, [][]byte{[]byte("-a"), []byte("-c"), []byte("-d")}); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	root, err = m2.Root(ctx)
+	if err != nil {
+		t.Fatalf("Root: %v", err)
+	}
+	if r := openRoot(t, v, root); !bytes.Equal(r.MapRoot, emptyHash) {
+		t.Errorf("MapRoot = %x, want empty", r.MapRoot)
+	}
+}
+
+func TestFollower(t *testing.T) {
+	ctx := t.Context()
+	s, v := newTestSigner(t)
+	m, err := New(ctx, &mapKV{m: make(map[string][]byte)}, s, testMapFunc, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tl, shutdown := testonly.NewTestLog(t, tessera.NewAppendOptions().
+		WithCheckpointInterval(time.Second).
+		WithFollower(m.Follower("vmap", &tessera.FollowerOptions{
+			BatchSize:       7,
+			PollInterval:    10 * time.Millisecond,
+			FollowPublished: true,
+		})))
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	}()
+
+	const numKeys, numEntries = 10, 300
+	awaiter := tessera.NewPublicationAwaiter(ctx, tl.LogReader.ReadCheckpoint, 10*time.Millisecond)
+	var last tessera.IndexFuture
+	for i := range numEntries {
+		last = tl.Appender.Add(ctx, tessera.NewEntry(fmt.Appendf(nil, "k%d=%d", i%numKeys, i)))
+	}
+	_, cp, err := awaiter.Await(ctx, last)
+	if err != nil {
+		t.Fatalf("Await: %v", err)
+	}
+	_, size, hash, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		t.Fatalf("CheckpointUnsafe: %v", err)
+	}
+
+	for {
+		root, err := m.Root(ctx)
+		if err != nil {
+			t.Fatalf("Root: %v", err)
+		}
+		r := openRoot(t, v, root)
+		if r.LogSize == size {
+			if !bytes.Equal(r.LogRoot, hash) {
+				t.Errorf("LogRoot = %x, want %x", r.LogRoot, hash)
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for k := range numKeys {
+		checkLookup(t, m, v, fmt.Sprintf("k%d", k), fmt.Appendf(nil, "%d", numEntries-numKeys+k))
+	}
+}
+
+func TestRootUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "valid", text: "example.com/map\n10\nAAAA\nAQID\n"},
+		{name: "missing trailing newline", text: "example.com/map\n10\nAAAA\nAQID", wantErr: true},
+		{name: "extra line", text: "example.com/map\n10\nAAAA\nAQID\nextra\n", wantErr: true},
+		{name: "bad size", text: "example.com/map\nten\nAAAA\nAQID\n", wantErr: true},
+		{name: "bad log root", text: "example.com/map\n10\n!!!!\nAQID\n", wantErr: true},
+		{name: "bad map root", text: "example.com/map\n10\nAAAA\n!!!!\n", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := Root{}
+			err := r.Unmarshal([]byte(test.text))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Unmarshal = %v, want error %t", err, test.wantErr)
+			}
+			if err == nil && string(r.Marshal()) != test.text {
+				t.Errorf("Marshal = %q, want %q", r.Marshal(), test.text)
+			}
+		})
+	}
+}
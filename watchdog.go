@@ -0,0 +1,232 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/tessera/api/layout"
+	"github.com/transparency-dev/tessera/client"
+	"github.com/transparency-dev/tessera/internal/parse"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+// DefaultWatchdogInterval is used by the consistency watchdog if WatchdogOptions.Interval is not set.
+const DefaultWatchdogInterval = time.Minute
+
+const (
+	// DivergenceSignature means that a checkpoint served by the log did not carry a valid signature.
+	DivergenceSignature = "signature"
+	// DivergenceConsistency means that a checkpoint served by the log was not consistent with one served earlier.
+	DivergenceConsistency = "consistency"
+	// DivergenceStorage means that a checkpoint served by the log was not consistent with the tree in storage.
+	DivergenceStorage = "storage"
+)
+
+// ErrDivergence is returned by the consistency watchdog when the log's published state has diverged from
+// what it should be.
+type ErrDivergence struct {
+	// Kind is one of the Divergence constants.
+	Kind string
+	// Checkpoint is the raw checkpoint served by the log.
+	Checkpoint []byte
+	Wrapped    error
+}
+
+func (e ErrDivergence) Unwrap() error {
+	return e.Wrapped
+}
+
+func (e ErrDivergence) Error() string {
+	return fmt.Sprintf("%s divergence: %v", e.Kind, e.Wrapped)
+}
+
+// WatchdogOptions configures the consistency watchdog enabled with WithConsistencyWatchdog.
+type WatchdogOptions struct {
+	// Verifier verifies the log's signature on the checkpoints it serves.
+	Verifier note.Verifier
+	// Origin is the origin line expected in the log's checkpoints. If empty, the name of Verifier is used.
+	Origin string
+	// FetchCheckpoint and FetchTile read the log through its public read path, i.e. the same way that clients
+	// of the log do. For example, these can be the methods of a client.HTTPFetcher pointed at the log's URL.
+	FetchCheckpoint client.CheckpointFetcherFunc
+	FetchTile       client.TileFetcherFunc
+	// Interval is how often the log's published checkpoint is checked. Defaults to DefaultWatchdogInterval.
+	Interval time.Duration
+	// OnDivergence, if set, is called with an ErrDivergence each time one is found, e.g. to alert an operator.
+	OnDivergence func(ctx context.Context, err ErrDivergence)
+}
+
+// WithConsistencyWatchdog configures the Appender to independently check the checkpoints that the log publishes,
+// as a defence against storage corruption, or different views of the log being served to different clients.
+//
+// The watchdog periodically fetches the log's checkpoint through its public read path, and checks that:
+//   - it carries a valid signature from the log,
+//   - it's consistent with the checkpoint that the watchdog fetched previously, using tiles fetched through the
+//     public read path, and
+//   - it's consistent with the tree in the log's storage, using tiles read directly from the LogReader.
+//
+// Any divergence is logged, counted by the tessera.appender.watchdog.divergences metric, and passed to
+// WatchdogOptions.OnDivergence if it's set. Failures to fetch the checkpoint or tiles are logged, but are not
+// treated as divergence.
+func (o *AppendOptions) WithConsistencyWatchdog(opts WatchdogOptions) *AppendOptions {
+	o.watchdog = &opts
+	return o
+}
+
+// watchdog checks the checkpoints published by a log.
+type watchdog struct {
+	opts   WatchdogOptions
+	reader LogReader
+	hasher merkle.LogHasher
+	tiling layout.Tiling
+
+	// latest is the largest checkpoint seen so far which passed all of the checks.
+	latest    *log.Checkpoint
+	latestRaw []byte
+}
+
+func newWatchdog(opts WatchdogOptions, r LogReader, h merkle.LogHasher, tl layout.Tiling) *watchdog {
+	if opts.Origin == "" {
+		opts.Origin = opts.Verifier.Name()
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchdogInterval
+	}
+	return &watchdog{opts: opts, reader: r, hasher: h, tiling: tl}
+}
+
+// run periodically checks the log's published checkpoint.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (w *watchdog) run(ctx context.Context) {
+	t := time.NewTicker(w.opts.Interval)
+	defer t.Stop()
+	for {
+		if err := w.check(ctx); err != nil {
+			var d ErrDivergence
+			if errors.As(err, &d) {
+				klog.Errorf("watchdog: %v", err)
+				appenderWatchdogDivergences.Add(ctx, 1, metric.WithAttributes(divergenceKindKey.String(d.Kind)))
+				if w.opts.OnDivergence != nil {
+					w.opts.OnDivergence(ctx, d)
+				}
+			} else {
+				klog.Warningf("watchdog: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// check fetches the log's published checkpoint, and verifies it against the previously seen checkpoint and the
+// tree in storage. An ErrDivergence is returned if any of these checks fail.
+func (w *watchdog) check(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "tessera.watchdog.check")
+	defer span.End()
+
+	cpRaw, err := w.opts.FetchCheckpoint(ctx)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Nothing has been published yet.
+			return nil
+		}
+		return fmt.Errorf("failed to fetch checkpoint: %v", err)
+	}
+	cp, _, _, err := log.ParseCheckpoint(cpRaw, w.opts.Origin, w.opts.Verifier)
+	if err != nil {
+		return ErrDivergence{Kind: DivergenceSignature, Checkpoint: cpRaw, Wrapped: err}
+	}
+
+	if w.latest != nil {
+		if err := w.consistent(ctx, w.opts.FetchTile, w.latest, cp); err != nil {
+			return w.wrap(err, DivergenceConsistency, cpRaw, fmt.Sprintf("checkpoint is inconsistent with earlier checkpoint %q", w.latestRaw))
+		}
+	}
+
+	storedRaw, err := w.reader.ReadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint from storage: %v", err)
+	}
+	_, size, hash, err := parse.CheckpointUnsafe(storedRaw)
+	if err != nil {
+		return fmt.Errorf("failed to parse checkpoint from storage: %v", err)
+	}
+	stored := &log.Checkpoint{Size: size, Hash: hash}
+	if err := w.consistent(ctx, w.reader.ReadTile, stored, cp); err != nil {
+		return w.wrap(err, DivergenceStorage, cpRaw, fmt.Sprintf("checkpoint is inconsistent with checkpoint %q in storage", storedRaw))
+	}
+
+	if w.latest == nil || cp.Size > w.latest.Size {
+		w.latest, w.latestRaw = cp, cpRaw
+	}
+	return nil
+}
+
+// errInconsistent is wrapped by errors returned from consistent if the checkpoints are proven to be inconsistent,
+// as opposed to the proof being unavailable.
+var errInconsistent = errors.New("inconsistent checkpoints")
+
+// consistent returns an error if the checkpoints a and b do not commit to the same tree, or one of them to a
+// prefix of the other. Tiles needed to prove this are read using f.
+func (w *watchdog) consistent(ctx context.Context, f client.TileFetcherFunc, a, b *log.Checkpoint) error {
+	if a.Size > b.Size {
+		a, b = b, a
+	}
+	if a.Size == b.Size {
+		if !bytes.Equal(a.Hash, b.Hash) {
+			return fmt.Errorf("%w: roots differ at size %d", errInconsistent, a.Size)
+		}
+		return nil
+	}
+	if a.Size == 0 {
+		return nil
+	}
+	pb, err := client.NewProofBuilderWithTiling(ctx, b.Size, f, w.hasher, w.tiling)
+	if err != nil {
+		return fmt.Errorf("failed to create proof builder: %v", err)
+	}
+	p, err := pb.ConsistencyProof(ctx, a.Size, b.Size)
+	if err != nil {
+		return fmt.Errorf("failed to build consistency proof from %d to %d: %v", a.Size, b.Size, err)
+	}
+	if err := proof.VerifyConsistency(w.hasher, a.Size, b.Size, p, a.Hash, b.Hash); err != nil {
+		return fmt.Errorf("%w: %v", errInconsistent, err)
+	}
+	return nil
+}
+
+// wrap returns an ErrDivergence of the given kind if err shows that the checkpoint cpRaw is inconsistent, or
+// err otherwise.
+func (w *watchdog) wrap(err error, kind string, cpRaw []byte, msg string) error {
+	if !errors.Is(err, errInconsistent) {
+		return fmt.Errorf("%s: %v", msg, err)
+	}
+	return ErrDivergence{Kind: kind, Checkpoint: cpRaw, Wrapped: fmt.Errorf("%s: %w", msg, err)}
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	f_log "github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle/compact"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/tessera/api"
+	"github.com/transparency-dev/tessera/api/layout"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// memTree is a Merkle tree of fewer than 256 entries, held in memory.
+type memTree struct {
+	leafHashes [][]byte
+}
+
+func newMemTree(prefix string, n int) *memTree {
+	t := &memTree{}
+	for i := range n {
+		t.leafHashes = append(t.leafHashes, rfc6962.DefaultHasher.HashLeaf(fmt.Appendf(nil, "%s %d", prefix, i)))
+	}
+	return t
+}
+
+func (t *memTree) ReadTile(_ context.Context, level, index uint64, p uint8) ([]byte, error) {
+	if level != 0 || index != 0 || int(p) > len(t.leafHashes) {
+		return nil, os.ErrNotExist
+	}
+	return api.HashTile{Nodes: t.leafHashes[:p]}.MarshalText()
+}
+
+// checkpoint returns a checkpoint for the first size entries in the tree, signed by s.
+func (t *memTree) checkpoint(tb testing.TB, s note.Signer, size int) []byte {
+	tb.Helper()
+	rf := compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+	r := rf.NewEmptyRange(0)
+	for _, h := range t.leafHashes[:size] {
+		if err := r.Append(h, nil); err != nil {
+			tb.Fatalf("Append: %v", err)
+		}
+	}
+	root, err := r.GetRootHash(nil)
+	if err != nil {
+		tb.Fatalf("GetRootHash: %v", err)
+	}
+	cp := f_log.Checkpoint{Origin: s.Name(), Size: uint64(size), Hash: root}
+	n, err := note.Sign(&note.Note{Text: string(cp.Marshal())}, s)
+	if err != nil {
+		tb.Fatalf("Sign: %v", err)
+	}
+	return n
+}
+
+// watchdogLogReader is a LogReader which serves a memTree as the log's storage.
+type watchdogLogReader struct {
+	fakeLogReader
+	tree       *memTree
+	checkpoint []byte
+}
+
+func (r *watchdogLogReader) ReadCheckpoint(_ context.Context) ([]byte, error) {
+	return r.checkpoint, nil
+}
+
+func (r *watchdogLogReader) ReadTile(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+	return r.tree.ReadTile(ctx, level, index, p)
+}
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	s, v := mustCreateKeys(t, "example.com/log")
+	otherS, _ := mustCreateKeys(t, "example.com/log")
+	a, b := newMemTree("a", 10), newMemTree("b", 10)
+
+	// step describes the state of the log seen by one check.
+	type step struct {
+		// public is the tree served through the public read path, and publicSize the size of its checkpoint.
+		public     *memTree
+		publicSize int
+		// signer signs the public checkpoint, if set.
+		signer note.Signer
+		// noTiles causes fetching public tiles to fail.
+		noTiles bool
+		// stored is the tree in storage, and storedSize the size of its checkpoint.
+		stored     *memTree
+		storedSize int
+		wantErr    bool
+		// wantKind is the kind of divergence expected, if any.
+		wantKind string
+	}
+	for _, test := range []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "growing",
+			steps: []step{
+				{public: a, publicSize: 3, stored: a, storedSize: 5},
+				{public: a, publicSize: 5, stored: a, storedSize: 5},
+				{public: a, publicSize: 8, stored: a, storedSize: 10},
+			},
+		}, {
+			name: "stale checkpoint",
+			steps: []step{
+				{public: a, publicSize: 8, stored: a, storedSize: 8},
+				{public: a, publicSize: 3, stored: a, storedSize: 8},
+			},
+		}, {
+			name: "bad signature",
+			steps: []step{
+				{public: a, publicSize: 3, signer: otherS, stored: a, storedSize: 3, wantErr: true, wantKind: DivergenceSignature},
+			},
+		}, {
+			name: "split view",
+			steps: []step{
+				{public: b, publicSize: 3, stored: a, storedSize: 5, wantErr: true, wantKind: DivergenceStorage},
+			},
+		}, {
+			name: "same size split view",
+			steps: []step{
+				{public: b, publicSize: 5, stored: a, storedSize: 5, wantErr: true, wantKind: DivergenceStorage},
+			},
+		}, {
+			name: "fork",
+			steps: []step{
+				{public: a, publicSize: 3, stored: a, storedSize: 3},
+				{public: b, publicSize: 5, stored: b, storedSize: 5, wantErr: true, wantKind: DivergenceConsistency},
+			},
+		}, {
+			name: "tiles unavailable",
+			steps: []step{
+				{public: a, publicSize: 3, stored: a, storedSize: 3},
+				{public: a, publicSize: 5, noTiles: true, stored: a, storedSize: 5, wantErr: true},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var st step
+			r := &watchdogLogReader{}
+			w := newWatchdog(WatchdogOptions{
+				Verifier: v,
+				FetchCheckpoint: func(_ context.Context) ([]byte, error) {
+					signer := s
+					if st.signer != nil {
+						signer = st.signer
+					}
+					return st.public.checkpoint(t, signer, st.publicSize), nil
+				},
+				FetchTile: func(ctx context.Context, level, index uint64, p uint8) ([]byte, error) {
+					if st.noTiles {
+						return nil, errors.New("unavailable")
+					}
+					return st.public.ReadTile(ctx, level, index, p)
+				},
+			}, r, rfc6962.DefaultHasher, layout.DefaultTiling)
+			for i := range test.steps {
+				st = test.steps[i]
+				r.tree, r.checkpoint = st.stored, st.stored.checkpoint(t, s, st.storedSize)
+				err := w.check(ctx)
+				if gotErr := err != nil; gotErr != st.wantErr {
+					t.Fatalf("step %d: check: %v, want error %t", i, err, st.wantErr)
+				}
+				var d ErrDivergence
+				if gotDivergence := errors.As(err, &d); gotDivergence != (st.wantKind != "") || d.Kind != st.wantKind {
+					t.Fatalf("step %d: check: %v, want divergence %q", i, err, st.wantKind)
+				}
+			}
+		})
+	}
+}
+
+func mustCreateKeys(t *testing.T, name string) (note.Signer, note.Verifier) {
+	t.Helper()
+	sk, vk, err := note.GenerateKey(nil, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return s, v
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transparency-dev/tessera/internal/parse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultWebhookMaxAttempts is used if WebhookOptions.MaxAttempts is not set.
+	DefaultWebhookMaxAttempts = 5
+	// DefaultWebhookTimeout is the timeout of each attempt to deliver a notification, if WebhookOptions.Client
+	// is not set.
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// WebhookSignatureHeader is the HTTP header carrying the signature of a webhook notification, in the form
+	// "sha256=<hex HMAC>". See VerifyWebhookSignature.
+	WebhookSignatureHeader = "X-Tessera-Signature"
+	// WebhookTimestampHeader is the HTTP header carrying the time, in Unix seconds, at which a webhook
+	// notification was signed.
+	WebhookTimestampHeader = "X-Tessera-Timestamp"
+
+	webhookMinRetryDelay = time.Second
+	webhookMaxRetryDelay = 30 * time.Second
+)
+
+// WebhookOptions configures the webhooks enabled with WithWebhooks.
+type WebhookOptions struct {
+	// URLs are the webhooks to which notifications are POSTed.
+	URLs []string
+	// Secret, if set, is the key used to sign each notification with HMAC-SHA256. See VerifyWebhookSignature.
+	Secret []byte
+	// IncludeRange adds the range of indices covered by each checkpoint, but not by the one previously
+	// published, to notifications.
+	IncludeRange bool
+	// MaxAttempts is the number of times delivery of a notification is attempted before it's dropped.
+	// Defaults to DefaultWebhookMaxAttempts.
+	MaxAttempts int
+	// Client is used to make requests to the webhooks. Defaults to a client with a timeout of
+	// DefaultWebhookTimeout.
+	Client *http.Client
+}
+
+// WebhookNotification is the JSON body POSTed to webhooks when a checkpoint is published.
+type WebhookNotification struct {
+	Origin string `json:"origin"`
+	Size   uint64 `json:"size"`
+	// Checkpoint is the published checkpoint.
+	Checkpoint string `json:"checkpoint"`
+	// Range is set if WebhookOptions.IncludeRange is.
+	Range *WebhookRange `json:"range,omitempty"`
+}
+
+// WebhookRange is the range [From, To) of indices which a checkpoint covers, but which the previously published
+// one did not.
+type WebhookRange struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// WithWebhooks configures the Appender to POST a WebhookNotification to each of opts.URLs when a new checkpoint
+// is published, so that downstream systems can react to the log growing without polling it.
+//
+// Notifications are delivered asynchronously, in order, to each webhook. Requests which fail with a network
+// error, or a 429 or 5xx status, are retried with exponential backoff. If a checkpoint is published while an
+// earlier notification is still waiting to be delivered to a webhook, the two are merged into one for the newer
+// checkpoint, with a Range which covers both, so that no indices are skipped.
+func (o *AppendOptions) WithWebhooks(opts WebhookOptions) *AppendOptions {
+	n := newWebhookNotifier(opts)
+	o.onPublish = append(o.onPublish, n.publish)
+	return o
+}
+
+// VerifyWebhookSignature returns an error unless signature is a valid signature using secret of the webhook
+// notification body sent at timestamp. These are the values of the request's body, WebhookSignatureHeader,
+// and WebhookTimestampHeader respectively.
+//
+// Receivers should also check that timestamp is recent, to prevent notifications from being replayed.
+func VerifyWebhookSignature(secret []byte, timestamp string, body []byte, signature string) error {
+	hexMAC, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return fmt.Errorf("unsupported signature %q", signature)
+	}
+	mac, err := hex.DecodeString(hexMAC)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if !hmac.Equal(mac, webhookMAC(secret, timestamp, body)) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// webhookMAC returns the HMAC-SHA256 of the timestamp and body of a notification.
+func webhookMAC(secret []byte, timestamp string, body []byte) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(timestamp))
+	m.Write([]byte("."))
+	m.Write(body)
+	return m.Sum(nil)
+}
+
+// webhookNotifier delivers notifications of published checkpoints to webhooks.
+type webhookNotifier struct {
+	opts     WebhookOptions
+	now      func() time.Time
+	minDelay time.Duration
+
+	start sync.Once
+	hooks []*webhook
+}
+
+func newWebhookNotifier(opts WebhookOptions) *webhookNotifier {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	n := &webhookNotifier{opts: opts, now: time.Now, minDelay: webhookMinRetryDelay}
+	for _, u := range opts.URLs {
+		n.hooks = append(n.hooks, &webhook{url: u, ready: make(chan struct{}, 1)})
+	}
+	return n
+}
+
+// publish queues a notification of the checkpoint cp, which grew the tree from prevSize to size, for delivery
+// to each webhook.
+func (n *webhookNotifier) publish(ctx context.Context, prevSize, size uint64, cp []byte) {
+	n.start.Do(func() {
+		for _, h := range n.hooks {
+			go n.deliver(ctx, h)
+		}
+	})
+	origin, _, _, err := parse.CheckpointUnsafe(cp)
+	if err != nil {
+		klog.Warningf("webhooks: failed to parse checkpoint: %v", err)
+		return
+	}
+	for _, h := range n.hooks {
+		h.queue(WebhookNotification{
+			Origin:     origin,
+			Size:       size,
+			Checkpoint: string(cp),
+			Range:      &WebhookRange{From: prevSize, To: size},
+		})
+	}
+}
+
+// deliver sends each notification queued for h to it.
+//
+// This is a long running function, exiting only when the provided context is done.
+func (n *webhookNotifier) deliver(ctx context.Context, h *webhook) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.ready:
+		}
+		h.mu.Lock()
+		msg := h.pending
+		h.pending = nil
+		h.mu.Unlock()
+		if msg == nil {
+			continue
+		}
+		if !n.opts.IncludeRange {
+			msg.Range = nil
+		}
+		if err := n.send(ctx, h.url, *msg); err != nil {
+			klog.Errorf("webhooks: failed to notify %q of checkpoint at size %d: %v", h.url, msg.Size, err)
+			appenderWebhookFailures.Add(ctx, 1)
+		}
+	}
+}
+
+// send POSTs msg to url, retrying as necessary.
+func (n *webhookNotifier) send(ctx context.Context, url string, msg WebhookNotification) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	delay := n.minDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.opts.MaxAttempts {
+			return fmt.Errorf("attempt %d: %v", attempt, err)
+		}
+		klog.V(1).Infof("webhooks: attempt %d to notify %q failed, retrying in %v: %v", attempt, url, delay, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, webhookMaxRetryDelay)
+	}
+}
+
+// post makes one attempt to POST body to url. The returned bool is true if a failed attempt should be retried.
+func (n *webhookNotifier) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.opts.Secret) > 0 {
+		ts := strconv.FormatInt(n.now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, ts)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(webhookMAC(n.opts.Secret, ts, body)))
+	}
+	resp, err := n.opts.Client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return false, fmt.Errorf("status %s", resp.Status)
+	}
+}
+
+// webhook holds the notification waiting to be delivered to a URL.
+type webhook struct {
+	url string
+	// ready is signalled when pending is set.
+	ready chan struct{}
+
+	mu      sync.Mutex
+	pending *WebhookNotification
+}
+
+// queue sets msg as the notification to be delivered next, merging it with any which is already pending.
+func (h *webhook) queue(msg WebhookNotification) {
+	h.mu.Lock()
+	if h.pending != nil {
+		msg.Range.From = h.pending.Range.From
+	}
+	h.pending = &msg
+	h.mu.Unlock()
+	select {
+	case h.ready <- struct{}{}:
+	default:
+	}
+}
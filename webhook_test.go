@@ -0,0 +1,206 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tessera
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// webhookReceiver is a webhook which sends the notifications it accepts to received, responding to each
+// request with the next of statuses, or 200 once they've run out.
+type webhookReceiver struct {
+	t      *testing.T
+	secret []byte
+
+	mu       sync.Mutex
+	statuses []int
+	requests int
+	received chan WebhookNotification
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.t.Errorf("ReadAll: %v", err)
+	}
+	if r.secret != nil {
+		if err := VerifyWebhookSignature(r.secret, req.Header.Get(WebhookTimestampHeader), body, req.Header.Get(WebhookSignatureHeader)); err != nil {
+			r.t.Errorf("VerifyWebhookSignature: %v", err)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if len(r.statuses) > 0 {
+		s := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if s != http.StatusOK {
+			w.WriteHeader(s)
+			return
+		}
+	}
+	var n WebhookNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		r.t.Errorf("Unmarshal: %v", err)
+	}
+	r.received <- n
+}
+
+func webhookCheckpoint(size uint64) []byte {
+	return fmt.Appendf(nil, "example.com/log\n%d\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", size)
+}
+
+func TestWebhooks(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		includeRange bool
+		want         []WebhookNotification
+	}{
+		{
+			name: "without range",
+			want: []WebhookNotification{
+				{Origin: "example.com/log", Size: 3, Checkpoint: string(webhookCheckpoint(3))},
+				{Origin: "example.com/log", Size: 10, Checkpoint: string(webhookCheckpoint(10))},
+			},
+		}, {
+			name:         "with range",
+			includeRange: true,
+			want: []WebhookNotification{
+				{Origin: "example.com/log", Size: 3, Checkpoint: string(webhookCheckpoint(3)), Range: &WebhookRange{From: 0, To: 3}},
+				{Origin: "example.com/log", Size: 10, Checkpoint: string(webhookCheckpoint(10)), Range: &WebhookRange{From: 3, To: 10}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			secret := []byte("secret")
+			// Both webhooks should be sent every notification.
+			var rs []*webhookReceiver
+			var urls []string
+			for range 2 {
+				r := &webhookReceiver{t: t, secret: secret, received: make(chan WebhookNotification, 1)}
+				srv := httptest.NewServer(r)
+				defer srv.Close()
+				rs, urls = append(rs, r), append(urls, srv.URL)
+			}
+
+			n := newWebhookNotifier(WebhookOptions{URLs: urls, Secret: secret, IncludeRange: test.includeRange})
+			var prev uint64
+			for _, w := range test.want {
+				n.publish(ctx, prev, w.Size, webhookCheckpoint(w.Size))
+				for i, r := range rs {
+					if d := cmp.Diff(w, waitFor(t, r.received)); d != "" {
+						t.Errorf("webhook %d: diff (-want +got):\n%s", i, d)
+					}
+				}
+				prev = w.Size
+			}
+		})
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name         string
+		statuses     []int
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "delivered",
+			wantRequests: 1,
+		}, {
+			name:         "retried",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			wantRequests: 3,
+		}, {
+			name:         "gives up",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable},
+			wantRequests: 3,
+			wantErr:      true,
+		}, {
+			name:         "not retried",
+			statuses:     []int{http.StatusBadRequest},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := &webhookReceiver{t: t, statuses: test.statuses, received: make(chan WebhookNotification, 1)}
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			n := newWebhookNotifier(WebhookOptions{MaxAttempts: 3})
+			n.minDelay = time.Millisecond
+			err := n.send(ctx, srv.URL, WebhookNotification{Size: 1})
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("send: %v, want error %t", err, test.wantErr)
+			}
+			if r.requests != test.wantRequests {
+				t.Errorf("got %d requests, want %d", r.requests, test.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWebhookMerging(t *testing.T) {
+	h := &webhook{ready: make(chan struct{}, 1)}
+	h.queue(WebhookNotification{Size: 3, Range: &WebhookRange{From: 0, To: 3}})
+	h.queue(WebhookNotification{Size: 10, Range: &WebhookRange{From: 3, To: 10}})
+	want := &WebhookNotification{Size: 10, Range: &WebhookRange{From: 0, To: 10}}
+	if d := cmp.Diff(want, h.pending); d != "" {
+		t.Errorf("pending: diff (-want +got):\n%s", d)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	secret, body, ts := []byte("secret"), []byte(`{"size":1}`), "1700000000"
+	sig := "sha256=" + hex.EncodeToString(webhookMAC(secret, ts, body))
+	for _, test := range []struct {
+		name      string
+		secret    []byte
+		timestamp string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{name: "valid", secret: secret, timestamp: ts, body: body, signature: sig},
+		{name: "wrong secret", secret: []byte("other"), timestamp: ts, body: body, signature: sig, wantErr: true},
+		{name: "wrong timestamp", secret: secret, timestamp: "1700000001", body: body, signature: sig, wantErr: true},
+		{name: "wrong body", secret: secret, timestamp: ts, body: []byte(`{"size":2}`), signature: sig, wantErr: true},
+		{name: "unknown algorithm", secret: secret, timestamp: ts, body: body, signature: "sha1=00", wantErr: true},
+		{name: "malformed", secret: secret, timestamp: ts, body: body, signature: "sha256=zz", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(test.secret, test.timestamp, test.body, test.signature)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("VerifyWebhookSignature: %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
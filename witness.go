@@ -23,6 +23,7 @@ import (
 
 	"maps"
 
+	"github.com/transparency-dev/tessera/witness/policy"
 	"golang.org/x/mod/sumdb/note"
 )
 
@@ -156,3 +157,37 @@ func (wg WitnessGroup) Endpoints() map[string]note.Verifier {
 	}
 	return endpoints
 }
+
+// NewWitnessGroupFromPolicy returns a WitnessGroup which implements the quorum of the provided witness policy,
+// for use with WithWitnesses.
+//
+// Every witness which the quorum refers to must have a URL, since it will be asked to cosign checkpoints.
+func NewWitnessGroupFromPolicy(p *policy.Policy) (WitnessGroup, error) {
+	c, err := policyComponentFromNode(p.Quorum)
+	if err != nil {
+		return WitnessGroup{}, err
+	}
+	if g, ok := c.(WitnessGroup); ok {
+		return g, nil
+	}
+	return NewWitnessGroup(1, c), nil
+}
+
+// policyComponentFromNode returns the policyComponent equivalent to n.
+func policyComponentFromNode(n policy.Node) (policyComponent, error) {
+	if w := n.Witness; w != nil {
+		if w.URL == nil {
+			return nil, fmt.Errorf("witness %q has no URL", w.Name)
+		}
+		return NewWitness(w.VKey, w.URL)
+	}
+	g := WitnessGroup{N: n.N}
+	for _, c := range n.Children {
+		pc, err := policyComponentFromNode(c)
+		if err != nil {
+			return nil, err
+		}
+		g.Components = append(g.Components, pc)
+	}
+	return g, nil
+}
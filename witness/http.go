@@ -0,0 +1,115 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxRequestSize is the largest add-checkpoint request body which will be accepted.
+	maxRequestSize = 1 << 16
+	// maxProofLines is the largest number of consistency proof lines permitted by the spec.
+	maxProofLines = 63
+)
+
+// Handler returns an http.Handler which serves the tlog-witness add-checkpoint endpoint at /add-checkpoint.
+func (w *Witness) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /add-checkpoint", w.handleAddCheckpoint)
+	return mux
+}
+
+func (w *Witness) handleAddCheckpoint(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	oldSize, consistency, cp, err := parseAddCheckpoint(body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sigs, err := w.AddCheckpoint(r.Context(), oldSize, consistency, cp)
+	if err != nil {
+		var conflict ConflictError
+		switch {
+		case errors.As(err, &conflict):
+			rw.Header().Set("Content-Type", "text/x.tlog.size")
+			rw.WriteHeader(http.StatusConflict)
+			_, _ = fmt.Fprintf(rw, "%d\n", conflict.Size)
+		case errors.Is(err, ErrRootMismatch):
+			http.Error(rw, err.Error(), http.StatusConflict)
+		case errors.Is(err, ErrUnknownLog):
+			http.Error(rw, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrInvalidSignature):
+			http.Error(rw, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrMalformed):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrInvalidProof):
+			http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			klog.Warningf("AddCheckpoint: %v", err)
+			http.Error(rw, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	_, _ = rw.Write(sigs)
+}
+
+// parseAddCheckpoint parses an add-checkpoint request body into the old size, the consistency proof, and the
+// checkpoint.
+func parseAddCheckpoint(body []byte) (uint64, [][]byte, []byte, error) {
+	line, rest, found := bytes.Cut(body, []byte("\n"))
+	if !found {
+		return 0, nil, nil, errors.New("missing old size line")
+	}
+	sizeStr, ok := bytes.CutPrefix(line, []byte("old "))
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("invalid old size line %q", line)
+	}
+	oldSize, err := strconv.ParseUint(string(sizeStr), 10, 64)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("invalid old size %q: %v", sizeStr, err)
+	}
+	var consistency [][]byte
+	for {
+		line, rest, found = bytes.Cut(rest, []byte("\n"))
+		if !found {
+			return 0, nil, nil, errors.New("missing empty line before checkpoint")
+		}
+		if len(line) == 0 {
+			break
+		}
+		if len(consistency) == maxProofLines {
+			return 0, nil, nil, fmt.Errorf("consistency proof has more than %d lines", maxProofLines)
+		}
+		h, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("invalid consistency proof line %q: %v", line, err)
+		}
+		consistency = append(consistency, h)
+	}
+	return oldSize, consistency, rest, nil
+}
@@ -0,0 +1,401 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy provides a small language for describing which witnesses must cosign a log's checkpoints.
+//
+// A policy is made up of lines, each of which is blank, a comment starting with '#', or one of:
+//
+//	witness <name> <vkey> [<url>]
+//	group <name> <witness>...
+//	quorum <expression>
+//
+// Witnesses are identified by their note verifier key, and the URL is where they can be reached using the
+// https://c2sp.org/tlog-witness protocol. Groups name a set of witnesses, which the quorum expression can then
+// require a number of cosignatures from. Exactly one quorum line must be present, and must follow the definitions
+// it refers to. For example:
+//
+//	witness a1 a1.example.com+1c2b4d1e+AaVrE0Ygw9B0mleAUbjaOMpY6Tf8V2qDRLmZdaMFA1yr https://a1.example.com/
+//	witness a2 ...
+//	witness a3 ...
+//	witness b1 ...
+//	group armored a1 a2 a3
+//	quorum any 2 of armored AND b1
+//
+// An expression is made up of terms joined with AND and OR, where AND binds more tightly than OR and
+// parentheses may be used for grouping. A term is one of:
+//   - the name of a witness, which is satisfied by its cosignature.
+//   - the name of a group, which is satisfied by cosignatures from all of its witnesses.
+//   - "<N> of <group>" or "any <N> of <group>", which are satisfied by cosignatures from at least N of the group's witnesses.
+//   - "any of <group>" or "all of <group>", which are satisfied by one or all of them, respectively.
+//   - "none", which is always satisfied.
+//
+// Keywords are case-insensitive.
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// Witness is a witness defined by a policy.
+type Witness struct {
+	// Name is the name the policy refers to the witness by.
+	Name string
+	// VKey is the witness's note verifier key.
+	VKey string
+	// Key is the verifier for the witness's cosignatures.
+	Key note.Verifier
+	// URL is the witness's base URL, or nil if the policy doesn't say where it can be reached.
+	URL *url.URL
+}
+
+// Node is an element of a policy's quorum expression.
+//
+// A node is either a single witness, if Witness is set, or requires at least N of its Children to be satisfied.
+type Node struct {
+	Witness  *Witness
+	N        int
+	Children []Node
+}
+
+// Satisfied returns true if the node is satisfied by cosignatures from the witnesses in signed.
+func (n Node) Satisfied(signed func(w *Witness) bool) bool {
+	if n.Witness != nil {
+		return signed(n.Witness)
+	}
+	satisfaction := 0
+	for _, c := range n.Children {
+		if satisfaction >= n.N {
+			break
+		}
+		if c.Satisfied(signed) {
+			satisfaction++
+		}
+	}
+	return satisfaction >= n.N
+}
+
+// Policy is a parsed witness policy.
+type Policy struct {
+	// Witnesses are all the witnesses defined by the policy, in the order they were defined.
+	Witnesses []*Witness
+	// Quorum describes which witnesses must cosign a checkpoint for the policy to be satisfied.
+	Quorum Node
+}
+
+// Parse parses the policy in text.
+func Parse(text []byte) (*Policy, error) {
+	p := &policyParser{
+		policy:    &Policy{},
+		witnesses: make(map[string]*Witness),
+		groups:    make(map[string][]*Witness),
+	}
+	quorum := false
+	s := bufio.NewScanner(bytes.NewReader(text))
+	for l := 1; s.Scan(); l++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var err error
+		switch fields[0] {
+		case "witness":
+			err = p.witness(fields[1:])
+		case "group":
+			err = p.group(fields[1:])
+		case "quorum":
+			if quorum {
+				err = errors.New("quorum already defined")
+				break
+			}
+			quorum = true
+			p.policy.Quorum, err = p.quorum(fields[1:])
+		default:
+			err = fmt.Errorf("unknown directive %q", fields[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", l, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !quorum {
+		return nil, errors.New("no quorum defined")
+	}
+	return p.policy, nil
+}
+
+// Satisfied returns true if the checkpoint is cosigned by enough of the policy's witnesses to satisfy its quorum.
+//
+// Signatures from keys which aren't part of the policy are ignored, as is the checkpoint's body, so it's up to
+// the caller to check that the checkpoint is otherwise valid.
+func (p *Policy) Satisfied(cp []byte) bool {
+	n, err := note.Open(cp, p.verifiers())
+	if err != nil {
+		if _, ok := err.(*note.UnverifiedNoteError); !ok {
+			return false
+		}
+		n = &note.Note{}
+	}
+	return p.Quorum.Satisfied(func(w *Witness) bool {
+		return slices.ContainsFunc(n.Sigs, func(s note.Signature) bool {
+			return s.Name == w.Key.Name() && s.Hash == w.Key.KeyHash()
+		})
+	})
+}
+
+// verifiers returns the verifiers for all of the policy's witnesses.
+func (p *Policy) verifiers() note.Verifiers {
+	vs := make([]note.Verifier, 0, len(p.Witnesses))
+	for _, w := range p.Witnesses {
+		vs = append(vs, w.Key)
+	}
+	return note.VerifierList(vs...)
+}
+
+// policyParser holds the definitions parsed so far.
+type policyParser struct {
+	policy    *Policy
+	witnesses map[string]*Witness
+	groups    map[string][]*Witness
+}
+
+// keywords may not be used as the names of witnesses or groups.
+var keywords = []string{"and", "or", "of", "any", "all", "none"}
+
+func (p *policyParser) checkName(name string) error {
+	if slices.Contains(keywords, strings.ToLower(name)) || strings.ContainsAny(name, "()") {
+		return fmt.Errorf("%q can't be used as a name", name)
+	}
+	if _, ok := p.witnesses[name]; ok {
+		return fmt.Errorf("%q is already defined", name)
+	}
+	if _, ok := p.groups[name]; ok {
+		return fmt.Errorf("%q is already defined", name)
+	}
+	return nil
+}
+
+func (p *policyParser) witness(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("want witness <name> <vkey> [<url>]")
+	}
+	if err := p.checkName(args[0]); err != nil {
+		return err
+	}
+	v, err := note.NewVerifier(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid vkey for witness %q: %v", args[0], err)
+	}
+	w := &Witness{Name: args[0], VKey: args[1], Key: v}
+	if len(args) == 3 {
+		u, err := url.Parse(args[2])
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid URL %q for witness %q", args[2], args[0])
+		}
+		w.URL = u
+	}
+	for _, o := range p.policy.Witnesses {
+		if o.Key.Name() == v.Name() && o.Key.KeyHash() == v.KeyHash() {
+			return fmt.Errorf("witness %q has the same key as %q", args[0], o.Name)
+		}
+	}
+	p.witnesses[w.Name] = w
+	p.policy.Witnesses = append(p.policy.Witnesses, w)
+	return nil
+}
+
+func (p *policyParser) group(args []string) error {
+	if len(args) < 2 {
+		return errors.New("want group <name> <witness>...")
+	}
+	if err := p.checkName(args[0]); err != nil {
+		return err
+	}
+	members := make([]*Witness, 0, len(args)-1)
+	for _, m := range args[1:] {
+		w, ok := p.witnesses[m]
+		if !ok {
+			return fmt.Errorf("group %q: %q is not a witness", args[0], m)
+		}
+		if slices.Contains(members, w) {
+			return fmt.Errorf("group %q: %q is listed more than once", args[0], m)
+		}
+		members = append(members, w)
+	}
+	p.groups[args[0]] = members
+	return nil
+}
+
+func (p *policyParser) quorum(args []string) (Node, error) {
+	// Parentheses needn't be separated from the tokens around them.
+	var toks []string
+	for _, a := range args {
+		a = strings.ReplaceAll(strings.ReplaceAll(a, "(", " ( "), ")", " ) ")
+		toks = append(toks, strings.Fields(a)...)
+	}
+	if len(toks) == 0 {
+		return Node{}, errors.New("want quorum <expression>")
+	}
+	e := &exprParser{p: p, toks: toks}
+	n, err := e.or()
+	if err != nil {
+		return Node{}, err
+	}
+	if e.pos < len(e.toks) {
+		return Node{}, fmt.Errorf("unexpected %q", e.toks[e.pos])
+	}
+	return n, nil
+}
+
+// exprParser is a recursive descent parser for quorum expressions.
+type exprParser struct {
+	p    *policyParser
+	toks []string
+	pos  int
+}
+
+// peek returns the next token, lowercased if it's a keyword, or the empty string if there are none left.
+func (e *exprParser) peek() string {
+	if e.pos >= len(e.toks) {
+		return ""
+	}
+	if t := strings.ToLower(e.toks[e.pos]); slices.Contains(keywords, t) {
+		return t
+	}
+	return e.toks[e.pos]
+}
+
+func (e *exprParser) next() string {
+	t := e.peek()
+	e.pos++
+	return t
+}
+
+// or parses: and { OR and }
+func (e *exprParser) or() (Node, error) {
+	return e.list("or", e.and, 1)
+}
+
+// and parses: term { AND term }
+func (e *exprParser) and() (Node, error) {
+	return e.list("and", e.term, 0)
+}
+
+// list parses one or more sub-expressions separated by op, returning a node which requires n of them to be
+// satisfied, or all of them if n is zero.
+func (e *exprParser) list(op string, sub func() (Node, error), n int) (Node, error) {
+	c, err := sub()
+	if err != nil {
+		return Node{}, err
+	}
+	children := []Node{c}
+	for e.peek() == op {
+		e.next()
+		c, err := sub()
+		if err != nil {
+			return Node{}, err
+		}
+		children = append(children, c)
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	if n == 0 {
+		n = len(children)
+	}
+	return Node{N: n, Children: children}, nil
+}
+
+// term parses a single term, as described in the package documentation.
+func (e *exprParser) term() (Node, error) {
+	switch t := e.next(); t {
+	case "":
+		return Node{}, errors.New("unexpected end of expression")
+	case "(":
+		n, err := e.or()
+		if err != nil {
+			return Node{}, err
+		}
+		if e.next() != ")" {
+			return Node{}, errors.New("missing )")
+		}
+		return n, nil
+	case "none":
+		return Node{}, nil
+	case "all":
+		return e.threshold(-1)
+	case "any":
+		if e.peek() == "of" {
+			return e.threshold(1)
+		}
+		n, err := strconv.Atoi(e.next())
+		if err != nil || n < 1 {
+			return Node{}, errors.New("want any <N> of <group> or any of <group>")
+		}
+		return e.threshold(n)
+	default:
+		if n, err := strconv.Atoi(t); err == nil {
+			if n < 1 {
+				return Node{}, fmt.Errorf("threshold %d must be at least 1", n)
+			}
+			return e.threshold(n)
+		}
+		if w, ok := e.p.witnesses[t]; ok {
+			return Node{Witness: w}, nil
+		}
+		if g, ok := e.p.groups[t]; ok {
+			return groupNode(len(g), g), nil
+		}
+		return Node{}, fmt.Errorf("%q is not a witness or group", t)
+	}
+}
+
+// threshold parses the "of <group>" following a threshold of n, which is -1 to require all of the group.
+func (e *exprParser) threshold(n int) (Node, error) {
+	if e.next() != "of" {
+		return Node{}, errors.New("want of <group> after threshold")
+	}
+	name := e.next()
+	g, ok := e.p.groups[name]
+	if !ok {
+		return Node{}, fmt.Errorf("%q is not a group", name)
+	}
+	if n == -1 {
+		n = len(g)
+	}
+	if n > len(g) {
+		return Node{}, fmt.Errorf("threshold %d is larger than group %q, which has %d witnesses", n, name, len(g))
+	}
+	return groupNode(n, g), nil
+}
+
+// groupNode returns a node which requires n of the witnesses in g.
+func groupNode(n int, g []*Witness) Node {
+	children := make([]Node, 0, len(g))
+	for _, w := range g {
+		children = append(children, Node{Witness: w})
+	}
+	return Node{N: n, Children: children}
+}
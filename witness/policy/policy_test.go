@@ -0,0 +1,175 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/note"
+)
+
+// testWitnesses returns signers for the named witnesses, and witness lines defining them.
+func testWitnesses(t *testing.T, names ...string) (map[string]note.Signer, string) {
+	t.Helper()
+	signers := make(map[string]note.Signer)
+	defs := &strings.Builder{}
+	for _, n := range names {
+		sk, vk, err := note.GenerateKey(nil, n+".example.com")
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		s, err := note.NewSigner(sk)
+		if err != nil {
+			t.Fatalf("NewSigner: %v", err)
+		}
+		signers[n] = s
+		fmt.Fprintf(defs, "witness %s %s https://%s.example.com/\n", n, vk, n)
+	}
+	return signers, defs.String()
+}
+
+func TestParse(t *testing.T) {
+	_, defs := testWitnesses(t, "a1", "a2", "b1")
+	for _, test := range []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{
+			name:   "single witness",
+			policy: defs + "quorum a1",
+		}, {
+			name:   "groups",
+			policy: defs + "# A comment.\n\ngroup a a1 a2\ngroup b b1\nquorum any 1 of a AND all of b",
+		}, {
+			name:   "parentheses",
+			policy: defs + "group a a1 a2\nquorum (2 of a) OR (a1 AND b1)",
+		}, {
+			name:   "keywords are case insensitive",
+			policy: defs + "group a a1 a2\nquorum ANY OF a and b1",
+		}, {
+			name:   "none",
+			policy: "quorum none",
+		}, {
+			name:    "no quorum",
+			policy:  defs,
+			wantErr: true,
+		}, {
+			name:    "two quorums",
+			policy:  defs + "quorum a1\nquorum a2",
+			wantErr: true,
+		}, {
+			name:    "unknown directive",
+			policy:  defs + "log foo\nquorum a1",
+			wantErr: true,
+		}, {
+			name:    "bad vkey",
+			policy:  "witness a1 notakey\nquorum a1",
+			wantErr: true,
+		}, {
+			name:    "bad url",
+			policy:  strings.Replace(defs, "https://a1.example.com/", "a1", 1) + "quorum a1",
+			wantErr: true,
+		}, {
+			name:    "duplicate name",
+			policy:  defs + "group a1 a2\nquorum a1",
+			wantErr: true,
+		}, {
+			name:    "keyword as name",
+			policy:  defs + "group any a1\nquorum a1",
+			wantErr: true,
+		}, {
+			name:    "group of unknown witness",
+			policy:  defs + "group a a1 c1\nquorum a",
+			wantErr: true,
+		}, {
+			name:    "undefined name",
+			policy:  defs + "quorum c1",
+			wantErr: true,
+		}, {
+			name:    "threshold too large",
+			policy:  defs + "group a a1 a2\nquorum 3 of a",
+			wantErr: true,
+		}, {
+			name:    "threshold of witness",
+			policy:  defs + "quorum 1 of a1",
+			wantErr: true,
+		}, {
+			name:    "unbalanced parentheses",
+			policy:  defs + "quorum (a1 AND a2",
+			wantErr: true,
+		}, {
+			name:    "trailing tokens",
+			policy:  defs + "quorum a1 a2",
+			wantErr: true,
+		}, {
+			name:    "dangling operator",
+			policy:  defs + "quorum a1 AND",
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.policy))
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("Parse: got err %v, want err %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSatisfied(t *testing.T) {
+	signers, defs := testWitnesses(t, "a1", "a2", "a3", "b1", "b2", "other")
+	p, err := Parse([]byte(defs + "group a a1 a2 a3\ngroup b b1 b2\nquorum any 2 of a AND 1 of b OR (all of a)"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	logSK, _, err := note.GenerateKey(nil, "log.example.com")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	logSigner, err := note.NewSigner(logSK)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		cosigned []string
+		want     bool
+	}{
+		{name: "uncosigned"},
+		{name: "2 of a without b", cosigned: []string{"a1", "a2"}},
+		{name: "2 of a and 1 of b", cosigned: []string{"a1", "a3", "b2"}, want: true},
+		{name: "all of a", cosigned: []string{"a1", "a2", "a3"}, want: true},
+		{name: "b only", cosigned: []string{"b1", "b2"}},
+		{name: "witness outside quorum", cosigned: []string{"a1", "other", "b1"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := []note.Signer{logSigner}
+			for _, c := range test.cosigned {
+				s = append(s, signers[c])
+			}
+			cp, err := note.Sign(&note.Note{Text: "log.example.com\n1\nAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n"}, s...)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			if got := p.Satisfied(cp); got != test.want {
+				t.Errorf("Satisfied = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a Store which keeps the state for each log in a file in a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore which keeps its files in dir, creating it if necessary.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %q: %v", dir, err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the path of the file holding the state of the log with the given origin.
+func (s *FileStore) path(origin string) string {
+	h := sha256.Sum256([]byte(origin))
+	return filepath.Join(s.dir, hex.EncodeToString(h[:]))
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, origin string) ([]byte, error) {
+	b, err := os.ReadFile(s.path(origin))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// Set implements Store.
+//
+// The file is replaced atomically, so a failure will not leave the state partially written.
+func (s *FileStore) Set(_ context.Context, origin string, body []byte) error {
+	p := s.path(origin)
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer func() {
+		_ = os.Remove(tmp)
+	}()
+	if _, err := f.Write(body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
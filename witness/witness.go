@@ -0,0 +1,197 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package witness provides an implementation of a witness which follows the
+// https://c2sp.org/tlog-witness protocol.
+//
+// Logs submit new checkpoints along with a consistency proof from the last checkpoint
+// the witness cosigned for them. The witness verifies the checkpoint's signature and the
+// proof, durably records the new checkpoint, and returns its cosignature.
+package witness
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/transparency-dev/formats/log"
+	"github.com/transparency-dev/merkle"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"golang.org/x/mod/sumdb/note"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// ErrMalformed is returned when a request cannot be parsed, or is not self-consistent.
+	ErrMalformed = errors.New("malformed request")
+	// ErrUnknownLog is returned when a checkpoint is submitted for a log which the witness doesn't know about.
+	ErrUnknownLog = errors.New("unknown log")
+	// ErrInvalidSignature is returned when a checkpoint is not signed by the log's key.
+	ErrInvalidSignature = errors.New("checkpoint is not signed by the log")
+	// ErrInvalidProof is returned when the consistency proof provided with a checkpoint doesn't verify.
+	ErrInvalidProof = errors.New("invalid consistency proof")
+	// ErrRootMismatch is returned when a checkpoint has the same size as the latest cosigned checkpoint
+	// for the log, but a different root hash.
+	ErrRootMismatch = errors.New("root hash does not match latest cosigned checkpoint of the same size")
+)
+
+// ConflictError is returned when the old size provided with a checkpoint does not match the size of the
+// latest checkpoint cosigned for the log.
+type ConflictError struct {
+	// Size is the size of the latest checkpoint cosigned for the log.
+	Size uint64
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("old size does not match latest cosigned size %d", e.Size)
+}
+
+// LogConfig describes a log which the witness will cosign checkpoints for.
+type LogConfig struct {
+	// Origin is the origin line of the log's checkpoints.
+	Origin string
+	// Verifier verifies the log's signature on its checkpoints.
+	Verifier note.Verifier
+	// Hasher is used to verify consistency proofs. If nil, the RFC 6962 SHA-256 hasher is used.
+	Hasher merkle.LogHasher
+}
+
+// Store durably records the latest checkpoint cosigned for each log.
+//
+// A Store must not be shared between multiple Witness instances.
+type Store interface {
+	// Get returns the body of the latest checkpoint cosigned for the log with the given origin, or nil if
+	// no checkpoint has been cosigned for it.
+	Get(ctx context.Context, origin string) ([]byte, error)
+	// Set durably records the body of the latest checkpoint cosigned for the log with the given origin.
+	Set(ctx context.Context, origin string, body []byte) error
+}
+
+// trackedLog is a log known to the witness.
+type trackedLog struct {
+	LogConfig
+	// mu serialises updates to the log's state.
+	mu sync.Mutex
+}
+
+// Witness verifies and cosigns checkpoints for a set of logs.
+type Witness struct {
+	signer note.Signer
+	store  Store
+	logs   map[string]*trackedLog
+}
+
+// New returns a Witness which cosigns checkpoints for the provided logs using signer, and records its
+// state in store.
+//
+// The tlog-witness protocol requires that the signer produces timestamped cosignature/v1 signatures, e.g. as
+// created by github.com/transparency-dev/formats/note.NewSignerForCosignatureV1.
+func New(signer note.Signer, store Store, logs ...LogConfig) (*Witness, error) {
+	w := &Witness{
+		signer: signer,
+		store:  store,
+		logs:   make(map[string]*trackedLog, len(logs)),
+	}
+	for _, l := range logs {
+		if l.Origin == "" || l.Verifier == nil {
+			return nil, fmt.Errorf("log %q must have an origin and a verifier", l.Origin)
+		}
+		if _, ok := w.logs[l.Origin]; ok {
+			return nil, fmt.Errorf("duplicate log %q", l.Origin)
+		}
+		if l.Hasher == nil {
+			l.Hasher = rfc6962.DefaultHasher
+		}
+		w.logs[l.Origin] = &trackedLog{LogConfig: l}
+	}
+	return w, nil
+}
+
+// AddCheckpoint verifies that the provided checkpoint is signed by a known log and is consistent, according
+// to the provided proof, with the latest checkpoint of size oldSize cosigned for that log.
+//
+// If so, the checkpoint is recorded as the latest cosigned checkpoint for the log, and the witness's
+// cosignature lines are returned. Otherwise, an error corresponding to one of the failure cases of the
+// tlog-witness spec is returned.
+func (w *Witness) AddCheckpoint(ctx context.Context, oldSize uint64, consistency [][]byte, cpRaw []byte) ([]byte, error) {
+	origin, _, found := bytes.Cut(cpRaw, []byte("\n"))
+	if !found {
+		return nil, fmt.Errorf("%w: invalid checkpoint", ErrMalformed)
+	}
+	l, ok := w.logs[string(origin)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLog, origin)
+	}
+	n, err := note.Open(cpRaw, note.VerifierList(l.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	cp := &log.Checkpoint{}
+	if _, err := cp.Unmarshal([]byte(n.Text)); err != nil {
+		return nil, fmt.Errorf("%w: invalid checkpoint: %v", ErrMalformed, err)
+	}
+	if cp.Origin != l.Origin {
+		return nil, fmt.Errorf("%w: checkpoint origin %q does not match %q", ErrMalformed, cp.Origin, l.Origin)
+	}
+	if oldSize > cp.Size {
+		return nil, fmt.Errorf("%w: old size %d is larger than checkpoint size %d", ErrMalformed, oldSize, cp.Size)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	latest := &log.Checkpoint{}
+	latestRaw, err := w.store.Get(ctx, l.Origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state for %q: %v", l.Origin, err)
+	}
+	if latestRaw != nil {
+		if _, err := latest.Unmarshal(latestRaw); err != nil {
+			return nil, fmt.Errorf("failed to parse stored checkpoint for %q: %v", l.Origin, err)
+		}
+	}
+	if oldSize != latest.Size {
+		return nil, ConflictError{Size: latest.Size}
+	}
+	if oldSize == cp.Size {
+		if latestRaw != nil && !bytes.Equal(latest.Hash, cp.Hash) {
+			return nil, ErrRootMismatch
+		}
+		if len(consistency) != 0 {
+			return nil, fmt.Errorf("%w: unexpected consistency proof for checkpoint of unchanged size", ErrInvalidProof)
+		}
+	} else {
+		if oldSize > 0 {
+			if err := proof.VerifyConsistency(l.Hasher, oldSize, cp.Size, consistency, latest.Hash, cp.Hash); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+			}
+		} else if len(consistency) != 0 {
+			return nil, fmt.Errorf("%w: unexpected consistency proof from empty tree", ErrInvalidProof)
+		}
+		if err := w.store.Set(ctx, l.Origin, []byte(n.Text)); err != nil {
+			return nil, fmt.Errorf("failed to store state for %q: %v", l.Origin, err)
+		}
+		klog.V(1).Infof("Witnessed %q at size %d", l.Origin, cp.Size)
+	}
+
+	signed, err := note.Sign(&note.Note{Text: n.Text}, w.signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cosign checkpoint: %v", err)
+	}
+	// The signed note is the text, a blank line, and then the signature lines.
+	return signed[len(n.Text)+1:], nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 The Tessera authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/transparency-dev/formats/log"
+	f_note "github.com/transparency-dev/formats/note"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+	"golang.org/x/mod/sumdb/note"
+)
+
+const testOrigin = "example.com/log"
+
+func mustSigner(t *testing.T, name string) (note.Signer, note.Verifier) {
+	t.Helper()
+	sk, vk, err := note.GenerateKey(nil, name)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s, err := note.NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	v, err := note.NewVerifier(vk)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return s, v
+}
+
+func checkpoint(t *testing.T, s note.Signer, origin string, size uint64, hash []byte) []byte {
+	t.Helper()
+	cp, err := note.Sign(&note.Note{Text: string(log.Checkpoint{Origin: origin, Size: size, Hash: hash}.Marshal())}, s)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return cp
+}
+
+func TestHandler(t *testing.T) {
+	logSigner, logVerifier := mustSigner(t, testOrigin)
+	otherSigner, _ := mustSigner(t, testOrigin)
+	unknownSigner, _ := mustSigner(t, "example.com/unknown")
+	sk, _, err := note.GenerateKey(nil, "example.com/witness")
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	cosigner, err := f_note.NewSignerForCosignatureV1(sk)
+	if err != nil {
+		t.Fatalf("NewSignerForCosignatureV1: %v", err)
+	}
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	w, err := New(cosigner, store, LogConfig{Origin: testOrigin, Verifier: logVerifier})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv := httptest.NewServer(w.Handler())
+	defer srv.Close()
+
+	tree := testonly.New(rfc6962.DefaultHasher)
+	for i := range 20 {
+		tree.AppendData(fmt.Appendf(nil, "leaf %d", i))
+	}
+	proof := func(from, to uint64) [][]byte {
+		p, err := tree.ConsistencyProof(from, to)
+		if err != nil {
+			t.Fatalf("ConsistencyProof: %v", err)
+		}
+		return p
+	}
+
+	// The steps are run in order against the same witness.
+	for _, test := range []struct {
+		name       string
+		old        uint64
+		proof      [][]byte
+		cp         []byte
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "malformed",
+			body:       "old x\n\n",
+			wantStatus: http.StatusBadRequest,
+		}, {
+			name:       "unknown origin",
+			cp:         checkpoint(t, unknownSigner, "example.com/unknown", 5, tree.HashAt(5)),
+			wantStatus: http.StatusNotFound,
+		}, {
+			name:       "bad signature",
+			cp:         checkpoint(t, otherSigner, testOrigin, 5, tree.HashAt(5)),
+			wantStatus: http.StatusForbidden,
+		}, {
+			name:       "proof from empty tree",
+			proof:      proof(1, 5),
+			cp:         checkpoint(t, logSigner, testOrigin, 5, tree.HashAt(5)),
+			wantStatus: http.StatusUnprocessableEntity,
+		}, {
+			name:       "first checkpoint",
+			cp:         checkpoint(t, logSigner, testOrigin, 5, tree.HashAt(5)),
+			wantStatus: http.StatusOK,
+		}, {
+			name:       "stale old size",
+			cp:         checkpoint(t, logSigner, testOrigin, 10, tree.HashAt(10)),
+			wantStatus: http.StatusConflict,
+			wantBody:   "5\n",
+		}, {
+			name:       "old size too large",
+			old:        11,
+			cp:         checkpoint(t, logSigner, testOrigin, 10, tree.HashAt(10)),
+			wantStatus: http.StatusBadRequest,
+		}, {
+			name:       "bad proof",
+			old:        5,
+			proof:      proof(4, 10),
+			cp:         checkpoint(t, logSigner, testOrigin, 10, tree.HashAt(10)),
+			wantStatus: http.StatusUnprocessableEntity,
+		}, {
+			name:       "consistent",
+			old:        5,
+			proof:      proof(5, 10),
+			cp:         checkpoint(t, logSigner, testOrigin, 10, tree.HashAt(10)),
+			wantStatus: http.StatusOK,
+		}, {
+			name:       "same size",
+			old:        10,
+			cp:         checkpoint(t, logSigner, testOrigin, 10, tree.HashAt(10)),
+			wantStatus: http.StatusOK,
+		}, {
+			name:       "same size different root",
+			old:        10,
+			cp:         checkpoint(t, logSigner, testOrigin, 10, tree.HashAt(9)),
+			wantStatus: http.StatusConflict,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			body := test.body
+			if body == "" {
+				body = fmt.Sprintf("old %d\n", test.old)
+				for _, p := range test.proof {
+					body += base64.StdEncoding.EncodeToString(p) + "\n"
+				}
+				body += "\n" + string(test.cp)
+			}
+			resp, err := http.Post(srv.URL+"/add-checkpoint", "text/plain", strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("Post: %v", err)
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			rb, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("got status %d (%q), want %d", resp.StatusCode, rb, test.wantStatus)
+			}
+			if test.wantBody != "" && string(rb) != test.wantBody {
+				t.Errorf("got body %q, want %q", rb, test.wantBody)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+			n, err := note.Open(append(test.cp, rb...), note.VerifierList(logVerifier, cosigner.Verifier()))
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if len(n.Sigs) != 2 {
+				t.Fatalf("got %d verified signatures, want 2", len(n.Sigs))
+			}
+			if _, err := f_note.CoSigV1Timestamp(n.Sigs[1]); err != nil {
+				t.Errorf("CoSigV1Timestamp: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	if got, err := s.Get(ctx, testOrigin); err != nil || got != nil {
+		t.Fatalf("Get: got (%q, %v), want (nil, nil)", got, err)
+	}
+	for _, want := range []string{"one", "two"} {
+		if err := s.Set(ctx, testOrigin, []byte(want)); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		// A new store on the same directory should see the same state.
+		s, err = NewFileStore(dir)
+		if err != nil {
+			t.Fatalf("NewFileStore: %v", err)
+		}
+		if got, err := s.Get(ctx, testOrigin); err != nil || string(got) != want {
+			t.Fatalf("Get: got (%q, %v), want (%q, nil)", got, err, want)
+		}
+	}
+	if got, err := s.Get(ctx, "example.com/other"); err != nil || got != nil {
+		t.Errorf("Get other: got (%q, %v), want (nil, nil)", got, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, v := mustSigner(t, testOrigin)
+	for _, test := range []struct {
+		name    string
+		logs    []LogConfig
+		wantErr bool
+	}{
+		{name: "ok", logs: []LogConfig{{Origin: testOrigin, Verifier: v}}},
+		{name: "no verifier", logs: []LogConfig{{Origin: testOrigin}}, wantErr: true},
+		{name: "duplicate", logs: []LogConfig{{Origin: testOrigin, Verifier: v}, {Origin: testOrigin, Verifier: v}}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New(nil, nil, test.logs...); (err != nil) != test.wantErr {
+				t.Errorf("New: got err %v, want err %t", err, test.wantErr)
+			}
+		})
+	}
+}
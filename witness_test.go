@@ -1,11 +1,13 @@
 package tessera_test
 
 import (
+	"fmt"
 	"net/url"
 	"slices"
 	"testing"
 
 	"github.com/transparency-dev/tessera"
+	"github.com/transparency-dev/tessera/witness/policy"
 	"golang.org/x/mod/sumdb/note"
 )
 
@@ -208,3 +210,47 @@ func BenchmarkWitnessGroupSatisfaction(b *testing.B) {
 		}
 	}
 }
+
+func TestNewWitnessGroupFromPolicy(t *testing.T) {
+	p, err := policy.Parse([]byte(fmt.Sprintf(`
+witness w1 %s https://b1.example.com/
+witness w2 %s https://b1.example.com/
+witness w3 %s https://b2.example.com/
+group g w2 w3
+quorum w1 AND any 1 of g
+`, wit1_vkey, wit2_vkey, wit3_vkey)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	group, err := tessera.NewWitnessGroupFromPolicy(p)
+	if err != nil {
+		t.Fatalf("NewWitnessGroupFromPolicy: %v", err)
+	}
+	if got, want := len(group.Endpoints()), 3; got != want {
+		t.Errorf("got %d endpoints, want %d", got, want)
+	}
+	for _, signers := range [][]note.Signer{
+		{},
+		{wit1Sign},
+		{wit2Sign, wit3Sign},
+		{wit1Sign, wit3Sign},
+		{wit1Sign, wit2Sign, wit3Sign},
+	} {
+		cp, err := note.Sign(&note.Note{Text: "sign me\n"}, signers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := group.Satisfied(cp), p.Satisfied(cp); got != want {
+			t.Errorf("%d signers: group satisfied = %t, but policy satisfied = %t", len(signers), got, want)
+		}
+	}
+
+	// Witnesses which the appender must contact need URLs.
+	p, err = policy.Parse([]byte(fmt.Sprintf("witness w1 %s\nquorum w1\n", wit1_vkey)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := tessera.NewWitnessGroupFromPolicy(p); err == nil {
+		t.Error("NewWitnessGroupFromPolicy: got nil error for witness without URL")
+	}
+}